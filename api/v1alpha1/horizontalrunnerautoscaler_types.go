@@ -38,6 +38,38 @@ type HorizontalRunnerAutoscalerSpec struct {
 	// +optional
 	ScaleDownDelaySecondsAfterScaleUp *int `json:"scaleDownDelaySecondsAfterScaleOut,omitempty"`
 
+	// ScaleUpMaxRatePerMinute is the maximum number of replicas added to the scale target in a minute.
+	// It prevents e.g. a storm of webhook events from scaling the pool from 5 to 500 replicas at once and exhausting the cluster capacity.
+	// The webhook-based autoscaler stops adding capacity reservations beyond the rate, too.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ScaleUpMaxRatePerMinute *int `json:"scaleUpMaxRatePerMinute,omitempty"`
+
+	// ScaleDownMaxRatePerMinute is the maximum number of replicas removed from the scale target in a minute.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ScaleDownMaxRatePerMinute *int `json:"scaleDownMaxRatePerMinute,omitempty"`
+
+	// ScaleDownBehavior is the preset of how quickly the scale target is scaled down, one of Conservative, Balanced, and Aggressive.
+	// It sets the defaults of ScaleDownDelaySecondsAfterScaleUp, ScaleDownMaxRatePerMinute, and the ScaleDownThreshold and ScaleDownFactor
+	// of PercentageRunnersBusy, and whether the scale down waits for the busy runners to become idle.
+	// The fields set explicitly take precedence over the preset.
+	// +optional
+	// +kubebuilder:validation:Enum=Conservative;Balanced;Aggressive
+	ScaleDownBehavior string `json:"scaleDownBehavior,omitempty"`
+
+	// SuspendScaleUpWhileUnschedulable keeps the desired replicas from growing while any runner pod of the scale target
+	// is Pending because the scheduler found no node for it, instead of piling up more Pending pods.
+	// Scaling down isn't affected.
+	// +optional
+	SuspendScaleUpWhileUnschedulable bool `json:"suspendScaleUpWhileUnschedulable,omitempty"`
+
+	// LimitWhileUnschedulable caps the desired replicas at the replicas the cluster can run once any runner pod of
+	// the scale target is found unschedulable, so that the Pending pods are removed rather than piling up.
+	// The cap is lifted after the duration, to retry scaling up in case the cluster has grown.
+	// +optional
+	LimitWhileUnschedulable *LimitWhileUnschedulableSpec `json:"limitWhileUnschedulable,omitempty"`
+
 	// Metrics is the collection of various metric targets to calculate desired number of runners
 	// +optional
 	Metrics []MetricSpec `json:"metrics,omitempty"`
@@ -60,18 +92,252 @@ type HorizontalRunnerAutoscalerSpec struct {
 	// The earlier a scheduled override is, the higher it is prioritized.
 	// +optional
 	ScheduledOverrides []ScheduledOverride `json:"scheduledOverrides,omitempty"`
+
+	// HostedRunnerFallback enables recommending GitHub-hosted runners while the desired replicas
+	// keep exceeding MaxReplicas, so that jobs don't wait long in the queue.
+	// +optional
+	HostedRunnerFallback *HostedRunnerFallbackSpec `json:"hostedRunnerFallback,omitempty"`
+
+	// DualRun helps migrating from the pull-based scaling with Metrics to the webhook-based scaling with ScaleUpTriggers.
+	// Both calculations run on every reconciliation but only the one named by DrivenBy drives the desired replicas,
+	// and the two are compared over the observation window to tell if it's safe to switch.
+	// +optional
+	DualRun *DualRunSpec `json:"dualRun,omitempty"`
+
+	// Placeholders makes the controller run low-priority placeholder pods shaped like the runner pods,
+	// sized by the short-term forecast of the demand seen by the webhook-based autoscaler,
+	// so that the cluster autoscaler starts provisioning nodes a few minutes before the runner pods arrive.
+	// +optional
+	Placeholders *PlaceholdersSpec `json:"placeholders,omitempty"`
+
+	// Predictive makes the autoscaler learn the recurring daily or weekly peaks of the demand, and pre-scale the scale target
+	// ahead of them, so that the runners are ready by the time e.g. the nightly builds or the working hours start.
+	// +optional
+	Predictive *PredictiveSpec `json:"predictive,omitempty"`
+
+	// CheckRunSummary makes the webhook-based autoscaler post a check run on the head commit of each workflow run
+	// whose jobs it scales for, summarizing how many runners have been provisioned for the jobs and when the rest are estimated to start.
+	// +optional
+	CheckRunSummary *CheckRunSummarySpec `json:"checkRunSummary,omitempty"`
+
+	// ExternalRunners are the runners registered to GitHub outside of ARC, like the ones on VMs being replaced by ARC,
+	// that are counted along with the runners of the scale target by the PercentageRunnersBusy metric.
+	// ARC only observes them, and never scales, unregisters, or deletes them.
+	// +optional
+	ExternalRunners *ExternalRunnersSpec `json:"externalRunners,omitempty"`
+
+	// CapacityShare is the priority and the weight of this HRA in the allocation of the cluster's runner capacity
+	// among all the HRAs, when the controller is configured with the maximum number of runners in the cluster.
+	// HRAs without it have the priority 0 and the weight 1.
+	// +optional
+	CapacityShare *CapacityShareSpec `json:"capacityShare,omitempty"`
+
+	// Paused keeps the replicas of the scale target and the placeholder pods as they are, like during a maintenance window,
+	// while the metrics and the status of the autoscaler keep being updated.
+	// The capacity reservations added by the webhook-based autoscaler in the meantime are applied once it's unpaused, unless they expire.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+const (
+	ScaleDownBehaviorConservative = "Conservative"
+	ScaleDownBehaviorBalanced     = "Balanced"
+	ScaleDownBehaviorAggressive   = "Aggressive"
+)
+
+// CapacityShareSpec configures the share of the cluster's runner capacity of an HRA.
+type CapacityShareSpec struct {
+	// Priority orders the HRAs. The capacity is allocated to the HRAs with the highest priority first,
+	// and the rest to the HRAs with the next highest priority, so that the HRAs with a lower priority
+	// give their runners back as they become idle when the ones with a higher priority need them.
+	// Defaults to 0.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// Weight is the share of the capacity allocated to this HRA among the HRAs with the same priority
+	// that request more than the capacity left for them. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Weight *int `json:"weight,omitempty"`
+}
+
+// ExternalRunnersSpec selects the runners registered outside of ARC in the scope of the scale target.
+// A runner is external when it isn't a runner of the scale target and it matches either Names or Labels.
+type ExternalRunnersSpec struct {
+	// Names are the names of the external runners.
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// Labels selects the runners that have all of the labels, like the custom labels given to the runners on VMs.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+}
+
+// CheckRunSummarySpec configures the check run summarizing the runners provisioned for the jobs of a workflow run.
+// It requires the webhook-based autoscaler to authenticate as a GitHub App with the permission to write checks,
+// and costs up to three GitHub API calls per workflow_job event.
+type CheckRunSummarySpec struct {
+	// Name is the name of the check run, which is followed by the runner labels of the jobs. Defaults to "Self-hosted runners".
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RunnerStartupTime is the typical time for a new runner to start and pick up a job,
+	// used to estimate when the jobs waiting for runners start. Defaults to 1m.
+	// +optional
+	RunnerStartupTime *metav1.Duration `json:"runnerStartupTime,omitempty"`
+}
+
+const (
+	DualRunDrivenByMetrics = "Metrics"
+	DualRunDrivenByWebhook = "Webhook"
+)
+
+// DualRunSpec configures the dual-run mode of HorizontalRunnerAutoscaler.
+type DualRunSpec struct {
+	// DrivenBy is the calculation that drives the desired replicas.
+	// "Metrics" uses the replicas suggested by Metrics, ignoring the capacity reservations added by the webhook-based autoscaler.
+	// "Webhook" uses minReplicas plus the capacity reservations, ignoring Metrics.
+	// Defaults to Metrics.
+	// +optional
+	// +kubebuilder:validation:Enum=Metrics;Webhook
+	DrivenBy string `json:"drivenBy,omitempty"`
+
+	// ObservationWindow is the duration of each comparison report. Defaults to 24h.
+	// +optional
+	ObservationWindow *metav1.Duration `json:"observationWindow,omitempty"`
+
+	// Tolerance is the difference in replicas up to which the two calculations are considered to agree.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Tolerance int `json:"tolerance,omitempty"`
+}
+
+// PlaceholdersSpec configures the placeholder pods of HorizontalRunnerAutoscaler.
+type PlaceholdersSpec struct {
+	// PriorityClassName is the priority class of the placeholder pods. It needs a lower priority than the runner pods,
+	// usually a negative one, so that the scheduler preempts the placeholder pods for the runner pods.
+	PriorityClassName string `json:"priorityClassName"`
+
+	// Image is the image of the placeholder container, which only needs to sleep. Defaults to k8s.gcr.io/pause:3.6.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Lookback is the period over which the growth of the capacity reservations is observed. Defaults to 5m.
+	// +optional
+	Lookback *metav1.Duration `json:"lookback,omitempty"`
+
+	// Horizon is how far ahead the demand is forecasted, which should be about how long it takes to provision a node.
+	// The growth of the capacity reservations within Lookback is extrapolated over Horizon. Defaults to 3m.
+	// +optional
+	Horizon *metav1.Duration `json:"horizon,omitempty"`
+
+	// MinReplicas is the number of placeholder pods kept regardless of the forecast. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinReplicas *int `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the maximum number of placeholder pods. Defaults to the maxReplicas of the HorizontalRunnerAutoscaler, if any.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas *int `json:"maxReplicas,omitempty"`
+
+	// ReservePercent is the number of placeholder pods kept regardless of the forecast, in percentage of the desired
+	// replicas of the scale target, rounded up. Unlike MinReplicas, the reserve grows and shrinks with the runners,
+	// and it doesn't need the capacity reservations of the webhook-based autoscaler. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ReservePercent *int `json:"reservePercent,omitempty"`
+}
+
+// PredictiveSpec configures the predictive scaling of HorizontalRunnerAutoscaler.
+type PredictiveSpec struct {
+	// Enabled makes the autoscaler raise the desired replicas to the demand predicted within Lookahead.
+	// While it's false, the demand is still recorded and predicted in the status without affecting the desired replicas,
+	// so that the history can be built up and the predictions checked before enabling it.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Lookahead is how far ahead the demand is predicted, which should be about how long it takes for a runner to get ready,
+	// including the node provisioning if any. Defaults to 10m.
+	// +optional
+	Lookahead *metav1.Duration `json:"lookahead,omitempty"`
+
+	// Season is the period over which the demand recurs, either Daily or Weekly. Defaults to Daily.
+	// A Weekly season tells the weekends from the weekdays, but takes a week to learn.
+	// +optional
+	// +kubebuilder:validation:Enum=Daily;Weekly
+	Season string `json:"season,omitempty"`
+}
+
+// LimitWhileUnschedulableSpec configures the cap of the desired replicas while runner pods are unschedulable.
+type LimitWhileUnschedulableSpec struct {
+	// Duration is how long the desired replicas are capped before scaling up is retried. Defaults to 5m.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// HostedRunnerFallbackSpec configures when HorizontalRunnerAutoscaler recommends falling back to GitHub-hosted runners.
+type HostedRunnerFallbackSpec struct {
+	// SustainedFor is how long the desired replicas need to keep exceeding MaxReplicas before the fallback is recommended.
+	// Defaults to 10m.
+	// +optional
+	SustainedFor *metav1.Duration `json:"sustainedFor,omitempty"`
+
+	// AverageJobDuration is the typical duration of a job run on the runners, used to estimate how long a queued job waits for a runner.
+	// Defaults to 10m.
+	// +optional
+	AverageJobDuration *metav1.Duration `json:"averageJobDuration,omitempty"`
+
+	// CommitStatus makes the webhook-based autoscaler set a pending commit status that recommends GitHub-hosted runners
+	// on the commit of every workflow job queued while the fallback is recommended.
+	// It requires the webhook-based autoscaler to have GitHub credentials with the permission to write commit statuses.
+	// +optional
+	CommitStatus bool `json:"commitStatus,omitempty"`
 }
 
 type ScaleUpTrigger struct {
 	GitHubEvent *GitHubEventScaleUpTriggerSpec `json:"githubEvent,omitempty"`
 	Amount      int                            `json:"amount,omitempty"`
 	Duration    metav1.Duration                `json:"duration,omitempty"`
+
+	// LabelAmounts is the number of replicas reserved for a workflow job with the label, keyed by the label,
+	// so that heavier jobs reserve proportionally more capacity from the same pool of runners.
+	// A job with more than one of the labels reserves the largest amount, and a job with none of them reserves 1 replica.
+	// Labels are compared case-insensitively, and amounts less than 1 are ignored.
+	// Only used for workflow_job events.
+	// +optional
+	LabelAmounts map[string]int `json:"labelAmounts,omitempty"`
 }
 
 type GitHubEventScaleUpTriggerSpec struct {
-	CheckRun    *CheckRunSpec    `json:"checkRun,omitempty"`
-	PullRequest *PullRequestSpec `json:"pullRequest,omitempty"`
-	Push        *PushSpec        `json:"push,omitempty"`
+	CheckRun         *CheckRunSpec         `json:"checkRun,omitempty"`
+	CheckSuite       *CheckSuiteSpec       `json:"checkSuite,omitempty"`
+	PullRequest      *PullRequestSpec      `json:"pullRequest,omitempty"`
+	Push             *PushSpec             `json:"push,omitempty"`
+	Deployment       *DeploymentSpec       `json:"deployment,omitempty"`
+	DeploymentStatus *DeploymentStatusSpec `json:"deploymentStatus,omitempty"`
+	WorkflowJob      *WorkflowJobSpec      `json:"workflowJob,omitempty"`
+}
+
+// WorkflowJobSpec is the condition for routing workflow_job events to the HorizontalRunnerAutoscaler.
+// Also see https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads#workflow_job
+type WorkflowJobSpec struct {
+	// LabelSelectors selects the workflow jobs by their runs-on labels, instead of requiring the runners
+	// of the scale target to have all the labels of the job.
+	// +optional
+	LabelSelectors *WorkflowJobLabelSelectors `json:"labelSelectors,omitempty"`
+}
+
+// WorkflowJobLabelSelectors selects the workflow jobs whose labels include all the Include labels and none of the Exclude labels.
+// The other labels of the jobs are ignored. Labels are compared case-insensitively, like GitHub does.
+type WorkflowJobLabelSelectors struct {
+	// Include is the list of the labels a workflow job needs to have all of.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is the list of the labels a workflow job must have none of.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // https://docs.github.com/en/actions/reference/events-that-trigger-workflows#check_run
@@ -90,15 +356,92 @@ type CheckRunSpec struct {
 	Repositories []string `json:"repositories,omitempty"`
 }
 
+// CheckSuiteSpec is the condition for triggering scale-up on check_suite event.
+// This is useful for pre-scaling when check_suite events are sent earlier than check_run events,
+// as with some GitHub Enterprise Server versions.
+// Also see https://docs.github.com/en/actions/reference/events-that-trigger-workflows#check_suite
+type CheckSuiteSpec struct {
+	// Types is a list of check_suite activity types like "requested" and "rerequested".
+	// Any check_suite event whose action matches one of types in the list can trigger autoscaling.
+	Types []string `json:"types,omitempty"`
+
+	// Branches is a list of branches.
+	// Any check_suite event whose head branch matches one of branches in the list can trigger autoscaling.
+	Branches []string `json:"branches,omitempty"`
+
+	// Repositories is a list of GitHub repositories.
+	// Any check_suite event whose repository matches one of repositories in the list can trigger autoscaling.
+	Repositories []string `json:"repositories,omitempty"`
+}
+
 // https://docs.github.com/en/actions/reference/events-that-trigger-workflows#pull_request
 type PullRequestSpec struct {
-	Types    []string `json:"types,omitempty"`
+	Types []string `json:"types,omitempty"`
+
+	// Branches is a list of GitHub Actions glob patterns.
+	// Any pull request event whose base branch matches one of patterns in the list can trigger autoscaling.
+	// Like on.pull_request.branches of workflows, a pattern prefixed with "!" excludes the branches matched by the preceding patterns.
+	// +optional
 	Branches []string `json:"branches,omitempty"`
+
+	// Labels is a list of pull request labels.
+	// Any pull request event whose pull request has one of the labels can trigger autoscaling.
+	// This is useful when self-hosted jobs are gated behind a label like "run-e2e".
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// ExcludeDrafts makes events of draft pull requests not trigger autoscaling.
+	// Add "ready_for_review" to Types to scale up when a draft is marked ready.
+	// +optional
+	ExcludeDrafts bool `json:"excludeDrafts,omitempty"`
+
+	// Optimistic makes the capacity reserved on the pull_request event optimistic.
+	// The subsequent workflow_job queued events for the same repository consume the optimistic reservation
+	// instead of adding more replicas, and the rest of it is cancelled once the trigger's duration elapses.
+	// This is useful to pre-scale on e.g. the synchronize action, because a push to a pull request
+	// almost always results in workflow jobs.
+	// +optional
+	Optimistic bool `json:"optimistic,omitempty"`
 }
 
 // PushSpec is the condition for triggering scale-up on push event
 // Also see https://docs.github.com/en/actions/reference/events-that-trigger-workflows#push
 type PushSpec struct {
+	// Branches is a list of GitHub Actions glob patterns.
+	// Any push event whose branch matches one of patterns in the list can trigger autoscaling.
+	// Like on.push.branches of workflows, a pattern prefixed with "!" excludes the branches matched by the preceding patterns.
+	// Pushes of tags never match when this is set.
+	// +optional
+	Branches []string `json:"branches,omitempty"`
+
+	// Paths is a list of GitHub Actions glob patterns.
+	// Any push event that added, modified, or removed a file matching one of patterns in the list can trigger autoscaling.
+	// Like on.push.paths of workflows, a pattern prefixed with "!" excludes the files matched by the preceding patterns.
+	// Pushes whose payloads list no files, like the creation of a branch, always match.
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+}
+
+// DeploymentSpec is the condition for triggering scale-up on deployment event.
+// This is useful for pre-warming runners for jobs gated on environments, as the deployment is created
+// before the job is queued after the environment's protection rules are satisfied.
+// Also see https://docs.github.com/en/actions/reference/events-that-trigger-workflows#deployment
+type DeploymentSpec struct {
+	// Environments is a list of GitHub Actions glob patterns.
+	// Any deployment event whose environment matches one of patterns in the list can trigger autoscaling.
+	Environments []string `json:"environments,omitempty"`
+}
+
+// DeploymentStatusSpec is the condition for triggering scale-up on deployment_status event.
+// Also see https://docs.github.com/en/actions/reference/events-that-trigger-workflows#deployment_status
+type DeploymentStatusSpec struct {
+	// States is a list of deployment states like "queued", "in_progress", and "waiting".
+	// Any deployment_status event whose state matches one of states in the list can trigger autoscaling.
+	States []string `json:"states,omitempty"`
+
+	// Environments is a list of GitHub Actions glob patterns.
+	// Any deployment_status event whose environment matches one of patterns in the list can trigger autoscaling.
+	Environments []string `json:"environments,omitempty"`
 }
 
 // CapacityReservation specifies the number of replicas temporarily added
@@ -107,6 +450,35 @@ type CapacityReservation struct {
 	Name           string      `json:"name,omitempty"`
 	ExpirationTime metav1.Time `json:"expirationTime,omitempty"`
 	Replicas       int         `json:"replicas,omitempty"`
+
+	// Optimistic is true when the replicas are reserved in anticipation of workflow jobs for the repository named Name,
+	// rather than for actual workflow jobs.
+	// +optional
+	Optimistic bool `json:"optimistic,omitempty"`
+
+	// IdempotencyKey identifies the operation that made this reservation, like the ID of the workflow job
+	// or the delivery ID of the webhook event, so that a retried operation doesn't add the reservation twice.
+	// +optional
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
+	// RunnerName is the name of the runner that picked up the workflow job this reservation is made for.
+	// It's recorded on the workflow_job event for the job getting in progress, so that the reservation can be
+	// released as soon as the runner is interrupted.
+	// +optional
+	RunnerName string `json:"runnerName,omitempty"`
+
+	// Repository is the full name of the repository, like `OWNER/REPO`, of the workflow job this reservation is made for.
+	// It's stamped on the pod of the runner that picked up the job, so that the cost of the runner can be attributed to the repository.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// Workflow is the name of the workflow of the workflow job this reservation is made for.
+	// +optional
+	Workflow string `json:"workflow,omitempty"`
+
+	// WorkflowRunID is the ID of the workflow run of the workflow job this reservation is made for.
+	// +optional
+	WorkflowRunID int64 `json:"workflowRunID,omitempty"`
 }
 
 type ScaleTargetRef struct {
@@ -158,8 +530,21 @@ type MetricSpec struct {
 	// You can only specify either ScaleDownFactor or ScaleDownAdjustment.
 	// +optional
 	ScaleDownAdjustment int `json:"scaleDownAdjustment,omitempty"`
+
+	// BusyRunnersSource is where PercentageRunnersBusy gets the busy runners from.
+	// GitHubAPI, the default, lists the runners with the GitHub API whenever the desired replicas are computed.
+	// WorkflowJobEvents counts the runners busy according to the workflow_job events received by the github-webhook-server,
+	// which is near-real-time and needs no GitHub API calls, but can't count external runners.
+	// +optional
+	// +kubebuilder:validation:Enum=GitHubAPI;WorkflowJobEvents
+	BusyRunnersSource string `json:"busyRunnersSource,omitempty"`
 }
 
+const (
+	BusyRunnersSourceGitHubAPI         = "GitHubAPI"
+	BusyRunnersSourceWorkflowJobEvents = "WorkflowJobEvents"
+)
+
 // ScheduledOverride can be used to override a few fields of HorizontalRunnerAutoscalerSpec on schedule.
 // A schedule can optionally be recurring, so that the correspoding override happens every day, week, month, or year.
 type ScheduledOverride struct {
@@ -216,6 +601,275 @@ type HorizontalRunnerAutoscalerStatus struct {
 	// for observability.
 	// +optional
 	ScheduledOverridesSummary *string `json:"scheduledOverridesSummary,omitempty"`
+
+	// HostedRunnerFallback is the overflow of the desired replicas beyond MaxReplicas.
+	// It's set only when spec.hostedRunnerFallback is set.
+	// +optional
+	HostedRunnerFallback *HostedRunnerFallbackStatus `json:"hostedRunnerFallback,omitempty"`
+
+	// AccountRunnerLimit is the share of the GitHub account's runner limit allocated to this HRA.
+	// It's set only when the controller is configured with the maximum number of runners per GitHub account.
+	// +optional
+	AccountRunnerLimit *AccountRunnerLimitStatus `json:"accountRunnerLimit,omitempty"`
+
+	// ClusterCapacity is the share of the cluster's runner capacity allocated to this HRA.
+	// It's set only when the controller is configured with the maximum number of runners in the cluster.
+	// +optional
+	ClusterCapacity *ClusterCapacityStatus `json:"clusterCapacity,omitempty"`
+
+	// ScaleRateLimitWindow is the current one-minute window within which the desired replicas can change
+	// by up to ScaleUpMaxRatePerMinute or ScaleDownMaxRatePerMinute replicas.
+	// It's set only when either of them is set.
+	// +optional
+	ScaleRateLimitWindow *ScaleRateLimitWindow `json:"scaleRateLimitWindow,omitempty"`
+
+	// DualRun is the comparison of the pull-based and the webhook-based calculations.
+	// It's set only when spec.dualRun is set.
+	// +optional
+	DualRun *DualRunStatus `json:"dualRun,omitempty"`
+
+	// Placeholders is the forecast of the demand and the resulting number of placeholder pods.
+	// It's set only when spec.placeholders is set.
+	// +optional
+	Placeholders *PlaceholdersStatus `json:"placeholders,omitempty"`
+
+	// Predictive is the history of the demand and the demand predicted from it.
+	// It's set only when spec.predictive is set.
+	// +optional
+	Predictive *PredictiveStatus `json:"predictive,omitempty"`
+
+	// UnschedulableReplicas is the number of the runner pods of the scale target that the scheduler found no node for.
+	// It's set only when spec.suspendScaleUpWhileUnschedulable is true or spec.limitWhileUnschedulable is set.
+	// +optional
+	UnschedulableReplicas int `json:"unschedulableReplicas,omitempty"`
+
+	// UnschedulableLimit is the cap of the desired replicas at the replicas the cluster can run.
+	// It's set only while spec.limitWhileUnschedulable caps the desired replicas.
+	// +optional
+	UnschedulableLimit *UnschedulableLimitStatus `json:"unschedulableLimit,omitempty"`
+
+	// WorkflowRuns is the number of queued and in-progress workflow runs and jobs last observed for
+	// the TotalNumberOfQueuedAndInProgressWorkflowRuns metric.
+	// It's recorded so that every replica of the controller can serve it through the external metrics API.
+	// +optional
+	WorkflowRuns *WorkflowRunsStatus `json:"workflowRuns,omitempty"`
+
+	// BusyRunners are the runners of the scale target running workflow jobs according to the workflow_job events.
+	// They're recorded by the github-webhook-server only when PercentageRunnersBusy counts the busy runners from WorkflowJobEvents.
+	// +optional
+	BusyRunners []BusyRunner `json:"busyRunners,omitempty"`
+
+	// Conditions represent the latest observations of the autoscaler, like whether the desired replicas are limited.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// HorizontalRunnerAutoscalerConditionTypeLimited is true while the desired replicas are capped at the replicas
+	// the cluster can run or by a RunnerQuota.
+	// It's set only when spec.limitWhileUnschedulable is set or any RunnerQuota covers the scale target.
+	HorizontalRunnerAutoscalerConditionTypeLimited = "Limited"
+
+	HorizontalRunnerAutoscalerConditionReasonRunnerPodsUnschedulable = "RunnerPodsUnschedulable"
+	HorizontalRunnerAutoscalerConditionReasonRunnerPodsSchedulable   = "RunnerPodsSchedulable"
+	HorizontalRunnerAutoscalerConditionReasonRunnerQuotaExceeded     = "RunnerQuotaExceeded"
+	HorizontalRunnerAutoscalerConditionReasonWithinRunnerQuota       = "WithinRunnerQuota"
+
+	// HorizontalRunnerAutoscalerConditionTypeScalingActive is false while the autoscaler is paused.
+	// It's set only while spec.paused is true, the same way as the condition of HorizontalPodAutoscaler.
+	HorizontalRunnerAutoscalerConditionTypeScalingActive = "ScalingActive"
+
+	HorizontalRunnerAutoscalerConditionReasonScalingPaused = "ScalingPaused"
+)
+
+type BusyRunner struct {
+	// Name is the name of the runner, which is the same as the name of its pod.
+	Name string `json:"name"`
+
+	// JobID is the ID of the workflow job the runner is running.
+	// +optional
+	JobID int64 `json:"jobID,omitempty"`
+
+	// Since is the time the in_progress event of the job was received.
+	Since metav1.Time `json:"since"`
+}
+
+type UnschedulableLimitStatus struct {
+	// Replicas is the number of replicas the cluster could run when runner pods were last found unschedulable.
+	Replicas int `json:"replicas"`
+
+	// ExpirationTime is the time the cap is lifted.
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+type WorkflowRunsStatus struct {
+	// Queued is the number of queued workflow runs and jobs.
+	Queued int `json:"queued"`
+
+	// InProgress is the number of in-progress workflow runs and jobs.
+	InProgress int `json:"inProgress"`
+}
+
+type PlaceholdersStatus struct {
+	// Replicas is the number of placeholder pods.
+	// +optional
+	Replicas int `json:"replicas,omitempty"`
+
+	// Class describes the nodes the placeholder pods are scheduled onto, by the resource requests and the node selector,
+	// including the architecture, of the runner pods, like "cpu=2,memory=4Gi,kubernetes.io/arch=arm64".
+	// +optional
+	Class string `json:"class,omitempty"`
+
+	// Samples are the reserved replicas sampled at most once a minute within spec.placeholders.lookback.
+	// +optional
+	Samples []ReservedReplicasSample `json:"samples,omitempty"`
+}
+
+type PredictiveStatus struct {
+	// Season is the season the slots cover, either Daily or Weekly.
+	Season string `json:"season"`
+
+	// Slots are the peak demand of each 30 minutes of the season in UTC, starting at midnight for Daily and Sunday midnight for Weekly.
+	// Each slot is the average of the peak observed in the last season and the slot of the seasons before, so that the older seasons
+	// weigh exponentially less. It's -1 until the slot is observed.
+	Slots []int `json:"slots"`
+
+	// CurrentSlot is the index of the slot being observed.
+	CurrentSlot int `json:"currentSlot"`
+
+	// CurrentPeak is the peak demand observed within the current slot so far, which is averaged into the slot once it ends.
+	// +optional
+	CurrentPeak int `json:"currentPeak,omitempty"`
+
+	// LastSampleTime is the time at which the demand was observed last.
+	LastSampleTime metav1.Time `json:"lastSampleTime"`
+
+	// PredictedReplicas is the peak demand predicted within spec.predictive.lookahead.
+	// +optional
+	PredictedReplicas int `json:"predictedReplicas,omitempty"`
+}
+
+type ReservedReplicasSample struct {
+	Time     metav1.Time `json:"time"`
+	Replicas int         `json:"replicas"`
+}
+
+type DualRunStatus struct {
+	// Current is the report of the ongoing observation window.
+	Current DualRunReport `json:"current"`
+
+	// Last is the report of the last completed observation window.
+	// +optional
+	Last *DualRunReport `json:"last,omitempty"`
+
+	// MetricsReplicas is the desired replicas calculated from Metrics on the last reconciliation.
+	// +optional
+	MetricsReplicas int `json:"metricsReplicas,omitempty"`
+
+	// WebhookReplicas is the desired replicas calculated from the capacity reservations on the last reconciliation.
+	// +optional
+	WebhookReplicas int `json:"webhookReplicas,omitempty"`
+}
+
+// DualRunReport compares the two calculations sampled on every reconciliation within an observation window.
+type DualRunReport struct {
+	// StartTime is the time at which the observation window started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// EndTime is the time at which the observation window completed.
+	// +optional
+	// +nullable
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Samples is the number of reconciliations observed.
+	// +optional
+	Samples int `json:"samples,omitempty"`
+
+	// Agreements is the number of samples where the two calculations differed by no more than the tolerance.
+	// +optional
+	Agreements int `json:"agreements,omitempty"`
+
+	// AgreementPercentage is Agreements out of Samples in percent.
+	// +optional
+	AgreementPercentage int `json:"agreementPercentage,omitempty"`
+
+	// TotalDivergence is the sum of the differences in replicas between the two calculations.
+	// +optional
+	TotalDivergence int `json:"totalDivergence,omitempty"`
+
+	// MaxDivergence is the largest difference in replicas between the two calculations.
+	// +optional
+	MaxDivergence int `json:"maxDivergence,omitempty"`
+
+	// MissedScaleUps is the number of samples where the calculation from Metrics scaled up
+	// but the calculation from the capacity reservations stayed below it.
+	// Switching to the webhook-based scaling is safe only when it's zero or close to zero.
+	// +optional
+	MissedScaleUps int `json:"missedScaleUps,omitempty"`
+}
+
+type ScaleRateLimitWindow struct {
+	// StartTime is the time at which the window started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// StartReplicas is the desired replicas at StartTime.
+	StartReplicas int `json:"startReplicas"`
+}
+
+type ClusterCapacityStatus struct {
+	// MaxRunners is the maximum number of runners shared by all the HRAs in the cluster.
+	// +optional
+	MaxRunners int `json:"maxRunners,omitempty"`
+
+	// RequestedReplicas is the number of desired replicas of this HRA before being clamped by the cluster's capacity.
+	// +optional
+	RequestedReplicas int `json:"requestedReplicas,omitempty"`
+
+	// AllocatedReplicas is the number of replicas allocated to this HRA by its priority and weight
+	// when the HRAs collectively request more than MaxRunners.
+	// +optional
+	AllocatedReplicas int `json:"allocatedReplicas,omitempty"`
+}
+
+type AccountRunnerLimitStatus struct {
+	// Account is the GitHub enterprise, organization or user that owns the runners of the scale target.
+	// Enterprises are prefixed with "enterprises/".
+	// +optional
+	Account string `json:"account,omitempty"`
+
+	// MaxRunners is the maximum number of runners shared by all the HRAs for the account.
+	// +optional
+	MaxRunners int `json:"maxRunners,omitempty"`
+
+	// RequestedReplicas is the number of desired replicas of this HRA before being clamped by the account's runner limit.
+	// +optional
+	RequestedReplicas int `json:"requestedReplicas,omitempty"`
+
+	// AllocatedReplicas is the number of replicas allocated to this HRA, which is proportional to the requested
+	// replicas when the HRAs for the account collectively request more than MaxRunners.
+	// +optional
+	AllocatedReplicas int `json:"allocatedReplicas,omitempty"`
+}
+
+type HostedRunnerFallbackStatus struct {
+	// OverflowReplicas is the number of desired replicas that exceeded MaxReplicas on the last reconciliation.
+	// +optional
+	OverflowReplicas int `json:"overflowReplicas,omitempty"`
+
+	// OverflowSince is the time since when the desired replicas have kept exceeding MaxReplicas.
+	// +optional
+	// +nullable
+	OverflowSince *metav1.Time `json:"overflowSince,omitempty"`
+
+	// Recommended is true when the overflow has been sustained for spec.hostedRunnerFallback.sustainedFor.
+	// +optional
+	Recommended bool `json:"recommended,omitempty"`
+
+	// EstimatedWaitSeconds is the estimated time a newly queued job waits for a runner while overflowing.
+	// +optional
+	EstimatedWaitSeconds int `json:"estimatedWaitSeconds,omitempty"`
 }
 
 const CacheEntryKeyDesiredReplicas = "desiredReplicas"
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceTemplateSpec defines the default resources created in every namespace matching NamespaceSelector.
+type NamespaceTemplateSpec struct {
+	// NamespaceSelector selects the namespaces to create the resources in.
+	// An empty selector selects all the namespaces.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// RunnerDeployment is the template of the RunnerDeployment created in each selected namespace.
+	// Its name defaults to the name of the NamespaceTemplate.
+	RunnerDeployment RunnerDeploymentTemplate `json:"runnerDeployment"`
+
+	// HorizontalRunnerAutoscaler is the template of the HorizontalRunnerAutoscaler created in each selected namespace.
+	// Its name defaults to the name of the NamespaceTemplate, and its scaleTargetRef defaults to the RunnerDeployment.
+	// +optional
+	HorizontalRunnerAutoscaler *HorizontalRunnerAutoscalerTemplate `json:"horizontalRunnerAutoscaler,omitempty"`
+}
+
+// RunnerDeploymentTemplate is the template of a RunnerDeployment.
+type RunnerDeploymentTemplate struct {
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RunnerDeploymentSpec `json:"spec,omitempty"`
+}
+
+// HorizontalRunnerAutoscalerTemplate is the template of a HorizontalRunnerAutoscaler.
+type HorizontalRunnerAutoscalerTemplate struct {
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HorizontalRunnerAutoscalerSpec `json:"spec,omitempty"`
+}
+
+type NamespaceTemplateStatus struct {
+	// Namespaces are the namespaces the resources are created and kept in sync in.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=nstemplate
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// NamespaceTemplate creates a RunnerDeployment and optionally a HorizontalRunnerAutoscaler in every namespace
+// selected by its namespace selector, and keeps them in sync with the templates, so that new tenant namespaces
+// get runners without any setup.
+type NamespaceTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceTemplateSpec   `json:"spec,omitempty"`
+	Status NamespaceTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceTemplateList contains a list of NamespaceTemplate
+type NamespaceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceTemplate{}, &NamespaceTemplateList{})
+}
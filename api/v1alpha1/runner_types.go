@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
@@ -29,6 +30,65 @@ import (
 type RunnerSpec struct {
 	RunnerConfig  `json:",inline"`
 	RunnerPodSpec `json:",inline"`
+
+	// Unregistration configures how the runner is unregistered from GitHub before it gets deleted.
+	// +optional
+	Unregistration *RunnerUnregistrationConfig `json:"unregistration,omitempty"`
+}
+
+// RunnerUnregistrationConfig configures how the controller deals with the runner that GitHub reports as busy or offline
+// while unregistering it before deletion.
+type RunnerUnregistrationConfig struct {
+	// BusyWaitTimeout is how long the controller waits for the busy runner to complete its job, counted from the deletion request.
+	// Once exceeded, the runner is deleted without being unregistered, leaving it to GitHub to remove the offline runner.
+	// Defaults to waiting indefinitely.
+	// +optional
+	BusyWaitTimeout *metav1.Duration `json:"busyWaitTimeout,omitempty"`
+
+	// BusyCheckRetries is how many more times the controller checks if the busy runner has completed its job
+	// before giving up unregistering it, at an interval of 10 seconds.
+	// Defaults to retrying indefinitely.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BusyCheckRetries *int `json:"busyCheckRetries,omitempty"`
+
+	// DeleteOfflineImmediately makes the controller unregister the runner that GitHub reports as offline without
+	// waiting for it even when GitHub also reports it as busy, which happens when the runner got stuck or
+	// its job completion is yet to be reflected.
+	// +optional
+	DeleteOfflineImmediately bool `json:"deleteOfflineImmediately,omitempty"`
+
+	// Policy is when the runner is unregistered from GitHub before deletion.
+	// IfIdle, the default, waits for the busy runner to complete its job according to BusyWaitTimeout and BusyCheckRetries.
+	// Always deletes the busy runner without waiting, failing its job and leaving it to GitHub to remove the offline runner.
+	// Never deletes the runner without calling GitHub at all, which is useful when the GitHub API is unreachable from the cluster.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;IfIdle;Never
+	Policy UnregistrationPolicy `json:"policy,omitempty"`
+
+	// Timeout is how long the controller keeps retrying to unregister the runner on GitHub API errors, counted from the deletion request.
+	// Once exceeded, the runner is deleted without being unregistered, leaving it to GitHub to remove the offline runner.
+	// Defaults to retrying indefinitely.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// UnregistrationPolicy is when the runner is unregistered from GitHub before deletion.
+type UnregistrationPolicy string
+
+const (
+	UnregistrationPolicyAlways UnregistrationPolicy = "Always"
+	UnregistrationPolicyIfIdle UnregistrationPolicy = "IfIdle"
+	UnregistrationPolicyNever  UnregistrationPolicy = "Never"
+)
+
+// UnregistrationPolicy returns the unregistration policy of the runner, defaulting to IfIdle.
+func (rs *RunnerSpec) UnregistrationPolicy() UnregistrationPolicy {
+	if rs.Unregistration == nil || rs.Unregistration.Policy == "" {
+		return UnregistrationPolicyIfIdle
+	}
+
+	return rs.Unregistration.Policy
 }
 
 type RunnerConfig struct {
@@ -50,12 +110,31 @@ type RunnerConfig struct {
 	// +optional
 	Group string `json:"group,omitempty"`
 
+	// GitHubAPICredentialsFrom selects the GitHub endpoint, like github.com or a GitHub Enterprise Server instance,
+	// and the credentials the runners are registered and unregistered with, instead of the ones of the controller.
+	// +optional
+	GitHubAPICredentialsFrom *GitHubAPICredentialsFrom `json:"githubAPICredentialsFrom,omitempty"`
+
 	// +optional
 	Ephemeral *bool `json:"ephemeral,omitempty"`
 
 	// +optional
 	Image string `json:"image"`
 
+	// OS is the operating system of the runner, either linux or windows. Defaults to linux.
+	// Windows runners are scheduled onto Windows nodes without docker, and match workflow jobs by the implicit windows and x64 labels.
+	// +optional
+	// +kubebuilder:validation:Enum=linux;windows
+	OS string `json:"os,omitempty"`
+
+	// Arch is the CPU architecture of the runner, either amd64 or arm64.
+	// Defaults to the kubernetes.io/arch node selector of the pod template, if any.
+	// When known, the runner is scheduled onto nodes of the architecture, gets the runner image for it,
+	// and matches workflow jobs by the implicit linux and x64 or arm64 labels.
+	// +optional
+	// +kubebuilder:validation:Enum=amd64;arm64
+	Arch string `json:"arch,omitempty"`
+
 	// +optional
 	WorkDir string `json:"workDir,omitempty"`
 
@@ -67,10 +146,251 @@ type RunnerConfig struct {
 	DockerMTU *int64 `json:"dockerMTU,omitempty"`
 	// +optional
 	DockerRegistryMirror *string `json:"dockerRegistryMirror,omitempty"`
+	// DockerIPv6CIDR is the IPv6 subnet, e.g. fd00:1::/80, that dockerd allocates addresses to containers from.
+	// Setting this enables IPv6 networking in dockerd, so that containers can reach the network in IPv6-only
+	// and dual-stack clusters.
+	// +optional
+	DockerIPv6CIDR *string `json:"dockerIPv6CIDR,omitempty"`
 	// +optional
 	VolumeSizeLimit *resource.Quantity `json:"volumeSizeLimit,omitempty"`
 	// +optional
 	VolumeStorageMedium *string `json:"volumeStorageMedium,omitempty"`
+
+	// Proxy is the HTTP(S) proxy configuration for the runner and the docker containers.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// LogForwarder adds a sidecar container to the runner pod that forwards the runner's job logs to an external log collector.
+	// +optional
+	LogForwarder *LogForwarderSpec `json:"logForwarder,omitempty"`
+
+	// OIDC configures the runner container for workflows that federate GitHub Actions OIDC tokens with cloud providers,
+	// which typically need a custom issuer, audience, and CA on GitHub Enterprise Server.
+	// +optional
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+
+	// MaxJobDuration is the maximum duration of a workflow job run by the runner.
+	// The watchdog in the runner container cancels the job exceeding it, and the runner pod is recreated afterwards.
+	// Requires the runner image to ship the job hooks of actions-runner-controller and actions/runner v2.300.0 or greater.
+	// +optional
+	MaxJobDuration *metav1.Duration `json:"maxJobDuration,omitempty"`
+
+	// JobHooks are the scripts the runner runs before and after each job, like setting up and cleaning up credentials,
+	// without building a custom runner image.
+	// Requires actions/runner v2.300.0 or greater.
+	// +optional
+	JobHooks *JobHooks `json:"hooks,omitempty"`
+
+	// HealthCheck makes the runner container periodically check its disk usage, memory usage, and dockerd,
+	// which is reported in the Healthy condition of the runner.
+	// Requires the runner image to ship the job hooks of actions-runner-controller.
+	// +optional
+	HealthCheck *RunnerHealthCheck `json:"healthCheck,omitempty"`
+
+	// GPU requests GPUs for the runner container, schedules the runner onto GPU nodes,
+	// and adds the gpu label, along with the type of the GPUs when set, to the runner.
+	// +optional
+	GPU *GPUConfig `json:"gpu,omitempty"`
+
+	// ToolCache mounts a PersistentVolumeClaim shared by the runners at the tool cache directory,
+	// so that the tools downloaded by actions like setup-node and setup-go are reused across runners without custom runner images.
+	// +optional
+	ToolCache *ToolCacheSpec `json:"toolCache,omitempty"`
+
+	// Cache points actions/cache in the jobs at a self-hosted cache server compatible with the cache service of GitHub,
+	// instead of the cache of GitHub. Defaults to the cache server of the controller, if any.
+	// Requires the runner image to ship the job hooks of actions-runner-controller.
+	// +optional
+	Cache *RunnerCacheConfig `json:"cache,omitempty"`
+}
+
+// RunnerCacheConfig is the cache server the jobs of the runner use for actions/cache.
+type RunnerCacheConfig struct {
+	// URL is the base URL of the cache server. Defaults to --runner-cache-url of the controller.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Disabled makes the jobs use the cache of GitHub, even when the controller has the default cache server.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// ToolCacheSpec is the PersistentVolumeClaim mounted at the tool cache directory of the runner,
+// which is exposed to the actions via the RUNNER_TOOL_CACHE environment variable.
+type ToolCacheSpec struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the namespace of the runners.
+	// It needs to be ReadWriteMany to be shared by the runners on different nodes.
+	ClaimName string `json:"claimName"`
+
+	// MountPath is the tool cache directory. Defaults to /opt/hostedtoolcache, the one of the runner images of this project.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// Provision makes the controller create the PersistentVolumeClaim when it doesn't exist.
+	// The provisioned claim isn't owned by any runner, so that it outlives them, and needs to be deleted manually.
+	// +optional
+	Provision *ToolCacheProvision `json:"provision,omitempty"`
+}
+
+// ToolCacheProvision is the ReadWriteMany PersistentVolumeClaim the controller provisions for the tool cache.
+type ToolCacheProvision struct {
+	// StorageClassName is the storage class of the claim, which needs to support ReadWriteMany, like NFS or EFS.
+	// The default storage class is used when omitted.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Size is the storage requested by the claim.
+	Size resource.Quantity `json:"size"`
+}
+
+// GPUConfig is the GPUs of the runner, exposed by the NVIDIA device plugin as the nvidia.com/gpu resource.
+type GPUConfig struct {
+	// Count is the number of GPUs requested by the runner container.
+	// +kubebuilder:validation:Minimum=1
+	Count int64 `json:"count"`
+
+	// Type is the product name of the GPUs, like Tesla-T4, which selects the nodes by the nvidia.com/gpu.product label
+	// of NVIDIA GPU Feature Discovery. It's also added to the labels of the runner, so that jobs can select the type.
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// RunnerHealthCheck configures the health check the runner container runs on itself. See runner/hooks/health-check.sh.
+// dockerd is checked too when docker is enabled.
+type RunnerHealthCheck struct {
+	// DiskUsagePercent is the usage of the filesystem of the work directory above which the runner is unhealthy.
+	// Defaults to 90.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DiskUsagePercent *int `json:"diskUsagePercent,omitempty"`
+
+	// MemoryUsagePercent is the memory usage of the runner container, relative to its memory limit,
+	// above which the runner is unhealthy. Ignored when the runner container has no memory limit.
+	// Defaults to 90.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MemoryUsagePercent *int `json:"memoryUsagePercent,omitempty"`
+
+	// PeriodSeconds is how often the health is checked. Defaults to 30.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// Recycle makes the runner stop after the job when it's unhealthy, so that the runner pod is recreated
+	// and the next job runs on a fresh runner. Only useful for non-ephemeral runners, as ephemeral ones are recreated after every job anyway.
+	// Requires actions/runner v2.300.0 or greater.
+	// +optional
+	Recycle bool `json:"recycle,omitempty"`
+
+	// Strict makes sure that no job starts on the runner being recycled. The runner may pick up the next job while the health check
+	// after the previous job is still running, so with Strict the next job waits for the result, and fails without running any step
+	// when the runner is going to be recycled. Requires Recycle.
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+}
+
+// JobHooks are the bash scripts run by the runner through ACTIONS_RUNNER_HOOK_JOB_STARTED and ACTIONS_RUNNER_HOOK_JOB_COMPLETED.
+type JobHooks struct {
+	// PreJob is the script run before each job. The job fails when the script exits with a non-zero status.
+	// +optional
+	PreJob *JobHookSource `json:"preJob,omitempty"`
+
+	// PostJob is the script run after each job.
+	// +optional
+	PostJob *JobHookSource `json:"postJob,omitempty"`
+}
+
+// JobHookSource is the key of a ConfigMap or a Secret that contains a job hook script.
+// Exactly one of ConfigMapKeyRef and SecretKeyRef needs to be set.
+type JobHookSource struct {
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// OIDCConfig is the GitHub Actions OIDC configuration that is exposed to the runner container
+// via the ACTIONS_ID_TOKEN_ISSUER, ACTIONS_ID_TOKEN_AUDIENCE, and ACTIONS_ID_TOKEN_CA_FILE environment variables.
+type OIDCConfig struct {
+	// Issuer is the issuer URL of the OIDC tokens, which must be the token service of the GitHub endpoint the runner is registered to,
+	// i.e. https://token.actions.githubusercontent.com for GitHub.com or https://HOSTNAME/_services/token for GitHub Enterprise Server,
+	// optionally followed by a path like the enterprise slug. Defaults to the token service of the GitHub endpoint.
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
+
+	// Audience is the default audience of the OIDC tokens requested by workflows, like sts.amazonaws.com.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+
+	// CASecretName is the name of the secret whose ca.crt is mounted to the runner container, for trusting the private CA
+	// of the token service and the cloud provider endpoints. NODE_EXTRA_CA_CERTS is set to it too, so that JavaScript actions trust it.
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+}
+
+// GitHubAPICredentialsFrom refers to the Secret that contains the GitHub endpoint and the credentials.
+type GitHubAPICredentialsFrom struct {
+	// SecretRef is the Secret in the namespace of the runners. It has the same keys as the Secret of the controller,
+	// like github_token or github_app_id, github_app_installation_id and github_app_private_key,
+	// plus github_enterprise_url, or github_url and github_upload_url, to select the GitHub endpoint.
+	// The endpoint defaults to github.com.
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// SecretReference refers to a Secret in the same namespace.
+type SecretReference struct {
+	Name string `json:"name"`
+}
+
+// ProxyConfig is the HTTP(S) proxy configuration that is exposed to the runner pod's containers
+// via the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables and their lowercase variants.
+type ProxyConfig struct {
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is the list of hosts, domains, IP addresses, and CIDRs that should be accessed without the proxy.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// LogForwarderSpec configures the log forwarder sidecar that tails the runner's diagnostic and job logs under /runner/_diag
+// and forwards them to the log collector using the Fluentd forward protocol.
+type LogForwarderSpec struct {
+	// Endpoint is the host:port of the log collector, like Fluentd or Fluent Bit, that accepts the forward protocol.
+	Endpoint string `json:"endpoint"`
+
+	// Image is the container image of the log forwarder. Defaults to fluent/fluent-bit.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// TLS enables TLS for the connection to the log collector.
+	// +optional
+	TLS *LogForwarderTLS `json:"tls,omitempty"`
+
+	// Labels are added to every forwarded log record, along with the runner name.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// LogForwarderTLS is the TLS configuration of the connection to the log collector.
+type LogForwarderTLS struct {
+	// CASecretName is the name of the secret whose ca.crt is used to verify the log collector's certificate.
+	// The system CAs are used when omitted.
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+
+	// ClientSecretName is the name of the kubernetes.io/tls secret used for the client authentication.
+	// +optional
+	ClientSecretName string `json:"clientSecretName,omitempty"`
+
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
 // RunnerPodSpec defines the desired pod spec fields of the runner pod
@@ -123,6 +443,12 @@ type RunnerPodSpec struct {
 	// +optional
 	SidecarContainers []corev1.Container `json:"sidecarContainers,omitempty"`
 
+	// Sidecars are the containers that run alongside the runner, like artifact proxies, secrets agents, or test databases.
+	// Unlike SidecarContainers, each sidecar mounts the working directory of the runner, starts before the runner,
+	// and terminates after the runner has exited.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
 	// +optional
 	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
 
@@ -152,6 +478,12 @@ type RunnerPodSpec struct {
 	// +optional
 	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
 
+	// PriorityClassName is the priority class of the runner pods. Give it a higher priority than the other workloads
+	// sharing the nodes to let the runner pods preempt them, instead of staying Pending.
+	// More info: https://kubernetes.io/docs/concepts/scheduling-eviction/pod-priority-preemption/
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
 	// +optional
 	DnsConfig []corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
 }
@@ -179,6 +511,101 @@ func (rs *RunnerSpec) ValidateRepository() error {
 	return nil
 }
 
+const (
+	RunnerOSLinux   = "linux"
+	RunnerOSWindows = "windows"
+)
+
+const (
+	RunnerArchAMD64 = "amd64"
+	RunnerArchARM64 = "arm64"
+)
+
+// IsWindows returns true when the runner runs on Windows nodes.
+func (rs *RunnerConfig) IsWindows() bool {
+	return rs.OS == RunnerOSWindows
+}
+
+// ValidateOS validates os field.
+// Windows runners can't run docker, and the job hooks of actions-runner-controller are bash scripts.
+func (rs *RunnerConfig) ValidateOS() error {
+	if !rs.IsWindows() {
+		return nil
+	}
+
+	if rs.Arch == RunnerArchARM64 {
+		return errors.New("arch arm64 is not supported by windows runners")
+	}
+
+	if rs.DockerdWithinRunnerContainer != nil && *rs.DockerdWithinRunnerContainer {
+		return errors.New("dockerdWithinRunnerContainer is not supported by windows runners")
+	}
+
+	if rs.DockerEnabled != nil && *rs.DockerEnabled {
+		return errors.New("dockerEnabled is not supported by windows runners")
+	}
+
+	if rs.MaxJobDuration != nil || rs.JobHooks != nil || rs.LogForwarder != nil || rs.HealthCheck != nil {
+		return errors.New("maxJobDuration, hooks, logForwarder, and healthCheck are not supported by windows runners")
+	}
+
+	return nil
+}
+
+// ValidateSidecars validates sidecars field.
+// The names of the sidecars must not conflict with the containers added by actions-runner-controller,
+// and the working directory of Windows runners can't be shared as it's relative to the runner directory.
+func (rs *RunnerSpec) ValidateSidecars() error {
+	if len(rs.Sidecars) == 0 {
+		return nil
+	}
+
+	if rs.IsWindows() {
+		return errors.New("sidecars are not supported by windows runners")
+	}
+
+	names := map[string]bool{}
+
+	for _, c := range rs.Sidecars {
+		if c.Name == "" {
+			return errors.New("every sidecar needs a name")
+		}
+
+		if c.Name == "runner" || c.Name == "docker" || names[c.Name] {
+			return fmt.Errorf("sidecar name %q conflicts with another container", c.Name)
+		}
+
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+// ValidateJobHooks validates hooks field.
+func (rs *RunnerConfig) ValidateJobHooks() error {
+	if rs.JobHooks == nil {
+		return nil
+	}
+
+	if err := rs.JobHooks.PreJob.validate(); err != nil {
+		return fmt.Errorf("preJob %w", err)
+	}
+
+	if err := rs.JobHooks.PostJob.validate(); err != nil {
+		return fmt.Errorf("postJob %w", err)
+	}
+
+	return nil
+}
+
+func (s *JobHookSource) validate() error {
+	if s != nil && (s.ConfigMapKeyRef == nil) == (s.SecretKeyRef == nil) {
+		return errors.New("needs exactly one of configMapKeyRef and secretKeyRef")
+	}
+
+	return nil
+}
+
 // RunnerStatus defines the observed state of Runner
 type RunnerStatus struct {
 	// +optional
@@ -189,11 +616,57 @@ type RunnerStatus struct {
 	Reason string `json:"reason,omitempty"`
 	// +optional
 	Message string `json:"message,omitempty"`
+	// Ready is true when the runner pod is running and GitHub reports the runner as online,
+	// so that runners still registering themselves aren't counted as available.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
 	// +optional
 	// +nullable
 	LastRegistrationCheckTime *metav1.Time `json:"lastRegistrationCheckTime,omitempty"`
+	// RunnerID is the ID of the runner on GitHub observed on the last registration check, so that the next check
+	// gets the single runner by the ID instead of listing every runner in the enterprise, organization or repository.
+	// +optional
+	RunnerID int64 `json:"runnerID,omitempty"`
+	// BusyChecks is the number of times GitHub reported the runner as busy while unregistering it for deletion.
+	// +optional
+	BusyChecks int `json:"busyChecks,omitempty"`
+	// +optional
+	// +nullable
+	LastBusyCheckTime *metav1.Time `json:"lastBusyCheckTime,omitempty"`
+	// Conditions represent the latest observations of the runner, like whether it has been unregistered from GitHub.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// RunnerConditionTypeUnregistered is the condition type that reports the progress of the unregistration before deletion.
+	RunnerConditionTypeUnregistered = "Unregistered"
+
+	RunnerConditionReasonRunnerBusy                = "RunnerBusy"
+	RunnerConditionReasonBusyWaitTimedOut          = "BusyWaitTimedOut"
+	RunnerConditionReasonBusyCheckRetriesExhausted = "BusyCheckRetriesExhausted"
+	RunnerConditionReasonBusyRunnerForceDeleted    = "BusyRunnerForceDeleted"
+	RunnerConditionReasonUnregistrationSkipped     = "UnregistrationSkipped"
+	RunnerConditionReasonUnregistrationTimedOut    = "UnregistrationTimedOut"
+
+	// RunnerConditionTypeHealthy is the condition type that reports the result of the health check of the runner container.
+	// See RunnerHealthCheck.
+	RunnerConditionTypeHealthy = "Healthy"
+
+	RunnerConditionReasonHealthCheckPassed = "HealthCheckPassed"
+	RunnerConditionReasonHealthCheckFailed = "HealthCheckFailed"
+	// RunnerConditionReasonRunnerRecycled is the reason of the unhealthy runner stopped after the job to be recreated.
+	RunnerConditionReasonRunnerRecycled = "RunnerRecycled"
+
+	// RunnerConditionTypeFeaturesSupported is the condition type that reports the features of the runner that
+	// the GitHub server lacks, and how the runner falls back. It's false only while a feature is unsupported.
+	RunnerConditionTypeFeaturesSupported = "FeaturesSupported"
+
+	RunnerConditionReasonEphemeralRunnersUnsupported = "EphemeralRunnersUnsupported"
+)
+
 // RunnerStatusRegistration contains runner registration status
 type RunnerStatusRegistration struct {
 	Enterprise   string      `json:"enterprise,omitempty"`
@@ -76,6 +76,18 @@ func (r *Runner) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "repository"), r.Spec.Repository, err.Error()))
 	}
 
+	if err := r.Spec.ValidateJobHooks(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "hooks"), r.Spec.JobHooks, err.Error()))
+	}
+
+	if err := r.Spec.ValidateOS(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "os"), r.Spec.OS, err.Error()))
+	}
+
+	if err := r.Spec.ValidateSidecars(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "sidecars"), r.Spec.Sidecars, err.Error()))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
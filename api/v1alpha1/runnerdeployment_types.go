@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -35,8 +36,161 @@ type RunnerDeploymentSpec struct {
 	// +nullable
 	Selector *metav1.LabelSelector `json:"selector"`
 	Template RunnerTemplate        `json:"template"`
+
+	// GitHubTeardownPolicy determines what happens to the GitHub-side artifacts of this RunnerDeployment on deletion.
+	// "Retain", the default, leaves them as-is.
+	// "Delete" makes the controller wait for all the runners to terminate, and then unregisters the runners of
+	// this RunnerDeployment that are still registered on GitHub, like the ones left behind by uncleanly terminated pods.
+	// "DryRun" only reports what "Delete" would remove, via events and logs.
+	// +optional
+	// +kubebuilder:validation:Enum=Retain;Delete;DryRun
+	GitHubTeardownPolicy string `json:"githubTeardownPolicy,omitempty"`
+
+	// ScaleDownProtectionAfterRollout is the duration after each rollout of a new template during which
+	// HorizontalRunnerAutoscaler never scales this RunnerDeployment down.
+	// Right after a rollout the new runners have not picked up any jobs yet, so that the workflow metrics look idle
+	// and would otherwise result in a premature scale down.
+	// +optional
+	// +nullable
+	ScaleDownProtectionAfterRollout *metav1.Duration `json:"scaleDownProtectionAfterRollout,omitempty"`
+
+	// WarmPool keeps a number of registered idle runners on top of Replicas, so that jobs are picked up without waiting
+	// for new runners to start. The warm runners aren't part of Replicas, which is what HorizontalRunnerAutoscaler sets,
+	// and the runners consumed by jobs are backfilled.
+	// +optional
+	WarmPool *WarmPoolSpec `json:"warmPool,omitempty"`
+
+	// Strategy is how the runners are replaced with the ones of an updated template.
+	// When unset, the runner replica set of the updated template is created with all the replicas at once,
+	// and the old runner replica sets are deleted once all the new runners are ready.
+	// +optional
+	Strategy *RunnerDeploymentStrategy `json:"strategy,omitempty"`
+
+	// Paused freezes the runner replica sets of this RunnerDeployment, like during a maintenance window,
+	// so that neither the changes of the replicas nor the updates of the template are applied until it's unpaused.
+	// The status keeps being updated.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// RunnerVersionUpgrade makes the runners upgraded automatically when a new version of actions/runner is published,
+	// by rolling out the template with the runner image of the new version according to Strategy.
+	// It requires the controller to be configured to watch the releases of actions/runner.
+	// +optional
+	RunnerVersionUpgrade *RunnerVersionUpgradeSpec `json:"runnerVersionUpgrade,omitempty"`
+}
+
+// RunnerVersionPlaceholder is replaced with the runner version in RunnerVersionUpgradeSpec.Image.
+const RunnerVersionPlaceholder = "{version}"
+
+// RunnerVersionUpgradeSpec configures the automatic runner version upgrades of a RunnerDeployment.
+type RunnerVersionUpgradeSpec struct {
+	// Image is the runner image of each version, in which `{version}` is replaced with the version without the `v` prefix,
+	// like `summerwind/actions-runner:v{version}-ubuntu-20.04`. It overrides the image of the template.
+	Image string `json:"image"`
+
+	// Channel pins the upgrades to the versions that have the prefix, like "2.290" for the patch releases of 2.290 only.
+	// When empty, the runners are upgraded to the latest release.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window of time in which the automatic runner version upgrades are rolled out.
+type MaintenanceWindow struct {
+	// StartTime is the time at which the first window starts.
+	StartTime metav1.Time `json:"startTime"`
+
+	// EndTime is the time at which the first window ends.
+	EndTime metav1.Time `json:"endTime"`
+
+	// +optional
+	RecurrenceRule RecurrenceRule `json:"recurrenceRule,omitempty"`
+}
+
+const (
+	RunnerDeploymentStrategyTypeRollingUpdate = "RollingUpdate"
+	RunnerDeploymentStrategyTypeCanary        = "Canary"
+)
+
+// RunnerDeploymentStrategy configures the rollout of an updated template, mirroring the strategy of apps/v1 Deployment.
+type RunnerDeploymentStrategy struct {
+	// Type is either "RollingUpdate", the default, or "Canary".
+	// "RollingUpdate" gradually scales the runner replica set of the updated template up and the old ones down within
+	// the bounds of RollingUpdate.
+	// "Canary" first creates Canary.Replicas runners from the updated template alongside the old runners,
+	// and rolls the rest out the same way as "RollingUpdate" once the canary is promoted.
+	// +optional
+	// +kubebuilder:validation:Enum=RollingUpdate;Canary
+	Type string `json:"type,omitempty"`
+
+	// RollingUpdate bounds the number of the runners during the rollout.
+	// +optional
+	RollingUpdate *RollingUpdateRunnerDeployment `json:"rollingUpdate,omitempty"`
+
+	// Canary configures the canary runners. Required for the "Canary" type.
+	// +optional
+	Canary *CanaryRunnerDeployment `json:"canary,omitempty"`
+
+	// MaintenanceWindows are the windows of time in which the upgrades by RunnerVersionUpgrade are started.
+	// When empty, the upgrades are started as soon as the new versions are published.
+	// The updates of the template made by users are rolled out regardless of the windows.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// RollingUpdateRunnerDeployment bounds the number of the runners during the rollout of a RunnerDeployment.
+type RollingUpdateRunnerDeployment struct {
+	// MaxSurge is the maximum number of the runners that can be created above the desired replicas during the rollout.
+	// A percentage of the desired replicas is rounded up. Defaults to 25%.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is the maximum number of the runners that can be unavailable below the desired replicas during the rollout.
+	// A percentage of the desired replicas is rounded down. Defaults to 25%.
+	// This can't be 0 if MaxSurge is 0.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// CanaryRunnerDeployment configures the canary runners of a RunnerDeployment.
+type CanaryRunnerDeployment struct {
+	// Replicas is the number of the runners created from the updated template before it's promoted.
+	// +kubebuilder:validation:Minimum=1
+	Replicas int `json:"replicas"`
+
+	// SoakTime is how long all the canary runners need to have been available before the canary is promoted automatically.
+	// When unset, the canary is promoted only manually, by annotating the RunnerDeployment with
+	// actions-runner-controller/promote-canary set to status.canary.templateHash.
+	// +optional
+	// +nullable
+	SoakTime *metav1.Duration `json:"soakTime,omitempty"`
+}
+
+// WarmPoolSpec configures the warm pool of a RunnerDeployment.
+type WarmPoolSpec struct {
+	// Replicas is the number of idle runners kept on top of the replicas of the RunnerDeployment.
+	// +kubebuilder:validation:Minimum=0
+	Replicas int `json:"replicas"`
+
+	// SyncPeriod is how often the busy runners are listed on GitHub to backfill the warm pool. Defaults to 1m.
+	// +optional
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
 }
 
+// WarmPoolStatus is the state of the warm pool last observed on GitHub.
+type WarmPoolStatus struct {
+	// BusyReplicas is the number of the runners of the RunnerDeployment that were running jobs.
+	BusyReplicas int `json:"busyReplicas"`
+
+	// LastSyncTime is the time the busy runners were listed on GitHub.
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+}
+
+const (
+	GitHubTeardownPolicyRetain = "Retain"
+	GitHubTeardownPolicyDelete = "Delete"
+	GitHubTeardownPolicyDryRun = "DryRun"
+)
+
 type RunnerDeploymentStatus struct {
 	// See K8s deployment controller code for reference
 	// https://github.com/kubernetes/kubernetes/blob/ea0764452222146c47ec826977f49d7001b0ea8c/pkg/controller/deployment/sync.go#L487-L505
@@ -46,12 +200,17 @@ type RunnerDeploymentStatus struct {
 	// +optional
 	AvailableReplicas *int `json:"availableReplicas"`
 
-	// ReadyReplicas is the total number of available runners which have been successfully registered to GitHub and still running.
+	// ReadyReplicas is the total number of runners whose pods are running, including the ones still registering to GitHub.
 	// This corresponds to the sum of status.readyReplicas of all the runner replica sets.
 	// +optional
 	ReadyReplicas *int `json:"readyReplicas"`
 
-	// ReadyReplicas is the total number of available runners which have been successfully registered to GitHub and still running.
+	// UnavailableReplicas is the total number of runners that are still required for the deployment to have 100% available capacity,
+	// which is the desired replicas minus the available replicas, or zero.
+	// +optional
+	UnavailableReplicas *int `json:"unavailableReplicas"`
+
+	// UpdatedReplicas is the total number of runners created from the current template.
 	// This corresponds to status.replicas of the runner replica set that has the desired template hash.
 	// +optional
 	UpdatedReplicas *int `json:"updatedReplicas"`
@@ -64,13 +223,126 @@ type RunnerDeploymentStatus struct {
 	// Replicas is the total number of replicas
 	// +optional
 	Replicas *int `json:"replicas"`
+
+	// UnschedulableReplicas is the total number of runners whose pods are Pending because the scheduler found no node for them.
+	// This corresponds to the sum of status.unschedulableReplicas of all the runner replica sets.
+	// +optional
+	UnschedulableReplicas *int `json:"unschedulableReplicas,omitempty"`
+
+	// PreemptingReplicas is the total number of the unschedulable runners whose pods are waiting for preemption.
+	// This corresponds to the sum of status.preemptingReplicas of all the runner replica sets.
+	// +optional
+	PreemptingReplicas *int `json:"preemptingReplicas,omitempty"`
+
+	// TerminatingReplicas is the total number of runners being deleted.
+	// This corresponds to the sum of status.terminatingReplicas of all the runner replica sets.
+	// +optional
+	TerminatingReplicas *int `json:"terminatingReplicas,omitempty"`
+
+	// LastRolloutTime is the time the runner replica set for the current template was created,
+	// which is either the creation of this RunnerDeployment or the last template update.
+	// +optional
+	// +nullable
+	LastRolloutTime *metav1.Time `json:"lastRolloutTime,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the controller.
+	// The rest of the status reflects the current spec only when this equals metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions are the latest observations of the rollout, with the Available and Progressing types
+	// and reasons of apps/v1 Deployment, so that standard rollout tooling can tell whether the rollout has completed.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// WarmPool is the state of the warm pool, set only when the warm pool is enabled.
+	// +optional
+	WarmPool *WarmPoolStatus `json:"warmPool,omitempty"`
+
+	// Canary is the state of the canary of the last template rolled out with the "Canary" strategy.
+	// +optional
+	Canary *CanaryStatus `json:"canary,omitempty"`
+
+	// Usage is the usage of the runners in each of the last 7 days, oldest first.
+	// It's set only when the controller is configured to write the daily usage summaries.
+	// +optional
+	Usage []RunnerUsageSummary `json:"usage,omitempty"`
+
+	// RunnerVersion is the version of actions/runner that the runners are upgraded to by RunnerVersionUpgrade.
+	// +optional
+	RunnerVersion string `json:"runnerVersion,omitempty"`
+}
+
+// RunnerUsageSummary is the usage of the runners of a RunnerDeployment in a day, for attributing the cost of CI.
+type RunnerUsageSummary struct {
+	// Date is the day in UTC, like 2022-03-01.
+	Date string `json:"date"`
+
+	// RunnerSeconds is the total seconds the runner pods were running.
+	RunnerSeconds int64 `json:"runnerSeconds"`
+
+	// BusySeconds is the total seconds the runners were busy running jobs on GitHub.
+	BusySeconds int64 `json:"busySeconds"`
+
+	// Jobs is the number of jobs the runners were observed to start.
+	Jobs int `json:"jobs"`
+}
+
+// CanaryStatus is the state of the canary of a template.
+type CanaryStatus struct {
+	// TemplateHash is the hash of the template of the canary runners,
+	// which is also the value of the annotation that promotes the canary manually.
+	TemplateHash string `json:"templateHash"`
+
+	// AvailableTime is the time all the canary runners became available, from which the soak time is counted.
+	// +optional
+	// +nullable
+	AvailableTime *metav1.Time `json:"availableTime,omitempty"`
+
+	// Promoted is true once the canary is promoted and the rest of the runners are rolled out.
+	// +optional
+	Promoted bool `json:"promoted,omitempty"`
 }
 
+const (
+	// RunnerDeploymentConditionTypeAvailable is true when the desired number of runners are available.
+	RunnerDeploymentConditionTypeAvailable = "Available"
+	// RunnerDeploymentConditionTypeProgressing is true while and after rolling out the current template.
+	// Its reason is NewReplicaSetAvailable once the rollout has completed.
+	RunnerDeploymentConditionTypeProgressing = "Progressing"
+
+	RunnerDeploymentConditionReasonMinimumReplicasAvailable   = "MinimumReplicasAvailable"
+	RunnerDeploymentConditionReasonMinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+	RunnerDeploymentConditionReasonReplicaSetUpdated          = "ReplicaSetUpdated"
+	RunnerDeploymentConditionReasonNewReplicaSetAvailable     = "NewReplicaSetAvailable"
+	RunnerDeploymentConditionReasonDeploymentPaused           = "DeploymentPaused"
+
+	// RunnerDeploymentConditionTypeLimited is true while the replicas are limited by a RunnerQuota.
+	// It's set only while any RunnerQuota covers the runner deployment.
+	RunnerDeploymentConditionTypeLimited = "Limited"
+
+	RunnerDeploymentConditionReasonRunnerQuotaExceeded = "RunnerQuotaExceeded"
+	RunnerDeploymentConditionReasonWithinRunnerQuota   = "WithinRunnerQuota"
+
+	// RunnerDeploymentConditionTypeRunnerGroupValid is true when the runner group exists and the repositories the runners
+	// are scaled for have access to it. It's set only when the runner deployment specifies the runner group.
+	RunnerDeploymentConditionTypeRunnerGroupValid = "RunnerGroupValid"
+
+	RunnerDeploymentConditionReasonRunnerGroupFound                  = "RunnerGroupFound"
+	RunnerDeploymentConditionReasonRunnerGroupNotFound               = "RunnerGroupNotFound"
+	RunnerDeploymentConditionReasonRunnerGroupRepositoryAccessDenied = "RunnerGroupRepositoryAccessDenied"
+	RunnerDeploymentConditionReasonRunnerGroupNotApplicable          = "RunnerGroupNotApplicable"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=rdeploy
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas
 // +kubebuilder:printcolumn:JSONPath=".spec.replicas",name=Desired,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.replicas",name=Current,type=number
+// +kubebuilder:printcolumn:JSONPath=".status.readyReplicas",name=Ready,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.updatedReplicas",name=Up-To-Date,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.availableReplicas",name=Available,type=number
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
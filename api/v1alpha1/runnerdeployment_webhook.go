@@ -17,8 +17,13 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -76,9 +81,101 @@ func (r *RunnerDeployment) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "repository"), r.Spec.Template.Spec.Repository, err.Error()))
 	}
 
+	errList = append(errList, validateSelectorMatchesTemplateLabels(r.Spec.Selector, r.Spec.Template.ObjectMeta.Labels, field.NewPath("spec"))...)
+
+	if err := r.Spec.Template.Spec.ValidateJobHooks(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "hooks"), r.Spec.Template.Spec.JobHooks, err.Error()))
+	}
+
+	if err := r.Spec.Template.Spec.ValidateOS(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "os"), r.Spec.Template.Spec.OS, err.Error()))
+	}
+
+	if err := r.Spec.Template.Spec.ValidateSidecars(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "sidecars"), r.Spec.Template.Spec.Sidecars, err.Error()))
+	}
+
+	errList = append(errList, validateRunnerDeploymentStrategy(r.Spec.Strategy, field.NewPath("spec", "strategy"))...)
+
+	if u := r.Spec.RunnerVersionUpgrade; u != nil && !strings.Contains(u.Image, RunnerVersionPlaceholder) {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "runnerVersionUpgrade", "image"), u.Image, "must contain "+RunnerVersionPlaceholder))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
 
 	return nil
 }
+
+// validateSelectorMatchesTemplateLabels ensures that the runners created from the template are matched by the selector.
+// Otherwise the runners get orphaned, as the controllers and the autoscaler find runners by the selector.
+// A nil selector is valid, because the controller defaults it to the selector that matches the template.
+func validateSelectorMatchesTemplateLabels(selector *metav1.LabelSelector, templateLabels map[string]string, specPath *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	if selector == nil {
+		return nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return append(errList, field.Invalid(specPath.Child("selector"), selector, err.Error()))
+	}
+
+	if s.Empty() {
+		return append(errList, field.Invalid(specPath.Child("selector"), selector, "empty selector is invalid"))
+	}
+
+	if !s.Matches(labels.Set(templateLabels)) {
+		errList = append(errList, field.Invalid(specPath.Child("template", "metadata", "labels"), templateLabels, "`selector` does not match template `labels`"))
+	}
+
+	return errList
+}
+
+// validateRunnerDeploymentStrategy rejects the strategies the rollout can't follow,
+// the same way as the validation of the strategy of apps/v1 Deployment.
+func validateRunnerDeploymentStrategy(strategy *RunnerDeploymentStrategy, path *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	if strategy == nil {
+		return nil
+	}
+
+	if strategy.Type == RunnerDeploymentStrategyTypeCanary && strategy.Canary == nil {
+		errList = append(errList, field.Required(path.Child("canary"), "required for the Canary strategy"))
+	}
+
+	if ru := strategy.RollingUpdate; ru != nil {
+		surge, err := validateIntOrPercent(ru.MaxSurge, path.Child("rollingUpdate", "maxSurge"))
+		errList = append(errList, err...)
+
+		unavailable, err := validateIntOrPercent(ru.MaxUnavailable, path.Child("rollingUpdate", "maxUnavailable"))
+		errList = append(errList, err...)
+
+		if ru.MaxSurge != nil && ru.MaxUnavailable != nil && surge == 0 && unavailable == 0 {
+			errList = append(errList, field.Invalid(path.Child("rollingUpdate", "maxUnavailable"), ru.MaxUnavailable.String(), "may not be 0 when maxSurge is 0"))
+		}
+	}
+
+	return errList
+}
+
+// validateIntOrPercent returns the value scaled to 100, so that a percentage can be compared with 0.
+func validateIntOrPercent(v *intstr.IntOrString, path *field.Path) (int, field.ErrorList) {
+	if v == nil {
+		return 0, nil
+	}
+
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(v, 100, true)
+	if err != nil {
+		return 0, field.ErrorList{field.Invalid(path, v.String(), err.Error())}
+	}
+
+	if scaled < 0 {
+		return 0, field.ErrorList{field.Invalid(path, v.String(), "must be greater than or equal to 0")}
+	}
+
+	return scaled, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunnerFleetCounts are the numbers of runners and the demand for them, summed over a set of scale targets.
+type RunnerFleetCounts struct {
+	// Runners is the number of runners, including the ones still starting up.
+	Runners int `json:"runners"`
+
+	// ReadyRunners is the number of runners registered to GitHub and ready to run jobs.
+	ReadyRunners int `json:"readyRunners"`
+
+	// BusyRunners is the number of runners running workflow jobs, as recorded by the webhook-based autoscaler.
+	BusyRunners int `json:"busyRunners"`
+
+	// BusyPercent is the percentage of BusyRunners in Runners, rounded down.
+	BusyPercent int `json:"busyPercent"`
+
+	// IdleRunners is the number of ready runners that aren't running workflow jobs.
+	IdleRunners int `json:"idleRunners"`
+
+	// QueuedDemand is the number of runners reserved for the queued workflow jobs that no runner has picked up yet.
+	QueuedDemand int `json:"queuedDemand"`
+
+	// DesiredRunners is the sum of the desired replicas computed by the HorizontalRunnerAutoscalers.
+	DesiredRunners int `json:"desiredRunners"`
+
+	// UnschedulableRunners is the number of runner pods pending because no node can fit them.
+	UnschedulableRunners int `json:"unschedulableRunners"`
+}
+
+// RunnerFleetNamespaceStatus is the breakdown of the fleet for a namespace.
+type RunnerFleetNamespaceStatus struct {
+	Namespace string `json:"namespace"`
+
+	RunnerFleetCounts `json:",inline"`
+}
+
+// RunnerFleetScaleTargetStatus is the breakdown of the fleet for a RunnerDeployment or a RunnerSet.
+type RunnerFleetScaleTargetStatus struct {
+	// Kind is either RunnerDeployment or RunnerSet.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// DesiredRunners is the desired replicas of the scale target.
+	DesiredRunners int `json:"desiredRunners"`
+
+	// ReadyRunners is the number of runners of the scale target that are ready to run jobs.
+	ReadyRunners int `json:"readyRunners"`
+
+	// BusyRunners is the number of runners running workflow jobs, as recorded by the webhook-based autoscaler
+	// of the HorizontalRunnerAutoscalers scaling the scale target.
+	BusyRunners int `json:"busyRunners"`
+
+	// IdleRunners is the number of ready runners that aren't running workflow jobs.
+	IdleRunners int `json:"idleRunners"`
+}
+
+// GitHubAPIBudget is the rate limit of the GitHub API last observed by the controller.
+type GitHubAPIBudget struct {
+	// Limit is the maximum number of requests permitted per hour.
+	Limit int `json:"limit"`
+
+	// Remaining is the number of requests remaining in the current rate limit window.
+	Remaining int `json:"remaining"`
+
+	// ResetTime is the time the current rate limit window resets.
+	// +optional
+	// +nullable
+	ResetTime *metav1.Time `json:"resetTime,omitempty"`
+
+	// ObservedTime is the time of the GitHub API response the budget is read from.
+	ObservedTime metav1.Time `json:"observedTime"`
+}
+
+type RunnerFleetStatusStatus struct {
+	// ObservedTime is the time the status was aggregated.
+	// +optional
+	// +nullable
+	ObservedTime *metav1.Time `json:"observedTime,omitempty"`
+
+	// RunnerFleetCounts are the totals across all the namespaces.
+	RunnerFleetCounts `json:",inline"`
+
+	// APIBudget is nil until the controller receives a response of the GitHub API.
+	// +optional
+	APIBudget *GitHubAPIBudget `json:"apiBudget,omitempty"`
+
+	// Namespaces are the breakdowns per namespace, sorted by namespace.
+	// Only the namespaces with any runner, RunnerSet or HorizontalRunnerAutoscaler are listed.
+	// +optional
+	Namespaces []RunnerFleetNamespaceStatus `json:"namespaces,omitempty"`
+
+	// ScaleTargets are the breakdowns per RunnerDeployment and RunnerSet, sorted by namespace, kind and name.
+	// +optional
+	ScaleTargets []RunnerFleetScaleTargetStatus `json:"scaleTargets,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=fleet
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.runners",name=Runners,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.readyRunners",name=Ready,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.desiredRunners",name=Desired,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.busyRunners",name=Busy,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.idleRunners",name=Idle,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.busyPercent",name=Busy%,type=integer,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.queuedDemand",name=Queued,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.apiBudget.remaining",name=API Remaining,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.apiBudget.resetTime",name=API Reset,type=date,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.observedTime",name=Observed,type=date
+
+// RunnerFleetStatus is the summary of all the runners managed by the controller, aggregated across namespaces.
+// It's created and updated at an interval by the controller, so that dashboards can read the state of the fleet
+// with a single GET.
+type RunnerFleetStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status RunnerFleetStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerFleetStatusList contains a list of RunnerFleetStatus
+type RunnerFleetStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerFleetStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerFleetStatus{}, &RunnerFleetStatusList{})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunnerPoolSpec defines the desired state of RunnerPool
+type RunnerPoolSpec struct {
+	// Members are the RunnerDeployments in the same namespace that the webhook-based autoscaler distributes
+	// capacity reservations across.
+	// Each member needs its own HorizontalRunnerAutoscaler with a workflow_job scale up trigger.
+	// +kubebuilder:validation:MinItems=1
+	Members []RunnerPoolMember `json:"members"`
+
+	// Rebalance enables the rebalancer that gradually moves capacity reservations between the members with the same priority
+	// toward their weights while the pool is idle, so that a member doesn't stay larger than its share for long.
+	// +optional
+	Rebalance *RunnerPoolRebalanceSpec `json:"rebalance,omitempty"`
+}
+
+// RunnerPoolRebalanceSpec configures the rebalancer of a RunnerPool.
+type RunnerPoolRebalanceSpec struct {
+	// IdleFor is how long none of the members need to have scaled up before the rebalancer moves reservations.
+	// Defaults to 10m.
+	// +optional
+	IdleFor *metav1.Duration `json:"idleFor,omitempty"`
+
+	// MaxReplicasPerStep is the maximum number of reserved replicas moved between two members per minute.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicasPerStep *int `json:"maxReplicasPerStep,omitempty"`
+}
+
+// RunnerPoolMember is a RunnerDeployment in a RunnerPool.
+type RunnerPoolMember struct {
+	// RunnerDeployment is the name of the RunnerDeployment.
+	RunnerDeployment string `json:"runnerDeployment"`
+
+	// Priority orders the members. Capacity is reserved on the members with the highest priority
+	// until all of them reach their maxReplicas, and then on the members with the next highest priority.
+	// Defaults to 0.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// Weight is the share of the capacity reserved on this member among the members with the same priority.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Weight *int `json:"weight,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rp
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// RunnerPool groups RunnerDeployments, like the ones for spot and on-demand node pools,
+// so that the webhook-based autoscaler distributes capacity reservations for workflow jobs across them.
+type RunnerPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RunnerPoolSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerPoolList contains a list of RunnerPool
+type RunnerPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerPool{}, &RunnerPoolList{})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunnerQuotaSpec defines the desired state of RunnerQuota
+type RunnerQuotaSpec struct {
+	// Organization limits the quota to the RunnerDeployments of the organization, including the ones of its repositories.
+	// When empty, the quota covers all the RunnerDeployments in the namespace.
+	// +optional
+	Organization string `json:"organization,omitempty"`
+
+	// Hard is the limit of the total of the runners of all the RunnerDeployments covered by the quota.
+	Hard RunnerQuotaLimits `json:"hard"`
+}
+
+// RunnerQuotaLimits are the limits of a RunnerQuota. The unset limits aren't enforced.
+type RunnerQuotaLimits struct {
+	// Replicas is the maximum number of runners.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int `json:"replicas,omitempty"`
+
+	// CPU is the maximum sum of the CPU requests of the runner pods.
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory is the maximum sum of the memory requests of the runner pods.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+}
+
+// RunnerQuotaStatus defines the observed state of RunnerQuota
+type RunnerQuotaStatus struct {
+	// Used is the total of the runners of all the RunnerDeployments covered by the quota.
+	// +optional
+	Used RunnerQuotaUsage `json:"used,omitempty"`
+}
+
+// RunnerQuotaUsage is the usage of a RunnerQuota.
+type RunnerQuotaUsage struct {
+	// Replicas is the number of desired runners.
+	Replicas int `json:"replicas"`
+
+	// CPU is the sum of the CPU requests of the desired runner pods.
+	// +optional
+	CPU resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory is the sum of the memory requests of the desired runner pods.
+	// +optional
+	Memory resource.Quantity `json:"memory,omitempty"`
+}
+
+// CoversOrganization returns true when the quota covers the runners registered to the organization or the repository.
+func (q RunnerQuota) CoversOrganization(organization, repository string) bool {
+	if q.Spec.Organization == "" {
+		return true
+	}
+
+	if organization != "" {
+		return organization == q.Spec.Organization
+	}
+
+	return strings.SplitN(repository, "/", 2)[0] == q.Spec.Organization
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=rquota
+// +kubebuilder:printcolumn:JSONPath=".spec.organization",name=Organization,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.used.replicas",name=Used,type=number
+// +kubebuilder:printcolumn:JSONPath=".spec.hard.replicas",name=Hard,type=number
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// RunnerQuota limits the total replicas, CPU and memory of the runners of the RunnerDeployments in its namespace,
+// optionally only the ones of a GitHub organization, so that a single team can't consume the entire shared cluster.
+type RunnerQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RunnerQuotaSpec   `json:"spec,omitempty"`
+	Status RunnerQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerQuotaList contains a list of RunnerQuota
+type RunnerQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerQuota{}, &RunnerQuotaList{})
+}
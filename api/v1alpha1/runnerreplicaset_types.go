@@ -46,6 +46,20 @@ type RunnerReplicaSetStatus struct {
 	// AvailableReplicas is the number of runners that are created and Runnning.
 	// This is currently same as ReadyReplicas but perserved for future use.
 	AvailableReplicas *int `json:"availableReplicas"`
+
+	// UnschedulableReplicas is the number of runners whose pods are Pending because the scheduler found no node for them.
+	// +optional
+	UnschedulableReplicas *int `json:"unschedulableReplicas,omitempty"`
+
+	// PreemptingReplicas is the number of the unschedulable runners whose pods are waiting for
+	// lower-priority pods to be preempted from the nodes nominated for them. See RunnerPodSpec.PriorityClassName.
+	// +optional
+	PreemptingReplicas *int `json:"preemptingReplicas,omitempty"`
+
+	// TerminatingReplicas is the number of runners being deleted, which are unregistered from GitHub
+	// and then have their pods deleted. It tells the progress of a scale down.
+	// +optional
+	TerminatingReplicas *int `json:"terminatingReplicas,omitempty"`
 }
 
 type RunnerTemplate struct {
@@ -76,6 +76,20 @@ func (r *RunnerReplicaSet) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "repository"), r.Spec.Template.Spec.Repository, err.Error()))
 	}
 
+	errList = append(errList, validateSelectorMatchesTemplateLabels(r.Spec.Selector, r.Spec.Template.ObjectMeta.Labels, field.NewPath("spec"))...)
+
+	if err := r.Spec.Template.Spec.ValidateJobHooks(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "hooks"), r.Spec.Template.Spec.JobHooks, err.Error()))
+	}
+
+	if err := r.Spec.Template.Spec.ValidateOS(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "os"), r.Spec.Template.Spec.OS, err.Error()))
+	}
+
+	if err := r.Spec.Template.Spec.ValidateSidecars(); err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "sidecars"), r.Spec.Template.Spec.Sidecars, err.Error()))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookSourceSpec defines the webhooks that the WebhookSource matches, and how their deliveries are validated.
+type WebhookSourceSpec struct {
+	// HookIDs are the IDs of the GitHub webhooks, sent in the X-GitHub-Hook-ID header of every delivery.
+	// They take precedence over Organizations, so that the webhooks of the same organization can have different secrets.
+	// +optional
+	HookIDs []int64 `json:"hookIDs,omitempty"`
+
+	// Organizations are the GitHub organizations, or the owners of the repositories, whose webhook events the WebhookSource matches.
+	// +optional
+	Organizations []string `json:"organizations,omitempty"`
+
+	// SecretRef is the Secret in the namespace of the WebhookSource that contains the secret tokens of the webhooks.
+	SecretRef WebhookSourceSecretRef `json:"secretRef"`
+
+	// Namespaces limits the HorizontalRunnerAutoscalers scaled on the webhook events to the ones in the namespaces.
+	// When empty, only the HorizontalRunnerAutoscalers in the namespace of the WebhookSource are scaled.
+	// Include "*" to scale the HorizontalRunnerAutoscalers in all the namespaces watched by the webhook server.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// WebhookSourceSecretRef refers to the Secret that contains the secret tokens of the webhooks.
+type WebhookSourceSecretRef struct {
+	Name string `json:"name"`
+
+	// Key is the key of the secret tokens in the Secret, one per line, so that a token can be rotated
+	// by adding the new one next to the current one. Defaults to github_webhook_secret_token.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=whsource
+// +kubebuilder:printcolumn:JSONPath=".spec.organizations",name=Organizations,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.hookIDs",name=Hook IDs,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// WebhookSource lets a single webhook server receive the webhooks of multiple GitHub organizations or GitHub Apps
+// that are signed with different secret tokens, optionally scaling only the HorizontalRunnerAutoscalers in some namespaces.
+type WebhookSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WebhookSourceSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WebhookSourceList contains a list of WebhookSource
+type WebhookSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WebhookSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WebhookSource{}, &WebhookSourceList{})
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// WorkflowJobTraceSpec is the record of a GitHub Actions workflow job run by a runner pod.
+type WorkflowJobTraceSpec struct {
+	// JobID is the ID of the workflow job on GitHub.
+	JobID int64 `json:"jobID"`
+
+	// RunID is the ID of the workflow run that the job belongs to.
+	// +optional
+	RunID int64 `json:"runID,omitempty"`
+
+	// Repository is the full name of the repository, like OWNER/REPO.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// JobName is the name of the job in the workflow.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// HTMLURL is the URL of the job on GitHub.
+	// +optional
+	HTMLURL string `json:"htmlURL,omitempty"`
+
+	// Labels are the runs-on labels of the job.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// RunnerName is the name of the runner that ran the job, which is also the name of the runner pod.
+	RunnerName string `json:"runnerName"`
+
+	// PodUID is the UID of the runner pod, to tell the pod from the one recreated with the same name.
+	// +optional
+	PodUID types.UID `json:"podUID,omitempty"`
+
+	// NodeName is the name of the node the runner pod ran on.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// RunnerDeployment is the name of the RunnerDeployment that the runner belongs to, if any.
+	// +optional
+	RunnerDeployment string `json:"runnerDeployment,omitempty"`
+
+	// RunnerSet is the name of the RunnerSet that the runner belongs to, if any.
+	// +optional
+	RunnerSet string `json:"runnerSet,omitempty"`
+
+	// +optional
+	// +nullable
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// +optional
+	// +nullable
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// Conclusion is the conclusion of the job, like success and failure. It's empty until the job completes.
+	// +optional
+	Conclusion string `json:"conclusion,omitempty"`
+
+	// ExpirationTime is the time after which the record is deleted.
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=wjt
+// +kubebuilder:printcolumn:JSONPath=".spec.repository",name=Repository,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.runnerName",name=Runner,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.nodeName",name=Node,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.conclusion",name=Conclusion,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// WorkflowJobTrace links a GitHub Actions workflow job to the runner pod that ran it, for incident response.
+// It's named after the ID of the job, and created in the namespace of the runner pod by the webhook-based autoscaler.
+type WorkflowJobTrace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkflowJobTraceSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkflowJobTraceList contains a list of WorkflowJobTrace
+type WorkflowJobTraceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkflowJobTrace `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkflowJobTrace{}, &WorkflowJobTraceList{})
+}
@@ -110,4 +110,73 @@ Create the name of the service account to use
 
 {{- define "actions-runner-controller.pdbName" -}}
 {{- include "actions-runner-controller.fullname" . | trunc 59 }}-pdb
-{{- end }}
\ No newline at end of file
+{{- end }}
+
+{{- define "actions-runner-controller.cacheServerName" -}}
+{{- include "actions-runner-controller.fullname" . | trunc 50 }}-cache-server
+{{- end }}
+
+{{/*
+Selector labels of the bundled cache server.
+The instance label differs from the controller manager's, so that the deployments don't select each other's pods.
+*/}}
+{{- define "actions-runner-controller.cacheServerSelectorLabels" -}}
+app.kubernetes.io/name: {{ include "actions-runner-controller.name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}-cache-server
+{{- end }}
+
+{{/*
+The base URL of the cache server the runners use by default, which is the bundled cache server unless set explicitly.
+*/}}
+{{- define "actions-runner-controller.runnerCacheURL" -}}
+{{- if .Values.runnerCache.url }}
+{{- .Values.runnerCache.url }}
+{{- else if .Values.runnerCache.server.enabled }}
+{{- printf "http://%s.%s.svc:%v/" (include "actions-runner-controller.cacheServerName" .) .Release.Namespace .Values.runnerCache.server.port }}
+{{- end }}
+{{- end }}
+
+{{- define "actions-runner-controller.admissionWebhooksFullname" -}}
+{{- include "actions-runner-controller.fullname" . | trunc 44 }}-admission-webhooks
+{{- end }}
+
+{{/*
+Selector labels of the admission webhooks deployment.
+The instance label differs from the controller manager's, so that the deployments don't select each other's pods.
+*/}}
+{{- define "actions-runner-controller.admissionWebhooksSelectorLabels" -}}
+app.kubernetes.io/name: {{ include "actions-runner-controller.name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}-admission-webhooks
+{{- end }}
+
+{{- define "actions-runner-controller.admissionWebhooksServiceAccountName" -}}
+{{- if .Values.admissionWebHooks.serviceAccount.create }}
+{{- default (include "actions-runner-controller.admissionWebhooksFullname" .) .Values.admissionWebHooks.serviceAccount.name }}
+{{- else }}
+{{- default "default" .Values.admissionWebHooks.serviceAccount.name }}
+{{- end }}
+{{- end }}
+
+{{- define "actions-runner-controller.admissionWebhooksRoleName" -}}
+{{- include "actions-runner-controller.admissionWebhooksFullname" . }}
+{{- end }}
+
+{{/*
+The comma-separated namespaces watched by the controller and the github webhook server when scope.namespaced is true
+*/}}
+{{- define "actions-runner-controller.watchNamespaces" -}}
+{{- if .Values.scope.watchNamespaces }}
+{{- join "," .Values.scope.watchNamespaces }}
+{{- else }}
+{{- .Release.Namespace }}
+{{- end }}
+{{- end }}
+
+{{/*
+The namespaces to create Roles and RoleBindings in. An empty string stands for a ClusterRole and a ClusterRoleBinding
+*/}}
+{{- define "actions-runner-controller.rbacNamespaces" -}}
+{{- if .Values.scope.namespaced }}
+{{- include "actions-runner-controller.watchNamespaces" . }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// arcctl is the command-line tool for operating actions-runner-controller.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/exec"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	_ = actionsv1alpha1.AddToScheme(scheme)
+}
+
+const usage = `arcctl is the command-line tool for operating actions-runner-controller.
+
+Usage:
+  arcctl lookup [-namespace NAMESPACE] [-o json] JOB_ID
+    Shows the runner pod that ran the GitHub Actions workflow job, from the WorkflowJobTrace
+    recorded by the github-webhook-server run with -workflow-job-trace-ttl.
+
+The cluster is chosen in the same way as kubectl, e.g. by the KUBECONFIG environment variable.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "lookup":
+		err = lookup(context.Background(), os.Stdout, os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func lookup(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+
+	namespace := fs.String("namespace", "", "The namespace of the runner pods. Defaults to searching all the namespaces")
+	output := fs.String("o", "", `The output format. Set to "json" to print the WorkflowJobTrace as-is`)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("lookup requires exactly one JOB_ID")
+	}
+
+	jobID := fs.Arg(0)
+
+	if _, err := strconv.ParseInt(jobID, 10, 64); err != nil {
+		return fmt.Errorf("invalid JOB_ID %q: %w", jobID, err)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("loading kubeconfig: %w", err)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	opts := []client.ListOption{client.MatchingFields{"metadata.name": jobID}}
+
+	if *namespace != "" {
+		opts = append(opts, client.InNamespace(*namespace))
+	}
+
+	var traces actionsv1alpha1.WorkflowJobTraceList
+
+	if err := c.List(ctx, &traces, opts...); err != nil {
+		return fmt.Errorf("listing workflowjobtraces: %w", err)
+	}
+
+	for _, trace := range traces.Items {
+		if trace.Name != jobID {
+			continue
+		}
+
+		if *output == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+
+			return enc.Encode(trace)
+		}
+
+		printWorkflowJobTrace(w, trace)
+
+		return nil
+	}
+
+	return fmt.Errorf("no workflowjobtrace found for job %s. It may have expired, or the job may have run on a runner not managed by actions-runner-controller", jobID)
+}
+
+func printWorkflowJobTrace(w io.Writer, trace actionsv1alpha1.WorkflowJobTrace) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	defer tw.Flush()
+
+	s := trace.Spec
+
+	fmt.Fprintf(tw, "Job:\t%d\t%s\n", s.JobID, s.JobName)
+	fmt.Fprintf(tw, "URL:\t%s\n", s.HTMLURL)
+	fmt.Fprintf(tw, "Repository:\t%s\n", s.Repository)
+	fmt.Fprintf(tw, "Run:\t%d\n", s.RunID)
+	fmt.Fprintf(tw, "Labels:\t%v\n", s.Labels)
+	fmt.Fprintf(tw, "Pod:\t%s/%s\t%s\n", trace.Namespace, s.RunnerName, s.PodUID)
+	fmt.Fprintf(tw, "Node:\t%s\n", s.NodeName)
+
+	if s.RunnerDeployment != "" {
+		fmt.Fprintf(tw, "RunnerDeployment:\t%s\n", s.RunnerDeployment)
+	}
+
+	if s.RunnerSet != "" {
+		fmt.Fprintf(tw, "RunnerSet:\t%s\n", s.RunnerSet)
+	}
+
+	fmt.Fprintf(tw, "Started:\t%s\n", formatTime(s.StartedAt))
+	fmt.Fprintf(tw, "Completed:\t%s\n", formatTime(s.CompletedAt))
+	fmt.Fprintf(tw, "Conclusion:\t%s\n", s.Conclusion)
+}
+
+func formatTime(t *metav1.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
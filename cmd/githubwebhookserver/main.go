@@ -29,15 +29,18 @@ import (
 	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/anomaly"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/audit"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/tracing"
 	"github.com/kelseyhightower/envconfig"
-	zaplib "go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/exec"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,12 +50,8 @@ var (
 )
 
 const (
-	logLevelDebug = "debug"
-	logLevelInfo  = "info"
-	logLevelWarn  = "warn"
-	logLevelError = "error"
-
-	webhookSecretTokenEnvName = "GITHUB_WEBHOOK_SECRET_TOKEN"
+	webhookSecretTokenEnvName         = "GITHUB_WEBHOOK_SECRET_TOKEN"
+	webhookPreviousSecretTokenEnvName = "GITHUB_WEBHOOK_PREVIOUS_SECRET_TOKEN"
 )
 
 func init() {
@@ -73,12 +72,42 @@ func main() {
 		webhookSecretToken    string
 		webhookSecretTokenEnv string
 
+		// The secret token that was used before the current one, accepted while rotating the token.
+		webhookPreviousSecretToken string
+
+		// The file that contains the secret tokens, one per line, re-read on change.
+		webhookSecretTokensFile string
+
+		requireWebhookSignature bool
+
+		enableWebhookSources bool
+		enableRunnerPools    bool
+
 		watchNamespace string
 
 		enableLeaderElection bool
 		syncPeriod           time.Duration
 		logLevel             string
 
+		logSampling          logging.SamplingOptions
+		logRateLimitInterval time.Duration
+
+		workflowJobTraceTTL time.Duration
+
+		enterpriseHostSlugs string
+
+		dryRun bool
+
+		runtimeSettingsFile string
+
+		anomalyNotificationConfig string
+
+		tracingOptions tracing.Options
+
+		auditLogSink string
+
+		unmatchedJobsBufferSize int
+
 		ghClient *github.Client
 	)
 
@@ -90,24 +119,51 @@ func main() {
 	}
 
 	webhookSecretTokenEnv = os.Getenv(webhookSecretTokenEnvName)
+	webhookPreviousSecretToken = os.Getenv(webhookPreviousSecretTokenEnvName)
 
 	flag.StringVar(&webhookAddr, "webhook-addr", ":8000", "The address the metric endpoint binds to.")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
-	flag.StringVar(&watchNamespace, "watch-namespace", "", "The namespace to watch for HorizontalRunnerAutoscaler's to scale on Webhook. Set to empty for letting it watch for all namespaces.")
+	flag.StringVar(&watchNamespace, "watch-namespace", "", "Comma-separated list of the namespaces to watch for HorizontalRunnerAutoscaler's to scale on Webhook. Set to empty for letting it watch for all namespaces.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Minute, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled. When you use autoscaling, set to a lower value like 10 minute, because this corresponds to the minimum time to react on demand change")
-	flag.StringVar(&logLevel, "log-level", logLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
+	flag.StringVar(&logLevel, "log-level", logging.LogLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
+	flag.IntVar(&logSampling.First, "log-sampling-first", 0, "The number of log lines with the same level and message logged per second before the rest are sampled. Defaults to 0, which disables the sampling")
+	flag.IntVar(&logSampling.Thereafter, "log-sampling-thereafter", 100, "Every N-th log line with the same level and message is logged once -log-sampling-first lines are logged within a second. Only used when -log-sampling-first is set")
+	flag.DurationVar(&logRateLimitInterval, "log-rate-limit-interval", 0, "The minimum interval between repetitive warnings with the same reason, like a webhook event without a scale target. The suppressed lines are still counted in the log_events_suppressed_total metric. Defaults to 0, which logs every line")
+	flag.StringVar(&enterpriseHostSlugs, "github-enterprise-host-slugs", "", "Comma-separated list of HOST=SLUG pairs that map the hostnames of GitHub Enterprise Server instances, sent in the X-GitHub-Enterprise-Host header of webhook events, to the slugs of their enterprises. Used for finding enterprise runners to scale when the event payload doesn't contain the enterprise")
+	flag.BoolVar(&dryRun, "dry-run", false, "Resolve the scale targets and compute the capacity reservations for webhook events without patching HorizontalRunnerAutoscalers. What would have been done is logged and counted in the horizontalrunnerautoscaler_dry_run_replicas_total metric")
+	flag.StringVar(&runtimeSettingsFile, "runtime-settings-file", "", "The path to the YAML file, usually mounted from a configmap, that overrides logLevel, logRateLimitInterval, and dryRun. The file is re-read on change so that they can be changed without restarting the server")
+	flag.DurationVar(&workflowJobTraceTTL, "workflow-job-trace-ttl", 0, "How long the WorkflowJobTrace, that links each workflow job to the runner pod that ran it, is kept. Set to e.g. 168h to record the traces from workflow_job events. Defaults to 0, which disables the recording")
+	flag.StringVar(&anomalyNotificationConfig, "anomaly-notification-config", "", "The path to the YAML file that configures the webhooks, like Slack incoming webhooks or PagerDuty Events API, notified of anomalies like repeated workflow jobs without scale targets and failed scale ups. Defaults to empty, which disables the notifications")
+	flag.StringVar(&tracingOptions.Endpoint, "tracing-otlp-endpoint", "", "The host and port of the OTLP/HTTP receiver, like otel-collector:4318, that OpenTelemetry spans of webhook deliveries are exported to. Defaults to empty, which disables the tracing")
+	flag.BoolVar(&tracingOptions.Insecure, "tracing-otlp-insecure", false, "Export the OpenTelemetry spans over plain HTTP instead of HTTPS")
+	flag.Float64Var(&tracingOptions.SampleRatio, "tracing-sample-ratio", 1, "The ratio of webhook deliveries traced, from 0 to 1. Deliveries carrying the traceparent header are traced as the caller sampled them")
+	flag.StringVar(&auditLogSink, "audit-log", "", `Where every webhook delivery is recorded as a JSON line along with the matched HorizontalRunnerAutoscaler and the replica math. Either "stdout", the path of the file to append to, or the http:// or https:// URL that every record is POSTed to. Defaults to empty, which disables the audit log`)
+	flag.IntVar(&unmatchedJobsBufferSize, "unmatched-jobs-buffer-size", 0, "The number of the latest queued workflow jobs without any HorizontalRunnerAutoscaler to scale for them that are kept until they are picked up or completed, and listed in JSON at /unmatched-jobs of the metrics endpoint. Defaults to 0, which disables the buffer")
 	flag.StringVar(&webhookSecretToken, "github-webhook-secret-token", "", "The personal access token of GitHub.")
+	flag.StringVar(&webhookPreviousSecretToken, "github-webhook-previous-secret-token", webhookPreviousSecretToken, fmt.Sprintf("The previous webhook secret token that is accepted in addition to -github-webhook-secret-token while rotating it. Defaults to the %s environment variable", webhookPreviousSecretTokenEnvName))
+	flag.StringVar(&webhookSecretTokensFile, "github-webhook-secret-tokens-file", "", "The path to the file that contains the webhook secret tokens, one per line. The file is re-read on change so that the tokens can be rotated without restarting the server")
+	flag.BoolVar(&requireWebhookSignature, "require-webhook-signature", false, "Refuse to start when no webhook secret token is configured, instead of accepting every request without validating its signature. Recommended for production")
+	flag.BoolVar(&enableRunnerPools, "enable-runner-pools", false, "Distribute the capacity reservations for workflow_job events across the members of RunnerPools. Requires the RunnerPool CRD")
+	flag.BoolVar(&enableWebhookSources, "enable-webhook-sources", false, "Validate the deliveries of the webhooks matched by WebhookSources, by the X-GitHub-Hook-ID header or the organization, with the secret tokens in their Secrets instead of -github-webhook-secret-token, so that a single server can receive the webhooks of multiple GitHub organizations or GitHub Apps")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
+	flag.StringVar(&c.EnterpriseToken, "github-enterprise-token", c.EnterpriseToken, "The personal access token of GitHub used only for the enterprise-level API calls, like managing enterprise runners. Set along with the GitHub App credential, which can't call them, to use the GitHub App for the other API calls")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&c.AppInstallationID, "github-app-installation-id", c.AppInstallationID, "The installation ID of GitHub App.")
 	flag.StringVar(&c.AppPrivateKey, "github-app-private-key", c.AppPrivateKey, "The path of a private key file to authenticate as a GitHub App")
+	flag.StringVar(&c.CredentialsDir, "github-credentials-dir", c.CredentialsDir, "The directory of the files of the GitHub credentials named after the keys of the controller's Secret, like github_token and github_app_private_key. The files take precedence over the other GitHub credential flags, and are watched so that the rotated credentials, like the ones written by Vault Agent or External Secrets, are used without restarting")
 	flag.StringVar(&c.URL, "github-url", c.URL, "GitHub URL to be used for GitHub API calls")
 	flag.StringVar(&c.UploadURL, "github-upload-url", c.UploadURL, "GitHub Upload URL to be used for GitHub API calls")
 	flag.StringVar(&c.BasicauthUsername, "github-basicauth-username", c.BasicauthUsername, "Username for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.BasicauthPassword, "github-basicauth-password", c.BasicauthPassword, "Password for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.RunnerGitHubURL, "runner-github-url", c.RunnerGitHubURL, "GitHub URL to be used by runners during registration")
+	flag.StringVar(&c.HTTPProxy, "github-http-proxy", c.HTTPProxy, "The proxy URL for plain HTTP GitHub API calls. Defaults to the HTTP_PROXY environment variable")
+	flag.StringVar(&c.HTTPSProxy, "github-https-proxy", c.HTTPSProxy, "The proxy URL for HTTPS GitHub API calls. Defaults to the HTTPS_PROXY environment variable")
+	flag.StringVar(&c.NoProxy, "github-no-proxy", c.NoProxy, "Comma-separated list of hosts that GitHub API calls should reach without the proxy. Defaults to the NO_PROXY environment variable")
+	flag.IntVar(&c.MaxRetries, "github-api-max-retries", c.MaxRetries, "The number of times a GitHub API call that failed with a transient error, like 502 Bad Gateway, is retried with jittered exponential backoff before the reconciliation is requeued. Set to 0 to disable retries")
+	flag.DurationVar(&c.RetryBaseDelay, "github-api-retry-base-delay", c.RetryBaseDelay, "The initial delay between the retries of GitHub API calls. Defaults to 500ms")
+	flag.DurationVar(&c.RetryMaxDelay, "github-api-retry-max-delay", c.RetryMaxDelay, "The maximum delay between the retries of GitHub API calls. Defaults to 10s")
 
 	flag.Parse()
 
@@ -116,31 +172,82 @@ func main() {
 		webhookSecretToken = webhookSecretTokenEnv
 	}
 
-	if webhookSecretToken == "" {
-		setupLog.Info(fmt.Sprintf("-github-webhook-secret-token and %s are missing or empty. Create one following https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks and specify it via the flag or the envvar", webhookSecretTokenEnvName))
+	if webhookSecretToken == "" && webhookSecretTokensFile == "" {
+		if requireWebhookSignature && enableWebhookSources {
+			setupLog.Info("No default webhook secret token is configured. Only the webhook deliveries matched by WebhookSources are accepted")
+		} else if requireWebhookSignature {
+			setupLog.Error(fmt.Errorf("-github-webhook-secret-token, -github-webhook-secret-tokens-file, and %s are missing or empty", webhookSecretTokenEnvName), "-require-webhook-signature is set but no webhook secret token is configured. Create one following https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks and specify it via the flag or the envvar")
+			os.Exit(1)
+		} else {
+			setupLog.Info(fmt.Sprintf("WARNING: -github-webhook-secret-token and %s are missing or empty. Every webhook request is accepted without validating its signature, so anyone who can reach the webhook server can trigger scale ups. Create one following https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks and specify it via the flag or the envvar, and set -require-webhook-signature to refuse to start without it", webhookSecretTokenEnvName))
+		}
 	}
 
-	if watchNamespace == "" {
+	enterpriseHostSlugsByHost, err := controllers.ParseEnterpriseHostSlugs(enterpriseHostSlugs)
+	if err != nil {
+		setupLog.Error(err, "invalid -github-enterprise-host-slugs")
+		os.Exit(1)
+	}
+
+	watchNamespaces := controllers.ParseWatchNamespaces(watchNamespace)
+
+	if len(watchNamespaces) == 0 {
 		setupLog.Info("-watch-namespace is empty. HorizontalRunnerAutoscalers in all the namespaces are watched, cached, and considered as scale targets.")
 	} else {
-		setupLog.Info("-watch-namespace is %q. Only HorizontalRunnerAutoscalers in %q are watched, cached, and considered as scale targets.")
-	}
-
-	logger := zap.New(func(o *zap.Options) {
-		switch logLevel {
-		case logLevelDebug:
-			o.Development = true
-		case logLevelInfo:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.InfoLevel)
-			o.Level = &lvl
-		case logLevelWarn:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.WarnLevel)
-			o.Level = &lvl
-		case logLevelError:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.ErrorLevel)
-			o.Level = &lvl
+		setupLog.Info("Only HorizontalRunnerAutoscalers in the namespaces specified by -watch-namespace are watched, cached, and considered as scale targets.", "namespaces", watchNamespaces)
+	}
+
+	logger := logging.NewLogger(logLevel, logSampling)
+	logRateLimiter := logging.NewRateLimiter(logRateLimitInterval)
+
+	var anomalyNotifier *anomaly.Notifier
+
+	if anomalyNotificationConfig != "" {
+		config, err := anomaly.LoadConfig(anomalyNotificationConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to load -anomaly-notification-config")
+			os.Exit(1)
 		}
-	})
+
+		anomalyNotifier, err = anomaly.NewNotifier("github-webhook-server", config, logger.WithName("anomaly"))
+		if err != nil {
+			setupLog.Error(err, "invalid -anomaly-notification-config")
+			os.Exit(1)
+		}
+
+		c.OnRateLimitExhausted = func(reset time.Time) {
+			anomalyNotifier.Record(anomaly.KindRateLimitExhausted, fmt.Sprintf("The rate limit resets at %s", reset.Format(time.RFC3339)))
+		}
+	}
+
+	if tracingOptions.Endpoint != "" {
+		tracingOptions.ServiceName = "github-webhook-server"
+
+		shutdownTracing, err := tracing.Setup(context.Background(), tracingOptions)
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				setupLog.Error(err, "unable to flush the spans")
+			}
+		}()
+	}
+
+	if c.CredentialsDir != "" {
+		creds, err := (&github.FileCredentialsSource{Dir: c.CredentialsDir}).Read()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: Reading GitHub credentials failed.", err)
+			os.Exit(1)
+		}
+
+		c.SetCredentials(creds)
+	}
 
 	if len(c.Token) > 0 || (c.AppID > 0 && c.AppInstallationID > 0 && c.AppPrivateKey != "") || (len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0) {
 		ghClient, err = c.NewClient()
@@ -153,11 +260,26 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
+	if ghClient != nil {
+		detectCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		capabilities, err := ghClient.DetectCapabilities(detectCtx)
+		cancel()
+
+		if err != nil {
+			setupLog.Error(err, "unable to detect the version of the GitHub server. Assuming that it supports every feature")
+		} else if capabilities.GHESVersion != "" {
+			setupLog.Info("Detected GitHub Enterprise Server", "version", capabilities.GHESVersion)
+		}
+	}
+
+	cacheNamespace, newCache := controllers.WatchNamespacesCacheOptions(watchNamespaces)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		SyncPeriod:         &syncPeriod,
 		LeaderElection:     enableLeaderElection,
-		Namespace:          watchNamespace,
+		Namespace:          cacheNamespace,
+		NewCache:           newCache,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 	})
@@ -166,14 +288,129 @@ func main() {
 		os.Exit(1)
 	}
 
+	if ghClient != nil && c.CredentialsDir != "" {
+		credentialsReloader := &github.CredentialsReloader{
+			Client: ghClient,
+			Source: &github.FileCredentialsSource{Dir: c.CredentialsDir, Log: ctrl.Log.WithName("credentials")},
+			Log:    ctrl.Log.WithName("credentials"),
+		}
+
+		if err := mgr.Add(credentialsReloader); err != nil {
+			setupLog.Error(err, "unable to watch GitHub credentials", "dir", c.CredentialsDir)
+			os.Exit(1)
+		}
+	}
+
+	var secretKeysFile *controllers.WebhookSecretsFile
+
+	if webhookSecretTokensFile != "" {
+		secretKeysFile, err = controllers.NewWebhookSecretsFile(webhookSecretTokensFile, ctrl.Log.WithName("webhooksecrets"))
+		if err != nil {
+			setupLog.Error(err, "unable to load webhook secret tokens file", "path", webhookSecretTokensFile)
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(secretKeysFile); err != nil {
+			setupLog.Error(err, "unable to watch webhook secret tokens file", "path", webhookSecretTokensFile)
+			os.Exit(1)
+		}
+	}
+
+	var runtimeSettings *controllers.RuntimeSettingsFile
+
+	if runtimeSettingsFile != "" {
+		defaults := controllers.RuntimeSettings{
+			LogLevel:             &logLevel,
+			LogRateLimitInterval: &metav1.Duration{Duration: logRateLimitInterval},
+			DryRun:               &dryRun,
+		}
+
+		runtimeSettings, err = controllers.NewRuntimeSettingsFile(runtimeSettingsFile, defaults, logRateLimiter, ctrl.Log.WithName("runtimesettings"))
+		if err != nil {
+			setupLog.Error(err, "unable to load runtime settings file", "path", runtimeSettingsFile)
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(runtimeSettings); err != nil {
+			setupLog.Error(err, "unable to watch runtime settings file", "path", runtimeSettingsFile)
+			os.Exit(1)
+		}
+	}
+
+	var auditLog *audit.Log
+
+	if auditLogSink != "" {
+		auditLog, err = audit.New(auditLogSink, ctrl.Log.WithName("audit"))
+		if err != nil {
+			setupLog.Error(err, "invalid -audit-log")
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(auditLog); err != nil {
+			setupLog.Error(err, "unable to start audit log")
+			os.Exit(1)
+		}
+	}
+
+	var unmatchedJobs *controllers.UnmatchedJobs
+
+	if unmatchedJobsBufferSize > 0 {
+		unmatchedJobs = controllers.NewUnmatchedJobs(unmatchedJobsBufferSize)
+
+		// Served on the metrics endpoint rather than the webhook endpoint, which is usually exposed to GitHub
+		if err := mgr.AddMetricsExtraHandler("/unmatched-jobs", unmatchedJobs); err != nil {
+			setupLog.Error(err, "unable to serve unmatched jobs")
+			os.Exit(1)
+		}
+	}
+
+	var webhookSources *controllers.WebhookSources
+
+	if enableWebhookSources {
+		webhookSources = controllers.NewWebhookSources()
+
+		webhookSourceReconciler := &controllers.WebhookSourceReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("WebhookSource"),
+			Sources:      webhookSources,
+			SecretReader: mgr.GetAPIReader(),
+		}
+
+		if err = webhookSourceReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "WebhookSource")
+			os.Exit(1)
+		}
+	}
+
+	var additionalSecretKeyBytes [][]byte
+	if webhookPreviousSecretToken != "" {
+		additionalSecretKeyBytes = append(additionalSecretKeyBytes, []byte(webhookPreviousSecretToken))
+	}
+
 	hraGitHubWebhook := &controllers.HorizontalRunnerAutoscalerGitHubWebhook{
-		Client:         mgr.GetClient(),
-		Log:            ctrl.Log.WithName("controllers").WithName("Runner"),
-		Recorder:       nil,
-		Scheme:         mgr.GetScheme(),
-		SecretKeyBytes: []byte(webhookSecretToken),
-		Namespace:      watchNamespace,
-		GitHubClient:   ghClient,
+		Client:                   mgr.GetClient(),
+		Log:                      ctrl.Log.WithName("controllers").WithName("Runner"),
+		Recorder:                 nil,
+		Scheme:                   mgr.GetScheme(),
+		SecretKeyBytes:           []byte(webhookSecretToken),
+		AdditionalSecretKeyBytes: additionalSecretKeyBytes,
+		SecretKeysFile:           secretKeysFile,
+		RequireSignature:         requireWebhookSignature,
+		Namespace:                cacheNamespace,
+		Namespaces:               watchNamespaces,
+		GitHubClient:             ghClient,
+		MultiGitHubClient:        controllers.NewMultiGitHubClient(mgr.GetAPIReader(), c),
+		LogRateLimiter:           logRateLimiter,
+		AnomalyNotifier:          anomalyNotifier,
+		AuditLog:                 auditLog,
+		Sources:                  webhookSources,
+		UnmatchedJobs:            unmatchedJobs,
+		WorkflowJobTraceTTL:      workflowJobTraceTTL,
+		EnterpriseHostSlugs:      enterpriseHostSlugsByHost,
+		DryRun:                   dryRun,
+		RunnerPools:              enableRunnerPools,
+		RuntimeSettings:          runtimeSettings,
+		APIReader:                mgr.GetAPIReader(),
 	}
 
 	if err = hraGitHubWebhook.SetupWithManager(mgr); err != nil {
@@ -181,6 +418,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if workflowJobTraceTTL > 0 {
+		workflowJobTraceReconciler := &controllers.WorkflowJobTraceReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("WorkflowJobTrace"),
+		}
+
+		if err = workflowJobTraceReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "WorkflowJobTrace")
+			os.Exit(1)
+		}
+	}
+
 	var wg sync.WaitGroup
 
 	ctx, cancel := context.WithCancel(context.Background())
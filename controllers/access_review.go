@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourceAccess is an access to a Kubernetes API resource that a controller requires.
+type ResourceAccess struct {
+	Group    string
+	Resource string
+	Verb     string
+}
+
+func (a ResourceAccess) String() string {
+	if a.Group == "" {
+		return fmt.Sprintf("%s %s", a.Verb, a.Resource)
+	}
+
+	return fmt.Sprintf("%s %s.%s", a.Verb, a.Resource, a.Group)
+}
+
+// RunnerSetResourceAccesses are the accesses required by the RunnerSet controller and the RunnerPod controller,
+// on top of the ones to the actions.summerwind.dev resources.
+var RunnerSetResourceAccesses = []ResourceAccess{
+	{Group: "apps", Resource: "statefulsets", Verb: "get"},
+	{Group: "apps", Resource: "statefulsets", Verb: "list"},
+	{Group: "apps", Resource: "statefulsets", Verb: "watch"},
+	{Group: "apps", Resource: "statefulsets", Verb: "create"},
+	{Group: "apps", Resource: "statefulsets", Verb: "update"},
+	{Group: "apps", Resource: "statefulsets", Verb: "delete"},
+	{Group: "", Resource: "pods", Verb: "update"},
+	{Group: "", Resource: "pods", Verb: "patch"},
+}
+
+// DeniedResourceAccesses returns the accesses that the identity of the client isn't allowed to perform in the namespace,
+// by creating a SelfSubjectAccessReview per access.
+// An empty namespace means all namespaces.
+//
+// It's used on startup to skip optional controllers that the ServiceAccount of the controller isn't given permissions for,
+// so that the controller can run with a minimal ClusterRole.
+// SelfSubjectAccessReview is allowed for every authenticated user, so this doesn't require any additional permission.
+func DeniedResourceAccesses(ctx context.Context, c client.Client, namespace string, accesses []ResourceAccess) ([]ResourceAccess, error) {
+	var denied []ResourceAccess
+
+	for _, a := range accesses {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Group:     a.Group,
+					Resource:  a.Resource,
+					Verb:      a.Verb,
+				},
+			},
+		}
+
+		if err := c.Create(ctx, review); err != nil {
+			return nil, fmt.Errorf("creating selfsubjectaccessreview for %s: %w", a, err)
+		}
+
+		if !review.Status.Allowed {
+			denied = append(denied, a)
+		}
+	}
+
+	return denied, nil
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// accessReviewClient answers SelfSubjectAccessReviews like the API server would for a role that
+// allows only the given resources.
+type accessReviewClient struct {
+	client.Client
+
+	allowedResources map[string]bool
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+		review.Status.Allowed = c.allowedResources[review.Spec.ResourceAttributes.Resource]
+		return nil
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestDeniedResourceAccesses(t *testing.T) {
+	c := &accessReviewClient{
+		Client: fake.NewClientBuilder().Build(),
+		allowedResources: map[string]bool{
+			"pods": true,
+		},
+	}
+
+	denied, err := DeniedResourceAccesses(context.Background(), c, "default", RunnerSetResourceAccesses)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []ResourceAccess{
+		{Group: "apps", Resource: "statefulsets", Verb: "get"},
+		{Group: "apps", Resource: "statefulsets", Verb: "list"},
+		{Group: "apps", Resource: "statefulsets", Verb: "watch"},
+		{Group: "apps", Resource: "statefulsets", Verb: "create"},
+		{Group: "apps", Resource: "statefulsets", Verb: "update"},
+		{Group: "apps", Resource: "statefulsets", Verb: "delete"},
+	}
+
+	if d := cmp.Diff(want, denied); d != "" {
+		t.Errorf("unexpected denied accesses (-want +got):\n%s", d)
+	}
+
+	c.allowedResources["statefulsets"] = true
+
+	denied, err = DeniedResourceAccesses(context.Background(), c, "", RunnerSetResourceAccesses)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(denied) != 0 {
+		t.Errorf("expected no denied accesses, got %v", denied)
+	}
+}
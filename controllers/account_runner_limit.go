@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// githubAccountOf returns the GitHub account that owns the runners of the scale target.
+// Repository runners count towards the repository owner, which is the same account as the organization's runners.
+func githubAccountOf(st scaleTarget) string {
+	switch {
+	case st.enterprise != "":
+		return "enterprises/" + st.enterprise
+	case st.org != "":
+		return st.org
+	case st.repo != "":
+		return strings.Split(st.repo, "/")[0]
+	}
+
+	return ""
+}
+
+// allocateAccountRunners computes the share of the account's runner limit of the HRA that requests
+// the desired replicas, given the requests of all the other HRAs for the same account.
+//
+// The requests of the other HRAs are read from their status, which is updated on each reconciliation,
+// so that the allocation is stateless and survives controller restarts.
+func (r *HorizontalRunnerAutoscalerReconciler) allocateAccountRunners(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, requested int) (*v1alpha1.AccountRunnerLimitStatus, error) {
+	account := githubAccountOf(st)
+
+	self := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}.String()
+
+	requests := map[string]int{
+		self: requested,
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hraList); err != nil {
+		return nil, fmt.Errorf("listing horizontalrunnerautoscalers: %w", err)
+	}
+
+	for _, other := range hraList.Items {
+		key := types.NamespacedName{Namespace: other.Namespace, Name: other.Name}.String()
+
+		if key == self || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if s := other.Status.AccountRunnerLimit; s != nil && s.Account == account {
+			requests[key] = s.RequestedReplicas
+		}
+	}
+
+	allocations := allocateProportionally(r.AccountMaxRunners, requests)
+
+	return &v1alpha1.AccountRunnerLimitStatus{
+		Account:           account,
+		MaxRunners:        r.AccountMaxRunners,
+		RequestedReplicas: requested,
+		AllocatedReplicas: allocations[self],
+	}, nil
+}
+
+// allocateProportionally allocates max to the requests in proportion to the requested amounts when they sum up to more than max.
+// The remainders of the proportional shares are distributed by the largest remainder method, with ties broken by the key,
+// so that every HRA for the same account computes the same allocations.
+func allocateProportionally(max int, requests map[string]int) map[string]int {
+	var total int
+
+	for _, v := range requests {
+		total += v
+	}
+
+	allocations := make(map[string]int, len(requests))
+
+	if total <= max {
+		for k, v := range requests {
+			allocations[k] = v
+		}
+
+		return allocations
+	}
+
+	type remainder struct {
+		key   string
+		value int
+	}
+
+	var (
+		allocated  int
+		remainders []remainder
+	)
+
+	for k, v := range requests {
+		allocations[k] = v * max / total
+		allocated += allocations[k]
+
+		remainders = append(remainders, remainder{key: k, value: v * max % total})
+	}
+
+	sort.Slice(remainders, func(i, j int) bool {
+		if remainders[i].value != remainders[j].value {
+			return remainders[i].value > remainders[j].value
+		}
+
+		return remainders[i].key < remainders[j].key
+	})
+
+	for i := 0; allocated < max; i++ {
+		allocations[remainders[i].key]++
+		allocated++
+	}
+
+	return allocations
+}
+
+// horizontalRunnerAutoscalersForSameAccount enqueues the other HRAs for the same account as the updated HRA,
+// so that their allocations follow the changes in the requests without waiting for the next sync.
+func (r *HorizontalRunnerAutoscalerReconciler) horizontalRunnerAutoscalersForSameAccount(obj client.Object) []reconcile.Request {
+	hra, ok := obj.(*v1alpha1.HorizontalRunnerAutoscaler)
+	if !ok || hra.Status.AccountRunnerLimit == nil {
+		return nil
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(context.TODO(), &hraList); err != nil {
+		r.Log.Error(err, "Failed to list horizontalrunnerautoscalers for the account", "account", hra.Status.AccountRunnerLimit.Account)
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, other := range hraList.Items {
+		if other.Namespace == hra.Namespace && other.Name == hra.Name {
+			continue
+		}
+
+		if s := other.Status.AccountRunnerLimit; s != nil && s.Account == hra.Status.AccountRunnerLimit.Account {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: other.Namespace, Name: other.Name}})
+		}
+	}
+
+	return reqs
+}
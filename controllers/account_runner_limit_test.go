@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestAllocateProportionally(t *testing.T) {
+	testcases := []struct {
+		max      int
+		requests map[string]int
+		want     map[string]int
+	}{
+		{
+			max:      10,
+			requests: map[string]int{"a": 3, "b": 4},
+			want:     map[string]int{"a": 3, "b": 4},
+		},
+		{
+			max:      10,
+			requests: map[string]int{"a": 10, "b": 10},
+			want:     map[string]int{"a": 5, "b": 5},
+		},
+		{
+			max:      10,
+			requests: map[string]int{"a": 30, "b": 10},
+			want:     map[string]int{"a": 8, "b": 2},
+		},
+		// The remainder goes to the largest fraction, and then to the first key on ties
+		{
+			max:      10,
+			requests: map[string]int{"a": 1, "b": 1, "c": 1, "d": 20},
+			want:     map[string]int{"a": 1, "b": 0, "c": 0, "d": 9},
+		},
+	}
+
+	for i, tc := range testcases {
+		got := allocateProportionally(tc.max, tc.requests)
+
+		if d := cmp.Diff(tc.want, got); d != "" {
+			t.Errorf("%d: unexpected allocations (-want +got):\n%s", i, d)
+		}
+	}
+}
+
+func TestAllocateAccountRunners(t *testing.T) {
+	newHRA := func(ns, name, account string, requested int) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Status: actionsv1alpha1.HorizontalRunnerAutoscalerStatus{
+				AccountRunnerLimit: &actionsv1alpha1.AccountRunnerLimitStatus{
+					Account:           account,
+					RequestedReplicas: requested,
+				},
+			},
+		}
+	}
+
+	self := newHRA("default", "self", "acme", 0)
+	self.Status = actionsv1alpha1.HorizontalRunnerAutoscalerStatus{}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		self,
+		newHRA("team-a", "build", "acme", 30),
+		newHRA("team-b", "build", "other", 100),
+	)
+
+	r := &HorizontalRunnerAutoscalerReconciler{Client: client, AccountMaxRunners: 20}
+
+	got, err := r.allocateAccountRunners(context.Background(), *self, scaleTarget{repo: "acme/app"}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &actionsv1alpha1.AccountRunnerLimitStatus{
+		Account:           "acme",
+		MaxRunners:        20,
+		RequestedReplicas: 10,
+		AllocatedReplicas: 5,
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected status (-want +got):\n%s", d)
+	}
+
+	got, err = r.allocateAccountRunners(context.Background(), *self, scaleTarget{enterprise: "acme"}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Account != "enterprises/acme" || got.AllocatedReplicas != 10 {
+		t.Errorf("unexpected status for the enterprise: %+v", got)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	arcmetrics "github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
 	"github.com/google/go-github/v39/github"
 )
 
@@ -129,7 +130,37 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestDesiredReplicas(st scaleTa
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgressWorkflowRuns(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics *v1alpha1.MetricSpec) (*int, error) {
+	counts, err := r.countWorkflowRuns(st, metrics)
+	if err != nil || counts == nil {
+		return nil, err
+	}
+
+	necessaryReplicas := counts.queued + counts.inProgress
+
+	arcmetrics.SetHorizontalRunnerAutoscalerWorkflowRuns(hra.ObjectMeta, arcmetrics.WorkflowRuns{Queued: counts.queued, InProgress: counts.inProgress})
+
+	r.Log.V(1).Info(
+		fmt.Sprintf("Suggested desired replicas of %d by TotalNumberOfQueuedAndInProgressWorkflowRuns", necessaryReplicas),
+		"workflow_runs_completed", counts.completed,
+		"workflow_runs_in_progress", counts.inProgress,
+		"workflow_runs_queued", counts.queued,
+		"workflow_runs_unknown", counts.unknown,
+		"namespace", hra.Namespace,
+		"kind", st.kind,
+		"name", st.st,
+		"horizontal_runner_autoscaler", hra.Name,
+	)
+
+	return &necessaryReplicas, nil
+}
 
+type workflowRunCounts struct {
+	queued, inProgress, completed, unknown int
+}
+
+// countWorkflowRuns counts the workflow runs and jobs of the repositories of the scale target for TotalNumberOfQueuedAndInProgressWorkflowRuns.
+// It returns nil for organizational runners without the metric, whose desired replicas are left to the other sources.
+func (r *HorizontalRunnerAutoscalerReconciler) countWorkflowRuns(st scaleTarget, metrics *v1alpha1.MetricSpec) (*workflowRunCounts, error) {
 	var repos [][]string
 	repoID := st.repo
 	if repoID == "" {
@@ -168,7 +199,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 		opt := github.ListWorkflowJobsOptions{ListOptions: github.ListOptions{PerPage: 50}}
 		var allJobs []*github.WorkflowJob
 		for {
-			jobs, resp, err := r.GitHubClient.Actions.ListWorkflowJobs(context.TODO(), user, repoName, runID, &opt)
+			jobs, resp, err := r.gitHubClientOf(st).Actions.ListWorkflowJobs(context.TODO(), user, repoName, runID, &opt)
 			if err != nil {
 				r.Log.Error(err, "Error listing workflow jobs")
 				return //err
@@ -204,7 +235,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 
 	for _, repo := range repos {
 		user, repoName := repo[0], repo[1]
-		workflowRuns, err := r.GitHubClient.ListRepositoryWorkflowRuns(context.TODO(), user, repoName)
+		workflowRuns, err := r.gitHubClientOf(st).ListRepositoryWorkflowRuns(context.TODO(), user, repoName)
 		if err != nil {
 			return nil, err
 		}
@@ -229,29 +260,16 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 		}
 	}
 
-	necessaryReplicas := queued + inProgress
-
-	r.Log.V(1).Info(
-		fmt.Sprintf("Suggested desired replicas of %d by TotalNumberOfQueuedAndInProgressWorkflowRuns", necessaryReplicas),
-		"workflow_runs_completed", completed,
-		"workflow_runs_in_progress", inProgress,
-		"workflow_runs_queued", queued,
-		"workflow_runs_unknown", unknown,
-		"namespace", hra.Namespace,
-		"kind", st.kind,
-		"name", st.st,
-		"horizontal_runner_autoscaler", hra.Name,
-	)
-
-	return &necessaryReplicas, nil
+	return &workflowRunCounts{queued: queued, inProgress: inProgress, completed: completed, unknown: unknown}, nil
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunnersBusy(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
 	ctx := context.Background()
+	scaleDownBehavior := scaleDownBehaviorOf(hra)
 	scaleUpThreshold := defaultScaleUpThreshold
-	scaleDownThreshold := defaultScaleDownThreshold
+	scaleDownThreshold := scaleDownBehavior.scaleDownThreshold
 	scaleUpFactor := defaultScaleUpFactor
-	scaleDownFactor := defaultScaleDownFactor
+	scaleDownFactor := scaleDownBehavior.scaleDownFactor
 
 	if metrics.ScaleUpThreshold != "" {
 		sut, err := strconv.ParseFloat(metrics.ScaleUpThreshold, 64)
@@ -314,14 +332,24 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		repository   = st.repo
 	)
 
-	// ListRunners will return all runners managed by GitHub - not restricted to ns
-	runners, err := r.GitHubClient.ListRunners(
-		ctx,
-		enterprise,
-		organization,
-		repository)
-	if err != nil {
-		return nil, err
+	var numRunnersRegistered, numRunnersBusy, numExternalRunners, numExternalRunnersBusy int
+
+	if metrics.BusyRunnersSource == v1alpha1.BusyRunnersSourceWorkflowJobEvents {
+		// The runners are registered as far as the events are concerned, and the external runners are unknown to them
+		numRunnersRegistered = len(runnerMap)
+		numRunnersBusy = countBusyRunners(hra.Status.BusyRunners, runnerMap)
+	} else {
+		// ListRunners will return all runners managed by GitHub - not restricted to ns
+		runners, err := r.gitHubClientOf(st).ListRunners(
+			ctx,
+			enterprise,
+			organization,
+			repository)
+		if err != nil {
+			return nil, err
+		}
+
+		numRunnersRegistered, numRunnersBusy, numExternalRunners, numExternalRunnersBusy = countRunners(runners, runnerMap, hra.Spec.ExternalRunners)
 	}
 
 	var desiredReplicasBefore int
@@ -332,26 +360,12 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		desiredReplicasBefore = *v
 	}
 
-	var (
-		numRunners           int
-		numRunnersRegistered int
-		numRunnersBusy       int
-	)
-
-	numRunners = len(runnerMap)
-
-	for _, runner := range runners {
-		if _, ok := runnerMap[*runner.Name]; ok {
-			numRunnersRegistered++
-
-			if runner.GetBusy() {
-				numRunnersBusy++
-			}
-		}
-	}
+	numRunners := len(runnerMap)
 
+	// External runners add to the capacity the busy fraction is computed against, but they are never scaled,
+	// so the desired replicas are still derived from the replicas of the scale target.
 	var desiredReplicas int
-	fractionBusy := float64(numRunnersBusy) / float64(desiredReplicasBefore)
+	fractionBusy := float64(numRunnersBusy+numExternalRunnersBusy) / float64(desiredReplicasBefore+numExternalRunners)
 	if fractionBusy >= scaleUpThreshold {
 		if scaleUpAdjustment > 0 {
 			desiredReplicas = desiredReplicasBefore + scaleUpAdjustment
@@ -364,6 +378,11 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		} else {
 			desiredReplicas = int(float64(desiredReplicasBefore) * scaleDownFactor)
 		}
+
+		// The busy runners aren't removed anyway, so the scale down stops at them rather than removing them as they finish their jobs
+		if scaleDownBehavior.idleRunnersOnly && desiredReplicas < numRunnersBusy {
+			desiredReplicas = numRunnersBusy
+		}
 	} else {
 		desiredReplicas = *st.replicas
 	}
@@ -380,6 +399,9 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		"num_runners", numRunners,
 		"num_runners_registered", numRunnersRegistered,
 		"num_runners_busy", numRunnersBusy,
+		"num_external_runners", numExternalRunners,
+		"num_external_runners_busy", numExternalRunnersBusy,
+		"busy_runners_source", metrics.BusyRunnersSource,
 		"namespace", hra.Namespace,
 		"kind", st.kind,
 		"name", st.st,
@@ -391,3 +413,61 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 
 	return &desiredReplicas, nil
 }
+
+// countRunners returns the numbers of the registered and busy runners of the scale target in runnerMap,
+// and the numbers of the online and busy external runners selected by the HRA.
+// Offline external runners are excluded because they can't run jobs, like the ones on VMs being decommissioned.
+func countRunners(runners []*github.Runner, runnerMap map[string]struct{}, external *v1alpha1.ExternalRunnersSpec) (registered, busy, numExternal, externalBusy int) {
+	for _, runner := range runners {
+		if _, ok := runnerMap[runner.GetName()]; ok {
+			registered++
+
+			if runner.GetBusy() {
+				busy++
+			}
+
+			continue
+		}
+
+		if !isExternalRunner(runner, external) || runner.GetStatus() != "online" {
+			continue
+		}
+
+		numExternal++
+
+		if runner.GetBusy() {
+			externalBusy++
+		}
+	}
+
+	return registered, busy, numExternal, externalBusy
+}
+
+func isExternalRunner(runner *github.Runner, external *v1alpha1.ExternalRunnersSpec) bool {
+	if external == nil {
+		return false
+	}
+
+	for _, name := range external.Names {
+		if runner.GetName() == name {
+			return true
+		}
+	}
+
+	if len(external.Labels) == 0 {
+		return false
+	}
+
+	labels := map[string]bool{}
+	for _, l := range runner.Labels {
+		labels[strings.ToLower(l.GetName())] = true
+	}
+
+	for _, l := range external.Labels {
+		if !labels[strings.ToLower(l)] {
+			return false
+		}
+	}
+
+	return true
+}
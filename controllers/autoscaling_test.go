@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
@@ -40,6 +41,7 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 	}
 
 	metav1Now := metav1.Now()
+	metav1AnHourAgo := metav1.NewTime(metav1Now.Add(-time.Hour))
 	testcases := []struct {
 		repo      string
 		org       string
@@ -49,6 +51,9 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 		sReplicas *int
 		sTime     *metav1.Time
 
+		scaleDownProtection *metav1.Duration
+		lastRolloutTime     *metav1.Time
+
 		workflowRuns             string
 		workflowRuns_queued      string
 		workflowRuns_in_progress string
@@ -80,6 +85,32 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 			workflowRuns_in_progress: `{"total_count": 1, "workflow_runs":[{"status":"in_progress"}]}"`,
 			want:                     3,
 		},
+		// 2 demanded, max at 3, currently 3, delay scaling down due to the recent rollout
+		{
+			repo:                     "test/valid",
+			min:                      intPtr(2),
+			max:                      intPtr(3),
+			sReplicas:                intPtr(3),
+			scaleDownProtection:      &metav1.Duration{Duration: 10 * time.Minute},
+			lastRolloutTime:          &metav1Now,
+			workflowRuns:             `{"total_count": 3, "workflow_runs":[{"status":"queued"}, {"status":"in_progress"}, {"status":"completed"}]}"`,
+			workflowRuns_queued:      `{"total_count": 1, "workflow_runs":[{"status":"queued"}]}"`,
+			workflowRuns_in_progress: `{"total_count": 1, "workflow_runs":[{"status":"in_progress"}]}"`,
+			want:                     3,
+		},
+		// 2 demanded, max at 3, currently 3, the protection after the rollout has passed
+		{
+			repo:                     "test/valid",
+			min:                      intPtr(2),
+			max:                      intPtr(3),
+			sReplicas:                intPtr(3),
+			scaleDownProtection:      &metav1.Duration{Duration: 10 * time.Minute},
+			lastRolloutTime:          &metav1AnHourAgo,
+			workflowRuns:             `{"total_count": 3, "workflow_runs":[{"status":"queued"}, {"status":"in_progress"}, {"status":"completed"}]}"`,
+			workflowRuns_queued:      `{"total_count": 1, "workflow_runs":[{"status":"queued"}]}"`,
+			workflowRuns_in_progress: `{"total_count": 1, "workflow_runs":[{"status":"in_progress"}]}"`,
+			want:                     2,
+		},
 		// 3 demanded, max at 2
 		{
 			repo:                     "test/valid",
@@ -209,10 +240,12 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 							},
 						},
 					},
-					Replicas: tc.fixed,
+					Replicas:                        tc.fixed,
+					ScaleDownProtectionAfterRollout: tc.scaleDownProtection,
 				},
 				Status: v1alpha1.RunnerDeploymentStatus{
 					DesiredReplicas: tc.sReplicas,
+					LastRolloutTime: tc.lastRolloutTime,
 				},
 			}
 
@@ -518,3 +551,119 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestSuggestReplicasByPercentageRunnersBusy_ExternalRunners(t *testing.T) {
+	runners := `{"total_count": 5, "runners": [
+{"id": 1, "name": "example-runner-1", "status": "online", "busy": true, "labels": [{"name": "self-hosted"}]},
+{"id": 2, "name": "example-runner-2", "status": "online", "busy": false, "labels": [{"name": "self-hosted"}]},
+{"id": 3, "name": "vm-1", "status": "online", "busy": false, "labels": [{"name": "self-hosted"}, {"name": "VM"}]},
+{"id": 4, "name": "vm-2", "status": "online", "busy": false, "labels": [{"name": "self-hosted"}, {"name": "vm"}]},
+{"id": 5, "name": "vm-3", "status": "offline", "busy": false, "labels": [{"name": "self-hosted"}, {"name": "vm"}]}
+]}`
+
+	testcases := []struct {
+		name     string
+		external *v1alpha1.ExternalRunnersSpec
+		want     int
+	}{
+		// 1 busy out of 2 is between the default thresholds
+		{name: "no external runners", external: nil, want: 2},
+		// 1 busy out of 4 is below the default scale down threshold
+		{name: "by names", external: &v1alpha1.ExternalRunnersSpec{Names: []string{"vm-1", "vm-2"}}, want: 1},
+		{name: "by labels", external: &v1alpha1.ExternalRunnersSpec{Labels: []string{"vm"}}, want: 1},
+		{name: "offline", external: &v1alpha1.ExternalRunnersSpec{Names: []string{"vm-3"}}, want: 2},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fake.NewServer(fake.WithListRunnersResponse(200, runners))
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          zap.New(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			replicas := 2
+
+			st := scaleTarget{
+				st:       "example",
+				kind:     "RunnerDeployment",
+				repo:     "test/valid",
+				replicas: &replicas,
+				getRunnerMap: func() (map[string]struct{}, error) {
+					return map[string]struct{}{"example-runner-1": {}, "example-runner-2": {}}, nil
+				},
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{ExternalRunners: tc.external},
+			}
+
+			got, err := h.suggestReplicasByPercentageRunnersBusy(st, hra, v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %d", tc.want, *got)
+			}
+		})
+	}
+}
+
+func TestSuggestReplicasByPercentageRunnersBusy_WorkflowJobEvents(t *testing.T) {
+	busyRunner := func(name string) v1alpha1.BusyRunner {
+		return v1alpha1.BusyRunner{Name: name, Since: metav1.Now()}
+	}
+
+	testcases := []struct {
+		name        string
+		busyRunners []v1alpha1.BusyRunner
+		want        int
+	}{
+		// 2 busy out of 2 is above the default scale up threshold
+		{name: "all busy", busyRunners: []v1alpha1.BusyRunner{busyRunner("example-runner-1"), busyRunner("example-runner-2")}, want: 3},
+		// The runner that no longer exists isn't counted
+		{name: "deleted runner", busyRunners: []v1alpha1.BusyRunner{busyRunner("example-runner-3")}, want: 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// No GitHub client, as the busy runners are counted without calling the GitHub API
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log: zap.New(),
+			}
+
+			replicas := 2
+
+			st := scaleTarget{
+				st:       "example",
+				kind:     "RunnerDeployment",
+				repo:     "test/valid",
+				replicas: &replicas,
+				getRunnerMap: func() (map[string]struct{}, error) {
+					return map[string]struct{}{"example-runner-1": {}, "example-runner-2": {}}, nil
+				},
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{BusyRunners: tc.busyRunners},
+			}
+
+			metric := v1alpha1.MetricSpec{
+				Type:              v1alpha1.AutoscalingMetricTypePercentageRunnersBusy,
+				BusyRunnersSource: v1alpha1.BusyRunnersSourceWorkflowJobEvents,
+			}
+
+			got, err := h.suggestReplicasByPercentageRunnersBusy(st, hra, metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %d", tc.want, *got)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const (
+	DefaultBusyLedgerDriftThreshold = 10
+
+	// busyLedgerMinSnapshotInterval is the shortest interval the snapshots are taken at while the drift is high.
+	busyLedgerMinSnapshotInterval = time.Minute
+)
+
+// BusyLedgerReconciler compares the busy runners recorded by the webhook-based autoscaler, which are the runner names of
+// the capacity reservations for in-progress workflow jobs, with the busy runners listed by the GitHub API.
+//
+// The webhook-derived state drifts when GitHub fails to deliver workflow_job events. A reservation for a completed job
+// whose event was missed keeps the runner counted as busy until the reservation expires, so this removes it once the
+// runner is observed idle or gone in two consecutive snapshots.
+// The snapshots are taken every Interval, and more frequently while the drift exceeds DriftThreshold.
+type BusyLedgerReconciler struct {
+	client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+	Recorder     record.EventRecorder
+	Name         string
+
+	// MultiGitHubClient provides the clients of the scale targets with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
+	// Interval is the interval between the snapshots while the drift is low.
+	Interval time.Duration
+
+	// DriftThreshold is the percentage of the drift above which the snapshots are taken more frequently.
+	// Defaults to DefaultBusyLedgerDriftThreshold.
+	DriftThreshold float64
+
+	mu     sync.Mutex
+	states map[types.NamespacedName]*busyLedgerState
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type busyLedgerState struct {
+	lastSnapshotTime time.Time
+	interval         time.Duration
+	// stale are the runners recorded as busy that were observed idle or gone in the last snapshot
+	stale map[string]bool
+}
+
+// busyLedgerDrift is the difference between the busy runners recorded by the webhook-based autoscaler and the GitHub API.
+type busyLedgerDrift struct {
+	// stale are the runners recorded as busy that are idle or gone on GitHub
+	stale []string
+	// missed are the busy runners on GitHub that aren't recorded as busy, whose in_progress events were likely missed
+	missed []string
+	// percentage is the share of the mismatched runners among all the runners busy on either side
+	percentage float64
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments;runnersets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *BusyLedgerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("horizontalrunnerautoscaler", req.NamespacedName)
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := r.Get(ctx, req.NamespacedName, &hra); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !hra.DeletionTimestamp.IsZero() {
+		r.forget(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	state := r.state(req.NamespacedName)
+
+	// Reconciled on every update of the HRA, but the snapshots are taken only at the interval
+	if next := state.lastSnapshotTime.Add(state.interval); now.Before(next) {
+		return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+
+	config, runners, err := r.getScaleTargetRunners(ctx, hra)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if runners == nil {
+		return ctrl.Result{}, nil
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, hra.Namespace, config.GitHubAPICredentialsFrom)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ghRunners, err := ghc.ListRunners(ctx, config.Enterprise, config.Organization, config.Repository)
+	if err != nil {
+		var e *gogithub.RateLimitError
+		if errors.As(err, &e) {
+			log.Info(fmt.Sprintf("Failed to take busy runners snapshot due to GitHub API rate limits. Retrying in %s", retryDelayOnGitHubAPIRateLimitError))
+
+			return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	busy := map[string]bool{}
+
+	for _, runner := range ghRunners {
+		if runners[runner.GetName()] && runner.GetBusy() {
+			busy[runner.GetName()] = true
+		}
+	}
+
+	drift := computeBusyLedgerDrift(hra, busy, now)
+
+	metrics.SetHorizontalRunnerAutoscalerBusyLedgerDrift(hra.ObjectMeta, drift.percentage)
+
+	// A runner can be observed idle right between the completion of a job and the webhook event for it,
+	// so only the runners observed stale twice in a row are corrected.
+	var corrected []string
+
+	stale := map[string]bool{}
+
+	for _, name := range drift.stale {
+		if state.stale[name] {
+			corrected = append(corrected, name)
+		} else {
+			stale[name] = true
+		}
+	}
+
+	if len(corrected) > 0 {
+		released, err := r.releaseStaleCapacityReservations(ctx, hra, corrected, now)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		msg := fmt.Sprintf("Released %d replicas reserved for the jobs of runners that are no longer busy on GitHub: %v", released, corrected)
+
+		log.Info(msg)
+
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "BusyLedgerCorrected", msg)
+	}
+
+	threshold := r.DriftThreshold
+	if threshold <= 0 {
+		threshold = DefaultBusyLedgerDriftThreshold
+	}
+
+	interval := nextBusyLedgerSnapshotInterval(state.interval, r.Interval, drift.percentage, threshold)
+
+	log.V(1).Info("Took busy runners snapshot",
+		"busy", len(busy),
+		"stale", drift.stale,
+		"missed", drift.missed,
+		"drift_percentage", drift.percentage,
+		"next_snapshot_after", interval,
+	)
+
+	state.lastSnapshotTime = now
+	state.interval = interval
+	state.stale = stale
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// getScaleTargetRunners returns the runner config of the scale target, which tells the GitHub enterprise, organization,
+// repository and API credentials of its runners, and the names of its runners, which are the same as the names of its runner pods.
+// The runners are nil when the scale target isn't found.
+func (r *BusyLedgerReconciler) getScaleTargetRunners(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (v1alpha1.RunnerConfig, map[string]bool, error) {
+	var (
+		config   v1alpha1.RunnerConfig
+		labelKey string
+	)
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := r.Get(ctx, key, &rd); err != nil {
+			return config, nil, client.IgnoreNotFound(err)
+		}
+
+		config = rd.Spec.Template.Spec.RunnerConfig
+		labelKey = LabelKeyRunnerDeploymentName
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := r.Get(ctx, key, &rs); err != nil {
+			return config, nil, client.IgnoreNotFound(err)
+		}
+
+		config = rs.Spec.RunnerConfig
+		labelKey = LabelKeyRunnerSetName
+	default:
+		return config, nil, nil
+	}
+
+	var pods corev1.PodList
+
+	if err := r.List(ctx, &pods, client.InNamespace(hra.Namespace), client.MatchingLabels{labelKey: key.Name}); err != nil {
+		return config, nil, err
+	}
+
+	runners := map[string]bool{}
+
+	for _, pod := range pods.Items {
+		runners[pod.Name] = true
+	}
+
+	return config, runners, nil
+}
+
+// computeBusyLedgerDrift compares the runners recorded as busy in the valid capacity reservations of the HRA
+// with the runners busy on GitHub.
+func computeBusyLedgerDrift(hra v1alpha1.HorizontalRunnerAutoscaler, busy map[string]bool, now time.Time) busyLedgerDrift {
+	var drift busyLedgerDrift
+
+	recorded := map[string]bool{}
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		if cr.RunnerName == "" || !cr.ExpirationTime.Time.After(now) || recorded[cr.RunnerName] {
+			continue
+		}
+
+		recorded[cr.RunnerName] = true
+
+		if !busy[cr.RunnerName] {
+			drift.stale = append(drift.stale, cr.RunnerName)
+		}
+	}
+
+	for name := range busy {
+		if !recorded[name] {
+			drift.missed = append(drift.missed, name)
+		}
+	}
+
+	sort.Strings(drift.missed)
+
+	if total := len(recorded) + len(drift.missed); total > 0 {
+		drift.percentage = float64(len(drift.stale)+len(drift.missed)) * 100 / float64(total)
+	}
+
+	return drift
+}
+
+// nextBusyLedgerSnapshotInterval halves the interval while the drift exceeds the threshold, down to busyLedgerMinSnapshotInterval,
+// and doubles it back otherwise, up to the base interval.
+func nextBusyLedgerSnapshotInterval(current, base time.Duration, percentage, threshold float64) time.Duration {
+	if current <= 0 {
+		current = base
+	}
+
+	if percentage > threshold {
+		next := current / 2
+		if next < busyLedgerMinSnapshotInterval {
+			next = busyLedgerMinSnapshotInterval
+		}
+
+		return next
+	}
+
+	next := current * 2
+	if next > base {
+		next = base
+	}
+
+	return next
+}
+
+// releaseStaleCapacityReservations removes the capacity reservations recorded for the stale runners and returns the released replicas.
+func (r *BusyLedgerReconciler) releaseStaleCapacityReservations(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, runners []string, now time.Time) (int, error) {
+	stale := map[string]bool{}
+	for _, name := range runners {
+		stale[name] = true
+	}
+
+	copy := hra.DeepCopy()
+	copy.Spec.CapacityReservations = nil
+
+	var released int
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		if stale[cr.RunnerName] && cr.ExpirationTime.Time.After(now) {
+			released += cr.Replicas
+			continue
+		}
+
+		copy.Spec.CapacityReservations = append(copy.Spec.CapacityReservations, cr)
+	}
+
+	if err := r.Patch(ctx, copy, client.MergeFrom(&hra)); err != nil {
+		return 0, fmt.Errorf("patching horizontalrunnerautoscaler to release stale capacity reservations: %w", err)
+	}
+
+	return released, nil
+}
+
+// state returns the state of the snapshots for the HRA.
+// The state is updated without locking, because controller-runtime never reconciles the same HRA concurrently.
+func (r *BusyLedgerReconciler) state(key types.NamespacedName) *busyLedgerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.states == nil {
+		r.states = map[types.NamespacedName]*busyLedgerState{}
+	}
+
+	s, ok := r.states[key]
+	if !ok {
+		s = &busyLedgerState{}
+		r.states[key] = s
+	}
+
+	return s
+}
+
+func (r *BusyLedgerReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.states, key)
+}
+
+func (r *BusyLedgerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "busyledger-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		Named(name).
+		Complete(r)
+}
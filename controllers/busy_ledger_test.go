@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestBusyLedgerReconciler(t *testing.T) {
+	runners := []*github.Runner{
+		{ID: github.Int64(1), Name: github.String("example-a"), Status: github.String("online"), Busy: github.Bool(true)},
+		// Completed the job whose completion event was missed
+		{ID: github.Int64(2), Name: github.String("example-b"), Status: github.String("online"), Busy: github.Bool(false)},
+		// Picked up the job whose in_progress event was missed
+		{ID: github.Int64(3), Name: github.String("example-c"), Status: github.String("online"), Busy: github.Bool(true)},
+		// Not a runner of the scale target
+		{ID: github.Int64(4), Name: github.String("other-a"), Status: github.String("online"), Busy: github.Bool(true)},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+		j, err := json.Marshal(github.Runners{TotalCount: len(runners), Runners: runners})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiration := metav1.Time{Time: now.Add(time.Hour)}
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+	rd.Spec.Template.Spec.Repository = "test/valid"
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1", RunnerName: "example-a"},
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/2", RunnerName: "example-b"},
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/3"},
+			},
+		},
+	}
+
+	objs := []runtime.Object{rd, hra}
+	for _, name := range []string{"example-a", "example-b", "example-c"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+		})
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, objs...)
+	recorder := record.NewFakeRecorder(10)
+
+	clock := now
+
+	r := &BusyLedgerReconciler{
+		Client:       client,
+		GitHubClient: newGithubClient(server),
+		Log:          logr.Discard(),
+		Recorder:     recorder,
+		Interval:     8 * time.Minute,
+		now:          func() time.Time { return clock },
+	}
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func(wantRequeueAfter time.Duration) []v1alpha1.CapacityReservation {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Errorf("unexpected requeue after: want %s, got %s", wantRequeueAfter, res.RequeueAfter)
+		}
+
+		var got v1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got.Spec.CapacityReservations
+	}
+
+	// 2 of the 3 busy runners drifted, which halves the interval
+	if rs := reconcile(4 * time.Minute); len(rs) != 3 {
+		t.Fatalf("unexpected reservations released on the first observation: %+v", rs)
+	}
+
+	// No snapshot until the interval passes
+	clock = now.Add(time.Minute)
+
+	reconcile(3 * time.Minute)
+
+	clock = now.Add(4 * time.Minute)
+
+	rs := reconcile(2 * time.Minute)
+	if len(rs) != 2 || rs[0].RunnerName != "example-a" || rs[1].IdempotencyKey != "workflow_job/3" {
+		t.Fatalf("unexpected reservations after the correction: %+v", rs)
+	}
+
+	if got := len(recorder.Events); got != 1 {
+		t.Errorf("unexpected number of correction events: want 1, got %d", got)
+	}
+}
+
+func TestNextBusyLedgerSnapshotInterval(t *testing.T) {
+	base := 10 * time.Minute
+
+	testcases := []struct {
+		current    time.Duration
+		percentage float64
+		want       time.Duration
+	}{
+		{current: 0, percentage: 0, want: base},
+		{current: 0, percentage: 50, want: 5 * time.Minute},
+		{current: 5 * time.Minute, percentage: 10, want: base},
+		{current: 2 * time.Minute, percentage: 5, want: 4 * time.Minute},
+		{current: 90 * time.Second, percentage: 50, want: time.Minute},
+	}
+
+	for _, tc := range testcases {
+		if got := nextBusyLedgerSnapshotInterval(tc.current, base, tc.percentage, 10); got != tc.want {
+			t.Errorf("unexpected interval for current=%s, percentage=%v: want %s, got %s", tc.current, tc.percentage, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/status,verbs=get;update;patch
+
+// busyRunnersFromWorkflowJobEvents returns true when the HRA counts the busy runners of PercentageRunnersBusy
+// from the workflow_job events instead of the GitHub API.
+func busyRunnersFromWorkflowJobEvents(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	for _, m := range hra.Spec.Metrics {
+		if m.Type == v1alpha1.AutoscalingMetricTypePercentageRunnersBusy && m.BusyRunnersSource == v1alpha1.BusyRunnersSourceWorkflowJobEvents {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suggestedReplicasCacheable returns false when the suggested replicas need no GitHub API calls to be computed,
+// in which case they're recomputed on every reconciliation to follow the workflow_job events in near-real-time.
+func suggestedReplicasCacheable(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	return len(hra.Spec.Metrics) != 1 || !busyRunnersFromWorkflowJobEvents(hra)
+}
+
+// updateBusyRunners marks the runner busy running the job, or idle, and returns true when the busy runners have changed.
+// A runner runs one job at a time, so the runner picking up another job replaces its previous job
+// whose completed event was missed.
+func updateBusyRunners(runners []v1alpha1.BusyRunner, name string, jobID int64, busy bool, now time.Time) ([]v1alpha1.BusyRunner, bool) {
+	var (
+		updated []v1alpha1.BusyRunner
+		changed bool
+	)
+
+	for _, r := range runners {
+		if r.Name != name {
+			updated = append(updated, r)
+
+			continue
+		}
+
+		// The same in_progress event can be redelivered
+		if busy && r.JobID == jobID {
+			return runners, false
+		}
+
+		changed = true
+	}
+
+	if busy {
+		updated = append(updated, v1alpha1.BusyRunner{Name: name, JobID: jobID, Since: metav1.Time{Time: now}})
+		changed = true
+	}
+
+	return updated, changed
+}
+
+// countBusyRunners returns the number of the busy runners that are still in runnerMap.
+func countBusyRunners(runners []v1alpha1.BusyRunner, runnerMap map[string]struct{}) int {
+	var busy int
+
+	for _, r := range runners {
+		if _, ok := runnerMap[r.Name]; ok {
+			busy++
+		}
+	}
+
+	return busy
+}
+
+// pruneBusyRunners removes the busy runners that no longer exist, whose completed events were missed.
+func pruneBusyRunners(runners []v1alpha1.BusyRunner, runnerMap map[string]struct{}) []v1alpha1.BusyRunner {
+	var pruned []v1alpha1.BusyRunner
+
+	for _, r := range runners {
+		if _, ok := runnerMap[r.Name]; ok {
+			pruned = append(pruned, r)
+		}
+	}
+
+	return pruned
+}
+
+// trackBusyRunner records the runner that picked up the workflow job as busy, or the runner that completed it as idle,
+// to the HRAs that count the busy runners of their scale targets from the workflow_job events.
+// Failures are only logged, because the runner is counted correctly again on its next job.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) trackBusyRunner(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, runnerName string) {
+	if runnerName == "" {
+		return
+	}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := autoscaler.Client.List(ctx, &hras, client.InNamespace(autoscaler.Namespace)); err != nil {
+		log.Error(err, "Failed to list horizontalrunnerautoscalers for tracking busy runner")
+
+		return
+	}
+
+	var candidates []v1alpha1.HorizontalRunnerAutoscaler
+
+	for _, hra := range hras.Items {
+		if busyRunnersFromWorkflowJobEvents(hra) {
+			candidates = append(candidates, hra)
+		}
+	}
+
+	// Most clusters don't count the busy runners from the events, in which case the runner pod isn't even looked up
+	if len(candidates) == 0 {
+		return
+	}
+
+	pod, err := autoscaler.findRunnerPod(ctx, runnerName)
+	if err != nil {
+		log.Error(err, "Failed to find runner pod for tracking busy runner", "runnerName", runnerName)
+
+		return
+	}
+
+	if pod == nil {
+		return
+	}
+
+	kind, name := "RunnerDeployment", pod.Labels[LabelKeyRunnerDeploymentName]
+	if name == "" {
+		kind, name = "RunnerSet", pod.Labels[LabelKeyRunnerSetName]
+	}
+
+	busy := e.GetAction() == "in_progress"
+
+	for _, hra := range candidates {
+		ref := hra.Spec.ScaleTargetRef
+
+		refKind := ref.Kind
+		if refKind == "" {
+			refKind = "RunnerDeployment"
+		}
+
+		if hra.Namespace != pod.Namespace || refKind != kind || ref.Name != name {
+			continue
+		}
+
+		runners, changed := updateBusyRunners(hra.Status.BusyRunners, runnerName, e.GetWorkflowJob().GetID(), busy, time.Now())
+		if !changed {
+			return
+		}
+
+		copy := hra.DeepCopy()
+		copy.Status.BusyRunners = runners
+
+		if err := autoscaler.Client.Status().Patch(ctx, copy, client.MergeFrom(&hra)); err != nil {
+			log.Error(err, "Failed to record busy runner", "hra", hra.Name, "runnerName", runnerName)
+
+			return
+		}
+
+		log.V(1).Info("Recorded busy runner", "hra", hra.Name, "runnerName", runnerName, "busy", busy, "busyRunners", len(runners))
+
+		return
+	}
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestUpdateBusyRunners(t *testing.T) {
+	now := metav1.Time{Time: time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)}
+	later := metav1.Time{Time: now.Add(time.Minute)}
+
+	runners, changed := updateBusyRunners(nil, "runner-1", 1, true, now.Time)
+	if !changed {
+		t.Errorf("expected the busy runner to be added")
+	}
+
+	// Redelivered
+	if _, changed := updateBusyRunners(runners, "runner-1", 1, true, later.Time); changed {
+		t.Errorf("unexpected change on the redelivered event")
+	}
+
+	runners, _ = updateBusyRunners(runners, "runner-2", 2, true, now.Time)
+
+	// The completed event of the previous job was missed
+	runners, changed = updateBusyRunners(runners, "runner-1", 3, true, later.Time)
+	if !changed {
+		t.Errorf("expected the job of the busy runner to be replaced")
+	}
+
+	want := []actionsv1alpha1.BusyRunner{
+		{Name: "runner-2", JobID: 2, Since: now},
+		{Name: "runner-1", JobID: 3, Since: later},
+	}
+
+	if d := cmp.Diff(want, runners); d != "" {
+		t.Errorf("unexpected busy runners (-want +got):\n%s", d)
+	}
+
+	runners, changed = updateBusyRunners(runners, "runner-2", 2, false, later.Time)
+	if !changed {
+		t.Errorf("expected the idle runner to be removed")
+	}
+
+	if _, changed := updateBusyRunners(runners, "runner-2", 2, false, later.Time); changed {
+		t.Errorf("unexpected change for the runner that isn't busy")
+	}
+
+	runnerMap := map[string]struct{}{"runner-2": {}}
+
+	if n := countBusyRunners(runners, runnerMap); n != 0 {
+		t.Errorf("unexpected number of busy runners: %d", n)
+	}
+
+	if pruned := pruneBusyRunners(runners, runnerMap); len(pruned) != 0 {
+		t.Errorf("expected the busy runner that no longer exists to be pruned, got %v", pruned)
+	}
+}
+
+func TestTrackBusyRunner(t *testing.T) {
+	newHRA := func(name string, source string) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "runners"},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: name},
+				Metrics: []actionsv1alpha1.MetricSpec{
+					{Type: actionsv1alpha1.AutoscalingMetricTypePercentageRunnersBusy, BusyRunnersSource: source},
+				},
+			},
+		}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-runner-abcde",
+			Namespace: "runners",
+			Labels: map[string]string{
+				LabelKeyRunnerDeploymentName: "example-runner",
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, pod, newHRA("example-runner", actionsv1alpha1.BusyRunnersSourceWorkflowJobEvents), newHRA("other-runner", ""))
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	newEvent := func(action string) *github.WorkflowJobEvent {
+		return &github.WorkflowJobEvent{
+			Action:      github.String(action),
+			WorkflowJob: &github.WorkflowJob{ID: github.Int64(123456789)},
+		}
+	}
+
+	ctx := context.Background()
+
+	getBusyRunners := func() []string {
+		var hra actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, types.NamespacedName{Namespace: "runners", Name: "example-runner"}, &hra); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, r := range hra.Status.BusyRunners {
+			names = append(names, r.Name)
+		}
+
+		return names
+	}
+
+	// Jobs run by runners without pods, like GitHub-hosted runners, are not tracked
+	webhook.trackBusyRunner(ctx, webhook.Log, newEvent("in_progress"), "GitHub Actions 2")
+	webhook.trackBusyRunner(ctx, webhook.Log, newEvent("in_progress"), "example-runner-abcde")
+
+	if d := cmp.Diff([]string{"example-runner-abcde"}, getBusyRunners()); d != "" {
+		t.Errorf("unexpected busy runners (-want +got):\n%s", d)
+	}
+
+	webhook.trackBusyRunner(ctx, webhook.Log, newEvent("completed"), "example-runner-abcde")
+
+	if d := cmp.Diff([]string(nil), getBusyRunners()); d != "" {
+		t.Errorf("unexpected busy runners (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// LabelKeyRepositoryOwner and LabelKeyRepositoryName are the labels that have the repository of the workflow job
+	// on the pod of the ephemeral runner that picked up the job, so that cost allocation tools can attribute the cost
+	// of the pod to the repository.
+	LabelKeyRepositoryOwner = "actions-runner-controller/repository-owner"
+	LabelKeyRepositoryName  = "actions-runner-controller/repository-name"
+	LabelKeyWorkflowRunID   = "actions-runner-controller/workflow-run-id"
+
+	// AnnotationKeyRepository and AnnotationKeyWorkflow have the values that may not fit in labels as-is.
+	AnnotationKeyRepository    = "actions-runner-controller/repository"
+	AnnotationKeyWorkflow      = "actions-runner-controller/workflow"
+	AnnotationKeyWorkflowRunID = "actions-runner-controller/workflow-run-id"
+
+	// labelValueMaxLength is the maximum length of a label value allowed by Kubernetes.
+	labelValueMaxLength = 63
+)
+
+// workflowJobWorkflowName returns the name of the workflow of the workflow_job event.
+// go-github doesn't have the field yet, so it's parsed from the payload the same way as the runner name.
+// It's empty for GHES versions that don't send it.
+func workflowJobWorkflowName(log logr.Logger, payload []byte) string {
+	var workflowJob struct {
+		WorkflowJob struct {
+			WorkflowName string `json:"workflow_name,omitempty"`
+		} `json:"workflow_job,omitempty"`
+	}
+
+	if err := json.Unmarshal(payload, &workflowJob); err != nil {
+		log.Error(err, "could not parse workflow_job payload for extracting workflow name")
+	}
+
+	return workflowJob.WorkflowJob.WorkflowName
+}
+
+// chargebackReservation returns the capacity reservation made for the workflow job that the runner picked up,
+// or nil when the runner hasn't picked up a job the webhook-based autoscaler scaled for.
+func chargebackReservation(hras []v1alpha1.HorizontalRunnerAutoscaler, runnerName string) *v1alpha1.CapacityReservation {
+	for i := range hras {
+		for j := range hras[i].Spec.CapacityReservations {
+			r := &hras[i].Spec.CapacityReservations[j]
+
+			if r.RunnerName == runnerName && r.Repository != "" {
+				return r
+			}
+		}
+	}
+
+	return nil
+}
+
+// chargebackMetadata returns the labels and annotations for the pod of the runner that picked up the workflow job
+// of the capacity reservation.
+func chargebackMetadata(r v1alpha1.CapacityReservation) (map[string]string, map[string]string) {
+	labels := map[string]string{}
+	annotations := map[string]string{
+		AnnotationKeyRepository: r.Repository,
+	}
+
+	owner, name := r.Repository, ""
+	if i := strings.Index(r.Repository, "/"); i >= 0 {
+		owner, name = r.Repository[:i], r.Repository[i+1:]
+	}
+
+	if v := labelValue(owner); v != "" {
+		labels[LabelKeyRepositoryOwner] = v
+	}
+
+	if v := labelValue(name); v != "" {
+		labels[LabelKeyRepositoryName] = v
+	}
+
+	if r.Workflow != "" {
+		annotations[AnnotationKeyWorkflow] = r.Workflow
+	}
+
+	if r.WorkflowRunID != 0 {
+		id := strconv.FormatInt(r.WorkflowRunID, 10)
+
+		labels[LabelKeyWorkflowRunID] = id
+		annotations[AnnotationKeyWorkflowRunID] = id
+	}
+
+	return labels, annotations
+}
+
+// labelValue makes the string a valid label value, by replacing the characters not allowed in label values,
+// and trimming it to the maximum length and to start and end with an alphanumeric character.
+func labelValue(s string) string {
+	b := []byte(s)
+
+	for i, c := range b {
+		if !isAlphanumeric(c) && c != '-' && c != '_' && c != '.' {
+			b[i] = '_'
+		}
+	}
+
+	if len(b) > labelValueMaxLength {
+		b = b[:labelValueMaxLength]
+	}
+
+	return strings.TrimFunc(string(b), func(r rune) bool {
+		return !isAlphanumeric(byte(r))
+	})
+}
+
+func isAlphanumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// stampChargebackMetadata labels and annotates the pod of the ephemeral runner with the repository, workflow, and
+// workflow run of the job it picked up, which are recorded in the capacity reservation made for the job.
+// Non-ephemeral runners aren't stamped as they run jobs of various workflows over their lifetime.
+func (r *RunnerReconciler) stampChargebackMetadata(ctx context.Context, runner v1alpha1.Runner, pod corev1.Pod, log logr.Logger) error {
+	if runner.Spec.Ephemeral != nil && !*runner.Spec.Ephemeral {
+		return nil
+	}
+
+	if pod.Annotations[AnnotationKeyRepository] != "" {
+		return nil
+	}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hras, client.InNamespace(runner.Namespace)); err != nil {
+		return fmt.Errorf("listing horizontalrunnerautoscalers for chargeback labels: %w", err)
+	}
+
+	reservation := chargebackReservation(hras.Items, runner.Name)
+	if reservation == nil {
+		return nil
+	}
+
+	labels, annotations := chargebackMetadata(*reservation)
+
+	updated := pod.DeepCopy()
+
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+
+	for k, v := range labels {
+		updated.Labels[k] = v
+	}
+
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+
+	for k, v := range annotations {
+		updated.Annotations[k] = v
+	}
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&pod)); err != nil {
+		return fmt.Errorf("patching runner pod for chargeback labels: %w", err)
+	}
+
+	log.V(1).Info("Stamped chargeback labels on the runner pod", "repository", reservation.Repository, "workflowRunID", reservation.WorkflowRunID)
+
+	return nil
+}
+
+// runnersForCapacityReservations maps the HorizontalRunnerAutoscaler to the runners that picked up the workflow jobs
+// of its capacity reservations, so that their pods are stamped as soon as the runner names are recorded.
+func (r *RunnerReconciler) runnersForCapacityReservations(obj client.Object) []reconcile.Request {
+	hra, ok := obj.(*v1alpha1.HorizontalRunnerAutoscaler)
+	if !ok {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, reservation := range hra.Spec.CapacityReservations {
+		if reservation.RunnerName == "" || reservation.Repository == "" {
+			continue
+		}
+
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: hra.Namespace, Name: reservation.RunnerName}})
+	}
+
+	return reqs
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestStampChargebackMetadata(t *testing.T) {
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{Replicas: 1, RunnerName: "example-other"},
+				{Replicas: 1, RunnerName: "example-abc", Repository: "test/valid", Workflow: "CI", WorkflowRunID: 1234},
+			},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-abc", Namespace: "default", Labels: map[string]string{"app": "runner"}},
+	}
+
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-abc", Namespace: "default"},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra, pod)
+
+	r := &RunnerReconciler{Client: client}
+
+	ctx := context.Background()
+
+	if err := r.stampChargebackMetadata(ctx, runner, *pod, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got corev1.Pod
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-abc"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	wantLabels := map[string]string{
+		"app":                   "runner",
+		LabelKeyRepositoryOwner: "test",
+		LabelKeyRepositoryName:  "valid",
+		LabelKeyWorkflowRunID:   "1234",
+	}
+
+	if d := cmp.Diff(wantLabels, got.Labels); d != "" {
+		t.Errorf("unexpected labels (-want +got):\n%s", d)
+	}
+
+	wantAnnotations := map[string]string{
+		AnnotationKeyRepository:    "test/valid",
+		AnnotationKeyWorkflow:      "CI",
+		AnnotationKeyWorkflowRunID: "1234",
+	}
+
+	if d := cmp.Diff(wantAnnotations, got.Annotations); d != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", d)
+	}
+
+	wantRequests := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "example-abc"}}}
+
+	if d := cmp.Diff(wantRequests, r.runnersForCapacityReservations(hra)); d != "" {
+		t.Errorf("unexpected requests (-want +got):\n%s", d)
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	testcases := map[string]string{
+		"valid":                  "valid",
+		"my.repo_name-1":         "my.repo_name-1",
+		"-leading-and-trailing-": "leading-and-trailing",
+		"with space":             "with_space",
+		strings.Repeat("a", 70):  strings.Repeat("a", 63),
+	}
+
+	for in, want := range testcases {
+		if got := labelValue(in); got != want {
+			t.Errorf("unexpected label value for %q: want %q, got %q", in, want, got)
+		}
+	}
+}
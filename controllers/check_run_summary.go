@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultCheckRunSummaryName              = "Self-hosted runners"
+	DefaultCheckRunSummaryRunnerStartupTime = time.Minute
+)
+
+// checkRunSummary is the content of the check run summarizing the runners provisioned for the jobs of a workflow run.
+type checkRunSummary struct {
+	Status     string
+	Conclusion string
+	Title      string
+	Summary    string
+}
+
+// computeCheckRunSummary summarizes the jobs of the workflow run that need runners with the labels.
+// A job that is in progress or completed has been provisioned a runner, and the rest are waiting for one.
+// The check run is completed once every job has been provisioned a runner.
+func computeCheckRunSummary(hra v1alpha1.HorizontalRunnerAutoscaler, runID int64, labels []string, jobs []*gogithub.WorkflowJob) checkRunSummary {
+	var total, provisioned int
+
+	for _, j := range jobs {
+		if !sameRunnerLabels(j.Labels, labels) {
+			continue
+		}
+
+		total++
+
+		if s := j.GetStatus(); s == "in_progress" || s == "completed" {
+			provisioned++
+		}
+	}
+
+	waiting := total - provisioned
+	labelList := strings.Join(labels, ",")
+
+	if waiting == 0 {
+		return checkRunSummary{
+			Status:     "completed",
+			Conclusion: "neutral",
+			Title:      fmt.Sprintf("All %d runners provisioned", total),
+			Summary:    fmt.Sprintf("Every job of workflow run %d for runners with labels [%s] got a runner.", runID, labelList),
+		}
+	}
+
+	return checkRunSummary{
+		Status: "in_progress",
+		Title:  fmt.Sprintf("%d of %d runners provisioned", provisioned, total),
+		Summary: fmt.Sprintf(
+			"%d job(s) of workflow run %d are waiting for runners with labels [%s]. Estimated start in %s.",
+			waiting, runID, labelList, estimatedJobStart(hra),
+		),
+	}
+}
+
+// estimatedJobStart is the time for a new runner to start, plus the wait for a free runner estimated by the hosted runner fallback
+// while the desired replicas exceed MaxReplicas.
+func estimatedJobStart(hra v1alpha1.HorizontalRunnerAutoscaler) time.Duration {
+	d := DefaultCheckRunSummaryRunnerStartupTime
+	if t := hra.Spec.CheckRunSummary.RunnerStartupTime; t != nil {
+		d = t.Duration
+	}
+
+	if s := hra.Status.HostedRunnerFallback; s != nil {
+		d += time.Duration(s.EstimatedWaitSeconds) * time.Second
+	}
+
+	return d
+}
+
+// sameRunnerLabels returns true when both contain the same labels, which are case-insensitive like the runs-on of workflow jobs.
+func sameRunnerLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := map[string]bool{}
+	for _, l := range a {
+		set[strings.ToLower(l)] = true
+	}
+
+	for _, l := range b {
+		if !set[strings.ToLower(l)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkRunSummaryName(spec *v1alpha1.CheckRunSummarySpec, labels []string) string {
+	name := spec.Name
+	if name == "" {
+		name = DefaultCheckRunSummaryName
+	}
+
+	return fmt.Sprintf("%s (%s)", name, strings.Join(labels, ", "))
+}
+
+// updateCheckRunSummary creates or updates the check run summarizing the runners provisioned for the jobs of the workflow run
+// of the workflow job, when the HorizontalRunnerAutoscaler opts in to it.
+// The check run of the workflow run is identified by its external ID, which is the ID of the workflow run.
+// Failures are only logged, because the check run is informational and shouldn't fail scaling.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) updateCheckRunSummary(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, hra v1alpha1.HorizontalRunnerAutoscaler) {
+	spec := hra.Spec.CheckRunSummary
+	job := e.GetWorkflowJob()
+	sha, runID := job.GetHeadSHA(), job.GetRunID()
+
+	if spec == nil || sha == "" || runID == 0 {
+		return
+	}
+
+	ghc, err := gitHubClientForScaleTarget(ctx, autoscaler.Client, autoscaler.MultiGitHubClient, autoscaler.GitHubClient, hra)
+	if err != nil {
+		log.Error(err, "Failed to get the GitHub client for the check run summary")
+		return
+	}
+
+	if ghc == nil {
+		log.V(1).Info("Skipped updating the check run summary because the GitHub client isn't configured")
+		return
+	}
+
+	owner, repo := e.Repo.Owner.GetLogin(), e.Repo.GetName()
+
+	var jobs []*gogithub.WorkflowJob
+
+	opts := &gogithub.ListWorkflowJobsOptions{ListOptions: gogithub.ListOptions{PerPage: 100}}
+
+	for {
+		list, resp, err := ghc.Actions.ListWorkflowJobs(ctx, owner, repo, runID, opts)
+		if err != nil {
+			log.Error(err, "Failed to list workflow jobs for the check run summary", "runID", runID)
+			return
+		}
+
+		for _, j := range list.Jobs {
+			// The job in the event can be newer than the one returned by the API
+			if j.GetID() == job.GetID() {
+				j = job
+			}
+
+			jobs = append(jobs, j)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	summary := computeCheckRunSummary(hra, runID, job.Labels, jobs)
+
+	name := checkRunSummaryName(spec, job.Labels)
+	externalID := strconv.FormatInt(runID, 10)
+	output := &gogithub.CheckRunOutput{
+		Title:   gogithub.String(summary.Title),
+		Summary: gogithub.String(summary.Summary),
+	}
+
+	var (
+		conclusion  *string
+		completedAt *gogithub.Timestamp
+	)
+
+	if summary.Conclusion != "" {
+		conclusion = gogithub.String(summary.Conclusion)
+		completedAt = &gogithub.Timestamp{Time: time.Now()}
+	}
+
+	existing, _, err := ghc.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, &gogithub.ListCheckRunsOptions{CheckName: gogithub.String(name)})
+	if err != nil {
+		log.Error(err, "Failed to list check runs for the check run summary", "sha", sha)
+		return
+	}
+
+	for _, cr := range existing.CheckRuns {
+		if cr.GetExternalID() != externalID {
+			continue
+		}
+
+		_, _, err := ghc.Checks.UpdateCheckRun(ctx, owner, repo, cr.GetID(), gogithub.UpdateCheckRunOptions{
+			Name:        name,
+			ExternalID:  gogithub.String(externalID),
+			Status:      gogithub.String(summary.Status),
+			Conclusion:  conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+		})
+		if err != nil {
+			log.Error(err, "Failed to update the check run summary", "sha", sha, "checkRunID", cr.GetID())
+		}
+
+		return
+	}
+
+	_, _, err = ghc.Checks.CreateCheckRun(ctx, owner, repo, gogithub.CreateCheckRunOptions{
+		Name:        name,
+		HeadSHA:     sha,
+		ExternalID:  gogithub.String(externalID),
+		Status:      gogithub.String(summary.Status),
+		Conclusion:  conclusion,
+		CompletedAt: completedAt,
+		Output:      output,
+	})
+	if err != nil {
+		log.Error(err, "Failed to create the check run summary", "sha", sha)
+	}
+}
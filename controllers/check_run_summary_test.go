@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestComputeCheckRunSummary(t *testing.T) {
+	labels := []string{"self-hosted", "linux"}
+
+	newJob := func(status string, labels ...string) *github.WorkflowJob {
+		return &github.WorkflowJob{Status: github.String(status), Labels: labels}
+	}
+
+	newHRA := func(estimatedWaitSeconds int) v1alpha1.HorizontalRunnerAutoscaler {
+		hra := v1alpha1.HorizontalRunnerAutoscaler{
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				CheckRunSummary: &v1alpha1.CheckRunSummarySpec{
+					RunnerStartupTime: &metav1.Duration{Duration: 2 * time.Minute},
+				},
+			},
+		}
+
+		if estimatedWaitSeconds > 0 {
+			hra.Status.HostedRunnerFallback = &v1alpha1.HostedRunnerFallbackStatus{EstimatedWaitSeconds: estimatedWaitSeconds}
+		}
+
+		return hra
+	}
+
+	testcases := []struct {
+		name string
+		hra  v1alpha1.HorizontalRunnerAutoscaler
+		jobs []*github.WorkflowJob
+		want checkRunSummary
+	}{
+		{
+			name: "waiting",
+			hra:  newHRA(0),
+			jobs: []*github.WorkflowJob{
+				newJob("completed", "self-hosted", "linux"),
+				newJob("queued", "Linux", "self-hosted"),
+				newJob("queued", "self-hosted", "linux"),
+				newJob("queued", "self-hosted", "windows"),
+			},
+			want: checkRunSummary{
+				Status:  "in_progress",
+				Title:   "1 of 3 runners provisioned",
+				Summary: "2 job(s) of workflow run 123 are waiting for runners with labels [self-hosted,linux]. Estimated start in 2m0s.",
+			},
+		},
+		{
+			name: "waiting beyond max replicas",
+			hra:  newHRA(180),
+			jobs: []*github.WorkflowJob{
+				newJob("queued", "self-hosted", "linux"),
+			},
+			want: checkRunSummary{
+				Status:  "in_progress",
+				Title:   "0 of 1 runners provisioned",
+				Summary: "1 job(s) of workflow run 123 are waiting for runners with labels [self-hosted,linux]. Estimated start in 5m0s.",
+			},
+		},
+		{
+			name: "provisioned",
+			hra:  newHRA(0),
+			jobs: []*github.WorkflowJob{
+				newJob("completed", "self-hosted", "linux"),
+				newJob("in_progress", "self-hosted", "linux"),
+				newJob("queued", "self-hosted", "windows"),
+			},
+			want: checkRunSummary{
+				Status:     "completed",
+				Conclusion: "neutral",
+				Title:      "All 2 runners provisioned",
+				Summary:    "Every job of workflow run 123 for runners with labels [self-hosted,linux] got a runner.",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := computeCheckRunSummary(tc.hra, 123, labels, tc.jobs)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected summary (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestUpdateCheckRunSummary(t *testing.T) {
+	var (
+		existing []*github.CheckRun
+		created  []github.CreateCheckRunOptions
+		updated  []int64
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/runs/123/jobs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(github.Jobs{
+			TotalCount: github.Int(2),
+			Jobs: []*github.WorkflowJob{
+				{ID: github.Int64(1), Status: github.String("queued"), Labels: []string{"self-hosted"}},
+				{ID: github.Int64(2), Status: github.String("queued"), Labels: []string{"self-hosted"}},
+			},
+		})
+	})
+	mux.HandleFunc("/repos/owner/repo/commits/abc/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("check_name"); got != "Runners (self-hosted)" {
+			t.Errorf("unexpected check name: %s", got)
+		}
+		json.NewEncoder(w).Encode(github.ListCheckRunsResults{Total: github.Int(len(existing)), CheckRuns: existing})
+	})
+	mux.HandleFunc("/repos/owner/repo/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.CreateCheckRunOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Error(err)
+		}
+		created = append(created, opts)
+		json.NewEncoder(w).Encode(github.CheckRun{ID: github.Int64(99)})
+	})
+	mux.HandleFunc("/repos/owner/repo/check-runs/99", func(w http.ResponseWriter, r *http.Request) {
+		updated = append(updated, 99)
+		json.NewEncoder(w).Encode(github.CheckRun{ID: github.Int64(99)})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	autoscaler := &HorizontalRunnerAutoscalerGitHubWebhook{Client: fake.NewFakeClientWithScheme(sc), GitHubClient: newGithubClient(server)}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			CheckRunSummary: &v1alpha1.CheckRunSummarySpec{Name: "Runners"},
+		},
+	}
+
+	newEvent := func(status string) *github.WorkflowJobEvent {
+		return &github.WorkflowJobEvent{
+			WorkflowJob: &github.WorkflowJob{
+				ID:      github.Int64(1),
+				RunID:   github.Int64(123),
+				HeadSHA: github.String("abc"),
+				Status:  github.String(status),
+				Labels:  []string{"self-hosted"},
+			},
+			Repo: &github.Repository{Name: github.String("repo"), Owner: &github.User{Login: github.String("owner")}},
+		}
+	}
+
+	ctx := context.Background()
+
+	autoscaler.updateCheckRunSummary(ctx, logr.Discard(), newEvent("queued"), hra)
+
+	if len(created) != 1 || len(updated) != 0 {
+		t.Fatalf("expected a check run to be created, got %d created and %d updated", len(created), len(updated))
+	}
+
+	if got := created[0]; got.GetExternalID() != "123" || got.GetStatus() != "in_progress" || got.Output.GetTitle() != "0 of 2 runners provisioned" {
+		t.Errorf("unexpected check run: %+v", got)
+	}
+
+	existing = []*github.CheckRun{{ID: github.Int64(99), ExternalID: github.String("123")}}
+
+	// The job in the event is newer than the one listed by the API
+	autoscaler.updateCheckRunSummary(ctx, logr.Discard(), newEvent("in_progress"), hra)
+
+	if len(created) != 1 || len(updated) != 1 {
+		t.Errorf("expected the check run to be updated, got %d created and %d updated", len(created), len(updated))
+	}
+}
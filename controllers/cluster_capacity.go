@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// capacityShareRequest is the request of an HRA for the cluster's runner capacity.
+type capacityShareRequest struct {
+	key       string
+	priority  int
+	weight    int
+	requested int
+}
+
+func newCapacityShareRequest(hra v1alpha1.HorizontalRunnerAutoscaler, requested int) capacityShareRequest {
+	req := capacityShareRequest{
+		key:       types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}.String(),
+		weight:    1,
+		requested: requested,
+	}
+
+	if s := hra.Spec.CapacityShare; s != nil {
+		req.priority = s.Priority
+
+		if s.Weight != nil && *s.Weight > 1 {
+			req.weight = *s.Weight
+		}
+	}
+
+	return req
+}
+
+// allocateClusterCapacity computes the share of the cluster's runner capacity of the HRA that requests
+// the desired replicas, given the requests of all the other HRAs in the cluster.
+//
+// The requests of the other HRAs are read from their status, which is updated on each reconciliation,
+// so that the allocation is stateless and survives controller restarts, the same as allocateAccountRunners.
+func (r *HorizontalRunnerAutoscalerReconciler) allocateClusterCapacity(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, requested int) (*v1alpha1.ClusterCapacityStatus, error) {
+	self := newCapacityShareRequest(hra, requested)
+
+	requests := []capacityShareRequest{self}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hraList); err != nil {
+		return nil, fmt.Errorf("listing horizontalrunnerautoscalers: %w", err)
+	}
+
+	for _, other := range hraList.Items {
+		if (other.Namespace == hra.Namespace && other.Name == hra.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if s := other.Status.ClusterCapacity; s != nil {
+			requests = append(requests, newCapacityShareRequest(other, s.RequestedReplicas))
+		}
+	}
+
+	allocations := allocateByPriority(r.ClusterMaxRunners, requests)
+
+	return &v1alpha1.ClusterCapacityStatus{
+		MaxRunners:        r.ClusterMaxRunners,
+		RequestedReplicas: requested,
+		AllocatedReplicas: allocations[self.key],
+	}, nil
+}
+
+// allocateByPriority allocates max to the requests with the highest priority first, and what's left of it
+// to the requests with the next highest priority, and so on.
+// The requests with the same priority that don't fit in what's left share it by their weights.
+func allocateByPriority(max int, requests []capacityShareRequest) map[string]int {
+	sorted := append([]capacityShareRequest{}, requests...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].priority != sorted[j].priority {
+			return sorted[i].priority > sorted[j].priority
+		}
+
+		return sorted[i].key < sorted[j].key
+	})
+
+	allocations := make(map[string]int, len(sorted))
+
+	remaining := max
+
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j].priority == sorted[i].priority {
+			j++
+		}
+
+		remaining -= allocateFairShare(remaining, sorted[i:j], allocations)
+
+		i = j
+	}
+
+	return allocations
+}
+
+// allocateFairShare allocates up to max to the requests by weighted max-min fairness, and returns the allocated amount.
+// Each replica goes to the request with the smallest allocation per weight that still needs one,
+// with ties broken by the order of the requests, so that every HRA computes the same allocations.
+func allocateFairShare(max int, requests []capacityShareRequest, allocations map[string]int) int {
+	var total int
+
+	for _, req := range requests {
+		total += req.requested
+	}
+
+	if total <= max {
+		for _, req := range requests {
+			allocations[req.key] = req.requested
+		}
+
+		return total
+	}
+
+	for _, req := range requests {
+		allocations[req.key] = 0
+	}
+
+	for allocated := 0; allocated < max; allocated++ {
+		next := -1
+
+		for i, req := range requests {
+			if allocations[req.key] >= req.requested {
+				continue
+			}
+
+			// Compares allocation/weight after the next replica without dividing
+			if next < 0 || (allocations[req.key]+1)*requests[next].weight < (allocations[requests[next].key]+1)*req.weight {
+				next = i
+			}
+		}
+
+		allocations[requests[next].key]++
+	}
+
+	return max
+}
+
+// horizontalRunnerAutoscalersSharingClusterCapacity enqueues the other HRAs sharing the cluster's runner capacity
+// with the updated HRA, so that their allocations follow the changes in the requests without waiting for the next sync.
+// This is what makes the HRAs with a lower priority give the capacity back as soon as the ones with a higher priority need it.
+func (r *HorizontalRunnerAutoscalerReconciler) horizontalRunnerAutoscalersSharingClusterCapacity(obj client.Object) []reconcile.Request {
+	hra, ok := obj.(*v1alpha1.HorizontalRunnerAutoscaler)
+	if !ok || hra.Status.ClusterCapacity == nil {
+		return nil
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(context.TODO(), &hraList); err != nil {
+		r.Log.Error(err, "Failed to list horizontalrunnerautoscalers sharing the cluster capacity")
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, other := range hraList.Items {
+		if other.Namespace == hra.Namespace && other.Name == hra.Name {
+			continue
+		}
+
+		if other.Status.ClusterCapacity != nil {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: other.Namespace, Name: other.Name}})
+		}
+	}
+
+	return reqs
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestAllocateByPriority(t *testing.T) {
+	testcases := []struct {
+		name     string
+		max      int
+		requests []capacityShareRequest
+		want     map[string]int
+	}{
+		{
+			name: "within the capacity",
+			max:  10,
+			requests: []capacityShareRequest{
+				{key: "a", weight: 1, requested: 3},
+				{key: "b", weight: 1, requested: 4, priority: 1},
+			},
+			want: map[string]int{"a": 3, "b": 4},
+		},
+		{
+			name: "higher priority first",
+			max:  10,
+			requests: []capacityShareRequest{
+				{key: "low", weight: 1, requested: 8},
+				{key: "high", weight: 1, requested: 7, priority: 1},
+			},
+			want: map[string]int{"low": 3, "high": 7},
+		},
+		{
+			name: "higher priority takes all",
+			max:  10,
+			requests: []capacityShareRequest{
+				{key: "low", weight: 5, requested: 8},
+				{key: "high", weight: 1, requested: 12, priority: 1},
+			},
+			want: map[string]int{"low": 0, "high": 10},
+		},
+		{
+			name: "shared by weights",
+			max:  12,
+			requests: []capacityShareRequest{
+				{key: "a", weight: 2, requested: 20},
+				{key: "b", weight: 1, requested: 20},
+			},
+			want: map[string]int{"a": 8, "b": 4},
+		},
+		{
+			// b needs less than its share, and the rest of it goes to a and c
+			name: "max-min fairness",
+			max:  12,
+			requests: []capacityShareRequest{
+				{key: "a", weight: 1, requested: 20},
+				{key: "b", weight: 1, requested: 2},
+				{key: "c", weight: 1, requested: 20},
+			},
+			want: map[string]int{"a": 5, "b": 2, "c": 5},
+		},
+		{
+			// The odd replica goes to the first key
+			name: "ties",
+			max:  5,
+			requests: []capacityShareRequest{
+				{key: "b", weight: 1, requested: 10},
+				{key: "a", weight: 1, requested: 10},
+			},
+			want: map[string]int{"a": 3, "b": 2},
+		},
+		{
+			name: "what's left after the higher priority shared by weights",
+			max:  10,
+			requests: []capacityShareRequest{
+				{key: "high", weight: 1, requested: 4, priority: 10},
+				{key: "a", weight: 1, requested: 10},
+				{key: "b", weight: 2, requested: 10},
+			},
+			want: map[string]int{"high": 4, "a": 2, "b": 4},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := allocateByPriority(tc.max, tc.requests)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected allocations (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestAllocateClusterCapacity(t *testing.T) {
+	newHRA := func(ns, name string, share *actionsv1alpha1.CapacityShareSpec, requested *int) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       actionsv1alpha1.HorizontalRunnerAutoscalerSpec{CapacityShare: share},
+		}
+
+		if requested != nil {
+			hra.Status.ClusterCapacity = &actionsv1alpha1.ClusterCapacityStatus{RequestedReplicas: *requested}
+		}
+
+		return hra
+	}
+
+	self := newHRA("default", "self", &actionsv1alpha1.CapacityShareSpec{Priority: 1, Weight: intPtr(3)}, nil)
+
+	client := fake.NewFakeClientWithScheme(sc,
+		self,
+		newHRA("team-a", "release", &actionsv1alpha1.CapacityShareSpec{Priority: 2}, intPtr(5)),
+		newHRA("team-b", "build", &actionsv1alpha1.CapacityShareSpec{Priority: 1}, intPtr(30)),
+		newHRA("team-c", "nightly", nil, intPtr(30)),
+		// Not yet reconciled with the cluster capacity
+		newHRA("team-d", "build", &actionsv1alpha1.CapacityShareSpec{Priority: 2}, nil),
+	)
+
+	r := &HorizontalRunnerAutoscalerReconciler{Client: client, ClusterMaxRunners: 25}
+
+	got, err := r.allocateClusterCapacity(context.Background(), *self, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 25 - 5 for team-a/release, shared 3:1 with team-b/build
+	want := &actionsv1alpha1.ClusterCapacityStatus{
+		MaxRunners:        25,
+		RequestedReplicas: 30,
+		AllocatedReplicas: 15,
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected status (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Names of the controllers that can be tuned with ControllerOptions via ParseControllerOptions
+const (
+	ControllerNameRunner                     = "runner"
+	ControllerNameRunnerReplicaSet           = "runnerreplicaset"
+	ControllerNameRunnerDeployment           = "runnerdeployment"
+	ControllerNameRunnerSet                  = "runnerset"
+	ControllerNameRunnerPod                  = "runnerpod"
+	ControllerNameHorizontalRunnerAutoscaler = "horizontalrunnerautoscaler"
+	ControllerNameRunnerPodInterruption      = "runnerpodinterruption"
+)
+
+var controllerNames = []string{
+	ControllerNameRunner,
+	ControllerNameRunnerReplicaSet,
+	ControllerNameRunnerDeployment,
+	ControllerNameRunnerSet,
+	ControllerNameRunnerPod,
+	ControllerNameHorizontalRunnerAutoscaler,
+	ControllerNameRunnerPodInterruption,
+}
+
+// ControllerOptions tunes the throughput of a controller.
+//
+// The depth of the work queue of each controller is exported by controller-runtime as the workqueue_depth metric,
+// labeled with the name of the controller, which tells you whether you need to tune these.
+type ControllerOptions struct {
+	// MaxConcurrentReconciles is the maximum number of resources reconciled concurrently.
+	// Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ResyncPeriod is the duration after which a resource is reconciled again after a successful reconciliation
+	// that didn't request a requeue.
+	// Defaults to 0, which leaves it to the sync period of the manager.
+	ResyncPeriod time.Duration
+}
+
+func (o ControllerOptions) complete(b *builder.Builder, r reconcile.Reconciler) error {
+	if o.ResyncPeriod > 0 {
+		r = &resyncReconciler{Reconciler: r, period: o.ResyncPeriod}
+	}
+
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: o.MaxConcurrentReconciles}).Complete(r)
+}
+
+// resyncReconciler requeues the resource after the period on every successful reconciliation,
+// so that a controller can resync more or less often than the other controllers sharing the same cache.
+type resyncReconciler struct {
+	reconcile.Reconciler
+
+	period time.Duration
+}
+
+func (r *resyncReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	res, err := r.Reconciler.Reconcile(ctx, req)
+	if err != nil || res.Requeue || res.RequeueAfter > 0 {
+		return res, err
+	}
+
+	return reconcile.Result{RequeueAfter: r.period}, nil
+}
+
+// ParseControllerOptions returns the options per controller name from the values of the command-line flags.
+//
+// Each value is either NAME=VALUE, which applies to the controller of the NAME, or a bare VALUE,
+// which applies to all the controllers that aren't given their own value.
+func ParseControllerOptions(maxConcurrentReconciles, resyncPeriods []string) (map[string]ControllerOptions, error) {
+	opts := map[string]ControllerOptions{}
+
+	concurrencies, err := parseControllerValues(maxConcurrentReconciles, func(v string) (interface{}, error) {
+		n, err := strconv.Atoi(v)
+		if err == nil && n < 1 {
+			err = fmt.Errorf("must be greater than 0")
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing max concurrent reconciles: %w", err)
+	}
+
+	periods, err := parseControllerValues(resyncPeriods, func(v string) (interface{}, error) {
+		d, err := time.ParseDuration(v)
+		if err == nil && d < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing resync periods: %w", err)
+	}
+
+	for _, name := range controllerNames {
+		var o ControllerOptions
+
+		if v, ok := concurrencies[name]; ok {
+			o.MaxConcurrentReconciles = v.(int)
+		}
+
+		if v, ok := periods[name]; ok {
+			o.ResyncPeriod = v.(time.Duration)
+		}
+
+		opts[name] = o
+	}
+
+	return opts, nil
+}
+
+func parseControllerValues(values []string, parse func(string) (interface{}, error)) (map[string]interface{}, error) {
+	byName := map[string]interface{}{}
+
+	var (
+		defaultValue interface{}
+		hasDefault   bool
+	)
+
+	for _, kv := range values {
+		name, v := "", kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name, v = kv[:i], kv[i+1:]
+		}
+
+		parsed, err := parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", kv, err)
+		}
+
+		if name == "" {
+			defaultValue, hasDefault = parsed, true
+			continue
+		}
+
+		var known bool
+		for _, n := range controllerNames {
+			if n == name {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, fmt.Errorf("unknown controller %q in %q: valid controllers are %s", name, kv, strings.Join(controllerNames, ", "))
+		}
+
+		byName[name] = parsed
+	}
+
+	if hasDefault {
+		for _, n := range controllerNames {
+			if _, ok := byName[n]; !ok {
+				byName[n] = defaultValue
+			}
+		}
+	}
+
+	return byName, nil
+}
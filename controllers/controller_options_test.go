@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestParseControllerOptions(t *testing.T) {
+	opts, err := ParseControllerOptions([]string{"4", "runner=16"}, []string{"horizontalrunnerautoscaler=1m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]ControllerOptions{
+		ControllerNameRunner:                     {MaxConcurrentReconciles: 16},
+		ControllerNameRunnerReplicaSet:           {MaxConcurrentReconciles: 4},
+		ControllerNameRunnerDeployment:           {MaxConcurrentReconciles: 4},
+		ControllerNameRunnerSet:                  {MaxConcurrentReconciles: 4},
+		ControllerNameRunnerPod:                  {MaxConcurrentReconciles: 4},
+		ControllerNameHorizontalRunnerAutoscaler: {MaxConcurrentReconciles: 4, ResyncPeriod: time.Minute},
+		ControllerNameRunnerPodInterruption:      {MaxConcurrentReconciles: 4},
+	}
+
+	if d := cmp.Diff(want, opts); d != "" {
+		t.Errorf("unexpected options (-want +got):\n%s", d)
+	}
+
+	for _, tc := range []struct {
+		maxConcurrentReconciles, resyncPeriods []string
+	}{
+		{maxConcurrentReconciles: []string{"unknown=1"}},
+		{maxConcurrentReconciles: []string{"runner=0"}},
+		{maxConcurrentReconciles: []string{"runner=many"}},
+		{resyncPeriods: []string{"runner=-1m"}},
+		{resyncPeriods: []string{"runner=1"}},
+	} {
+		if _, err := ParseControllerOptions(tc.maxConcurrentReconciles, tc.resyncPeriods); err == nil {
+			t.Errorf("expected error for %v and %v", tc.maxConcurrentReconciles, tc.resyncPeriods)
+		}
+	}
+}
+
+type reconcilerFunc func(context.Context, reconcile.Request) (reconcile.Result, error)
+
+func (f reconcilerFunc) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	return f(ctx, req)
+}
+
+func TestResyncReconciler(t *testing.T) {
+	errReconcile := errors.New("reconcile error")
+
+	for _, tc := range []struct {
+		res  reconcile.Result
+		err  error
+		want reconcile.Result
+	}{
+		{res: reconcile.Result{}, want: reconcile.Result{RequeueAfter: time.Minute}},
+		{res: reconcile.Result{RequeueAfter: time.Second}, want: reconcile.Result{RequeueAfter: time.Second}},
+		{res: reconcile.Result{Requeue: true}, want: reconcile.Result{Requeue: true}},
+		{res: reconcile.Result{}, err: errReconcile, want: reconcile.Result{}},
+	} {
+		r := &resyncReconciler{
+			Reconciler: reconcilerFunc(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+				return tc.res, tc.err
+			}),
+			period: time.Minute,
+		}
+
+		got, err := r.Reconcile(context.Background(), reconcile.Request{})
+		if err != tc.err {
+			t.Errorf("unexpected error: want %v, got %v", tc.err, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("unexpected result: want %+v, got %+v", tc.want, got)
+		}
+	}
+}
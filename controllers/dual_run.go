@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+const DefaultDualRunObservationWindow = 24 * time.Hour
+
+// dualRunDesiredReplicas returns the desired replicas before being clamped by min and max replicas,
+// calculated by the one of the two calculations that drives the replicas in the dual-run mode.
+func dualRunDesiredReplicas(spec v1alpha1.DualRunSpec, suggested, minReplicas, reserved int) int {
+	if spec.DrivenBy == v1alpha1.DualRunDrivenByWebhook {
+		return minReplicas + reserved
+	}
+
+	return suggested
+}
+
+// clampReplicas clamps the replicas by min and max replicas in the same way as the desired replicas.
+func clampReplicas(hra v1alpha1.HorizontalRunnerAutoscaler, replicas, minReplicas int) int {
+	if replicas < minReplicas {
+		return minReplicas
+	}
+
+	if hra.Spec.MaxReplicas != nil && replicas > *hra.Spec.MaxReplicas {
+		return *hra.Spec.MaxReplicas
+	}
+
+	return replicas
+}
+
+// computeDualRunStatus adds the sample of the two calculations to the report of the current observation window.
+// Once the window elapses, the report is moved to Last and a new window starts with the sample.
+func computeDualRunStatus(hra v1alpha1.HorizontalRunnerAutoscaler, metricsReplicas, webhookReplicas int, now time.Time) v1alpha1.DualRunStatus {
+	spec := hra.Spec.DualRun
+
+	window := DefaultDualRunObservationWindow
+	if spec.ObservationWindow != nil && spec.ObservationWindow.Duration > 0 {
+		window = spec.ObservationWindow.Duration
+	}
+
+	status := v1alpha1.DualRunStatus{
+		Current: v1alpha1.DualRunReport{StartTime: metav1.Time{Time: now}},
+	}
+
+	var scaledUp bool
+
+	if prev := hra.Status.DualRun; prev != nil {
+		status.Last = prev.Last
+
+		if now.Before(prev.Current.StartTime.Add(window)) {
+			status.Current = prev.Current
+		} else {
+			last := prev.Current
+			last.EndTime = &metav1.Time{Time: now}
+			status.Last = &last
+		}
+
+		scaledUp = metricsReplicas > prev.MetricsReplicas
+	}
+
+	status.MetricsReplicas = metricsReplicas
+	status.WebhookReplicas = webhookReplicas
+
+	divergence := metricsReplicas - webhookReplicas
+	if divergence < 0 {
+		divergence = -divergence
+	}
+
+	report := &status.Current
+
+	report.Samples++
+
+	if divergence <= spec.Tolerance {
+		report.Agreements++
+	}
+
+	report.AgreementPercentage = report.Agreements * 100 / report.Samples
+	report.TotalDivergence += divergence
+
+	if divergence > report.MaxDivergence {
+		report.MaxDivergence = divergence
+	}
+
+	if scaledUp && webhookReplicas < metricsReplicas {
+		report.MissedScaleUps++
+	}
+
+	return status
+}
+
+// recordDualRun exposes the latest sample as metrics, and emits an event with the report of each completed observation window.
+func (r *HorizontalRunnerAutoscalerReconciler) recordDualRun(hra v1alpha1.HorizontalRunnerAutoscaler, status v1alpha1.DualRunStatus) {
+	metrics.SetHorizontalRunnerAutoscalerDualRun(hra.ObjectMeta, status)
+
+	last := status.Last
+	if last == nil || last.EndTime == nil {
+		return
+	}
+
+	if prev := hra.Status.DualRun; prev != nil && prev.Last != nil && prev.Last.StartTime.Equal(&last.StartTime) {
+		return
+	}
+
+	r.Recorder.Event(&hra, corev1.EventTypeNormal, "DualRunReport", fmt.Sprintf(
+		"Between %s and %s, the webhook-based calculation agreed with the metrics-based one on %d%% of %d samples, "+
+			"with the average divergence of %s replicas, the max divergence of %d replicas, and %d missed scale-ups",
+		last.StartTime.Format(time.RFC3339), last.EndTime.Format(time.RFC3339), last.AgreementPercentage, last.Samples,
+		dualRunAverageDivergence(*last), last.MaxDivergence, last.MissedScaleUps,
+	))
+}
+
+func dualRunAverageDivergence(report v1alpha1.DualRunReport) string {
+	if report.Samples == 0 {
+		return "0"
+	}
+
+	return fmt.Sprintf("%.2f", float64(report.TotalDivergence)/float64(report.Samples))
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDualRunDesiredReplicas(t *testing.T) {
+	if got := dualRunDesiredReplicas(v1alpha1.DualRunSpec{}, 5, 1, 2); got != 5 {
+		t.Errorf("expected the metrics-driven replicas to ignore the reservations, got %d", got)
+	}
+
+	if got := dualRunDesiredReplicas(v1alpha1.DualRunSpec{DrivenBy: v1alpha1.DualRunDrivenByWebhook}, 5, 1, 2); got != 3 {
+		t.Errorf("expected the webhook-driven replicas to ignore the metrics, got %d", got)
+	}
+}
+
+func TestComputeDualRunStatus(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	windowStart := metav1.Time{Time: now.Add(-time.Hour)}
+
+	newHRA := func(prev *v1alpha1.DualRunStatus) v1alpha1.HorizontalRunnerAutoscaler {
+		return v1alpha1.HorizontalRunnerAutoscaler{
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				DualRun: &v1alpha1.DualRunSpec{
+					ObservationWindow: &metav1.Duration{Duration: 2 * time.Hour},
+					Tolerance:         1,
+				},
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+				DualRun: prev,
+			},
+		}
+	}
+
+	testcases := []struct {
+		name    string
+		prev    *v1alpha1.DualRunStatus
+		metrics int
+		webhook int
+		want    v1alpha1.DualRunStatus
+	}{
+		{
+			name:    "first sample",
+			metrics: 3,
+			webhook: 2,
+			want: v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           metav1.Time{Time: now},
+					Samples:             1,
+					Agreements:          1,
+					AgreementPercentage: 100,
+					TotalDivergence:     1,
+					MaxDivergence:       1,
+				},
+				MetricsReplicas: 3,
+				WebhookReplicas: 2,
+			},
+		},
+		{
+			name: "missed scale-up",
+			prev: &v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           windowStart,
+					Samples:             1,
+					Agreements:          1,
+					AgreementPercentage: 100,
+				},
+				MetricsReplicas: 2,
+				WebhookReplicas: 2,
+			},
+			metrics: 6,
+			webhook: 3,
+			want: v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           windowStart,
+					Samples:             2,
+					Agreements:          1,
+					AgreementPercentage: 50,
+					TotalDivergence:     3,
+					MaxDivergence:       3,
+					MissedScaleUps:      1,
+				},
+				MetricsReplicas: 6,
+				WebhookReplicas: 3,
+			},
+		},
+		{
+			name: "webhook scaled up ahead of metrics",
+			prev: &v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           windowStart,
+					Samples:             1,
+					Agreements:          1,
+					AgreementPercentage: 100,
+				},
+				MetricsReplicas: 2,
+				WebhookReplicas: 2,
+			},
+			metrics: 3,
+			webhook: 5,
+			want: v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           windowStart,
+					Samples:             2,
+					Agreements:          1,
+					AgreementPercentage: 50,
+					TotalDivergence:     2,
+					MaxDivergence:       2,
+				},
+				MetricsReplicas: 3,
+				WebhookReplicas: 5,
+			},
+		},
+		{
+			name: "window completed",
+			prev: &v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           metav1.Time{Time: now.Add(-2 * time.Hour)},
+					Samples:             4,
+					Agreements:          3,
+					AgreementPercentage: 75,
+					TotalDivergence:     2,
+					MaxDivergence:       2,
+				},
+				MetricsReplicas: 2,
+				WebhookReplicas: 2,
+			},
+			metrics: 2,
+			webhook: 2,
+			want: v1alpha1.DualRunStatus{
+				Current: v1alpha1.DualRunReport{
+					StartTime:           metav1.Time{Time: now},
+					Samples:             1,
+					Agreements:          1,
+					AgreementPercentage: 100,
+				},
+				Last: &v1alpha1.DualRunReport{
+					StartTime:           metav1.Time{Time: now.Add(-2 * time.Hour)},
+					EndTime:             &metav1.Time{Time: now},
+					Samples:             4,
+					Agreements:          3,
+					AgreementPercentage: 75,
+					TotalDivergence:     2,
+					MaxDivergence:       2,
+				},
+				MetricsReplicas: 2,
+				WebhookReplicas: 2,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := computeDualRunStatus(newHRA(tc.prev), tc.metrics, tc.webhook, now)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected status (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package externalmetrics provides a minimal implementation of the external.metrics.k8s.io API
+// that exposes the metrics computed by HorizontalRunnerAutoscalers, so that
+// HorizontalPodAutoscaler, KEDA, and other tools can consume them.
+//
+// The server is meant to be registered to the Kubernetes API aggregation layer via an APIService.
+// It serves only GET requests for the metric values, and the API discovery of the group version,
+// and only to the API aggregation layer, which authenticates and authorizes the users in the first place.
+package externalmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// MetricDesiredReplicas is the desired replicas computed by the HorizontalRunnerAutoscaler.
+	MetricDesiredReplicas = "horizontalrunnerautoscaler-desired-replicas"
+	// MetricQueuedWorkflowRuns is the number of queued workflow runs and jobs observed by the HorizontalRunnerAutoscaler.
+	MetricQueuedWorkflowRuns = "horizontalrunnerautoscaler-queued-workflow-runs"
+	// MetricInProgressWorkflowRuns is the number of in-progress workflow runs and jobs observed by the HorizontalRunnerAutoscaler.
+	MetricInProgressWorkflowRuns = "horizontalrunnerautoscaler-in-progress-workflow-runs"
+
+	// LabelKeyHorizontalRunnerAutoscaler is the metric label that contains the name of the HorizontalRunnerAutoscaler.
+	// Use it in the metric selector to choose the HorizontalRunnerAutoscaler.
+	LabelKeyHorizontalRunnerAutoscaler = "horizontalrunnerautoscaler"
+	// LabelKeyScaleTarget is the metric label that contains the name of the scale target of the HorizontalRunnerAutoscaler.
+	LabelKeyScaleTarget = "scale-target"
+
+	apiPathPrefix = "/apis/" + externalmetricsv1beta1.GroupName + "/v1beta1"
+
+	// authenticationConfigMapNamespace and authenticationConfigMapName locate the ConfigMap that the API server publishes
+	// for the extension API servers, which contains the CA and the names of the client certificates of the API aggregation layer.
+	authenticationConfigMapNamespace = "kube-system"
+	authenticationConfigMapName      = "extension-apiserver-authentication"
+)
+
+var supportedMetrics = []string{
+	MetricDesiredReplicas,
+	MetricQueuedWorkflowRuns,
+	MetricInProgressWorkflowRuns,
+}
+
+// Server serves the external.metrics.k8s.io API.
+// It implements manager.Runnable so that it can be added to the controller manager.
+type Server struct {
+	Client client.Reader
+	Log    logr.Logger
+
+	// APIReader reads the extension-apiserver-authentication ConfigMap in kube-system, for authenticating
+	// the API aggregation layer by its client certificate. It should bypass the cache, like the APIReader of the manager.
+	APIReader client.Reader
+
+	// Addr is the address the server binds to.
+	Addr string
+
+	// CertDir is the directory that contains tls.crt and tls.key.
+	// A self-signed certificate is generated when the directory doesn't contain them,
+	// which works with an APIService with insecureSkipTLSVerify.
+	CertDir string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Every replica serves the API, because the API aggregation layer can route requests to any of them.
+// The values are read from the HorizontalRunnerAutoscalers, including the workflow runs recorded in their status by the leader,
+// so that every replica returns the same values.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	cert, err := s.loadCertificate()
+	if err != nil {
+		return err
+	}
+
+	clientCAs, allowedNames, err := s.loadRequestHeaderClientCA(ctx)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    s.Addr,
+		Handler: authenticateFrontProxy(s, allowedNames),
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.Log.Info("Starting external metrics server", "addr", s.Addr)
+
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) loadCertificate() (tls.Certificate, error) {
+	if s.CertDir != "" {
+		certFile := filepath.Join(s.CertDir, "tls.crt")
+		keyFile := filepath.Join(s.CertDir, "tls.key")
+
+		if _, err := os.Stat(certFile); err == nil {
+			return tls.LoadX509KeyPair(certFile, keyFile)
+		}
+	}
+
+	s.Log.Info("Using a self-signed certificate for the external metrics server")
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("actions-runner-controller-external-metrics", nil, nil)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generating self-signed certificate: %w", err)
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// loadRequestHeaderClientCA returns the CA of the client certificates of the API aggregation layer,
+// and the common names allowed for them, which can be empty to allow any.
+func (s *Server) loadRequestHeaderClientCA(ctx context.Context) (*x509.CertPool, []string, error) {
+	key := types.NamespacedName{Namespace: authenticationConfigMapNamespace, Name: authenticationConfigMapName}
+
+	var cm corev1.ConfigMap
+	if err := s.APIReader.Get(ctx, key, &cm); err != nil {
+		return nil, nil, fmt.Errorf("getting configmap %s: %w", key, err)
+	}
+
+	ca := cm.Data["requestheader-client-ca-file"]
+	if ca == "" {
+		return nil, nil, fmt.Errorf("configmap %s has no requestheader-client-ca-file. Is the API aggregation layer enabled?", key)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, nil, fmt.Errorf("invalid requestheader-client-ca-file in configmap %s", key)
+	}
+
+	var allowedNames []string
+
+	if v := cm.Data["requestheader-allowed-names"]; v != "" {
+		if err := json.Unmarshal([]byte(v), &allowedNames); err != nil {
+			return nil, nil, fmt.Errorf("invalid requestheader-allowed-names in configmap %s: %w", key, err)
+		}
+	}
+
+	return pool, allowedNames, nil
+}
+
+// authenticateFrontProxy rejects the requests that aren't from the API aggregation layer, whose client certificate
+// the TLS handshake has verified against the requestheader-client-ca-file, unless its common name is in allowedNames.
+func authenticateFrontProxy(next http.Handler, allowedNames []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			writeStatus(w, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "a client certificate of the API aggregation layer is required")
+			return
+		}
+
+		if len(allowedNames) > 0 {
+			cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+
+			var allowed bool
+			for _, n := range allowedNames {
+				if n == cn {
+					allowed = true
+					break
+				}
+			}
+
+			if !allowed {
+				writeStatus(w, http.StatusForbidden, metav1.StatusReasonForbidden, fmt.Sprintf("client certificate %q is not allowed", cn))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatus(w, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	if path == apiPathPrefix {
+		writeJSON(w, http.StatusOK, apiResourceList())
+		return
+	}
+
+	// /apis/external.metrics.k8s.io/v1beta1/namespaces/{namespace}/{metric}
+	parts := strings.Split(strings.TrimPrefix(path, apiPathPrefix+"/"), "/")
+	if !strings.HasPrefix(path, apiPathPrefix+"/") || len(parts) != 3 || parts[0] != "namespaces" {
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("%s not found", r.URL.Path))
+		return
+	}
+
+	namespace, metricName := parts[1], parts[2]
+
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid labelSelector: %v", err))
+		return
+	}
+
+	values, err := s.getMetricValues(r.Context(), namespace, metricName, selector)
+	if err != nil {
+		var nf notFoundError
+		if errors.As(err, &nf) {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, err.Error())
+			return
+		}
+
+		s.Log.Error(err, "Failed to get external metric", "namespace", namespace, "metric", metricName)
+
+		writeStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &externalmetricsv1beta1.ExternalMetricValueList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ExternalMetricValueList",
+			APIVersion: externalmetricsv1beta1.SchemeGroupVersion.String(),
+		},
+		Items: values,
+	})
+}
+
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+// getMetricValues returns one value per HorizontalRunnerAutoscaler in the namespace whose metric labels match the selector.
+// HorizontalRunnerAutoscalers without any value for the metric are omitted.
+func (s *Server) getMetricValues(ctx context.Context, namespace, metricName string, selector labels.Selector) ([]externalmetricsv1beta1.ExternalMetricValue, error) {
+	var valueOf func(hra v1alpha1.HorizontalRunnerAutoscaler) (int, bool)
+
+	switch metricName {
+	case MetricDesiredReplicas:
+		valueOf = func(hra v1alpha1.HorizontalRunnerAutoscaler) (int, bool) {
+			if hra.Status.DesiredReplicas == nil {
+				return 0, false
+			}
+			return *hra.Status.DesiredReplicas, true
+		}
+	case MetricQueuedWorkflowRuns:
+		valueOf = func(hra v1alpha1.HorizontalRunnerAutoscaler) (int, bool) {
+			if hra.Status.WorkflowRuns == nil {
+				return 0, false
+			}
+			return hra.Status.WorkflowRuns.Queued, true
+		}
+	case MetricInProgressWorkflowRuns:
+		valueOf = func(hra v1alpha1.HorizontalRunnerAutoscaler) (int, bool) {
+			if hra.Status.WorkflowRuns == nil {
+				return 0, false
+			}
+			return hra.Status.WorkflowRuns.InProgress, true
+		}
+	default:
+		return nil, notFoundError(fmt.Sprintf("external metric %q is not supported. Supported metrics are: %s", metricName, strings.Join(supportedMetrics, ", ")))
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+	if err := s.Client.List(ctx, &hraList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+
+	var values []externalmetricsv1beta1.ExternalMetricValue
+
+	for _, hra := range hraList.Items {
+		metricLabels := metricLabelsFor(hra)
+
+		if !selector.Matches(labels.Set(metricLabels)) {
+			continue
+		}
+
+		v, ok := valueOf(hra)
+		if !ok {
+			continue
+		}
+
+		values = append(values, externalmetricsv1beta1.ExternalMetricValue{
+			MetricName:   metricName,
+			MetricLabels: metricLabels,
+			Timestamp:    now,
+			Value:        *resource.NewQuantity(int64(v), resource.DecimalSI),
+		})
+	}
+
+	return values, nil
+}
+
+// metricLabelsFor returns the labels of the metrics of the HorizontalRunnerAutoscaler,
+// which are the labels of the HorizontalRunnerAutoscaler itself plus its name and the scale target name.
+func metricLabelsFor(hra v1alpha1.HorizontalRunnerAutoscaler) map[string]string {
+	l := map[string]string{}
+
+	for k, v := range hra.Labels {
+		l[k] = v
+	}
+
+	l[LabelKeyHorizontalRunnerAutoscaler] = hra.Name
+	l[LabelKeyScaleTarget] = hra.Spec.ScaleTargetRef.Name
+
+	return l
+}
+
+func apiResourceList() *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "APIResourceList",
+			APIVersion: "v1",
+		},
+		GroupVersion: externalmetricsv1beta1.SchemeGroupVersion.String(),
+	}
+
+	for _, m := range supportedMetrics {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       m,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      metav1.Verbs{"get"},
+		})
+	}
+
+	return list
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, msg string) {
+	writeJSON(w, code, &metav1.Status{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Status",
+			APIVersion: "v1",
+		},
+		Status:  metav1.StatusFailure,
+		Message: msg,
+		Reason:  reason,
+		Code:    int32(code),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(v)
+}
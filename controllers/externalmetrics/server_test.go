@@ -0,0 +1,269 @@
+package externalmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	certutil "k8s.io/client-go/util/cert"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	sc := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(sc); err != nil {
+		t.Fatal(err)
+	}
+
+	intPtr := func(v int) *int { return &v }
+
+	hras := []runtime.Object{
+		&v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "hra1", Namespace: "default", Labels: map[string]string{"team": "a"}},
+			Spec:       v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "rd1"}},
+			Status:     v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(3)},
+		},
+		&v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "hra2", Namespace: "default", Labels: map[string]string{"team": "b"}},
+			Spec:       v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "rd2"}},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+				DesiredReplicas: intPtr(5),
+				WorkflowRuns:    &v1alpha1.WorkflowRunsStatus{Queued: 4, InProgress: 1},
+			},
+		},
+	}
+
+	return &Server{
+		Client: fake.NewFakeClientWithScheme(sc, hras...),
+		Log:    zap.New(),
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	s := newTestServer(t)
+
+	testcases := []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantValues map[string]int64
+	}{
+		{
+			name:       "desired replicas of all",
+			path:       apiPathPrefix + "/namespaces/default/" + MetricDesiredReplicas,
+			wantCode:   http.StatusOK,
+			wantValues: map[string]int64{"hra1": 3, "hra2": 5},
+		},
+		{
+			name:       "desired replicas by hra labels",
+			path:       apiPathPrefix + "/namespaces/default/" + MetricDesiredReplicas + "?labelSelector=team%3Da",
+			wantCode:   http.StatusOK,
+			wantValues: map[string]int64{"hra1": 3},
+		},
+		{
+			name:       "desired replicas by scale target",
+			path:       apiPathPrefix + "/namespaces/default/" + MetricDesiredReplicas + "?labelSelector=scale-target%3Drd2",
+			wantCode:   http.StatusOK,
+			wantValues: map[string]int64{"hra2": 5},
+		},
+		{
+			name:       "queued workflow runs omits unobserved hras",
+			path:       apiPathPrefix + "/namespaces/default/" + MetricQueuedWorkflowRuns,
+			wantCode:   http.StatusOK,
+			wantValues: map[string]int64{"hra2": 4},
+		},
+		{
+			name:       "another namespace",
+			path:       apiPathPrefix + "/namespaces/other/" + MetricDesiredReplicas,
+			wantCode:   http.StatusOK,
+			wantValues: map[string]int64{},
+		},
+		{
+			name:     "unsupported metric",
+			path:     apiPathPrefix + "/namespaces/default/foo",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "invalid selector",
+			path:     apiPathPrefix + "/namespaces/default/" + MetricDesiredReplicas + "?labelSelector=%3D%3D",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("unexpected status code: want %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+
+			if tc.wantValues == nil {
+				return
+			}
+
+			var list externalmetricsv1beta1.ExternalMetricValueList
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]int64{}
+			for _, item := range list.Items {
+				got[item.MetricLabels[LabelKeyHorizontalRunnerAutoscaler]] = item.Value.Value()
+			}
+
+			if len(got) != len(tc.wantValues) {
+				t.Fatalf("unexpected values: want %v, got %v", tc.wantValues, got)
+			}
+
+			for k, v := range tc.wantValues {
+				if got[k] != v {
+					t.Errorf("unexpected value for %s: want %d, got %d", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Discovery(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiPathPrefix, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+
+	var list metav1.APIResourceList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if list.GroupVersion != "external.metrics.k8s.io/v1beta1" || len(list.APIResources) != len(supportedMetrics) {
+		t.Errorf("unexpected api resource list: %+v", list)
+	}
+}
+
+func TestAuthenticateFrontProxy(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	verified := func(cn string) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+		}
+	}
+
+	testcases := []struct {
+		name         string
+		tls          *tls.ConnectionState
+		allowedNames []string
+		wantCode     int
+	}{
+		{
+			name:     "no tls",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "no client certificate",
+			tls:      &tls.ConnectionState{},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "any name allowed",
+			tls:      verified("front-proxy-client"),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:         "allowed name",
+			tls:          verified("front-proxy-client"),
+			allowedNames: []string{"front-proxy-client"},
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "disallowed name",
+			tls:          verified("someone-else"),
+			allowedNames: []string{"front-proxy-client"},
+			wantCode:     http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, apiPathPrefix, nil)
+			req.TLS = tc.tls
+
+			rec := httptest.NewRecorder()
+
+			authenticateFrontProxy(ok, tc.allowedNames).ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Errorf("unexpected status code: want %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_LoadRequestHeaderClientCA(t *testing.T) {
+	caPEM, _, err := certutil.GenerateSelfSignedCertKey("front-proxy-ca", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := runtime.NewScheme()
+	if err := corev1.AddToScheme(sc); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: authenticationConfigMapNamespace, Name: authenticationConfigMapName},
+		Data: map[string]string{
+			"requestheader-client-ca-file": string(caPEM),
+			"requestheader-allowed-names":  `["front-proxy-client"]`,
+		},
+	}
+
+	s := &Server{APIReader: fake.NewFakeClientWithScheme(sc, cm)}
+
+	pool, allowedNames, err := s.loadRequestHeaderClientCA(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pool == nil {
+		t.Errorf("expected the client CA")
+	}
+
+	if len(allowedNames) != 1 || allowedNames[0] != "front-proxy-client" {
+		t.Errorf("unexpected allowed names: %v", allowedNames)
+	}
+
+	s = &Server{APIReader: fake.NewFakeClientWithScheme(sc)}
+
+	if _, _, err := s.loadRequestHeaderClientCA(context.Background()); err == nil {
+		t.Errorf("expected an error without the configmap")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+// webhookGHESVersion returns the version of the GitHub Enterprise Server that sent the webhook delivery,
+// or empty for GitHub.com and when it's unknown.
+// The version is taken from the X-GitHub-Enterprise-Version header of the delivery. Older servers that lack the header
+// are probed via the /meta API, when the webhook server's GitHub client is for the same host.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) webhookGHESVersion(ctx context.Context, r *http.Request, enterpriseHost string) string {
+	if v := r.Header.Get(github.HeaderGitHubEnterpriseVersion); v != "" {
+		return v
+	}
+
+	if enterpriseHost == "" {
+		return ""
+	}
+
+	client := autoscaler.gitHubClientFor(enterpriseHost)
+	if client == nil {
+		return ""
+	}
+
+	capabilities, err := client.DetectCapabilities(ctx)
+	if err != nil {
+		return ""
+	}
+
+	return capabilities.GHESVersion
+}
+
+// gitHubClientFor returns the GitHub client that can look up the resources of the webhook event sent from the host,
+// or nil when there's none. Events from GitHub.com, whose host is empty, are looked up by any client.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) gitHubClientFor(enterpriseHost string) *github.Client {
+	client := autoscaler.GitHubClient
+	if client == nil {
+		return nil
+	}
+
+	if enterpriseHost == "" {
+		return client
+	}
+
+	if client.BaseURL == nil || normalizeGitHubEnterpriseHost(client.BaseURL.Hostname()) != normalizeGitHubEnterpriseHost(enterpriseHost) {
+		return nil
+	}
+
+	return client
+}
+
+// compatWorkflowJobEvent fills in the fields of the workflow_job event that some GitHub Enterprise Server versions omit,
+// so that the event scales the runners instead of being ignored or routed by the repository alone:
+//
+//   - The action, which is derived from the status of the job.
+//   - The labels, which are looked up via the API. Without them, the job matches any runners of the repository.
+//   - The runner name of the in_progress event, which is looked up via the API.
+//
+// It returns the runner name of the job, if any.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) compatWorkflowJobEvent(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, payload []byte, enterpriseHost string) string {
+	job := e.GetWorkflowJob()
+	if job == nil {
+		return ""
+	}
+
+	if e.GetAction() == "" && job.GetStatus() != "" {
+		log.V(1).Info("Derived the missing action of the workflow_job event from the job status", "status", job.GetStatus())
+
+		e.Action = gogithub.String(job.GetStatus())
+	}
+
+	var runnerJob struct {
+		WorkflowJob struct {
+			RunnerName string `json:"runner_name,omitempty"`
+		} `json:"workflow_job,omitempty"`
+	}
+
+	if err := json.Unmarshal(payload, &runnerJob); err != nil {
+		log.Error(err, "could not parse workflow_job payload for extracting runner name")
+	}
+
+	runnerName := runnerJob.WorkflowJob.RunnerName
+
+	// An empty list of labels is sent as []. Only the payloads without the field leave it nil.
+	missingLabels := job.Labels == nil
+	missingRunnerName := runnerName == "" && e.GetAction() == "in_progress"
+
+	if !missingLabels && !missingRunnerName {
+		return runnerName
+	}
+
+	client := autoscaler.gitHubClientFor(enterpriseHost)
+
+	if client == nil || job.GetID() == 0 {
+		if missingLabels {
+			log.Info("The workflow_job event has no labels and the job can't be looked up. Matching the job by the repository only")
+		}
+
+		return runnerName
+	}
+
+	found, err := client.GetWorkflowJob(ctx, e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName(), job.GetID())
+	if err != nil {
+		log.Error(err, "Failed to look up the workflow job for the fields missing in the workflow_job event",
+			"missingLabels", missingLabels,
+			"missingRunnerName", missingRunnerName,
+		)
+
+		return runnerName
+	}
+
+	if missingLabels && found.WorkflowJob != nil {
+		job.Labels = append([]string{}, found.Labels...)
+
+		log.V(1).Info("Looked up the labels missing in the workflow_job event", "labels", job.Labels)
+	}
+
+	if missingRunnerName {
+		runnerName = found.RunnerName
+	}
+
+	return runnerName
+}
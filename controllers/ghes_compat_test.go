@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+)
+
+func TestCompatWorkflowJobEvent(t *testing.T) {
+	var lookups int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/jobs/1", func(w http.ResponseWriter, req *http.Request) {
+		lookups++
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":1,"status":"in_progress","labels":["self-hosted","gpu"],"runner_name":"example-abc"}`))
+	})
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-GitHub-Enterprise-Version", "3.2.5")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	autoscaler := &HorizontalRunnerAutoscalerGitHubWebhook{
+		GitHubClient: newGithubClient(server),
+		Log:          logr.Discard(),
+	}
+
+	testcases := []struct {
+		name           string
+		payload        string
+		enterpriseHost string
+		wantAction     string
+		wantLabels     []string
+		wantRunnerName string
+		wantLookups    int
+	}{
+		{
+			name:       "complete",
+			payload:    `{"action":"queued","workflow_job":{"id":1,"status":"queued","labels":["self-hosted"]}}`,
+			wantAction: "queued",
+			wantLabels: []string{"self-hosted"},
+		},
+		{
+			name:       "empty labels",
+			payload:    `{"action":"queued","workflow_job":{"id":1,"status":"queued","labels":[]}}`,
+			wantAction: "queued",
+			wantLabels: []string{},
+		},
+		{
+			name:        "missing labels",
+			payload:     `{"action":"queued","workflow_job":{"id":1,"status":"queued"}}`,
+			wantAction:  "queued",
+			wantLabels:  []string{"self-hosted", "gpu"},
+			wantLookups: 1,
+		},
+		{
+			name:        "missing action",
+			payload:     `{"workflow_job":{"id":1,"status":"completed","labels":["self-hosted"]}}`,
+			wantAction:  "completed",
+			wantLabels:  []string{"self-hosted"},
+			wantLookups: 0,
+		},
+		{
+			name:           "missing runner name",
+			payload:        `{"action":"in_progress","workflow_job":{"id":1,"status":"in_progress","labels":["self-hosted"]}}`,
+			wantAction:     "in_progress",
+			wantLabels:     []string{"self-hosted"},
+			wantRunnerName: "example-abc",
+			wantLookups:    1,
+		},
+		{
+			name:           "runner name",
+			payload:        `{"action":"in_progress","workflow_job":{"id":1,"status":"in_progress","labels":["self-hosted"],"runner_name":"example-def"}}`,
+			wantAction:     "in_progress",
+			wantLabels:     []string{"self-hosted"},
+			wantRunnerName: "example-def",
+		},
+		{
+			name:           "missing labels from the host of the client",
+			payload:        `{"action":"queued","workflow_job":{"id":1,"status":"queued"}}`,
+			enterpriseHost: "127.0.0.1",
+			wantAction:     "queued",
+			wantLabels:     []string{"self-hosted", "gpu"},
+			wantLookups:    1,
+		},
+		{
+			name:           "missing labels from another host",
+			payload:        `{"action":"queued","workflow_job":{"id":1,"status":"queued"}}`,
+			enterpriseHost: "ghes.example.com",
+			wantAction:     "queued",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookups = 0
+
+			var e github.WorkflowJobEvent
+			if err := json.Unmarshal([]byte(tc.payload), &e); err != nil {
+				t.Fatal(err)
+			}
+			e.Repo = &github.Repository{Name: github.String("valid"), Owner: &github.User{Login: github.String("test")}}
+
+			runnerName := autoscaler.compatWorkflowJobEvent(context.Background(), logr.Discard(), &e, []byte(tc.payload), tc.enterpriseHost)
+
+			if got := e.GetAction(); got != tc.wantAction {
+				t.Errorf("unexpected action: want %q, got %q", tc.wantAction, got)
+			}
+
+			if d := cmp.Diff(tc.wantLabels, e.WorkflowJob.Labels); d != "" {
+				t.Errorf("unexpected labels (-want +got):\n%s", d)
+			}
+
+			if runnerName != tc.wantRunnerName {
+				t.Errorf("unexpected runner name: want %q, got %q", tc.wantRunnerName, runnerName)
+			}
+
+			if lookups != tc.wantLookups {
+				t.Errorf("unexpected number of lookups: want %d, got %d", tc.wantLookups, lookups)
+			}
+		})
+	}
+
+	t.Run("version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+
+		if got := autoscaler.webhookGHESVersion(context.Background(), req, ""); got != "" {
+			t.Errorf("unexpected version for GitHub.com: %q", got)
+		}
+
+		if got := autoscaler.webhookGHESVersion(context.Background(), req, "127.0.0.1"); got != "3.2.5" {
+			t.Errorf("unexpected version probed via /meta: want %q, got %q", "3.2.5", got)
+		}
+
+		req.Header.Set("X-GitHub-Enterprise-Version", "3.4.0")
+
+		if got := autoscaler.webhookGHESVersion(context.Background(), req, "127.0.0.1"); got != "3.4.0" {
+			t.Errorf("unexpected version from the header: want %q, got %q", "3.4.0", got)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const envVarRunnerFeatureFlagEphemeral = "RUNNER_FEATURE_FLAG_EPHEMERAL"
+
+// degradeUnsupportedFeatures falls back to the legacy ephemeral runner, that runs with --once, when the runner sets
+// RUNNER_FEATURE_FLAG_EPHEMERAL but the GitHub server lacks the --ephemeral option, which would otherwise fail
+// the registration with a cryptic error. The fallback is reported in the FeaturesSupported condition of the runner.
+func (r *RunnerReconciler) degradeUnsupportedFeatures(ctx context.Context, log logr.Logger, runner v1alpha1.Runner, pod *corev1.Pod) error {
+	var unsupported error
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if c.Name != containerName {
+			continue
+		}
+
+		for j := range c.Env {
+			if c.Env[j].Name != envVarRunnerFeatureFlagEphemeral || c.Env[j].Value != "true" {
+				continue
+			}
+
+			if err := r.GitHubClient.CheckCapability(ctx, github.CapabilityEphemeralRunners); err != nil {
+				c.Env[j].Value = "false"
+				unsupported = err
+			}
+		}
+	}
+
+	current := meta.FindStatusCondition(runner.Status.Conditions, v1alpha1.RunnerConditionTypeFeaturesSupported)
+
+	updated := runner.DeepCopy()
+
+	if unsupported == nil {
+		if current == nil {
+			return nil
+		}
+
+		meta.RemoveStatusCondition(&updated.Status.Conditions, v1alpha1.RunnerConditionTypeFeaturesSupported)
+	} else {
+		cond := metav1.Condition{
+			Type:               v1alpha1.RunnerConditionTypeFeaturesSupported,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1alpha1.RunnerConditionReasonEphemeralRunnersUnsupported,
+			Message:            fmt.Sprintf("%v. Ignoring %s=true and falling back to the legacy ephemeral runner with --once", unsupported, envVarRunnerFeatureFlagEphemeral),
+			ObservedGeneration: runner.Generation,
+		}
+
+		if current != nil && current.Status == cond.Status && current.Reason == cond.Reason && current.Message == cond.Message {
+			return nil
+		}
+
+		meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+		log.Info(cond.Message)
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, cond.Reason, cond.Message)
+	}
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
+		return fmt.Errorf("updating the %s condition: %w", v1alpha1.RunnerConditionTypeFeaturesSupported, err)
+	}
+
+	return nil
+}
+
+// warnUnsupportedScaleUpTriggers reports the workflowJob scale up triggers of the HRA as a warning event when
+// the GitHub server never sends workflow_job events, which otherwise leaves the HRA silently not scaling.
+func (r *HorizontalRunnerAutoscalerReconciler) warnUnsupportedScaleUpTriggers(ctx context.Context, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler) {
+	if r.GitHubClient == nil {
+		return
+	}
+
+	for _, t := range hra.Spec.ScaleUpTriggers {
+		if t.GitHubEvent == nil || t.GitHubEvent.WorkflowJob == nil {
+			continue
+		}
+
+		if err := r.GitHubClient.CheckCapability(ctx, github.CapabilityWorkflowJobEvents); err != nil {
+			msg := fmt.Sprintf("%v. The workflowJob scale up trigger never scales the runners. Use checkRun or pull-based metrics instead", err)
+
+			r.LogRateLimiter.Info(log, "workflow_job_events_unsupported", msg)
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, "WorkflowJobEventsUnsupported", msg)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	githubfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+)
+
+func TestDegradeUnsupportedFeatures(t *testing.T) {
+	newPod := func() corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: containerName, Env: []corev1.EnvVar{{Name: envVarRunnerFeatureFlagEphemeral, Value: "true"}}},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		version       string
+		wantFlag      string
+		wantCondition bool
+	}{
+		{version: "3.2.5", wantFlag: "false", wantCondition: true},
+		{version: "3.3.0", wantFlag: "true", wantCondition: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.version, func(t *testing.T) {
+			server := githubfake.NewServer(githubfake.WithListRunnersResponse(200, githubfake.RunnersListBody), githubfake.WithEnterpriseVersion(tc.version))
+			defer server.Close()
+
+			runner := &v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+
+			client := fake.NewFakeClientWithScheme(sc, runner)
+
+			r := &RunnerReconciler{
+				Client:       client,
+				Recorder:     record.NewFakeRecorder(10),
+				GitHubClient: newGithubClient(server),
+			}
+
+			ctx := context.Background()
+			pod := newPod()
+
+			if err := r.degradeUnsupportedFeatures(ctx, logr.Discard(), *runner, &pod); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := pod.Spec.Containers[0].Env[0].Value; got != tc.wantFlag {
+				t.Errorf("unexpected %s: want %q, got %q", envVarRunnerFeatureFlagEphemeral, tc.wantFlag, got)
+			}
+
+			var got v1alpha1.Runner
+			if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, v1alpha1.RunnerConditionTypeFeaturesSupported)
+			if (cond != nil) != tc.wantCondition {
+				t.Fatalf("unexpected conditions: %+v", got.Status.Conditions)
+			}
+
+			if cond != nil && (cond.Status != metav1.ConditionFalse || cond.Reason != v1alpha1.RunnerConditionReasonEphemeralRunnersUnsupported) {
+				t.Errorf("unexpected condition: %+v", cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// HeaderGitHubEnterpriseHost is the header that GitHub Enterprise Server adds to every webhook delivery,
+	// whose value is the hostname of the GitHub Enterprise Server instance that sent the event.
+	HeaderGitHubEnterpriseHost = "X-GitHub-Enterprise-Host"
+
+	// AnnotationKeyGitHubEnterpriseHost limits the HRA to scale only on the webhook events sent from the GitHub Enterprise Server
+	// instance with the hostname, so that a single webhook server can serve multiple GitHub Enterprise Server instances
+	// whose organizations and repositories have the same names.
+	AnnotationKeyGitHubEnterpriseHost = "actions-runner-controller/github-enterprise-host"
+)
+
+type gitHubEnterpriseHostContextKey struct{}
+
+// withGitHubEnterpriseHost returns the context that carries the GitHub Enterprise Server hostname of the webhook event
+// down to the lookups of the scale targets.
+func withGitHubEnterpriseHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, gitHubEnterpriseHostContextKey{}, host)
+}
+
+func gitHubEnterpriseHostFrom(ctx context.Context) string {
+	host, _ := ctx.Value(gitHubEnterpriseHostContextKey{}).(string)
+
+	return host
+}
+
+// matchesGitHubEnterpriseHost returns true if the HRA accepts the webhook event sent from the host,
+// which is empty for events sent from GitHub.com.
+// HRAs without the AnnotationKeyGitHubEnterpriseHost annotation accept events from any host.
+func matchesGitHubEnterpriseHost(hra v1alpha1.HorizontalRunnerAutoscaler, host string) bool {
+	want := normalizeGitHubEnterpriseHost(hra.Annotations[AnnotationKeyGitHubEnterpriseHost])
+	if want == "" {
+		return true
+	}
+
+	return want == normalizeGitHubEnterpriseHost(host)
+}
+
+// filterHRAsByGitHubEnterpriseHost returns the HRAs that accept the webhook event sent from the host carried by the context.
+func filterHRAsByGitHubEnterpriseHost(ctx context.Context, hras []v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.HorizontalRunnerAutoscaler {
+	host := gitHubEnterpriseHostFrom(ctx)
+
+	var filtered []v1alpha1.HorizontalRunnerAutoscaler
+
+	for _, hra := range hras {
+		if matchesGitHubEnterpriseHost(hra, host) {
+			filtered = append(filtered, hra)
+		}
+	}
+
+	return filtered
+}
+
+func normalizeGitHubEnterpriseHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// resolveEnterpriseSlug returns the slug of the enterprise that the webhook event belongs to.
+// The slug in the payload is preferred. Some GitHub Enterprise Server versions omit the enterprise from the payloads,
+// in which case the slug configured for the host in EnterpriseHostSlugs is used.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) resolveEnterpriseSlug(log logr.Logger, payloadSlug, host string) string {
+	hostSlug := autoscaler.EnterpriseHostSlugs[normalizeGitHubEnterpriseHost(host)]
+
+	if payloadSlug == "" {
+		return hostSlug
+	}
+
+	if hostSlug != "" && hostSlug != payloadSlug {
+		log.Info(
+			"Enterprise slug in the payload differs from the one configured for the GitHub Enterprise Server host. Using the one in the payload",
+			"enterprise.slug", payloadSlug,
+			"host", host,
+			"hostEnterpriseSlug", hostSlug,
+		)
+	}
+
+	return payloadSlug
+}
+
+// ParseEnterpriseHostSlugs parses the comma-separated list of HOST=SLUG pairs, that map the hostnames of
+// GitHub Enterprise Server instances to the slugs of their enterprises.
+func ParseEnterpriseHostSlugs(s string) (map[string]string, error) {
+	slugs := map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid enterprise host slug %q: it must be in the HOST=SLUG format", pair)
+		}
+
+		slugs[normalizeGitHubEnterpriseHost(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return slugs, nil
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestParseEnterpriseHostSlugs(t *testing.T) {
+	got, err := ParseEnterpriseHostSlugs("GHES1.example.com=acme, ghes2.example.com=example,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"ghes1.example.com": "acme", "ghes2.example.com": "example"}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected slugs: %s", d)
+	}
+
+	if _, err := ParseEnterpriseHostSlugs("ghes1.example.com"); err == nil {
+		t.Errorf("expected error for the pair without slug")
+	}
+}
+
+func TestResolveEnterpriseSlug(t *testing.T) {
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		EnterpriseHostSlugs: map[string]string{"ghes.example.com": "acme"},
+	}
+
+	testcases := []struct {
+		payloadSlug, host, want string
+	}{
+		{payloadSlug: "", host: "", want: ""},
+		{payloadSlug: "", host: "GHES.example.com", want: "acme"},
+		{payloadSlug: "other", host: "ghes.example.com", want: "other"},
+		{payloadSlug: "", host: "unknown.example.com", want: ""},
+	}
+
+	for _, tc := range testcases {
+		if got := webhook.resolveEnterpriseSlug(logr.Discard(), tc.payloadSlug, tc.host); got != tc.want {
+			t.Errorf("unexpected slug for %q from %q: want %q, got %q", tc.payloadSlug, tc.host, tc.want, got)
+		}
+	}
+}
+
+func TestFindHRAsByKey_GitHubEnterpriseHost(t *testing.T) {
+	newHRA := func(name, host string) *v1alpha1.HorizontalRunnerAutoscaler {
+		hra := &v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+			},
+		}
+
+		if host != "" {
+			hra.Annotations = map[string]string{AnnotationKeyGitHubEnterpriseHost: host}
+		}
+
+		return hra
+	}
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{RunnerConfig: v1alpha1.RunnerConfig{Organization: "example"}},
+			},
+		},
+	}
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client: fake.NewFakeClientWithScheme(sc, rd, newHRA("ghes1", "ghes1.example.com"), newHRA("ghes2", "ghes2.example.com"), newHRA("any", "")),
+	}
+
+	testcases := []struct {
+		host string
+		want []string
+	}{
+		{host: "", want: []string{"any"}},
+		{host: "ghes1.example.com", want: []string{"any", "ghes1"}},
+		{host: "GHES2.example.com", want: []string{"any", "ghes2"}},
+	}
+
+	for _, tc := range testcases {
+		hras, err := webhook.findHRAsByKey(withGitHubEnterpriseHost(context.Background(), tc.host), "example")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, hra := range hras {
+			got = append(got, hra.Name)
+		}
+
+		if d := cmp.Diff(tc.want, got); d != "" {
+			t.Errorf("unexpected hras for %q: %s", tc.host, d)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// ResourceNameGPU is the resource that the NVIDIA device plugin exposes GPUs as.
+	// GPU nodes are usually tainted with the same key, like the ones of GKE and the NVIDIA GPU Operator.
+	ResourceNameGPU corev1.ResourceName = "nvidia.com/gpu"
+
+	// LabelKeyGPUProduct is the node label of NVIDIA GPU Feature Discovery that has the product name of the GPUs.
+	LabelKeyGPUProduct = "nvidia.com/gpu.product"
+
+	// RunnerLabelGPU is the runner label added to every runner with GPUs.
+	RunnerLabelGPU = "gpu"
+)
+
+// gpuRunnerLabels returns the labels that runners with the GPUs register themselves with.
+func gpuRunnerLabels(gpu *v1alpha1.GPUConfig) []string {
+	if gpu == nil {
+		return nil
+	}
+
+	labels := []string{RunnerLabelGPU}
+
+	if gpu.Type != "" {
+		labels = append(labels, gpu.Type)
+	}
+
+	return labels
+}
+
+// runnerRegistrationLabels returns the labels of the runner followed by the ones inferred from its GPUs,
+// without duplicates. GitHub compares labels case-insensitively.
+func runnerRegistrationLabels(runnerSpec v1alpha1.RunnerConfig) []string {
+	inferred := gpuRunnerLabels(runnerSpec.GPU)
+	if len(inferred) == 0 {
+		return runnerSpec.Labels
+	}
+
+	labels := append([]string{}, runnerSpec.Labels...)
+
+	for _, l := range inferred {
+		var found bool
+
+		for _, existing := range labels {
+			if strings.EqualFold(existing, l) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			labels = append(labels, l)
+		}
+	}
+
+	return labels
+}
+
+// setGPURequests requests the GPUs for the container, unless it already requests any.
+// Extended resources like GPUs can't be overcommitted, so the limit and the request are the same.
+func setGPURequests(c *corev1.Container, gpu v1alpha1.GPUConfig) {
+	if _, ok := c.Resources.Limits[ResourceNameGPU]; ok {
+		return
+	}
+
+	q := *resource.NewQuantity(gpu.Count, resource.DecimalSI)
+
+	limits := corev1.ResourceList{ResourceNameGPU: q}
+	for name, v := range c.Resources.Limits {
+		limits[name] = v
+	}
+
+	requests := corev1.ResourceList{ResourceNameGPU: q}
+	for name, v := range c.Resources.Requests {
+		requests[name] = v
+	}
+
+	c.Resources.Limits = limits
+	c.Resources.Requests = requests
+}
+
+// setGPUScheduling lets the pod tolerate the taint of GPU nodes, and selects the nodes with the type of the GPUs.
+// The tolerations and the node selector already set in the pod are kept.
+func setGPUScheduling(pod *corev1.Pod, gpu v1alpha1.GPUConfig) {
+	var tolerated bool
+
+	for _, t := range pod.Spec.Tolerations {
+		if t.Key == string(ResourceNameGPU) {
+			tolerated = true
+			break
+		}
+	}
+
+	if !tolerated {
+		pod.Spec.Tolerations = append(append([]corev1.Toleration{}, pod.Spec.Tolerations...), corev1.Toleration{
+			Key:      string(ResourceNameGPU),
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	if gpu.Type != "" {
+		setDefaultNodeSelector(pod, LabelKeyGPUProduct, gpu.Type)
+	}
+}
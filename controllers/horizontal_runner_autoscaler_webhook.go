@@ -17,6 +17,7 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -25,23 +26,46 @@ import (
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	gogithub "github.com/google/go-github/v39/github"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/anomaly"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/audit"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/tracing"
 )
 
 const (
-	scaleTargetKey = "scaleTarget"
+	// explicitRepositoryKey indexes HRAs by the repositories listed in their AnnotationKeyRepositories annotation
+	explicitRepositoryKey = "explicitRepository"
+
+	// AnnotationKeyRepositories is the annotation on a HorizontalRunnerAutoscaler that lists the comma-separated
+	// OWNER/REPO repositories whose workflow jobs scale the HRA, in preference to the other HRAs for the same repository,
+	// organization or enterprise.
+	AnnotationKeyRepositories = "actions-runner-controller/repositories"
+
+	// LabelPrefixExplicitHRA is the prefix of the runs-on label like arc-hra-NAME that makes the workflow job scale
+	// the HorizontalRunnerAutoscaler named NAME.
+	LabelPrefixExplicitHRA = "arc-hra-"
 
 	keyPrefixEnterprise = "enterprises/"
 	keyRunnerGroup      = "/group/"
@@ -59,25 +83,157 @@ type HorizontalRunnerAutoscalerGitHubWebhook struct {
 	// the administrator is generated and specified in GitHub Web UI.
 	SecretKeyBytes []byte
 
+	// AdditionalSecretKeyBytes are the Webhook secret tokens accepted in addition to SecretKeyBytes,
+	// like the previous token while rotating it.
+	AdditionalSecretKeyBytes [][]byte
+
+	// SecretKeysFile provides the Webhook secret tokens that can be rotated without restarting the server.
+	// When set, every request must be signed with one of the tokens, even if the file is empty.
+	SecretKeysFile *WebhookSecretsFile
+
+	// RequireSignature rejects every request that isn't signed with one of the Webhook secret tokens.
+	// Unlike the default, it rejects all the requests when no token is configured, instead of accepting them unvalidated.
+	RequireSignature bool
+
 	// GitHub Client to discover runner groups assigned to a repository
 	GitHubClient *github.Client
 
+	// MultiGitHubClient provides the clients for the scale targets whose runners select their GitHub endpoints and credentials
+	// by githubAPICredentialsFrom, for the check run summaries and the hosted runner fallback commit statuses. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
 	// Namespace is the namespace to watch for HorizontalRunnerAutoscaler's to be
 	// scaled on Webhook.
 	// Set to empty for letting it watch for all namespaces.
 	Namespace string
-	Name      string
+	// Namespaces are all the namespaces to watch, which can be more than one unlike Namespace.
+	// The reads that bypass the cache, like the ones by APIReader, are limited to them,
+	// so that the webhook server doesn't need the cluster-wide permissions.
+	Namespaces []string
+	Name       string
+
+	// EnterpriseHostSlugs maps the hostnames of GitHub Enterprise Server instances to the slugs of their enterprises,
+	// for resolving the enterprise of the webhook events whose payloads don't contain it.
+	// See HeaderGitHubEnterpriseHost.
+	EnterpriseHostSlugs map[string]string
+
+	// DryRun makes the webhook server resolve the scale targets and compute the capacity reservations as usual,
+	// but only log and count them in the horizontalrunnerautoscaler_dry_run_replicas_total metric
+	// instead of patching HRAs, so that a new configuration can be validated against the production traffic.
+	// Other writes, like recording WorkflowJobTraces and the commit statuses of the hosted runner fallback, are skipped too.
+	DryRun bool
+
+	// RunnerPools makes the capacity reservations for workflow_job events distributed across the members of RunnerPools.
+	// Requires the RunnerPool CRD.
+	RunnerPools bool
+
+	// RuntimeSettings overrides DryRun without restarting the webhook server when set.
+	RuntimeSettings *RuntimeSettingsFile
+
+	// LogRateLimiter suppresses the log lines that repeat for every webhook event during event storms
+	LogRateLimiter *logging.RateLimiter
+
+	// AnomalyNotifier is notified of the queued workflow jobs without scale targets and the failed scale ups. Can be nil.
+	AnomalyNotifier *anomaly.Notifier
+
+	// WorkflowJobTraceTTL is how long the WorkflowJobTrace recorded for each workflow job run by our runners is kept.
+	// Zero disables the recording.
+	WorkflowJobTraceTTL time.Duration
+
+	// UnmatchedJobs keeps the queued workflow jobs without scale targets for operators to discover,
+	// and for retrying the scale up once a matching HRA is created or updated. Can be nil.
+	UnmatchedJobs *UnmatchedJobs
+
+	// AuditLog records every webhook delivery along with the scale target and the replica math. Can be nil.
+	AuditLog *audit.Log
+
+	// Sources validate the deliveries of the webhooks of multiple GitHub organizations or GitHub Apps with their own secret tokens,
+	// instead of the ones above, and limit the namespaces of their scale targets. Can be nil.
+	Sources *WebhookSources
+
+	// APIReader reads runner pods without caching all the pods in the cluster, for recording WorkflowJobTraces.
+	// Defaults to the Client.
+	APIReader client.Reader
+
+	routes hraRoutingTable
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+// Reconcile keeps the route of the HRA in the routing table up to date,
+// and retries the unmatched workflow jobs that the HRA may match after the change.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if autoscaler.Namespace != "" && request.Namespace != autoscaler.Namespace {
+		return ctrl.Result{}, nil
+	}
+
+	if err := autoscaler.routes.update(ctx, autoscaler.Client, request.NamespacedName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := autoscaler.retryUnmatchedJobs(ctx, request.NamespacedName); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerpools,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
+// dryRun returns true when the dry-run mode is currently enabled, by RuntimeSettings if set, or by DryRun otherwise.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) dryRun() bool {
+	return autoscaler.RuntimeSettings.DryRun(autoscaler.DryRun)
+}
+
+// secretKeys returns all the Webhook secret tokens that are currently accepted.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) secretKeys() [][]byte {
+	var keys [][]byte
+
+	if len(autoscaler.SecretKeyBytes) > 0 {
+		keys = append(keys, autoscaler.SecretKeyBytes)
+	}
+
+	for _, k := range autoscaler.AdditionalSecretKeyBytes {
+		if len(k) > 0 {
+			keys = append(keys, k)
+		}
+	}
+
+	if autoscaler.SecretKeysFile != nil {
+		keys = append(keys, autoscaler.SecretKeysFile.Keys()...)
+	}
+
+	return keys
+}
+
+// validatePayloadWithAnyOf returns the payload of the request if it is signed with any of the secret tokens.
+// The request body is read only once and replayed for each token.
+func validatePayloadWithAnyOf(r *http.Request, secretKeys [][]byte) ([]byte, error) {
+	if len(secretKeys) == 0 {
+		return nil, fmt.Errorf("no webhook secret token is available to validate the request")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range secretKeys {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		payload, verr := gogithub.ValidatePayload(r, key)
+		if verr == nil {
+			return payload, nil
+		}
+
+		err = verr
+	}
+
+	return nil, err
+}
+
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.ResponseWriter, r *http.Request) {
 	var (
 		ok bool
@@ -85,6 +241,21 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		err error
 	)
 
+	traceCtx, span := tracing.Start(
+		otel.GetTextMapPropagator().Extract(context.TODO(), propagation.HeaderCarrier(r.Header)),
+		"Handle",
+		attribute.String("github.event", gogithub.WebHookType(r)),
+		attribute.String("github.delivery", r.Header.Get("X-GitHub-Delivery")),
+	)
+
+	defer func() {
+		if !ok && err == nil {
+			span.SetStatus(codes.Error, "failed handling webhook event")
+		}
+
+		tracing.End(span, err)
+	}()
+
 	defer func() {
 		if !ok {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -111,13 +282,52 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		return
 	}
 
+	rec := audit.Record{
+		Event:    gogithub.WebHookType(r),
+		Delivery: r.Header.Get("X-GitHub-Delivery"),
+	}
+
+	defer func() {
+		if rec.Decision == "" {
+			if ok {
+				rec.Decision = audit.DecisionIgnored
+			} else {
+				rec.Decision = audit.DecisionFailed
+			}
+		}
+
+		if err != nil && rec.Error == "" {
+			rec.Error = err.Error()
+		}
+
+		autoscaler.AuditLog.Record(rec)
+	}()
+
 	var payload []byte
 
-	if len(autoscaler.SecretKeyBytes) > 0 {
-		payload, err = gogithub.ValidatePayload(r, autoscaler.SecretKeyBytes)
+	source, err := autoscaler.findWebhookSource(r)
+	if err != nil {
+		autoscaler.Log.Error(err, "error reading request body")
+
+		return
+	}
+
+	if source != nil {
+		payload, err = validatePayloadWithAnyOf(r, source.keys)
+		if err != nil {
+			autoscaler.Log.Error(err, "error validating request body", "webhookSource", source.name)
+
+			rec.Decision = audit.DecisionRejected
+
+			return
+		}
+	} else if secretKeys := autoscaler.secretKeys(); len(secretKeys) > 0 || autoscaler.SecretKeysFile != nil || autoscaler.RequireSignature {
+		payload, err = validatePayloadWithAnyOf(r, secretKeys)
 		if err != nil {
 			autoscaler.Log.Error(err, "error validating request body")
 
+			rec.Decision = audit.DecisionRejected
+
 			return
 		}
 	} else {
@@ -139,17 +349,55 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 		autoscaler.Log.Error(err, "could not parse webhook", "webhookType", webhookType, "payload", s)
 
+		rec.Decision = audit.DecisionRejected
+
 		return
 	}
 
+	if autoscaler.AuditLog != nil {
+		var auditEvent struct {
+			Action     string `json:"action,omitempty"`
+			Repository struct {
+				FullName string `json:"full_name,omitempty"`
+			} `json:"repository,omitempty"`
+		}
+
+		if err := json.Unmarshal(payload, &auditEvent); err == nil {
+			rec.Action = auditEvent.Action
+			rec.Repository = auditEvent.Repository.FullName
+		}
+	}
+
 	var target *ScaleTarget
 
+	enterpriseHost := r.Header.Get(HeaderGitHubEnterpriseHost)
+
 	log := autoscaler.Log.WithValues(
 		"event", webhookType,
 		"hookID", r.Header.Get("X-GitHub-Hook-ID"),
 		"delivery", r.Header.Get("X-GitHub-Delivery"),
 	)
 
+	if enterpriseHost != "" {
+		log = log.WithValues("enterpriseHost", enterpriseHost)
+	}
+
+	// Only the HRAs that accept the events from the GitHub Enterprise Server host are considered as the scale targets
+	ctx := withGitHubEnterpriseHost(traceCtx, enterpriseHost)
+
+	if source != nil {
+		log = log.WithValues("webhookSource", source.name)
+
+		// Only the HRAs in the namespaces of the WebhookSource are considered as the scale targets
+		ctx = withWebhookSourceNamespaces(ctx, source.namespaces)
+	}
+
+	if ghesVersion := autoscaler.webhookGHESVersion(ctx, r, enterpriseHost); ghesVersion != "" {
+		log = log.WithValues("ghesVersion", ghesVersion)
+
+		span.SetAttributes(attribute.String("github.enterprise_version", ghesVersion))
+	}
+
 	var enterpriseEvent struct {
 		Enterprise struct {
 			Slug string `json:"slug,omitempty"`
@@ -162,12 +410,12 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		}
 		autoscaler.Log.Error(err, "could not parse webhook payload for extracting enterprise slug", "webhookType", webhookType, "payload", s)
 	}
-	enterpriseSlug := enterpriseEvent.Enterprise.Slug
+	enterpriseSlug := autoscaler.resolveEnterpriseSlug(log, enterpriseEvent.Enterprise.Slug, enterpriseHost)
 
 	switch e := event.(type) {
 	case *gogithub.PushEvent:
 		target, err = autoscaler.getScaleUpTarget(
-			context.TODO(),
+			ctx,
 			log,
 			e.Repo.GetName(),
 			e.Repo.Owner.GetLogin(),
@@ -179,7 +427,7 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		)
 	case *gogithub.PullRequestEvent:
 		target, err = autoscaler.getScaleUpTarget(
-			context.TODO(),
+			ctx,
 			log,
 			e.Repo.GetName(),
 			e.Repo.Owner.GetLogin(),
@@ -190,6 +438,13 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 			autoscaler.MatchPullRequestEvent(e),
 		)
 
+		if target != nil {
+			if pr := target.ScaleUpTrigger.GitHubEvent.PullRequest; pr != nil && pr.Optimistic {
+				target.repository = e.Repo.GetFullName()
+				target.optimistic = true
+			}
+		}
+
 		if pullRequest := e.PullRequest; pullRequest != nil {
 			log = log.WithValues(
 				"pullRequest.base.ref", e.PullRequest.Base.GetRef(),
@@ -198,7 +453,7 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		}
 	case *gogithub.CheckRunEvent:
 		target, err = autoscaler.getScaleUpTarget(
-			context.TODO(),
+			ctx,
 			log,
 			e.Repo.GetName(),
 			e.Repo.Owner.GetLogin(),
@@ -215,7 +470,65 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 				"action", e.GetAction(),
 			)
 		}
+	case *gogithub.CheckSuiteEvent:
+		target, err = autoscaler.getScaleUpTarget(
+			ctx,
+			log,
+			e.Repo.GetName(),
+			e.Repo.Owner.GetLogin(),
+			e.Repo.Owner.GetType(),
+			// Most go-github Event types don't seem to contain Enteprirse(.Slug) fields
+			// we need, so we parse it by ourselves.
+			enterpriseSlug,
+			autoscaler.MatchCheckSuiteEvent(e),
+		)
+
+		if checkSuite := e.GetCheckSuite(); checkSuite != nil {
+			log = log.WithValues(
+				"checkSuite.headBranch", checkSuite.GetHeadBranch(),
+				"action", e.GetAction(),
+			)
+		}
+	case *gogithub.DeploymentEvent:
+		target, err = autoscaler.getScaleUpTarget(
+			ctx,
+			log,
+			e.Repo.GetName(),
+			e.Repo.Owner.GetLogin(),
+			e.Repo.Owner.GetType(),
+			// Most go-github Event types don't seem to contain Enteprirse(.Slug) fields
+			// we need, so we parse it by ourselves.
+			enterpriseSlug,
+			autoscaler.MatchDeploymentEvent(e),
+		)
+
+		if deployment := e.GetDeployment(); deployment != nil {
+			log = log.WithValues(
+				"deployment.environment", deployment.GetEnvironment(),
+			)
+		}
+	case *gogithub.DeploymentStatusEvent:
+		target, err = autoscaler.getScaleUpTarget(
+			ctx,
+			log,
+			e.Repo.GetName(),
+			e.Repo.Owner.GetLogin(),
+			e.Repo.Owner.GetType(),
+			// Most go-github Event types don't seem to contain Enteprirse(.Slug) fields
+			// we need, so we parse it by ourselves.
+			enterpriseSlug,
+			autoscaler.MatchDeploymentStatusEvent(e),
+		)
+
+		if deploymentStatus := e.GetDeploymentStatus(); deploymentStatus != nil {
+			log = log.WithValues(
+				"deploymentStatus.state", deploymentStatus.GetState(),
+				"deploymentStatus.environment", deploymentStatus.GetEnvironment(),
+			)
+		}
 	case *gogithub.WorkflowJobEvent:
+		runnerName := autoscaler.compatWorkflowJobEvent(ctx, log, e, payload, enterpriseHost)
+
 		if workflowJob := e.GetWorkflowJob(); workflowJob != nil {
 			log = log.WithValues(
 				"workflowJob.status", workflowJob.GetStatus(),
@@ -226,14 +539,34 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 				"enterprise.slug", enterpriseSlug,
 				"action", e.GetAction(),
 			)
+
+			span.SetAttributes(
+				attribute.String("github.action", e.GetAction()),
+				attribute.String("github.repository", e.Repo.GetFullName()),
+				attribute.StringSlice("github.workflow_job.labels", workflowJob.Labels),
+			)
 		}
 
 		labels := e.WorkflowJob.Labels
 
+		rec.Labels = labels
+
+		if e.GetAction() != "queued" && autoscaler.UnmatchedJobs.remove(e.GetWorkflowJob().GetID()) {
+			log.V(1).Info("Forgot the unmatched workflow job as it was picked up or completed")
+		}
+
 		switch action := e.GetAction(); action {
 		case "queued", "completed":
+			if action == "completed" && autoscaler.WorkflowJobTraceTTL > 0 && !autoscaler.dryRun() {
+				autoscaler.completeWorkflowJobTrace(ctx, log, e)
+			}
+
+			if action == "completed" && !autoscaler.dryRun() {
+				autoscaler.trackBusyRunner(ctx, log, e, runnerName)
+			}
+
 			target, err = autoscaler.getJobScaleUpTargetForRepoOrOrg(
-				context.TODO(),
+				ctx,
 				log,
 				e.Repo.GetName(),
 				e.Repo.Owner.GetLogin(),
@@ -243,16 +576,48 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 			)
 
 			if target != nil {
+				target.repository = e.Repo.GetFullName()
+
+				amount := workflowJobAmount(target.HorizontalRunnerAutoscaler, labels)
+
 				if e.GetAction() == "queued" {
-					target.Amount = 1
+					target.Amount = amount
+					target.workflow = workflowJobWorkflowName(log, payload)
+					target.workflowRunID = e.GetWorkflowJob().GetRunID()
 				} else if e.GetAction() == "completed" {
 					// A nagative amount is processed in the tryScale func as a scale-down request,
 					// that erasese the oldest CapacityReservation with the same amount.
 					// If the first CapacityReservation was with Replicas=1, this negative scale target erases that,
 					// so that the resulting desired replicas decreases by 1.
-					target.Amount = -1
+					target.Amount = -amount
+				}
+			}
+		case "in_progress":
+			ok = true
+
+			w.WriteHeader(http.StatusOK)
+
+			if autoscaler.dryRun() {
+				log.V(1).Info("Dry run: skipped recording the runner that picked up the workflow job")
+
+				return
+			}
+
+			if runnerName != "" {
+				if t, err := autoscaler.getReservationTargetForWorkflowJob(ctx, log, e, enterpriseSlug); err != nil {
+					log.Error(err, "Failed to find the horizontalrunnerautoscaler for recording runner name")
+				} else if t != nil {
+					autoscaler.recordRunnerName(ctx, log, t.HorizontalRunnerAutoscaler, t.idempotencyKey, runnerName)
 				}
 			}
+
+			autoscaler.trackBusyRunner(ctx, log, e, runnerName)
+
+			if autoscaler.WorkflowJobTraceTTL > 0 {
+				autoscaler.recordWorkflowJobTrace(ctx, log, e, runnerName)
+			}
+
+			return
 		default:
 			ok = true
 
@@ -277,7 +642,7 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 		return
 	default:
-		log.Info("unknown event type", "eventType", webhookType)
+		autoscaler.LogRateLimiter.Info(log, "unknown_event_type", "unknown event type", "eventType", webhookType)
 
 		return
 	}
@@ -289,10 +654,23 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 
 	if target == nil {
-		log.Info(
+		autoscaler.LogRateLimiter.Info(
+			log,
+			"scale_target_not_found",
 			"Scale target not found. If this is unexpected, ensure that there is exactly one repository-wide or organizational runner deployment that matches this webhook event",
 		)
 
+		if e, ok := event.(*gogithub.WorkflowJobEvent); ok && e.GetAction() == "queued" {
+			autoscaler.UnmatchedJobs.add(e, enterpriseSlug, enterpriseHost, r.Header.Get("X-GitHub-Delivery"), webhookSourceNamespacesFrom(ctx))
+
+			autoscaler.AnomalyNotifier.Record(
+				anomaly.KindUnmatchedJobs,
+				fmt.Sprintf("Job %q of %s with labels %s", e.GetWorkflowJob().GetName(), e.GetRepo().GetFullName(), strings.Join(e.GetWorkflowJob().Labels, ",")),
+			)
+		}
+
+		rec.Decision = audit.DecisionNoScaleTarget
+
 		msg := "no horizontalrunnerautoscaler to scale for this github event"
 
 		ok = true
@@ -306,17 +684,60 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		return
 	}
 
-	if err := autoscaler.tryScale(context.TODO(), target); err != nil {
+	target.idempotencyKey = webhookIdempotencyKey(event, r.Header.Get("X-GitHub-Delivery"))
+
+	span.SetAttributes(
+		attribute.String("horizontalrunnerautoscaler.namespace", target.Namespace),
+		attribute.String("horizontalrunnerautoscaler.name", target.Name),
+		attribute.Int("amount", target.Amount),
+	)
+
+	if e, ok := event.(*gogithub.WorkflowJobEvent); ok {
+		target, err = autoscaler.selectRunnerPoolMember(ctx, log, target, e.WorkflowJob.Labels)
+		if err != nil {
+			log.Error(err, "could not select runner pool member")
+
+			return
+		}
+	}
+
+	rec.HorizontalRunnerAutoscaler = target.Namespace + "/" + target.Name
+	rec.MinReplicas = target.Spec.MinReplicas
+	rec.MaxReplicas = target.Spec.MaxReplicas
+
+	if err := autoscaler.tryScale(ctx, target); err != nil {
 		log.Error(err, "could not scale up")
 
+		rec.Decision = audit.DecisionFailed
+		rec.Error = err.Error()
+
+		autoscaler.recordScaleEvent(target, rec)
+
+		autoscaler.AnomalyNotifier.Record(anomaly.KindScaleUpFailures, fmt.Sprintf("Failed to scale %s/%s: %v", target.Namespace, target.Name, err))
+
 		return
 	}
 
+	rec.Decision = target.result.decision
+	rec.Amount = target.result.amount
+	rec.ReservedReplicasBefore = &target.result.reservedBefore
+	rec.ReservedReplicasAfter = &target.result.reservedAfter
+
+	autoscaler.recordScaleEvent(target, rec)
+
+	if e, ok := event.(*gogithub.WorkflowJobEvent); ok && !autoscaler.dryRun() {
+		autoscaler.setHostedRunnerFallbackCommitStatus(ctx, log, e, target.HorizontalRunnerAutoscaler)
+		autoscaler.updateCheckRunSummary(ctx, log, e, target.HorizontalRunnerAutoscaler)
+	}
+
 	ok = true
 
 	w.WriteHeader(http.StatusOK)
 
 	msg := fmt.Sprintf("scaled %s by %d", target.Name, target.Amount)
+	if autoscaler.dryRun() {
+		msg = fmt.Sprintf("would have scaled %s by %d (dry run)", target.Name, target.Amount)
+	}
 
 	autoscaler.Log.Info(msg)
 
@@ -325,34 +746,77 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) findHRAsByKey(ctx context.Context, value string) ([]v1alpha1.HorizontalRunnerAutoscaler, error) {
-	ns := autoscaler.Namespace
+// recordScaleEvent records the decision on the webhook event as an event of the scaled HRA,
+// so that `kubectl describe hra` tells which webhook deliveries have scaled it and by how much.
+// The decisions that don't change the capacity reservations, like the duplicate deliveries, aren't recorded,
+// and neither are the ones of the dry run, so that the dry-run webhook server running alongside another doesn't duplicate them.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) recordScaleEvent(target *ScaleTarget, rec audit.Record) {
+	if autoscaler.Recorder == nil {
+		return
+	}
 
-	var defaultListOpts []client.ListOption
+	source := rec.Event
+	if rec.Action != "" {
+		source += " " + rec.Action
+	}
+	if rec.Repository != "" {
+		source += " of " + rec.Repository
+	}
+	if len(rec.Labels) > 0 {
+		source += " with labels " + strings.Join(rec.Labels, ",")
+	}
+	if rec.Delivery != "" {
+		source += fmt.Sprintf(" (delivery %s)", rec.Delivery)
+	}
+
+	hra := &target.HorizontalRunnerAutoscaler
+
+	switch {
+	case rec.Decision == audit.DecisionFailed:
+		autoscaler.Recorder.Event(hra, corev1.EventTypeWarning, "WebhookScaleFailed", fmt.Sprintf("Failed to scale on %s: %s", source, rec.Error))
+	case rec.Decision != audit.DecisionScaled || rec.Amount == 0:
+		return
+	case rec.Amount > 0:
+		autoscaler.Recorder.Event(hra, corev1.EventTypeNormal, "WebhookScaledUp", fmt.Sprintf(
+			"Scaled up by %d on %s. Reserved replicas: %d -> %d",
+			rec.Amount, source, target.result.reservedBefore, target.result.reservedAfter,
+		))
+	default:
+		autoscaler.Recorder.Event(hra, corev1.EventTypeNormal, "WebhookScaledDown", fmt.Sprintf(
+			"Scaled down by %d on %s. Reserved replicas: %d -> %d",
+			-rec.Amount, source, target.result.reservedBefore, target.result.reservedAfter,
+		))
+	}
+}
+
+// findHRAsByKey returns the HRAs found by the repository, organization, enterprise or runner group key
+// that accept the webhook event sent from the host, and are in the namespaces of the WebhookSource, carried by the context.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) findHRAsByKey(ctx context.Context, value string) ([]v1alpha1.HorizontalRunnerAutoscaler, error) {
+	if value == "" {
+		return nil, nil
+	}
 
-	if ns != "" {
-		defaultListOpts = append(defaultListOpts, client.InNamespace(ns))
+	if err := autoscaler.routes.build(ctx, autoscaler.Client, autoscaler.Namespace); err != nil {
+		return nil, err
 	}
 
 	var hras []v1alpha1.HorizontalRunnerAutoscaler
 
-	if value != "" {
-		opts := append([]client.ListOption{}, defaultListOpts...)
-		opts = append(opts, client.MatchingFields{scaleTargetKey: value})
-
-		if autoscaler.Namespace != "" {
-			opts = append(opts, client.InNamespace(autoscaler.Namespace))
+	for _, name := range autoscaler.routes.hrasByKey(value, gitHubEnterpriseHostFrom(ctx)) {
+		if !matchesWebhookSourceNamespaces(ctx, name.Namespace) {
+			continue
 		}
 
-		var hraList v1alpha1.HorizontalRunnerAutoscalerList
+		var hra v1alpha1.HorizontalRunnerAutoscaler
 
-		if err := autoscaler.List(ctx, &hraList, opts...); err != nil {
+		// The HRA deleted after the last reconciliation is skipped until the table catches up
+		if err := autoscaler.Get(ctx, name, &hra); kerrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
 			return nil, err
 		}
 
-		for _, d := range hraList.Items {
-			hras = append(hras, d)
-		}
+		hras = append(hras, hra)
 	}
 
 	return hras, nil
@@ -379,6 +843,31 @@ func matchTriggerConditionAgainstEvent(types []string, eventAction *string) bool
 type ScaleTarget struct {
 	v1alpha1.HorizontalRunnerAutoscaler
 	v1alpha1.ScaleUpTrigger
+
+	// repository is the full name of the repository that triggered the scale,
+	// used to match optimistic capacity reservations with the workflow jobs that follow.
+	repository string
+	// optimistic is true when the capacity is reserved in anticipation of workflow jobs.
+	optimistic bool
+	// idempotencyKey identifies the operation that triggered the scale, so that a redelivered webhook event
+	// doesn't reserve the capacity twice. See webhookIdempotencyKey.
+	idempotencyKey string
+	// workflow and workflowRunID are the workflow and the workflow run of the workflow job that triggered the scale,
+	// recorded in the capacity reservation for chargeback.
+	workflow      string
+	workflowRunID int64
+	// result is what tryScale did for the target, recorded in the audit log.
+	result scaleResult
+}
+
+// scaleResult is the replica math of a scale, recorded in the audit log.
+type scaleResult struct {
+	decision audit.Decision
+	// amount is the number of replicas actually reserved, or released when negative.
+	amount int
+	// reservedBefore and reservedAfter are the sums of the replicas of the valid capacity reservations
+	// before and after the scale.
+	reservedBefore, reservedAfter int
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) searchScaleTargets(hras []v1alpha1.HorizontalRunnerAutoscaler, f func(v1alpha1.ScaleUpTrigger) bool) []ScaleTarget {
@@ -427,7 +916,9 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleTarget(ctx co
 			scaleTargetIDs = append(scaleTargetIDs, t.HorizontalRunnerAutoscaler.Name)
 		}
 
-		autoscaler.Log.Info(
+		autoscaler.LogRateLimiter.Info(
+			autoscaler.Log,
+			"too_many_scale_targets",
 			"Found too many scale targets: "+
 				"It must be exactly one to avoid ambiguity. "+
 				"Either set Namespace for the webhook-based autoscaler to let it only find HRAs in the namespace, "+
@@ -451,53 +942,157 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleUpTargetFo
 	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, labels []string,
 ) (*ScaleTarget, error) {
 
+	if target, err := autoscaler.getExplicitJobScaleTarget(ctx, log, owner+"/"+repo, labels); err != nil {
+		log.Error(err, "finding explicitly targeted runners", "repository", owner+"/"+repo)
+		return nil, err
+	} else if target != nil {
+		log.Info("job scale up target is explicitly targeted runners", "hra", target.Name)
+		return target, nil
+	}
+
 	scaleTarget := func(value string) (*ScaleTarget, error) {
 		return autoscaler.getJobScaleTarget(ctx, value, labels)
 	}
 	return autoscaler.getScaleUpTargetWithFunction(ctx, log, repo, owner, ownerType, enterprise, scaleTarget)
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTargetWithFunction(
-	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, scaleTarget func(value string) (*ScaleTarget, error)) (*ScaleTarget, error) {
+// getExplicitJobScaleTarget returns the HRA that the workflow job targets explicitly, either by the arc-hra-NAME runs-on label,
+// or by the AnnotationKeyRepositories annotation of the HRA that lists the repository.
+// It returns nil when there's no such HRA, so that the scale target is searched by the repository, organization and enterprise.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getExplicitJobScaleTarget(ctx context.Context, log logr.Logger, repositoryRunnerKey string, labels []string) (_ *ScaleTarget, err error) {
+	ctx, span := tracing.Start(ctx, "getExplicitJobScaleTarget", attribute.String("github.repository", repositoryRunnerKey))
+	defer func() { tracing.End(span, err) }()
 
-	repositoryRunnerKey := owner + "/" + repo
+	var opts []client.ListOption
 
-	// Search for repository HRAs
-	if target, err := scaleTarget(repositoryRunnerKey); err != nil {
-		log.Error(err, "finding repository-wide runner", "repository", repositoryRunnerKey)
-		return nil, err
-	} else if target != nil {
-		log.Info("job scale up target is repository-wide runners", "repository", repo)
-		return target, nil
+	if autoscaler.Namespace != "" {
+		opts = append(opts, client.InNamespace(autoscaler.Namespace))
 	}
 
-	if ownerType == "User" {
-		log.V(1).Info("user repositories not supported", "owner", owner)
-		return nil, nil
+	name := explicitHRAName(labels)
+
+	if name == "" {
+		opts = append(opts, client.MatchingFields{explicitRepositoryKey: repositoryRunnerKey})
 	}
 
-	// Search for organization runner HRAs in default runner group
-	if target, err := scaleTarget(owner); err != nil {
-		log.Error(err, "finding organizational runner", "organization", owner)
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := autoscaler.List(ctx, &hraList, opts...); err != nil {
 		return nil, err
-	} else if target != nil {
-		log.Info("job scale up target is organizational runners", "organization", owner)
-		return target, nil
 	}
 
-	if enterprise != "" {
-		// Search for enterprise runner HRAs in default runner group
-		if target, err := scaleTarget(enterpriseKey(enterprise)); err != nil {
-			log.Error(err, "finding enterprise runner", "enterprise", enterprise)
-			return nil, err
-		} else if target != nil {
-			log.Info("scale up target is default enterprise runners", "enterprise", enterprise)
-			return target, nil
+	for _, hra := range filterHRAsByGitHubEnterpriseHost(ctx, hraList.Items) {
+		if name != "" && hra.Name != name {
+			continue
 		}
-	}
 
-	// At this point there were no default organization/enterprise runners available to use, try now
-	// searching in runner groups
+		if !matchesWebhookSourceNamespaces(ctx, hra.Namespace) {
+			continue
+		}
+
+		// The index is consulted in the first place, but we check it here too to be sure
+		if name == "" && !hasExplicitRepository(hra, repositoryRunnerKey) {
+			continue
+		}
+
+		target, err := autoscaler.getJobScaleTargetForHRA(ctx, hra, labels)
+		if err != nil {
+			return nil, err
+		}
+
+		if target != nil {
+			return target, nil
+		}
+	}
+
+	if name != "" {
+		log.Info("No HRA found for the explicit runs-on label. Falling back to searching HRAs by the repository", "label", LabelPrefixExplicitHRA+name)
+	}
+
+	return nil, nil
+}
+
+// explicitRepositories returns the repositories listed in the AnnotationKeyRepositories annotation of the HRA.
+func explicitRepositories(hra v1alpha1.HorizontalRunnerAutoscaler) []string {
+	var repos []string
+
+	for _, repo := range strings.Split(hra.Annotations[AnnotationKeyRepositories], ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos
+}
+
+func hasExplicitRepository(hra v1alpha1.HorizontalRunnerAutoscaler, repo string) bool {
+	for _, r := range explicitRepositories(hra) {
+		if r == repo {
+			return true
+		}
+	}
+
+	return false
+}
+
+// explicitHRAName returns NAME of the arc-hra-NAME label of the workflow job, if any.
+func explicitHRAName(labels []string) string {
+	for _, l := range labels {
+		if strings.HasPrefix(l, LabelPrefixExplicitHRA) {
+			return strings.TrimPrefix(l, LabelPrefixExplicitHRA)
+		}
+	}
+
+	return ""
+}
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTargetWithFunction(
+	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, scaleTarget func(value string) (*ScaleTarget, error)) (_ *ScaleTarget, err error) {
+
+	repositoryRunnerKey := owner + "/" + repo
+
+	ctx, span := tracing.Start(ctx, "getScaleUpTarget",
+		attribute.String("github.repository", repositoryRunnerKey),
+		attribute.String("github.enterprise", enterprise),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	// Search for repository HRAs
+	if target, err := scaleTarget(repositoryRunnerKey); err != nil {
+		log.Error(err, "finding repository-wide runner", "repository", repositoryRunnerKey)
+		return nil, err
+	} else if target != nil {
+		log.Info("job scale up target is repository-wide runners", "repository", repo)
+		return target, nil
+	}
+
+	if ownerType == "User" {
+		log.V(1).Info("user repositories not supported", "owner", owner)
+		return nil, nil
+	}
+
+	// Search for organization runner HRAs in default runner group
+	if target, err := scaleTarget(owner); err != nil {
+		log.Error(err, "finding organizational runner", "organization", owner)
+		return nil, err
+	} else if target != nil {
+		log.Info("job scale up target is organizational runners", "organization", owner)
+		return target, nil
+	}
+
+	if enterprise != "" {
+		// Search for enterprise runner HRAs in default runner group
+		if target, err := scaleTarget(enterpriseKey(enterprise)); err != nil {
+			log.Error(err, "finding enterprise runner", "enterprise", enterprise)
+			return nil, err
+		} else if target != nil {
+			log.Info("scale up target is default enterprise runners", "enterprise", enterprise)
+			return target, nil
+		}
+	}
+
+	// At this point there were no default organization/enterprise runners available to use, try now
+	// searching in runner groups
 
 	// We need to get the potential runner groups first to avoid spending API queries needless. Once/if GitHub improves an
 	// API to find related/linked runner groups from a specific repository this logic could be removed
@@ -516,20 +1111,30 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTargetWithF
 
 	var enterpriseGroups []string
 	var organizationGroups []string
+
+	runnerGroupsSupported := true
 	if autoscaler.GitHubClient != nil {
+		if err := autoscaler.GitHubClient.CheckCapability(ctx, github.CapabilityRunnerGroups); err != nil {
+			autoscaler.LogRateLimiter.Info(log, "runner_groups_unsupported", fmt.Sprintf("%v. Assuming that every runner group is visible to every repository", err))
+			runnerGroupsSupported = false
+		}
+	}
+
+	if autoscaler.GitHubClient != nil && runnerGroupsSupported {
 		// Get available organization runner groups and enterprise runner groups for a repository
 		// These are the sum of runner groups with repository access = All repositories plus
 		// runner groups where owner/repo has access to
 		enterpriseGroups, organizationGroups, err = autoscaler.GitHubClient.GetRunnerGroupsFromRepository(ctx, owner, repositoryRunnerKey, availableEnterpriseGroups, availableOrganizationGroups)
 		log.V(1).Info("Searching in runner groups", "enterprise.groups", enterpriseGroups, "organization.groups", organizationGroups)
 		if err != nil {
-			log.Error(err, "Unable to find runner groups from repository", "organization", owner, "repository", repo)
+			autoscaler.LogRateLimiter.Error(log, err, "runner_groups_lookup_failed", "Unable to find runner groups from repository", "organization", owner, "repository", repo)
+			span.RecordError(err)
 			return nil, nil
 		}
 	} else {
-		// For backwards compatibility if GitHub authentication is not configured, we assume all runner groups have
-		// visibility=all to honor the previous implementation, therefore any available enterprise/organization runner
-		// is a potential target for scaling
+		// For backwards compatibility if GitHub authentication is not configured, or the GitHub server lacks the runner
+		// groups API, we assume all runner groups have visibility=all to honor the previous implementation, therefore
+		// any available enterprise/organization runner is a potential target for scaling
 		enterpriseGroups = availableEnterpriseGroups
 		organizationGroups = availableOrganizationGroups
 	}
@@ -563,51 +1168,19 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTargetWithF
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getPotentialGroupsFromHRAs(ctx context.Context, enterprise, org string) ([]string, []string, error) {
-	var enterpriseRunnerGroups []string
-	var orgRunnerGroups []string
-	ns := autoscaler.Namespace
-
-	var defaultListOpts []client.ListOption
-	if ns != "" {
-		defaultListOpts = append(defaultListOpts, client.InNamespace(ns))
+	if err := autoscaler.routes.build(ctx, autoscaler.Client, autoscaler.Namespace); err != nil {
+		return nil, nil, err
 	}
 
-	opts := append([]client.ListOption{}, defaultListOpts...)
-	if autoscaler.Namespace != "" {
-		opts = append(opts, client.InNamespace(autoscaler.Namespace))
-	}
+	host := gitHubEnterpriseHostFrom(ctx)
 
-	var hraList v1alpha1.HorizontalRunnerAutoscalerList
-	if err := autoscaler.List(ctx, &hraList, opts...); err != nil {
-		return orgRunnerGroups, enterpriseRunnerGroups, err
+	var enterpriseRunnerGroups []string
+	if enterprise != "" {
+		enterpriseRunnerGroups = autoscaler.routes.runnerGroups(enterpriseKey(enterprise), host)
 	}
 
-	for _, hra := range hraList.Items {
-		switch hra.Spec.ScaleTargetRef.Kind {
-		case "RunnerSet":
-			var rs v1alpha1.RunnerSet
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rs); err != nil {
-				return orgRunnerGroups, enterpriseRunnerGroups, err
-			}
-			if rs.Spec.Organization == org && rs.Spec.Group != "" {
-				orgRunnerGroups = append(orgRunnerGroups, rs.Spec.Group)
-			}
-			if rs.Spec.Enterprise == enterprise && rs.Spec.Group != "" {
-				enterpriseRunnerGroups = append(enterpriseRunnerGroups, rs.Spec.Group)
-			}
-		case "RunnerDeployment", "":
-			var rd v1alpha1.RunnerDeployment
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
-				return orgRunnerGroups, enterpriseRunnerGroups, err
-			}
-			if rd.Spec.Template.Spec.Organization == org && rd.Spec.Template.Spec.Group != "" {
-				orgRunnerGroups = append(orgRunnerGroups, rd.Spec.Template.Spec.Group)
-			}
-			if rd.Spec.Template.Spec.Enterprise == enterprise && rd.Spec.Template.Spec.Group != "" {
-				enterpriseRunnerGroups = append(enterpriseRunnerGroups, rd.Spec.Template.Spec.Group)
-			}
-		}
-	}
+	orgRunnerGroups := autoscaler.routes.runnerGroups(org, host)
+
 	return enterpriseRunnerGroups, orgRunnerGroups, nil
 }
 
@@ -619,110 +1192,299 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleTarget(ctx
 
 	autoscaler.Log.V(1).Info(fmt.Sprintf("Found %d HRAs by key", len(hras)), "key", name)
 
-HRA:
 	for _, hra := range hras {
-		if !hra.ObjectMeta.DeletionTimestamp.IsZero() {
-			continue
+		target, err := autoscaler.getJobScaleTargetForHRA(ctx, hra, labels)
+		if err != nil {
+			return nil, err
+		}
+
+		if target != nil {
+			return target, nil
 		}
+	}
 
-		if len(hra.Spec.ScaleUpTriggers) > 1 {
-			autoscaler.Log.V(1).Info("Skipping this HRA as it has too many ScaleUpTriggers to be used in workflow_job based scaling", "hra", hra.Name)
+	return nil, nil
+}
 
-			continue
+// getJobScaleTargetForHRA returns the scale target for the workflow job when the runners of the HRA have all the labels requested by the job.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleTargetForHRA(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, labels []string) (*ScaleTarget, error) {
+	if !hra.ObjectMeta.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	if len(hra.Spec.ScaleUpTriggers) > 1 {
+		autoscaler.Log.V(1).Info("Skipping this HRA as it has too many ScaleUpTriggers to be used in workflow_job based scaling", "hra", hra.Name)
+
+		return nil, nil
+	}
+
+	duration := jobScaleUpDuration(hra)
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+
+		if err := autoscaler.Client.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rs); err != nil {
+			return nil, err
 		}
 
-		var duration metav1.Duration
+		// Ensure that the RunnerSet-managed runners have all the labels requested by the workflow_job,
+		// unless the HRA selects the jobs by labels.
+		if !workflowJobLabelsMatch(hra, labels, runnerLabels(rs.Spec.RunnerConfig, rs.Spec.Template.Spec.NodeSelector)) {
+			return nil, nil
+		}
+
+		return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
+	case "RunnerDeployment", "":
+		var rd v1alpha1.RunnerDeployment
 
-		if len(hra.Spec.ScaleUpTriggers) > 0 {
-			duration = hra.Spec.ScaleUpTriggers[0].Duration
+		if err := autoscaler.Client.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
+			return nil, err
 		}
 
-		if duration.Duration <= 0 {
-			// Try to release the reserved capacity after at least 10 minutes by default,
-			// we won't end up in the reserved capacity remained forever in case GitHub somehow stopped sending us "completed" workflow_job events.
-			// GitHub usually send us those but nothing is 100% guaranteed, e.g. in case of something went wrong on GitHub :)
-			// Probably we'd better make this configurable via custom resources in the future?
-			duration.Duration = 10 * time.Minute
+		// Ensure that the RunnerDeployment-managed runners have all the labels requested by the workflow_job,
+		// unless the HRA selects the jobs by labels.
+		if !workflowJobLabelsMatch(hra, labels, runnerLabels(rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.NodeSelector)) {
+			return nil, nil
 		}
 
-		switch hra.Spec.ScaleTargetRef.Kind {
-		case "RunnerSet":
-			var rs v1alpha1.RunnerSet
+		return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scaleTargetRef.kind: %v", hra.Spec.ScaleTargetRef.Kind)
+	}
+}
+
+// workflowJobAmount returns the number of replicas reserved for the workflow job with the labels,
+// which is the largest of the labelAmounts of the job's labels, or 1 when the job has none of them.
+func workflowJobAmount(hra v1alpha1.HorizontalRunnerAutoscaler, labels []string) int {
+	amount := 1
+
+	if len(hra.Spec.ScaleUpTriggers) == 0 {
+		return amount
+	}
+
+	for label, a := range hra.Spec.ScaleUpTriggers[0].LabelAmounts {
+		if a <= amount {
+			continue
+		}
 
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rs); err != nil {
-				return nil, err
+		for _, l := range labels {
+			// Labels are case-insensitive on GitHub
+			if strings.EqualFold(l, label) {
+				amount = a
+				break
 			}
+		}
+	}
 
-			// Ensure that the RunnerSet-managed runners have all the labels requested by the workflow_job.
-			for _, l := range labels {
-				var matched bool
+	return amount
+}
 
-				// ignore "self-hosted" label as all instance here are self-hosted
-				if l == "self-hosted" {
-					continue
-				}
+// jobScaleUpDuration returns how long the capacity reserved for a workflow job lasts.
+func jobScaleUpDuration(hra v1alpha1.HorizontalRunnerAutoscaler) metav1.Duration {
+	var duration metav1.Duration
 
-				// TODO labels related to OS and architecture needs to be explicitly declared or the current implementation will not be able to find them.
+	if len(hra.Spec.ScaleUpTriggers) > 0 {
+		duration = hra.Spec.ScaleUpTriggers[0].Duration
+	}
 
-				for _, l2 := range rs.Spec.Labels {
-					if l == l2 {
-						matched = true
-						break
-					}
-				}
+	if duration.Duration <= 0 {
+		// Try to release the reserved capacity after at least 10 minutes by default,
+		// we won't end up in the reserved capacity remained forever in case GitHub somehow stopped sending us "completed" workflow_job events.
+		// GitHub usually send us those but nothing is 100% guaranteed, e.g. in case of something went wrong on GitHub :)
+		// Probably we'd better make this configurable via custom resources in the future?
+		duration.Duration = 10 * time.Minute
+	}
 
-				if !matched {
-					continue HRA
-				}
-			}
+	return duration
+}
 
-			return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
-		case "RunnerDeployment", "":
-			var rd v1alpha1.RunnerDeployment
+// workflowJobLabelsMatch returns true when the workflow job is routed to the HRA.
+// The labels of the job are matched against the label selectors of the HRA's scale up trigger when set,
+// and otherwise the runners need to have all the labels of the job.
+func workflowJobLabelsMatch(hra v1alpha1.HorizontalRunnerAutoscaler, jobLabels, runnerLabels []string) bool {
+	if len(hra.Spec.ScaleUpTriggers) > 0 {
+		if g := hra.Spec.ScaleUpTriggers[0].GitHubEvent; g != nil && g.WorkflowJob != nil && g.WorkflowJob.LabelSelectors != nil {
+			return matchWorkflowJobLabelSelectors(*g.WorkflowJob.LabelSelectors, jobLabels)
+		}
+	}
+
+	return runnerLabelsMatch(jobLabels, runnerLabels)
+}
 
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
-				return nil, err
+// matchWorkflowJobLabelSelectors returns true when the labels include all the included labels and none of the excluded labels.
+func matchWorkflowJobLabelSelectors(selectors v1alpha1.WorkflowJobLabelSelectors, labels []string) bool {
+	has := func(label string) bool {
+		for _, l := range labels {
+			// Labels are case-insensitive on GitHub
+			if strings.EqualFold(l, label) {
+				return true
 			}
+		}
 
-			// Ensure that the RunnerDeployment-managed runners have all the labels requested by the workflow_job.
-			for _, l := range labels {
-				var matched bool
+		return false
+	}
 
-				// ignore "self-hosted" label as all instance here are self-hosted
-				if l == "self-hosted" {
-					continue
-				}
+	for _, l := range selectors.Include {
+		if !has(l) {
+			return false
+		}
+	}
 
-				// TODO labels related to OS and architecture needs to be explicitly declared or the current implementation will not be able to find them.
+	for _, l := range selectors.Exclude {
+		if has(l) {
+			return false
+		}
+	}
 
-				for _, l2 := range rd.Spec.Template.Spec.Labels {
-					if l == l2 {
-						matched = true
-						break
-					}
-				}
+	return true
+}
 
-				if !matched {
-					continue HRA
-				}
+// runnerLabelsMatch returns true when the runners have all the labels requested by the workflow job.
+func runnerLabelsMatch(jobLabels, runnerLabels []string) bool {
+	for _, l := range jobLabels {
+		var matched bool
+
+		// ignore "self-hosted" label as all instance here are self-hosted
+		if l == "self-hosted" {
+			continue
+		}
+
+		// Labels related to OS and architecture of linux runners of unknown architecture need to be explicitly declared,
+		// as the implicit ones are added by runnerLabels only when the architecture is known.
+
+		for _, l2 := range runnerLabels {
+			// Labels are case-insensitive on GitHub
+			if strings.EqualFold(l, l2) {
+				matched = true
+				break
 			}
+		}
 
-			return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
-		default:
-			return nil, fmt.Errorf("unsupported scaleTargetRef.kind: %v", hra.Spec.ScaleTargetRef.Kind)
+		if !matched {
+			return false
 		}
 	}
 
-	return nil, nil
+	return true
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) tryScale(ctx context.Context, target *ScaleTarget) error {
+// runnerLabels returns the labels of the runners, including the labels inferred from the GPUs and the implicit labels
+// of the OS and the architecture that the runners register themselves with.
+// Windows runners always run on x64 nodes. Linux runners get the implicit labels only when the architecture is known
+// from the arch field or the kubernetes.io/arch node selector, as they may otherwise run on either x64 or arm64 nodes.
+func runnerLabels(runnerSpec v1alpha1.RunnerConfig, nodeSelector map[string]string) []string {
+	labels := runnerRegistrationLabels(runnerSpec)
+
+	if runnerSpec.IsWindows() {
+		return append(append([]string{}, labels...), v1alpha1.RunnerOSWindows, "x64")
+	}
+
+	switch runnerArch(runnerSpec, nodeSelector) {
+	case v1alpha1.RunnerArchAMD64:
+		return append(append([]string{}, labels...), v1alpha1.RunnerOSLinux, "x64")
+	case v1alpha1.RunnerArchARM64:
+		return append(append([]string{}, labels...), v1alpha1.RunnerOSLinux, "arm64")
+	}
+
+	return labels
+}
+
+// webhookIdempotencyKey returns the key that identifies the operation requested by the webhook event.
+//
+// It's the workflow job ID for workflow_job events, so that the completion of a job removes the reservation made for
+// the same job. Otherwise it's the delivery ID, which GitHub keeps when the event is redelivered.
+func webhookIdempotencyKey(event interface{}, deliveryID string) string {
+	if e, ok := event.(*gogithub.WorkflowJobEvent); ok && e.GetWorkflowJob().GetID() != 0 {
+		return fmt.Sprintf("workflow_job/%d", e.GetWorkflowJob().GetID())
+	}
+
+	if deliveryID != "" {
+		return "delivery/" + deliveryID
+	}
+
+	return ""
+}
+
+// getReservationTargetForWorkflowJob returns the scale target the capacity reservation for the workflow job was made on,
+// resolved in the same way as the queued and completed events so that only the HRA of the job is consulted.
+// It returns nil when there's no HRA for the job.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getReservationTargetForWorkflowJob(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, enterpriseSlug string) (*ScaleTarget, error) {
+	labels := e.GetWorkflowJob().Labels
+
+	target, err := autoscaler.getJobScaleUpTargetForRepoOrOrg(
+		ctx,
+		log,
+		e.Repo.GetName(),
+		e.Repo.Owner.GetLogin(),
+		e.Repo.Owner.GetType(),
+		enterpriseSlug,
+		labels,
+	)
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	target.idempotencyKey = webhookIdempotencyKey(e, "")
+	// A negative amount keeps the runner pool from redirecting the job to another member
+	// unless the reservation was made on that member.
+	target.Amount = -workflowJobAmount(target.HorizontalRunnerAutoscaler, labels)
+
+	return autoscaler.selectRunnerPoolMember(ctx, log, target, labels)
+}
+
+// recordRunnerName records the name of the runner that picked up the workflow job to the capacity reservation
+// made for the job on the HRA, so that the reservation can be released as soon as the runner is interrupted.
+// See RunnerPodInterruptionReconciler.
+// Failures are only logged, because the reservation is released on the completion of the job anyway.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) recordRunnerName(ctx context.Context, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler, idempotencyKey, runnerName string) {
+	if idempotencyKey == "" || runnerName == "" {
+		return
+	}
+
+	copy := hra.DeepCopy()
+
+	var found bool
+
+	for i, r := range copy.Spec.CapacityReservations {
+		if r.IdempotencyKey == idempotencyKey && r.RunnerName != runnerName {
+			copy.Spec.CapacityReservations[i].RunnerName = runnerName
+			found = true
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	if err := autoscaler.Client.Patch(ctx, copy, client.MergeFrom(&hra)); err != nil {
+		log.Error(err, "Failed to record runner name to capacity reservation", "hra", hra.Name, "runnerName", runnerName)
+
+		return
+	}
+
+	log.V(1).Info("Recorded runner name to capacity reservation", "hra", hra.Name, "idempotencyKey", idempotencyKey, "runnerName", runnerName)
+}
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) tryScale(ctx context.Context, target *ScaleTarget) (err error) {
 	if target == nil {
 		return nil
 	}
 
+	ctx, span := tracing.Start(ctx, "tryScale",
+		attribute.String("horizontalrunnerautoscaler.namespace", target.Namespace),
+		attribute.String("horizontalrunnerautoscaler.name", target.Name),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	// Read once so that the mode can't be switched by the runtime settings in the middle of the scale
+	dryRun := autoscaler.dryRun()
+
 	copy := target.HorizontalRunnerAutoscaler.DeepCopy()
 
+	// Captured before getValidCapacityReservations so that every reservation expired by now is removed by this call
+	now := time.Now()
+
 	amount := 1
 
 	if target.ScaleUpTrigger.Amount != 0 {
@@ -731,40 +1493,230 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) tryScale(ctx context.
 
 	capacityReservations := getValidCapacityReservations(copy)
 
+	reserved := reservedReplicas(capacityReservations)
+
+	target.result = scaleResult{reservedBefore: reserved, reservedAfter: reserved}
+
+	if amount > 0 && target.idempotencyKey != "" {
+		for _, r := range capacityReservations {
+			if r.IdempotencyKey == target.idempotencyKey {
+				autoscaler.Log.Info(
+					"Skipped adding capacity reservation as it has already been added for the same operation",
+					"idempotencyKey", target.idempotencyKey,
+				)
+
+				target.result.decision = audit.DecisionDuplicate
+
+				return nil
+			}
+		}
+	}
+
+	// Reservations added while paused would scale the target up all at once on unpause, so they're skipped.
+	// The scale downs are still processed so that the reservations made before the pause are removed on the completion of their jobs.
+	if amount > 0 && copy.Spec.Paused {
+		autoscaler.Log.Info(
+			"Skipped adding capacity reservation as the horizontalrunnerautoscaler is paused",
+			"horizontalrunnerautoscaler", copy.Name,
+			"namespace", copy.Namespace,
+		)
+
+		target.result.decision = audit.DecisionPaused
+
+		return nil
+	}
+
 	if amount > 0 {
+		allowance, limited := capacityReservationAllowance(target.HorizontalRunnerAutoscaler, capacityReservations, target.ScaleUpTrigger.Duration.Duration, time.Now())
+
+		if limited && amount > allowance {
+			msg := fmt.Sprintf(
+				"Suppressed reserving %d of %d replicas by scaleUpMaxRatePerMinute of %d",
+				amount-allowance, amount, *copy.Spec.ScaleUpMaxRatePerMinute,
+			)
+
+			autoscaler.Log.Info(msg, "horizontalrunnerautoscaler", copy.Name, "namespace", copy.Namespace)
+
+			if !dryRun && autoscaler.Recorder != nil {
+				autoscaler.Recorder.Event(&target.HorizontalRunnerAutoscaler, corev1.EventTypeWarning, "ScaleUpSuppressed", msg)
+			}
+
+			if allowance == 0 {
+				target.result.decision = audit.DecisionSuppressed
+
+				return nil
+			}
+
+			amount = allowance
+		}
+	}
+
+	// Optimistic reservations that expired without being consumed are the surplus of the prediction.
+	// They are counted only once the patch that removes them succeeds, so that a failed patch doesn't count them again on the next call.
+	var reservedOptimistic, consumedOptimistic, cancelledOptimistic int
+
+	for _, r := range copy.Spec.CapacityReservations {
+		if r.Optimistic && !r.ExpirationTime.Time.After(now) {
+			cancelledOptimistic += r.Replicas
+		}
+	}
+
+	if amount > 0 && target.optimistic {
+		copy.Spec.CapacityReservations = append(capacityReservations, v1alpha1.CapacityReservation{
+			Name:           target.repository,
+			ExpirationTime: metav1.Time{Time: time.Now().Add(target.ScaleUpTrigger.Duration.Duration)},
+			Replicas:       amount,
+			Optimistic:     true,
+			IdempotencyKey: target.idempotencyKey,
+		})
+
+		reservedOptimistic = amount
+	} else if amount > 0 {
+		capacityReservations, consumedOptimistic = consumeOptimisticCapacityReservations(capacityReservations, target.repository, amount)
+
+		// The replicas for the consumed part of the optimistic reservations are already there, so this doesn't change
+		// the desired replicas for them. It only extends their lifetime to that of the actual workflow jobs.
 		copy.Spec.CapacityReservations = append(capacityReservations, v1alpha1.CapacityReservation{
 			ExpirationTime: metav1.Time{Time: time.Now().Add(target.ScaleUpTrigger.Duration.Duration)},
 			Replicas:       amount,
+			IdempotencyKey: target.idempotencyKey,
+			Repository:     target.repository,
+			Workflow:       target.workflow,
+			WorkflowRunID:  target.workflowRunID,
 		})
 	} else if amount < 0 {
 		var reservations []v1alpha1.CapacityReservation
 
 		var found bool
 
-		for _, r := range capacityReservations {
-			if !found && r.Replicas+amount == 0 {
+		// Prefer the reservation made for the same workflow job, so that a job completed before others
+		// doesn't shorten their reservations.
+		// Reservations with other keys are never removed, so a retried scale-down can't remove the reservation for another job.
+		keyed := -1
+		if target.idempotencyKey != "" {
+			for i, r := range capacityReservations {
+				if r.IdempotencyKey == target.idempotencyKey {
+					keyed = i
+					break
+				}
+			}
+		}
+
+		for i, r := range capacityReservations {
+			if keyed >= 0 {
+				if i == keyed {
+					found = true
+					continue
+				}
+			} else if !found && !r.Optimistic && r.IdempotencyKey == "" && r.Replicas+amount == 0 {
+				// Optimistic reservations are never removed by completed workflow jobs, because they're not made for them.
+				// Keyed reservations are removed only by the operation with the same key.
 				found = true
-			} else {
-				reservations = append(reservations, r)
+				continue
 			}
+
+			reservations = append(reservations, r)
 		}
 
 		copy.Spec.CapacityReservations = reservations
 	}
 
+	target.result.amount = amount
+	target.result.reservedAfter = reservedReplicas(getValidCapacityReservations(copy))
+	target.result.decision = audit.DecisionScaled
+
+	if dryRun {
+		target.result.decision = audit.DecisionDryRun
+
+		autoscaler.Log.Info(
+			"Dry run: skipped patching hra for capacityReservations update",
+			"horizontalrunnerautoscaler", copy.Name,
+			"namespace", copy.Namespace,
+			"amount", amount,
+			"before", target.HorizontalRunnerAutoscaler.Spec.CapacityReservations,
+			"after", copy.Spec.CapacityReservations,
+		)
+
+		metrics.AddHorizontalRunnerAutoscalerDryRunReplicas(copy.ObjectMeta, amount)
+
+		return nil
+	}
+
 	autoscaler.Log.Info(
 		"Patching hra for capacityReservations update",
 		"before", target.HorizontalRunnerAutoscaler.Spec.CapacityReservations,
 		"after", copy.Spec.CapacityReservations,
 	)
 
-	if err := autoscaler.Client.Patch(ctx, copy, client.MergeFrom(&target.HorizontalRunnerAutoscaler)); err != nil {
+	span.SetAttributes(attribute.Int("amount", amount), attribute.Int("capacity_reservations", len(copy.Spec.CapacityReservations)))
+
+	patchCtx, patchSpan := tracing.Start(ctx, "PatchHorizontalRunnerAutoscaler")
+	err = autoscaler.Client.Patch(patchCtx, copy, client.MergeFrom(&target.HorizontalRunnerAutoscaler))
+	tracing.End(patchSpan, err)
+
+	if err != nil {
 		return fmt.Errorf("patching horizontalrunnerautoscaler to add capacity reservation: %w", err)
 	}
 
+	if reservedOptimistic > 0 {
+		metrics.AddHorizontalRunnerAutoscalerOptimisticReplicas(copy.ObjectMeta, metrics.OptimisticReplicasReserved, reservedOptimistic)
+	}
+
+	if consumedOptimistic > 0 {
+		metrics.AddHorizontalRunnerAutoscalerOptimisticReplicas(copy.ObjectMeta, metrics.OptimisticReplicasConsumed, consumedOptimistic)
+	}
+
+	if cancelledOptimistic > 0 {
+		metrics.AddHorizontalRunnerAutoscalerOptimisticReplicas(copy.ObjectMeta, metrics.OptimisticReplicasCancelled, cancelledOptimistic)
+	}
+
 	return nil
 }
 
+// reservedReplicas returns the sum of the replicas of the capacity reservations.
+func reservedReplicas(reservations []v1alpha1.CapacityReservation) int {
+	var replicas int
+
+	for _, r := range reservations {
+		replicas += r.Replicas
+	}
+
+	return replicas
+}
+
+// consumeOptimisticCapacityReservations decreases the optimistic capacity reservations for the repository by up to amount
+// replicas, starting from the oldest one, and returns the remaining reservations and the number of consumed replicas.
+func consumeOptimisticCapacityReservations(reservations []v1alpha1.CapacityReservation, repository string, amount int) ([]v1alpha1.CapacityReservation, int) {
+	if repository == "" {
+		return reservations, 0
+	}
+
+	var (
+		remaining []v1alpha1.CapacityReservation
+		consumed  int
+	)
+
+	for _, r := range reservations {
+		if r.Optimistic && r.Name == repository && consumed < amount {
+			n := r.Replicas
+			if n > amount-consumed {
+				n = amount - consumed
+			}
+
+			consumed += n
+			r.Replicas -= n
+
+			if r.Replicas <= 0 {
+				continue
+			}
+		}
+
+		remaining = append(remaining, r)
+	}
+
+	return remaining, consumed
+}
+
 func getValidCapacityReservations(autoscaler *v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.CapacityReservation {
 	var capacityReservations []v1alpha1.CapacityReservation
 
@@ -787,75 +1739,18 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) SetupWithManager(mgr
 
 	autoscaler.Recorder = mgr.GetEventRecorderFor(name)
 
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.HorizontalRunnerAutoscaler{}, scaleTargetKey, func(rawObj client.Object) []string {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.HorizontalRunnerAutoscaler{}, explicitRepositoryKey, func(rawObj client.Object) []string {
 		hra := rawObj.(*v1alpha1.HorizontalRunnerAutoscaler)
 
-		if hra.Spec.ScaleTargetRef.Name == "" {
-			return nil
-		}
-
-		switch hra.Spec.ScaleTargetRef.Kind {
-		case "", "RunnerDeployment":
-			var rd v1alpha1.RunnerDeployment
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
-				autoscaler.Log.V(1).Info(fmt.Sprintf("RunnerDeployment not found with scale target ref name %s for hra %s", hra.Spec.ScaleTargetRef.Name, hra.Name))
-				return nil
-			}
-
-			keys := []string{}
-			if rd.Spec.Template.Spec.Repository != "" {
-				keys = append(keys, rd.Spec.Template.Spec.Repository) // Repository runners
-			}
-			if rd.Spec.Template.Spec.Organization != "" {
-				if group := rd.Spec.Template.Spec.Group; group != "" {
-					keys = append(keys, organizationalRunnerGroupKey(rd.Spec.Template.Spec.Organization, rd.Spec.Template.Spec.Group)) // Organization runner groups
-				} else {
-					keys = append(keys, rd.Spec.Template.Spec.Organization) // Organization runners
-				}
-			}
-			if enterprise := rd.Spec.Template.Spec.Enterprise; enterprise != "" {
-				if group := rd.Spec.Template.Spec.Group; group != "" {
-					keys = append(keys, enterpriseRunnerGroupKey(enterprise, rd.Spec.Template.Spec.Group)) // Enterprise runner groups
-				} else {
-					keys = append(keys, enterpriseKey(enterprise)) // Enterprise runners
-				}
-			}
-			autoscaler.Log.V(1).Info(fmt.Sprintf("HRA keys indexed for HRA %s: %v", hra.Name, keys))
-			return keys
-		case "RunnerSet":
-			var rs v1alpha1.RunnerSet
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rs); err != nil {
-				autoscaler.Log.V(1).Info(fmt.Sprintf("RunnerSet not found with scale target ref name %s for hra %s", hra.Spec.ScaleTargetRef.Name, hra.Name))
-				return nil
-			}
-
-			keys := []string{}
-			if rs.Spec.Repository != "" {
-				keys = append(keys, rs.Spec.Repository) // Repository runners
-			}
-			if rs.Spec.Organization != "" {
-				keys = append(keys, rs.Spec.Organization) // Organization runners
-				if group := rs.Spec.Group; group != "" {
-					keys = append(keys, organizationalRunnerGroupKey(rs.Spec.Organization, rs.Spec.Group)) // Organization runner groups
-				}
-			}
-			if enterprise := rs.Spec.Enterprise; enterprise != "" {
-				keys = append(keys, enterpriseKey(enterprise)) // Enterprise runners
-				if group := rs.Spec.Group; group != "" {
-					keys = append(keys, enterpriseRunnerGroupKey(enterprise, rs.Spec.Group)) // Enterprise runner groups
-				}
-			}
-			autoscaler.Log.V(1).Info(fmt.Sprintf("HRA keys indexed for HRA %s: %v", hra.Name, keys))
-			return keys
-		}
-
-		return nil
+		return explicitRepositories(*hra)
 	}); err != nil {
 		return err
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		Watches(&source.Kind{Type: &v1alpha1.RunnerDeployment{}}, handler.EnqueueRequestsFromMapFunc(autoscaler.hrasForScaleTarget("RunnerDeployment"))).
+		Watches(&source.Kind{Type: &v1alpha1.RunnerSet{}}, handler.EnqueueRequestsFromMapFunc(autoscaler.hrasForScaleTarget("RunnerSet"))).
 		Named(name).
 		Complete(autoscaler)
 }
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-github/v39/github"
+)
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchCheckSuiteEvent(event *github.CheckSuiteEvent) func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+	return func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+		g := scaleUpTrigger.GitHubEvent
+
+		if g == nil {
+			return false
+		}
+
+		cs := g.CheckSuite
+
+		if cs == nil {
+			return false
+		}
+
+		if !matchTriggerConditionAgainstEvent(cs.Types, event.Action) {
+			return false
+		}
+
+		if !matchTriggerConditionAgainstEvent(cs.Branches, event.GetCheckSuite().HeadBranch) {
+			return false
+		}
+
+		if len(cs.Repositories) > 0 {
+			for _, repository := range cs.Repositories {
+				if repository == event.GetRepo().GetName() {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		return true
+	}
+}
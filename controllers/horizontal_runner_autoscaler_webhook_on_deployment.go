@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/actionsglob"
+	"github.com/google/go-github/v39/github"
+)
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchDeploymentEvent(event *github.DeploymentEvent) func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+	return func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+		g := scaleUpTrigger.GitHubEvent
+
+		if g == nil {
+			return false
+		}
+
+		d := g.Deployment
+
+		if d == nil {
+			return false
+		}
+
+		return matchEnvironmentAgainstPatterns(d.Environments, event.GetDeployment().GetEnvironment())
+	}
+}
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchDeploymentStatusEvent(event *github.DeploymentStatusEvent) func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+	return func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+		g := scaleUpTrigger.GitHubEvent
+
+		if g == nil {
+			return false
+		}
+
+		ds := g.DeploymentStatus
+
+		if ds == nil {
+			return false
+		}
+
+		status := event.GetDeploymentStatus()
+
+		if !matchTriggerConditionAgainstEvent(ds.States, status.State) {
+			return false
+		}
+
+		// deployment_status.environment is usually the same as deployment.environment,
+		// but we prefer the former as it's the environment the status is reported for.
+		env := status.GetEnvironment()
+		if env == "" {
+			env = event.GetDeployment().GetEnvironment()
+		}
+
+		return matchEnvironmentAgainstPatterns(ds.Environments, env)
+	}
+}
+
+func matchEnvironmentAgainstPatterns(patterns []string, env string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pat := range patterns {
+		if actionsglob.Match(pat, env) {
+			return true
+		}
+	}
+
+	return false
+}
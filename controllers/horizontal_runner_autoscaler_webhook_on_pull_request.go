@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"strings"
+
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/google/go-github/v39/github"
 )
@@ -23,10 +25,32 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchPullRequestEvent
 			return false
 		}
 
-		if !matchTriggerConditionAgainstEvent(pr.Branches, event.PullRequest.Base.Ref) {
+		if len(pr.Branches) > 0 && !matchAgainstOrderedPatterns(pr.Branches, event.GetPullRequest().GetBase().GetRef()) {
+			return false
+		}
+
+		if pr.ExcludeDrafts && event.GetPullRequest().GetDraft() {
+			return false
+		}
+
+		if len(pr.Labels) > 0 && !hasPullRequestLabel(event.GetPullRequest(), pr.Labels) {
 			return false
 		}
 
 		return true
 	}
 }
+
+// hasPullRequestLabel returns true when the pull request has one of the labels.
+// Labels are compared case-insensitively, like GitHub does.
+func hasPullRequestLabel(pr *github.PullRequest, labels []string) bool {
+	for _, l := range pr.Labels {
+		for _, want := range labels {
+			if strings.EqualFold(l.GetName(), want) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"strings"
+
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/actionsglob"
 	"github.com/google/go-github/v39/github"
 )
 
@@ -19,6 +22,72 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchPushEvent(event
 			return false
 		}
 
+		if len(push.Branches) > 0 {
+			branch := strings.TrimPrefix(event.GetRef(), "refs/heads/")
+
+			// Pushes of tags have refs like refs/tags/v1.0.0
+			if branch == event.GetRef() || !matchAgainstOrderedPatterns(push.Branches, branch) {
+				return false
+			}
+		}
+
+		if len(push.Paths) > 0 {
+			files := pushedFiles(event)
+
+			// We can't tell the changed files, so err on the side of scaling up
+			if len(files) == 0 {
+				return true
+			}
+
+			for _, f := range files {
+				if matchAgainstOrderedPatterns(push.Paths, f) {
+					return true
+				}
+			}
+
+			return false
+		}
+
 		return true
 	}
 }
+
+// pushedFiles returns the files added, modified, and removed by the commits in the push event.
+// Note that GitHub includes at most 20 commits in the payload.
+func pushedFiles(event *github.PushEvent) []string {
+	var files []string
+
+	for _, c := range event.Commits {
+		files = append(files, c.Added...)
+		files = append(files, c.Modified...)
+		files = append(files, c.Removed...)
+	}
+
+	return files
+}
+
+// matchAgainstOrderedPatterns returns true when the last pattern that matches s is not negated,
+// which is how GitHub Actions evaluates branch and path filters containing patterns prefixed with "!".
+func matchAgainstOrderedPatterns(patterns []string, s string) bool {
+	var matched bool
+
+	for _, pat := range patterns {
+		if pat == "" {
+			continue
+		}
+
+		if pat[0] == '!' {
+			if len(pat) > 1 && actionsglob.Match(pat[1:], s) {
+				matched = false
+			}
+
+			continue
+		}
+
+		if actionsglob.Match(pat, s) {
+			matched = true
+		}
+	}
+
+	return matched
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,16 +11,22 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/audit"
 	"github.com/go-logr/logr"
 	"github.com/google/go-github/v39/github"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
@@ -89,6 +96,51 @@ func TestWebhookPullRequest(t *testing.T) {
 	)
 }
 
+func TestMatchPullRequestEvent(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+
+	newPullRequest := func(action, base string, draft bool, labels ...string) *github.PullRequestEvent {
+		pr := &github.PullRequest{
+			Base:  &github.PullRequestBranch{Ref: github.String(base)},
+			Draft: github.Bool(draft),
+		}
+
+		for _, l := range labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		return &github.PullRequestEvent{Action: github.String(action), PullRequest: pr}
+	}
+
+	testcases := []struct {
+		name  string
+		spec  *actionsv1alpha1.PullRequestSpec
+		event *github.PullRequestEvent
+		want  bool
+	}{
+		{name: "no filters", spec: &actionsv1alpha1.PullRequestSpec{}, event: newPullRequest("synchronize", "main", true), want: true},
+		{name: "type", spec: &actionsv1alpha1.PullRequestSpec{Types: []string{"opened"}}, event: newPullRequest("synchronize", "main", false), want: false},
+		{name: "base branch", spec: &actionsv1alpha1.PullRequestSpec{Branches: []string{"main", "releases/**"}}, event: newPullRequest("synchronize", "releases/v1", false), want: true},
+		{name: "other base branch", spec: &actionsv1alpha1.PullRequestSpec{Branches: []string{"main"}}, event: newPullRequest("synchronize", "develop", false), want: false},
+		{name: "excluded base branch", spec: &actionsv1alpha1.PullRequestSpec{Branches: []string{"releases/**", "!releases/**-alpha"}}, event: newPullRequest("synchronize", "releases/v1-alpha", false), want: false},
+		{name: "draft", spec: &actionsv1alpha1.PullRequestSpec{ExcludeDrafts: true}, event: newPullRequest("synchronize", "main", true), want: false},
+		{name: "ready for review", spec: &actionsv1alpha1.PullRequestSpec{ExcludeDrafts: true}, event: newPullRequest("ready_for_review", "main", false), want: true},
+		{name: "label", spec: &actionsv1alpha1.PullRequestSpec{Labels: []string{"run-e2e"}}, event: newPullRequest("synchronize", "main", false, "bug", "Run-E2E"), want: true},
+		{name: "no label", spec: &actionsv1alpha1.PullRequestSpec{Labels: []string{"run-e2e"}}, event: newPullRequest("synchronize", "main", false, "bug"), want: false},
+		{name: "label on draft", spec: &actionsv1alpha1.PullRequestSpec{Labels: []string{"run-e2e"}, ExcludeDrafts: true}, event: newPullRequest("labeled", "main", true, "run-e2e"), want: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			trigger := actionsv1alpha1.ScaleUpTrigger{GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{PullRequest: tc.spec}}
+
+			if got := hraWebhook.MatchPullRequestEvent(tc.event)(trigger); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestWebhookPush(t *testing.T) {
 	testServer(t,
 		"push",
@@ -103,6 +155,201 @@ func TestWebhookPush(t *testing.T) {
 	)
 }
 
+func TestMatchPushEvent(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+
+	newPush := func(ref string, files ...string) *github.PushEvent {
+		return &github.PushEvent{
+			Ref:     github.String(ref),
+			Commits: []*github.HeadCommit{{Modified: files}},
+		}
+	}
+
+	testcases := []struct {
+		name  string
+		spec  *actionsv1alpha1.PushSpec
+		event *github.PushEvent
+		want  bool
+	}{
+		{name: "no filters", spec: &actionsv1alpha1.PushSpec{}, event: newPush("refs/tags/v1.0.0"), want: true},
+		{name: "branch", spec: &actionsv1alpha1.PushSpec{Branches: []string{"main", "releases/**"}}, event: newPush("refs/heads/releases/v1"), want: true},
+		{name: "other branch", spec: &actionsv1alpha1.PushSpec{Branches: []string{"main"}}, event: newPush("refs/heads/feature"), want: false},
+		{name: "excluded branch", spec: &actionsv1alpha1.PushSpec{Branches: []string{"releases/**", "!releases/**-alpha"}}, event: newPush("refs/heads/releases/v1-alpha"), want: false},
+		{name: "tag with branches", spec: &actionsv1alpha1.PushSpec{Branches: []string{"**"}}, event: newPush("refs/tags/v1.0.0"), want: false},
+		{name: "path", spec: &actionsv1alpha1.PushSpec{Paths: []string{"**.go"}}, event: newPush("refs/heads/main", "README.md", "cmd/main.go"), want: true},
+		{name: "docs only", spec: &actionsv1alpha1.PushSpec{Paths: []string{"**", "!docs/**", "!**.md"}}, event: newPush("refs/heads/main", "README.md", "docs/index.html"), want: false},
+		{name: "no files", spec: &actionsv1alpha1.PushSpec{Paths: []string{"**.go"}}, event: &github.PushEvent{Ref: github.String("refs/heads/main")}, want: true},
+		{name: "branch and path", spec: &actionsv1alpha1.PushSpec{Branches: []string{"main"}, Paths: []string{"**.go"}}, event: newPush("refs/heads/feature", "main.go"), want: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			trigger := actionsv1alpha1.ScaleUpTrigger{GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{Push: tc.spec}}
+
+			if got := hraWebhook.MatchPushEvent(tc.event)(trigger); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWebhookDeployment(t *testing.T) {
+	testServer(t,
+		"deployment",
+		&github.DeploymentEvent{
+			Deployment: &github.Deployment{
+				Environment: github.String("production"),
+			},
+			Repo: &github.Repository{
+				Name: github.String("myrepo"),
+				Owner: &github.User{
+					Login: github.String("myorg"),
+					Type:  github.String("Organization"),
+				},
+			},
+		},
+		200,
+		"no horizontalrunnerautoscaler to scale for this github event",
+	)
+}
+
+func TestMatchDeploymentEvent(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+
+	event := &github.DeploymentEvent{
+		Deployment: &github.Deployment{
+			Environment: github.String("production-eu"),
+		},
+	}
+
+	testcases := []struct {
+		spec *actionsv1alpha1.GitHubEventScaleUpTriggerSpec
+		want bool
+	}{
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{Deployment: &actionsv1alpha1.DeploymentSpec{}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{Deployment: &actionsv1alpha1.DeploymentSpec{Environments: []string{"staging", "production-*"}}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{Deployment: &actionsv1alpha1.DeploymentSpec{Environments: []string{"production"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{DeploymentStatus: &actionsv1alpha1.DeploymentStatusSpec{}}, want: false},
+		{spec: nil, want: false},
+	}
+
+	for i, tc := range testcases {
+		got := hraWebhook.MatchDeploymentEvent(event)(actionsv1alpha1.ScaleUpTrigger{GitHubEvent: tc.spec})
+		if got != tc.want {
+			t.Errorf("#%d: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestWebhookDeploymentStatus(t *testing.T) {
+	testServer(t,
+		"deployment_status",
+		&github.DeploymentStatusEvent{
+			Deployment: &github.Deployment{
+				Environment: github.String("production"),
+			},
+			DeploymentStatus: &github.DeploymentStatus{
+				State:       github.String("queued"),
+				Environment: github.String("production"),
+			},
+			Repo: &github.Repository{
+				Name: github.String("myrepo"),
+				Owner: &github.User{
+					Login: github.String("myorg"),
+					Type:  github.String("Organization"),
+				},
+			},
+		},
+		200,
+		"no horizontalrunnerautoscaler to scale for this github event",
+	)
+}
+
+func TestMatchDeploymentStatusEvent(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+
+	event := &github.DeploymentStatusEvent{
+		Deployment: &github.Deployment{
+			Environment: github.String("production-eu"),
+		},
+		DeploymentStatus: &github.DeploymentStatus{
+			State: github.String("queued"),
+		},
+	}
+
+	testcases := []struct {
+		spec *actionsv1alpha1.GitHubEventScaleUpTriggerSpec
+		want bool
+	}{
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{DeploymentStatus: &actionsv1alpha1.DeploymentStatusSpec{}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{DeploymentStatus: &actionsv1alpha1.DeploymentStatusSpec{States: []string{"queued"}, Environments: []string{"production-*"}}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{DeploymentStatus: &actionsv1alpha1.DeploymentStatusSpec{States: []string{"success"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{DeploymentStatus: &actionsv1alpha1.DeploymentStatusSpec{Environments: []string{"staging"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{Deployment: &actionsv1alpha1.DeploymentSpec{}}, want: false},
+	}
+
+	for i, tc := range testcases {
+		got := hraWebhook.MatchDeploymentStatusEvent(event)(actionsv1alpha1.ScaleUpTrigger{GitHubEvent: tc.spec})
+		if got != tc.want {
+			t.Errorf("#%d: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestWebhookCheckSuite(t *testing.T) {
+	testServer(t,
+		"check_suite",
+		&github.CheckSuiteEvent{
+			Action: github.String("requested"),
+			CheckSuite: &github.CheckSuite{
+				HeadBranch: github.String("main"),
+			},
+			Repo: &github.Repository{
+				Name: github.String("myrepo"),
+				Owner: &github.User{
+					Login: github.String("myorg"),
+					Type:  github.String("Organization"),
+				},
+			},
+		},
+		200,
+		"no horizontalrunnerautoscaler to scale for this github event",
+	)
+}
+
+func TestMatchCheckSuiteEvent(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+
+	event := &github.CheckSuiteEvent{
+		Action: github.String("rerequested"),
+		CheckSuite: &github.CheckSuite{
+			HeadBranch: github.String("main"),
+		},
+		Repo: &github.Repository{
+			Name: github.String("myrepo"),
+		},
+	}
+
+	testcases := []struct {
+		spec *actionsv1alpha1.GitHubEventScaleUpTriggerSpec
+		want bool
+	}{
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckSuite: &actionsv1alpha1.CheckSuiteSpec{}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckSuite: &actionsv1alpha1.CheckSuiteSpec{Types: []string{"requested", "rerequested"}, Branches: []string{"main"}, Repositories: []string{"myrepo"}}}, want: true},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckSuite: &actionsv1alpha1.CheckSuiteSpec{Types: []string{"completed"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckSuite: &actionsv1alpha1.CheckSuiteSpec{Branches: []string{"develop"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckSuite: &actionsv1alpha1.CheckSuiteSpec{Repositories: []string{"otherrepo"}}}, want: false},
+		{spec: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{CheckRun: &actionsv1alpha1.CheckRunSpec{}}, want: false},
+	}
+
+	for i, tc := range testcases {
+		got := hraWebhook.MatchCheckSuiteEvent(event)(actionsv1alpha1.ScaleUpTrigger{GitHubEvent: tc.spec})
+		if got != tc.want {
+			t.Errorf("#%d: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
 func TestWebhookPing(t *testing.T) {
 	testServer(t,
 		"ping",
@@ -114,6 +361,27 @@ func TestWebhookPing(t *testing.T) {
 	)
 }
 
+func TestWebhookRequireSignature(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client:           fake.NewFakeClientWithScheme(sc),
+		RequireSignature: true,
+	}
+	installTestLogger(hraWebhook)
+
+	server := httptest.NewServer(http.HandlerFunc(hraWebhook.Handle))
+	defer server.Close()
+
+	resp, err := sendWebhook(server, "ping", &github.PingEvent{Zen: github.String("zen")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 500 {
+		t.Errorf("expected the unsigned request to be rejected, got status %d", resp.StatusCode)
+	}
+}
+
 func TestWebhookWorkflowJob(t *testing.T) {
 	setupTest := func() github.WorkflowJobEvent {
 		f, err := os.Open("testdata/org_webhook_workflow_job_payload.json")
@@ -442,6 +710,640 @@ func TestGetValidCapacityReservations(t *testing.T) {
 	}
 }
 
+func TestTryScale_OptimisticCapacityReservation(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	scale := func(amount int, optimistic bool) []actionsv1alpha1.CapacityReservation {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			repository: "owner/repo",
+			optimistic: optimistic,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		return current.Spec.CapacityReservations
+	}
+
+	total := func(rs []actionsv1alpha1.CapacityReservation) (optimistic, actual int) {
+		for _, r := range rs {
+			if r.Optimistic {
+				optimistic += r.Replicas
+			} else {
+				actual += r.Replicas
+			}
+		}
+		return
+	}
+
+	// pull_request synchronize
+	if o, a := total(scale(2, true)); o != 2 || a != 0 {
+		t.Fatalf("unexpected reservations after pre-scale: optimistic=%d, actual=%d", o, a)
+	}
+
+	// workflow_job queued consumes the optimistic reservation without changing the total
+	if o, a := total(scale(1, false)); o != 1 || a != 1 {
+		t.Fatalf("unexpected reservations after the first queued job: optimistic=%d, actual=%d", o, a)
+	}
+
+	// workflow_job completed removes the actual reservation, not the optimistic one
+	if o, a := total(scale(-1, false)); o != 1 || a != 0 {
+		t.Fatalf("unexpected reservations after the completed job: optimistic=%d, actual=%d", o, a)
+	}
+
+	// Jobs exceeding the prediction add replicas as usual
+	if o, a := total(scale(1, false)); o != 0 || a != 1 {
+		t.Fatalf("unexpected reservations after the second queued job: optimistic=%d, actual=%d", o, a)
+	}
+
+	if o, a := total(scale(1, false)); o != 0 || a != 2 {
+		t.Fatalf("unexpected reservations after the third queued job: optimistic=%d, actual=%d", o, a)
+	}
+}
+
+// failingPatchClient fails the first patches, like the ones conflicting with other webhook servers.
+type failingPatchClient struct {
+	client.Client
+
+	failures int
+}
+
+func (c *failingPatchClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if c.failures > 0 {
+		c.failures--
+
+		return fmt.Errorf("conflict")
+	}
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestTryScale_OptimisticReplicasCancelledOnce(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra-cancelled-once",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				{Name: "owner/repo", ExpirationTime: metav1.Time{Time: time.Now().Add(-time.Minute)}, Replicas: 3, Optimistic: true},
+			},
+		},
+	}
+
+	c := &failingPatchClient{Client: fake.NewFakeClientWithScheme(sc, hra), failures: 1}
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: c}
+	installTestLogger(webhook)
+
+	cancelled := func() float64 {
+		t.Helper()
+
+		families, err := ctrlmetrics.Registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, f := range families {
+			if f.GetName() != "horizontalrunnerautoscaler_optimistic_replicas_total" {
+				continue
+			}
+
+			for _, m := range f.GetMetric() {
+				labels := map[string]string{}
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				if labels["horizontalrunnerautoscaler"] == hra.Name && labels["outcome"] == "cancelled" {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+
+		return 0
+	}
+
+	scale := func() error {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: hra.Name}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		return webhook.tryScale(context.Background(), &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   1,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			repository: "owner/other",
+		})
+	}
+
+	if err := scale(); err == nil {
+		t.Fatal("expected the first patch to fail")
+	}
+
+	if got := cancelled(); got != 0 {
+		t.Errorf("expected no cancelled replicas before the expired reservation is removed, got %v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := scale(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := cancelled(); got != 3 {
+		t.Errorf("expected the expired reservation to be counted once, got %v", got)
+	}
+}
+
+func TestTryScale_IdempotencyKey(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	scale := func(amount int, key string) []actionsv1alpha1.CapacityReservation {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			idempotencyKey: key,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		return current.Spec.CapacityReservations
+	}
+
+	scale(1, "workflow_job/1")
+	scale(1, "workflow_job/2")
+
+	// Redelivered events don't add reservations
+	if rs := scale(1, "workflow_job/1"); len(rs) != 2 {
+		t.Fatalf("unexpected number of reservations after the redelivered event: want 2, got %d", len(rs))
+	}
+
+	// The completion of a job removes the reservation for the same job
+	rs := scale(-1, "workflow_job/2")
+	if len(rs) != 1 || rs[0].IdempotencyKey != "workflow_job/1" {
+		t.Fatalf("unexpected reservations after the completed job: %+v", rs)
+	}
+
+	// The redelivered completion doesn't remove the reservation for another job
+	if rs := scale(-1, "workflow_job/2"); len(rs) != 1 {
+		t.Fatalf("unexpected number of reservations after the redelivered completion: want 1, got %d", len(rs))
+	}
+}
+
+func TestTryScale_Result(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				{ExpirationTime: metav1.Time{Time: time.Now().Add(time.Minute)}, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+				{ExpirationTime: metav1.Time{Time: time.Now().Add(-time.Minute)}, Replicas: 5},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	scale := func(amount int, key string) scaleResult {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			idempotencyKey: key,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		return target.result
+	}
+
+	// The expired reservation isn't counted
+	if got, want := scale(2, "workflow_job/2"), (scaleResult{decision: audit.DecisionScaled, amount: 2, reservedBefore: 1, reservedAfter: 3}); got != want {
+		t.Errorf("unexpected result of the scale up: want %+v, got %+v", want, got)
+	}
+
+	if got, want := scale(2, "workflow_job/2"), (scaleResult{decision: audit.DecisionDuplicate, reservedBefore: 3, reservedAfter: 3}); got != want {
+		t.Errorf("unexpected result of the redelivered scale up: want %+v, got %+v", want, got)
+	}
+
+	if got, want := scale(-1, "workflow_job/1"), (scaleResult{decision: audit.DecisionScaled, amount: -1, reservedBefore: 3, reservedAfter: 2}); got != want {
+		t.Errorf("unexpected result of the scale down: want %+v, got %+v", want, got)
+	}
+}
+
+func TestTryScale_Paused(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			Paused: true,
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				{ExpirationTime: metav1.Time{Time: time.Now().Add(time.Minute)}, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	scale := func(amount int, key string) scaleResult {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			idempotencyKey: key,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		return target.result
+	}
+
+	if got, want := scale(1, "workflow_job/2"), (scaleResult{decision: audit.DecisionPaused, reservedBefore: 1, reservedAfter: 1}); got != want {
+		t.Errorf("unexpected result of the scale up: want %+v, got %+v", want, got)
+	}
+
+	// The reservation made before the pause is removed on the completion of its job
+	if got, want := scale(-1, "workflow_job/1"), (scaleResult{decision: audit.DecisionScaled, amount: -1, reservedBefore: 1, reservedAfter: 0}); got != want {
+		t.Errorf("unexpected result of the scale down: want %+v, got %+v", want, got)
+	}
+}
+
+func TestTryScale_DryRun(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client, DryRun: true}
+	logs := installTestLogger(webhook)
+
+	target := &ScaleTarget{
+		HorizontalRunnerAutoscaler: *hra,
+		ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+			Amount:   2,
+			Duration: metav1.Duration{Duration: time.Minute},
+		},
+		idempotencyKey: "workflow_job/1",
+	}
+
+	if err := webhook.tryScale(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+
+	var current actionsv1alpha1.HorizontalRunnerAutoscaler
+	if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs := current.Spec.CapacityReservations; len(rs) != 0 {
+		t.Errorf("expected no capacity reservations in dry run, got %+v", rs)
+	}
+
+	if !strings.Contains(logs.String(), "Dry run: skipped patching hra") {
+		t.Errorf("expected the dry run to be logged, got %s", logs.String())
+	}
+}
+
+func TestRecordRunnerName(t *testing.T) {
+	expiration := metav1.Time{Time: time.Now().Add(time.Minute)}
+
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/2"},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	webhook.recordRunnerName(context.Background(), webhook.Log, *hra, "workflow_job/2", "runner-abc")
+
+	var got actionsv1alpha1.HorizontalRunnerAutoscaler
+	if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs := got.Spec.CapacityReservations; rs[0].RunnerName != "" || rs[1].RunnerName != "runner-abc" {
+		t.Errorf("unexpected reservations: %+v", rs)
+	}
+}
+
+func TestGetExplicitJobScaleTarget(t *testing.T) {
+	newHRA := func(name string, annotations map[string]string) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: name},
+			},
+		}
+	}
+
+	newRD := func(name string, labels ...string) *actionsv1alpha1.RunnerDeployment {
+		rd := &actionsv1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		rd.Spec.Template.Spec.Organization = "MYORG"
+		rd.Spec.Template.Spec.Labels = labels
+		return rd
+	}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		newHRA("default-pool", nil), newRD("default-pool", "label1"),
+		newHRA("team-pool", map[string]string{AnnotationKeyRepositories: "MYORG/app, MYORG/web"}), newRD("team-pool", "label1", "arc-hra-team-pool"),
+	)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client}
+	installTestLogger(webhook)
+
+	testcases := []struct {
+		repo   string
+		labels []string
+		want   string
+	}{
+		{repo: "MYORG/other", labels: []string{"label1", "arc-hra-team-pool"}, want: "team-pool"},
+		{repo: "MYORG/other", labels: []string{"label1", "arc-hra-missing"}, want: ""},
+		{repo: "MYORG/web", labels: []string{"label1"}, want: "team-pool"},
+		{repo: "MYORG/other", labels: []string{"label1"}, want: ""},
+		// The runners of the explicitly targeted HRA must have the labels requested by the job
+		{repo: "MYORG/web", labels: []string{"label2"}, want: ""},
+	}
+
+	for i, tc := range testcases {
+		target, err := webhook.getExplicitJobScaleTarget(context.Background(), webhook.Log, tc.repo, tc.labels)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+
+		var got string
+		if target != nil {
+			got = target.Name
+		}
+
+		if got != tc.want {
+			t.Errorf("%d: unexpected scale target: want %q, got %q", i, tc.want, got)
+		}
+	}
+}
+
+func TestWebhookIdempotencyKey(t *testing.T) {
+	jobEvent := &github.WorkflowJobEvent{WorkflowJob: &github.WorkflowJob{ID: github.Int64(123)}}
+
+	if got := webhookIdempotencyKey(jobEvent, "abc"); got != "workflow_job/123" {
+		t.Errorf("unexpected key for workflow_job event: %q", got)
+	}
+
+	if got := webhookIdempotencyKey(&github.PushEvent{}, "abc"); got != "delivery/abc" {
+		t.Errorf("unexpected key for push event: %q", got)
+	}
+
+	if got := webhookIdempotencyKey(&github.PushEvent{}, ""); got != "" {
+		t.Errorf("unexpected key without delivery ID: %q", got)
+	}
+}
+
+func TestRunnerLabelsMatch_Windows(t *testing.T) {
+	windows := actionsv1alpha1.RunnerConfig{OS: actionsv1alpha1.RunnerOSWindows, Labels: []string{"gpu"}}
+	linux := actionsv1alpha1.RunnerConfig{Labels: []string{"gpu"}}
+
+	testcases := []struct {
+		jobLabels []string
+		runner    actionsv1alpha1.RunnerConfig
+		want      bool
+	}{
+		{jobLabels: []string{"self-hosted", "Windows", "X64"}, runner: windows, want: true},
+		{jobLabels: []string{"self-hosted", "windows", "gpu"}, runner: windows, want: true},
+		{jobLabels: []string{"self-hosted", "windows", "arm64"}, runner: windows, want: false},
+		{jobLabels: []string{"self-hosted", "windows"}, runner: linux, want: false},
+		{jobLabels: []string{"self-hosted", "gpu"}, runner: linux, want: true},
+	}
+
+	for _, tc := range testcases {
+		if got := runnerLabelsMatch(tc.jobLabels, runnerLabels(tc.runner, nil)); got != tc.want {
+			t.Errorf("unexpected match of %v against the %q runner: want %v, got %v", tc.jobLabels, tc.runner.OS, tc.want, got)
+		}
+	}
+}
+
+func TestRunnerLabelsMatch_Arch(t *testing.T) {
+	arm64 := actionsv1alpha1.RunnerConfig{Arch: actionsv1alpha1.RunnerArchARM64}
+	unknown := actionsv1alpha1.RunnerConfig{}
+
+	testcases := []struct {
+		jobLabels    []string
+		runner       actionsv1alpha1.RunnerConfig
+		nodeSelector map[string]string
+		want         bool
+	}{
+		{jobLabels: []string{"self-hosted", "linux", "ARM64"}, runner: arm64, want: true},
+		{jobLabels: []string{"self-hosted", "linux", "x64"}, runner: arm64, want: false},
+		{jobLabels: []string{"self-hosted", "linux", "x64"}, runner: unknown, nodeSelector: map[string]string{"kubernetes.io/arch": "amd64"}, want: true},
+		{jobLabels: []string{"self-hosted", "linux", "arm64"}, runner: unknown, nodeSelector: map[string]string{"kubernetes.io/arch": "amd64"}, want: false},
+		{jobLabels: []string{"self-hosted", "linux", "x64"}, runner: unknown, want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := runnerLabelsMatch(tc.jobLabels, runnerLabels(tc.runner, tc.nodeSelector)); got != tc.want {
+			t.Errorf("unexpected match of %v against the runner with arch %q and node selector %v: want %v, got %v", tc.jobLabels, tc.runner.Arch, tc.nodeSelector, tc.want, got)
+		}
+	}
+}
+
+func TestRunnerLabelsMatch_GPU(t *testing.T) {
+	t4 := actionsv1alpha1.RunnerConfig{Labels: []string{"cuda"}, GPU: &actionsv1alpha1.GPUConfig{Count: 1, Type: "Tesla-T4"}}
+	anyGPU := actionsv1alpha1.RunnerConfig{GPU: &actionsv1alpha1.GPUConfig{Count: 1}}
+
+	testcases := []struct {
+		jobLabels []string
+		runner    actionsv1alpha1.RunnerConfig
+		want      bool
+	}{
+		{jobLabels: []string{"self-hosted", "gpu"}, runner: t4, want: true},
+		{jobLabels: []string{"self-hosted", "cuda", "tesla-t4"}, runner: t4, want: true},
+		{jobLabels: []string{"self-hosted", "gpu", "A100"}, runner: t4, want: false},
+		{jobLabels: []string{"self-hosted", "gpu"}, runner: anyGPU, want: true},
+		{jobLabels: []string{"self-hosted", "gpu"}, runner: actionsv1alpha1.RunnerConfig{}, want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := runnerLabelsMatch(tc.jobLabels, runnerLabels(tc.runner, nil)); got != tc.want {
+			t.Errorf("unexpected match of %v against the runner with gpu %+v: want %v, got %v", tc.jobLabels, tc.runner.GPU, tc.want, got)
+		}
+	}
+}
+
+func TestWorkflowJobLabelsMatch(t *testing.T) {
+	runner := runnerLabels(actionsv1alpha1.RunnerConfig{Labels: []string{"gpu"}}, nil)
+
+	newHRA := func(selectors *actionsv1alpha1.WorkflowJobLabelSelectors) actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return actionsv1alpha1.HorizontalRunnerAutoscaler{
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+					{GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{WorkflowJob: &actionsv1alpha1.WorkflowJobSpec{LabelSelectors: selectors}}},
+				},
+			},
+		}
+	}
+
+	gpuNotWindows := &actionsv1alpha1.WorkflowJobLabelSelectors{Include: []string{"gpu"}, Exclude: []string{"windows"}}
+
+	testcases := []struct {
+		name      string
+		hra       actionsv1alpha1.HorizontalRunnerAutoscaler
+		jobLabels []string
+		want      bool
+	}{
+		{name: "all runner labels", hra: newHRA(nil), jobLabels: []string{"self-hosted", "gpu"}, want: true},
+		{name: "missing runner label", hra: newHRA(nil), jobLabels: []string{"self-hosted", "gpu", "large"}, want: false},
+		{name: "included", hra: newHRA(gpuNotWindows), jobLabels: []string{"self-hosted", "GPU", "large"}, want: true},
+		{name: "not included", hra: newHRA(gpuNotWindows), jobLabels: []string{"self-hosted", "large"}, want: false},
+		{name: "excluded", hra: newHRA(gpuNotWindows), jobLabels: []string{"self-hosted", "gpu", "Windows"}, want: false},
+		{name: "exclude only", hra: newHRA(&actionsv1alpha1.WorkflowJobLabelSelectors{Exclude: []string{"windows"}}), jobLabels: []string{"self-hosted", "linux"}, want: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := workflowJobLabelsMatch(tc.hra, tc.jobLabels, runner); got != tc.want {
+				t.Errorf("unexpected match of %v: want %v, got %v", tc.jobLabels, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWorkflowJobAmount(t *testing.T) {
+	hra := actionsv1alpha1.HorizontalRunnerAutoscaler{
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+				{LabelAmounts: map[string]int{"large": 2, "xlarge": 4, "ignored": 0}},
+			},
+		},
+	}
+
+	testcases := []struct {
+		name      string
+		hra       actionsv1alpha1.HorizontalRunnerAutoscaler
+		jobLabels []string
+		want      int
+	}{
+		{name: "no trigger", hra: actionsv1alpha1.HorizontalRunnerAutoscaler{}, jobLabels: []string{"self-hosted", "xlarge"}, want: 1},
+		{name: "no label", hra: hra, jobLabels: []string{"self-hosted"}, want: 1},
+		{name: "label", hra: hra, jobLabels: []string{"self-hosted", "Large"}, want: 2},
+		{name: "largest", hra: hra, jobLabels: []string{"self-hosted", "large", "xlarge"}, want: 4},
+		{name: "amount less than 1", hra: hra, jobLabels: []string{"self-hosted", "ignored"}, want: 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := workflowJobAmount(tc.hra, tc.jobLabels); got != tc.want {
+				t.Errorf("unexpected amount for %v: want %d, got %d", tc.jobLabels, tc.want, got)
+			}
+		})
+	}
+}
+
 func installTestLogger(webhook *HorizontalRunnerAutoscalerGitHubWebhook) *bytes.Buffer {
 	logs := &bytes.Buffer{}
 
@@ -596,3 +1498,192 @@ func (l *testLogSink) WithValues(kvs ...interface{}) logr.LogSink {
 		writer:    l.writer,
 	}
 }
+
+func TestTryScale_ScaleUpMaxRatePerMinute(t *testing.T) {
+	rate := 3
+
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleUpMaxRatePerMinute: &rate,
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				// Added more than a minute ago
+				{ExpirationTime: metav1.Time{Time: time.Now().Add(5 * time.Minute)}, Replicas: 10},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, hra)
+	recorder := record.NewFakeRecorder(10)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client, Recorder: recorder}
+	installTestLogger(webhook)
+
+	scale := func(amount int) int {
+		t.Helper()
+
+		var current actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: current,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "hra"}, &current); err != nil {
+			t.Fatal(err)
+		}
+
+		var total int
+		for _, r := range current.Spec.CapacityReservations {
+			total += r.Replicas
+		}
+
+		return total
+	}
+
+	if got := scale(2); got != 12 {
+		t.Fatalf("unexpected replicas reserved within the rate: want 12, got %d", got)
+	}
+
+	if got := scale(2); got != 13 {
+		t.Fatalf("unexpected replicas reserved beyond the rate: want 13, got %d", got)
+	}
+
+	if got := scale(1); got != 13 {
+		t.Fatalf("unexpected replicas reserved after the rate was exhausted: want 13, got %d", got)
+	}
+
+	// Scale-down isn't limited by the webhook-based autoscaler
+	if got := scale(-1); got != 12 {
+		t.Fatalf("unexpected replicas reserved after the scale down: want 12, got %d", got)
+	}
+
+	if got := len(recorder.Events); got != 2 {
+		t.Errorf("unexpected number of suppression events: want 2, got %d", got)
+	}
+}
+
+func TestTryScale_ScaleUpMaxRatePerMinuteWithoutRecorder(t *testing.T) {
+	rate := 1
+
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hra",
+			Namespace: "default",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleUpMaxRatePerMinute: &rate,
+		},
+	}
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: fake.NewFakeClientWithScheme(sc, hra)}
+	installTestLogger(webhook)
+
+	target := &ScaleTarget{
+		HorizontalRunnerAutoscaler: *hra,
+		ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+			Amount:   2,
+			Duration: metav1.Duration{Duration: 10 * time.Minute},
+		},
+	}
+
+	// The suppression is only logged without the event recorder
+	if err := webhook.tryScale(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := target.result.amount; got != 1 {
+		t.Errorf("unexpected amount reserved within the rate: want 1, got %d", got)
+	}
+}
+
+func TestRecordScaleEvent(t *testing.T) {
+	rec := audit.Record{
+		Delivery:   "1234",
+		Event:      "workflow_job",
+		Action:     "queued",
+		Repository: "owner/repo",
+		Labels:     []string{"self-hosted", "linux"},
+	}
+
+	testcases := []struct {
+		name     string
+		decision audit.Decision
+		amount   int
+		err      string
+		want     string
+	}{
+		{
+			name:     "scaled up",
+			decision: audit.DecisionScaled,
+			amount:   2,
+			want:     "Normal WebhookScaledUp Scaled up by 2 on workflow_job queued of owner/repo with labels self-hosted,linux (delivery 1234). Reserved replicas: 1 -> 3",
+		},
+		{
+			name:     "scaled down",
+			decision: audit.DecisionScaled,
+			amount:   -1,
+			want:     "Normal WebhookScaledDown Scaled down by 1 on workflow_job queued of owner/repo with labels self-hosted,linux (delivery 1234). Reserved replicas: 1 -> 3",
+		},
+		{
+			name:     "failed",
+			decision: audit.DecisionFailed,
+			err:      "conflict",
+			want:     "Warning WebhookScaleFailed Failed to scale on workflow_job queued of owner/repo with labels self-hosted,linux (delivery 1234): conflict",
+		},
+		{
+			name:     "duplicate",
+			decision: audit.DecisionDuplicate,
+		},
+		{
+			name:     "dry run",
+			decision: audit.DecisionDryRun,
+			amount:   1,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+
+			webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Recorder: recorder}
+
+			target := &ScaleTarget{
+				HorizontalRunnerAutoscaler: actionsv1alpha1.HorizontalRunnerAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "hra", Namespace: "default"},
+				},
+				result: scaleResult{reservedBefore: 1, reservedAfter: 3},
+			}
+
+			r := rec
+			r.Decision = tc.decision
+			r.Amount = tc.amount
+			r.Error = tc.err
+
+			webhook.recordScaleEvent(target, r)
+
+			var got string
+			select {
+			case got = <-recorder.Events:
+			default:
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected event: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -27,17 +29,21 @@ import (
 	"github.com/actions-runner-controller/actions-runner-controller/github"
 	"github.com/go-logr/logr"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
 )
 
 const (
@@ -52,8 +58,28 @@ type HorizontalRunnerAutoscalerReconciler struct {
 	Recorder     record.EventRecorder
 	Scheme       *runtime.Scheme
 
-	CacheDuration time.Duration
-	Name          string
+	CacheDuration     time.Duration
+	Name              string
+	ControllerOptions ControllerOptions
+	// LogRateLimiter suppresses the log lines that repeat on every reconciliation while the cause persists
+	LogRateLimiter *logging.RateLimiter
+	// AccountMaxRunners is the maximum number of runners shared by all the HRAs whose scale targets belong to
+	// the same GitHub account. The desired replicas are clamped proportionally once the HRAs collectively exceed it.
+	// Zero disables the limit.
+	AccountMaxRunners int
+	// ClusterMaxRunners is the maximum number of runners shared by all the HRAs in the cluster.
+	// The desired replicas are clamped by the priorities and the weights of the HRAs once they collectively exceed it.
+	// Zero disables the limit.
+	ClusterMaxRunners int
+	// MultiGitHubClient provides the clients of the scale targets with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+	// RunnerQuotas makes the desired replicas of RunnerDeployments limited by the RunnerQuotas. Requires the RunnerQuota CRD.
+	RunnerQuotas bool
+
+	// capacityReservations are the fingerprints of the capacity reservations of the HRAs observed on the last reconciliations.
+	// See invalidateRunnersOnCapacityReservationsChange.
+	capacityReservations   map[types.NamespacedName]string
+	capacityReservationsMu sync.Mutex
 }
 
 const defaultReplicas = 1
@@ -62,6 +88,8 @@ const defaultReplicas = 1
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerquotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -78,6 +106,8 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 
 	metrics.SetHorizontalRunnerAutoscalerSpec(hra.ObjectMeta, hra.Spec)
 
+	r.warnUnsupportedScaleUpTriggers(ctx, log, hra)
+
 	kind := hra.Spec.ScaleTargetRef.Kind
 
 	switch kind {
@@ -96,6 +126,13 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 
 		st := r.scaleTargetFromRD(ctx, rd)
 
+		ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rd.Namespace, rd.Spec.Template.Spec.GitHubAPICredentialsFrom)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		st.githubClient = ghc
+
 		return r.reconcile(ctx, req, log, hra, st, func(newDesiredReplicas int) error {
 			currentDesiredReplicas := getIntOrDefault(rd.Spec.Replicas, defaultReplicas)
 
@@ -123,54 +160,14 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 			return ctrl.Result{}, nil
 		}
 
-		var replicas *int
+		st := r.scaleTargetFromRS(ctx, rs)
 
-		if rs.Spec.Replicas != nil {
-			v := int(*rs.Spec.Replicas)
-			replicas = &v
+		ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rs.Namespace, rs.Spec.GitHubAPICredentialsFrom)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
 
-		st := scaleTarget{
-			st:         rs.Name,
-			kind:       "runnerset",
-			enterprise: rs.Spec.Enterprise,
-			org:        rs.Spec.Organization,
-			repo:       rs.Spec.Repository,
-			replicas:   replicas,
-			getRunnerMap: func() (map[string]struct{}, error) {
-				// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
-				var runnerPodList corev1.PodList
-
-				var opts []client.ListOption
-
-				opts = append(opts, client.InNamespace(rs.Namespace))
-
-				selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
-				if err != nil {
-					return nil, err
-				}
-
-				opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
-
-				r.Log.V(2).Info("Finding runnerset's runner pods with selector", "ns", rs.Namespace)
-
-				if err := r.List(
-					ctx,
-					&runnerPodList,
-					opts...,
-				); err != nil {
-					if !kerrors.IsNotFound(err) {
-						return nil, err
-					}
-				}
-				runnerMap := make(map[string]struct{})
-				for _, items := range runnerPodList.Items {
-					runnerMap[items.Name] = struct{}{}
-				}
-
-				return runnerMap, nil
-			},
-		}
+		st.githubClient = ghc
 
 		return r.reconcile(ctx, req, log, hra, st, func(newDesiredReplicas int) error {
 			var replicas *int
@@ -193,7 +190,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 		})
 	}
 
-	log.Info(fmt.Sprintf("Unsupported scale target %s %s: kind %s is not supported. valid kinds are %s and %s", kind, hra.Spec.ScaleTargetRef.Name, kind, "RunnerDeployment", "RunnerSet"))
+	r.LogRateLimiter.Info(log, "unsupported_scale_target", fmt.Sprintf("Unsupported scale target %s %s: kind %s is not supported. valid kinds are %s and %s", kind, hra.Spec.ScaleTargetRef.Name, kind, "RunnerDeployment", "RunnerSet"))
 
 	return ctrl.Result{}, nil
 }
@@ -205,7 +202,9 @@ func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetFromRD(ctx context.Con
 		enterprise: rd.Spec.Template.Spec.Enterprise,
 		org:        rd.Spec.Template.Spec.Organization,
 		repo:       rd.Spec.Template.Spec.Repository,
+		labels:     rd.Spec.Template.Spec.Labels,
 		replicas:   rd.Spec.Replicas,
+		podSpec:    runnerPodSpecFromRD(rd),
 		getRunnerMap: func() (map[string]struct{}, error) {
 			// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
 			var runnerList v1alpha1.RunnerList
@@ -239,6 +238,106 @@ func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetFromRD(ctx context.Con
 
 			return runnerMap, nil
 		},
+		getUnschedulableReplicas: func() (int, error) {
+			// The pods are counted rather than reading the status of the runner deployment,
+			// which lags behind the pod event that triggered the reconciliation.
+			selector, err := metav1.LabelSelectorAsSelector(getSelector(&rd))
+			if err != nil {
+				return 0, err
+			}
+
+			var runnerPodList corev1.PodList
+
+			if err := r.List(ctx, &runnerPodList, client.InNamespace(rd.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return 0, err
+			}
+
+			unschedulable, _ := countUnschedulablePods(runnerPodList.Items)
+
+			return unschedulable, nil
+		},
+	}
+
+	if r.RunnerQuotas {
+		st.limitByRunnerQuotas = func(requested int) (runnerQuotaResult, error) {
+			return limitByRunnerQuotas(ctx, r.Client, rd, requested)
+		}
+	}
+
+	if p := rd.Spec.ScaleDownProtectionAfterRollout; p != nil && rd.Status.LastRolloutTime != nil {
+		t := rd.Status.LastRolloutTime.Add(p.Duration)
+		st.scaleDownProtectedUntil = &t
+	}
+
+	return st
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetFromRS(ctx context.Context, rs v1alpha1.RunnerSet) scaleTarget {
+	var replicas *int
+
+	if rs.Spec.Replicas != nil {
+		v := int(*rs.Spec.Replicas)
+		replicas = &v
+	}
+
+	st := scaleTarget{
+		st:         rs.Name,
+		kind:       "runnerset",
+		enterprise: rs.Spec.Enterprise,
+		org:        rs.Spec.Organization,
+		repo:       rs.Spec.Repository,
+		labels:     rs.Spec.Labels,
+		replicas:   replicas,
+		podSpec:    runnerPodSpecFromRS(rs),
+		getRunnerMap: func() (map[string]struct{}, error) {
+			// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
+			var runnerPodList corev1.PodList
+
+			var opts []client.ListOption
+
+			opts = append(opts, client.InNamespace(rs.Namespace))
+
+			selector, err := metav1.LabelSelectorAsSelector(getRunnerSetSelector(&rs))
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+
+			r.Log.V(2).Info("Finding runnerset's runner pods with selector", "ns", rs.Namespace)
+
+			if err := r.List(
+				ctx,
+				&runnerPodList,
+				opts...,
+			); err != nil {
+				if !kerrors.IsNotFound(err) {
+					return nil, err
+				}
+			}
+			runnerMap := make(map[string]struct{})
+			for _, items := range runnerPodList.Items {
+				runnerMap[items.Name] = struct{}{}
+			}
+
+			return runnerMap, nil
+		},
+		getUnschedulableReplicas: func() (int, error) {
+			selector, err := metav1.LabelSelectorAsSelector(getRunnerSetSelector(&rs))
+			if err != nil {
+				return 0, err
+			}
+
+			var runnerPodList corev1.PodList
+
+			if err := r.List(ctx, &runnerPodList, client.InNamespace(rs.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return 0, err
+			}
+
+			unschedulable, _ := countUnschedulablePods(runnerPodList.Items)
+
+			return unschedulable, nil
+		},
 	}
 
 	return st
@@ -247,14 +346,34 @@ func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetFromRD(ctx context.Con
 type scaleTarget struct {
 	st, kind              string
 	enterprise, repo, org string
+	labels                []string
 	replicas              *int
 
+	// githubClient is the client for the GitHub endpoint and the credentials of the scale target.
+	// It's nil when the scale target uses the default client of the controller
+	githubClient *github.Client
+
+	// podSpec has the containers and the scheduling constraints of the runner pods, which the placeholder pods mimic
+	podSpec corev1.PodSpec
+
+	// scaleDownProtectedUntil is set when the scale target has been rolled out recently and
+	// must not be scaled down until then
+	scaleDownProtectedUntil *time.Time
+
 	getRunnerMap func() (map[string]struct{}, error)
+
+	// getUnschedulableReplicas returns the number of the runner pods that the scheduler found no node for
+	getUnschedulableReplicas func() (int, error)
+
+	// limitByRunnerQuotas limits the desired replicas by the runner quotas. It's nil for the kinds not covered by runner quotas
+	limitByRunnerQuotas func(requested int) (runnerQuotaResult, error)
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, req ctrl.Request, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, updatedDesiredReplicas func(int) error) (ctrl.Result, error) {
 	now := time.Now()
 
+	r.invalidateRunnersOnCapacityReservationsChange(req.NamespacedName, hra, st)
+
 	minReplicas, active, upcoming, err := r.getMinReplicas(log, now, hra)
 	if err != nil {
 		log.Error(err, "Could not compute min replicas")
@@ -271,6 +390,181 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		return ctrl.Result{}, err
 	}
 
+	metrics.SetHorizontalRunnerAutoscalerReplicasBySource(hra.ObjectMeta, replicasBySource(hra, computedReplicas, getReservedReplicas(hra, now), minReplicas))
+
+	var predictive *v1alpha1.PredictiveStatus
+
+	if hra.Spec.Predictive != nil {
+		p := computePredictiveStatus(hra, computedReplicas+getReservedReplicas(hra, now), now)
+
+		if hra.Spec.Predictive.Enabled && p.PredictedReplicas > newDesiredReplicas {
+			predicted := clampReplicas(hra, p.PredictedReplicas, minReplicas)
+
+			log.V(1).Info("Pre-scaled for the predicted demand",
+				"requested", newDesiredReplicas,
+				"predicted", p.PredictedReplicas,
+				"season", p.Season,
+			)
+
+			newDesiredReplicas = predicted
+		}
+
+		predictive = &p
+	}
+
+	suspendedReplicas, unschedulableReplicas, err := suspendScaleUpWhileUnschedulable(hra, st, newDesiredReplicas)
+	if err != nil {
+		log.Error(err, "Could not count unschedulable runner pods")
+
+		return ctrl.Result{}, err
+	}
+
+	if suspendedReplicas != newDesiredReplicas {
+		log.V(1).Info("Suspended scale up while runner pods are unschedulable",
+			"requested", newDesiredReplicas,
+			"current", suspendedReplicas,
+			"unschedulable", unschedulableReplicas,
+		)
+
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "ScaleUpSuspended", fmt.Sprintf(
+			"Kept %d replicas instead of scaling up to %d while %d runner pods are unschedulable", suspendedReplicas, newDesiredReplicas, unschedulableReplicas))
+
+		newDesiredReplicas = suspendedReplicas
+	}
+
+	limitedByUnschedulable, unschedulableLimit, unschedulable, err := limitWhileUnschedulable(hra, st, newDesiredReplicas, now)
+	if err != nil {
+		log.Error(err, "Could not count unschedulable runner pods")
+
+		return ctrl.Result{}, err
+	}
+
+	if unschedulable > unschedulableReplicas {
+		unschedulableReplicas = unschedulable
+	}
+
+	if limitedByUnschedulable != newDesiredReplicas {
+		log.V(1).Info("Limited desired replicas to the replicas the cluster can run",
+			"requested", newDesiredReplicas,
+			"limited", limitedByUnschedulable,
+			"unschedulable", unschedulable,
+			"expiration_time", unschedulableLimit.ExpirationTime,
+		)
+
+		if prev := hra.Status.UnschedulableLimit; prev == nil || !prev.ExpirationTime.Equal(&unschedulableLimit.ExpirationTime) {
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, v1alpha1.HorizontalRunnerAutoscalerConditionReasonRunnerPodsUnschedulable, fmt.Sprintf(
+				"Limited desired replicas to %d instead of %d until %s because %d runner pods are unschedulable",
+				limitedByUnschedulable, newDesiredReplicas, unschedulableLimit.ExpirationTime.UTC().Format(time.RFC3339), unschedulable))
+		}
+
+		newDesiredReplicas = limitedByUnschedulable
+	}
+
+	pausedReplicas := getIntOrDefault(st.replicas, defaultReplicas)
+
+	// The replicas are pinned here so that the runner limits below are allocated and the scale rate is limited for the pinned replicas
+	if hra.Spec.Paused {
+		if pausedReplicas != newDesiredReplicas {
+			log.V(1).Info("Kept the replicas of the scale target while the autoscaler is paused",
+				"requested", newDesiredReplicas,
+				"current", pausedReplicas,
+			)
+		}
+
+		newDesiredReplicas = pausedReplicas
+	}
+
+	var accountRunnerLimit *v1alpha1.AccountRunnerLimitStatus
+
+	if r.AccountMaxRunners > 0 {
+		accountRunnerLimit, err = r.allocateAccountRunners(ctx, hra, st, newDesiredReplicas)
+		if err != nil {
+			log.Error(err, "Could not allocate runners within the GitHub account's limit")
+
+			return ctrl.Result{}, err
+		}
+
+		if accountRunnerLimit.AllocatedReplicas < newDesiredReplicas {
+			log.V(1).Info("Clamped desired replicas by the GitHub account's runner limit",
+				"account", accountRunnerLimit.Account,
+				"max_runners", accountRunnerLimit.MaxRunners,
+				"requested", newDesiredReplicas,
+				"allocated", accountRunnerLimit.AllocatedReplicas,
+			)
+
+			newDesiredReplicas = accountRunnerLimit.AllocatedReplicas
+		}
+	}
+
+	var clusterCapacity *v1alpha1.ClusterCapacityStatus
+
+	if r.ClusterMaxRunners > 0 {
+		clusterCapacity, err = r.allocateClusterCapacity(ctx, hra, newDesiredReplicas)
+		if err != nil {
+			log.Error(err, "Could not allocate runners within the cluster's capacity")
+
+			return ctrl.Result{}, err
+		}
+
+		if clusterCapacity.AllocatedReplicas < newDesiredReplicas {
+			log.V(1).Info("Clamped desired replicas by the cluster's runner capacity",
+				"max_runners", clusterCapacity.MaxRunners,
+				"requested", newDesiredReplicas,
+				"allocated", clusterCapacity.AllocatedReplicas,
+			)
+
+			if prev := hra.Status.ClusterCapacity; prev == nil || prev.AllocatedReplicas != clusterCapacity.AllocatedReplicas {
+				r.Recorder.Event(&hra, corev1.EventTypeNormal, "ClusterCapacityLimited", fmt.Sprintf(
+					"Allocated %d of %d requested replicas within the cluster's %d runners by the priorities and the weights of the autoscalers",
+					clusterCapacity.AllocatedReplicas, newDesiredReplicas, clusterCapacity.MaxRunners))
+			}
+
+			newDesiredReplicas = clusterCapacity.AllocatedReplicas
+		}
+	}
+
+	limitedReplicas, scaleRateLimitWindow := limitScaleRate(hra, newDesiredReplicas, now)
+
+	if limitedReplicas != newDesiredReplicas {
+		log.V(1).Info("Limited desired replicas by the scale rate limits",
+			"requested", newDesiredReplicas,
+			"limited", limitedReplicas,
+			"window_start_time", scaleRateLimitWindow.StartTime,
+			"window_start_replicas", scaleRateLimitWindow.StartReplicas,
+		)
+
+		r.recordScaleRateLimit(hra, newDesiredReplicas, limitedReplicas)
+
+		newDesiredReplicas = limitedReplicas
+	}
+
+	var quota runnerQuotaResult
+
+	// The runner quotas are applied last, as they are hard limits that the scale rate limits can't keep the replicas above
+	if st.limitByRunnerQuotas != nil {
+		quota, err = st.limitByRunnerQuotas(newDesiredReplicas)
+		if err != nil {
+			log.Error(err, "Could not apply runner quotas")
+
+			return ctrl.Result{}, err
+		}
+
+		if quota.limited() {
+			log.V(1).Info("Limited desired replicas by the runner quota",
+				"runnerquota", quota.limitedBy,
+				"requested", newDesiredReplicas,
+				"limited", quota.replicas,
+			)
+
+			newDesiredReplicas = quota.replicas
+		}
+	}
+
+	// The runner limits above can lower the pinned replicas, which must not scale the paused scale target down either
+	if hra.Spec.Paused {
+		newDesiredReplicas = pausedReplicas
+	}
+
 	if err := updatedDesiredReplicas(newDesiredReplicas); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -287,7 +581,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		updated.Status.DesiredReplicas = &newDesiredReplicas
 	}
 
-	if computedReplicasFromCache == nil {
+	if computedReplicasFromCache == nil && suggestedReplicasCacheable(hra) {
 		cacheEntries := getValidCacheEntries(updated, now)
 
 		var cacheDuration time.Duration
@@ -322,12 +616,96 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		}
 	}
 
+	if busyRunnersFromWorkflowJobEvents(hra) {
+		if len(hra.Status.BusyRunners) > 0 {
+			runnerMap, err := st.getRunnerMap()
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			// The webhook server may record another busy runner in the meantime,
+			// so the busy runners are patched only when there's something to prune, which is rare.
+			if pruned := pruneBusyRunners(hra.Status.BusyRunners, runnerMap); len(pruned) != len(hra.Status.BusyRunners) {
+				updated.Status.BusyRunners = pruned
+			}
+		}
+	} else {
+		updated.Status.BusyRunners = nil
+	}
+
+	updated.Status.Predictive = predictive
+	updated.Status.UnschedulableReplicas = unschedulableReplicas
+	updated.Status.UnschedulableLimit = unschedulableLimit
+
+	if runs, ok := metrics.GetHorizontalRunnerAutoscalerWorkflowRuns(hra.Namespace, hra.Name); ok {
+		updated.Status.WorkflowRuns = &v1alpha1.WorkflowRunsStatus{Queued: runs.Queued, InProgress: runs.InProgress}
+	}
+
+	setLimitedCondition(updated, unschedulableLimit, quota)
+	setScalingActiveCondition(updated)
+
 	if overridesSummary != "" {
 		updated.Status.ScheduledOverridesSummary = &overridesSummary
 	} else {
 		updated.Status.ScheduledOverridesSummary = nil
 	}
 
+	if hra.Spec.HostedRunnerFallback != nil {
+		fallback := computeHostedRunnerFallbackStatus(hra, computedReplicas+getReservedReplicas(hra, now), now)
+
+		r.recordHostedRunnerFallback(hra, st, fallback)
+
+		updated.Status.HostedRunnerFallback = &fallback
+	} else {
+		updated.Status.HostedRunnerFallback = nil
+	}
+
+	if hra.Spec.DualRun != nil {
+		dualRun := computeDualRunStatus(
+			hra,
+			clampReplicas(hra, computedReplicas, minReplicas),
+			clampReplicas(hra, minReplicas+getReservedReplicas(hra, now), minReplicas),
+			now,
+		)
+
+		r.recordDualRun(hra, dualRun)
+
+		updated.Status.DualRun = &dualRun
+	} else {
+		updated.Status.DualRun = nil
+	}
+
+	updated.Status.AccountRunnerLimit = accountRunnerLimit
+	updated.Status.ClusterCapacity = clusterCapacity
+	updated.Status.ScaleRateLimitWindow = scaleRateLimitWindow
+
+	switch {
+	case hra.Spec.Paused:
+		// The placeholder pods are kept as they are until the autoscaler is unpaused
+	case hra.Spec.Placeholders != nil:
+		placeholders := computePlaceholdersStatus(hra, getReservedReplicas(hra, now), newDesiredReplicas, placeholderClass(st.podSpec), now)
+
+		if err := r.syncPlaceholders(ctx, hra, st.podSpec, placeholders.Replicas); err != nil {
+			log.Error(err, "Could not sync placeholder pods")
+
+			return ctrl.Result{}, err
+		}
+
+		if prev := hra.Status.Placeholders; prev == nil || prev.Replicas != placeholders.Replicas {
+			log.V(1).Info("Resized placeholder pods for the forecasted demand", "class", placeholders.Class, "replicas", placeholders.Replicas)
+		}
+
+		updated.Status.Placeholders = &placeholders
+	case hra.Status.Placeholders != nil:
+		if err := r.deletePlaceholders(ctx, hra); err != nil {
+			log.Error(err, "Could not delete placeholder pods")
+
+			return ctrl.Result{}, err
+		}
+
+		updated.Status.Placeholders = nil
+	}
+
 	if !reflect.DeepEqual(hra.Status, updated.Status) {
 		metrics.SetHorizontalRunnerAutoscalerStatus(updated.ObjectMeta, updated.Status)
 
@@ -336,7 +714,34 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		}
 	}
 
-	return ctrl.Result{}, nil
+	var res ctrl.Result
+
+	// Capacity reservations are otherwise trimmed only when the webhook-based autoscaler receives the next event,
+	// which never comes after a burst, so the desired replicas are recomputed as soon as the next one expires.
+	if next := getNextCapacityReservationExpiry(hra, now); next != nil {
+		res.RequeueAfter = next.Sub(now)
+
+		log.V(2).Info("Requeueing for recomputing desired replicas on the next capacity reservation expiry", "expiration_time", next)
+	}
+
+	// The cap is lifted on expiry to retry scaling up
+	if unschedulableLimit != nil {
+		if d := unschedulableLimit.ExpirationTime.Sub(now); res.RequeueAfter == 0 || d < res.RequeueAfter {
+			res.RequeueAfter = d
+		}
+	}
+
+	// The forecast needs to be updated even without new capacity reservations, so that the placeholders shrink as the demand settles
+	if hra.Spec.Placeholders != nil && (res.RequeueAfter == 0 || res.RequeueAfter > placeholdersSampleInterval) {
+		res.RequeueAfter = placeholdersSampleInterval
+	}
+
+	// Every slot needs to be observed, and the predicted peaks need to be pre-scaled for before they come
+	if hra.Spec.Predictive != nil && (res.RequeueAfter == 0 || res.RequeueAfter > predictiveSampleInterval) {
+		res.RequeueAfter = predictiveSampleInterval
+	}
+
+	return res, nil
 }
 
 func getValidCacheEntries(hra *v1alpha1.HorizontalRunnerAutoscaler, now time.Time) []v1alpha1.CacheEntry {
@@ -351,6 +756,55 @@ func getValidCacheEntries(hra *v1alpha1.HorizontalRunnerAutoscaler, now time.Tim
 	return cacheEntries
 }
 
+func getReservedReplicas(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time) int {
+	var reserved int
+
+	for _, reservation := range hra.Spec.CapacityReservations {
+		if reservation.ExpirationTime.Time.After(now) {
+			reserved += reservation.Replicas
+		}
+	}
+
+	return reserved
+}
+
+// replicasBySource breaks down the suggested replicas plus the reserved replicas, clamped at minReplicas, by the source.
+// The HRAs scaled only by the webhook-based autoscaler have minReplicas suggested, which is counted as the baseline rather than the metrics.
+func replicasBySource(hra v1alpha1.HorizontalRunnerAutoscaler, suggested, reserved, minReplicas int) metrics.ReplicasBySource {
+	r := metrics.ReplicasBySource{
+		Metrics:              suggested,
+		Reservations:         reserved,
+		EffectiveMinReplicas: minReplicas,
+	}
+
+	if len(hra.Spec.Metrics) == 0 && len(hra.Spec.ScaleUpTriggers) > 0 {
+		r.Metrics = 0
+		r.Min = suggested
+	}
+
+	if total := suggested + reserved; total < minReplicas {
+		r.Min += minReplicas - total
+	}
+
+	return r
+}
+
+// getNextCapacityReservationExpiry returns the earliest expiration time of the capacity reservations that haven't expired yet,
+// or nil if there's none.
+func getNextCapacityReservationExpiry(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time) *time.Time {
+	var next *time.Time
+
+	for _, reservation := range hra.Spec.CapacityReservations {
+		t := reservation.ExpirationTime.Time
+
+		if t.After(now) && (next == nil || t.Before(*next)) {
+			next = &t
+		}
+	}
+
+	return next
+}
+
 func (r *HorizontalRunnerAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	name := "horizontalrunnerautoscaler-controller"
 	if r.Name != "" {
@@ -359,10 +813,29 @@ func (r *HorizontalRunnerAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager
 
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.HorizontalRunnerAutoscaler{}, unschedulableScaleTargetKey, func(rawObj client.Object) []string {
+		hra := rawObj.(*v1alpha1.HorizontalRunnerAutoscaler)
+
+		return unschedulableScaleTarget(*hra)
+	}); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
-		Named(name).
-		Complete(r)
+		Named(name)
+
+	if r.AccountMaxRunners > 0 {
+		b = b.Watches(&source.Kind{Type: &v1alpha1.HorizontalRunnerAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(r.horizontalRunnerAutoscalersForSameAccount))
+	}
+
+	if r.ClusterMaxRunners > 0 {
+		b = b.Watches(&source.Kind{Type: &v1alpha1.HorizontalRunnerAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(r.horizontalRunnerAutoscalersSharingClusterCapacity))
+	}
+
+	b = b.Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.horizontalRunnerAutoscalersForUnschedulablePod))
+
+	return r.ControllerOptions.complete(b, r)
 }
 
 type Override struct {
@@ -446,7 +919,11 @@ func (r *HorizontalRunnerAutoscalerReconciler) getMinReplicas(log logr.Logger, n
 func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr.Logger, now time.Time, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, minReplicas int) (int, int, *int, error) {
 	var suggestedReplicas int
 
-	suggestedReplicasFromCache := r.fetchSuggestedReplicasFromCache(hra)
+	var suggestedReplicasFromCache *int
+
+	if suggestedReplicasCacheable(hra) {
+		suggestedReplicasFromCache = r.fetchSuggestedReplicasFromCache(hra)
+	}
 
 	var cached *int
 
@@ -471,33 +948,21 @@ func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr
 		}
 	}
 
-	var reserved int
-
-	for _, reservation := range hra.Spec.CapacityReservations {
-		if reservation.ExpirationTime.Time.After(now) {
-			reserved += reservation.Replicas
-		}
-	}
+	reserved := getReservedReplicas(hra, now)
 
 	newDesiredReplicas := suggestedReplicas + reserved
 
-	if newDesiredReplicas < minReplicas {
-		newDesiredReplicas = minReplicas
-	} else if hra.Spec.MaxReplicas != nil && newDesiredReplicas > *hra.Spec.MaxReplicas {
-		newDesiredReplicas = *hra.Spec.MaxReplicas
+	if dualRun := hra.Spec.DualRun; dualRun != nil {
+		newDesiredReplicas = dualRunDesiredReplicas(*dualRun, suggestedReplicas, minReplicas, reserved)
 	}
 
+	newDesiredReplicas = clampReplicas(hra, newDesiredReplicas, minReplicas)
+
 	//
-	// Delay scaling-down for ScaleDownDelaySecondsAfterScaleUp or DefaultScaleDownDelay
+	// Delay scaling-down for ScaleDownDelaySecondsAfterScaleUp, or the delay of ScaleDownBehavior or DefaultScaleDownDelay
 	//
 
-	var scaleDownDelay time.Duration
-
-	if hra.Spec.ScaleDownDelaySecondsAfterScaleUp != nil {
-		scaleDownDelay = time.Duration(*hra.Spec.ScaleDownDelaySecondsAfterScaleUp) * time.Second
-	} else {
-		scaleDownDelay = DefaultScaleDownDelay
-	}
+	scaleDownDelay := scaleDownBehaviorOf(hra).delay
 
 	var scaleDownDelayUntil *time.Time
 
@@ -517,6 +982,21 @@ func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr
 		newDesiredReplicas = *hra.Status.DesiredReplicas
 	}
 
+	//
+	// Suppress scaling-down within ScaleDownProtectionAfterRollout after the scale target's template rollout
+	//
+
+	var scaleDownProtected bool
+
+	if hra.Status.DesiredReplicas != nil &&
+		*hra.Status.DesiredReplicas > newDesiredReplicas &&
+		st.scaleDownProtectedUntil != nil &&
+		st.scaleDownProtectedUntil.After(now) {
+
+		scaleDownProtected = true
+		newDesiredReplicas = *hra.Status.DesiredReplicas
+	}
+
 	//
 	// Logs various numbers for monitoring and debugging purpose
 	//
@@ -536,6 +1016,10 @@ func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr
 		kvs = append(kvs, "scale_down_delay_until", scaleDownDelayUntil)
 	}
 
+	if scaleDownProtected {
+		kvs = append(kvs, "scale_down_protected_until", *st.scaleDownProtectedUntil)
+	}
+
 	if maxReplicas := hra.Spec.MaxReplicas; maxReplicas != nil {
 		kvs = append(kvs, "max", *maxReplicas)
 	}
@@ -546,3 +1030,63 @@ func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr
 
 	return newDesiredReplicas, suggestedReplicas, suggestedReplicasFromCache, nil
 }
+
+// setScalingActiveCondition sets the ScalingActive condition of the HRA to false while it's paused,
+// and removes it otherwise.
+func setScalingActiveCondition(hra *v1alpha1.HorizontalRunnerAutoscaler) {
+	if !hra.Spec.Paused {
+		meta.RemoveStatusCondition(&hra.Status.Conditions, v1alpha1.HorizontalRunnerAutoscalerConditionTypeScalingActive)
+
+		return
+	}
+
+	meta.SetStatusCondition(&hra.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.HorizontalRunnerAutoscalerConditionTypeScalingActive,
+		Status:             metav1.ConditionFalse,
+		Reason:             v1alpha1.HorizontalRunnerAutoscalerConditionReasonScalingPaused,
+		Message:            "The replicas of the scale target are kept as they are because the autoscaler is paused",
+		ObservedGeneration: hra.Generation,
+	})
+}
+
+// gitHubClientOf returns the client for the GitHub API calls of the scale target.
+func (r *HorizontalRunnerAutoscalerReconciler) gitHubClientOf(st scaleTarget) *github.Client {
+	if st.githubClient != nil {
+		return st.githubClient
+	}
+
+	return r.GitHubClient
+}
+
+// invalidateRunnersOnCapacityReservationsChange invalidates the runner inventory of the scale target once the capacity
+// reservations of the HRA change. The webhook-based autoscaler changes them on workflow_job events, which signal that
+// runners are about to be registered, have picked up jobs, or have completed them.
+func (r *HorizontalRunnerAutoscalerReconciler) invalidateRunnersOnCapacityReservationsChange(name types.NamespacedName, hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget) {
+	ghc := r.gitHubClientOf(st)
+	if ghc == nil {
+		return
+	}
+
+	var reservations []string
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		reservations = append(reservations, fmt.Sprintf("%s/%s/%d/%s", cr.IdempotencyKey, cr.Name, cr.Replicas, cr.RunnerName))
+	}
+
+	fingerprint := strings.Join(reservations, ",")
+
+	r.capacityReservationsMu.Lock()
+	defer r.capacityReservationsMu.Unlock()
+
+	if r.capacityReservations == nil {
+		r.capacityReservations = map[types.NamespacedName]string{}
+	}
+
+	last, ok := r.capacityReservations[name]
+
+	r.capacityReservations[name] = fingerprint
+
+	if ok && last != fingerprint {
+		ghc.InvalidateRunners(st.enterprise, st.org, st.repo)
+	}
+}
@@ -1,12 +1,21 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func TestGetValidCacheEntries(t *testing.T) {
@@ -48,3 +57,169 @@ func TestGetValidCacheEntries(t *testing.T) {
 		t.Errorf("%s", d)
 	}
 }
+
+func TestGetNextCapacityReservationExpiry(t *testing.T) {
+	now := time.Now()
+
+	hra := actionsv1alpha1.HorizontalRunnerAutoscaler{}
+
+	if next := getNextCapacityReservationExpiry(hra, now); next != nil {
+		t.Errorf("expected no expiry without reservations, got %s", next)
+	}
+
+	hra.Spec.CapacityReservations = []actionsv1alpha1.CapacityReservation{
+		{Replicas: 1, ExpirationTime: metav1.Time{Time: now.Add(-time.Second)}},
+		{Replicas: 1, ExpirationTime: metav1.Time{Time: now.Add(3 * time.Minute)}},
+		{Replicas: 1, ExpirationTime: metav1.Time{Time: now.Add(time.Minute)}},
+		{Replicas: 1, ExpirationTime: metav1.Time{Time: now}},
+	}
+
+	next := getNextCapacityReservationExpiry(hra, now)
+	if next == nil || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the earliest unexpired reservation to expire at %s, got %v", now.Add(time.Minute), next)
+	}
+}
+
+func TestReplicasBySource(t *testing.T) {
+	metricsDriven := actionsv1alpha1.HorizontalRunnerAutoscaler{
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			Metrics: []actionsv1alpha1.MetricSpec{{Type: actionsv1alpha1.AutoscalingMetricTypePercentageRunnersBusy}},
+		},
+	}
+
+	webhookDriven := actionsv1alpha1.HorizontalRunnerAutoscaler{
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{{Duration: metav1.Duration{Duration: time.Minute}}},
+		},
+	}
+
+	testcases := []struct {
+		name                         string
+		hra                          actionsv1alpha1.HorizontalRunnerAutoscaler
+		suggested, reserved, minimum int
+		want                         metrics.ReplicasBySource
+	}{
+		{
+			name:      "metrics above min",
+			hra:       metricsDriven,
+			suggested: 5,
+			reserved:  2,
+			minimum:   3,
+			want:      metrics.ReplicasBySource{Metrics: 5, Reservations: 2, EffectiveMinReplicas: 3},
+		},
+		{
+			name:      "metrics below min",
+			hra:       metricsDriven,
+			suggested: 1,
+			reserved:  1,
+			minimum:   3,
+			want:      metrics.ReplicasBySource{Metrics: 1, Reservations: 1, Min: 1, EffectiveMinReplicas: 3},
+		},
+		{
+			name:      "webhook",
+			hra:       webhookDriven,
+			suggested: 3,
+			reserved:  4,
+			minimum:   3,
+			want:      metrics.ReplicasBySource{Reservations: 4, Min: 3, EffectiveMinReplicas: 3},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := replicasBySource(tc.hra, tc.suggested, tc.reserved, tc.minimum)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected replicas (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestHorizontalRunnerAutoscalerReconciler_Paused(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	testcases := []struct {
+		paused            bool
+		replicas          int
+		clusterMaxRunners int
+		want              int
+	}{
+		{paused: false, replicas: 1, want: 3},
+		{paused: true, replicas: 1, want: 1},
+		// The runner limits applied after the pause don't scale the paused scale target down
+		{paused: true, replicas: 4, clusterMaxRunners: 2, want: 4},
+	}
+
+	for _, tc := range testcases {
+		paused := tc.paused
+
+		rd := &actionsv1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: actionsv1alpha1.RunnerDeploymentSpec{
+				Replicas: intPtr(tc.replicas),
+				Template: actionsv1alpha1.RunnerTemplate{
+					Spec: actionsv1alpha1.RunnerSpec{
+						RunnerConfig: actionsv1alpha1.RunnerConfig{Repository: "test/valid"},
+					},
+				},
+			},
+		}
+
+		hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: "example"},
+				MinReplicas:    intPtr(3),
+				MaxReplicas:    intPtr(5),
+				// Webhook-based autoscaling only, so that no GitHub API call is made
+				ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{{Duration: metav1.Duration{Duration: time.Minute}}},
+				Paused:          paused,
+			},
+		}
+
+		c := fake.NewFakeClientWithScheme(sc, rd, hra)
+
+		r := &HorizontalRunnerAutoscalerReconciler{
+			Client:            c,
+			Log:               logf.Log,
+			Recorder:          record.NewFakeRecorder(10),
+			LogRateLimiter:    logging.NewRateLimiter(0),
+			ClusterMaxRunners: tc.clusterMaxRunners,
+		}
+
+		key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Get(context.Background(), key, rd); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Get(context.Background(), key, hra); err != nil {
+			t.Fatal(err)
+		}
+
+		want := tc.want
+
+		if got := *rd.Spec.Replicas; got != want {
+			t.Errorf("paused=%v: unexpected replicas of the runner deployment: want %d, got %d", paused, want, got)
+		}
+
+		if got := hra.Status.DesiredReplicas; got == nil || *got != want {
+			t.Errorf("paused=%v: unexpected desired replicas in the status: want %d, got %v", paused, want, got)
+		}
+
+		cond := meta.FindStatusCondition(hra.Status.Conditions, actionsv1alpha1.HorizontalRunnerAutoscalerConditionTypeScalingActive)
+
+		if paused && (cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != actionsv1alpha1.HorizontalRunnerAutoscalerConditionReasonScalingPaused) {
+			t.Errorf("expected ScalingActive=False while paused, got %+v", cond)
+		}
+
+		if !paused && cond != nil {
+			t.Errorf("unexpected condition while not paused: %+v", cond)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+const (
+	DefaultHostedRunnerFallbackSustainedFor       = 10 * time.Minute
+	DefaultHostedRunnerFallbackAverageJobDuration = 10 * time.Minute
+
+	// hostedRunnerFallbackCommitStatusContext is the context of the commit status that recommends GitHub-hosted runners.
+	// The same context is updated on every queued job for the same commit, rather than adding a status per job.
+	hostedRunnerFallbackCommitStatusContext = "actions-runner-controller/hosted-runner-fallback"
+)
+
+// computeHostedRunnerFallbackStatus compares the desired replicas before being capped by MaxReplicas, the demand,
+// to MaxReplicas, and recommends GitHub-hosted runners once the demand keeps exceeding it for SustainedFor.
+func computeHostedRunnerFallbackStatus(hra v1alpha1.HorizontalRunnerAutoscaler, demand int, now time.Time) v1alpha1.HostedRunnerFallbackStatus {
+	var status v1alpha1.HostedRunnerFallbackStatus
+
+	if hra.Spec.MaxReplicas == nil || demand <= *hra.Spec.MaxReplicas {
+		return status
+	}
+
+	spec := hra.Spec.HostedRunnerFallback
+
+	sustainedFor := DefaultHostedRunnerFallbackSustainedFor
+	if spec.SustainedFor != nil {
+		sustainedFor = spec.SustainedFor.Duration
+	}
+
+	averageJobDuration := DefaultHostedRunnerFallbackAverageJobDuration
+	if spec.AverageJobDuration != nil {
+		averageJobDuration = spec.AverageJobDuration.Duration
+	}
+
+	maxReplicas := *hra.Spec.MaxReplicas
+
+	status.OverflowReplicas = demand - maxReplicas
+	status.OverflowSince = &metav1.Time{Time: now}
+
+	if prev := hra.Status.HostedRunnerFallback; prev != nil && prev.OverflowSince != nil {
+		status.OverflowSince = prev.OverflowSince
+	}
+
+	status.Recommended = !now.Before(status.OverflowSince.Add(sustainedFor))
+
+	// Every runner takes another job once it completes the current one, so the jobs beyond the capacity
+	// are drained in waves of maxReplicas jobs, each of which takes averageJobDuration.
+	// A newly queued job waits for all the waves ahead of it.
+	if maxReplicas > 0 {
+		waves := (status.OverflowReplicas + maxReplicas - 1) / maxReplicas
+		status.EstimatedWaitSeconds = int((time.Duration(waves) * averageJobDuration).Seconds())
+	}
+
+	return status
+}
+
+// recordHostedRunnerFallback exposes the status as metrics, and emits events when the recommendation starts or stops.
+func (r *HorizontalRunnerAutoscalerReconciler) recordHostedRunnerFallback(hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, status v1alpha1.HostedRunnerFallbackStatus) {
+	metrics.SetHorizontalRunnerAutoscalerHostedRunnerFallback(hra.ObjectMeta, st.labels, status)
+
+	wasRecommended := hra.Status.HostedRunnerFallback != nil && hra.Status.HostedRunnerFallback.Recommended
+
+	if status.Recommended && !wasRecommended {
+		r.Recorder.Event(&hra, corev1.EventTypeWarning, "HostedRunnerFallbackRecommended", fmt.Sprintf(
+			"Desired replicas have exceeded maxReplicas by %d since %s. Consider running jobs for runner labels [%s] on GitHub-hosted runners. %s",
+			status.OverflowReplicas, status.OverflowSince.Format(time.RFC3339), strings.Join(st.labels, ","), hostedRunnerFallbackEstimatedWait(status),
+		))
+	} else if !status.Recommended && wasRecommended {
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "HostedRunnerFallbackCleared", "Desired replicas no longer exceed maxReplicas")
+	}
+}
+
+func hostedRunnerFallbackEstimatedWait(status v1alpha1.HostedRunnerFallbackStatus) string {
+	return fmt.Sprintf("Estimated wait time for a runner is %s.", time.Duration(status.EstimatedWaitSeconds)*time.Second)
+}
+
+// setHostedRunnerFallbackCommitStatus sets a pending commit status on the commit of a queued workflow job
+// when the HorizontalRunnerAutoscaler of the job recommends GitHub-hosted runners and opts in to commit statuses.
+// The status is resolved to success once a job for the same commit completes.
+// Failures are only logged, because the commit status is advisory and shouldn't fail scaling.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) setHostedRunnerFallbackCommitStatus(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, hra v1alpha1.HorizontalRunnerAutoscaler) {
+	sha := e.GetWorkflowJob().GetHeadSHA()
+
+	if sha == "" || hra.Spec.HostedRunnerFallback == nil || !hra.Spec.HostedRunnerFallback.CommitStatus {
+		return
+	}
+
+	ghc, err := gitHubClientForScaleTarget(ctx, autoscaler.Client, autoscaler.MultiGitHubClient, autoscaler.GitHubClient, hra)
+	if err != nil {
+		log.Error(err, "Failed to get the GitHub client for the hosted runner fallback commit status")
+		return
+	}
+
+	if ghc == nil {
+		log.V(1).Info("Skipped setting the hosted runner fallback commit status because the GitHub client isn't configured")
+		return
+	}
+
+	owner, repo := e.Repo.Owner.GetLogin(), e.Repo.GetName()
+
+	var repoStatus *gogithub.RepoStatus
+
+	switch e.GetAction() {
+	case "queued":
+		status := hra.Status.HostedRunnerFallback
+		if status == nil || !status.Recommended {
+			return
+		}
+
+		repoStatus = &gogithub.RepoStatus{
+			State:       gogithub.String("pending"),
+			Description: gogithub.String(fmt.Sprintf("Self-hosted runners are saturated. %s Consider GitHub-hosted runners.", hostedRunnerFallbackEstimatedWait(*status))),
+		}
+	case "completed":
+		combined, _, err := ghc.Repositories.GetCombinedStatus(ctx, owner, repo, sha, nil)
+		if err != nil {
+			log.Error(err, "Failed to get commit statuses for resolving the hosted runner fallback commit status", "sha", sha)
+			return
+		}
+
+		var pending bool
+		for _, s := range combined.Statuses {
+			if s.GetContext() == hostedRunnerFallbackCommitStatusContext && s.GetState() == "pending" {
+				pending = true
+			}
+		}
+
+		if !pending {
+			return
+		}
+
+		repoStatus = &gogithub.RepoStatus{
+			State:       gogithub.String("success"),
+			Description: gogithub.String("A self-hosted runner has run a job for this commit."),
+		}
+	default:
+		return
+	}
+
+	repoStatus.Context = gogithub.String(hostedRunnerFallbackCommitStatusContext)
+
+	if _, _, err := ghc.Repositories.CreateStatus(ctx, owner, repo, sha, repoStatus); err != nil {
+		log.Error(err, "Failed to set the hosted runner fallback commit status", "sha", sha)
+	}
+}
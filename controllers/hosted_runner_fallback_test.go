@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeHostedRunnerFallbackStatus(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	maxReplicas := 4
+
+	newHRA := func(prev *v1alpha1.HostedRunnerFallbackStatus) v1alpha1.HorizontalRunnerAutoscaler {
+		return v1alpha1.HorizontalRunnerAutoscaler{
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MaxReplicas: &maxReplicas,
+				HostedRunnerFallback: &v1alpha1.HostedRunnerFallbackSpec{
+					SustainedFor:       &metav1.Duration{Duration: 5 * time.Minute},
+					AverageJobDuration: &metav1.Duration{Duration: 3 * time.Minute},
+				},
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+				HostedRunnerFallback: prev,
+			},
+		}
+	}
+
+	testcases := []struct {
+		name   string
+		prev   *v1alpha1.HostedRunnerFallbackStatus
+		demand int
+		want   v1alpha1.HostedRunnerFallbackStatus
+	}{
+		{
+			name:   "within capacity",
+			demand: 4,
+			want:   v1alpha1.HostedRunnerFallbackStatus{},
+		},
+		{
+			name:   "overflow started",
+			demand: 5,
+			want: v1alpha1.HostedRunnerFallbackStatus{
+				OverflowReplicas:     1,
+				OverflowSince:        &metav1.Time{Time: now},
+				EstimatedWaitSeconds: 180,
+			},
+		},
+		{
+			name:   "overflow not sustained long enough",
+			prev:   &v1alpha1.HostedRunnerFallbackStatus{OverflowSince: &metav1.Time{Time: now.Add(-4 * time.Minute)}},
+			demand: 9,
+			want: v1alpha1.HostedRunnerFallbackStatus{
+				OverflowReplicas:     5,
+				OverflowSince:        &metav1.Time{Time: now.Add(-4 * time.Minute)},
+				EstimatedWaitSeconds: 360,
+			},
+		},
+		{
+			name:   "overflow sustained",
+			prev:   &v1alpha1.HostedRunnerFallbackStatus{OverflowSince: &metav1.Time{Time: now.Add(-5 * time.Minute)}},
+			demand: 9,
+			want: v1alpha1.HostedRunnerFallbackStatus{
+				OverflowReplicas:     5,
+				OverflowSince:        &metav1.Time{Time: now.Add(-5 * time.Minute)},
+				Recommended:          true,
+				EstimatedWaitSeconds: 360,
+			},
+		},
+		{
+			name:   "overflow resolved",
+			prev:   &v1alpha1.HostedRunnerFallbackStatus{OverflowSince: &metav1.Time{Time: now.Add(-time.Hour)}, Recommended: true},
+			demand: 3,
+			want:   v1alpha1.HostedRunnerFallbackStatus{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := computeHostedRunnerFallbackStatus(newHRA(tc.prev), tc.demand, now)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected status (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,412 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// hraRoute is what the webhook-based autoscaler needs to know about an HRA to route the webhook events to it.
+// It's computed from the scale target of the HRA once per change, instead of once per webhook event.
+type hraRoute struct {
+	// targetKind and targetName are of the scale target, for re-routing the HRA when the scale target changes
+	targetKind, targetName string
+
+	// keys are the repository, organization, enterprise and runner group keys that the HRA is found by
+	keys []string
+
+	// organization, enterprise and group are of the runners of the scale target, for finding the potential runner groups
+	organization, enterprise, group string
+
+	// host is the normalized AnnotationKeyGitHubEnterpriseHost of the HRA
+	host string
+}
+
+// newHRARoute computes the route of the HRA from its scale target.
+// The route has no keys while the scale target is missing, so that the HRA isn't found until the scale target is created.
+func newHRARoute(ctx context.Context, c client.Reader, hra v1alpha1.HorizontalRunnerAutoscaler) (hraRoute, error) {
+	route := hraRoute{
+		targetKind: hra.Spec.ScaleTargetRef.Kind,
+		targetName: hra.Spec.ScaleTargetRef.Name,
+		host:       normalizeGitHubEnterpriseHost(hra.Annotations[AnnotationKeyGitHubEnterpriseHost]),
+	}
+
+	if route.targetKind == "" {
+		route.targetKind = "RunnerDeployment"
+	}
+
+	if route.targetName == "" {
+		return route, nil
+	}
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: route.targetName}
+
+	switch route.targetKind {
+	case "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, key, &rd); kerrors.IsNotFound(err) {
+			return route, nil
+		} else if err != nil {
+			return route, err
+		}
+
+		spec := rd.Spec.Template.Spec
+
+		route.organization, route.enterprise, route.group = spec.Organization, spec.Enterprise, spec.Group
+
+		if spec.Repository != "" {
+			route.keys = append(route.keys, spec.Repository) // Repository runners
+		}
+		if spec.Organization != "" {
+			if spec.Group != "" {
+				route.keys = append(route.keys, organizationalRunnerGroupKey(spec.Organization, spec.Group)) // Organization runner groups
+			} else {
+				route.keys = append(route.keys, spec.Organization) // Organization runners
+			}
+		}
+		if spec.Enterprise != "" {
+			if spec.Group != "" {
+				route.keys = append(route.keys, enterpriseRunnerGroupKey(spec.Enterprise, spec.Group)) // Enterprise runner groups
+			} else {
+				route.keys = append(route.keys, enterpriseKey(spec.Enterprise)) // Enterprise runners
+			}
+		}
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := c.Get(ctx, key, &rs); kerrors.IsNotFound(err) {
+			return route, nil
+		} else if err != nil {
+			return route, err
+		}
+
+		spec := rs.Spec.RunnerConfig
+
+		route.organization, route.enterprise, route.group = spec.Organization, spec.Enterprise, spec.Group
+
+		if spec.Repository != "" {
+			route.keys = append(route.keys, spec.Repository) // Repository runners
+		}
+		if spec.Organization != "" {
+			route.keys = append(route.keys, spec.Organization) // Organization runners
+			if spec.Group != "" {
+				route.keys = append(route.keys, organizationalRunnerGroupKey(spec.Organization, spec.Group)) // Organization runner groups
+			}
+		}
+		if spec.Enterprise != "" {
+			route.keys = append(route.keys, enterpriseKey(spec.Enterprise)) // Enterprise runners
+			if spec.Group != "" {
+				route.keys = append(route.keys, enterpriseRunnerGroupKey(spec.Enterprise, spec.Group)) // Enterprise runner groups
+			}
+		}
+	}
+
+	return route, nil
+}
+
+// hraRoutingTable finds the HRAs for a webhook event without listing all the HRAs and getting their scale targets
+// on every event, which takes tens of milliseconds per event with thousands of HRAs.
+//
+// The table is built from all the HRAs on the first lookup, and then kept up to date by the webhook-based autoscaler
+// reconciling the HRAs on their changes and the changes of their scale targets.
+type hraRoutingTable struct {
+	mu    sync.RWMutex
+	built bool
+
+	routes map[types.NamespacedName]hraRoute
+
+	// byKey maps the keys to the HRAs found by them, sorted for the deterministic selection of the scale target
+	byKey map[string][]types.NamespacedName
+	// byGroupOwner maps the organizations and the enterprise keys to the HRAs of their runner groups
+	byGroupOwner map[string][]types.NamespacedName
+	// byTarget maps the namespaces, the kinds and the names of the scale targets to the HRAs
+	byTarget map[string][]types.NamespacedName
+}
+
+func scaleTargetRouteKey(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+// build computes the routes of all the HRAs in the namespace, or all the namespaces when it's empty, unless already built.
+// The routes are computed by a bounded number of workers, because each of them reads the scale target.
+func (t *hraRoutingTable) build(ctx context.Context, c client.Reader, namespace string) error {
+	t.mu.RLock()
+	built := t.built
+	t.mu.RUnlock()
+
+	if built {
+		return nil
+	}
+
+	// The write lock is held while listing, so that the reconciliations of the HRAs changed meanwhile are
+	// applied after, not before, the build
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.built {
+		return nil
+	}
+
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+	if err := c.List(ctx, &hraList, opts...); err != nil {
+		return err
+	}
+
+	routes := make([]hraRoute, len(hraList.Items))
+	errs := make([]error, len(hraList.Items))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(hraList.Items) {
+		workers = len(hraList.Items)
+	}
+
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				routes[i], errs[i] = newHRARoute(ctx, c, hraList.Items[i])
+			}
+		}()
+	}
+
+	for i := range hraList.Items {
+		indices <- i
+	}
+	close(indices)
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	t.routes = map[types.NamespacedName]hraRoute{}
+	t.byKey = map[string][]types.NamespacedName{}
+	t.byGroupOwner = map[string][]types.NamespacedName{}
+	t.byTarget = map[string][]types.NamespacedName{}
+
+	for i, hra := range hraList.Items {
+		t.set(types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}, routes[i])
+	}
+
+	t.built = true
+
+	return nil
+}
+
+// update recomputes the route of the HRA by the name. It's a no-op until the table is built,
+// because the build reads the latest HRA anyway.
+func (t *hraRoutingTable) update(ctx context.Context, c client.Reader, name types.NamespacedName) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.built {
+		return nil
+	}
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := c.Get(ctx, name, &hra); kerrors.IsNotFound(err) {
+		t.delete(name)
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	route, err := newHRARoute(ctx, c, hra)
+	if err != nil {
+		return err
+	}
+
+	t.delete(name)
+	t.set(name, route)
+
+	return nil
+}
+
+func (t *hraRoutingTable) set(name types.NamespacedName, route hraRoute) {
+	t.routes[name] = route
+
+	for _, k := range route.keys {
+		t.byKey[k] = insertName(t.byKey[k], name)
+	}
+
+	if route.group != "" {
+		if route.organization != "" {
+			t.byGroupOwner[route.organization] = insertName(t.byGroupOwner[route.organization], name)
+		}
+
+		if route.enterprise != "" {
+			k := enterpriseKey(route.enterprise)
+			t.byGroupOwner[k] = insertName(t.byGroupOwner[k], name)
+		}
+	}
+
+	k := scaleTargetRouteKey(name.Namespace, route.targetKind, route.targetName)
+	t.byTarget[k] = insertName(t.byTarget[k], name)
+}
+
+func (t *hraRoutingTable) delete(name types.NamespacedName) {
+	route, ok := t.routes[name]
+	if !ok {
+		return
+	}
+
+	delete(t.routes, name)
+
+	for _, k := range route.keys {
+		t.byKey[k] = removeName(t.byKey[k], name)
+		if len(t.byKey[k]) == 0 {
+			delete(t.byKey, k)
+		}
+	}
+
+	for _, k := range []string{route.organization, enterpriseKey(route.enterprise)} {
+		if names, ok := t.byGroupOwner[k]; ok {
+			t.byGroupOwner[k] = removeName(names, name)
+			if len(t.byGroupOwner[k]) == 0 {
+				delete(t.byGroupOwner, k)
+			}
+		}
+	}
+
+	k := scaleTargetRouteKey(name.Namespace, route.targetKind, route.targetName)
+	t.byTarget[k] = removeName(t.byTarget[k], name)
+	if len(t.byTarget[k]) == 0 {
+		delete(t.byTarget, k)
+	}
+}
+
+// hrasByKey returns the names of the HRAs found by the key that accept the webhook events sent from the host.
+func (t *hraRoutingTable) hrasByKey(key, host string) []types.NamespacedName {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var names []types.NamespacedName
+
+	for _, name := range t.byKey[key] {
+		if matchesRouteHost(t.routes[name], host) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// runnerGroups returns the runner groups of the HRAs for the organization or the enterprise key
+// that accept the webhook events sent from the host, without duplicates.
+func (t *hraRoutingTable) runnerGroups(owner, host string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	seen := map[string]bool{}
+
+	var groups []string
+
+	for _, name := range t.byGroupOwner[owner] {
+		route := t.routes[name]
+
+		if !matchesRouteHost(route, host) || seen[route.group] {
+			continue
+		}
+
+		seen[route.group] = true
+		groups = append(groups, route.group)
+	}
+
+	sort.Strings(groups)
+
+	return groups
+}
+
+// hrasByScaleTarget returns the names of the HRAs that scale the scale target.
+func (t *hraRoutingTable) hrasByScaleTarget(namespace, kind, name string) []types.NamespacedName {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return append([]types.NamespacedName{}, t.byTarget[scaleTargetRouteKey(namespace, kind, name)]...)
+}
+
+func matchesRouteHost(route hraRoute, host string) bool {
+	return route.host == "" || route.host == normalizeGitHubEnterpriseHost(host)
+}
+
+func insertName(names []types.NamespacedName, name types.NamespacedName) []types.NamespacedName {
+	i := sort.Search(len(names), func(i int) bool { return !lessName(names[i], name) })
+	if i < len(names) && names[i] == name {
+		return names
+	}
+
+	names = append(names, types.NamespacedName{})
+	copy(names[i+1:], names[i:])
+	names[i] = name
+
+	return names
+}
+
+func removeName(names []types.NamespacedName, name types.NamespacedName) []types.NamespacedName {
+	i := sort.Search(len(names), func(i int) bool { return !lessName(names[i], name) })
+	if i == len(names) || names[i] != name {
+		return names
+	}
+
+	return append(names[:i], names[i+1:]...)
+}
+
+func lessName(a, b types.NamespacedName) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+
+	return a.Name < b.Name
+}
+
+// hrasForScaleTarget returns the function that maps a scale target of the kind to the reconcile requests of its HRAs,
+// so that the HRAs are re-routed when the repository, the organization, the enterprise or the group of the runners changes.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) hrasForScaleTarget(kind string) func(client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		var reqs []reconcile.Request
+
+		for _, name := range autoscaler.routes.hrasByScaleTarget(obj.GetNamespace(), kind, obj.GetName()) {
+			reqs = append(reqs, reconcile.Request{NamespacedName: name})
+		}
+
+		return reqs
+	}
+}
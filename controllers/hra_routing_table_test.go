@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func newRoutedRunnerDeployment(namespace, name string, runnerConfig v1alpha1.RunnerConfig) *v1alpha1.RunnerDeployment {
+	return &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{RunnerConfig: runnerConfig},
+			},
+		},
+	}
+}
+
+func newRoutedHRA(namespace, name, kind, target string) *v1alpha1.HorizontalRunnerAutoscaler {
+	return &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Kind: kind, Name: target},
+		},
+	}
+}
+
+func TestHRARoutingTable(t *testing.T) {
+	ctx := context.Background()
+
+	rs := &v1alpha1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise", Namespace: "ns2"},
+		Spec: v1alpha1.RunnerSetSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Enterprise: "acme", Group: "large"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc,
+		newRoutedRunnerDeployment("ns1", "repo", v1alpha1.RunnerConfig{Repository: "example/app"}),
+		newRoutedRunnerDeployment("ns1", "org", v1alpha1.RunnerConfig{Organization: "example"}),
+		newRoutedRunnerDeployment("ns2", "group", v1alpha1.RunnerConfig{Organization: "example", Group: "gpu"}),
+		rs,
+		newRoutedHRA("ns1", "repo", "", "repo"),
+		newRoutedHRA("ns1", "org", "RunnerDeployment", "org"),
+		newRoutedHRA("ns2", "group", "", "group"),
+		newRoutedHRA("ns2", "enterprise", "RunnerSet", "enterprise"),
+		newRoutedHRA("ns2", "missing", "", "missing"),
+	)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: c}
+
+	names := func(key string) []string {
+		t.Helper()
+
+		hras, err := webhook.findHRAsByKey(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, hra := range hras {
+			names = append(names, hra.Namespace+"/"+hra.Name)
+		}
+
+		return names
+	}
+
+	testcases := []struct {
+		key  string
+		want []string
+	}{
+		{key: "example/app", want: []string{"ns1/repo"}},
+		{key: "example", want: []string{"ns1/org"}},
+		{key: organizationalRunnerGroupKey("example", "gpu"), want: []string{"ns2/group"}},
+		{key: enterpriseKey("acme"), want: []string{"ns2/enterprise"}},
+		{key: enterpriseRunnerGroupKey("acme", "large"), want: []string{"ns2/enterprise"}},
+		{key: "missing", want: nil},
+	}
+
+	for _, tc := range testcases {
+		if d := cmp.Diff(tc.want, names(tc.key)); d != "" {
+			t.Errorf("unexpected hras for %q: %s", tc.key, d)
+		}
+	}
+
+	enterpriseGroups, orgGroups, err := webhook.getPotentialGroupsFromHRAs(ctx, "acme", "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"large"}, enterpriseGroups); d != "" {
+		t.Errorf("unexpected enterprise groups: %s", d)
+	}
+
+	if d := cmp.Diff([]string{"gpu"}, orgGroups); d != "" {
+		t.Errorf("unexpected organization groups: %s", d)
+	}
+
+	// The HRA is re-routed on the change of its scale target
+	var rd v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: "org"}, &rd); err != nil {
+		t.Fatal(err)
+	}
+
+	rd.Spec.Template.Spec.Organization = "other"
+	if err := c.Update(ctx, &rd); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, req := range webhook.hrasForScaleTarget("RunnerDeployment")(&rd) {
+		if _, err := webhook.Reconcile(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d := cmp.Diff([]string(nil), names("example")); d != "" {
+		t.Errorf("unexpected hras for the previous organization: %s", d)
+	}
+
+	if d := cmp.Diff([]string{"ns1/org"}, names("other")); d != "" {
+		t.Errorf("unexpected hras for the new organization: %s", d)
+	}
+
+	// The HRA is unrouted on its deletion
+	if err := c.Delete(ctx, newRoutedHRA("ns1", "repo", "", "repo")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := webhook.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "repo"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string(nil), names("example/app")); d != "" {
+		t.Errorf("unexpected hras after the deletion: %s", d)
+	}
+}
+
+func TestHRARoutingTable_Namespace(t *testing.T) {
+	ctx := context.Background()
+
+	c := fake.NewFakeClientWithScheme(sc,
+		newRoutedRunnerDeployment("ns1", "org", v1alpha1.RunnerConfig{Organization: "example"}),
+		newRoutedRunnerDeployment("ns2", "org", v1alpha1.RunnerConfig{Organization: "example"}),
+		newRoutedHRA("ns1", "org", "", "org"),
+		newRoutedHRA("ns2", "org", "", "org"),
+	)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: c, Namespace: "ns2"}
+
+	hras, err := webhook.findHRAsByKey(ctx, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hras) != 1 || hras[0].Namespace != "ns2" {
+		t.Errorf("expected only the hra in the watched namespace, got %v", hras)
+	}
+
+	if _, err := webhook.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "org"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if hras := webhook.routes.hrasByKey("example", ""); len(hras) != 1 {
+		t.Errorf("expected the hra in the other namespace to be ignored, got %v", hras)
+	}
+}
+
+// newBenchmarkClient returns the client with the HRAs for n repositories spread across 100 namespaces,
+// plus the HRAs for the runner groups of the organization.
+func newBenchmarkClient(n int) client.Client {
+	var objs []runtime.Object
+
+	for i := 0; i < n; i++ {
+		ns := fmt.Sprintf("ns%d", i%100)
+		name := fmt.Sprintf("repo%d", i)
+
+		objs = append(objs,
+			newRoutedRunnerDeployment(ns, name, v1alpha1.RunnerConfig{Repository: "example/" + name}),
+			newRoutedHRA(ns, name, "", name),
+		)
+	}
+
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("group%d", i)
+
+		objs = append(objs,
+			newRoutedRunnerDeployment("groups", name, v1alpha1.RunnerConfig{Organization: "example", Group: name}),
+			newRoutedHRA("groups", name, "", name),
+		)
+	}
+
+	return fake.NewFakeClientWithScheme(sc, objs...)
+}
+
+func BenchmarkFindHRAsByKey_10kHRAs(b *testing.B) {
+	ctx := context.Background()
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: newBenchmarkClient(10000)}
+
+	if _, err := webhook.findHRAsByKey(ctx, "example/repo0"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		hras, err := webhook.findHRAsByKey(ctx, fmt.Sprintf("example/repo%d", i%10000))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if len(hras) != 1 {
+			b.Fatalf("unexpected hras: %d", len(hras))
+		}
+	}
+}
+
+func BenchmarkGetScaleUpTarget_10kHRAs_RunnerGroup(b *testing.B) {
+	ctx := context.Background()
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: newBenchmarkClient(10000), Log: logr.Discard()}
+
+	scaleTarget := func(value string) (*ScaleTarget, error) {
+		return webhook.getJobScaleTarget(ctx, value, []string{"self-hosted"})
+	}
+
+	// The repository without the HRA falls back to the organization runner groups
+	resolve := func() (*ScaleTarget, error) {
+		return webhook.getScaleUpTargetWithFunction(ctx, logr.Discard(), "unknown", "example", "Organization", "", scaleTarget)
+	}
+
+	if _, err := resolve(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		target, err := resolve()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if target == nil || target.Name != "group0" {
+			b.Fatalf("unexpected target: %v", target)
+		}
+	}
+}
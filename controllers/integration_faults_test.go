@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+)
+
+var _ = Context("INTEGRATION: With GitHub API faults", func() {
+	ctx := context.TODO()
+	env := SetupIntegrationTest(ctx)
+	ns := env.Namespace
+
+	newRunnerDeployment := func(name string, replicas int) *actionsv1alpha1.RunnerDeployment {
+		return &actionsv1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+			},
+			Spec: actionsv1alpha1.RunnerDeploymentSpec{
+				Replicas: intPtr(replicas),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"foo": "bar",
+					},
+				},
+				Template: actionsv1alpha1.RunnerTemplate{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							"foo": "bar",
+						},
+					},
+					Spec: actionsv1alpha1.RunnerSpec{
+						RunnerConfig: actionsv1alpha1.RunnerConfig{
+							Repository: "test/valid",
+							Image:      "bar",
+						},
+						RunnerPodSpec: actionsv1alpha1.RunnerPodSpec{
+							Env: []corev1.EnvVar{
+								{Name: "FOO", Value: "FOOVALUE"},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	Describe("when GitHub fails", func() {
+
+		It("should create runners after transient server errors, an expired token, and a rate limit", func() {
+			name := "example-runnerdeploy"
+
+			env.Faults.Enqueue(
+				fake.ServerErrorFault(http.StatusBadGateway),
+				fake.ServerErrorFault(http.StatusInternalServerError),
+				fake.UnauthorizedFault(),
+				fake.RateLimitFault(time.Now().Add(time.Second)),
+			)
+
+			ExpectCreate(ctx, newRunnerDeployment(name, 2), "test RunnerDeployment")
+			ExpectRunnerSetsCountEventuallyEquals(ctx, ns.Name, 1)
+			ExpectRunnerSetsManagedReplicasCountEventuallyEquals(ctx, ns.Name, 2)
+			ExpectRunnerCountEventuallyEquals(ctx, ns.Name, 2)
+
+			Eventually(env.Faults.Faults, time.Second*10, time.Millisecond*500).Should(Equal(4), "all the enqueued faults should be returned")
+
+			env.ExpectRegisteredNumberCountEventuallyEquals(2, "count of fake runners after faults")
+		})
+
+		It("should scale runners while GitHub randomly fails and responds slowly", func() {
+			name := "example-runnerdeploy"
+
+			env.Faults.SetLatency(10 * time.Millisecond)
+			env.Faults.SetErrorRate(0.3)
+
+			ExpectCreate(ctx, newRunnerDeployment(name, 3), "test RunnerDeployment")
+			ExpectRunnerSetsCountEventuallyEquals(ctx, ns.Name, 1)
+			ExpectRunnerSetsManagedReplicasCountEventuallyEquals(ctx, ns.Name, 3)
+
+			ExpectRunnerDeploymentEventuallyUpdates(ctx, ns.Name, name, func(rd *actionsv1alpha1.RunnerDeployment) {
+				rd.Spec.Replicas = intPtr(1)
+			})
+			ExpectRunnerSetsManagedReplicasCountEventuallyEquals(ctx, ns.Name, 1)
+
+			// Recovers fully once GitHub does
+			env.Faults.SetErrorRate(0)
+
+			ExpectRunnerCountEventuallyEquals(ctx, ns.Name, 1)
+			env.ExpectRegisteredNumberCountEventuallyEquals(1, "count of fake runners after recovery")
+		})
+
+		It("should create runners once the rate limit that ran out resets", func() {
+			name := "example-runnerdeploy"
+
+			env.Faults.SequenceRateLimits(time.Now().Add(2*time.Second), 1, 0)
+
+			ExpectCreate(ctx, newRunnerDeployment(name, 2), "test RunnerDeployment")
+			ExpectRunnerSetsCountEventuallyEquals(ctx, ns.Name, 1)
+			ExpectRunnerSetsManagedReplicasCountEventuallyEquals(ctx, ns.Name, 2)
+			ExpectRunnerCountEventuallyEquals(ctx, ns.Name, 2)
+
+			env.ExpectRegisteredNumberCountEventuallyEquals(2, "count of fake runners after the rate limit reset")
+		})
+	})
+})
@@ -29,6 +29,7 @@ import (
 type testEnvironment struct {
 	Namespace *corev1.Namespace
 	Responses *fake.FixedResponses
+	Faults    *fake.FaultInjector
 
 	webhookServer    *httptest.Server
 	ghClient         *github2.Client
@@ -88,9 +89,11 @@ func SetupIntegrationTest(ctx2 context.Context) *testEnvironment {
 		}
 		fakeRunnerList := fake.NewRunnersList()
 		responses.ListRunners = fakeRunnerList.HandleList()
-		fakeGithubServer := fake.NewServer(fake.WithFixedResponses(responses))
+		faults := fake.NewFaultInjector(GinkgoRandomSeed())
+		fakeGithubServer := fake.NewServer(fake.WithFixedResponses(responses), fake.WithFaultInjector(faults))
 
 		env.Responses = responses
+		env.Faults = faults
 		env.fakeRunnerList = fakeRunnerList
 		env.fakeGithubServer = fakeGithubServer
 		env.ghClient = newGithubClient(fakeGithubServer)
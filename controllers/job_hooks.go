@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// EnvVarPreJobHook and EnvVarPostJobHook are the user-provided job hooks run by the ARC job hooks
+	// after starting and stopping the job watchdog. See runner/hooks.
+	EnvVarPreJobHook  = "RUNNER_PRE_JOB_HOOK"
+	EnvVarPostJobHook = "RUNNER_POST_JOB_HOOK"
+
+	envVarJobStartedHook   = "ACTIONS_RUNNER_HOOK_JOB_STARTED"
+	envVarJobCompletedHook = "ACTIONS_RUNNER_HOOK_JOB_COMPLETED"
+
+	jobStartedHookPath   = "/etc/arc/hooks/job-started.sh"
+	jobCompletedHookPath = "/etc/arc/hooks/job-completed.sh"
+
+	jobHooksVolumeName = "job-hooks"
+	jobHooksMountPath  = "/etc/actions-runner-controller/job-hooks"
+
+	preJobHookFile  = "pre-job.sh"
+	postJobHookFile = "post-job.sh"
+)
+
+// newJobHooksConfig returns the environment variables, volumes, and volume mounts for the runner container
+// that make the runner run the job hooks.
+//
+// With maxJobDuration, the health check recycling unhealthy runners, or the cache server, the runner runs the ARC job hooks
+// that start and stop the job watchdog and the health check, and pass the cache server to the job, which in turn run the user-provided hooks.
+// Otherwise, the runner runs the user-provided hooks directly, so that they work with any runner image.
+func newJobHooksConfig(hooks *v1alpha1.JobHooks, maxJobDuration *metav1.Duration, healthCheck *v1alpha1.RunnerHealthCheck, cacheURL string) ([]corev1.EnvVar, []corev1.Volume, []corev1.VolumeMount) {
+	var (
+		env     []corev1.EnvVar
+		sources []corev1.VolumeProjection
+
+		startedHook, completedHook string
+	)
+
+	if (maxJobDuration != nil && maxJobDuration.Duration > 0) || (healthCheck != nil && healthCheck.Recycle) || cacheURL != "" {
+		startedHook = jobStartedHookPath
+		completedHook = jobCompletedHookPath
+	}
+
+	if hooks != nil {
+		if hooks.PreJob != nil {
+			path := filepath.Join(jobHooksMountPath, preJobHookFile)
+
+			if startedHook != "" {
+				env = append(env, corev1.EnvVar{Name: EnvVarPreJobHook, Value: path})
+			} else {
+				startedHook = path
+			}
+
+			sources = append(sources, newJobHookVolumeProjection(*hooks.PreJob, preJobHookFile))
+		}
+
+		if hooks.PostJob != nil {
+			path := filepath.Join(jobHooksMountPath, postJobHookFile)
+
+			if completedHook != "" {
+				env = append(env, corev1.EnvVar{Name: EnvVarPostJobHook, Value: path})
+			} else {
+				completedHook = path
+			}
+
+			sources = append(sources, newJobHookVolumeProjection(*hooks.PostJob, postJobHookFile))
+		}
+	}
+
+	if startedHook != "" {
+		env = append(env, corev1.EnvVar{Name: envVarJobStartedHook, Value: startedHook})
+	}
+
+	if completedHook != "" {
+		env = append(env, corev1.EnvVar{Name: envVarJobCompletedHook, Value: completedHook})
+	}
+
+	if len(sources) == 0 {
+		return env, nil, nil
+	}
+
+	defaultMode := int32(0755)
+
+	volumes := []corev1.Volume{
+		{
+			Name: jobHooksVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources:     sources,
+					DefaultMode: &defaultMode,
+				},
+			},
+		},
+	}
+
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      jobHooksVolumeName,
+			MountPath: jobHooksMountPath,
+			ReadOnly:  true,
+		},
+	}
+
+	return env, volumes, volumeMounts
+}
+
+func newJobHookVolumeProjection(source v1alpha1.JobHookSource, file string) corev1.VolumeProjection {
+	if ref := source.SecretKeyRef; ref != nil {
+		return corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: ref.LocalObjectReference,
+				Items:                []corev1.KeyToPath{{Key: ref.Key, Path: file}},
+				Optional:             ref.Optional,
+			},
+		}
+	}
+
+	ref := source.ConfigMapKeyRef
+
+	return corev1.VolumeProjection{
+		ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: ref.LocalObjectReference,
+			Items:                []corev1.KeyToPath{{Key: ref.Key, Path: file}},
+			Optional:             ref.Optional,
+		},
+	}
+}
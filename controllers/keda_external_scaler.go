@@ -0,0 +1,390 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/externalscaler"
+)
+
+const (
+	// KEDAMetricNameDesiredReplicas is the name of the only metric served by KEDAExternalScaler.
+	// Its value is the number of queued and in-progress workflow jobs for the scale target, and
+	// its target size is 1 so that KEDA scales the scale target to one runner per job.
+	KEDAMetricNameDesiredReplicas = "desired-replicas"
+
+	// Keys of the metadata of the external scaler trigger in ScaledObject
+	kedaMetadataKeyScaleTargetKind = "scaleTargetKind"
+	kedaMetadataKeyScaleTargetName = "scaleTargetName"
+	kedaMetadataKeyRepositoryNames = "repositoryNames"
+
+	DefaultKEDAExternalScalerPollingInterval = 30 * time.Second
+
+	// kedaExternalScalerCacheDuration dedups GitHub API calls made for the IsActive and GetMetrics calls
+	// that KEDA makes within a polling interval.
+	kedaExternalScalerCacheDuration = 10 * time.Second
+)
+
+// KEDAExternalScaler serves KEDA's ExternalScaler gRPC service, so that KEDA ScaledObjects can scale
+// RunnerDeployments and RunnerSets by the number of queued and in-progress workflow jobs, computed the same way
+// as the TotalNumberOfQueuedAndInProgressWorkflowRuns metric of HorizontalRunnerAutoscaler.
+//
+// A ScaledObject specifies the scale target with the scaleTargetName and optionally scaleTargetKind metadata of the trigger.
+// It implements manager.Runnable so that it can be added to the controller manager.
+type KEDAExternalScaler struct {
+	externalscaler.UnimplementedExternalScalerServer
+
+	Client       client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+
+	// MultiGitHubClient provides the clients for the scale targets whose runners select their GitHub endpoints and credentials
+	// by githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
+	// Addr is the address the gRPC server binds to.
+	Addr string
+
+	// CertDir is the directory that contains tls.crt and tls.key, and optionally ca.crt.
+	// A self-signed certificate is generated when the directory doesn't contain tls.crt,
+	// which works with the unsafeSsl metadata of the trigger.
+	// When the directory contains ca.crt, KEDA must present a client certificate signed by it, by the tlsClientCert of the trigger.
+	CertDir string
+
+	// Namespaces are the namespaces the controller watches. The requests for the ScaledObjects in the other namespaces
+	// are rejected. Empty for all namespaces.
+	Namespaces []string
+
+	// PollingInterval is the interval at which StreamIsActive re-computes the activity of the scale target.
+	PollingInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[kedaCacheKey]kedaCacheEntry
+}
+
+// kedaCacheKey identifies the value computed for the trigger of the ScaledObject.
+// It contains the scale target and the repositories too, so that a changed trigger doesn't get the value computed for the previous one.
+type kedaCacheKey struct {
+	namespace, scaledObject string
+	targetKind, targetName  string
+	repositoryNames         string
+}
+
+type kedaCacheEntry struct {
+	value     int
+	expiresAt time.Time
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Every replica serves the API, because KEDA can be configured to connect to any of them through a Service.
+func (s *KEDAExternalScaler) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (s *KEDAExternalScaler) Start(ctx context.Context) error {
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.Addr, err)
+	}
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	externalscaler.RegisterExternalScalerServer(srv, s)
+
+	go func() {
+		<-ctx.Done()
+
+		srv.GracefulStop()
+	}()
+
+	s.Log.Info("Starting KEDA external scaler", "addr", s.Addr)
+
+	return srv.Serve(lis)
+}
+
+func (s *KEDAExternalScaler) tlsConfig() (*tls.Config, error) {
+	cert, err := s.loadCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if s.CertDir == "" {
+		return config, nil
+	}
+
+	caFile := filepath.Join(s.CertDir, "ca.crt")
+
+	ca, err := os.ReadFile(caFile)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", caFile, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid client CA in %s", caFile)
+	}
+
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.ClientCAs = pool
+
+	return config, nil
+}
+
+func (s *KEDAExternalScaler) loadCertificate() (tls.Certificate, error) {
+	if s.CertDir != "" {
+		certFile := filepath.Join(s.CertDir, "tls.crt")
+		keyFile := filepath.Join(s.CertDir, "tls.key")
+
+		if _, err := os.Stat(certFile); err == nil {
+			return tls.LoadX509KeyPair(certFile, keyFile)
+		}
+	}
+
+	s.Log.Info("Using a self-signed certificate for the KEDA external scaler")
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("actions-runner-controller-keda-external-scaler", nil, nil)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generating self-signed certificate: %w", err)
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// IsActive returns true when there's any queued or in-progress workflow job for the scale target.
+func (s *KEDAExternalScaler) IsActive(ctx context.Context, ref *externalscaler.ScaledObjectRef) (*externalscaler.IsActiveResponse, error) {
+	v, err := s.getDesiredReplicas(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return &externalscaler.IsActiveResponse{Result: v > 0}, nil
+}
+
+// StreamIsActive sends the result of IsActive every PollingInterval until the stream is closed.
+func (s *KEDAExternalScaler) StreamIsActive(ref *externalscaler.ScaledObjectRef, stream externalscaler.ExternalScaler_StreamIsActiveServer) error {
+	interval := s.PollingInterval
+	if interval <= 0 {
+		interval = DefaultKEDAExternalScalerPollingInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+			res, err := s.IsActive(stream.Context(), ref)
+			if err != nil {
+				s.Log.Error(err, "Failed to compute activity for KEDA", "namespace", ref.Namespace, "scaledobject", ref.Name)
+				continue
+			}
+
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetMetricSpec returns the desired-replicas metric with the target size of 1.
+func (s *KEDAExternalScaler) GetMetricSpec(ctx context.Context, ref *externalscaler.ScaledObjectRef) (*externalscaler.GetMetricSpecResponse, error) {
+	return &externalscaler.GetMetricSpecResponse{
+		MetricSpecs: []*externalscaler.MetricSpec{
+			{
+				MetricName: KEDAMetricNameDesiredReplicas,
+				TargetSize: 1,
+			},
+		},
+	}, nil
+}
+
+// GetMetrics returns the number of queued and in-progress workflow jobs for the scale target.
+func (s *KEDAExternalScaler) GetMetrics(ctx context.Context, req *externalscaler.GetMetricsRequest) (*externalscaler.GetMetricsResponse, error) {
+	v, err := s.getDesiredReplicas(ctx, req.ScaledObjectRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return &externalscaler.GetMetricsResponse{
+		MetricValues: []*externalscaler.MetricValue{
+			{
+				MetricName:  KEDAMetricNameDesiredReplicas,
+				MetricValue: int64(v),
+			},
+		},
+	}, nil
+}
+
+func (s *KEDAExternalScaler) getDesiredReplicas(ctx context.Context, ref *externalscaler.ScaledObjectRef) (int, error) {
+	if ref == nil {
+		return 0, status.Error(codes.InvalidArgument, "scaledObjectRef is required")
+	}
+
+	if !namespaceWatched(s.Namespaces, ref.Namespace) {
+		return 0, status.Errorf(codes.PermissionDenied, "namespace %s isn't watched by the controller", ref.Namespace)
+	}
+
+	key := kedaCacheKey{
+		namespace:       ref.Namespace,
+		scaledObject:    ref.Name,
+		targetKind:      ref.ScalerMetadata[kedaMetadataKeyScaleTargetKind],
+		targetName:      ref.ScalerMetadata[kedaMetadataKeyScaleTargetName],
+		repositoryNames: ref.ScalerMetadata[kedaMetadataKeyRepositoryNames],
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+
+	if ok && now.Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client:       s.Client,
+		GitHubClient: s.GitHubClient,
+		Log:          s.Log,
+	}
+
+	st, err := s.getScaleTarget(ctx, r, ref)
+	if err != nil {
+		return 0, err
+	}
+
+	var repositoryNames []string
+	for _, n := range strings.Split(ref.ScalerMetadata[kedaMetadataKeyRepositoryNames], ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			repositoryNames = append(repositoryNames, n)
+		}
+	}
+
+	// The workflow runs are counted without any HorizontalRunnerAutoscaler, because KEDA scales the scale target,
+	// and recorded in the metrics of the ScaledObject rather than the ones of HorizontalRunnerAutoscalers.
+	counts, err := r.countWorkflowRuns(*st, &v1alpha1.MetricSpec{
+		Type:            v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+		RepositoryNames: repositoryNames,
+	})
+	if err != nil {
+		return 0, status.Error(codes.Unavailable, err.Error())
+	}
+
+	var v int
+	if counts != nil {
+		v = counts.queued + counts.inProgress
+
+		metrics.SetKEDAExternalScalerWorkflowRuns(ref.Namespace, ref.Name, counts.queued, counts.inProgress)
+	}
+
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = map[kedaCacheKey]kedaCacheEntry{}
+	}
+	s.cache[key] = kedaCacheEntry{value: v, expiresAt: now.Add(kedaExternalScalerCacheDuration)}
+	s.mu.Unlock()
+
+	return v, nil
+}
+
+func (s *KEDAExternalScaler) getScaleTarget(ctx context.Context, r *HorizontalRunnerAutoscalerReconciler, ref *externalscaler.ScaledObjectRef) (*scaleTarget, error) {
+	name := ref.ScalerMetadata[kedaMetadataKeyScaleTargetName]
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "metadata %s is required", kedaMetadataKeyScaleTargetName)
+	}
+
+	nsName := types.NamespacedName{Namespace: ref.Namespace, Name: name}
+
+	var (
+		st   scaleTarget
+		from *v1alpha1.GitHubAPICredentialsFrom
+	)
+
+	switch kind := ref.ScalerMetadata[kedaMetadataKeyScaleTargetKind]; kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := s.Client.Get(ctx, nsName, &rd); err != nil {
+			return nil, scaleTargetLookupError(err, "runnerdeployment", nsName)
+		}
+
+		st = r.scaleTargetFromRD(ctx, rd)
+		from = rd.Spec.Template.Spec.GitHubAPICredentialsFrom
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := s.Client.Get(ctx, nsName, &rs); err != nil {
+			return nil, scaleTargetLookupError(err, "runnerset", nsName)
+		}
+
+		st = r.scaleTargetFromRS(ctx, rs)
+		from = rs.Spec.GitHubAPICredentialsFrom
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported %s %q: valid kinds are RunnerDeployment and RunnerSet", kedaMetadataKeyScaleTargetKind, kind)
+	}
+
+	ghc, err := gitHubClientFor(ctx, s.MultiGitHubClient, s.GitHubClient, ref.Namespace, from)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "getting github client for %s: %v", nsName, err)
+	}
+
+	st.githubClient = ghc
+
+	return &st, nil
+}
+
+func scaleTargetLookupError(err error, kind string, nsName types.NamespacedName) error {
+	if kerrors.IsNotFound(err) {
+		return status.Errorf(codes.NotFound, "%s %s not found", kind, nsName)
+	}
+
+	return status.Errorf(codes.Internal, "getting %s %s: %v", kind, nsName, err)
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/externalscaler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	certutil "k8s.io/client-go/util/cert"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestKEDAExternalScaler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	server := fake.NewServer(
+		fake.WithListRepositoryWorkflowRunsResponse(200,
+			`{"total_count": 4, "workflow_runs":[{"status":"queued"}, {"status":"in_progress"}, {"status":"in_progress"}, {"status":"completed"}]}"`,
+			`{"total_count": 1, "workflow_runs":[{"status":"queued"}]}"`,
+			`{"total_count": 2, "workflow_runs":[{"status":"in_progress"}, {"status":"in_progress"}]}"`,
+		),
+		fake.WithListWorkflowJobsResponse(200, nil),
+		fake.WithListRunnersResponse(200, fake.RunnersListBody),
+	)
+	defer server.Close()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testrd",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository: "test/valid",
+					},
+				},
+			},
+		},
+	}
+
+	s := &KEDAExternalScaler{
+		Client:       clientfake.NewClientBuilder().WithScheme(scheme).WithObjects(rd).Build(),
+		GitHubClient: newGithubClient(server),
+		Log:          zap.New(),
+	}
+
+	ctx := context.Background()
+
+	ref := &externalscaler.ScaledObjectRef{
+		Name:      "testso",
+		Namespace: "default",
+		ScalerMetadata: map[string]string{
+			"scaleTargetName": "testrd",
+		},
+	}
+
+	spec, err := s.GetMetricSpec(ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(spec.MetricSpecs) != 1 || spec.MetricSpecs[0].MetricName != KEDAMetricNameDesiredReplicas || spec.MetricSpecs[0].TargetSize != 1 {
+		t.Errorf("unexpected metric spec: %v", spec.MetricSpecs)
+	}
+
+	metrics, err := s.GetMetrics(ctx, &externalscaler.GetMetricsRequest{ScaledObjectRef: ref, MetricName: KEDAMetricNameDesiredReplicas})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metrics.MetricValues) != 1 || metrics.MetricValues[0].MetricValue != 3 {
+		t.Errorf("unexpected metric values: want 3, got %v", metrics.MetricValues)
+	}
+
+	active, err := s.IsActive(ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !active.Result {
+		t.Errorf("expected the scale target to be active")
+	}
+
+	_, err = s.IsActive(ctx, &externalscaler.ScaledObjectRef{
+		Name:      "missing",
+		Namespace: "default",
+		ScalerMetadata: map[string]string{
+			"scaleTargetName": "missing",
+		},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unexpected error: want NotFound, got %v", err)
+	}
+
+	_, err = s.IsActive(ctx, &externalscaler.ScaledObjectRef{
+		Name:      "invalid",
+		Namespace: "default",
+		ScalerMetadata: map[string]string{
+			"scaleTargetName": "testrd",
+			"scaleTargetKind": "Deployment",
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unexpected error: want InvalidArgument, got %v", err)
+	}
+
+	// The value cached for the previous scale target of the same ScaledObject isn't reused
+	_, err = s.IsActive(ctx, &externalscaler.ScaledObjectRef{
+		Name:      "testso",
+		Namespace: "default",
+		ScalerMetadata: map[string]string{
+			"scaleTargetName": "missing",
+		},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unexpected error: want NotFound, got %v", err)
+	}
+
+	s.Namespaces = []string{"other"}
+
+	_, err = s.IsActive(ctx, ref)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("unexpected error: want PermissionDenied, got %v", err)
+	}
+}
+
+func TestKEDAExternalScaler_TLSConfig(t *testing.T) {
+	s := &KEDAExternalScaler{Log: zap.New()}
+
+	config, err := s.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Certificates) != 1 || config.ClientAuth != tls.NoClientCert {
+		t.Errorf("expected a self-signed certificate without client authentication: %+v", config)
+	}
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("keda", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	for name, data := range map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": certPEM} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.CertDir = dir
+
+	config, err = s.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Certificates) != 1 || config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Errorf("expected client authentication by ca.crt: %+v", config)
+	}
+}
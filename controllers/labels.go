@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// This file is the single source of truth for the labels put on the resources created by the controllers,
+// and for the selectors used to find them.
+//
+// The contract is:
+//
+// - A RunnerDeployment creates RunnerReplicaSets whose metadata.labels and spec.template.metadata.labels are
+//   runnerReplicaSetLabels, and whose spec.selector is runnerReplicaSetSelector.
+// - A RunnerReplicaSet creates Runners with its spec.template.metadata.labels.
+// - A Runner creates a runner pod with its metadata.labels plus LabelKeyPodTemplateHash.
+// - A RunnerSet creates a StatefulSet whose pod template labels are runnerSetPodLabels,
+//   and whose spec.selector is runnerSetStatefulSetSelector.
+//
+// so that the selectors of the parents, and the selectors returned by getSelector and getRunnerSetSelector that are
+// used by HorizontalRunnerAutoscaler and the other components, always match the labels of the children.
+
+// getSelector returns the selector that matches all the runners and runner pods managed by the RunnerDeployment.
+func getSelector(rd *v1alpha1.RunnerDeployment) *metav1.LabelSelector {
+	selector := rd.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: map[string]string{LabelKeyRunnerDeploymentName: rd.Name}}
+	}
+
+	return selector
+}
+
+// runnerReplicaSetLabels returns the labels of the RunnerReplicaSet for the RunnerDeployment and the template hash,
+// which are also put on its runners and runner pods.
+func runnerReplicaSetLabels(templateLabels map[string]string, rd *v1alpha1.RunnerDeployment, templateHash string) map[string]string {
+	labels := CloneAndAddLabel(templateLabels, LabelKeyRunnerTemplateHash, templateHash)
+
+	// This label selector is used by default when rd.Spec.Selector is empty.
+	labels = CloneAndAddLabel(labels, LabelKeyRunnerDeploymentName, rd.Name)
+
+	return labels
+}
+
+// runnerReplicaSetSelector returns the selector that matches the runners of the RunnerReplicaSet
+// for the RunnerDeployment and the template hash.
+func runnerReplicaSetSelector(rd *v1alpha1.RunnerDeployment, templateHash string) *metav1.LabelSelector {
+	return CloneSelectorAndAddLabel(getSelector(rd), LabelKeyRunnerTemplateHash, templateHash)
+}
+
+// getRunnerSetSelector returns the selector that matches all the runner pods managed by the RunnerSet.
+func getRunnerSetSelector(runnerSet *v1alpha1.RunnerSet) *metav1.LabelSelector {
+	selector := runnerSet.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: map[string]string{LabelKeyRunnerSetName: runnerSet.Name}}
+	}
+
+	return selector
+}
+
+// runnerSetPodLabels returns the labels of the runner pods for the RunnerSet, excluding the template hash
+// that is added after the pod template is finalized.
+func runnerSetPodLabels(templateLabels map[string]string, runnerSet *v1alpha1.RunnerSet) map[string]string {
+	// This label selector is used by default when runnerSet.Spec.Selector is empty.
+	labels := CloneAndAddLabel(templateLabels, LabelKeyRunnerSetName, runnerSet.Name)
+
+	return CloneAndAddLabel(labels, LabelKeyPodMutation, LabelValuePodMutation)
+}
+
+// runnerSetStatefulSetSelector returns the selector that matches the runner pods of the StatefulSet
+// for the RunnerSet and the template hash.
+func runnerSetStatefulSetSelector(runnerSet *v1alpha1.RunnerSet, templateHash string) *metav1.LabelSelector {
+	selector := getRunnerSetSelector(runnerSet)
+	selector = CloneSelectorAndAddLabel(selector, LabelKeyRunnerTemplateHash, templateHash)
+	selector = CloneSelectorAndAddLabel(selector, LabelKeyRunnerSetName, runnerSet.Name)
+	selector = CloneSelectorAndAddLabel(selector, LabelKeyPodMutation, LabelValuePodMutation)
+
+	return selector
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// The tests in this file verify that every controller conforms to the label/selector contract described in labels.go.
+// If any of them fails, some runners or runner pods are likely to be orphaned.
+
+func mustMatch(t *testing.T, what string, selector *metav1.LabelSelector, l map[string]string) {
+	t.Helper()
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		t.Fatalf("%s: invalid selector: %v", what, err)
+	}
+
+	if !s.Matches(labels.Set(l)) {
+		t.Errorf("%s: selector %s does not match labels %v", what, s, l)
+	}
+}
+
+func TestLabelContract_RunnerDeployment(t *testing.T) {
+	testcases := map[string]*metav1.LabelSelector{
+		"default selector": nil,
+		"custom selector": {
+			MatchLabels: map[string]string{"foo": "bar"},
+		},
+		"custom selector with expressions": {
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "foo", Operator: metav1.LabelSelectorOpIn, Values: []string{"bar", "baz"}},
+			},
+		},
+	}
+
+	for name, selector := range testcases {
+		selector := selector
+
+		t.Run(name, func(t *testing.T) {
+			rd := &v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Selector: selector,
+					Template: v1alpha1.RunnerTemplate{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"foo": "bar"},
+						},
+						Spec: v1alpha1.RunnerSpec{
+							RunnerConfig: v1alpha1.RunnerConfig{
+								Repository: "test/valid",
+							},
+						},
+					},
+				},
+			}
+
+			if err := rd.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+
+			rs, err := newRunnerReplicaSet(rd, []string{"common"}, sc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rs.Name = "example-abcde"
+
+			if err := rs.Validate(); err != nil {
+				t.Fatalf("unexpected validation error for the runner replica set: %v", err)
+			}
+
+			mustMatch(t, "runnerreplicaset selector against its template", rs.Spec.Selector, rs.Spec.Template.ObjectMeta.Labels)
+			mustMatch(t, "runnerdeployment selector against the runnerreplicaset", getSelector(rd), rs.ObjectMeta.Labels)
+
+			rsReconciler := &RunnerReplicaSetReconciler{Scheme: sc}
+
+			runner, err := rsReconciler.newRunner(*rs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			runner.Name = "example-abcde-fghij"
+
+			mustMatch(t, "runnerreplicaset selector against its runner", rs.Spec.Selector, runner.ObjectMeta.Labels)
+			mustMatch(t, "runnerdeployment selector against the runner", getSelector(rd), runner.ObjectMeta.Labels)
+
+			runnerReconciler := &RunnerReconciler{Scheme: sc, GitHubClient: &github.Client{}}
+
+			pod, err := runnerReconciler.newPod(runner, runnerReconciler.GitHubClient.GithubBaseURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mustMatch(t, "runnerreplicaset selector against the runner pod", rs.Spec.Selector, pod.ObjectMeta.Labels)
+			mustMatch(t, "runnerdeployment selector against the runner pod", getSelector(rd), pod.ObjectMeta.Labels)
+
+			if got := pod.ObjectMeta.Labels[LabelKeyRunnerDeploymentName]; got != rd.Name {
+				t.Errorf("unexpected %s label on the runner pod: want %q, got %q", LabelKeyRunnerDeploymentName, rd.Name, got)
+			}
+		})
+	}
+}
+
+func TestLabelContract_RunnerDeploymentSelectorMismatch(t *testing.T) {
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "example",
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: v1alpha1.RunnerTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"foo": "baz"},
+				},
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository: "test/valid",
+					},
+				},
+			},
+		},
+	}
+
+	if err := rd.Validate(); err == nil {
+		t.Errorf("expected the runnerdeployment whose selector doesn't match its template labels to be rejected")
+	}
+
+	rd.Spec.Selector = &metav1.LabelSelector{}
+
+	if err := rd.Validate(); err == nil {
+		t.Errorf("expected the runnerdeployment with the empty selector to be rejected")
+	}
+}
+
+func TestLabelContract_RunnerSet(t *testing.T) {
+	testcases := map[string]*metav1.LabelSelector{
+		"default selector": nil,
+		"custom selector": {
+			MatchLabels: map[string]string{"foo": "bar"},
+		},
+	}
+
+	for name, selector := range testcases {
+		selector := selector
+
+		t.Run(name, func(t *testing.T) {
+			runnerSet := &v1alpha1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+			}
+			runnerSet.Spec.Repository = "test/valid"
+			runnerSet.Spec.Selector = selector
+			runnerSet.Spec.Template.ObjectMeta.Labels = map[string]string{"foo": "bar"}
+
+			r := &RunnerSetReconciler{Scheme: sc}
+
+			sts, err := r.newStatefulSet(runnerSet, r.GitHubBaseURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mustMatch(t, "statefulset selector against its template", sts.Spec.Selector, sts.Spec.Template.ObjectMeta.Labels)
+			mustMatch(t, "runnerset selector against the runner pod", getRunnerSetSelector(runnerSet), sts.Spec.Template.ObjectMeta.Labels)
+
+			if got := sts.Spec.Template.ObjectMeta.Labels[LabelKeyRunnerSetName]; got != runnerSet.Name {
+				t.Errorf("unexpected %s label on the runner pod: want %q, got %q", LabelKeyRunnerSetName, runnerSet.Name, got)
+			}
+		})
+	}
+}
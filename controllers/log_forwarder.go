@@ -0,0 +1,211 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	logForwarderContainerName = "log-forwarder"
+	defaultLogForwarderImage  = "fluent/fluent-bit:1.8"
+
+	// logForwarderHealthPort is the port of the fluent-bit built-in HTTP server that is used for the liveness probe.
+	logForwarderHealthPort = 2020
+
+	logForwarderCAVolumeName         = "log-forwarder-ca"
+	logForwarderClientCertVolumeName = "log-forwarder-client-cert"
+	logForwarderCAMountPath          = "/etc/log-forwarder/ca"
+	logForwarderClientCertMountPath  = "/etc/log-forwarder/client"
+)
+
+// newLogForwarderContainer returns the sidecar container that tails the runner's diagnostic and job logs
+// in the runner volume and forwards them to the log collector, along with the volumes it requires.
+//
+// The container is configured via the fluent-bit command-line options only, so that
+// the controller doesn't need to manage any ConfigMap per runner.
+func newLogForwarderContainer(spec v1alpha1.LogForwarderSpec, runnerVolumeName, runnerVolumeMountPath string) (*corev1.Container, []corev1.Volume, error) {
+	host, port, err := net.SplitHostPort(spec.Endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid log forwarder endpoint %q: %w", spec.Endpoint, err)
+	}
+
+	image := spec.Image
+	if image == "" {
+		image = defaultLogForwarderImage
+	}
+
+	args := []string{
+		"-i", "tail",
+		"-p", fmt.Sprintf("path=%s/_diag/*.log,%s/_diag/pages/*.log", runnerVolumeMountPath, runnerVolumeMountPath),
+		"-p", "path_key=file",
+		"-t", "runner",
+		"-F", "record_modifier",
+		"-m", "*",
+		"-p", "Record=runner $(RUNNER_NAME)",
+	}
+
+	// Sorted for a stable pod template hash
+	var keys []string
+	for k := range spec.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		args = append(args, "-p", fmt.Sprintf("Record=%s %s", k, spec.Labels[k]))
+	}
+
+	args = append(args,
+		"-o", "forward",
+		"-m", "*",
+		"-p", fmt.Sprintf("host=%s", host),
+		"-p", fmt.Sprintf("port=%s", port),
+	)
+
+	var (
+		volumes      []corev1.Volume
+		volumeMounts = []corev1.VolumeMount{
+			{
+				Name:      runnerVolumeName,
+				MountPath: runnerVolumeMountPath,
+				ReadOnly:  true,
+			},
+		}
+	)
+
+	if tls := spec.TLS; tls != nil {
+		args = append(args, "-p", "tls=on")
+
+		if tls.InsecureSkipVerify {
+			args = append(args, "-p", "tls.verify=off")
+		} else {
+			args = append(args, "-p", "tls.verify=on")
+		}
+
+		if tls.CASecretName != "" {
+			args = append(args, "-p", fmt.Sprintf("tls.ca_file=%s/ca.crt", logForwarderCAMountPath))
+
+			volumes = append(volumes, corev1.Volume{
+				Name: logForwarderCAVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: tls.CASecretName},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      logForwarderCAVolumeName,
+				MountPath: logForwarderCAMountPath,
+				ReadOnly:  true,
+			})
+		}
+
+		if tls.ClientSecretName != "" {
+			args = append(args,
+				"-p", fmt.Sprintf("tls.crt_file=%s/%s", logForwarderClientCertMountPath, corev1.TLSCertKey),
+				"-p", fmt.Sprintf("tls.key_file=%s/%s", logForwarderClientCertMountPath, corev1.TLSPrivateKeyKey),
+			)
+
+			volumes = append(volumes, corev1.Volume{
+				Name: logForwarderClientCertVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: tls.ClientSecretName},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      logForwarderClientCertVolumeName,
+				MountPath: logForwarderClientCertMountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	args = append(args,
+		"-H",
+		"-P", fmt.Sprintf("%d", logForwarderHealthPort),
+	)
+
+	c := &corev1.Container{
+		Name:  logForwarderContainerName,
+		Image: image,
+		Command: []string{
+			"/fluent-bit/bin/fluent-bit",
+		},
+		Args: args,
+		Env: []corev1.EnvVar{
+			{
+				Name: "RUNNER_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "metadata.name",
+					},
+				},
+			},
+		},
+		VolumeMounts: volumeMounts,
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/",
+					Port: intstr.FromInt(logForwarderHealthPort),
+				},
+			},
+			InitialDelaySeconds: 10,
+			PeriodSeconds:       30,
+		},
+	}
+
+	return c, volumes, nil
+}
+
+// checkLogForwarderHealth emits a warning event on the runner when the log forwarder sidecar of the runner pod
+// is crash-looping or not ready, so that the user notices job logs aren't being shipped.
+// It doesn't restart the runner pod, because a broken log forwarder shouldn't affect the jobs.
+func (r *RunnerReconciler) checkLogForwarderHealth(runner v1alpha1.Runner, pod corev1.Pod, log logr.Logger) {
+	if runner.Spec.LogForwarder == nil || pod.Status.Phase != corev1.PodRunning {
+		return
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != logForwarderContainerName {
+			continue
+		}
+
+		if status.Ready {
+			return
+		}
+
+		var reason string
+		if w := status.State.Waiting; w != nil {
+			reason = w.Reason
+		}
+
+		msg := fmt.Sprintf("Log forwarder of pod '%s' is not ready (restarts: %d, reason: %q)", pod.Name, status.RestartCount, reason)
+
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, "LogForwarderUnhealthy", msg)
+		log.Info(msg, "endpoint", runner.Spec.LogForwarder.Endpoint)
+
+		return
+	}
+}
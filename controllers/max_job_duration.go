@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+const (
+	// EnvVarMaxJobDurationSeconds enables the job watchdog of the runner container.
+	// See runner/hooks for the watchdog.
+	EnvVarMaxJobDurationSeconds = "RUNNER_MAX_JOB_DURATION_SECONDS"
+
+	// reasonMaxJobDurationExceeded is the reason written to the termination message of the runner container by the watchdog,
+	// and the reason of the event emitted for the cancelled job.
+	reasonMaxJobDurationExceeded = "MaxJobDurationExceeded"
+)
+
+// jobTimeout is the termination message the watchdog writes on cancelling the job.
+type jobTimeout struct {
+	Reason                string `json:"reason"`
+	Repository            string `json:"repository"`
+	Workflow              string `json:"workflow"`
+	Job                   string `json:"job"`
+	RunID                 string `json:"runID"`
+	MaxJobDurationSeconds int64  `json:"maxJobDurationSeconds"`
+}
+
+// newMaxJobDurationEnvVars returns the environment variables that configure the watchdog of the job.
+// The job hooks that start and stop the watchdog are configured by newJobHooksConfig.
+func newMaxJobDurationEnvVars(maxJobDuration *metav1.Duration) []corev1.EnvVar {
+	if maxJobDuration == nil || maxJobDuration.Duration <= 0 {
+		return nil
+	}
+
+	seconds := int64(maxJobDuration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  EnvVarMaxJobDurationSeconds,
+			Value: strconv.FormatInt(seconds, 10),
+		},
+	}
+}
+
+// getJobTimeout returns the job timeout reported by the watchdog in the termination message of the runner container,
+// or nil if the runner container hasn't been terminated by the watchdog.
+// The last termination state is checked too, because the container may have already been restarted by kubelet.
+func getJobTimeout(pod corev1.Pod) *jobTimeout {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+
+			var t jobTimeout
+
+			if err := json.Unmarshal([]byte(terminated.Message), &t); err != nil || t.Reason != reasonMaxJobDurationExceeded {
+				continue
+			}
+
+			return &t
+		}
+	}
+
+	return nil
+}
+
+// recordJobTimeout emits the event and the metric that attribute the job timeout to the repository and the workflow.
+func recordJobTimeout(recorder record.EventRecorder, obj runtime.Object, namespace string, t jobTimeout) {
+	recorder.Event(obj, corev1.EventTypeWarning, reasonMaxJobDurationExceeded, fmt.Sprintf(
+		"Cancelled job %q of workflow %q in %s (run %s) after exceeding maxJobDuration of %ds",
+		t.Job, t.Workflow, t.Repository, t.RunID, t.MaxJobDurationSeconds,
+	))
+
+	metrics.IncRunnerJobTimeouts(namespace, t.Repository, t.Workflow)
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetJobTimeout(t *testing.T) {
+	message := `{"reason":"MaxJobDurationExceeded","repository":"example/myrepo","workflow":"CI","job":"build","runID":"42","maxJobDurationSeconds":3600}`
+
+	podWith := func(state, last corev1.ContainerState) corev1.Pod {
+		return corev1.Pod{
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "docker"},
+					{Name: containerName, State: state, LastTerminationState: last},
+				},
+			},
+		}
+	}
+
+	terminated := func(exitCode int32, message string) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Message: message}}
+	}
+
+	want := &jobTimeout{
+		Reason:                "MaxJobDurationExceeded",
+		Repository:            "example/myrepo",
+		Workflow:              "CI",
+		Job:                   "build",
+		RunID:                 "42",
+		MaxJobDurationSeconds: 3600,
+	}
+
+	testcases := []struct {
+		name string
+		pod  corev1.Pod
+		want *jobTimeout
+	}{
+		{
+			name: "running",
+			pod:  podWith(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, corev1.ContainerState{}),
+		},
+		{
+			name: "terminated without message",
+			pod:  podWith(terminated(0, ""), corev1.ContainerState{}),
+		},
+		{
+			name: "terminated with unrelated message",
+			pod:  podWith(terminated(1, "panic: something went wrong"), corev1.ContainerState{}),
+		},
+		{
+			name: "terminated by watchdog",
+			pod:  podWith(terminated(1, message), corev1.ContainerState{}),
+			want: want,
+		},
+		{
+			name: "restarted after terminated by watchdog",
+			pod:  podWith(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, terminated(1, message)),
+			want: want,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getJobTimeout(tc.pod)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected job timeout: %s", d)
+			}
+		})
+	}
+}
+
+func TestRecordJobTimeout(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+
+	recordJobTimeout(recorder, &corev1.Pod{}, "default", jobTimeout{
+		Reason:                "MaxJobDurationExceeded",
+		Repository:            "example/myrepo",
+		Workflow:              "CI",
+		Job:                   "build",
+		RunID:                 "42",
+		MaxJobDurationSeconds: 3600,
+	})
+
+	event := <-recorder.Events
+
+	for _, s := range []string{"Warning", "MaxJobDurationExceeded", `"build"`, `"CI"`, "example/myrepo", "3600s"} {
+		if !strings.Contains(event, s) {
+			t.Errorf("expected event %q to contain %q", event, s)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -9,6 +13,19 @@ import (
 const (
 	hraName      = "horizontalrunnerautoscaler"
 	hraNamespace = "namespace"
+	outcome      = "outcome"
+	direction    = "direction"
+	runnerLabels = "runner_labels"
+	calculation  = "calculation"
+	source       = "source"
+)
+
+// The outcomes of optimistically reserved replicas.
+// The ratio of consumed to reserved is the accuracy of the prediction.
+const (
+	OptimisticReplicasReserved  = "reserved"
+	OptimisticReplicasConsumed  = "consumed"
+	OptimisticReplicasCancelled = "cancelled"
 )
 
 var (
@@ -16,6 +33,21 @@ var (
 		horizontalRunnerAutoscalerMinReplicas,
 		horizontalRunnerAutoscalerMaxReplicas,
 		horizontalRunnerAutoscalerDesiredReplicas,
+		horizontalRunnerAutoscalerEffectiveMinReplicas,
+		horizontalRunnerAutoscalerSuggestedReplicas,
+		horizontalRunnerAutoscalerQueuedWorkflowRuns,
+		horizontalRunnerAutoscalerInProgressWorkflowRuns,
+		horizontalRunnerAutoscalerOptimisticReplicas,
+		horizontalRunnerAutoscalerOverflowReplicas,
+		horizontalRunnerAutoscalerHostedRunnerFallbackRecommended,
+		horizontalRunnerAutoscalerEstimatedWaitSeconds,
+		horizontalRunnerAutoscalerBusyLedgerDrift,
+		horizontalRunnerAutoscalerDryRunReplicas,
+		horizontalRunnerAutoscalerDualRunReplicas,
+		horizontalRunnerAutoscalerDualRunAgreementPercentage,
+		horizontalRunnerAutoscalerDualRunMaxDivergence,
+		horizontalRunnerAutoscalerDualRunMissedScaleUps,
+		horizontalRunnerAutoscalerMissedWorkflowJobs,
 	}
 )
 
@@ -41,6 +73,141 @@ var (
 		},
 		[]string{hraName, hraNamespace},
 	)
+	horizontalRunnerAutoscalerEffectiveMinReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_effective_min_replicas",
+			Help: "minReplicas of HorizontalRunnerAutoscaler in effect on the last reconciliation, including the scheduled overrides",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerSuggestedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_suggested_replicas",
+			Help: "The desired replicas of HorizontalRunnerAutoscaler before maxReplicas and the other limits, broken down by the source: metrics, reservations, or min",
+		},
+		[]string{hraName, hraNamespace, source},
+	)
+	horizontalRunnerAutoscalerQueuedWorkflowRuns = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_queued_workflow_runs",
+			Help: "The number of queued workflow runs and jobs last observed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerInProgressWorkflowRuns = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_in_progress_workflow_runs",
+			Help: "The number of in-progress workflow runs and jobs last observed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerOverflowReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_overflow_replicas",
+			Help: "The number of desired replicas that exceeded maxReplicas of HorizontalRunnerAutoscaler with hostedRunnerFallback",
+		},
+		[]string{hraName, hraNamespace, runnerLabels},
+	)
+	horizontalRunnerAutoscalerHostedRunnerFallbackRecommended = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_hosted_runner_fallback_recommended",
+			Help: "1 when the overflow of HorizontalRunnerAutoscaler has been sustained long enough to recommend GitHub-hosted runners, 0 otherwise",
+		},
+		[]string{hraName, hraNamespace, runnerLabels},
+	)
+	horizontalRunnerAutoscalerEstimatedWaitSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_estimated_wait_seconds",
+			Help: "The estimated time a newly queued job waits for a runner of HorizontalRunnerAutoscaler with hostedRunnerFallback",
+		},
+		[]string{hraName, hraNamespace, runnerLabels},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerBusyLedgerDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_busy_ledger_drift_percentage",
+			Help: "The percentage of the runners whose busy state recorded from webhook events differed from the GitHub API on the last snapshot",
+		},
+		[]string{hraName, hraNamespace},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerMissedWorkflowJobs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizontalrunnerautoscaler_missed_workflow_jobs_total",
+			Help: "The number of queued workflow jobs found by polling the GitHub API whose webhook events were missed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerDryRunReplicas = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizontalrunnerautoscaler_dry_run_replicas_total",
+			Help: "The number of replicas the webhook server in the dry-run mode would have reserved (up) or released (down) for HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace, direction},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerDualRunReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_dual_run_replicas",
+			Help: "The desired replicas calculated from metrics or webhook on the last reconciliation of HorizontalRunnerAutoscaler with dualRun",
+		},
+		[]string{hraName, hraNamespace, calculation},
+	)
+	horizontalRunnerAutoscalerDualRunAgreementPercentage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_dual_run_agreement_percentage",
+			Help: "The percentage of the samples in the current observation window where the two calculations of HorizontalRunnerAutoscaler with dualRun agreed",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerDualRunMaxDivergence = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_dual_run_max_divergence_replicas",
+			Help: "The largest difference between the two calculations of HorizontalRunnerAutoscaler with dualRun in the current observation window",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerDualRunMissedScaleUps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_dual_run_missed_scale_ups",
+			Help: "The number of scale-ups calculated from metrics but missed by the calculation from webhook of HorizontalRunnerAutoscaler with dualRun in the current observation window",
+		},
+		[]string{hraName, hraNamespace},
+	)
+)
+
+var (
+	horizontalRunnerAutoscalerOptimisticReplicas = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizontalrunnerautoscaler_optimistic_replicas_total",
+			Help: "The number of replicas optimistically reserved by HorizontalRunnerAutoscaler, and the ones consumed by workflow jobs or cancelled as surplus",
+		},
+		[]string{hraName, hraNamespace, outcome},
+	)
+)
+
+// WorkflowRuns is the number of workflow runs and jobs observed by a HorizontalRunnerAutoscaler
+// with the TotalNumberOfQueuedAndInProgressWorkflowRuns metric.
+type WorkflowRuns struct {
+	Queued     int
+	InProgress int
+}
+
+var (
+	workflowRunsMu sync.RWMutex
+	workflowRuns   = map[string]WorkflowRuns{}
 )
 
 func SetHorizontalRunnerAutoscalerSpec(o metav1.ObjectMeta, spec v1alpha1.HorizontalRunnerAutoscalerSpec) {
@@ -65,3 +232,152 @@ func SetHorizontalRunnerAutoscalerStatus(o metav1.ObjectMeta, status v1alpha1.Ho
 		horizontalRunnerAutoscalerDesiredReplicas.With(labels).Set(float64(*status.DesiredReplicas))
 	}
 }
+
+// ReplicasBySource is the breakdown of the desired replicas of a HorizontalRunnerAutoscaler before maxReplicas and the other limits.
+type ReplicasBySource struct {
+	// Metrics is the replicas suggested by the pull-based metrics
+	Metrics int
+	// Reservations is the replicas added by the capacity reservations of the webhook-based autoscaler
+	Reservations int
+	// Min is the replicas added to keep the desired replicas at MinReplicas
+	Min int
+	// EffectiveMinReplicas is MinReplicas in effect, including the scheduled overrides
+	EffectiveMinReplicas int
+}
+
+// SetHorizontalRunnerAutoscalerReplicasBySource records the breakdown of the desired replicas of the HorizontalRunnerAutoscaler,
+// so that the demand driven by the webhooks can be told from the one driven by the metrics and the baseline.
+func SetHorizontalRunnerAutoscalerReplicasBySource(o metav1.ObjectMeta, replicas ReplicasBySource) {
+	for s, v := range map[string]int{
+		"metrics":      replicas.Metrics,
+		"reservations": replicas.Reservations,
+		"min":          replicas.Min,
+	} {
+		horizontalRunnerAutoscalerSuggestedReplicas.With(prometheus.Labels{
+			hraName:      o.Name,
+			hraNamespace: o.Namespace,
+			source:       s,
+		}).Set(float64(v))
+	}
+
+	horizontalRunnerAutoscalerEffectiveMinReplicas.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}).Set(float64(replicas.EffectiveMinReplicas))
+}
+
+// SetHorizontalRunnerAutoscalerWorkflowRuns records the number of workflow runs observed by the HorizontalRunnerAutoscaler,
+// both as the Prometheus metrics and for GetHorizontalRunnerAutoscalerWorkflowRuns.
+func SetHorizontalRunnerAutoscalerWorkflowRuns(o metav1.ObjectMeta, runs WorkflowRuns) {
+	labels := prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}
+	horizontalRunnerAutoscalerQueuedWorkflowRuns.With(labels).Set(float64(runs.Queued))
+	horizontalRunnerAutoscalerInProgressWorkflowRuns.With(labels).Set(float64(runs.InProgress))
+
+	workflowRunsMu.Lock()
+	defer workflowRunsMu.Unlock()
+
+	workflowRuns[o.Namespace+"/"+o.Name] = runs
+}
+
+// GetHorizontalRunnerAutoscalerWorkflowRuns returns the number of workflow runs last observed by the HorizontalRunnerAutoscaler
+// in this process. It returns false when the HorizontalRunnerAutoscaler hasn't observed any yet.
+func GetHorizontalRunnerAutoscalerWorkflowRuns(namespace, name string) (WorkflowRuns, bool) {
+	workflowRunsMu.RLock()
+	defer workflowRunsMu.RUnlock()
+
+	runs, ok := workflowRuns[namespace+"/"+name]
+
+	return runs, ok
+}
+
+// AddHorizontalRunnerAutoscalerOptimisticReplicas counts the optimistically reserved replicas by the outcome.
+func AddHorizontalRunnerAutoscalerOptimisticReplicas(o metav1.ObjectMeta, outcomeValue string, replicas int) {
+	horizontalRunnerAutoscalerOptimisticReplicas.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+		outcome:      outcomeValue,
+	}).Add(float64(replicas))
+}
+
+// SetHorizontalRunnerAutoscalerHostedRunnerFallback records the overflow of the HorizontalRunnerAutoscaler,
+// labeled with the runner labels of the scale target so that it tells which jobs to move to GitHub-hosted runners.
+func SetHorizontalRunnerAutoscalerHostedRunnerFallback(o metav1.ObjectMeta, labels []string, status v1alpha1.HostedRunnerFallbackStatus) {
+	sorted := append([]string{}, labels...)
+	sort.Strings(sorted)
+
+	l := prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+		runnerLabels: strings.Join(sorted, ","),
+	}
+
+	var recommended float64
+	if status.Recommended {
+		recommended = 1
+	}
+
+	horizontalRunnerAutoscalerOverflowReplicas.With(l).Set(float64(status.OverflowReplicas))
+	horizontalRunnerAutoscalerHostedRunnerFallbackRecommended.With(l).Set(recommended)
+	horizontalRunnerAutoscalerEstimatedWaitSeconds.With(l).Set(float64(status.EstimatedWaitSeconds))
+}
+
+// AddHorizontalRunnerAutoscalerDryRunReplicas counts the replicas that the webhook server in the dry-run mode
+// would have reserved, for a positive amount, or released, for a negative amount.
+func AddHorizontalRunnerAutoscalerDryRunReplicas(o metav1.ObjectMeta, amount int) {
+	d := "up"
+	if amount < 0 {
+		d = "down"
+		amount = -amount
+	}
+
+	horizontalRunnerAutoscalerDryRunReplicas.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+		direction:    d,
+	}).Add(float64(amount))
+}
+
+// AddHorizontalRunnerAutoscalerMissedWorkflowJobs counts the queued workflow jobs that had no capacity reservation
+// when found by polling the GitHub API.
+func AddHorizontalRunnerAutoscalerMissedWorkflowJobs(o metav1.ObjectMeta, jobs int) {
+	horizontalRunnerAutoscalerMissedWorkflowJobs.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}).Add(float64(jobs))
+}
+
+// SetHorizontalRunnerAutoscalerBusyLedgerDrift records the drift of the busy runners recorded from webhook events
+// from the busy runners listed by the GitHub API.
+func SetHorizontalRunnerAutoscalerBusyLedgerDrift(o metav1.ObjectMeta, percentage float64) {
+	horizontalRunnerAutoscalerBusyLedgerDrift.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}).Set(percentage)
+}
+
+// SetHorizontalRunnerAutoscalerDualRun records the latest sample and the report of the current observation window
+// of the HorizontalRunnerAutoscaler in the dual-run mode.
+func SetHorizontalRunnerAutoscalerDualRun(o metav1.ObjectMeta, status v1alpha1.DualRunStatus) {
+	labels := prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}
+
+	horizontalRunnerAutoscalerDualRunReplicas.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+		calculation:  "metrics",
+	}).Set(float64(status.MetricsReplicas))
+	horizontalRunnerAutoscalerDualRunReplicas.With(prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+		calculation:  "webhook",
+	}).Set(float64(status.WebhookReplicas))
+
+	horizontalRunnerAutoscalerDualRunAgreementPercentage.With(labels).Set(float64(status.Current.AgreementPercentage))
+	horizontalRunnerAutoscalerDualRunMaxDivergence.With(labels).Set(float64(status.Current.MaxDivergence))
+	horizontalRunnerAutoscalerDualRunMissedScaleUps.With(labels).Set(float64(status.Current.MissedScaleUps))
+}
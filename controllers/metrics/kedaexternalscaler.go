@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	scaledObjectName      = "scaledobject"
+	scaledObjectNamespace = "namespace"
+)
+
+var (
+	kedaExternalScalerMetrics = []prometheus.Collector{
+		kedaExternalScalerQueuedWorkflowRuns,
+		kedaExternalScalerInProgressWorkflowRuns,
+	}
+)
+
+var (
+	kedaExternalScalerQueuedWorkflowRuns = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "keda_external_scaler_queued_workflow_runs",
+			Help: "The number of queued workflow runs and jobs last observed for the KEDA ScaledObject",
+		},
+		[]string{scaledObjectName, scaledObjectNamespace},
+	)
+	kedaExternalScalerInProgressWorkflowRuns = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "keda_external_scaler_in_progress_workflow_runs",
+			Help: "The number of in-progress workflow runs and jobs last observed for the KEDA ScaledObject",
+		},
+		[]string{scaledObjectName, scaledObjectNamespace},
+	)
+)
+
+// SetKEDAExternalScalerWorkflowRuns records the number of workflow runs observed by the KEDA external scaler for the ScaledObject.
+func SetKEDAExternalScalerWorkflowRuns(namespace, name string, queued, inProgress int) {
+	labels := prometheus.Labels{
+		scaledObjectName:      name,
+		scaledObjectNamespace: namespace,
+	}
+
+	kedaExternalScalerQueuedWorkflowRuns.With(labels).Set(float64(queued))
+	kedaExternalScalerInProgressWorkflowRuns.With(labels).Set(float64(inProgress))
+}
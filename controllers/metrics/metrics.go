@@ -11,4 +11,6 @@ import (
 func init() {
 	metrics.Registry.MustRegister(runnerDeploymentMetrics...)
 	metrics.Registry.MustRegister(horizontalRunnerAutoscalerMetrics...)
+	metrics.Registry.MustRegister(runnerMetrics...)
+	metrics.Registry.MustRegister(kedaExternalScalerMetrics...)
 }
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	runnerNamespace  = "namespace"
+	runnerRepository = "repository"
+	runnerWorkflow   = "workflow"
+	runnerPhase      = "phase"
+)
+
+var (
+	runnerMetrics = []prometheus.Collector{
+		runnerJobTimeouts,
+		runnerTerminalCollected,
+	}
+)
+
+var (
+	runnerJobTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_job_timeouts_total",
+			Help: "Number of workflow jobs cancelled by the runner-side watchdog for running longer than maxJobDuration",
+		},
+		[]string{runnerNamespace, runnerRepository, runnerWorkflow},
+	)
+	runnerTerminalCollected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_terminal_collected_total",
+			Help: "Number of runners deleted after their pods stayed succeeded or failed for the retention period",
+		},
+		[]string{runnerNamespace, runnerPhase},
+	)
+)
+
+// IncRunnerJobTimeouts counts a workflow job of the repository cancelled for exceeding maxJobDuration.
+func IncRunnerJobTimeouts(namespace, repository, workflow string) {
+	runnerJobTimeouts.With(prometheus.Labels{
+		runnerNamespace:  namespace,
+		runnerRepository: repository,
+		runnerWorkflow:   workflow,
+	}).Inc()
+}
+
+// IncRunnerTerminalCollected counts a runner deleted by the terminal runner collector, by the phase of its pod.
+func IncRunnerTerminalCollected(namespace, phase string) {
+	runnerTerminalCollected.With(prometheus.Labels{
+		runnerNamespace: namespace,
+		runnerPhase:     phase,
+	}).Inc()
+}
@@ -8,11 +8,20 @@ import (
 const (
 	rdName      = "runnerdeployment"
 	rdNamespace = "namespace"
+
+	interruptionReason = "reason"
+
+	runnerVersion = "version"
 )
 
 var (
 	runnerDeploymentMetrics = []prometheus.Collector{
 		runnerDeploymentReplicas,
+		runnerDeploymentInterruptions,
+		runnerDeploymentRunnerSeconds,
+		runnerDeploymentBusySeconds,
+		runnerDeploymentJobs,
+		runnerDeploymentOutdatedRunners,
 	}
 )
 
@@ -24,6 +33,41 @@ var (
 		},
 		[]string{rdName, rdNamespace},
 	)
+	runnerDeploymentInterruptions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runnerdeployment_interruptions_total",
+			Help: "Number of runner pods of RunnerDeployment interrupted by evictions or node terminations, like the ones of spot instances",
+		},
+		[]string{rdName, rdNamespace, interruptionReason},
+	)
+	runnerDeploymentRunnerSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runnerdeployment_runner_seconds_total",
+			Help: "Total seconds the runner pods of RunnerDeployment were running",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentBusySeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runnerdeployment_busy_seconds_total",
+			Help: "Total seconds the runners of RunnerDeployment were busy running jobs on GitHub",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentJobs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runnerdeployment_jobs_total",
+			Help: "Number of jobs the runners of RunnerDeployment were observed to start",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentOutdatedRunners = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runnerdeployment_outdated_runners_total",
+			Help: "Number of runner pods of RunnerDeployment recreated because GitHub no longer accepts jobs from their runner version",
+		},
+		[]string{rdName, rdNamespace, runnerVersion},
+	)
 )
 
 func SetRunnerDeployment(rd v1alpha1.RunnerDeployment) {
@@ -35,3 +79,33 @@ func SetRunnerDeployment(rd v1alpha1.RunnerDeployment) {
 		runnerDeploymentReplicas.With(labels).Set(float64(*rd.Spec.Replicas))
 	}
 }
+
+// IncRunnerDeploymentInterruptions counts a runner pod of the RunnerDeployment interrupted for the reason.
+func IncRunnerDeploymentInterruptions(namespace, name, reason string) {
+	runnerDeploymentInterruptions.With(prometheus.Labels{
+		rdName:             name,
+		rdNamespace:        namespace,
+		interruptionReason: reason,
+	}).Inc()
+}
+
+// AddRunnerDeploymentUsage adds the usage of the runners of the RunnerDeployment observed since the last observation.
+func AddRunnerDeploymentUsage(namespace, name string, runnerSeconds, busySeconds float64, jobs int) {
+	labels := prometheus.Labels{
+		rdName:      name,
+		rdNamespace: namespace,
+	}
+
+	runnerDeploymentRunnerSeconds.With(labels).Add(runnerSeconds)
+	runnerDeploymentBusySeconds.With(labels).Add(busySeconds)
+	runnerDeploymentJobs.With(labels).Add(float64(jobs))
+}
+
+// IncRunnerDeploymentOutdatedRunners counts a runner pod of the RunnerDeployment recreated for its outdated runner version.
+func IncRunnerDeploymentOutdatedRunners(namespace, name, version string) {
+	runnerDeploymentOutdatedRunners.With(prometheus.Labels{
+		rdName:        name,
+		rdNamespace:   namespace,
+		runnerVersion: version,
+	}).Inc()
+}
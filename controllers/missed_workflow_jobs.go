@@ -0,0 +1,350 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const (
+	// DefaultMissedWorkflowJobGracePeriod is how long a queued workflow job is left to the webhook-based autoscaler
+	// before it's considered missed.
+	DefaultMissedWorkflowJobGracePeriod = 2 * time.Minute
+)
+
+// MissedWorkflowJobReconciler is the safety net of the webhook-based autoscaler for the queued events that GitHub failed to deliver.
+//
+// Every Interval, it lists the queued workflow jobs of the repositories of each HRA scaled by workflow_job events,
+// and adds the capacity reservation for every job routed to the HRA by its labels that has been queued longer than GracePeriod
+// without a reservation. The reservation has the same idempotency key as the one the webhook would have added,
+// so that the completion event of the job removes it.
+type MissedWorkflowJobReconciler struct {
+	client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+	Recorder     record.EventRecorder
+	Name         string
+
+	// MultiGitHubClient provides the clients of the scale targets with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
+	// Interval is the interval between the checks for each HRA.
+	Interval time.Duration
+
+	// GracePeriod is how long a job can be queued without a reservation before it's considered missed.
+	// Defaults to DefaultMissedWorkflowJobGracePeriod.
+	GracePeriod time.Duration
+
+	mu          sync.Mutex
+	lastChecked map[types.NamespacedName]time.Time
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments;runnersets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *MissedWorkflowJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("horizontalrunnerautoscaler", req.NamespacedName)
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := r.Get(ctx, req.NamespacedName, &hra); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !hra.DeletionTimestamp.IsZero() || !scaledByWorkflowJobs(hra) {
+		r.forget(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	// Reconciled on every update of the HRA, but the jobs are checked only at the interval
+	if next := r.lastCheckedTime(req.NamespacedName).Add(r.Interval); now.Before(next) {
+		return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+
+	repos, labels, credentialsFrom, found, err := r.getScaleTargetRepositories(ctx, hra)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !found {
+		return ctrl.Result{}, nil
+	}
+
+	if len(repos) == 0 {
+		log.V(1).Info("Skipped checking missed workflow jobs as the repositories of the scale target are unknown. " +
+			"Set the " + AnnotationKeyRepositories + " annotation or metrics[].repositoryNames to check the jobs of organization or enterprise runners")
+
+		r.setLastCheckedTime(req.NamespacedName, now)
+
+		return ctrl.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, hra.Namespace, credentialsFrom)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var jobs []*gogithub.WorkflowJob
+
+	for _, repo := range repos {
+		parts := strings.Split(repo, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Info("Skipped checking missed workflow jobs of the repository not in the OWNER/REPO format", "repository", repo)
+
+			continue
+		}
+
+		queued, err := ghc.ListQueuedWorkflowJobs(ctx, parts[0], parts[1])
+		if err != nil {
+			var e *gogithub.RateLimitError
+			if errors.As(err, &e) {
+				log.Info(fmt.Sprintf("Failed to check missed workflow jobs due to GitHub API rate limits. Retrying in %s", retryDelayOnGitHubAPIRateLimitError))
+
+				return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		jobs = append(jobs, queued...)
+	}
+
+	gracePeriod := r.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultMissedWorkflowJobGracePeriod
+	}
+
+	missed := missedWorkflowJobReservations(hra, jobs, labels, now, gracePeriod)
+
+	if len(missed) > 0 {
+		copy := hra.DeepCopy()
+		copy.Spec.CapacityReservations = append(copy.Spec.CapacityReservations, missed...)
+
+		if err := r.Patch(ctx, copy, client.MergeFrom(&hra)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("patching horizontalrunnerautoscaler to reserve capacity for missed workflow jobs: %w", err)
+		}
+
+		metrics.AddHorizontalRunnerAutoscalerMissedWorkflowJobs(hra.ObjectMeta, len(missed))
+
+		var (
+			keys     []string
+			replicas int
+		)
+
+		for _, cr := range missed {
+			keys = append(keys, cr.IdempotencyKey)
+			replicas += cr.Replicas
+		}
+
+		msg := fmt.Sprintf("Reserved %d replicas for the queued workflow jobs whose webhook events were missed: %s", replicas, strings.Join(keys, ", "))
+
+		log.Info(msg)
+
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "MissedWorkflowJobsReserved", msg)
+	}
+
+	log.V(1).Info("Checked missed workflow jobs", "repositories", repos, "queued", len(jobs), "missed", len(missed))
+
+	r.setLastCheckedTime(req.NamespacedName, now)
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+// scaledByWorkflowJobs returns true when the HRA is scaled by workflow_job events.
+func scaledByWorkflowJobs(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	for _, t := range hra.Spec.ScaleUpTriggers {
+		if t.GitHubEvent != nil && t.GitHubEvent.WorkflowJob != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getScaleTargetRepositories returns the OWNER/REPO repositories whose workflow jobs can scale the HRA, the labels of its runners,
+// and the githubAPICredentialsFrom of its runners.
+// The repositories of organization and enterprise runners are the ones listed in the AnnotationKeyRepositories annotation
+// and the repositoryNames of the metrics, as listing the jobs of every repository of the organization is too expensive.
+// found is false when the scale target isn't found.
+func (r *MissedWorkflowJobReconciler) getScaleTargetRepositories(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (repos []string, labels []string, credentialsFrom *v1alpha1.GitHubAPICredentialsFrom, found bool, err error) {
+	var (
+		config       v1alpha1.RunnerConfig
+		nodeSelector map[string]string
+	)
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := r.Get(ctx, key, &rd); err != nil {
+			return nil, nil, nil, false, client.IgnoreNotFound(err)
+		}
+
+		config, nodeSelector = rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.NodeSelector
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := r.Get(ctx, key, &rs); err != nil {
+			return nil, nil, nil, false, client.IgnoreNotFound(err)
+		}
+
+		config, nodeSelector = rs.Spec.RunnerConfig, rs.Spec.Template.Spec.NodeSelector
+	default:
+		return nil, nil, nil, false, nil
+	}
+
+	seen := map[string]bool{}
+
+	add := func(repo string) {
+		if repo != "" && !seen[repo] {
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+
+	if config.Repository != "" {
+		add(config.Repository)
+	} else {
+		for _, repo := range explicitRepositories(hra) {
+			add(repo)
+		}
+
+		if config.Organization != "" {
+			for _, m := range hra.Spec.Metrics {
+				for _, name := range m.RepositoryNames {
+					add(config.Organization + "/" + name)
+				}
+			}
+		}
+	}
+
+	return repos, runnerLabels(config, nodeSelector), config.GitHubAPICredentialsFrom, true, nil
+}
+
+// missedWorkflowJobReservations returns the capacity reservations for the queued jobs routed to the HRA
+// that have been queued longer than the grace period without a reservation.
+// A job whose reservation expired is reserved again, as it's still waiting for a runner.
+func missedWorkflowJobReservations(hra v1alpha1.HorizontalRunnerAutoscaler, jobs []*gogithub.WorkflowJob, runnerLabels []string, now time.Time, gracePeriod time.Duration) []v1alpha1.CapacityReservation {
+	reserved := map[string]bool{}
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		if cr.IdempotencyKey != "" && cr.ExpirationTime.Time.After(now) {
+			reserved[cr.IdempotencyKey] = true
+		}
+	}
+
+	duration := jobScaleUpDuration(hra)
+
+	var missed []v1alpha1.CapacityReservation
+
+	for _, job := range jobs {
+		if job.GetID() == 0 || !workflowJobLabelsMatch(hra, job.Labels, runnerLabels) {
+			continue
+		}
+
+		// The webhook event for the job may just be on its way
+		if queuedAt := job.GetStartedAt().Time; !queuedAt.IsZero() && now.Sub(queuedAt) < gracePeriod {
+			continue
+		}
+
+		key := fmt.Sprintf("workflow_job/%d", job.GetID())
+		if reserved[key] {
+			continue
+		}
+
+		reserved[key] = true
+
+		missed = append(missed, v1alpha1.CapacityReservation{
+			ExpirationTime: metav1.Time{Time: now.Add(duration.Duration)},
+			Replicas:       workflowJobAmount(hra, job.Labels),
+			IdempotencyKey: key,
+		})
+	}
+
+	return missed
+}
+
+func (r *MissedWorkflowJobReconciler) lastCheckedTime(key types.NamespacedName) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastChecked[key]
+}
+
+func (r *MissedWorkflowJobReconciler) setLastCheckedTime(key types.NamespacedName, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastChecked == nil {
+		r.lastChecked = map[types.NamespacedName]time.Time{}
+	}
+
+	r.lastChecked[key] = t
+}
+
+func (r *MissedWorkflowJobReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.lastChecked, key)
+}
+
+func (r *MissedWorkflowJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "missedworkflowjob-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		Named(name).
+		Complete(r)
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestMissedWorkflowJobReconciler(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	queuedAt := &github.Timestamp{Time: now.Add(-5 * time.Minute)}
+
+	jobs := []*github.WorkflowJob{
+		// Reserved by the webhook
+		{ID: github.Int64(1), Status: github.String("queued"), Labels: []string{"self-hosted"}, StartedAt: queuedAt},
+		// Missed
+		{ID: github.Int64(2), Status: github.String("queued"), Labels: []string{"self-hosted", "gpu"}, StartedAt: queuedAt},
+		// Queued within the grace period
+		{ID: github.Int64(3), Status: github.String("queued"), Labels: []string{"self-hosted"}, StartedAt: &github.Timestamp{Time: now.Add(-time.Minute)}},
+		// Not routed to the HRA
+		{ID: github.Int64(4), Status: github.String("queued"), Labels: []string{"self-hosted", "windows"}, StartedAt: queuedAt},
+		// Already running
+		{ID: github.Int64(5), Status: github.String("in_progress"), Labels: []string{"self-hosted"}, StartedAt: queuedAt},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runs", func(w http.ResponseWriter, req *http.Request) {
+		var runs github.WorkflowRuns
+		if req.URL.Query().Get("status") == "queued" {
+			runs.WorkflowRuns = []*github.WorkflowRun{{ID: github.Int64(100)}}
+		}
+		runs.TotalCount = github.Int(len(runs.WorkflowRuns))
+
+		j, err := json.Marshal(runs)
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+	mux.HandleFunc("/repos/test/valid/actions/runs/100/jobs", func(w http.ResponseWriter, req *http.Request) {
+		j, err := json.Marshal(github.Jobs{TotalCount: github.Int(len(jobs)), Jobs: jobs})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	expiration := metav1.Time{Time: now.Add(time.Hour)}
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+	rd.Spec.Template.Spec.Repository = "test/valid"
+	rd.Spec.Template.Spec.Labels = []string{"gpu"}
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+			ScaleUpTriggers: []v1alpha1.ScaleUpTrigger{
+				{
+					GitHubEvent:  &v1alpha1.GitHubEventScaleUpTriggerSpec{WorkflowJob: &v1alpha1.WorkflowJobSpec{}},
+					Duration:     metav1.Duration{Duration: 30 * time.Minute},
+					LabelAmounts: map[string]int{"gpu": 2},
+				},
+			},
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, rd, hra)
+	recorder := record.NewFakeRecorder(10)
+
+	clock := now
+
+	r := &MissedWorkflowJobReconciler{
+		Client:       client,
+		GitHubClient: newGithubClient(server),
+		Log:          logr.Discard(),
+		Recorder:     recorder,
+		Interval:     5 * time.Minute,
+		now:          func() time.Time { return clock },
+	}
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func(wantRequeueAfter time.Duration) []v1alpha1.CapacityReservation {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Errorf("unexpected requeue after: want %s, got %s", wantRequeueAfter, res.RequeueAfter)
+		}
+
+		var got v1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got.Spec.CapacityReservations
+	}
+
+	want := []v1alpha1.CapacityReservation{
+		{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+		{ExpirationTime: metav1.Time{Time: now.Add(30 * time.Minute)}, Replicas: 2, IdempotencyKey: "workflow_job/2"},
+	}
+
+	if d := cmp.Diff(want, reconcile(5*time.Minute)); d != "" {
+		t.Errorf("unexpected reservations (-want +got):\n%s", d)
+	}
+
+	// No check until the interval passes
+	clock = now.Add(time.Minute)
+
+	reconcile(4 * time.Minute)
+
+	// The reserved job isn't reserved twice
+	clock = now.Add(5 * time.Minute)
+
+	if got := len(reconcile(5 * time.Minute)); got != 3 {
+		t.Errorf("unexpected number of reservations: want 3 including the job queued within the grace period, got %d", got)
+	}
+
+	if got := len(recorder.Events); got != 2 {
+		t.Errorf("unexpected number of events: want 2, got %d", got)
+	}
+}
+
+func TestMissedWorkflowJobReservations_ExpiredReservation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{ExpirationTime: metav1.Time{Time: now.Add(-time.Minute)}, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+			},
+		},
+	}
+
+	jobs := []*github.WorkflowJob{
+		{ID: github.Int64(1), Status: github.String("queued"), Labels: []string{"self-hosted"}, StartedAt: &github.Timestamp{Time: now.Add(-time.Hour)}},
+	}
+
+	got := missedWorkflowJobReservations(hra, jobs, nil, now, time.Minute)
+
+	want := []v1alpha1.CapacityReservation{
+		{ExpirationTime: metav1.Time{Time: now.Add(10 * time.Minute)}, Replicas: 1, IdempotencyKey: "workflow_job/1"},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected reservations (-want +got):\n%s", d)
+	}
+}
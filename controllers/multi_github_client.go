@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/hash"
+)
+
+var errGitHubAPICredentialsFromUnsupported = errors.New("githubAPICredentialsFrom is set but the controller isn't configured to read it")
+
+const (
+	// AnnotationKeyGitHubAPICredsSecret is the annotation on the runner pods whose runners select the GitHub endpoint and
+	// the credentials by githubAPICredentialsFrom, whose value is the name of the Secret.
+	// The pods of RunnerSets are unregistered, and get their registration tokens, by it.
+	AnnotationKeyGitHubAPICredsSecret = "actions-runner-controller/github-api-creds-secret"
+
+	// multiGitHubClientSecretTTL is how long the Secret of a client is trusted before it's re-read for the changes,
+	// so that the reconciliations of thousands of runners don't read the Secret every time.
+	multiGitHubClientSecretTTL = time.Minute
+)
+
+// MultiGitHubClient maintains a GitHub client per Secret referenced by githubAPICredentialsFrom of the runners,
+// so that a single controller manages the runners of github.com and GitHub Enterprise Server instances,
+// or of multiple GitHub Apps, along with the ones of its default client.
+type MultiGitHubClient struct {
+	// Client reads the Secrets. It should bypass the cache, like the APIReader of the manager,
+	// so that all the Secrets in the cluster aren't cached.
+	Client client.Reader
+
+	// Config is the config of the default client of the controller. The clients inherit its settings
+	// other than the endpoint and the credentials, like the proxy and the retries.
+	Config github.Config
+
+	mu      sync.Mutex
+	clients map[types.NamespacedName]*cachedGitHubClient
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type cachedGitHubClient struct {
+	client *github.Client
+	// hash is the hash of the data of the Secret the client is created from
+	hash   string
+	readAt time.Time
+}
+
+// NewMultiGitHubClient returns the MultiGitHubClient that reads the Secrets with the client.
+func NewMultiGitHubClient(c client.Reader, config github.Config) *MultiGitHubClient {
+	return &MultiGitHubClient{
+		Client:  c,
+		Config:  config,
+		clients: map[types.NamespacedName]*cachedGitHubClient{},
+		now:     time.Now,
+	}
+}
+
+// Init returns the client for the GitHub endpoint and the credentials in the Secret.
+// The client is reused until the Secret changes, so that the registration tokens and the runners it caches aren't lost.
+func (m *MultiGitHubClient) Init(ctx context.Context, namespace, secretName string) (*github.Client, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: secretName}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	cached, ok := m.clients[key]
+	if ok && now.Sub(cached.readAt) < multiGitHubClientSecretTTL {
+		return cached.client, nil
+	}
+
+	var secret corev1.Secret
+	if err := m.Client.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("getting secret %s of githubAPICredentialsFrom: %w", key, err)
+	}
+
+	h := hash.FNVHashStringObjects(secret.Data)
+
+	if ok && cached.hash == h {
+		cached.readAt = now
+
+		return cached.client, nil
+	}
+
+	config, err := m.Config.ConfigForSecret(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret %s of githubAPICredentialsFrom: %w", key, err)
+	}
+
+	config.CredentialName = "secret:" + key.String()
+
+	c, err := config.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating github client for secret %s: %w", key, err)
+	}
+
+	m.clients[key] = &cachedGitHubClient{client: c, hash: h, readAt: now}
+
+	return c, nil
+}
+
+// gitHubClientFor returns the client for the GitHub endpoint and the credentials that the runners in the namespace select
+// by githubAPICredentialsFrom, or the default client when they select none.
+func gitHubClientFor(ctx context.Context, multi *MultiGitHubClient, defaultClient *github.Client, namespace string, from *v1alpha1.GitHubAPICredentialsFrom) (*github.Client, error) {
+	if from == nil {
+		return defaultClient, nil
+	}
+
+	if multi == nil {
+		return nil, errGitHubAPICredentialsFromUnsupported
+	}
+
+	return multi.Init(ctx, namespace, from.SecretRef.Name)
+}
+
+// isGitHubCredentialsMissing tells whether the error returned by gitHubClientFor is due to the Secret that doesn't exist
+// or the controller that can't read it at all, in which case retrying doesn't help until the user fixes it.
+func isGitHubCredentialsMissing(err error) bool {
+	return kerrors.IsNotFound(err) || errors.Is(err, errGitHubAPICredentialsFromUnsupported)
+}
+
+// gitHubClientForScaleTarget returns the client for the runners of the scale target of the HorizontalRunnerAutoscaler,
+// by their githubAPICredentialsFrom. The default client is returned when the scale target isn't found.
+func gitHubClientForScaleTarget(ctx context.Context, c client.Reader, multi *MultiGitHubClient, defaultClient *github.Client, hra v1alpha1.HorizontalRunnerAutoscaler) (*github.Client, error) {
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+
+	var from *v1alpha1.GitHubAPICredentialsFrom
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, key, &rd); err != nil {
+			if kerrors.IsNotFound(err) {
+				return defaultClient, nil
+			}
+
+			return nil, err
+		}
+
+		from = rd.Spec.Template.Spec.GitHubAPICredentialsFrom
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := c.Get(ctx, key, &rs); err != nil {
+			if kerrors.IsNotFound(err) {
+				return defaultClient, nil
+			}
+
+			return nil, err
+		}
+
+		from = rs.Spec.GitHubAPICredentialsFrom
+	}
+
+	return gitHubClientFor(ctx, multi, defaultClient, hra.Namespace, from)
+}
+
+// gitHubClientForPod returns the client for the runner pod, by the Secret in its AnnotationKeyGitHubAPICredsSecret annotation if any.
+func gitHubClientForPod(ctx context.Context, multi *MultiGitHubClient, defaultClient *github.Client, pod corev1.Pod) (*github.Client, error) {
+	name, ok := pod.Annotations[AnnotationKeyGitHubAPICredsSecret]
+	if !ok {
+		return defaultClient, nil
+	}
+
+	return gitHubClientFor(ctx, multi, defaultClient, pod.Namespace, &v1alpha1.GitHubAPICredentialsFrom{SecretRef: v1alpha1.SecretReference{Name: name}})
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+func TestMultiGitHubClient(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ghes", Namespace: "default"},
+		Data: map[string][]byte{
+			"github_token":          []byte("token"),
+			"github_enterprise_url": []byte("https://ghes.example.com"),
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, secret)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := NewMultiGitHubClient(c, github.Config{})
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	first, err := m.Init(ctx, "default", "ghes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.GithubBaseURL != "https://ghes.example.com/" {
+		t.Errorf("unexpected github base url: %s", first.GithubBaseURL)
+	}
+
+	// The changes are picked up only after the TTL
+	secret.Data["github_enterprise_url"] = []byte("https://ghes2.example.com")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := m.Init(ctx, "default", "ghes"); err != nil {
+		t.Fatal(err)
+	} else if got != first {
+		t.Errorf("expected the cached client within the TTL")
+	}
+
+	now = now.Add(multiGitHubClientSecretTTL)
+
+	second, err := m.Init(ctx, "default", "ghes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second == first {
+		t.Fatalf("expected the client to be recreated on the secret change")
+	}
+
+	if second.GithubBaseURL != "https://ghes2.example.com/" {
+		t.Errorf("unexpected github base url: %s", second.GithubBaseURL)
+	}
+
+	// The unchanged secret keeps the client
+	now = now.Add(multiGitHubClientSecretTTL)
+
+	if got, err := m.Init(ctx, "default", "ghes"); err != nil {
+		t.Fatal(err)
+	} else if got != second {
+		t.Errorf("expected the client to be reused for the unchanged secret")
+	}
+
+	if _, err := m.Init(ctx, "default", "missing"); err == nil {
+		t.Errorf("expected error for the missing secret")
+	}
+}
+
+func TestGitHubClientFor(t *testing.T) {
+	defaultClient := &github.Client{}
+
+	from := &v1alpha1.GitHubAPICredentialsFrom{SecretRef: v1alpha1.SecretReference{Name: "ghes"}}
+
+	got, err := gitHubClientFor(context.Background(), nil, defaultClient, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != defaultClient {
+		t.Errorf("expected the default client without githubAPICredentialsFrom")
+	}
+
+	if _, err := gitHubClientFor(context.Background(), nil, defaultClient, "default", from); err == nil {
+		t.Errorf("expected error without MultiGitHubClient")
+	}
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "example-0", Namespace: "default"}}
+
+	got, err = gitHubClientForPod(context.Background(), nil, defaultClient, pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != defaultClient {
+		t.Errorf("expected the default client for the pod without the annotation")
+	}
+}
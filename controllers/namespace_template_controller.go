@@ -0,0 +1,452 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// LabelKeyNamespaceTemplate is the label put on the resources created from a NamespaceTemplate, whose value is the name of the template.
+	LabelKeyNamespaceTemplate = "actions-runner-controller/namespace-template"
+
+	// AnnotationKeyNamespaceTemplatesOptOut opts a namespace or a resource created from a NamespaceTemplate out of the templates.
+	// On a namespace, "true" opts out of all the templates, and a comma-separated list of template names opts out of those templates.
+	// On a resource, "true" makes the controller release it to the owner of the namespace, after which it's neither updated,
+	// deleted, nor garbage-collected along with the template.
+	AnnotationKeyNamespaceTemplatesOptOut = "actions-runner-controller/namespace-templates-opt-out"
+)
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=namespacetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=namespacetemplates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// NamespaceTemplateReconciler creates the RunnerDeployment and the HorizontalRunnerAutoscaler of each NamespaceTemplate
+// in the namespaces selected by the template, keeps them in sync with the template, and deletes them from the namespaces
+// no longer selected. The resources are owned by the template, so that they're garbage-collected along with it.
+type NamespaceTemplateReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+	Name     string
+}
+
+func (r *NamespaceTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("namespacetemplate", req.Name)
+
+	var nt v1alpha1.NamespaceTemplate
+	if err := r.Get(ctx, req.NamespacedName, &nt); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !nt.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&nt.Spec.NamespaceSelector)
+	if err != nil {
+		r.Recorder.Event(&nt, corev1.EventTypeWarning, "InvalidNamespaceSelector", err.Error())
+
+		return ctrl.Result{}, nil
+	}
+
+	var namespaces corev1.NamespaceList
+
+	if err := r.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	selected := map[string]bool{}
+
+	for _, ns := range namespaces.Items {
+		if !ns.DeletionTimestamp.IsZero() || namespaceOptedOutOfTemplate(ns, nt.Name) {
+			continue
+		}
+
+		selected[ns.Name] = true
+
+		if err := r.syncRunnerDeployment(ctx, log, nt, ns.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.syncHorizontalRunnerAutoscaler(ctx, log, nt, ns.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.deleteUnselected(ctx, log, nt, selected); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var synced []string
+	for ns := range selected {
+		synced = append(synced, ns)
+	}
+	sort.Strings(synced)
+
+	if !equality.Semantic.DeepEqual(nt.Status.Namespaces, synced) {
+		updated := nt.DeepCopy()
+		updated.Status.Namespaces = synced
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&nt)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("patching namespacetemplate status: %w", err)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func namespaceOptedOutOfTemplate(ns corev1.Namespace, template string) bool {
+	v, ok := ns.Annotations[AnnotationKeyNamespaceTemplatesOptOut]
+	if !ok {
+		return false
+	}
+
+	if v == "true" {
+		return true
+	}
+
+	for _, name := range strings.Split(v, ",") {
+		if strings.TrimSpace(name) == template {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resourceOptedOutOfTemplate(obj client.Object) bool {
+	return obj.GetAnnotations()[AnnotationKeyNamespaceTemplatesOptOut] == "true"
+}
+
+// newRunnerDeploymentFromTemplate returns the RunnerDeployment to be created in the namespace.
+func newRunnerDeploymentFromTemplate(nt v1alpha1.NamespaceTemplate, namespace string) v1alpha1.RunnerDeployment {
+	tmpl := nt.Spec.RunnerDeployment.DeepCopy()
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        tmpl.Name,
+			Labels:      tmpl.Labels,
+			Annotations: tmpl.Annotations,
+		},
+		Spec: tmpl.Spec,
+	}
+
+	if rd.Name == "" {
+		rd.Name = nt.Name
+	}
+
+	if rd.Labels == nil {
+		rd.Labels = map[string]string{}
+	}
+
+	rd.Labels[LabelKeyNamespaceTemplate] = nt.Name
+
+	return rd
+}
+
+// newHorizontalRunnerAutoscalerFromTemplate returns the HorizontalRunnerAutoscaler to be created in the namespace.
+func newHorizontalRunnerAutoscalerFromTemplate(nt v1alpha1.NamespaceTemplate, namespace string) v1alpha1.HorizontalRunnerAutoscaler {
+	tmpl := nt.Spec.HorizontalRunnerAutoscaler.DeepCopy()
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        tmpl.Name,
+			Labels:      tmpl.Labels,
+			Annotations: tmpl.Annotations,
+		},
+		Spec: tmpl.Spec,
+	}
+
+	if hra.Name == "" {
+		hra.Name = nt.Name
+	}
+
+	if hra.Spec.ScaleTargetRef.Name == "" {
+		hra.Spec.ScaleTargetRef = v1alpha1.ScaleTargetRef{
+			Kind: "RunnerDeployment",
+			Name: newRunnerDeploymentFromTemplate(nt, namespace).Name,
+		}
+	}
+
+	if hra.Labels == nil {
+		hra.Labels = map[string]string{}
+	}
+
+	hra.Labels[LabelKeyNamespaceTemplate] = nt.Name
+
+	return hra
+}
+
+func (r *NamespaceTemplateReconciler) syncRunnerDeployment(ctx context.Context, log logr.Logger, nt v1alpha1.NamespaceTemplate, namespace string) error {
+	desired := newRunnerDeploymentFromTemplate(nt, namespace)
+
+	var rd v1alpha1.RunnerDeployment
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: desired.Name}, &rd); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if err := controllerutil.SetControllerReference(&nt, &desired, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, &desired); err != nil {
+			return fmt.Errorf("creating runnerdeployment %s/%s: %w", namespace, desired.Name, err)
+		}
+
+		log.Info("Created runnerdeployment from namespace template", "namespace", namespace, "runnerdeployment", desired.Name)
+
+		return nil
+	}
+
+	if !metav1.IsControlledBy(&rd, &nt) {
+		return nil
+	}
+
+	if resourceOptedOutOfTemplate(&rd) {
+		return r.releaseFromTemplate(ctx, log, nt, &rd)
+	}
+
+	// The replicas are managed by the HorizontalRunnerAutoscaler, if any
+	if nt.Spec.HorizontalRunnerAutoscaler != nil {
+		desired.Spec.Replicas = rd.Spec.Replicas
+	}
+
+	updated := rd.DeepCopy()
+	updated.Spec = desired.Spec
+	mergeStringMap(&updated.ObjectMeta.Labels, desired.Labels)
+	mergeStringMap(&updated.ObjectMeta.Annotations, desired.Annotations)
+
+	if equality.Semantic.DeepEqual(rd.ObjectMeta, updated.ObjectMeta) && equality.Semantic.DeepEqual(rd.Spec, updated.Spec) {
+		return nil
+	}
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		return fmt.Errorf("patching runnerdeployment %s/%s: %w", namespace, rd.Name, err)
+	}
+
+	log.Info("Synced runnerdeployment with namespace template", "namespace", namespace, "runnerdeployment", rd.Name)
+
+	return nil
+}
+
+func (r *NamespaceTemplateReconciler) syncHorizontalRunnerAutoscaler(ctx context.Context, log logr.Logger, nt v1alpha1.NamespaceTemplate, namespace string) error {
+	if nt.Spec.HorizontalRunnerAutoscaler == nil {
+		return nil
+	}
+
+	desired := newHorizontalRunnerAutoscalerFromTemplate(nt, namespace)
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: desired.Name}, &hra); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if err := controllerutil.SetControllerReference(&nt, &desired, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, &desired); err != nil {
+			return fmt.Errorf("creating horizontalrunnerautoscaler %s/%s: %w", namespace, desired.Name, err)
+		}
+
+		log.Info("Created horizontalrunnerautoscaler from namespace template", "namespace", namespace, "horizontalrunnerautoscaler", desired.Name)
+
+		return nil
+	}
+
+	if !metav1.IsControlledBy(&hra, &nt) {
+		return nil
+	}
+
+	if resourceOptedOutOfTemplate(&hra) {
+		return r.releaseFromTemplate(ctx, log, nt, &hra)
+	}
+
+	// The capacity reservations are managed by the webhook-based autoscaler
+	desired.Spec.CapacityReservations = hra.Spec.CapacityReservations
+
+	updated := hra.DeepCopy()
+	updated.Spec = desired.Spec
+	mergeStringMap(&updated.ObjectMeta.Labels, desired.Labels)
+	mergeStringMap(&updated.ObjectMeta.Annotations, desired.Annotations)
+
+	if equality.Semantic.DeepEqual(hra.ObjectMeta, updated.ObjectMeta) && equality.Semantic.DeepEqual(hra.Spec, updated.Spec) {
+		return nil
+	}
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&hra)); err != nil {
+		return fmt.Errorf("patching horizontalrunnerautoscaler %s/%s: %w", namespace, hra.Name, err)
+	}
+
+	log.Info("Synced horizontalrunnerautoscaler with namespace template", "namespace", namespace, "horizontalrunnerautoscaler", hra.Name)
+
+	return nil
+}
+
+// deleteUnselected deletes the resources created from the template in the namespaces that are no longer selected.
+func (r *NamespaceTemplateReconciler) deleteUnselected(ctx context.Context, log logr.Logger, nt v1alpha1.NamespaceTemplate, selected map[string]bool) error {
+	opts := []client.ListOption{client.MatchingLabels{LabelKeyNamespaceTemplate: nt.Name}}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hras, opts...); err != nil {
+		return err
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+
+	if err := r.List(ctx, &rds, opts...); err != nil {
+		return err
+	}
+
+	var objs []client.Object
+
+	for i := range hras.Items {
+		objs = append(objs, &hras.Items[i])
+	}
+
+	for i := range rds.Items {
+		objs = append(objs, &rds.Items[i])
+	}
+
+	for _, obj := range objs {
+		if selected[obj.GetNamespace()] || !metav1.IsControlledBy(obj, &nt) {
+			continue
+		}
+
+		if resourceOptedOutOfTemplate(obj) {
+			if err := r.releaseFromTemplate(ctx, log, nt, obj); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("deleting %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+
+		log.Info("Deleted resource created from namespace template in unselected namespace", "namespace", obj.GetNamespace(), "name", obj.GetName())
+	}
+
+	return nil
+}
+
+// releaseFromTemplate removes the owner reference to and the label of the template from the resource opted out of the template,
+// so that the resource is neither garbage-collected along with the template nor deleted from an unselected namespace.
+func (r *NamespaceTemplateReconciler) releaseFromTemplate(ctx context.Context, log logr.Logger, nt v1alpha1.NamespaceTemplate, obj client.Object) error {
+	updated := obj.DeepCopyObject().(client.Object)
+
+	var refs []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != nt.UID {
+			refs = append(refs, ref)
+		}
+	}
+	updated.SetOwnerReferences(refs)
+
+	labels := updated.GetLabels()
+	delete(labels, LabelKeyNamespaceTemplate)
+	updated.SetLabels(labels)
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(obj)); err != nil {
+		return fmt.Errorf("releasing %s/%s from namespace template: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	log.Info("Released resource opted out of namespace template", "namespace", obj.GetNamespace(), "name", obj.GetName())
+
+	return nil
+}
+
+func mergeStringMap(dst *map[string]string, src map[string]string) {
+	if len(src) == 0 {
+		return
+	}
+
+	if *dst == nil {
+		*dst = map[string]string{}
+	}
+
+	for k, v := range src {
+		(*dst)[k] = v
+	}
+}
+
+// namespaceTemplatesForNamespace enqueues all the NamespaceTemplates on any namespace change,
+// because a label change can both select and unselect the namespace.
+func (r *NamespaceTemplateReconciler) namespaceTemplatesForNamespace(obj client.Object) []reconcile.Request {
+	var nts v1alpha1.NamespaceTemplateList
+
+	if err := r.List(context.TODO(), &nts); err != nil {
+		r.Log.Error(err, "Failed to list namespacetemplates for namespace", "namespace", obj.GetName())
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, nt := range nts.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: nt.Name}})
+	}
+
+	return reqs
+}
+
+func (r *NamespaceTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "namespacetemplate-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.NamespaceTemplate{}).
+		Owns(&v1alpha1.RunnerDeployment{}).
+		Owns(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.namespaceTemplatesForNamespace)).
+		Named(name).
+		Complete(r)
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestNamespaceTemplate(t *testing.T) {
+	newNamespace := func(name string, labels, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+	}
+
+	tenant := map[string]string{"tenant": "true"}
+
+	minReplicas, maxReplicas, replicas := 1, 5, 3
+
+	nt := &v1alpha1.NamespaceTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-runners", UID: "nt-uid"},
+		Spec: v1alpha1.NamespaceTemplateSpec{
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: tenant},
+			RunnerDeployment: v1alpha1.RunnerDeploymentTemplate{
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{
+							RunnerConfig: v1alpha1.RunnerConfig{Organization: "example"},
+						},
+					},
+				},
+			},
+			HorizontalRunnerAutoscaler: &v1alpha1.HorizontalRunnerAutoscalerTemplate{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: &minReplicas,
+					MaxReplicas: &maxReplicas,
+				},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		nt,
+		newNamespace("team-a", tenant, nil),
+		newNamespace("team-b", tenant, nil),
+		newNamespace("team-c", tenant, map[string]string{AnnotationKeyNamespaceTemplatesOptOut: "other,default-runners"}),
+		newNamespace("kube-system", nil, nil),
+	)
+
+	r := &NamespaceTemplateReconciler{
+		Client:   client,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+		Scheme:   sc,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: nt.Name}}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ns := range []string{"team-a", "team-b"} {
+		key := types.NamespacedName{Namespace: ns, Name: nt.Name}
+
+		var rd v1alpha1.RunnerDeployment
+		if err := client.Get(ctx, key, &rd); err != nil {
+			t.Fatalf("expected the runnerdeployment in %s: %v", ns, err)
+		}
+
+		if rd.Labels[LabelKeyNamespaceTemplate] != nt.Name || !metav1.IsControlledBy(&rd, nt) {
+			t.Errorf("expected the runnerdeployment in %s to be labeled and owned by the template: %+v", ns, rd.ObjectMeta)
+		}
+
+		var hra v1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, key, &hra); err != nil {
+			t.Fatalf("expected the horizontalrunnerautoscaler in %s: %v", ns, err)
+		}
+
+		if ref := hra.Spec.ScaleTargetRef; ref.Kind != "RunnerDeployment" || ref.Name != nt.Name {
+			t.Errorf("unexpected scale target ref in %s: %+v", ns, ref)
+		}
+	}
+
+	for _, ns := range []string{"team-c", "kube-system"} {
+		var rd v1alpha1.RunnerDeployment
+		if err := client.Get(ctx, types.NamespacedName{Namespace: ns, Name: nt.Name}, &rd); !kerrors.IsNotFound(err) {
+			t.Errorf("expected no runnerdeployment in %s, got %v", ns, err)
+		}
+	}
+
+	var got v1alpha1.NamespaceTemplate
+	if err := client.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Status.Namespaces) != 2 || got.Status.Namespaces[0] != "team-a" || got.Status.Namespaces[1] != "team-b" {
+		t.Errorf("unexpected status namespaces: %v", got.Status.Namespaces)
+	}
+
+	// Replicas set by the HRA and reservations added by the webhook-based autoscaler survive template updates
+	teamA := types.NamespacedName{Namespace: "team-a", Name: nt.Name}
+
+	var rd v1alpha1.RunnerDeployment
+	if err := client.Get(ctx, teamA, &rd); err != nil {
+		t.Fatal(err)
+	}
+	rd.Spec.Replicas = &replicas
+	if err := client.Update(ctx, &rd); err != nil {
+		t.Fatal(err)
+	}
+
+	got.Spec.RunnerDeployment.Spec.Template.Spec.Organization = "example2"
+	if err := client.Update(ctx, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, teamA, &rd); err != nil {
+		t.Fatal(err)
+	}
+
+	if rd.Spec.Template.Spec.Organization != "example2" || rd.Spec.Replicas == nil || *rd.Spec.Replicas != replicas {
+		t.Errorf("expected the runnerdeployment to be synced with the template while keeping the replicas: %+v", rd.Spec)
+	}
+
+	// The resources are deleted from the unselected namespace, unless opted out
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: nt.Name}, &hra); err != nil {
+		t.Fatal(err)
+	}
+	hra.Annotations = map[string]string{AnnotationKeyNamespaceTemplatesOptOut: "true"}
+	if err := client.Update(ctx, &hra); err != nil {
+		t.Fatal(err)
+	}
+
+	var teamB corev1.Namespace
+	if err := client.Get(ctx, types.NamespacedName{Name: "team-b"}, &teamB); err != nil {
+		t.Fatal(err)
+	}
+	teamB.Labels = nil
+	if err := client.Update(ctx, &teamB); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: nt.Name}, &rd); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the runnerdeployment in the unselected namespace to be deleted, got %v", err)
+	}
+
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: nt.Name}, &hra); err != nil {
+		t.Fatalf("expected the opted-out horizontalrunnerautoscaler to be kept: %v", err)
+	}
+
+	if metav1.IsControlledBy(&hra, nt) || hra.Labels[LabelKeyNamespaceTemplate] != "" {
+		t.Errorf("expected the opted-out horizontalrunnerautoscaler to be released from the template: %+v", hra.ObjectMeta)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// ParseWatchNamespaces parses the comma-separated list of the namespaces to watch, given to the -watch-namespace flag.
+// It returns nil for watching all the namespaces.
+func ParseWatchNamespaces(s string) []string {
+	var namespaces []string
+
+	seen := map[string]bool{}
+
+	for _, ns := range strings.Split(s, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
+		}
+
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+
+	return namespaces
+}
+
+// WatchNamespacesCacheOptions returns the Namespace and the NewCache options of the manager that restrict
+// the watches, the caches, and the field indexes of the manager to the namespaces.
+// Unlike the Namespace option alone, this supports multiple namespaces, so that the controllers can run
+// with only Roles in those namespaces rather than a ClusterRole.
+func WatchNamespacesCacheOptions(namespaces []string) (string, cache.NewCacheFunc) {
+	switch len(namespaces) {
+	case 0:
+		return "", nil
+	case 1:
+		return namespaces[0], nil
+	default:
+		return "", cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+}
+
+// namespaceWatched tells whether the namespace is one of the namespaces parsed by ParseWatchNamespaces, which is empty for all namespaces.
+func namespaceWatched(namespaces []string, namespace string) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
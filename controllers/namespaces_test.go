@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseWatchNamespaces(t *testing.T) {
+	testcases := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: nil},
+		{value: "default", want: []string{"default"}},
+		{value: "team-a, team-b,,team-a", want: []string{"team-a", "team-b"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			if d := cmp.Diff(tc.want, ParseWatchNamespaces(tc.value)); d != "" {
+				t.Errorf("unexpected namespaces: %s", d)
+			}
+		})
+	}
+}
+
+func TestWatchNamespacesCacheOptions(t *testing.T) {
+	if ns, newCache := WatchNamespacesCacheOptions(nil); ns != "" || newCache != nil {
+		t.Errorf("expected all namespaces to be watched, got %q", ns)
+	}
+
+	if ns, newCache := WatchNamespacesCacheOptions([]string{"team-a"}); ns != "team-a" || newCache != nil {
+		t.Errorf("expected the single namespace to be watched, got %q", ns)
+	}
+
+	if ns, newCache := WatchNamespacesCacheOptions([]string{"team-a", "team-b"}); ns != "" || newCache == nil {
+		t.Errorf("expected the multi-namespace cache, got %q", ns)
+	}
+}
@@ -0,0 +1,893 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewRunnerPod_DockerNetworking(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	strPtr := func(v string) *string { return &v }
+
+	findContainer := func(pod corev1.Pod, name string) *corev1.Container {
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == name {
+				return &pod.Spec.Containers[i]
+			}
+		}
+		return nil
+	}
+
+	findEnv := func(c *corev1.Container, name string) (string, bool) {
+		for _, e := range c.Env {
+			if e.Name == name {
+				return e.Value, true
+			}
+		}
+		return "", false
+	}
+
+	testcases := []struct {
+		name                 string
+		dockerdInRunner      bool
+		ipv6CIDR             *string
+		wantDockerdArgs      []string
+		wantRunnerIPv6EnvVar string
+	}{
+		{
+			name:            "ipv4 with dockerd sidecar",
+			wantDockerdArgs: nil,
+		},
+		{
+			name:            "ipv6 with dockerd sidecar",
+			ipv6CIDR:        strPtr("fd00:1::/80"),
+			wantDockerdArgs: []string{"--ipv6", "--fixed-cidr-v6=fd00:1::/80", "--experimental", "--ip6tables"},
+		},
+		{
+			name:            "ipv4 with dockerd within runner",
+			dockerdInRunner: true,
+		},
+		{
+			name:                 "ipv6 with dockerd within runner",
+			dockerdInRunner:      true,
+			ipv6CIDR:             strPtr("fd00:1::/80"),
+			wantRunnerIPv6EnvVar: "fd00:1::/80",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			runnerSpec := v1alpha1.RunnerConfig{
+				Repository:                   "test/valid",
+				DockerdWithinRunnerContainer: boolPtr(tc.dockerdInRunner),
+				DockerIPv6CIDR:               tc.ipv6CIDR,
+			}
+
+			pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			runner := findContainer(pod, containerName)
+			if runner == nil {
+				t.Fatalf("runner container not found")
+			}
+
+			got, ok := findEnv(runner, "DOCKER_IPV6_CIDR")
+			if tc.wantRunnerIPv6EnvVar == "" && ok {
+				t.Errorf("unexpected DOCKER_IPV6_CIDR env var: %s", got)
+			} else if got != tc.wantRunnerIPv6EnvVar {
+				t.Errorf("unexpected DOCKER_IPV6_CIDR env var: want %q, got %q", tc.wantRunnerIPv6EnvVar, got)
+			}
+
+			dockerd := findContainer(pod, "docker")
+			if tc.dockerdInRunner {
+				if dockerd != nil {
+					t.Fatalf("unexpected docker container")
+				}
+				return
+			}
+
+			if dockerd == nil {
+				t.Fatalf("docker container not found")
+			}
+
+			if !reflect.DeepEqual(dockerd.Args, tc.wantDockerdArgs) {
+				t.Errorf("unexpected docker container args: want %v, got %v", tc.wantDockerdArgs, dockerd.Args)
+			}
+		})
+	}
+}
+
+func TestNewRunnerPod_LogForwarder(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		LogForwarder: &v1alpha1.LogForwarderSpec{
+			Endpoint: "fluentd.logging.svc:24224",
+			TLS: &v1alpha1.LogForwarderTLS{
+				CASecretName: "fluentd-ca",
+			},
+			Labels: map[string]string{
+				"team": "platform",
+				"env":  "prod",
+			},
+		},
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var lf *corev1.Container
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == logForwarderContainerName {
+			lf = &pod.Spec.Containers[i]
+		}
+	}
+
+	if lf == nil {
+		t.Fatalf("log forwarder container not found")
+	}
+
+	if lf.Image != defaultLogForwarderImage {
+		t.Errorf("unexpected image: want %q, got %q", defaultLogForwarderImage, lf.Image)
+	}
+
+	args := strings.Join(lf.Args, " ")
+	for _, want := range []string{
+		"-p Record=runner $(RUNNER_NAME) -p Record=env prod -p Record=team platform",
+		"-o forward -m * -p host=fluentd.logging.svc -p port=24224",
+		"-p tls=on -p tls.verify=on -p tls.ca_file=/etc/log-forwarder/ca/ca.crt",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected args to contain %q, got %q", want, args)
+		}
+	}
+
+	var found bool
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == logForwarderCAVolumeName && v.Secret != nil && v.Secret.SecretName == "fluentd-ca" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("log forwarder CA volume not found in %v", pod.Spec.Volumes)
+	}
+
+	runnerSpec.LogForwarder.Endpoint = "fluentd.logging.svc"
+
+	if _, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false); err == nil {
+		t.Errorf("expected error for the endpoint without port")
+	}
+}
+
+func TestNewRunnerPod_StartupProbe(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	probe := pod.Spec.Containers[0].StartupProbe
+	if probe == nil || probe.Exec == nil {
+		t.Fatalf("expected the runner container to have the exec startup probe, got %v", probe)
+	}
+
+	if got := time.Duration(probe.PeriodSeconds*probe.FailureThreshold) * time.Second; got != registrationTimeout {
+		t.Errorf("unexpected startup probe timeout: want %s, got %s", registrationTimeout, got)
+	}
+
+	custom := &corev1.Probe{PeriodSeconds: 1}
+	template := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "runner", StartupProbe: custom},
+			},
+		},
+	}
+
+	pod, err = newRunnerPod(template, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(pod.Spec.Containers[0].StartupProbe, custom) {
+		t.Errorf("expected the startup probe in the template to be kept, got %v", pod.Spec.Containers[0].StartupProbe)
+	}
+
+	pod, err = newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if probe := pod.Spec.Containers[0].StartupProbe; probe != nil {
+		t.Errorf("expected no startup probe for the registration-only runner, got %v", probe)
+	}
+}
+
+func TestNewRunnerPod_OIDC(t *testing.T) {
+	envOf := func(c corev1.Container) map[string]string {
+		env := map[string]string{}
+		for _, e := range c.Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	testcases := []struct {
+		name          string
+		oidc          v1alpha1.OIDCConfig
+		githubBaseURL string
+		wantIssuer    string
+		wantErr       bool
+	}{
+		{
+			name:          "github.com default",
+			githubBaseURL: "https://github.com/",
+			wantIssuer:    "https://token.actions.githubusercontent.com",
+		},
+		{
+			name:          "github.com enterprise issuer",
+			oidc:          v1alpha1.OIDCConfig{Issuer: "https://token.actions.githubusercontent.com/example"},
+			githubBaseURL: "https://github.com/",
+			wantIssuer:    "https://token.actions.githubusercontent.com/example",
+		},
+		{
+			name:          "ghes default",
+			githubBaseURL: "https://ghes.example.com/",
+			wantIssuer:    "https://ghes.example.com/_services/token",
+		},
+		{
+			name:          "ghes issuer of github.com",
+			oidc:          v1alpha1.OIDCConfig{Issuer: "https://token.actions.githubusercontent.com"},
+			githubBaseURL: "https://ghes.example.com/",
+			wantErr:       true,
+		},
+		{
+			name:          "ghes issuer of another host",
+			oidc:          v1alpha1.OIDCConfig{Issuer: "https://ghes.example.com.evil/_services/token"},
+			githubBaseURL: "https://ghes.example.com/",
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			oidc := tc.oidc
+			runnerSpec := v1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				OIDC:       &oidc,
+			}
+
+			pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", tc.githubBaseURL, false)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := envOf(pod.Spec.Containers[0])["ACTIONS_ID_TOKEN_ISSUER"]; got != tc.wantIssuer {
+				t.Errorf("unexpected issuer: want %q, got %q", tc.wantIssuer, got)
+			}
+		})
+	}
+
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		OIDC: &v1alpha1.OIDCConfig{
+			Audience:     "sts.amazonaws.com",
+			CASecretName: "ghes-ca",
+		},
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://ghes.example.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner := pod.Spec.Containers[0]
+	env := envOf(runner)
+
+	for k, want := range map[string]string{
+		"ACTIONS_ID_TOKEN_AUDIENCE": "sts.amazonaws.com",
+		"ACTIONS_ID_TOKEN_CA_FILE":  "/etc/actions-runner-controller/oidc/ca.crt",
+		"NODE_EXTRA_CA_CERTS":       "/etc/actions-runner-controller/oidc/ca.crt",
+	} {
+		if env[k] != want {
+			t.Errorf("unexpected %s: want %q, got %q", k, want, env[k])
+		}
+	}
+
+	var found bool
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == oidcCAVolumeName && v.Secret != nil && v.Secret.SecretName == "ghes-ca" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("oidc CA volume not found in %v", pod.Spec.Volumes)
+	}
+
+	found = false
+	for _, m := range runner.VolumeMounts {
+		if m.Name == oidcCAVolumeName && m.MountPath == oidcCAMountPath {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("oidc CA volume mount not found in %v", runner.VolumeMounts)
+	}
+}
+
+func TestNewRunnerPod_MaxJobDuration(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository:     "test/valid",
+		MaxJobDuration: &metav1.Duration{Duration: 90 * time.Minute},
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env := map[string]string{}
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+
+	for k, want := range map[string]string{
+		"RUNNER_MAX_JOB_DURATION_SECONDS":   "5400",
+		"ACTIONS_RUNNER_HOOK_JOB_STARTED":   "/etc/arc/hooks/job-started.sh",
+		"ACTIONS_RUNNER_HOOK_JOB_COMPLETED": "/etc/arc/hooks/job-completed.sh",
+	} {
+		if env[k] != want {
+			t.Errorf("unexpected %s: want %q, got %q", k, want, env[k])
+		}
+	}
+
+	runnerSpec.MaxJobDuration = nil
+
+	pod, err = newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, e := range pod.Spec.Containers[0].Env {
+		if e.Name == EnvVarMaxJobDurationSeconds || e.Name == "ACTIONS_RUNNER_HOOK_JOB_STARTED" {
+			t.Errorf("unexpected env %s without maxJobDuration", e.Name)
+		}
+	}
+}
+
+func TestNewRunnerPod_JobHooks(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		JobHooks: &v1alpha1.JobHooks{
+			PreJob: &v1alpha1.JobHookSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "hooks"},
+					Key:                  "pre-job",
+				},
+			},
+			PostJob: &v1alpha1.JobHookSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "hooks"},
+					Key:                  "post-job",
+				},
+			},
+		},
+	}
+
+	envOf := func(pod corev1.Pod) map[string]string {
+		env := map[string]string{}
+		for _, e := range pod.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env := envOf(pod)
+
+	for k, want := range map[string]string{
+		"ACTIONS_RUNNER_HOOK_JOB_STARTED":   "/etc/actions-runner-controller/job-hooks/pre-job.sh",
+		"ACTIONS_RUNNER_HOOK_JOB_COMPLETED": "/etc/actions-runner-controller/job-hooks/post-job.sh",
+		"RUNNER_PRE_JOB_HOOK":               "",
+		"RUNNER_POST_JOB_HOOK":              "",
+	} {
+		if env[k] != want {
+			t.Errorf("unexpected %s: want %q, got %q", k, want, env[k])
+		}
+	}
+
+	var volume *corev1.Volume
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].Name == "job-hooks" {
+			volume = &pod.Spec.Volumes[i]
+		}
+	}
+
+	if volume == nil || volume.Projected == nil || len(volume.Projected.Sources) != 2 {
+		t.Fatalf("expected the projected job-hooks volume, got %+v", pod.Spec.Volumes)
+	}
+
+	if cm := volume.Projected.Sources[0].ConfigMap; cm == nil || cm.Name != "hooks" || cm.Items[0].Key != "pre-job" || cm.Items[0].Path != "pre-job.sh" {
+		t.Errorf("unexpected pre-job hook source: %+v", volume.Projected.Sources[0])
+	}
+
+	if s := volume.Projected.Sources[1].Secret; s == nil || s.Name != "hooks" || s.Items[0].Key != "post-job" || s.Items[0].Path != "post-job.sh" {
+		t.Errorf("unexpected post-job hook source: %+v", volume.Projected.Sources[1])
+	}
+
+	var mounted bool
+	for _, m := range pod.Spec.Containers[0].VolumeMounts {
+		if m.Name == "job-hooks" && m.MountPath == "/etc/actions-runner-controller/job-hooks" {
+			mounted = true
+		}
+	}
+
+	if !mounted {
+		t.Errorf("expected the job-hooks volume to be mounted to the runner container, got %+v", pod.Spec.Containers[0].VolumeMounts)
+	}
+
+	// With maxJobDuration, the hooks are run by the ARC job hooks after starting and stopping the watchdog
+	runnerSpec.MaxJobDuration = &metav1.Duration{Duration: time.Hour}
+
+	pod, err = newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env = envOf(pod)
+
+	for k, want := range map[string]string{
+		"ACTIONS_RUNNER_HOOK_JOB_STARTED":   "/etc/arc/hooks/job-started.sh",
+		"ACTIONS_RUNNER_HOOK_JOB_COMPLETED": "/etc/arc/hooks/job-completed.sh",
+		"RUNNER_PRE_JOB_HOOK":               "/etc/actions-runner-controller/job-hooks/pre-job.sh",
+		"RUNNER_POST_JOB_HOOK":              "/etc/actions-runner-controller/job-hooks/post-job.sh",
+	} {
+		if env[k] != want {
+			t.Errorf("unexpected %s: want %q, got %q", k, want, env[k])
+		}
+	}
+}
+
+func TestNewRunnerPod_Windows(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		OS:         v1alpha1.RunnerOSWindows,
+	}
+
+	if _, err := newRunnerPod(corev1.Pod{}, runnerSpec, "", nil, "docker:dind", "", "https://github.com/", false); err == nil {
+		t.Fatal("expected an error without the windows runner image")
+	}
+
+	template := corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"node.kubernetes.io/instance-type": "m5.large"},
+		},
+	}
+
+	pod, err := newRunnerPod(template, runnerSpec, "runner-windows:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pod.Spec.Containers) != 1 {
+		t.Fatalf("expected no docker sidecar, got %+v", pod.Spec.Containers)
+	}
+
+	runner := pod.Spec.Containers[0]
+
+	if runner.Image != "runner-windows:latest" {
+		t.Errorf("unexpected image: %s", runner.Image)
+	}
+
+	if runner.SecurityContext.Privileged != nil || runner.StartupProbe != nil || len(runner.VolumeMounts) != 0 {
+		t.Errorf("unexpected linux-only configuration of the runner container: %+v", runner)
+	}
+
+	want := map[string]string{"kubernetes.io/os": "windows", "node.kubernetes.io/instance-type": "m5.large"}
+	if !reflect.DeepEqual(pod.Spec.NodeSelector, want) {
+		t.Errorf("unexpected node selector: want %v, got %v", want, pod.Spec.NodeSelector)
+	}
+
+	if template.Spec.NodeSelector["kubernetes.io/os"] != "" {
+		t.Errorf("expected the template to be left unchanged, got %v", template.Spec.NodeSelector)
+	}
+
+	if sc := pod.Spec.SecurityContext; sc == nil || sc.WindowsOptions == nil || *sc.WindowsOptions.RunAsUserName != "ContainerAdministrator" {
+		t.Errorf("unexpected security context: %+v", sc)
+	}
+
+	for _, e := range runner.Env {
+		if e.Name == "RUNNER_WORKDIR" && e.Value != "_work" {
+			t.Errorf("unexpected RUNNER_WORKDIR: %s", e.Value)
+		}
+	}
+}
+
+func TestNewRunnerPod_Arch(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		Arch:       v1alpha1.RunnerArchARM64,
+	}
+
+	template := corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"node.kubernetes.io/instance-type": "m6g.large"},
+		},
+	}
+
+	pod, err := newRunnerPod(template, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"kubernetes.io/arch": "arm64", "node.kubernetes.io/instance-type": "m6g.large"}
+	if !reflect.DeepEqual(pod.Spec.NodeSelector, want) {
+		t.Errorf("unexpected node selector: want %v, got %v", want, pod.Spec.NodeSelector)
+	}
+
+	if template.Spec.NodeSelector["kubernetes.io/arch"] != "" {
+		t.Errorf("expected the template to be left unchanged, got %v", template.Spec.NodeSelector)
+	}
+
+	template.Spec.NodeSelector = map[string]string{"kubernetes.io/arch": "amd64"}
+
+	if _, err := newRunnerPod(template, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false); err == nil {
+		t.Error("expected an error for the arch conflicting with the node selector")
+	}
+}
+
+func TestNewRunnerPod_GPU(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		Labels:     []string{"cuda", "GPU"},
+		GPU:        &v1alpha1.GPUConfig{Count: 2, Type: "Tesla-T4"},
+	}
+
+	template := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "runner",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+					},
+				},
+			},
+		},
+	}
+
+	pod, err := newRunnerPod(template, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner := pod.Spec.Containers[0]
+
+	if q := runner.Resources.Limits[ResourceNameGPU]; q.Value() != 2 {
+		t.Errorf("unexpected gpu limit: %s", q.String())
+	}
+
+	if q := runner.Resources.Requests[ResourceNameGPU]; q.Value() != 2 {
+		t.Errorf("unexpected gpu request: %s", q.String())
+	}
+
+	if q := runner.Resources.Limits[corev1.ResourceMemory]; q.String() != "8Gi" {
+		t.Errorf("expected the memory limit to be kept, got %s", q.String())
+	}
+
+	if _, ok := template.Spec.Containers[0].Resources.Limits[ResourceNameGPU]; ok {
+		t.Errorf("expected the template to be left unchanged, got %v", template.Spec.Containers[0].Resources)
+	}
+
+	wantTolerations := []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+	if !reflect.DeepEqual(pod.Spec.Tolerations, wantTolerations) {
+		t.Errorf("unexpected tolerations: want %v, got %v", wantTolerations, pod.Spec.Tolerations)
+	}
+
+	if got := pod.Spec.NodeSelector[LabelKeyGPUProduct]; got != "Tesla-T4" {
+		t.Errorf("unexpected node selector: %v", pod.Spec.NodeSelector)
+	}
+
+	var labels string
+	for _, e := range runner.Env {
+		if e.Name == "RUNNER_LABELS" {
+			labels = e.Value
+		}
+	}
+
+	if labels != "cuda,GPU,Tesla-T4" {
+		t.Errorf("unexpected RUNNER_LABELS: %q", labels)
+	}
+}
+
+func TestNewRunnerPod_ToolCache(t *testing.T) {
+	runnerSpec := v1alpha1.RunnerConfig{
+		Repository: "test/valid",
+		ToolCache:  &v1alpha1.ToolCacheSpec{ClaimName: "tool-cache"},
+	}
+
+	pod, err := newRunnerPod(corev1.Pod{}, runnerSpec, "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var claimName string
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == toolCacheVolumeName && v.PersistentVolumeClaim != nil {
+			claimName = v.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	if claimName != "tool-cache" {
+		t.Errorf("unexpected claim of the tool cache volume: %q", claimName)
+	}
+
+	runner, docker := pod.Spec.Containers[0], pod.Spec.Containers[1]
+
+	var toolCache string
+	for _, e := range runner.Env {
+		if e.Name == "RUNNER_TOOL_CACHE" {
+			toolCache = e.Value
+		}
+	}
+
+	if toolCache != "/opt/hostedtoolcache" {
+		t.Errorf("unexpected RUNNER_TOOL_CACHE: %q", toolCache)
+	}
+
+	// The job containers run by dockerd mount the tool cache by the path in the runner container
+	for _, c := range []corev1.Container{runner, docker} {
+		var mountPath string
+		for _, m := range c.VolumeMounts {
+			if m.Name == toolCacheVolumeName {
+				mountPath = m.MountPath
+			}
+		}
+
+		if mountPath != "/opt/hostedtoolcache" {
+			t.Errorf("unexpected mount path of the tool cache in container %s: %q", c.Name, mountPath)
+		}
+	}
+}
+
+func TestNewRunnerPod_Cache(t *testing.T) {
+	testcases := []struct {
+		name       string
+		cache      *v1alpha1.RunnerCacheConfig
+		defaultURL string
+		want       string
+	}{
+		{
+			name:       "default",
+			defaultURL: "http://cache-server.actions-runner-system.svc:3000",
+			want:       "http://cache-server.actions-runner-system.svc:3000/",
+		},
+		{
+			name:       "runner",
+			cache:      &v1alpha1.RunnerCacheConfig{URL: "http://cache.example.com/"},
+			defaultURL: "http://cache-server.actions-runner-system.svc:3000",
+			want:       "http://cache.example.com/",
+		},
+		{
+			name:       "disabled",
+			cache:      &v1alpha1.RunnerCacheConfig{Disabled: true},
+			defaultURL: "http://cache-server.actions-runner-system.svc:3000",
+			want:       "",
+		},
+		{
+			name: "none",
+			want: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			runnerSpec := v1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				Cache:      tc.cache,
+			}
+
+			pod, err := newRunnerPod(corev1.Pod{}, withDefaultRunnerCache(runnerSpec, tc.defaultURL), "runner:latest", nil, "docker:dind", "", "https://github.com/", false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			env := map[string]string{}
+			for _, e := range pod.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+
+			if got := env[EnvVarRunnerCacheURL]; got != tc.want {
+				t.Errorf("unexpected %s: want %q, got %q", EnvVarRunnerCacheURL, tc.want, got)
+			}
+
+			// The ARC job hooks pass the cache server to the job
+			wantHook := ""
+			if tc.want != "" {
+				wantHook = jobStartedHookPath
+			}
+
+			if got := env[envVarJobStartedHook]; got != wantHook {
+				t.Errorf("unexpected %s: want %q, got %q", envVarJobStartedHook, wantHook, got)
+			}
+		})
+	}
+}
+
+func TestRunnerImageFor(t *testing.T) {
+	arm64NodeSelector := map[string]string{"kubernetes.io/arch": "arm64"}
+
+	testcases := []struct {
+		runnerSpec   v1alpha1.RunnerConfig
+		nodeSelector map[string]string
+		arm64Image   string
+		want         string
+	}{
+		{want: "runner:latest"},
+		{runnerSpec: v1alpha1.RunnerConfig{Arch: v1alpha1.RunnerArchARM64}, arm64Image: "runner-arm64:latest", want: "runner-arm64:latest"},
+		{nodeSelector: arm64NodeSelector, arm64Image: "runner-arm64:latest", want: "runner-arm64:latest"},
+		{nodeSelector: arm64NodeSelector, want: "runner:latest"},
+		{runnerSpec: v1alpha1.RunnerConfig{Arch: v1alpha1.RunnerArchAMD64}, arm64Image: "runner-arm64:latest", want: "runner:latest"},
+		{runnerSpec: v1alpha1.RunnerConfig{OS: v1alpha1.RunnerOSWindows}, arm64Image: "runner-arm64:latest", want: "runner-windows:latest"},
+	}
+
+	for i, tc := range testcases {
+		if got := runnerImageFor(tc.runnerSpec, tc.nodeSelector, "runner:latest", tc.arm64Image, "runner-windows:latest"); got != tc.want {
+			t.Errorf("%d: unexpected image: want %s, got %s", i, tc.want, got)
+		}
+	}
+}
+
+func TestRunnerReconcilerNewPod_WindowsNodeSelector(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				OS:         v1alpha1.RunnerOSWindows,
+			},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				NodeSelector: map[string]string{"node.kubernetes.io/instance-type": "m5.large"},
+			},
+		},
+	}
+
+	r := &RunnerReconciler{Scheme: sc, GitHubClient: &github.Client{}, WindowsRunnerImage: "runner-windows:latest"}
+
+	pod, err := r.newPod(runner, r.GitHubClient.GithubBaseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"kubernetes.io/os": "windows", "node.kubernetes.io/instance-type": "m5.large"}
+	if !reflect.DeepEqual(pod.Spec.NodeSelector, want) {
+		t.Errorf("unexpected node selector: want %v, got %v", want, pod.Spec.NodeSelector)
+	}
+
+	if pod.Spec.Containers[0].Image != "runner-windows:latest" {
+		t.Errorf("unexpected image: %s", pod.Spec.Containers[0].Image)
+	}
+}
+
+func TestRunnerReconcilerNewPod_Sidecars(t *testing.T) {
+	dockerdWithinRunner := true
+
+	testcases := []struct {
+		name                string
+		dockerdWithinRunner *bool
+	}{
+		{name: "dockerd sidecar"},
+		{name: "dockerd within runner", dockerdWithinRunner: &dockerdWithinRunner},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository:                   "test/valid",
+						DockerdWithinRunnerContainer: tc.dockerdWithinRunner,
+					},
+					RunnerPodSpec: v1alpha1.RunnerPodSpec{
+						Sidecars: []corev1.Container{
+							{Name: "proxy", Image: "proxy:latest"},
+							{
+								Name:      "db",
+								Image:     "postgres:latest",
+								Lifecycle: &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"pg_ctl", "stop"}}}},
+							},
+						},
+					},
+				},
+			}
+
+			r := &RunnerReconciler{Scheme: sc, GitHubClient: &github.Client{}}
+
+			pod, err := r.newPod(runner, r.GitHubClient.GithubBaseURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, c := range pod.Spec.Containers {
+				names = append(names, c.Name)
+			}
+
+			wantNames := []string{"proxy", "db", "runner"}
+			if tc.dockerdWithinRunner == nil {
+				wantNames = append(wantNames, "docker")
+			}
+
+			if d := cmp.Diff(wantNames, names); d != "" {
+				t.Fatalf("unexpected containers (-want +got):\n%s", d)
+			}
+
+			if present, _ := workVolumePresent(pod.Spec.Volumes); !present {
+				t.Errorf("missing work volume")
+			}
+
+			wantMarker := corev1.EnvVar{Name: EnvVarRunnerExitMarker, Value: "/runner/_work/.runner-exited"}
+
+			for _, c := range pod.Spec.Containers[:3] {
+				present, i := workVolumeMountPresent(c.VolumeMounts)
+				if !present || c.VolumeMounts[i].MountPath != "/runner/_work" {
+					t.Errorf("%s: missing work volume mount: %v", c.Name, c.VolumeMounts)
+				}
+
+				var found bool
+				for _, e := range c.Env {
+					if e == wantMarker {
+						found = true
+					}
+				}
+
+				if !found {
+					t.Errorf("%s: missing exit marker env: %v", c.Name, c.Env)
+				}
+			}
+
+			if d := cmp.Diff(sidecarPreStopCommand, pod.Spec.Containers[0].Lifecycle.PreStop.Exec.Command); d != "" {
+				t.Errorf("unexpected preStop hook (-want +got):\n%s", d)
+			}
+
+			if d := cmp.Diff([]string{"pg_ctl", "stop"}, pod.Spec.Containers[1].Lifecycle.PreStop.Exec.Command); d != "" {
+				t.Errorf("unexpected preStop hook (-want +got):\n%s", d)
+			}
+
+			if runner.Spec.Sidecars[0].Lifecycle != nil {
+				t.Errorf("the sidecars of the runner spec must not be modified")
+			}
+		})
+	}
+}
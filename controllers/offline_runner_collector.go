@@ -0,0 +1,341 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const (
+	DefaultOfflineRunnerCollectionInterval = 10 * time.Minute
+)
+
+// OfflineRunnerCollector periodically unregisters GitHub runners that are offline and
+// no longer backed by any runner pod.
+//
+// Runner pods that die uncleanly, e.g. due to node failures or force deletions, can leave
+// their runners registered on GitHub forever. Those stale offline runners count against
+// the organization's runner limit, so this collector removes them per RunnerDeployment or RunnerSet
+// after they have been observed offline and without a pod for GracePeriod.
+type OfflineRunnerCollector struct {
+	client.Client
+	Log          logr.Logger
+	Recorder     record.EventRecorder
+	Scheme       *runtime.Scheme
+	GitHubClient *github.Client
+	Name         string
+
+	// MultiGitHubClient provides the clients of the scale targets with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
+	// Kind is the kind of the scale target this collector watches.
+	// Either "RunnerDeployment" or "RunnerSet".
+	Kind string
+
+	// GracePeriod is the minimum duration a runner needs to be observed offline without a pod
+	// before getting unregistered.
+	GracePeriod time.Duration
+
+	// Interval is the interval between two collections for the same RunnerDeployment or RunnerSet.
+	Interval time.Duration
+
+	mu sync.Mutex
+	// offlineSince remembers when each runner is first observed offline without a pod, keyed by the RunnerDeployment or RunnerSet
+	// and the runner ID, so that the runners of a deleted RunnerDeployment or RunnerSet are forgotten with it.
+	// This is in-memory only, so the grace period restarts whenever the controller restarts.
+	offlineSince map[types.NamespacedName]map[int64]time.Time
+}
+
+// offlineRunnerScope is the set of GitHub runners that can belong to a RunnerDeployment or a RunnerSet.
+type offlineRunnerScope struct {
+	enterprise, org, repo string
+	namespace, name       string
+	podLabelKey           string
+	podLabelValue         string
+	runnerNamePattern     *regexp.Regexp
+	credentialsFrom       *v1alpha1.GitHubAPICredentialsFrom
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnersets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *OfflineRunnerCollector) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("kind", r.Kind, "name", req.NamespacedName)
+
+	var scope offlineRunnerScope
+	var obj client.Object
+
+	switch r.Kind {
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := r.Get(ctx, req.NamespacedName, &rs); err != nil {
+			if kerrors.IsNotFound(err) {
+				r.forget(req.NamespacedName)
+			}
+
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+
+		if !rs.ObjectMeta.DeletionTimestamp.IsZero() {
+			r.forget(req.NamespacedName)
+
+			return ctrl.Result{}, nil
+		}
+
+		obj = &rs
+		scope = offlineRunnerScopeForRunnerSet(rs)
+	case "RunnerDeployment", "":
+		var rd v1alpha1.RunnerDeployment
+		if err := r.Get(ctx, req.NamespacedName, &rd); err != nil {
+			if kerrors.IsNotFound(err) {
+				r.forget(req.NamespacedName)
+			}
+
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+
+		if !rd.ObjectMeta.DeletionTimestamp.IsZero() {
+			r.forget(req.NamespacedName)
+
+			return ctrl.Result{}, nil
+		}
+
+		obj = &rd
+		scope = offlineRunnerScopeForRunnerDeployment(rd)
+	default:
+		return ctrl.Result{}, fmt.Errorf("unsupported kind for offline runner collection: %s", r.Kind)
+	}
+
+	removed, err := r.collect(ctx, log, scope, time.Now())
+	if err != nil {
+		var e *gogithub.RateLimitError
+		if errors.As(err, &e) {
+			log.Error(
+				err,
+				fmt.Sprintf(
+					"Failed to collect offline runners due to GitHub API rate limit. Retrying in %s to avoid excessive GitHub API calls",
+					retryDelayOnGitHubAPIRateLimitError,
+				),
+			)
+
+			return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, nil
+		}
+
+		if github.IsTransient(err) {
+			log.Info(
+				fmt.Sprintf("Failed to collect offline runners due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+				"error", err.Error(),
+			)
+
+			return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	for _, name := range removed {
+		r.Recorder.Event(obj, corev1.EventTypeNormal, "OfflineRunnerRemoved", fmt.Sprintf("Removed offline runner '%s' that had no pod from GitHub", name))
+	}
+
+	return ctrl.Result{RequeueAfter: r.interval()}, nil
+}
+
+// forget drops the runners observed offline for the RunnerDeployment or RunnerSet.
+func (r *OfflineRunnerCollector) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.offlineSince, key)
+}
+
+func (r *OfflineRunnerCollector) interval() time.Duration {
+	if r.Interval > 0 {
+		return r.Interval
+	}
+
+	return DefaultOfflineRunnerCollectionInterval
+}
+
+// collect unregisters the offline runners without pods in the scope whose grace period has passed,
+// and returns the names of the removed runners.
+func (r *OfflineRunnerCollector) collect(ctx context.Context, log logr.Logger, scope offlineRunnerScope, now time.Time) ([]string, error) {
+	var pods corev1.PodList
+
+	if err := r.List(ctx, &pods, client.InNamespace(scope.namespace), client.MatchingLabels{scope.podLabelKey: scope.podLabelValue}); err != nil {
+		return nil, err
+	}
+
+	podNames := map[string]struct{}{}
+	for _, pod := range pods.Items {
+		podNames[pod.Name] = struct{}{}
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, scope.namespace, scope.credentialsFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	runners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.offlineSince == nil {
+		r.offlineSince = map[types.NamespacedName]map[int64]time.Time{}
+	}
+
+	key := types.NamespacedName{Namespace: scope.namespace, Name: scope.name}
+
+	offlineSince := r.offlineSince[key]
+	if offlineSince == nil {
+		offlineSince = map[int64]time.Time{}
+		r.offlineSince[key] = offlineSince
+	}
+
+	var removed []string
+
+	for _, runner := range runners {
+		name := runner.GetName()
+		id := runner.GetID()
+
+		if !scope.runnerNamePattern.MatchString(name) {
+			continue
+		}
+
+		_, hasPod := podNames[name]
+
+		// Sometimes a runner can stuck "busy" even though it is already "offline".
+		// Removing such runner results in a 422 error so we leave it until it becomes non-busy.
+		if hasPod || runner.GetStatus() != "offline" || runner.GetBusy() {
+			delete(offlineSince, id)
+			continue
+		}
+
+		since, ok := offlineSince[id]
+		if !ok {
+			since = now
+			offlineSince[id] = since
+		}
+
+		if now.Sub(since) < r.GracePeriod {
+			log.V(1).Info("Found offline runner without pod. Waiting for the grace period to pass before removing it", "runnerName", name, "offlineSince", since, "gracePeriod", r.GracePeriod)
+			continue
+		}
+
+		if err := ghc.RemoveRunner(ctx, scope.enterprise, scope.org, scope.repo, id); err != nil {
+			return removed, err
+		}
+
+		delete(offlineSince, id)
+
+		log.Info("Removed offline runner without pod from GitHub", "runnerName", name, "runnerID", id, "offlineSince", since)
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
+
+func offlineRunnerScopeForRunnerDeployment(rd v1alpha1.RunnerDeployment) offlineRunnerScope {
+	spec := rd.Spec.Template.Spec
+
+	return offlineRunnerScope{
+		enterprise:    spec.Enterprise,
+		org:           spec.Organization,
+		repo:          spec.Repository,
+		namespace:     rd.Namespace,
+		name:          rd.Name,
+		podLabelKey:   LabelKeyRunnerDeploymentName,
+		podLabelValue: rd.Name,
+		// A runner managed by a runner deployment is named like <runnerdeployment>-<5 random chars>-<hash of up to 10 chars>,
+		// because the runner replicaset is created with generateName and the runner is named after its slot.
+		runnerNamePattern: regexp.MustCompile("^" + regexp.QuoteMeta(rd.Name) + "-[a-z0-9]{5}-[a-z0-9]{1,10}$"),
+		credentialsFrom:   spec.GitHubAPICredentialsFrom,
+	}
+}
+
+func offlineRunnerScopeForRunnerSet(rs v1alpha1.RunnerSet) offlineRunnerScope {
+	return offlineRunnerScope{
+		enterprise:    rs.Spec.Enterprise,
+		org:           rs.Spec.Organization,
+		repo:          rs.Spec.Repository,
+		namespace:     rs.Namespace,
+		name:          rs.Name,
+		podLabelKey:   LabelKeyRunnerSetName,
+		podLabelValue: rs.Name,
+		// A runner managed by a runner set is named after the statefulset pod, i.e. <runnerset>-<ordinal>.
+		runnerNamePattern: regexp.MustCompile("^" + regexp.QuoteMeta(rs.Name) + "-[0-9]+$"),
+		credentialsFrom:   rs.Spec.GitHubAPICredentialsFrom,
+	}
+}
+
+func (r *OfflineRunnerCollector) SetupWithManager(mgr ctrl.Manager) error {
+	var (
+		obj  client.Object
+		name string
+	)
+
+	switch r.Kind {
+	case "RunnerSet":
+		obj = &v1alpha1.RunnerSet{}
+		name = "runnerset-offline-runner-collector"
+	case "RunnerDeployment", "":
+		obj = &v1alpha1.RunnerDeployment{}
+		name = "runnerdeployment-offline-runner-collector"
+	default:
+		return fmt.Errorf("unsupported kind for offline runner collection: %s", r.Kind)
+	}
+
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	// The status updates are ignored, as the collections are driven by RequeueAfter at the interval
+	// rather than by every change of the RunnerDeployment or RunnerSet.
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(name).
+		Complete(r)
+}
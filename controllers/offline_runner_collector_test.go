@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestOfflineRunnerCollector_collect(t *testing.T) {
+	runners := []*github.Runner{
+		// Offline and without a pod
+		{ID: github.Int64(1), Name: github.String("example-abcde-fghij"), Status: github.String("offline"), Busy: github.Bool(false)},
+		// Offline but still backed by a pod
+		{ID: github.Int64(2), Name: github.String("example-bcdef-ghijk"), Status: github.String("offline"), Busy: github.Bool(false)},
+		// Online
+		{ID: github.Int64(3), Name: github.String("example-cdefg-hijkl"), Status: github.String("online"), Busy: github.Bool(false)},
+		// Offline but stuck busy
+		{ID: github.Int64(4), Name: github.String("example-defgh-ijklm"), Status: github.String("offline"), Busy: github.Bool(true)},
+		// Belongs to another runner deployment whose name has the same prefix
+		{ID: github.Int64(5), Name: github.String("example-foo-efghi-jklmn"), Status: github.String("offline"), Busy: github.Bool(false)},
+	}
+
+	var removedIDs []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+		j, err := json.Marshal(github.Runners{TotalCount: len(runners), Runners: runners})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+	mux.HandleFunc("/repos/test/valid/actions/runners/", func(w http.ResponseWriter, req *http.Request) {
+		removedIDs = append(removedIDs, req.URL.Path[len("/repos/test/valid/actions/runners/"):])
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository: "test/valid",
+					},
+				},
+			},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-bcdef-ghijk",
+			Namespace: "default",
+			Labels: map[string]string{
+				LabelKeyRunnerDeploymentName: "example",
+			},
+		},
+	}
+
+	collector := &OfflineRunnerCollector{
+		Client:       fake.NewClientBuilder().WithScheme(sc).WithObjects(pod).Build(),
+		Log:          zap.New(func(o *zap.Options) { o.Development = true }),
+		GitHubClient: newGithubClient(server),
+		GracePeriod:  5 * time.Minute,
+	}
+
+	scope := offlineRunnerScopeForRunnerDeployment(rd)
+	now := time.Now()
+
+	removed, err := collector.collect(context.Background(), collector.Log, scope, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(removed) != 0 || len(removedIDs) != 0 {
+		t.Fatalf("expected no runners to be removed within the grace period, but got %v", removed)
+	}
+
+	removed, err = collector.collect(context.Background(), collector.Log, scope, now.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"example-abcde-fghij"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("unexpected removed runners: want %v, got %v", want, removed)
+	}
+
+	if want := []string{"1"}; !reflect.DeepEqual(removedIDs, want) {
+		t.Errorf("unexpected removed runner IDs: want %v, got %v", want, removedIDs)
+	}
+}
+
+func TestOfflineRunnerCollector_ForgetsDeletedOwner(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+	other := types.NamespacedName{Namespace: "default", Name: "other"}
+
+	collector := &OfflineRunnerCollector{
+		Client: fake.NewClientBuilder().WithScheme(sc).Build(),
+		Log:    zap.New(func(o *zap.Options) { o.Development = true }),
+		offlineSince: map[types.NamespacedName]map[int64]time.Time{
+			key:   {1: time.Now()},
+			other: {2: time.Now()},
+		},
+	}
+
+	if _, err := collector.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := collector.offlineSince[key]; ok {
+		t.Errorf("expected the runners of the deleted runner deployment to be forgotten")
+	}
+
+	if _, ok := collector.offlineSince[other]; !ok {
+		t.Errorf("expected the runners of the other runner deployment to be kept")
+	}
+}
+
+func TestOfflineRunnerScopeForRunnerSet(t *testing.T) {
+	rs := v1alpha1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+	}
+
+	scope := offlineRunnerScopeForRunnerSet(rs)
+
+	for name, want := range map[string]bool{
+		"example-0":                 true,
+		"example-12":                true,
+		"example-abcde-fghij":       false,
+		"example-registration-only": false,
+		"other-0":                   false,
+	} {
+		if got := scope.runnerNamePattern.MatchString(name); got != want {
+			t.Errorf("unexpected match result for %q: want %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestOfflineRunnerScopeForRunnerDeployment(t *testing.T) {
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+	}
+
+	scope := offlineRunnerScopeForRunnerDeployment(rd)
+
+	for name, want := range map[string]bool{
+		"example-abcde-fghij":             true,
+		"example-abcde-5c7b9f8d4":         true,
+		"example-abcde-registration-only": false,
+		"example-0":                       false,
+		"other-abcde-fghij":               false,
+	} {
+		if got := scope.runnerNamePattern.MatchString(name); got != want {
+			t.Errorf("unexpected match result for %q: want %v, got %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// githubDotComOIDCIssuer is the issuer of the OIDC tokens for the runners registered to GitHub.com
+	githubDotComOIDCIssuer = "https://token.actions.githubusercontent.com"
+
+	oidcCAVolumeName = "oidc-ca"
+	oidcCAMountPath  = "/etc/actions-runner-controller/oidc"
+)
+
+// newOIDCConfig returns the environment variables, volumes, and volume mounts for the runner container
+// that expose the OIDC configuration to workflows.
+// The issuer is validated against the GitHub endpoint, because the tokens are always issued by the token service of
+// the GitHub endpoint the runner is registered to, and other issuers would only result in the federation failing at job run time.
+func newOIDCConfig(oidc v1alpha1.OIDCConfig, githubBaseURL string) ([]corev1.EnvVar, []corev1.Volume, []corev1.VolumeMount, error) {
+	defaultIssuer, err := defaultOIDCIssuer(githubBaseURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	issuer := strings.TrimSuffix(oidc.Issuer, "/")
+	if issuer == "" {
+		issuer = defaultIssuer
+	} else if issuer != defaultIssuer && !strings.HasPrefix(issuer, defaultIssuer+"/") {
+		return nil, nil, nil, fmt.Errorf(
+			"oidc issuer %q doesn't belong to the GitHub endpoint %s. It must be %s or a URL under it",
+			oidc.Issuer, githubBaseURL, defaultIssuer,
+		)
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "ACTIONS_ID_TOKEN_ISSUER",
+			Value: issuer,
+		},
+	}
+
+	if oidc.Audience != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "ACTIONS_ID_TOKEN_AUDIENCE",
+			Value: oidc.Audience,
+		})
+	}
+
+	if oidc.CASecretName == "" {
+		return env, nil, nil, nil
+	}
+
+	caFile := oidcCAMountPath + "/ca.crt"
+
+	env = append(env,
+		corev1.EnvVar{
+			Name:  "ACTIONS_ID_TOKEN_CA_FILE",
+			Value: caFile,
+		},
+		corev1.EnvVar{
+			Name:  "NODE_EXTRA_CA_CERTS",
+			Value: caFile,
+		},
+	)
+
+	volumes := []corev1.Volume{
+		{
+			Name: oidcCAVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: oidc.CASecretName},
+			},
+		},
+	}
+
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      oidcCAVolumeName,
+			MountPath: oidcCAMountPath,
+			ReadOnly:  true,
+		},
+	}
+
+	return env, volumes, volumeMounts, nil
+}
+
+// defaultOIDCIssuer returns the issuer of the OIDC tokens for the runners registered to the GitHub endpoint,
+// which is GitHub.com's token service or the one hosted by GitHub Enterprise Server.
+func defaultOIDCIssuer(githubBaseURL string) (string, error) {
+	u, err := url.Parse(githubBaseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing github url %q: %w", githubBaseURL, err)
+	}
+
+	if u.Host == "github.com" {
+		return githubDotComOIDCIssuer, nil
+	}
+
+	return fmt.Sprintf("%s://%s%s/_services/token", u.Scheme, u.Host, strings.TrimSuffix(u.Path, "/")), nil
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultPlaceholderImage     = "k8s.gcr.io/pause:3.6"
+	DefaultPlaceholdersLookback = 5 * time.Minute
+	DefaultPlaceholdersHorizon  = 3 * time.Minute
+
+	// LabelKeyPlaceholdersFor is the label that has the name of the HorizontalRunnerAutoscaler on its placeholder pods.
+	LabelKeyPlaceholdersFor = "actions-runner-controller/placeholders-for"
+
+	// placeholdersSampleInterval is the minimum interval between the samples of the reserved replicas,
+	// which bounds the size of the status.
+	placeholdersSampleInterval = time.Minute
+)
+
+// computePlaceholdersStatus forecasts the number of runners needed within the horizon by extrapolating the growth of
+// the reserved replicas within the lookback period, and sizes the placeholder pods to it.
+//
+// The growth, rather than the reserved replicas themselves, is extrapolated because the runners for the jobs already
+// reserved are being created anyway. Only the runners beyond them are likely to need new nodes.
+//
+// The forecast is raised to the static buffer of MinReplicas and to the reserve of ReservePercent of the desired replicas,
+// whichever is the largest, so that the buffers aren't added on top of the forecast of the same demand.
+func computePlaceholdersStatus(hra v1alpha1.HorizontalRunnerAutoscaler, reserved, desiredReplicas int, class string, now time.Time) v1alpha1.PlaceholdersStatus {
+	spec := hra.Spec.Placeholders
+
+	lookback := DefaultPlaceholdersLookback
+	if spec.Lookback != nil && spec.Lookback.Duration > 0 {
+		lookback = spec.Lookback.Duration
+	}
+
+	horizon := DefaultPlaceholdersHorizon
+	if spec.Horizon != nil {
+		horizon = spec.Horizon.Duration
+	}
+
+	status := v1alpha1.PlaceholdersStatus{Class: class}
+
+	lowest := reserved
+
+	if prev := hra.Status.Placeholders; prev != nil {
+		for _, s := range prev.Samples {
+			if !s.Time.Add(lookback).After(now) {
+				continue
+			}
+
+			status.Samples = append(status.Samples, s)
+
+			if s.Replicas < lowest {
+				lowest = s.Replicas
+			}
+		}
+	}
+
+	if n := len(status.Samples); n == 0 || !now.Before(status.Samples[n-1].Time.Add(placeholdersSampleInterval)) {
+		status.Samples = append(status.Samples, v1alpha1.ReservedReplicasSample{Time: metav1.Time{Time: now}, Replicas: reserved})
+	}
+
+	replicas := int(math.Ceil(float64(reserved-lowest) * horizon.Seconds() / lookback.Seconds()))
+
+	if spec.MinReplicas != nil && replicas < *spec.MinReplicas {
+		replicas = *spec.MinReplicas
+	}
+
+	if spec.ReservePercent != nil && *spec.ReservePercent > 0 {
+		if reserve := int(math.Ceil(float64(desiredReplicas*(*spec.ReservePercent)) / 100)); replicas < reserve {
+			replicas = reserve
+		}
+	}
+
+	maxReplicas := spec.MaxReplicas
+	if maxReplicas == nil {
+		maxReplicas = hra.Spec.MaxReplicas
+	}
+
+	if maxReplicas != nil && replicas > *maxReplicas {
+		replicas = *maxReplicas
+	}
+
+	status.Replicas = replicas
+
+	return status
+}
+
+// runnerPodSpecFromRD returns the containers and the scheduling constraints of the runner pods of the runner deployment,
+// which are what the placeholder pods need to mimic to be scheduled onto the same class of nodes.
+func runnerPodSpecFromRD(rd v1alpha1.RunnerDeployment) corev1.PodSpec {
+	spec := rd.Spec.Template.Spec
+
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers:   spec.Containers,
+			NodeSelector: spec.NodeSelector,
+			Affinity:     spec.Affinity,
+			Tolerations:  spec.Tolerations,
+		},
+	}
+
+	if len(spec.Containers) == 0 {
+		pod.Spec.Containers = []corev1.Container{{Name: containerName, Resources: spec.Resources}}
+
+		if !spec.IsWindows() && (spec.DockerEnabled == nil || *spec.DockerEnabled) && (spec.DockerdWithinRunnerContainer == nil || !*spec.DockerdWithinRunnerContainer) {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "docker", Resources: spec.DockerdContainerResources})
+		}
+	}
+
+	for _, c := range spec.Sidecars {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: c.Name, Resources: c.Resources})
+	}
+
+	setRunnerPlatformNodeSelector(&pod, spec.RunnerConfig)
+	setRunnerGPUs(&pod, spec.RunnerConfig)
+
+	return pod.Spec
+}
+
+// runnerPodSpecFromRS is runnerPodSpecFromRD for runner sets.
+func runnerPodSpecFromRS(rs v1alpha1.RunnerSet) corev1.PodSpec {
+	pod := corev1.Pod{
+		Spec: *rs.Spec.Template.Spec.DeepCopy(),
+	}
+
+	setRunnerPlatformNodeSelector(&pod, rs.Spec.RunnerConfig)
+	setRunnerGPUs(&pod, rs.Spec.RunnerConfig)
+
+	return pod.Spec
+}
+
+// setRunnerPlatformNodeSelector adds the node selectors that newRunnerPod adds for the OS and the architecture of the runner.
+func setRunnerPlatformNodeSelector(pod *corev1.Pod, runnerConfig v1alpha1.RunnerConfig) {
+	if runnerConfig.IsWindows() {
+		setDefaultNodeSelector(pod, corev1.LabelOSStable, string(corev1.Windows))
+	}
+
+	if arch := runnerArch(runnerConfig, pod.Spec.NodeSelector); arch != "" {
+		setDefaultNodeSelector(pod, corev1.LabelArchStable, arch)
+	}
+}
+
+// setRunnerGPUs adds the GPU requests and the scheduling constraints that newRunnerPod adds for the GPUs of the runner.
+func setRunnerGPUs(pod *corev1.Pod, runnerConfig v1alpha1.RunnerConfig) {
+	gpu := runnerConfig.GPU
+	if gpu == nil {
+		return
+	}
+
+	containers := append([]corev1.Container{}, pod.Spec.Containers...)
+	for i := range containers {
+		if containers[i].Name == containerName {
+			setGPURequests(&containers[i], *gpu)
+		}
+	}
+	pod.Spec.Containers = containers
+
+	setGPUScheduling(pod, *gpu)
+}
+
+// placeholderResources returns the sum of the resource requests of the containers.
+// Kubernetes defaults the request of a resource to its limit, so the limit is used when the request is missing.
+func placeholderResources(podSpec corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+
+	for _, c := range podSpec.Containers {
+		for name, q := range c.Resources.Limits {
+			if _, ok := c.Resources.Requests[name]; ok {
+				continue
+			}
+
+			sum := requests[name]
+			sum.Add(q)
+			requests[name] = sum
+		}
+
+		for name, q := range c.Resources.Requests {
+			sum := requests[name]
+			sum.Add(q)
+			requests[name] = sum
+		}
+	}
+
+	return requests
+}
+
+// placeholderClass describes the class of nodes the placeholder pods are scheduled onto,
+// like "cpu=2,memory=4Gi,kubernetes.io/arch=arm64".
+func placeholderClass(podSpec corev1.PodSpec) string {
+	var class []string
+
+	requests := placeholderResources(podSpec)
+
+	var names []string
+	for name := range requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		q := requests[corev1.ResourceName(name)]
+		class = append(class, fmt.Sprintf("%s=%s", name, q.String()))
+	}
+
+	var keys []string
+	for k := range podSpec.NodeSelector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		class = append(class, fmt.Sprintf("%s=%s", k, podSpec.NodeSelector[k]))
+	}
+
+	return strings.Join(class, ",")
+}
+
+func placeholdersDeploymentName(hra v1alpha1.HorizontalRunnerAutoscaler) string {
+	return hra.Name + "-placeholders"
+}
+
+func newPlaceholdersDeployment(hra v1alpha1.HorizontalRunnerAutoscaler, runnerPodSpec corev1.PodSpec, replicas int) *appsv1.Deployment {
+	spec := hra.Spec.Placeholders
+
+	image := spec.Image
+	if image == "" {
+		image = DefaultPlaceholderImage
+	}
+
+	labels := map[string]string{LabelKeyPlaceholdersFor: hra.Name}
+
+	v := int32(replicas)
+
+	// Placeholders have nothing to clean up, so that they are deleted immediately on preemption
+	var terminationGracePeriodSeconds int64
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      placeholdersDeploymentName(hra),
+			Namespace: hra.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &v,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					PriorityClassName:             spec.PriorityClassName,
+					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
+					NodeSelector:                  runnerPodSpec.NodeSelector,
+					Affinity:                      runnerPodSpec.Affinity,
+					Tolerations:                   runnerPodSpec.Tolerations,
+					Containers: []corev1.Container{
+						{
+							Name:      "placeholder",
+							Image:     image,
+							Resources: corev1.ResourceRequirements{Requests: placeholderResources(runnerPodSpec)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// syncPlaceholders creates or updates the deployment of the placeholder pods of the HRA.
+func (r *HorizontalRunnerAutoscalerReconciler) syncPlaceholders(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, runnerPodSpec corev1.PodSpec, replicas int) error {
+	desired := newPlaceholdersDeployment(hra, runnerPodSpec, replicas)
+
+	if err := ctrl.SetControllerReference(&hra, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	var current appsv1.Deployment
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current); kerrors.IsNotFound(err) {
+		if err := r.Create(ctx, desired); err != nil {
+			return fmt.Errorf("creating placeholders deployment: %w", err)
+		}
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// DeepDerivative ignores the fields defaulted by the API server
+	if *current.Spec.Replicas == *desired.Spec.Replicas && equality.Semantic.DeepDerivative(desired.Spec.Template, current.Spec.Template) {
+		return nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Spec.Replicas = desired.Spec.Replicas
+	updated.Spec.Template = desired.Spec.Template
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&current)); err != nil {
+		return fmt.Errorf("patching placeholders deployment to have %d replicas: %w", replicas, err)
+	}
+
+	return nil
+}
+
+// deletePlaceholders deletes the deployment of the placeholder pods after spec.placeholders is removed from the HRA.
+func (r *HorizontalRunnerAutoscalerReconciler) deletePlaceholders(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) error {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: hra.Namespace,
+			Name:      placeholdersDeploymentName(hra),
+		},
+	}
+
+	if err := r.Delete(ctx, d); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("deleting placeholders deployment: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestComputePlaceholdersStatus(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	maxReplicas := 5
+
+	sample := func(ago time.Duration, replicas int) v1alpha1.ReservedReplicasSample {
+		return v1alpha1.ReservedReplicasSample{Time: metav1.Time{Time: now.Add(-ago)}, Replicas: replicas}
+	}
+
+	newHRA := func(minReplicas *int, samples ...v1alpha1.ReservedReplicasSample) v1alpha1.HorizontalRunnerAutoscaler {
+		hra := v1alpha1.HorizontalRunnerAutoscaler{
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MaxReplicas: &maxReplicas,
+				Placeholders: &v1alpha1.PlaceholdersSpec{
+					Lookback:    &metav1.Duration{Duration: 4 * time.Minute},
+					Horizon:     &metav1.Duration{Duration: 2 * time.Minute},
+					MinReplicas: minReplicas,
+				},
+			},
+		}
+
+		if len(samples) > 0 {
+			hra.Status.Placeholders = &v1alpha1.PlaceholdersStatus{Samples: samples}
+		}
+
+		return hra
+	}
+
+	one := 1
+
+	withReservePercent := func(hra v1alpha1.HorizontalRunnerAutoscaler, percent int) v1alpha1.HorizontalRunnerAutoscaler {
+		hra.Spec.Placeholders.ReservePercent = &percent
+		return hra
+	}
+
+	testcases := []struct {
+		name     string
+		hra      v1alpha1.HorizontalRunnerAutoscaler
+		reserved int
+		desired  int
+		want     v1alpha1.PlaceholdersStatus
+	}{
+		{
+			name:     "first sample",
+			hra:      newHRA(nil),
+			reserved: 3,
+			want: v1alpha1.PlaceholdersStatus{
+				Samples: []v1alpha1.ReservedReplicasSample{sample(0, 3)},
+			},
+		},
+		{
+			name:     "growing demand",
+			hra:      newHRA(nil, sample(3*time.Minute, 1), sample(2*time.Minute, 3)),
+			reserved: 6,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 3,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(3*time.Minute, 1), sample(2*time.Minute, 3), sample(0, 6)},
+			},
+		},
+		{
+			name:     "capped by maxReplicas",
+			hra:      newHRA(nil, sample(3*time.Minute, 0)),
+			reserved: 20,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 5,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(3*time.Minute, 0), sample(0, 20)},
+			},
+		},
+		{
+			name:     "settled demand keeps the static buffer",
+			hra:      newHRA(&one, sample(5*time.Minute, 0), sample(30*time.Second, 6)),
+			reserved: 6,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 1,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(30*time.Second, 6)},
+			},
+		},
+		{
+			name:    "reserve percent of the desired replicas rounded up",
+			hra:     withReservePercent(newHRA(&one), 20),
+			desired: 11,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 3,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(0, 0)},
+			},
+		},
+		{
+			name:     "forecast above the reserve",
+			hra:      withReservePercent(newHRA(nil, sample(3*time.Minute, 1), sample(2*time.Minute, 3)), 10),
+			reserved: 6,
+			desired:  10,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 3,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(3*time.Minute, 1), sample(2*time.Minute, 3), sample(0, 6)},
+			},
+		},
+		{
+			name:    "reserve capped by maxReplicas",
+			hra:     withReservePercent(newHRA(nil), 50),
+			desired: 20,
+			want: v1alpha1.PlaceholdersStatus{
+				Replicas: 5,
+				Samples:  []v1alpha1.ReservedReplicasSample{sample(0, 0)},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := computePlaceholdersStatus(tc.hra, tc.reserved, tc.desired, "", now)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected status (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestRunnerPodSpecFromRD_PlaceholderClass(t *testing.T) {
+	rd := v1alpha1.RunnerDeployment{
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Arch: v1alpha1.RunnerArchARM64},
+					RunnerPodSpec: v1alpha1.RunnerPodSpec{
+						NodeSelector: map[string]string{"node-pool": "runners"},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")},
+							Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+						DockerdContainerResources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podSpec := runnerPodSpecFromRD(rd)
+
+	if got, want := placeholderClass(podSpec), "cpu=2,memory=4Gi,kubernetes.io/arch=arm64,node-pool=runners"; got != want {
+		t.Errorf("unexpected class: want %q, got %q", want, got)
+	}
+
+	if rd.Spec.Template.Spec.NodeSelector["kubernetes.io/arch"] != "" {
+		t.Errorf("expected the runner deployment to be left unchanged, got %v", rd.Spec.Template.Spec.NodeSelector)
+	}
+}
+
+func TestSyncPlaceholders(t *testing.T) {
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "hra-uid"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			Placeholders: &v1alpha1.PlaceholdersSpec{PriorityClassName: "placeholder"},
+		},
+	}
+
+	podSpec := corev1.PodSpec{
+		NodeSelector: map[string]string{"kubernetes.io/arch": "amd64"},
+		Containers: []corev1.Container{
+			{Name: "runner", Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}},
+		},
+	}
+
+	ctx := context.Background()
+	client := fake.NewFakeClientWithScheme(sc)
+	r := &HorizontalRunnerAutoscalerReconciler{Client: client, Scheme: sc}
+	key := types.NamespacedName{Namespace: "default", Name: "example-placeholders"}
+
+	for _, replicas := range []int{2, 4} {
+		if err := r.syncPlaceholders(ctx, hra, podSpec, replicas); err != nil {
+			t.Fatal(err)
+		}
+
+		var d appsv1.Deployment
+		if err := client.Get(ctx, key, &d); err != nil {
+			t.Fatal(err)
+		}
+
+		if *d.Spec.Replicas != int32(replicas) {
+			t.Errorf("unexpected replicas: want %d, got %d", replicas, *d.Spec.Replicas)
+		}
+
+		pod := d.Spec.Template.Spec
+		if pod.PriorityClassName != "placeholder" || pod.NodeSelector["kubernetes.io/arch"] != "amd64" || pod.Containers[0].Image != DefaultPlaceholderImage {
+			t.Errorf("unexpected pod spec: %+v", pod)
+		}
+
+		if cpu := pod.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "1" {
+			t.Errorf("unexpected cpu request: %s", cpu.String())
+		}
+
+		if !metav1.IsControlledBy(&d, &hra) {
+			t.Errorf("expected the deployment to be owned by the hra: %+v", d.OwnerReferences)
+		}
+	}
+
+	if err := r.deletePlaceholders(ctx, hra); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, key, &appsv1.Deployment{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the deployment to be deleted, got %v", err)
+	}
+}
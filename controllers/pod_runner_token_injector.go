@@ -31,6 +31,9 @@ type PodRunnerTokenInjector struct {
 	Recorder     record.EventRecorder
 	GitHubClient *github.Client
 	decoder      *admission.Decoder
+
+	// MultiGitHubClient provides the clients of the runner pods annotated with AnnotationKeyGitHubAPICredsSecret. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
 }
 
 func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
@@ -66,7 +69,18 @@ func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Reque
 		return newEmptyResponse()
 	}
 
-	rt, err := t.GitHubClient.GetRegistrationToken(context.Background(), enterprise, org, repo, pod.Name)
+	// The namespace of the pod being created is set only in the request
+	if pod.Namespace == "" {
+		pod.Namespace = req.Namespace
+	}
+
+	ghc, err := gitHubClientForPod(ctx, t.MultiGitHubClient, t.GitHubClient, pod)
+	if err != nil {
+		t.Log.Error(err, "Failed to get the github client of the runner pod")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	rt, err := ghc.GetRegistrationToken(context.Background(), enterprise, org, repo, pod.Name)
 	if err != nil {
 		t.Log.Error(err, "Failed to get new registration token")
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultPredictiveLookahead = 10 * time.Minute
+
+	PredictiveSeasonDaily  = "Daily"
+	PredictiveSeasonWeekly = "Weekly"
+
+	// predictiveSlotDuration is the resolution of the history of the demand, which bounds the size of the status
+	// to 48 slots for Daily and 336 slots for Weekly.
+	predictiveSlotDuration = 30 * time.Minute
+
+	// predictiveSampleInterval is the maximum interval between the observations of the demand,
+	// so that every slot is observed and the scale target is pre-scaled in time even without other triggers of reconciliation.
+	predictiveSampleInterval = 5 * time.Minute
+)
+
+// computePredictiveStatus records the demand, which is the replicas suggested by the metrics plus the capacity reservations,
+// into the slot of the season it's observed in, and predicts the demand within the lookahead from the slots of the past seasons.
+//
+// The peak of the demand within each slot is recorded, rather than the average, because the runners need to be ready for the peak.
+func computePredictiveStatus(hra v1alpha1.HorizontalRunnerAutoscaler, demand int, now time.Time) v1alpha1.PredictiveStatus {
+	spec := hra.Spec.Predictive
+
+	season := spec.Season
+	if season == "" {
+		season = PredictiveSeasonDaily
+	}
+
+	lookahead := DefaultPredictiveLookahead
+	if spec.Lookahead != nil {
+		lookahead = spec.Lookahead.Duration
+	}
+
+	slot := predictiveSlot(season, now)
+
+	var status v1alpha1.PredictiveStatus
+
+	if prev := hra.Status.Predictive; prev != nil && prev.Season == season && len(prev.Slots) == predictiveSlots(season) {
+		status = *prev.DeepCopy()
+
+		if slot != status.CurrentSlot || now.Sub(status.LastSampleTime.Time) >= predictiveSlotDuration {
+			if v := status.Slots[status.CurrentSlot]; v < 0 {
+				status.Slots[status.CurrentSlot] = status.CurrentPeak
+			} else {
+				status.Slots[status.CurrentSlot] = (v + status.CurrentPeak + 1) / 2
+			}
+
+			status.CurrentPeak = 0
+		}
+	} else {
+		status = v1alpha1.PredictiveStatus{
+			Season: season,
+			Slots:  make([]int, predictiveSlots(season)),
+		}
+
+		for i := range status.Slots {
+			status.Slots[i] = -1
+		}
+	}
+
+	status.CurrentSlot = slot
+	status.LastSampleTime = metav1.Time{Time: now}
+
+	if demand > status.CurrentPeak {
+		status.CurrentPeak = demand
+	}
+
+	// The slots within the lookahead, including the current one, tell the demand of the past seasons at the time
+	predicted := 0
+
+	for t := now; ; t = t.Add(predictiveSlotDuration) {
+		if t.After(now.Add(lookahead)) {
+			t = now.Add(lookahead)
+		}
+
+		if v := status.Slots[predictiveSlot(season, t)]; v > predicted {
+			predicted = v
+		}
+
+		if !t.Before(now.Add(lookahead)) {
+			break
+		}
+	}
+
+	status.PredictedReplicas = predicted
+
+	return status
+}
+
+// predictiveSlot returns the index of the slot of the season that the time falls in.
+func predictiveSlot(season string, t time.Time) int {
+	t = t.UTC()
+
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	if season == PredictiveSeasonWeekly {
+		start = start.AddDate(0, 0, -int(t.Weekday()))
+	}
+
+	return int(t.Sub(start) / predictiveSlotDuration)
+}
+
+func predictiveSlots(season string) int {
+	if season == PredictiveSeasonWeekly {
+		return int(7 * 24 * time.Hour / predictiveSlotDuration)
+	}
+
+	return int(24 * time.Hour / predictiveSlotDuration)
+}
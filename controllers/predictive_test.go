@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestComputePredictiveStatus(t *testing.T) {
+	// A Tuesday
+	start := time.Date(2022, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			Predictive: &v1alpha1.PredictiveSpec{
+				Enabled:   true,
+				Lookahead: &metav1.Duration{Duration: 40 * time.Minute},
+			},
+		},
+	}
+
+	observe := func(now time.Time, demand int) v1alpha1.PredictiveStatus {
+		t.Helper()
+
+		status := computePredictiveStatus(hra, demand, now)
+		hra.Status.Predictive = &status
+
+		return status
+	}
+
+	// The first day has no history to predict from
+	for now := start; now.Before(start.Add(24 * time.Hour)); now = now.Add(predictiveSampleInterval) {
+		demand := 0
+		if now.Hour() == 9 {
+			demand = 8
+		}
+
+		if got := observe(now, demand).PredictedReplicas; got != 0 && now.Hour() != 9 {
+			t.Fatalf("unexpected prediction at %s: want 0, got %d", now, got)
+		}
+	}
+
+	status := *hra.Status.Predictive
+
+	if n := len(status.Slots); n != 48 {
+		t.Fatalf("unexpected number of slots: want 48, got %d", n)
+	}
+
+	if got := status.Slots[18]; got != 8 {
+		t.Errorf("unexpected peak of 09:00: want 8, got %d", got)
+	}
+
+	if got := status.Slots[17]; got != 0 {
+		t.Errorf("unexpected peak of 08:30: want 0, got %d", got)
+	}
+
+	// The second day is pre-scaled for the peak of the first day from 08:20, 40 minutes ahead
+	if got := observe(start.Add(24*time.Hour+15*time.Minute), 0).PredictedReplicas; got != 0 {
+		t.Errorf("unexpected prediction at 08:15: want 0, got %d", got)
+	}
+
+	if got := observe(start.Add(24*time.Hour+20*time.Minute), 0).PredictedReplicas; got != 8 {
+		t.Errorf("unexpected prediction at 08:20: want 8, got %d", got)
+	}
+
+	// The peak of the second day is averaged with the first day's
+	observe(start.Add(25*time.Hour), 2)
+	status = observe(start.Add(25*time.Hour+30*time.Minute), 0)
+
+	if got := status.Slots[18]; got != 5 {
+		t.Errorf("unexpected averaged peak of 09:00: want 5, got %d", got)
+	}
+
+	// Changing the season restarts the history
+	hra.Spec.Predictive.Season = PredictiveSeasonWeekly
+
+	status = observe(start.Add(26*time.Hour), 1)
+
+	if n := len(status.Slots); n != 336 {
+		t.Fatalf("unexpected number of weekly slots: want 336, got %d", n)
+	}
+
+	if got := status.PredictedReplicas; got != 0 {
+		t.Errorf("unexpected prediction after changing the season: want 0, got %d", got)
+	}
+
+	if got, want := status.CurrentSlot, 3*48+20; got != want {
+		t.Errorf("unexpected weekly slot of Wednesday 10:00: want %d, got %d", want, got)
+	}
+}
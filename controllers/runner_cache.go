@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// EnvVarRunnerCacheURL is the base URL of the cache server of the runner.
+// The ARC job hooks pass it to the steps of the job as ACTIONS_CACHE_URL, which actions/cache uses instead of the cache of GitHub.
+// See runner/hooks/job-started.sh.
+const EnvVarRunnerCacheURL = "RUNNER_CACHE_URL"
+
+// withDefaultRunnerCache returns the runner config that uses the default cache server of the controller,
+// unless the runner config has its own cache config.
+func withDefaultRunnerCache(runnerSpec v1alpha1.RunnerConfig, defaultURL string) v1alpha1.RunnerConfig {
+	if runnerSpec.Cache != nil || defaultURL == "" {
+		return runnerSpec
+	}
+
+	runnerSpec.Cache = &v1alpha1.RunnerCacheConfig{URL: defaultURL}
+
+	return runnerSpec
+}
+
+// runnerCacheURL returns the base URL of the cache server of the runner, or an empty string when the runner uses the cache of GitHub.
+// The URL always ends with a slash, because actions/cache appends the paths of the cache API to it as-is.
+func runnerCacheURL(cache *v1alpha1.RunnerCacheConfig) string {
+	if cache == nil || cache.Disabled || cache.URL == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(cache.URL, "/") + "/"
+}
@@ -30,16 +30,21 @@ import (
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/anomaly"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
 )
 
 const (
@@ -50,6 +55,18 @@ const (
 
 	retryDelayOnGitHubAPIRateLimitError = 30 * time.Second
 
+	// retryDelayOnTransientGitHubAPIError is how long to wait before retrying the reconciliation
+	// that failed due to a transient GitHub API error that persisted through the GitHub client's retries
+	retryDelayOnTransientGitHubAPIError = 10 * time.Second
+
+	// windowsRunAsUserName is the default user of the Windows runner containers
+	windowsRunAsUserName = "ContainerAdministrator"
+
+	// registrationTimeout is how long a runner pod can take to register the runner to GitHub before it's recreated
+	registrationTimeout = 10 * time.Minute
+
+	runnerStartupProbePeriodSeconds = 5
+
 	// This is an annotation internal to actions-runner-controller and can change in backward-incompatible ways
 	annotationKeyRegistrationOnly = "actions-runner-controller/registration-only"
 
@@ -66,12 +83,30 @@ type RunnerReconciler struct {
 	Scheme                      *runtime.Scheme
 	GitHubClient                *github.Client
 	RunnerImage                 string
+	ARM64RunnerImage            string
+	WindowsRunnerImage          string
 	RunnerImagePullSecrets      []string
 	DockerImage                 string
 	DockerRegistryMirror        string
 	Name                        string
+	ControllerOptions           ControllerOptions
 	RegistrationRecheckInterval time.Duration
 	RegistrationRecheckJitter   time.Duration
+	// LogRateLimiter suppresses the log lines that repeat on every reconciliation of every runner
+	LogRateLimiter *logging.RateLimiter
+	// AnomalyNotifier is notified of the runners that failed to register to GitHub. Can be nil.
+	AnomalyNotifier *anomaly.Notifier
+	// DiagnosticsTailLines is the number of the last lines of the runner and docker container logs collected
+	// when the runner pod fails to register the runner or crash-loops. Zero disables the collection.
+	DiagnosticsTailLines int64
+	// DiagnosticsConfigMaps makes the collected logs written into a ConfigMap per runner, in addition to the event.
+	DiagnosticsConfigMaps bool
+	// KubeClient gets the logs of the runner pods for the diagnostics, which the controller-runtime client can't.
+	KubeClient kubernetes.Interface
+	// RunnerCacheURL is the cache server of the runners without their own cache config. Empty for the cache of GitHub.
+	RunnerCacheURL string
+	// MultiGitHubClient provides the clients of the runners with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners,verbs=get;list;watch;create;update;patch;delete
@@ -79,7 +114,12 @@ type RunnerReconciler struct {
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=create
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch
 
 func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("runner", req.NamespacedName)
@@ -127,8 +167,9 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 				return ctrl.Result{Requeue: true}, nil
 			}
-		} else if err := r.Delete(ctx, &pod); err != nil {
-			if !kerrors.IsNotFound(err) {
+		} else if metav1.IsControlledBy(&pod, &runner) {
+			// The pod of the same name that isn't controlled by the runner belongs to the previous runner of the name
+			if err := r.Delete(ctx, &pod); err != nil && !kerrors.IsNotFound(err) {
 				log.Info(fmt.Sprintf("Retrying soon as we failed to delete registration-only runner pod: %v", err))
 
 				return ctrl.Result{Requeue: true}, nil
@@ -142,13 +183,27 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		log.Error(err, "Failed to get the GitHub client of the runner")
+		return ctrl.Result{}, err
+	}
+
 	var pod corev1.Pod
 	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
 		if !kerrors.IsNotFound(err) {
 			// An error ocurred
 			return ctrl.Result{}, err
 		}
-		return r.processRunnerCreation(ctx, runner, log)
+		return r.processRunnerCreation(ctx, runner, log, ghc)
+	}
+
+	// The pod of the same name can still be terminating after its runner has been deleted and recreated,
+	// in which case it's left alone until it's gone rather than adopted by the new runner.
+	if !metav1.IsControlledBy(&pod, &runner) {
+		log.Info("Waiting for the pod of the previous runner of the same name to be deleted", "pod", pod.Name)
+
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
 	// Pod already exists
@@ -157,6 +212,19 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return r.processRunnerPodDeletion(ctx, runner, log, pod)
 	}
 
+	r.checkLogForwarderHealth(runner, pod, log)
+
+	r.checkCrashLoop(ctx, runner, pod, log)
+
+	if err := r.stampChargebackMetadata(ctx, runner, pod, log); err != nil {
+		log.Error(err, "Failed to stamp chargeback labels on the runner pod")
+	}
+
+	if err := r.updateHealthCondition(ctx, &runner, pod); err != nil {
+		log.Error(err, "Failed to update the healthy condition of the runner")
+		return ctrl.Result{}, err
+	}
+
 	// If pod has ended up succeeded we need to restart it
 	// Happens e.g. when dind is in runner and run completes
 	stopped := pod.Status.Phase == corev1.PodSucceeded
@@ -175,6 +243,26 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	// The watchdog cancelled the job exceeding maxJobDuration.
+	// The pod is recreated regardless of the exit code, so that the next job runs on a fresh runner.
+	jobTimeout := getJobTimeout(pod)
+	if jobTimeout != nil {
+		stopped = true
+	}
+
+	// The health check stopped the unhealthy runner after the job
+	healthReport := getRunnerHealthReport(pod)
+	if healthReport != nil {
+		stopped = true
+	}
+
+	// GitHub refused the runner for its outdated version.
+	// The pod is recreated so that it pulls the runner image again, which may have been upgraded in the meantime.
+	outdatedVersion := getOutdatedRunnerVersion(pod)
+	if outdatedVersion != "" {
+		stopped = true
+	}
+
 	restart := stopped
 
 	if registrationOnly && stopped {
@@ -186,30 +274,49 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		)
 	}
 
-	if updated, err := r.updateRegistrationToken(ctx, runner); err != nil {
+	if updated, err := r.updateRegistrationToken(ctx, ghc, runner); err != nil {
+		r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Failed to get a registration token for runner %s/%s: %v", runner.Namespace, runner.Name, err))
+
 		return ctrl.Result{}, err
 	} else if updated {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	newPod, err := r.newPod(runner)
+	newPod, err := r.newPod(runner, ghc.GithubBaseURL)
 	if err != nil {
 		log.Error(err, "Could not create pod")
 		return ctrl.Result{}, err
 	}
 
+	if err := r.degradeUnsupportedFeatures(ctx, log, runner, &newPod); err != nil {
+		log.Error(err, "Could not degrade the features unsupported by GitHub")
+		return ctrl.Result{}, err
+	}
+
 	if registrationOnly {
-		newPod.Spec.Containers[0].Env = append(
-			newPod.Spec.Containers[0].Env,
-			corev1.EnvVar{
-				Name:  "RUNNER_REGISTRATION_ONLY",
-				Value: "true",
-			},
-		)
+		for i := range newPod.Spec.Containers {
+			if newPod.Spec.Containers[i].Name != containerName {
+				continue
+			}
+
+			newPod.Spec.Containers[i].Env = append(
+				newPod.Spec.Containers[i].Env,
+				corev1.EnvVar{
+					Name:  "RUNNER_REGISTRATION_ONLY",
+					Value: "true",
+				},
+			)
+		}
 	}
 
 	var registrationRecheckDelay time.Duration
 
+	// registered is true when GitHub reported the runner as online, or busy running a job, on the registration check.
+	// It stays as-is when the check is skipped, so that the runner doesn't flip between ready and not ready.
+	registered := runner.Status.Ready
+
+	runnerID := runner.Status.RunnerID
+
 	// all checks done below only decide whether a restart is needed
 	// if a restart was already decided before, there is no need for the checks
 	// saving API calls and scary log messages
@@ -232,7 +339,9 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			// shorter than 1s
 			requeueAfter := nextCheckTime.Sub(now) - time.Second
 			if requeueAfter > 0 {
-				log.Info(
+				r.LogRateLimiter.Info(
+					log,
+					"registration_check_deferred",
 					fmt.Sprintf("Skipped registration check because it's deferred until %s. Retrying in %s at latest", nextCheckTime, requeueAfter),
 					"lastRegistrationCheckTime", lastCheckTime,
 					"registrationCheckInterval", registrationCheckInterval,
@@ -253,7 +362,8 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		notFound := false
 		offline := false
 
-		runnerBusy, err := r.GitHubClient.IsRunnerBusy(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
+		// The ID observed on the last check saves listing every runner in the scope just to find this one
+		runnerBusy, id, err := ghc.IsRunnerBusyByID(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name, runner.Status.RunnerID)
 
 		currentTime := time.Now()
 
@@ -280,10 +390,22 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 					return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
 				}
 
+				if github.IsTransient(err) {
+					log.Info(
+						fmt.Sprintf("Failed to check if runner is busy due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+						"error", err.Error(),
+					)
+
+					return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+				}
+
 				return ctrl.Result{}, err
 			}
 		}
 
+		// Zero when the runner isn't found, so that the next check lists the runners to find it re-registered with a new ID
+		runnerID = id
+
 		// See the `newPod` function called above for more information
 		// about when this hash changes.
 		curHash := pod.Labels[LabelKeyPodTemplateHash]
@@ -293,7 +415,6 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			restart = true
 		}
 
-		registrationTimeout := 10 * time.Minute
 		durationAfterRegistrationTimeout := currentTime.Sub(pod.CreationTimestamp.Add(registrationTimeout))
 		registrationDidTimeout := durationAfterRegistrationTimeout > 0
 
@@ -309,6 +430,10 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 					"configuredRegistrationTimeout", registrationTimeout,
 				)
 
+				r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Runner %s/%s failed to register itself to GitHub within %s", runner.Namespace, runner.Name, registrationTimeout))
+
+				r.collectDiagnostics(ctx, runner, pod, DiagnosticsReasonRegistrationTimeout, false, log)
+
 				restart = true
 			} else {
 				log.V(1).Info(
@@ -343,6 +468,10 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 						"configuredRegistrationTimeout", registrationTimeout,
 					)
 
+					r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Runner %s/%s stayed offline on GitHub for %s after its pod was created", runner.Namespace, runner.Name, registrationTimeout))
+
+					r.collectDiagnostics(ctx, runner, pod, DiagnosticsReasonRegistrationTimeout, false, log)
+
 					restart = true
 				}
 			} else {
@@ -353,6 +482,8 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			}
 		}
 
+		registered = !notFound && (!offline || runnerBusy)
+
 		if (notFound || (offline && !registrationOnly)) && !registrationDidTimeout {
 			registrationRecheckJitter := 10 * time.Second
 			if r.RegistrationRecheckJitter > 0 {
@@ -372,6 +503,8 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 			updated := runner.DeepCopy()
 			updated.Status.LastRegistrationCheckTime = &metav1.Time{Time: time.Now()}
+			updated.Status.Ready = false
+			updated.Status.RunnerID = runnerID
 
 			if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
 				log.Error(err, "Failed to update runner status for LastRegistrationCheckTime")
@@ -381,8 +514,10 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			return ctrl.Result{RequeueAfter: registrationRecheckDelay}, nil
 		}
 
-		if runner.Status.Phase != string(pod.Status.Phase) {
-			if pod.Status.Phase == corev1.PodRunning {
+		ready := registered && pod.Status.Phase == corev1.PodRunning
+
+		if runner.Status.Phase != string(pod.Status.Phase) || runner.Status.Ready != ready || runner.Status.RunnerID != runnerID {
+			if ready && !runner.Status.Ready {
 				// Seeing this message, you can expect the runner to become `Running` soon.
 				log.Info(
 					"Runner appears to have registered and running.",
@@ -394,9 +529,11 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			updated.Status.Phase = string(pod.Status.Phase)
 			updated.Status.Reason = pod.Status.Reason
 			updated.Status.Message = pod.Status.Message
+			updated.Status.Ready = ready
+			updated.Status.RunnerID = runnerID
 
 			if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
-				log.Error(err, "Failed to update runner status for Phase/Reason/Message")
+				log.Error(err, "Failed to update runner status for Phase/Reason/Message/Ready/RunnerID")
 				return ctrl.Result{}, err
 			}
 		}
@@ -411,8 +548,31 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	r.Recorder.Event(&runner, corev1.EventTypeNormal, "PodDeleted", fmt.Sprintf("Deleted pod '%s'", newPod.Name))
+
+	if jobTimeout != nil {
+		recordJobTimeout(r.Recorder, &runner, runner.Namespace, *jobTimeout)
+	}
+
+	if healthReport != nil {
+		recordRunnerRecycled(r.Recorder, &runner, *healthReport)
+	}
+
+	if outdatedVersion != "" {
+		recordRunnerVersionOutdated(r.Recorder, &runner, outdatedVersion)
+	}
 	log.Info("Deleted runner pod", "repository", runner.Spec.Repository)
 
+	// The recreated pod needs to register the runner again before it gets ready
+	if runner.Status.Ready {
+		updated := runner.DeepCopy()
+		updated.Status.Ready = false
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
+			log.Error(err, "Failed to update runner status for Ready")
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -420,9 +580,38 @@ func (r *RunnerReconciler) processRunnerDeletion(runner v1alpha1.Runner, ctx con
 	finalizers, removed := removeFinalizer(runner.ObjectMeta.Finalizers, finalizerName)
 
 	if removed {
-		if len(runner.Status.Registration.Token) > 0 {
-			ok, err := r.unregisterRunner(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
-			if err != nil {
+		if runner.Spec.UnregistrationPolicy() == v1alpha1.UnregistrationPolicyNever {
+			msg := "Deleting the runner without unregistering it, as its unregistration policy is Never"
+			log.Info(msg)
+			r.Recorder.Event(&runner, corev1.EventTypeNormal, v1alpha1.RunnerConditionReasonUnregistrationSkipped, msg)
+		} else if len(runner.Status.Registration.Token) > 0 {
+			now := time.Now()
+
+			if delay := busyCheckDelay(runner, now); delay > 0 {
+				return ctrl.Result{RequeueAfter: delay}, nil
+			}
+
+			deleteOffline := runner.Spec.Unregistration != nil && runner.Spec.Unregistration.DeleteOfflineImmediately
+
+			// The client is resolved only here, so that a runner whose credentials are gone can still be deleted
+			ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+			if isGitHubCredentialsMissing(err) {
+				msg := fmt.Sprintf("Deleting the runner without unregistering it, as its GitHub API credentials are unavailable: %v", err)
+				log.Info(msg)
+				r.Recorder.Event(&runner, corev1.EventTypeWarning, v1alpha1.RunnerConditionReasonUnregistrationSkipped, msg)
+			} else if err != nil {
+				log.Error(err, "Failed to get the GitHub client of the runner")
+				return ctrl.Result{}, err
+			} else if ok, err := r.unregisterRunner(ctx, ghc, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name, deleteOffline); errors.Is(err, errRunnerBusy) {
+				giveUp, res, err := r.waitForBusyRunner(ctx, runner, log, now)
+				if !giveUp {
+					return res, err
+				}
+			} else if err != nil && unregistrationTimedOut(runner, now) {
+				msg := fmt.Sprintf("Deleting the runner without unregistering it, as it couldn't be unregistered within %s after the deletion request: %v", runner.Spec.Unregistration.Timeout.Duration, err)
+				log.Info(msg)
+				r.Recorder.Event(&runner, corev1.EventTypeWarning, v1alpha1.RunnerConditionReasonUnregistrationTimedOut, msg)
+			} else if err != nil {
 				if errors.Is(err, &gogithub.RateLimitError{}) {
 					// We log the underlying error when we failed calling GitHub API to list or unregisters,
 					// or the runner is still busy.
@@ -437,10 +626,17 @@ func (r *RunnerReconciler) processRunnerDeletion(runner v1alpha1.Runner, ctx con
 					return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
 				}
 
-				return ctrl.Result{}, err
-			}
+				if github.IsTransient(err) {
+					log.Info(
+						fmt.Sprintf("Failed to unregister runner due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+						"error", err.Error(),
+					)
 
-			if !ok {
+					return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+				}
+
+				return ctrl.Result{}, err
+			} else if !ok {
 				log.V(1).Info("Runner no longer exists on GitHub")
 			}
 		} else {
@@ -497,19 +693,26 @@ func (r *RunnerReconciler) processRunnerPodDeletion(ctx context.Context, runner
 	}
 }
 
-func (r *RunnerReconciler) processRunnerCreation(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) (reconcile.Result, error) {
-	if updated, err := r.updateRegistrationToken(ctx, runner); err != nil {
+func (r *RunnerReconciler) processRunnerCreation(ctx context.Context, runner v1alpha1.Runner, log logr.Logger, ghc *github.Client) (reconcile.Result, error) {
+	if updated, err := r.updateRegistrationToken(ctx, ghc, runner); err != nil {
+		r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Failed to get a registration token for runner %s/%s: %v", runner.Namespace, runner.Name, err))
+
 		return ctrl.Result{}, err
 	} else if updated {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	newPod, err := r.newPod(runner)
+	newPod, err := r.newPod(runner, ghc.GithubBaseURL)
 	if err != nil {
 		log.Error(err, "Could not create pod")
 		return ctrl.Result{}, err
 	}
 
+	if err := ensureToolCacheClaim(ctx, r.Client, runner.Namespace, runner.Spec.ToolCache); err != nil {
+		log.Error(err, "Failed to provision the tool cache")
+		return ctrl.Result{}, err
+	}
+
 	if err := r.Create(ctx, &newPod); err != nil {
 		if kerrors.IsAlreadyExists(err) {
 			// Gracefully handle pod-already-exists errors due to informer cache delay.
@@ -531,8 +734,10 @@ func (r *RunnerReconciler) processRunnerCreation(ctx context.Context, runner v1a
 	return ctrl.Result{}, nil
 }
 
-func (r *RunnerReconciler) unregisterRunner(ctx context.Context, enterprise, org, repo, name string) (bool, error) {
-	runners, err := r.GitHubClient.ListRunners(ctx, enterprise, org, repo)
+// unregisterRunner returns errRunnerBusy when GitHub reports the runner as busy.
+// The runner that is reported as offline too is unregistered regardless when deleteOffline is true.
+func (r *RunnerReconciler) unregisterRunner(ctx context.Context, ghc *github.Client, enterprise, org, repo, name string, deleteOffline bool) (bool, error) {
+	runners, err := ghc.ListRunners(ctx, enterprise, org, repo)
 	if err != nil {
 		return false, err
 	}
@@ -540,8 +745,8 @@ func (r *RunnerReconciler) unregisterRunner(ctx context.Context, enterprise, org
 	id := int64(0)
 	for _, runner := range runners {
 		if runner.GetName() == name {
-			if runner.GetBusy() {
-				return false, fmt.Errorf("runner is busy")
+			if runner.GetBusy() && !(deleteOffline && runner.GetStatus() == "offline") {
+				return false, errRunnerBusy
 			}
 			id = runner.GetID()
 			break
@@ -552,21 +757,21 @@ func (r *RunnerReconciler) unregisterRunner(ctx context.Context, enterprise, org
 		return false, nil
 	}
 
-	if err := r.GitHubClient.RemoveRunner(ctx, enterprise, org, repo, id); err != nil {
+	if err := ghc.RemoveRunner(ctx, enterprise, org, repo, id); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
-func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, runner v1alpha1.Runner) (bool, error) {
+func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, ghc *github.Client, runner v1alpha1.Runner) (bool, error) {
 	if runner.IsRegisterable() {
 		return false, nil
 	}
 
 	log := r.Log.WithValues("runner", runner.Name)
 
-	rt, err := r.GitHubClient.GetRegistrationToken(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
+	rt, err := ghc.GetRegistrationToken(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
 	if err != nil {
 		r.Recorder.Event(&runner, corev1.EventTypeWarning, "FailedUpdateRegistrationToken", "Updating registration token failed")
 		log.Error(err, "Failed to get new registration token")
@@ -577,7 +782,7 @@ func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, runner v
 	updated.Status.Registration = v1alpha1.RunnerStatusRegistration{
 		Organization: runner.Spec.Organization,
 		Repository:   runner.Spec.Repository,
-		Labels:       runner.Spec.Labels,
+		Labels:       runnerRegistrationLabels(runner.Spec.RunnerConfig),
 		Token:        rt.GetToken(),
 		ExpiresAt:    metav1.NewTime(rt.GetExpiresAt().Time),
 	}
@@ -593,7 +798,8 @@ func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, runner v
 	return true, nil
 }
 
-func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
+// newPod returns the runner pod that registers the runner to GitHub at the githubBaseURL.
+func (r *RunnerReconciler) newPod(runner v1alpha1.Runner, githubBaseURL string) (corev1.Pod, error) {
 	var template corev1.Pod
 
 	labels := map[string]string{}
@@ -625,7 +831,7 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		filterLabels(runner.ObjectMeta.Labels, LabelKeyRunnerTemplateHash),
 		runner.ObjectMeta.Annotations,
 		runner.Spec,
-		r.GitHubClient.GithubBaseURL,
+		githubBaseURL,
 	)
 
 	objectMeta := metav1.ObjectMeta{
@@ -646,7 +852,7 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 			Resources:       runner.Spec.Resources,
 		})
 
-		if (runner.Spec.DockerEnabled == nil || *runner.Spec.DockerEnabled) && (runner.Spec.DockerdWithinRunnerContainer == nil || !*runner.Spec.DockerdWithinRunnerContainer) {
+		if !runner.Spec.IsWindows() && (runner.Spec.DockerEnabled == nil || *runner.Spec.DockerEnabled) && (runner.Spec.DockerdWithinRunnerContainer == nil || !*runner.Spec.DockerdWithinRunnerContainer) {
 			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{
 				Name:         "docker",
 				VolumeMounts: runner.Spec.DockerVolumeMounts,
@@ -660,10 +866,14 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 
 	template.Spec.SecurityContext = runner.Spec.SecurityContext
 	template.Spec.EnableServiceLinks = runner.Spec.EnableServiceLinks
+	// Set before newRunnerPod so that the defaults of Windows and arch-specific runners are added to the node selector rather than overwritten
+	template.Spec.NodeSelector = runner.Spec.NodeSelector
 
 	registrationOnly := metav1.HasAnnotation(runner.ObjectMeta, annotationKeyRegistrationOnly)
 
-	pod, err := newRunnerPod(template, runner.Spec.RunnerConfig, r.RunnerImage, r.RunnerImagePullSecrets, r.DockerImage, r.DockerRegistryMirror, r.GitHubClient.GithubBaseURL, registrationOnly)
+	runnerImage := runnerImageFor(runner.Spec.RunnerConfig, template.Spec.NodeSelector, r.RunnerImage, r.ARM64RunnerImage, r.WindowsRunnerImage)
+
+	pod, err := newRunnerPod(template, withDefaultRunnerCache(runner.Spec.RunnerConfig, r.RunnerCacheURL), runnerImage, r.RunnerImagePullSecrets, r.DockerImage, r.DockerRegistryMirror, githubBaseURL, registrationOnly)
 	if err != nil {
 		return pod, err
 	}
@@ -701,9 +911,6 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		pod.Spec.InitContainers = append(pod.Spec.InitContainers, runnerSpec.InitContainers...)
 	}
 
-	if runnerSpec.NodeSelector != nil {
-		pod.Spec.NodeSelector = runnerSpec.NodeSelector
-	}
 	if runnerSpec.ServiceAccountName != "" {
 		pod.Spec.ServiceAccountName = runnerSpec.ServiceAccountName
 	}
@@ -715,6 +922,10 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		pod.Spec.Containers = append(pod.Spec.Containers, runnerSpec.SidecarContainers...)
 	}
 
+	if err := injectSidecars(&pod, runnerSpec.Sidecars); err != nil {
+		return pod, err
+	}
+
 	if len(runnerSpec.ImagePullSecrets) != 0 {
 		pod.Spec.ImagePullSecrets = runnerSpec.ImagePullSecrets
 	}
@@ -747,6 +958,10 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		pod.Spec.RuntimeClassName = runnerSpec.RuntimeClassName
 	}
 
+	if runnerSpec.PriorityClassName != "" {
+		pod.Spec.PriorityClassName = runnerSpec.PriorityClassName
+	}
+
 	pod.ObjectMeta.Name = runner.ObjectMeta.Name
 
 	// Inject the registration token and the runner name
@@ -783,15 +998,21 @@ func mutatePod(pod *corev1.Pod, token string) *corev1.Pod {
 func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, defaultRunnerImage string, defaultRunnerImagePullSecrets []string, defaultDockerImage, defaultDockerRegistryMirror string, githubBaseURL string, registrationOnly bool) (corev1.Pod, error) {
 	var (
 		privileged                bool = true
-		dockerdInRunner           bool = runnerSpec.DockerdWithinRunnerContainer != nil && *runnerSpec.DockerdWithinRunnerContainer
-		dockerEnabled             bool = runnerSpec.DockerEnabled == nil || *runnerSpec.DockerEnabled
+		windows                   bool = runnerSpec.IsWindows()
+		dockerdInRunner           bool = !windows && runnerSpec.DockerdWithinRunnerContainer != nil && *runnerSpec.DockerdWithinRunnerContainer
+		dockerEnabled             bool = !windows && (runnerSpec.DockerEnabled == nil || *runnerSpec.DockerEnabled)
 		ephemeral                 bool = runnerSpec.Ephemeral == nil || *runnerSpec.Ephemeral
 		dockerdInRunnerPrivileged bool = dockerdInRunner
 	)
 
 	workDir := runnerSpec.WorkDir
 	if workDir == "" {
-		workDir = "/runner/_work"
+		if windows {
+			// The layout of Windows runner images is up to the image, so the default of the runner itself is used
+			workDir = "_work"
+		} else {
+			workDir = "/runner/_work"
+		}
 	}
 
 	var dockerRegistryMirror string
@@ -818,7 +1039,7 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		},
 		{
 			Name:  "RUNNER_LABELS",
-			Value: strings.Join(runnerSpec.Labels, ","),
+			Value: strings.Join(runnerRegistrationLabels(runnerSpec), ","),
 		},
 		{
 			Name:  "RUNNER_GROUP",
@@ -854,6 +1075,57 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		)
 	}
 
+	proxyEnv := newProxyEnvVars(runnerSpec.Proxy)
+
+	env = append(env, proxyEnv...)
+
+	var (
+		oidcVolumes      []corev1.Volume
+		oidcVolumeMounts []corev1.VolumeMount
+	)
+
+	if oidc := runnerSpec.OIDC; oidc != nil {
+		oidcEnv, volumes, volumeMounts, err := newOIDCConfig(*oidc, githubBaseURL)
+		if err != nil {
+			return template, err
+		}
+
+		env = append(env, oidcEnv...)
+		oidcVolumes = volumes
+		oidcVolumeMounts = volumeMounts
+	}
+
+	var (
+		toolCacheVolumes      []corev1.Volume
+		toolCacheVolumeMounts []corev1.VolumeMount
+	)
+
+	if tc := runnerSpec.ToolCache; tc != nil {
+		toolCacheEnv, volumes, volumeMounts := newToolCacheConfig(*tc, windows)
+
+		env = append(env, toolCacheEnv...)
+		toolCacheVolumes = volumes
+		toolCacheVolumeMounts = volumeMounts
+	}
+
+	env = append(env, newMaxJobDurationEnvVars(runnerSpec.MaxJobDuration)...)
+
+	cacheURL := runnerCacheURL(runnerSpec.Cache)
+	if cacheURL != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  EnvVarRunnerCacheURL,
+			Value: cacheURL,
+		})
+	}
+
+	jobHooksEnv, jobHooksVolumes, jobHooksVolumeMounts := newJobHooksConfig(runnerSpec.JobHooks, runnerSpec.MaxJobDuration, runnerSpec.HealthCheck, cacheURL)
+
+	env = append(env, jobHooksEnv...)
+
+	healthCheckEnv, healthCheckProbe := newHealthCheckConfig(runnerSpec.HealthCheck)
+
+	env = append(env, healthCheckEnv...)
+
 	var seLinuxOptions *corev1.SELinuxOptions
 	if template.Spec.SecurityContext != nil {
 		seLinuxOptions = template.Spec.SecurityContext.SELinuxOptions
@@ -901,21 +1173,90 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 	if runnerContainer.Image == "" {
 		runnerContainer.Image = defaultRunnerImage
 	}
+	if runnerContainer.Image == "" && windows {
+		return template, errors.New("windows runner image is not specified. Set spec.image of the runner or --windows-runner-image of the controller")
+	}
 
 	if runnerContainer.ImagePullPolicy == "" {
 		runnerContainer.ImagePullPolicy = corev1.PullAlways
 	}
 
+	// The last lines of the logs of the failed runner tell why it failed, like its version being outdated.
+	// The termination message written by the runner, e.g. by the health check, takes precedence over the logs.
+	if runnerContainer.TerminationMessagePolicy == "" {
+		runnerContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	}
+
+	// The runner container gets ready only after the entrypoint registers the runner to GitHub,
+	// which writes the .runner file on success. Until then the pod isn't counted as ready,
+	// and the container is restarted if the registration doesn't complete within the registration timeout.
+	if runnerContainer.StartupProbe == nil && !registrationOnly && !windows {
+		runnerContainer.StartupProbe = &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"sh", "-c", `test -f "${RUNNER_HOME:-/runner}/.runner"`},
+				},
+			},
+			PeriodSeconds:    runnerStartupProbePeriodSeconds,
+			FailureThreshold: int32(registrationTimeout / (runnerStartupProbePeriodSeconds * time.Second)),
+		}
+	}
+
+	if runnerContainer.ReadinessProbe == nil && healthCheckProbe != nil && !registrationOnly && !windows {
+		runnerContainer.ReadinessProbe = healthCheckProbe
+	}
+
 	runnerContainer.Env = append(runnerContainer.Env, env...)
 
+	if gpu := runnerSpec.GPU; gpu != nil {
+		setGPURequests(runnerContainer, *gpu)
+	}
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, oidcVolumeMounts...)
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, toolCacheVolumeMounts...)
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, jobHooksVolumeMounts...)
+
 	if runnerContainer.SecurityContext == nil {
 		runnerContainer.SecurityContext = &corev1.SecurityContext{}
 	}
-	// Runner need to run privileged if it contains DinD
-	runnerContainer.SecurityContext.Privileged = &dockerdInRunnerPrivileged
+	if windows {
+		// Windows containers can't run privileged
+		runnerContainer.SecurityContext.Privileged = nil
+	} else {
+		// Runner need to run privileged if it contains DinD
+		runnerContainer.SecurityContext.Privileged = &dockerdInRunnerPrivileged
+	}
 
 	pod := template.DeepCopy()
 
+	// Tells the pod-level consumers, like the runner pod controller and the token injector, which client to use
+	if from := runnerSpec.GitHubAPICredentialsFrom; from != nil {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+
+		pod.Annotations[AnnotationKeyGitHubAPICredsSecret] = from.SecretRef.Name
+	}
+
+	if windows {
+		setWindowsDefaults(pod)
+	}
+
+	if runnerSpec.Arch != "" {
+		if arch := pod.Spec.NodeSelector[corev1.LabelArchStable]; arch != "" && arch != runnerSpec.Arch {
+			return template, fmt.Errorf("arch %s conflicts with the node selector %s=%s", runnerSpec.Arch, corev1.LabelArchStable, arch)
+		}
+
+		setDefaultNodeSelector(pod, corev1.LabelArchStable, runnerSpec.Arch)
+	}
+
+	if gpu := runnerSpec.GPU; gpu != nil {
+		setGPUScheduling(pod, *gpu)
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, oidcVolumes...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, toolCacheVolumes...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, jobHooksVolumes...)
+
 	if pod.Spec.RestartPolicy == "" {
 		pod.Spec.RestartPolicy = "OnFailure"
 	}
@@ -947,6 +1288,15 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		}...)
 	}
 
+	if cidr := runnerSpec.DockerIPv6CIDR; cidr != nil && dockerdInRunner {
+		runnerContainer.Env = append(runnerContainer.Env, []corev1.EnvVar{
+			{
+				Name:  "DOCKER_IPV6_CIDR",
+				Value: *cidr,
+			},
+		}...)
+	}
+
 	//
 	// /runner must be generated on runtime from /runnertmp embedded in the container image.
 	//
@@ -970,7 +1320,8 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		runnerVolumeEmptyDir.SizeLimit = runnerSpec.VolumeSizeLimit
 	}
 
-	if runnerSpec.VolumeSizeLimit == nil || !runnerSpec.VolumeSizeLimit.IsZero() {
+	// Windows runner images don't populate /runner from /runnertmp on startup, so the volume would hide the runner
+	if !windows && (runnerSpec.VolumeSizeLimit == nil || !runnerSpec.VolumeSizeLimit.IsZero()) {
 		pod.Spec.Volumes = append(pod.Spec.Volumes,
 			corev1.Volume{
 				Name: runnerVolumeName,
@@ -1068,6 +1419,9 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 			Value: "/certs",
 		})
 
+		// dockerd needs the proxy to pull images on behalf of the runner
+		dockerdContainer.Env = append(dockerdContainer.Env, proxyEnv...)
+
 		if dockerdContainer.SecurityContext == nil {
 			dockerdContainer.SecurityContext = &corev1.SecurityContext{
 				Privileged:     &privileged,
@@ -1076,6 +1430,7 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		}
 
 		dockerdContainer.VolumeMounts = append(dockerdContainer.VolumeMounts, dockerVolumeMounts...)
+		dockerdContainer.VolumeMounts = append(dockerdContainer.VolumeMounts, toolCacheVolumeMounts...)
 
 		if mtu := runnerSpec.DockerMTU; mtu != nil {
 			dockerdContainer.Env = append(dockerdContainer.Env, []corev1.EnvVar{
@@ -1097,6 +1452,10 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 				fmt.Sprintf("--registry-mirror=%s", dockerRegistryMirror),
 			)
 		}
+
+		if cidr := runnerSpec.DockerIPv6CIDR; cidr != nil {
+			dockerdContainer.Args = append(dockerdContainer.Args, dockerdIPv6Args(*cidr)...)
+		}
 	}
 
 	if runnerContainerIndex == -1 {
@@ -1117,9 +1476,118 @@ func newRunnerPod(template corev1.Pod, runnerSpec v1alpha1.RunnerConfig, default
 		}
 	}
 
+	if lf := runnerSpec.LogForwarder; lf != nil {
+		if runnerSpec.VolumeSizeLimit != nil && runnerSpec.VolumeSizeLimit.IsZero() {
+			return *pod, fmt.Errorf(
+				"%s volume can't be disabled because it is required to share the runner logs with the log forwarder",
+				runnerVolumeName,
+			)
+		}
+
+		logForwarder, volumes, err := newLogForwarderContainer(*lf, runnerVolumeName, runnerVolumeMountPath)
+		if err != nil {
+			return *pod, err
+		}
+
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volumes...)
+		pod.Spec.Containers = append(pod.Spec.Containers, *logForwarder)
+	}
+
 	return *pod, nil
 }
 
+// setWindowsDefaults schedules the runner pod onto Windows nodes and runs it as the administrator of the container,
+// which is required by the runner to install the tools used by jobs. The values set in the runner spec are kept.
+func setWindowsDefaults(pod *corev1.Pod) {
+	setDefaultNodeSelector(pod, corev1.LabelOSStable, string(corev1.Windows))
+
+	if pod.Spec.SecurityContext == nil {
+		pod.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+
+	if pod.Spec.SecurityContext.WindowsOptions == nil {
+		runAsUserName := windowsRunAsUserName
+		pod.Spec.SecurityContext.WindowsOptions = &corev1.WindowsSecurityContextOptions{RunAsUserName: &runAsUserName}
+	}
+}
+
+// setDefaultNodeSelector adds the node selector unless the pod already selects nodes by the key.
+// The map is copied because it can be shared with the runner spec.
+func setDefaultNodeSelector(pod *corev1.Pod, key, value string) {
+	if pod.Spec.NodeSelector[key] != "" {
+		return
+	}
+
+	nodeSelector := map[string]string{}
+	for k, v := range pod.Spec.NodeSelector {
+		nodeSelector[k] = v
+	}
+	nodeSelector[key] = value
+	pod.Spec.NodeSelector = nodeSelector
+}
+
+// runnerArch returns the CPU architecture of the runner, which is either set in the runner spec or
+// detected from the node selector of the pod. It returns an empty string when the architecture is unknown.
+func runnerArch(runnerSpec v1alpha1.RunnerConfig, nodeSelector map[string]string) string {
+	if runnerSpec.Arch != "" {
+		return runnerSpec.Arch
+	}
+
+	return nodeSelector[corev1.LabelArchStable]
+}
+
+// runnerImageFor returns the default image of the runner container, which differs between Linux and Windows runners.
+// ARM64 runners get arm64RunnerImage when it's set, and the multi-arch runnerImage otherwise.
+func runnerImageFor(runnerSpec v1alpha1.RunnerConfig, nodeSelector map[string]string, runnerImage, arm64RunnerImage, windowsRunnerImage string) string {
+	if runnerSpec.IsWindows() {
+		return windowsRunnerImage
+	}
+
+	if arm64RunnerImage != "" && runnerArch(runnerSpec, nodeSelector) == v1alpha1.RunnerArchARM64 {
+		return arm64RunnerImage
+	}
+
+	return runnerImage
+}
+
+// dockerdIPv6Args returns the dockerd flags to let containers use IPv6 with the given subnet.
+// ip6tables is required for containers to reach outside of the pod via NAT, and it is still experimental in some dockerd versions.
+func dockerdIPv6Args(cidr string) []string {
+	return []string{
+		"--ipv6",
+		fmt.Sprintf("--fixed-cidr-v6=%s", cidr),
+		"--experimental",
+		"--ip6tables",
+	}
+}
+
+// newProxyEnvVars returns the environment variables to let the runner pod's containers
+// use the proxy. Both upper and lowercase variants are set because tools disagree on which one to read.
+func newProxyEnvVars(proxy *v1alpha1.ProxyConfig) []corev1.EnvVar {
+	if proxy == nil {
+		return nil
+	}
+
+	var env []corev1.EnvVar
+
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+
+		env = append(env,
+			corev1.EnvVar{Name: strings.ToUpper(name), Value: value},
+			corev1.EnvVar{Name: strings.ToLower(name), Value: value},
+		)
+	}
+
+	add("HTTP_PROXY", proxy.HTTPProxy)
+	add("HTTPS_PROXY", proxy.HTTPSProxy)
+	add("NO_PROXY", strings.Join(proxy.NoProxy, ","))
+
+	return env
+}
+
 func (r *RunnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	name := "runner-controller"
 	if r.Name != "" {
@@ -1128,11 +1596,13 @@ func (r *RunnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Runner{}).
 		Owns(&corev1.Pod{}).
-		Named(name).
-		Complete(r)
+		Watches(&source.Kind{Type: &v1alpha1.HorizontalRunnerAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(r.runnersForCapacityReservations)).
+		Named(name)
+
+	return r.ControllerOptions.complete(b, r)
 }
 
 func addFinalizer(finalizers []string, finalizerName string) ([]string, bool) {
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	githubfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+)
+
+func TestRunnerReconciler_PodOfPreviousRunner(t *testing.T) {
+	server := githubfake.NewServer(githubfake.WithListRunnersResponse(200, githubfake.RunnersListBody))
+	defer server.Close()
+
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "example-runner",
+			Namespace:  "default",
+			UID:        "new",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+		},
+	}
+
+	controller := true
+
+	// The pod of the deleted runner of the same name is still terminating
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-runner",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: v1alpha1.GroupVersion.String(), Kind: "Runner", Name: "example-runner", UID: "previous", Controller: &controller},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, runner, pod)
+
+	r := &RunnerReconciler{
+		Client:       client,
+		Log:          logf.Log,
+		Recorder:     record.NewFakeRecorder(10),
+		Scheme:       sc,
+		GitHubClient: newGithubClient(server),
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example-runner"}
+
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.RequeueAfter == 0 {
+		t.Errorf("expected the runner to wait for the pod of the previous runner to be deleted")
+	}
+
+	var got corev1.Pod
+	if err := client.Get(context.Background(), key, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !metav1.IsControlledBy(&got, &v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{UID: "previous"}}) {
+		t.Errorf("unexpected adoption of the pod of the previous runner")
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const (
+	// DefaultRunnerDeletionParallelism is the default number of the runners of a runner replica set
+	// deleted concurrently on scale down.
+	DefaultRunnerDeletionParallelism = 10
+
+	// runnerDeletionAttempts is the number of attempts of listing the GitHub runners and of deleting each runner,
+	// so that a transient error on a runner doesn't fail the whole scale down.
+	runnerDeletionAttempts = 3
+)
+
+// runnerDeletionRetryDelay is the delay between the attempts, which is overridden in tests.
+var runnerDeletionRetryDelay = time.Second
+
+// runInParallel calls fn with the indices from 0 to n-1 using up to parallelism goroutines,
+// and returns the errors indexed the same way.
+func runInParallel(n, parallelism int, fn func(i int) error) []error {
+	if parallelism <= 0 {
+		parallelism = DefaultRunnerDeletionParallelism
+	}
+
+	errs := make([]error, n)
+
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < parallelism && w < n; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+
+	close(indices)
+
+	wg.Wait()
+
+	return errs
+}
+
+// retryRunnerOperation calls fn until it succeeds, it returns a non-retriable error, the attempts run out, or ctx is done.
+func retryRunnerOperation(ctx context.Context, fn func() error) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetriableRunnerOperationError(err) || attempt >= runnerDeletionAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(runnerDeletionRetryDelay):
+		}
+	}
+}
+
+// isRetriableRunnerOperationError returns true for the transient errors of the GitHub API and the Kubernetes API.
+// The GitHub API rate limit isn't retried, as retrying only consumes the remaining budget.
+func isRetriableRunnerOperationError(err error) bool {
+	return github.IsTransient(err) ||
+		kerrors.IsConflict(err) ||
+		kerrors.IsServerTimeout(err) ||
+		kerrors.IsTimeout(err) ||
+		kerrors.IsTooManyRequests(err) ||
+		kerrors.IsInternalError(err) ||
+		kerrors.IsServiceUnavailable(err)
+}
+
+// getDeletionCandidates returns the runners that can be deleted on scale down, in the order they should be deleted:
+// the runners whose pods are unschedulable first, and then the runners that are idle, offline, or failed to register in time.
+//
+// The runners are looked up in a single list of the GitHub runners rather than one list per runner,
+// so that scaling down by hundreds of runners doesn't cost hundreds of GitHub API calls.
+// The returned result is non-zero when the reconciliation needs to be retried later.
+func (r *RunnerReplicaSetReconciler) getDeletionCandidates(ctx context.Context, log logr.Logger, rs v1alpha1.RunnerReplicaSet, runners []v1alpha1.Runner) ([]v1alpha1.Runner, ctrl.Result, error) {
+	var alive []v1alpha1.Runner
+
+	// The runners being deleted are skipped, so that each reconciliation makes progress rather than deleting them again
+	for _, runner := range runners {
+		if runner.DeletionTimestamp.IsZero() {
+			alive = append(alive, runner)
+		}
+	}
+
+	runnerPods, err := r.getRunnerPods(ctx, alive)
+	if err != nil {
+		return nil, ctrl.Result{}, err
+	}
+
+	// Runners whose pods are unschedulable have never run a job, so they are deleted first without asking GitHub.
+	// Otherwise idle runners that are running would be deleted while the Pending pods stay.
+	unschedulableRunners := unschedulableRunnerNames(runnerPods)
+
+	var candidates, others []v1alpha1.Runner
+
+	for _, runner := range alive {
+		if unschedulableRunners[runner.Name] {
+			candidates = append(candidates, runner)
+		} else {
+			others = append(others, runner)
+		}
+	}
+
+	if len(others) == 0 {
+		return candidates, ctrl.Result{}, nil
+	}
+
+	spec := rs.Spec.Template.Spec
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rs.Namespace, spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, ctrl.Result{}, err
+	}
+
+	var registered []*gogithub.Runner
+
+	err = retryRunnerOperation(ctx, func() error {
+		var err error
+		registered, err = ghc.ListRunners(ctx, spec.Enterprise, spec.Organization, spec.Repository)
+		return err
+	})
+	if err != nil {
+		var e *gogithub.RateLimitError
+		if errors.As(err, &e) {
+			// We log the underlying error when we failed calling GitHub API to list or unregisters,
+			// or the runner is still busy.
+			log.Error(
+				err,
+				fmt.Sprintf(
+					"Failed to check if runner is busy due to GitHub API rate limit. Retrying in %s to avoid excessive GitHub API calls",
+					retryDelayOnGitHubAPIRateLimitError,
+				),
+			)
+
+			return nil, ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
+		}
+
+		if github.IsTransient(err) {
+			log.Info(
+				fmt.Sprintf("Failed to check if runner is busy due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+				"error", err.Error(),
+			)
+
+			return nil, ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+		}
+
+		return nil, ctrl.Result{}, err
+	}
+
+	byName := map[string]*gogithub.Runner{}
+	for _, runner := range registered {
+		byName[runner.GetName()] = runner
+	}
+
+	registrationTimeout := 15 * time.Minute
+	currentTime := time.Now()
+
+	for _, runner := range others {
+		ghRunner, ok := byName[runner.Name]
+
+		switch {
+		case !ok:
+			if currentTime.Sub(runner.CreationTimestamp.Add(registrationTimeout)) <= 0 {
+				log.V(1).Info("Failed to check if runner is busy. Either this runner has never been successfully registered to GitHub or it still needs more time.", "runnerName", runner.Name)
+
+				continue
+			}
+
+			log.Info(
+				"Runner failed to register itself to GitHub in timely manner. "+
+					"Marking the runner for scale down. "+
+					"CAUTION: If you see this a lot, you should investigate the root cause. "+
+					"See https://github.com/actions-runner-controller/actions-runner-controller/issues/288",
+				"runnerCreationTimestamp", runner.CreationTimestamp,
+				"currentTime", currentTime,
+				"configuredRegistrationTimeout", registrationTimeout,
+			)
+
+			candidates = append(candidates, runner)
+		// offline runners should always be a great target for scale down
+		case ghRunner.GetStatus() == "offline", !ghRunner.GetBusy():
+			candidates = append(candidates, runner)
+		}
+	}
+
+	return candidates, ctrl.Result{}, nil
+}
+
+// deleteRunners deletes the runners with up to DeletionParallelism concurrent deletions, retrying each on transient errors.
+// Each deleted runner is then unregistered from GitHub and has its pod deleted by the runner controller,
+// which runs as many reconciliations concurrently as -max-concurrent-reconciles allows.
+func (r *RunnerReplicaSetReconciler) deleteRunners(ctx context.Context, log logr.Logger, rs v1alpha1.RunnerReplicaSet, runners []v1alpha1.Runner) error {
+	errs := runInParallel(len(runners), r.DeletionParallelism, func(i int) error {
+		runner := runners[i]
+
+		if err := retryRunnerOperation(ctx, func() error {
+			return client.IgnoreNotFound(r.Client.Delete(ctx, &runner))
+		}); err != nil {
+			return err
+		}
+
+		r.Recorder.Event(&rs, corev1.EventTypeNormal, "RunnerDeleted", fmt.Sprintf("Deleted runner '%s'", runner.Name))
+		log.Info("Deleted runner", "runner", runner.Name)
+
+		return nil
+	})
+
+	var (
+		failed   int
+		firstErr error
+	)
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		log.Error(err, "Failed to delete runner resource", "runner", runners[i].Name)
+
+		failed++
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("failed to delete %d of %d runner(s): %w", failed, len(runners), firstErr)
+	}
+
+	return nil
+}
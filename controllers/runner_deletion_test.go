@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestRunInParallel(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		running   int
+		maxSeen   int
+		processed = map[int]bool{}
+	)
+
+	errs := runInParallel(20, 3, func(i int) error {
+		mu.Lock()
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		processed[i] = true
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if i%5 == 0 {
+			return errors.New("failed")
+		}
+
+		return nil
+	})
+
+	if maxSeen > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", maxSeen)
+	}
+
+	if len(processed) != 20 {
+		t.Errorf("expected all the 20 indices to be processed, got %d", len(processed))
+	}
+
+	for i, err := range errs {
+		if (i%5 == 0) != (err != nil) {
+			t.Errorf("unexpected error at %d: %v", i, err)
+		}
+	}
+
+	if errs := runInParallel(0, 3, func(int) error { return nil }); len(errs) != 0 {
+		t.Errorf("expected no errors for no items, got %v", errs)
+	}
+}
+
+func TestRetryRunnerOperation(t *testing.T) {
+	defer func(d time.Duration) { runnerDeletionRetryDelay = d }(runnerDeletionRetryDelay)
+	runnerDeletionRetryDelay = 0
+
+	conflict := kerrors.NewConflict(schema.GroupResource{Resource: "runners"}, "example", errors.New("conflict"))
+
+	testcases := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "success", errs: []error{nil}, wantCalls: 1},
+		{name: "retried until success", errs: []error{conflict, conflict, nil}, wantCalls: 3},
+		{name: "attempts exhausted", errs: []error{conflict, conflict, conflict, nil}, wantCalls: runnerDeletionAttempts, wantErr: true},
+		{name: "not retriable", errs: []error{errors.New("forbidden"), nil}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+
+			err := retryRunnerOperation(context.Background(), func() error {
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+
+			if calls != tc.wantCalls {
+				t.Errorf("unexpected calls: want %d, got %d", tc.wantCalls, calls)
+			}
+
+			if (err != nil) != tc.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunnerReplicaSetReconciler_getDeletionCandidates(t *testing.T) {
+	ghRunners := []*github.Runner{
+		{ID: github.Int64(1), Name: github.String("example-busy"), Status: github.String("online"), Busy: github.Bool(true)},
+		{ID: github.Int64(2), Name: github.String("example-idle"), Status: github.String("online"), Busy: github.Bool(false)},
+		{ID: github.Int64(3), Name: github.String("example-offline"), Status: github.String("offline"), Busy: github.Bool(true)},
+	}
+
+	var listed int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+		listed++
+
+		j, err := json.Marshal(github.Runners{TotalCount: len(ghRunners), Runners: ghRunners})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := metav1.Now()
+	old := metav1.NewTime(now.Add(-time.Hour))
+
+	newRunner := func(name string, created metav1.Time) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: created},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+		}
+	}
+
+	terminating := newRunner("example-terminating", old)
+	terminating.DeletionTimestamp = &now
+	terminating.Finalizers = []string{"runner.actions.summerwind.dev"}
+
+	objs := []*v1alpha1.Runner{
+		newRunner("example-busy", old),
+		newRunner("example-idle", old),
+		newRunner("example-offline", old),
+		newRunner("example-registering", now),
+		newRunner("example-unregistered", old),
+		newRunner("example-unschedulable", now),
+		terminating,
+	}
+
+	pod := newPendingPod("example-unschedulable", corev1.PodReasonUnschedulable, "")
+
+	c := fake.NewFakeClientWithScheme(sc, &pod)
+
+	var runners []v1alpha1.Runner
+
+	for _, o := range objs {
+		if err := c.Create(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+		runners = append(runners, *o)
+	}
+
+	r := &RunnerReplicaSetReconciler{
+		Client:              c,
+		GitHubClient:        newGithubClient(server),
+		Recorder:            record.NewFakeRecorder(10),
+		DeletionParallelism: 2,
+	}
+
+	rs := v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerReplicaSetSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	log := logf.Log
+
+	candidates, res, err := r.getDeletionCandidates(context.Background(), log, rs, runners)
+	if err != nil || res.Requeue || res.RequeueAfter > 0 {
+		t.Fatalf("unexpected result: %+v: %v", res, err)
+	}
+
+	var names []string
+	for _, runner := range candidates {
+		names = append(names, runner.Name)
+	}
+
+	want := []string{"example-unschedulable", "example-idle", "example-offline", "example-unregistered"}
+
+	if d := cmp.Diff(want, names); d != "" {
+		t.Errorf("unexpected deletion candidates: %s", d)
+	}
+
+	if listed != 1 {
+		t.Errorf("expected the runners to be listed once, got %d", listed)
+	}
+
+	if err := r.deleteRunners(context.Background(), log, rs, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining v1alpha1.RunnerList
+
+	if err := c.List(context.Background(), &remaining); err != nil {
+		t.Fatal(err)
+	}
+
+	names = nil
+	for _, runner := range remaining.Items {
+		names = append(names, runner.Name)
+	}
+
+	want = []string{"example-busy", "example-registering", "example-terminating"}
+
+	if d := cmp.Diff(want, names); d != "" {
+		t.Errorf("unexpected remaining runners: %s", d)
+	}
+
+	// Deleting the runners already gone is a no-op
+	if err := r.deleteRunners(context.Background(), log, rs, candidates); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// AnnotationKeyDiagnosticsRestartCount is the annotation on the runner pod that has the restart count of the
+	// crash-looping container the diagnostics were last collected for, so that they're collected once per crash.
+	AnnotationKeyDiagnosticsRestartCount = "actions-runner-controller/diagnostics-restart-count"
+
+	// LabelKeyDiagnosticsFor is the label that has the name of the runner on its diagnostics ConfigMap.
+	LabelKeyDiagnosticsFor = "actions-runner-controller/diagnostics-for"
+
+	DiagnosticsReasonRegistrationTimeout = "RegistrationTimeout"
+	DiagnosticsReasonCrashLoopBackOff    = "CrashLoopBackOff"
+
+	// diagnosticsEventMessageMaxLength bounds the logs in the event message, as the API server
+	// truncates larger messages from the head, which would drop the most recent lines.
+	diagnosticsEventMessageMaxLength = 1024
+)
+
+// diagnosticsContainerNames are the containers of the runner pod whose logs are collected.
+var diagnosticsContainerNames = []string{containerName, "docker"}
+
+// checkCrashLoop collects the diagnostics when the runner or docker container of the runner pod is crash-looping.
+// They're collected from the logs of the last terminated container, once per crash.
+func (r *RunnerReconciler) checkCrashLoop(ctx context.Context, runner v1alpha1.Runner, pod corev1.Pod, log logr.Logger) {
+	if r.DiagnosticsTailLines <= 0 {
+		return
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil || status.State.Waiting.Reason != DiagnosticsReasonCrashLoopBackOff {
+			continue
+		}
+
+		if !containsString(diagnosticsContainerNames, status.Name) {
+			continue
+		}
+
+		restarts := strconv.Itoa(int(status.RestartCount))
+		if pod.Annotations[AnnotationKeyDiagnosticsRestartCount] == restarts {
+			return
+		}
+
+		r.collectDiagnostics(ctx, runner, pod, DiagnosticsReasonCrashLoopBackOff, true, log)
+
+		updated := pod.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[AnnotationKeyDiagnosticsRestartCount] = restarts
+
+		if err := r.Patch(ctx, updated, client.MergeFrom(&pod)); err != nil {
+			log.Error(err, "Failed to annotate the runner pod with the restart count the diagnostics were collected for")
+		}
+
+		return
+	}
+}
+
+// collectDiagnostics collects the last lines of the logs of the runner and docker containers of the failed runner pod
+// into a warning event on the runner, and into the diagnostics ConfigMap of the runner when enabled,
+// so that users can tell why the runner never came online without access to the pod.
+//
+// Failures are only logged, because the diagnostics shouldn't block recreating the failed pod.
+func (r *RunnerReconciler) collectDiagnostics(ctx context.Context, runner v1alpha1.Runner, pod corev1.Pod, reason string, previous bool, log logr.Logger) {
+	if r.DiagnosticsTailLines <= 0 || r.KubeClient == nil {
+		return
+	}
+
+	logs := map[string]string{}
+
+	for _, c := range pod.Spec.Containers {
+		if !containsString(diagnosticsContainerNames, c.Name) {
+			continue
+		}
+
+		opts := &corev1.PodLogOptions{
+			Container: c.Name,
+			TailLines: &r.DiagnosticsTailLines,
+			Previous:  previous,
+		}
+
+		raw, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+		if err != nil {
+			log.Error(err, "Failed to get the logs of the runner pod for diagnostics", "container", c.Name)
+
+			continue
+		}
+
+		logs[c.Name] = string(raw)
+	}
+
+	r.Recorder.Event(&runner, corev1.EventTypeWarning, "RunnerDiagnostics", diagnosticsEventMessage(pod.Name, reason, logs[containerName]))
+
+	if !r.DiagnosticsConfigMaps {
+		return
+	}
+
+	if err := r.writeDiagnosticsConfigMap(ctx, runner, pod, reason, logs); err != nil {
+		log.Error(err, "Failed to write the diagnostics ConfigMap")
+
+		return
+	}
+
+	log.Info("Collected diagnostics of the failed runner pod", "reason", reason, "configMap", diagnosticsConfigMapName(runner.Name))
+}
+
+// diagnosticsEventMessage returns the message of the event that has the last lines of the runner container logs,
+// dropping the oldest lines that don't fit in the message.
+func diagnosticsEventMessage(podName, reason, logs string) string {
+	header := fmt.Sprintf("Runner pod '%s' failed (%s). Last lines of the runner logs:\n", podName, reason)
+
+	logs = strings.TrimRight(logs, "\n")
+
+	if max := diagnosticsEventMessageMaxLength - len(header); len(logs) > max {
+		logs = logs[len(logs)-max:]
+
+		if i := strings.Index(logs, "\n"); i >= 0 {
+			logs = logs[i+1:]
+		}
+	}
+
+	return header + logs
+}
+
+func diagnosticsConfigMapName(runnerName string) string {
+	return runnerName + "-diagnostics"
+}
+
+// writeDiagnosticsConfigMap writes the logs into the diagnostics ConfigMap of the runner, overwriting the ones of the
+// previous failure. The ConfigMap is owned by the runner, so that it's deleted along with the runner.
+func (r *RunnerReconciler) writeDiagnosticsConfigMap(ctx context.Context, runner v1alpha1.Runner, pod corev1.Pod, reason string, logs map[string]string) error {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      diagnosticsConfigMapName(runner.Name),
+			Namespace: runner.Namespace,
+			Labels: map[string]string{
+				LabelKeyDiagnosticsFor: runner.Name,
+			},
+		},
+		Data: map[string]string{
+			"pod":    pod.Name,
+			"reason": reason,
+		},
+	}
+
+	for name, l := range logs {
+		cm.Data[name+".log"] = l
+	}
+
+	if err := ctrl.SetControllerReference(&runner, &cm, r.Scheme); err != nil {
+		return err
+	}
+
+	// The ConfigMap is replaced as a whole without reading it first,
+	// so that the controller doesn't need to cache all the ConfigMaps in the cluster.
+	err := r.Create(ctx, &cm)
+	if !kerrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return r.Update(ctx, &cm)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
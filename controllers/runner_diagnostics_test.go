@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestCheckCrashLoop(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "abc"},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "runner"}, {Name: "docker"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "runner", RestartCount: 3, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				{Name: "docker", Ready: true},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, &runner, pod)
+	recorder := record.NewFakeRecorder(10)
+
+	r := &RunnerReconciler{
+		Client:                client,
+		Scheme:                sc,
+		Recorder:              recorder,
+		KubeClient:            kubefake.NewSimpleClientset(),
+		DiagnosticsTailLines:  10,
+		DiagnosticsConfigMaps: true,
+	}
+
+	ctx := context.Background()
+
+	r.checkCrashLoop(ctx, runner, *pod, logr.Discard())
+
+	if n := len(recorder.Events); n != 1 {
+		t.Fatalf("unexpected number of events: want 1, got %d", n)
+	}
+
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Warning RunnerDiagnostics Runner pod 'example' failed (CrashLoopBackOff)") {
+		t.Errorf("unexpected event: %s", e)
+	}
+
+	var cm corev1.ConfigMap
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-diagnostics"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"pod":        "example",
+		"reason":     "CrashLoopBackOff",
+		"runner.log": "fake logs",
+		"docker.log": "fake logs",
+	}
+
+	if d := cmp.Diff(want, cm.Data); d != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", d)
+	}
+
+	// Collected once per crash
+	var updated corev1.Pod
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	r.checkCrashLoop(ctx, runner, updated, logr.Discard())
+
+	if n := len(recorder.Events); n != 0 {
+		t.Errorf("unexpected number of events for the same crash: want 0, got %d", n)
+	}
+
+	// The ConfigMap is replaced on the next crash
+	updated.Status.ContainerStatuses[0].RestartCount = 4
+
+	r.checkCrashLoop(ctx, runner, updated, logr.Discard())
+
+	if n := len(recorder.Events); n != 1 {
+		t.Errorf("unexpected number of events for the next crash: want 1, got %d", n)
+	}
+
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-diagnostics"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff(want, cm.Data); d != "" {
+		t.Errorf("unexpected diagnostics after the next crash (-want +got):\n%s", d)
+	}
+}
+
+func TestDiagnosticsEventMessage(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", 19)+"\n")
+	}
+
+	msg := diagnosticsEventMessage("example", DiagnosticsReasonRegistrationTimeout, strings.Join(lines, ""))
+
+	if len(msg) > diagnosticsEventMessageMaxLength {
+		t.Errorf("unexpected length of the message: %d", len(msg))
+	}
+
+	header := "Runner pod 'example' failed (RegistrationTimeout). Last lines of the runner logs:\n"
+
+	if !strings.HasPrefix(msg, header) {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	// Only whole lines are kept
+	for _, l := range strings.Split(strings.TrimPrefix(msg, header), "\n") {
+		if len(l) != 19 {
+			t.Errorf("unexpected line in the message: %q", l)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+)
+
+const (
+	DefaultRunnerFleetStatusName = "default"
+)
+
+// RunnerFleetStatusAggregator periodically sums the runners, the busy runners and the queued demand of all the
+// namespaces into the cluster-scoped RunnerFleetStatus, along with the counts per RunnerDeployment and RunnerSet and
+// the GitHub API rate limit last observed by the controller.
+//
+// Everything is read from the informer cache and the capacity reservations recorded by the webhook-based autoscaler,
+// so the aggregation costs no GitHub API call.
+// It implements manager.Runnable so that it can be added to the controller manager.
+type RunnerFleetStatusAggregator struct {
+	client.Client
+	// Reader reads the RunnerFleetStatus without the cache, which doesn't watch cluster-scoped resources
+	// when the controller watches specific namespaces. Defaults to Client.
+	Reader client.Reader
+	Log    logr.Logger
+
+	// Name is the name of the RunnerFleetStatus. Defaults to DefaultRunnerFleetStatusName.
+	Name string
+
+	// Interval is the interval between the aggregations.
+	Interval time.Duration
+
+	// now and rateLimit are overridden in tests
+	now       func() time.Time
+	rateLimit func() *metrics.RateLimit
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerfleetstatuses,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerfleetstatuses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners;runnerdeployments;runnersets;horizontalrunnerautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader writes the status.
+func (a *RunnerFleetStatusAggregator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable to aggregate the status every Interval until the context is done.
+// A failed aggregation is retried at the next interval, leaving the previous status in place.
+func (a *RunnerFleetStatusAggregator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Aggregate(ctx); err != nil {
+			a.Log.Error(err, "Failed to aggregate runner fleet status")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Aggregate computes the status and writes it to the RunnerFleetStatus, creating it if it doesn't exist yet.
+func (a *RunnerFleetStatusAggregator) Aggregate(ctx context.Context) error {
+	now := time.Now()
+	if a.now != nil {
+		now = a.now()
+	}
+
+	rateLimit := metrics.LastRateLimit
+	if a.rateLimit != nil {
+		rateLimit = a.rateLimit
+	}
+
+	status, err := a.computeStatus(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	status.APIBudget = newGitHubAPIBudget(rateLimit())
+
+	name := a.Name
+	if name == "" {
+		name = DefaultRunnerFleetStatusName
+	}
+
+	reader := a.Reader
+	if reader == nil {
+		reader = a.Client
+	}
+
+	var fleet v1alpha1.RunnerFleetStatus
+
+	if err := reader.Get(ctx, types.NamespacedName{Name: name}, &fleet); kerrors.IsNotFound(err) {
+		fleet = v1alpha1.RunnerFleetStatus{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+		if err := a.Create(ctx, &fleet); err != nil {
+			return fmt.Errorf("creating runner fleet status %s: %w", name, err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	updated := fleet.DeepCopy()
+	updated.Status = status
+
+	if err := a.Status().Patch(ctx, updated, client.MergeFrom(&fleet)); err != nil {
+		return fmt.Errorf("patching runner fleet status %s: %w", name, err)
+	}
+
+	a.Log.V(1).Info("Aggregated runner fleet status",
+		"runners", status.Runners,
+		"busy_runners", status.BusyRunners,
+		"queued_demand", status.QueuedDemand,
+		"idle_runners", status.IdleRunners,
+		"namespaces", len(status.Namespaces),
+	)
+
+	return nil
+}
+
+func (a *RunnerFleetStatusAggregator) computeStatus(ctx context.Context, now time.Time) (v1alpha1.RunnerFleetStatusStatus, error) {
+	namespaces := map[string]*v1alpha1.RunnerFleetCounts{}
+
+	counts := func(ns string) *v1alpha1.RunnerFleetCounts {
+		c, ok := namespaces[ns]
+		if !ok {
+			c = &v1alpha1.RunnerFleetCounts{}
+			namespaces[ns] = c
+		}
+		return c
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := a.List(ctx, &runners); err != nil {
+		return v1alpha1.RunnerFleetStatusStatus{}, err
+	}
+
+	for _, r := range runners.Items {
+		c := counts(r.Namespace)
+		c.Runners++
+		if r.Status.Ready {
+			c.ReadyRunners++
+		}
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := a.List(ctx, &rds); err != nil {
+		return v1alpha1.RunnerFleetStatusStatus{}, err
+	}
+
+	var scaleTargets []v1alpha1.RunnerFleetScaleTargetStatus
+
+	for _, rd := range rds.Items {
+		counts(rd.Namespace).UnschedulableRunners += getIntOrDefault(rd.Status.UnschedulableReplicas, 0)
+
+		scaleTargets = append(scaleTargets, v1alpha1.RunnerFleetScaleTargetStatus{
+			Kind:           "RunnerDeployment",
+			Namespace:      rd.Namespace,
+			Name:           rd.Name,
+			DesiredRunners: getIntOrDefault(rd.Status.DesiredReplicas, getIntOrDefault(rd.Spec.Replicas, defaultReplicas)),
+			ReadyRunners:   getIntOrDefault(rd.Status.ReadyReplicas, 0),
+		})
+	}
+
+	var runnerSets v1alpha1.RunnerSetList
+	if err := a.List(ctx, &runnerSets); err != nil {
+		return v1alpha1.RunnerFleetStatusStatus{}, err
+	}
+
+	for _, rs := range runnerSets.Items {
+		c := counts(rs.Namespace)
+		c.Runners += getIntOrDefault(rs.Status.CurrentReplicas, 0)
+		c.ReadyRunners += getIntOrDefault(rs.Status.ReadyReplicas, 0)
+
+		desired := defaultReplicas
+		if rs.Spec.Replicas != nil {
+			desired = int(*rs.Spec.Replicas)
+		}
+
+		scaleTargets = append(scaleTargets, v1alpha1.RunnerFleetScaleTargetStatus{
+			Kind:           "RunnerSet",
+			Namespace:      rs.Namespace,
+			Name:           rs.Name,
+			DesiredRunners: desired,
+			ReadyRunners:   getIntOrDefault(rs.Status.ReadyReplicas, 0),
+		})
+	}
+
+	if len(runnerSets.Items) > 0 {
+		// RunnerSets have no status for unschedulable pods, so their pods are counted instead
+		var pods corev1.PodList
+		if err := a.List(ctx, &pods, client.HasLabels{LabelKeyRunnerSetName}); err != nil {
+			return v1alpha1.RunnerFleetStatusStatus{}, err
+		}
+
+		for _, pod := range pods.Items {
+			if unschedulable, _ := podUnschedulable(pod); unschedulable {
+				counts(pod.Namespace).UnschedulableRunners++
+			}
+		}
+	}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+	if err := a.List(ctx, &hras); err != nil {
+		return v1alpha1.RunnerFleetStatusStatus{}, err
+	}
+
+	busyByScaleTarget := map[types.NamespacedName]map[string]int{}
+
+	for _, hra := range hras.Items {
+		c := counts(hra.Namespace)
+
+		busy, queued := getBusyAndQueuedReplicas(hra, now)
+		c.BusyRunners += busy
+		c.QueuedDemand += queued
+		c.DesiredRunners += getIntOrDefault(hra.Status.DesiredReplicas, 0)
+
+		kind := hra.Spec.ScaleTargetRef.Kind
+		if kind == "" {
+			kind = "RunnerDeployment"
+		}
+
+		key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+		if busyByScaleTarget[key] == nil {
+			busyByScaleTarget[key] = map[string]int{}
+		}
+		busyByScaleTarget[key][kind] += busy
+	}
+
+	for i := range scaleTargets {
+		st := &scaleTargets[i]
+		st.BusyRunners = busyByScaleTarget[types.NamespacedName{Namespace: st.Namespace, Name: st.Name}][st.Kind]
+		st.IdleRunners = idleRunners(st.ReadyRunners, st.BusyRunners)
+	}
+
+	sort.SliceStable(scaleTargets, func(i, j int) bool {
+		a, b := scaleTargets[i], scaleTargets[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	var names []string
+	for ns := range namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+
+	status := v1alpha1.RunnerFleetStatusStatus{ObservedTime: &metav1.Time{Time: now}, ScaleTargets: scaleTargets}
+
+	for _, ns := range names {
+		c := *namespaces[ns]
+		c.BusyPercent = busyPercent(c.BusyRunners, c.Runners)
+		c.IdleRunners = idleRunners(c.ReadyRunners, c.BusyRunners)
+
+		status.Namespaces = append(status.Namespaces, v1alpha1.RunnerFleetNamespaceStatus{Namespace: ns, RunnerFleetCounts: c})
+
+		status.Runners += c.Runners
+		status.ReadyRunners += c.ReadyRunners
+		status.BusyRunners += c.BusyRunners
+		status.IdleRunners += c.IdleRunners
+		status.QueuedDemand += c.QueuedDemand
+		status.DesiredRunners += c.DesiredRunners
+		status.UnschedulableRunners += c.UnschedulableRunners
+	}
+
+	status.BusyPercent = busyPercent(status.BusyRunners, status.Runners)
+
+	return status, nil
+}
+
+// getBusyAndQueuedReplicas returns the replicas reserved for the workflow jobs that runners have picked up,
+// and the ones reserved for the queued jobs. Optimistic reservations are neither, because they aren't for actual jobs.
+func getBusyAndQueuedReplicas(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time) (busy, queued int) {
+	for _, reservation := range hra.Spec.CapacityReservations {
+		if !reservation.ExpirationTime.Time.After(now) || reservation.Optimistic {
+			continue
+		}
+
+		if reservation.RunnerName != "" {
+			busy += reservation.Replicas
+		} else {
+			queued += reservation.Replicas
+		}
+	}
+
+	return busy, queued
+}
+
+func busyPercent(busy, runners int) int {
+	if runners == 0 {
+		return 0
+	}
+
+	return busy * 100 / runners
+}
+
+// idleRunners returns the ready runners that aren't busy.
+// The busy runners can outnumber the ready ones for a while, as the busy runners are recorded from webhook events
+// while the ready runners are read from the cache.
+func idleRunners(ready, busy int) int {
+	if busy >= ready {
+		return 0
+	}
+
+	return ready - busy
+}
+
+func newGitHubAPIBudget(rateLimit *metrics.RateLimit) *v1alpha1.GitHubAPIBudget {
+	if rateLimit == nil {
+		return nil
+	}
+
+	budget := &v1alpha1.GitHubAPIBudget{
+		Limit:        rateLimit.Limit,
+		Remaining:    rateLimit.Remaining,
+		ObservedTime: metav1.Time{Time: rateLimit.ObservedAt},
+	}
+
+	if !rateLimit.Reset.IsZero() {
+		budget.ResetTime = &metav1.Time{Time: rateLimit.Reset}
+	}
+
+	return budget
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+)
+
+func TestRunnerFleetStatusAggregator(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := metav1.Time{Time: now.Add(time.Minute)}
+	expired := metav1.Time{Time: now.Add(-time.Minute)}
+
+	newRunner := func(ns, name string, ready bool) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+			Status:     v1alpha1.RunnerStatus{Ready: ready},
+		}
+	}
+
+	unschedulable := 1
+	desired := 4
+	current, ready := 2, 1
+	rdDesired, rdReady := 3, 2
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rd"},
+		Status: v1alpha1.RunnerDeploymentStatus{
+			UnschedulableReplicas: &unschedulable,
+			DesiredReplicas:       &rdDesired,
+			ReadyReplicas:         &rdReady,
+		},
+	}
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "hra"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "rd"},
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{ExpirationTime: valid, Replicas: 1, RunnerName: "runner1"},
+				{ExpirationTime: valid, Replicas: 1},
+				{ExpirationTime: valid, Replicas: 1},
+				{ExpirationTime: valid, Replicas: 1, Optimistic: true},
+				{ExpirationTime: expired, Replicas: 1},
+			},
+		},
+		Status: v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: &desired},
+	}
+
+	rs := &v1alpha1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "rs"},
+		Status:     v1alpha1.RunnerSetStatus{CurrentReplicas: &current, ReadyReplicas: &ready},
+	}
+
+	rsPod := newPendingPod("rs-0", corev1.PodReasonUnschedulable, "")
+	rsPod.Namespace = "ns2"
+	rsPod.Labels = map[string]string{LabelKeyRunnerSetName: "rs"}
+
+	c := fake.NewFakeClientWithScheme(sc,
+		newRunner("ns1", "runner1", true),
+		newRunner("ns1", "runner2", true),
+		newRunner("ns1", "runner3", false),
+		rd,
+		hra,
+		rs,
+		&rsPod,
+	)
+
+	reset := now.Add(30 * time.Minute)
+
+	a := &RunnerFleetStatusAggregator{
+		Client: c,
+		Log:    logr.Discard(),
+		now:    func() time.Time { return now },
+		rateLimit: func() *metrics.RateLimit {
+			return &metrics.RateLimit{Limit: 5000, Remaining: 4000, Reset: reset, ObservedAt: now}
+		},
+	}
+
+	if err := a.Aggregate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var fleet v1alpha1.RunnerFleetStatus
+	if err := c.Get(ctx, types.NamespacedName{Name: DefaultRunnerFleetStatusName}, &fleet); err != nil {
+		t.Fatal(err)
+	}
+
+	want := v1alpha1.RunnerFleetStatusStatus{
+		ObservedTime: &metav1.Time{Time: now},
+		RunnerFleetCounts: v1alpha1.RunnerFleetCounts{
+			Runners:              5,
+			ReadyRunners:         3,
+			BusyRunners:          1,
+			BusyPercent:          20,
+			IdleRunners:          2,
+			QueuedDemand:         2,
+			DesiredRunners:       4,
+			UnschedulableRunners: 2,
+		},
+		APIBudget: &v1alpha1.GitHubAPIBudget{
+			Limit:        5000,
+			Remaining:    4000,
+			ResetTime:    &metav1.Time{Time: reset},
+			ObservedTime: metav1.Time{Time: now},
+		},
+		Namespaces: []v1alpha1.RunnerFleetNamespaceStatus{
+			{
+				Namespace: "ns1",
+				RunnerFleetCounts: v1alpha1.RunnerFleetCounts{
+					Runners:              3,
+					ReadyRunners:         2,
+					BusyRunners:          1,
+					BusyPercent:          33,
+					IdleRunners:          1,
+					QueuedDemand:         2,
+					DesiredRunners:       4,
+					UnschedulableRunners: 1,
+				},
+			},
+			{
+				Namespace: "ns2",
+				RunnerFleetCounts: v1alpha1.RunnerFleetCounts{
+					Runners:              2,
+					ReadyRunners:         1,
+					IdleRunners:          1,
+					UnschedulableRunners: 1,
+				},
+			},
+		},
+		ScaleTargets: []v1alpha1.RunnerFleetScaleTargetStatus{
+			{Kind: "RunnerDeployment", Namespace: "ns1", Name: "rd", DesiredRunners: 3, ReadyRunners: 2, BusyRunners: 1, IdleRunners: 1},
+			{Kind: "RunnerSet", Namespace: "ns2", Name: "rs", DesiredRunners: 1, ReadyRunners: 1, IdleRunners: 1},
+		},
+	}
+
+	if d := cmp.Diff(want, fleet.Status); d != "" {
+		t.Errorf("unexpected status: %s", d)
+	}
+
+	// The existing status is replaced on the next aggregation
+	if err := c.Delete(ctx, hra); err != nil {
+		t.Fatal(err)
+	}
+
+	a.rateLimit = func() *metrics.RateLimit { return nil }
+
+	if err := a.Aggregate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Get(ctx, types.NamespacedName{Name: DefaultRunnerFleetStatusName}, &fleet); err != nil {
+		t.Fatal(err)
+	}
+
+	if fleet.Status.BusyRunners != 0 || fleet.Status.QueuedDemand != 0 || fleet.Status.APIBudget != nil {
+		t.Errorf("unexpected status after the update: %+v", fleet.Status)
+	}
+}
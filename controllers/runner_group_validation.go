@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+// runnerGroupRecheckInterval is how often the runner group of an unchanged runner deployment is validated again,
+// so that the groups created, deleted, or given access to the repositories later are reflected without
+// calling the GitHub API on every reconciliation.
+const runnerGroupRecheckInterval = 10 * time.Minute
+
+// runnerGroupCheck is the last validation of the runner group of a runner deployment.
+type runnerGroupCheck struct {
+	generation int64
+	checkedAt  time.Time
+}
+
+// validateRunnerGroup returns the RunnerGroupValid condition of the runner deployment, telling whether spec.group
+// exists in the organization or the enterprise and can be used by the repositories the runners are scaled for.
+// Without the validation, the runners fail to register or, for the runners whose group is ignored, silently register into the default group.
+//
+// The condition is nil when there's nothing to validate. The returned bool is false when the condition should be left as is,
+// like when the last validation is still fresh or the GitHub server lacks the runner groups API.
+func (r *RunnerDeploymentReconciler) validateRunnerGroup(ctx context.Context, rd v1alpha1.RunnerDeployment, now time.Time) (*metav1.Condition, bool, error) {
+	spec := rd.Spec.Template.Spec
+
+	if spec.Group == "" {
+		return nil, true, nil
+	}
+
+	key := types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}
+
+	r.runnerGroupChecksMu.Lock()
+	last, ok := r.runnerGroupChecks[key]
+	r.runnerGroupChecksMu.Unlock()
+
+	if ok && last.generation == rd.Generation && now.Sub(last.checkedAt) < runnerGroupRecheckInterval &&
+		meta.FindStatusCondition(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeRunnerGroupValid) != nil {
+		return nil, false, nil
+	}
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.RunnerDeploymentConditionTypeRunnerGroupValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1alpha1.RunnerDeploymentConditionReasonRunnerGroupFound,
+		ObservedGeneration: rd.Generation,
+	}
+
+	if spec.Repository != "" {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = v1alpha1.RunnerDeploymentConditionReasonRunnerGroupNotApplicable
+		cond.Message = fmt.Sprintf("Runner group %q is ignored, as repository runners can't be added to runner groups. Use organization or enterprise runners instead.", spec.Group)
+
+		r.markRunnerGroupChecked(key, rd.Generation, now)
+
+		return &cond, true, nil
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rd.Namespace, spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ghc == nil {
+		return nil, false, nil
+	}
+
+	if err := ghc.CheckCapability(ctx, github.CapabilityRunnerGroups); err != nil {
+		return nil, false, nil
+	}
+
+	runnerGroup, err := ghc.GetRunnerGroup(ctx, spec.Enterprise, spec.Organization, spec.Group)
+	if err != nil {
+		return nil, false, err
+	}
+
+	owner := spec.Organization
+	if owner == "" {
+		owner = "enterprise " + spec.Enterprise
+	}
+
+	if runnerGroup == nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = v1alpha1.RunnerDeploymentConditionReasonRunnerGroupNotFound
+		cond.Message = fmt.Sprintf("Runner group %q doesn't exist in %s.", spec.Group, owner)
+
+		r.markRunnerGroupChecked(key, rd.Generation, now)
+
+		return &cond, true, nil
+	}
+
+	cond.Message = fmt.Sprintf("Runner group %q exists in %s.", spec.Group, owner)
+
+	// The access of the repositories is granted per organization, so it's checked only for the organization runners
+	if spec.Organization != "" {
+		repos, err := r.runnerGroupRepositories(ctx, rd)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var denied []string
+
+		for _, repo := range repos {
+			ok, err := ghc.HasRepositoryAccessToRunnerGroup(ctx, spec.Organization, runnerGroup, spec.Organization+"/"+repo)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if !ok {
+				denied = append(denied, repo)
+			}
+		}
+
+		if len(denied) > 0 {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = v1alpha1.RunnerDeploymentConditionReasonRunnerGroupRepositoryAccessDenied
+			cond.Message = fmt.Sprintf("Runner group %q exists in %s but the repositories %s have no access to it.", spec.Group, owner, strings.Join(denied, ", "))
+		}
+	}
+
+	r.markRunnerGroupChecked(key, rd.Generation, now)
+
+	return &cond, true, nil
+}
+
+func (r *RunnerDeploymentReconciler) markRunnerGroupChecked(key types.NamespacedName, generation int64, now time.Time) {
+	r.runnerGroupChecksMu.Lock()
+	defer r.runnerGroupChecksMu.Unlock()
+
+	if r.runnerGroupChecks == nil {
+		r.runnerGroupChecks = map[types.NamespacedName]runnerGroupCheck{}
+	}
+
+	r.runnerGroupChecks[key] = runnerGroupCheck{generation: generation, checkedAt: now}
+}
+
+// runnerGroupRepositories returns the repositories that the HRAs scale the runner deployment for,
+// which are the repositories whose workflow jobs are expected to run on the runners.
+func (r *RunnerDeploymentReconciler) runnerGroupRepositories(ctx context.Context, rd v1alpha1.RunnerDeployment) ([]string, error) {
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hraList, client.InNamespace(rd.Namespace)); err != nil {
+		return nil, fmt.Errorf("listing horizontalrunnerautoscalers: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	var repos []string
+
+	for _, hra := range hraList.Items {
+		ref := hra.Spec.ScaleTargetRef
+		if (ref.Kind != "" && ref.Kind != "RunnerDeployment") || ref.Name != rd.Name {
+			continue
+		}
+
+		for _, m := range hra.Spec.Metrics {
+			for _, repo := range m.RepositoryNames {
+				if !seen[repo] {
+					seen[repo] = true
+					repos = append(repos, repo)
+				}
+			}
+		}
+	}
+
+	sort.Strings(repos)
+
+	return repos, nil
+}
+
+// setRunnerDeploymentRunnerGroupValidCondition sets the RunnerGroupValid condition of the runner deployment,
+// or removes it when cond is nil, and returns true if the condition has changed.
+func setRunnerDeploymentRunnerGroupValidCondition(rd *v1alpha1.RunnerDeployment, cond *metav1.Condition) bool {
+	prev := meta.FindStatusCondition(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeRunnerGroupValid)
+
+	if cond == nil {
+		meta.RemoveStatusCondition(&rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeRunnerGroupValid)
+
+		return prev != nil
+	}
+
+	if prev != nil && prev.Status == cond.Status && prev.Reason == cond.Reason && prev.Message == cond.Message && prev.ObservedGeneration == cond.ObservedGeneration {
+		return false
+	}
+
+	meta.SetStatusCondition(&rd.Status.Conditions, *cond)
+
+	return true
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	githubfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+)
+
+func TestValidateRunnerGroup(t *testing.T) {
+	server := githubfake.NewServer(githubfake.WithListRunnersResponse(200, githubfake.RunnersListBody))
+	defer server.Close()
+
+	newRD := func(enterprise, org, repo, group string) v1alpha1.RunnerDeployment {
+		rd := v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", Generation: 1},
+		}
+		rd.Spec.Template.Spec.Enterprise = enterprise
+		rd.Spec.Template.Spec.Organization = org
+		rd.Spec.Template.Spec.Repository = repo
+		rd.Spec.Template.Spec.Group = group
+		return rd
+	}
+
+	newHRA := func(repos ...string) *v1alpha1.HorizontalRunnerAutoscaler {
+		return &v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+				Metrics:        []v1alpha1.MetricSpec{{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns, RepositoryNames: repos}},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name       string
+		rd         v1alpha1.RunnerDeployment
+		hra        *v1alpha1.HorizontalRunnerAutoscaler
+		wantReason string
+	}{
+		{
+			name: "no group",
+			rd:   newRD("", "test", "", ""),
+		},
+		{
+			name:       "repository runners",
+			rd:         newRD("", "", "test/valid", "selected"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupNotApplicable,
+		},
+		{
+			name:       "missing organization group",
+			rd:         newRD("", "test", "", "missing"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupNotFound,
+		},
+		{
+			name:       "inherited enterprise group",
+			rd:         newRD("", "test", "", "enterprise"),
+			hra:        newHRA("other"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupFound,
+		},
+		{
+			name:       "selected repositories with access",
+			rd:         newRD("", "test", "", "selected"),
+			hra:        newHRA("valid"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupFound,
+		},
+		{
+			name:       "selected repositories without access",
+			rd:         newRD("", "test", "", "selected"),
+			hra:        newHRA("valid", "other"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupRepositoryAccessDenied,
+		},
+		{
+			name:       "enterprise group",
+			rd:         newRD("test", "", "", "enterprise"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupFound,
+		},
+		{
+			name:       "missing enterprise group",
+			rd:         newRD("test", "", "", "selected"),
+			wantReason: v1alpha1.RunnerDeploymentConditionReasonRunnerGroupNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var objs []runtime.Object
+			if tc.hra != nil {
+				objs = append(objs, tc.hra)
+			}
+
+			r := &RunnerDeploymentReconciler{
+				Client:       fake.NewFakeClientWithScheme(sc, objs...),
+				GitHubClient: newGithubClient(server),
+			}
+
+			now := time.Now()
+
+			cond, checked, err := r.validateRunnerGroup(context.Background(), tc.rd, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !checked {
+				t.Fatalf("expected the runner group to be validated")
+			}
+
+			var reason string
+			if cond != nil {
+				reason = cond.Reason
+			}
+
+			if d := cmp.Diff(tc.wantReason, reason); d != "" {
+				t.Fatalf("unexpected reason (-want +got):\n%s", d)
+			}
+
+			if cond == nil {
+				return
+			}
+
+			rd := tc.rd.DeepCopy()
+			if !setRunnerDeploymentRunnerGroupValidCondition(rd, cond) {
+				t.Errorf("expected the condition to be set")
+			}
+
+			// The fresh validation isn't repeated until the recheck interval
+			if _, checked, _ := r.validateRunnerGroup(context.Background(), *rd, now.Add(time.Minute)); checked {
+				t.Errorf("unexpected validation within the recheck interval")
+			}
+
+			if _, checked, _ := r.validateRunnerGroup(context.Background(), *rd, now.Add(runnerGroupRecheckInterval)); !checked {
+				t.Errorf("expected the validation after the recheck interval")
+			}
+		})
+	}
+}
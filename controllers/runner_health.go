@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// The environment variables that configure the health check of the runner container. See runner/hooks/health-check.sh.
+	envVarHealthCheckDiskUsagePercent   = "RUNNER_HEALTH_CHECK_DISK_USAGE_PERCENT"
+	envVarHealthCheckMemoryUsagePercent = "RUNNER_HEALTH_CHECK_MEMORY_USAGE_PERCENT"
+	envVarHealthCheckRecycle            = "RUNNER_HEALTH_CHECK_RECYCLE"
+	envVarHealthCheckStrict             = "RUNNER_HEALTH_CHECK_STRICT"
+
+	healthCheckPath = "/etc/arc/hooks/health-check.sh"
+
+	defaultHealthCheckDiskUsagePercent   = 90
+	defaultHealthCheckMemoryUsagePercent = 90
+	defaultHealthCheckPeriodSeconds      = 30
+
+	// healthCheckTimeoutSeconds leaves enough time for the health check to wait for the unresponsive dockerd
+	healthCheckTimeoutSeconds = 15
+
+	// reasonRunnerUnhealthy is the reason written to the termination message of the runner container by the health check
+	// that stopped the unhealthy runner, and the reason of the event emitted for it.
+	reasonRunnerUnhealthy = "RunnerUnhealthy"
+)
+
+// runnerHealthReport is the termination message the health check writes on stopping the unhealthy runner.
+type runnerHealthReport struct {
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	DiskUsagePercent   *int   `json:"diskUsagePercent"`
+	MemoryUsagePercent *int   `json:"memoryUsagePercent"`
+	DockerdAlive       *bool  `json:"dockerdAlive"`
+}
+
+// newHealthCheckConfig returns the environment variables that configure the health check,
+// and the readiness probe of the runner container that runs it.
+// The job hook that recycles the unhealthy runner is configured by newJobHooksConfig.
+func newHealthCheckConfig(healthCheck *v1alpha1.RunnerHealthCheck) ([]corev1.EnvVar, *corev1.Probe) {
+	if healthCheck == nil {
+		return nil, nil
+	}
+
+	diskUsagePercent := defaultHealthCheckDiskUsagePercent
+	if healthCheck.DiskUsagePercent != nil {
+		diskUsagePercent = *healthCheck.DiskUsagePercent
+	}
+
+	memoryUsagePercent := defaultHealthCheckMemoryUsagePercent
+	if healthCheck.MemoryUsagePercent != nil {
+		memoryUsagePercent = *healthCheck.MemoryUsagePercent
+	}
+
+	var periodSeconds int32 = defaultHealthCheckPeriodSeconds
+	if healthCheck.PeriodSeconds != nil {
+		periodSeconds = *healthCheck.PeriodSeconds
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  envVarHealthCheckDiskUsagePercent,
+			Value: strconv.Itoa(diskUsagePercent),
+		},
+		{
+			Name:  envVarHealthCheckMemoryUsagePercent,
+			Value: strconv.Itoa(memoryUsagePercent),
+		},
+	}
+
+	if healthCheck.Recycle {
+		env = append(env, corev1.EnvVar{
+			Name:  envVarHealthCheckRecycle,
+			Value: "true",
+		})
+
+		if healthCheck.Strict {
+			env = append(env, corev1.EnvVar{
+				Name:  envVarHealthCheckStrict,
+				Value: "true",
+			})
+		}
+	}
+
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{healthCheckPath},
+			},
+		},
+		PeriodSeconds:  periodSeconds,
+		TimeoutSeconds: healthCheckTimeoutSeconds,
+	}
+
+	return env, probe
+}
+
+// getRunnerHealthReport returns the report written to the termination message of the runner container
+// by the health check that stopped the unhealthy runner, or nil if the runner container hasn't been stopped by it.
+// The last termination state is checked too, because the container may have already been restarted by kubelet.
+func getRunnerHealthReport(pod corev1.Pod) *runnerHealthReport {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+
+			var r runnerHealthReport
+
+			if err := json.Unmarshal([]byte(terminated.Message), &r); err != nil || r.Reason != reasonRunnerUnhealthy {
+				continue
+			}
+
+			return &r
+		}
+	}
+
+	return nil
+}
+
+// recordRunnerRecycled emits the event for the unhealthy runner whose pod has been recreated.
+func recordRunnerRecycled(recorder record.EventRecorder, obj runtime.Object, r runnerHealthReport) {
+	recorder.Event(obj, corev1.EventTypeWarning, reasonRunnerUnhealthy, fmt.Sprintf("Recreated the runner pod stopped by the health check: %s", r.Message))
+}
+
+// runnerHealthCondition returns the Healthy condition of the runner observed from its pod,
+// or nil when it can't be told yet, like while the runner is registering itself.
+func runnerHealthCondition(runner v1alpha1.Runner, pod corev1.Pod) *metav1.Condition {
+	cond := &metav1.Condition{
+		Type:               v1alpha1.RunnerConditionTypeHealthy,
+		ObservedGeneration: runner.Generation,
+	}
+
+	if r := getRunnerHealthReport(pod); r != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = v1alpha1.RunnerConditionReasonRunnerRecycled
+		cond.Message = fmt.Sprintf("The health check stopped the runner after the job to recreate the runner pod: %s", r.Message)
+
+		return cond
+	}
+
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+
+		// The readiness probe running the health check starts after the startup probe succeeds
+		if status.State.Running == nil || status.Started == nil || !*status.Started {
+			return nil
+		}
+
+		if status.Ready {
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = v1alpha1.RunnerConditionReasonHealthCheckPassed
+			cond.Message = "The runner container passed the health check"
+		} else {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = v1alpha1.RunnerConditionReasonHealthCheckFailed
+			cond.Message = fmt.Sprintf("The runner container is failing the health check. See the events of pod '%s' for the details", pod.Name)
+		}
+
+		return cond
+	}
+
+	return nil
+}
+
+// updateHealthCondition updates the Healthy condition of the runner with the one observed from its pod,
+// or removes it when the health check is disabled.
+func (r *RunnerReconciler) updateHealthCondition(ctx context.Context, runner *v1alpha1.Runner, pod corev1.Pod) error {
+	updated := runner.DeepCopy()
+
+	if runner.Spec.HealthCheck == nil {
+		meta.RemoveStatusCondition(&updated.Status.Conditions, v1alpha1.RunnerConditionTypeHealthy)
+	} else if cond := runnerHealthCondition(*runner, pod); cond != nil {
+		meta.SetStatusCondition(&updated.Status.Conditions, *cond)
+	}
+
+	if equality.Semantic.DeepEqual(runner.Status.Conditions, updated.Status.Conditions) {
+		return nil
+	}
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(runner)); err != nil {
+		return fmt.Errorf("updating runner status for the healthy condition: %w", err)
+	}
+
+	*runner = *updated
+
+	return nil
+}
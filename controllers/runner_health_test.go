@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestNewRunnerPod_HealthCheck(t *testing.T) {
+	diskUsagePercent := 80
+
+	pod, err := newRunnerPod(corev1.Pod{}, v1alpha1.RunnerConfig{
+		HealthCheck: &v1alpha1.RunnerHealthCheck{DiskUsagePercent: &diskUsagePercent, Recycle: true},
+	}, "runner:latest", nil, "docker:dind", "", "https://github.com", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := pod.Spec.Containers[0]
+
+	env := map[string]string{}
+	for _, e := range runner.Env {
+		env[e.Name] = e.Value
+	}
+
+	for name, want := range map[string]string{
+		envVarHealthCheckDiskUsagePercent:   "80",
+		envVarHealthCheckMemoryUsagePercent: "90",
+		envVarHealthCheckRecycle:            "true",
+		envVarHealthCheckStrict:             "",
+		envVarJobCompletedHook:              jobCompletedHookPath,
+	} {
+		if env[name] != want {
+			t.Errorf("unexpected %s: want %q, got %q", name, want, env[name])
+		}
+	}
+
+	probe := runner.ReadinessProbe
+	if probe == nil || probe.Exec == nil || probe.Exec.Command[0] != healthCheckPath || probe.PeriodSeconds != defaultHealthCheckPeriodSeconds {
+		t.Errorf("unexpected readiness probe: %+v", probe)
+	}
+}
+
+func TestRunnerHealthCondition(t *testing.T) {
+	started := true
+	report := `{"reason":"RunnerUnhealthy","message":"dockerd is not responding","diskUsagePercent":42,"memoryUsagePercent":null,"dockerdAlive":false}`
+
+	podWith := func(phase corev1.PodPhase, status corev1.ContainerStatus) corev1.Pod {
+		status.Name = containerName
+
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "example"},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "docker"}, status},
+			},
+		}
+	}
+
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+
+	testcases := []struct {
+		name       string
+		pod        corev1.Pod
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "pending",
+			pod:  podWith(corev1.PodPending, corev1.ContainerStatus{}),
+		},
+		{
+			name: "registering",
+			pod:  podWith(corev1.PodRunning, corev1.ContainerStatus{State: running}),
+		},
+		{
+			name:       "healthy",
+			pod:        podWith(corev1.PodRunning, corev1.ContainerStatus{State: running, Started: &started, Ready: true}),
+			wantStatus: metav1.ConditionTrue,
+			wantReason: v1alpha1.RunnerConditionReasonHealthCheckPassed,
+		},
+		{
+			name:       "failing",
+			pod:        podWith(corev1.PodRunning, corev1.ContainerStatus{State: running, Started: &started}),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: v1alpha1.RunnerConditionReasonHealthCheckFailed,
+		},
+		{
+			name: "recycled",
+			pod: podWith(corev1.PodRunning, corev1.ContainerStatus{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: report}},
+			}),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: v1alpha1.RunnerConditionReasonRunnerRecycled,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := runnerHealthCondition(v1alpha1.Runner{}, tc.pod)
+
+			if tc.wantStatus == "" {
+				if got != nil {
+					t.Errorf("expected no condition, got %+v", got)
+				}
+				return
+			}
+
+			if got == nil || got.Status != tc.wantStatus || got.Reason != tc.wantReason {
+				t.Errorf("unexpected condition: want %s/%s, got %+v", tc.wantStatus, tc.wantReason, got)
+			}
+		})
+	}
+
+	alive := false
+	diskUsagePercent := 42
+	want := &runnerHealthReport{
+		Reason:           reasonRunnerUnhealthy,
+		Message:          "dockerd is not responding",
+		DiskUsagePercent: &diskUsagePercent,
+		DockerdAlive:     &alive,
+	}
+
+	pod := podWith(corev1.PodRunning, corev1.ContainerStatus{
+		State:                running,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: report}},
+	})
+
+	if d := cmp.Diff(want, getRunnerHealthReport(pod)); d != "" {
+		t.Errorf("unexpected health report: %s", d)
+	}
+}
+
+func TestUpdateHealthCondition(t *testing.T) {
+	started := true
+
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{HealthCheck: &v1alpha1.RunnerHealthCheck{}},
+		},
+	}
+
+	pod := corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: containerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, Started: &started},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	client := fake.NewFakeClientWithScheme(sc, runner)
+	r := &RunnerReconciler{Client: client}
+
+	if err := r.updateHealthCondition(ctx, runner, pod); err != nil {
+		t.Fatal(err)
+	}
+
+	var got v1alpha1.Runner
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.IsStatusConditionFalse(got.Status.Conditions, v1alpha1.RunnerConditionTypeHealthy) {
+		t.Errorf("expected the runner to be unhealthy: %+v", got.Status.Conditions)
+	}
+
+	got.Spec.HealthCheck = nil
+
+	if err := r.updateHealthCondition(ctx, &got, pod); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Status.Conditions) != 0 {
+		t.Errorf("expected the healthy condition to be removed: %+v", got.Status.Conditions)
+	}
+}
+
+// TestStrictRecycle runs the job hooks in runner/hooks to check that the job the runner picks up
+// while the health check after the previous job is running never starts on the runner being recycled.
+func TestStrictRecycle(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+
+	hook := func(name string) string {
+		return filepath.Join("..", "runner", "hooks", name)
+	}
+
+	run := func(env []string, name string, args ...string) error {
+		cmd := exec.Command("bash", append([]string{hook(name)}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		return cmd.Run()
+	}
+
+	testcases := []struct {
+		name string
+		// resolve is the content the health check writes after the next job has started, or "" to remove the file.
+		// It's nil when the health check doesn't complete in time.
+		resolve *string
+		wantErr bool
+	}{
+		{name: "healthy", resolve: new(string), wantErr: false},
+		{name: "recycled", resolve: func() *string { s := "recycle"; return &s }(), wantErr: true},
+		{name: "health check timed out", resolve: nil, wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			recycleFile := filepath.Join(t.TempDir(), "arc-recycle")
+
+			env := []string{
+				"RUNNER_HEALTH_CHECK_RECYCLE=true",
+				"RUNNER_HEALTH_CHECK_STRICT=true",
+				"RECYCLE_FILE=" + recycleFile,
+				"RECYCLE_WAIT_SECONDS=3",
+				"WATCHDOG_PID_FILE=" + filepath.Join(t.TempDir(), "watchdog.pid"),
+			}
+
+			// The file must be written by the time the job-completed hook returns, before the runner can pick up the next job
+			if err := run(env, "job-completed.sh"); err != nil {
+				t.Fatal(err)
+			}
+
+			if content, err := os.ReadFile(recycleFile); err != nil || string(content) != "pending\n" {
+				t.Fatalf("expected the pending recycle file, got %q: %v", content, err)
+			}
+
+			done := make(chan error)
+			go func() {
+				done <- run(env, "job-started.sh")
+			}()
+
+			select {
+			case err := <-done:
+				t.Fatalf("the next job started before the health check completed: %v", err)
+			case <-time.After(1500 * time.Millisecond):
+			}
+
+			if tc.resolve != nil {
+				if *tc.resolve == "" {
+					if err := os.Remove(recycleFile); err != nil {
+						t.Fatal(err)
+					}
+				} else if err := os.WriteFile(recycleFile, []byte(*tc.resolve+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := <-done; (err != nil) != tc.wantErr {
+				t.Errorf("unexpected result of the job-started hook: wantErr=%v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("health check", func(t *testing.T) {
+		recycleFile := filepath.Join(t.TempDir(), "arc-recycle")
+		terminationMessage := filepath.Join(t.TempDir(), "termination-log")
+
+		for _, percent := range []string{"100", "0"} {
+			if err := os.WriteFile(recycleFile, []byte("pending\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			env := []string{
+				"RUNNER_HEALTH_CHECK_STRICT=true",
+				"RUNNER_HEALTH_CHECK_DISK_USAGE_PERCENT=" + percent,
+				"RUNNER_HEALTH_CHECK_MEMORY_USAGE_PERCENT=100",
+				"RUNNER_WORKDIR=" + t.TempDir(),
+				// The worker of the previous job has already exited
+				"RUNNER_WORKER_PID=2147483647",
+				"RECYCLE_FILE=" + recycleFile,
+				"TERMINATION_MESSAGE_PATH=" + terminationMessage,
+			}
+
+			// The unhealthy runner exits with the status of pkill, which finds no runner service to stop here
+			if err := run(env, "health-check.sh", "--recycle"); err != nil && percent == "100" {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(recycleFile)
+
+			if percent == "100" && !os.IsNotExist(err) {
+				t.Errorf("expected the recycle file to be removed for the healthy runner, got %q: %v", content, err)
+			}
+
+			if percent == "0" && string(content) != "recycle\n" {
+				t.Errorf("expected the runner to be recycled, got %q: %v", content, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+// reasonRunnerVersionOutdated is the reason of the event emitted for the runner pod recreated
+// because GitHub refused the version of the runner.
+const reasonRunnerVersionOutdated = "RunnerVersionOutdated"
+
+// runnerVersionOutdatedPattern matches the error actions/runner logs before exiting,
+// when GitHub no longer accepts jobs from runners of its version.
+var runnerVersionOutdatedPattern = regexp.MustCompile(`Runner version v?([0-9.]*[0-9]) is deprecated and cannot receive messages`)
+
+// getOutdatedRunnerVersion returns the version of the runner whose container exited because its version is outdated,
+// or an empty string otherwise.
+// The runner container uses the FallbackToLogsOnError termination message policy by default,
+// so that the last lines of the logs of the failed runner end up in its termination message.
+// The last termination state is checked too, because the container may have already been restarted by kubelet.
+func getOutdatedRunnerVersion(pod corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+
+			if m := runnerVersionOutdatedPattern.FindStringSubmatch(terminated.Message); m != nil {
+				return m[1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// recordRunnerVersionOutdated emits the event for the outdated runner whose pod has been recreated,
+// and counts it for the RunnerDeployment the runner belongs to, if any.
+func recordRunnerVersionOutdated(recorder record.EventRecorder, runner *v1alpha1.Runner, version string) {
+	recorder.Event(runner, corev1.EventTypeWarning, reasonRunnerVersionOutdated, fmt.Sprintf(
+		"Recreated the runner pod as GitHub no longer accepts jobs from runner version %s. "+
+			"Upgrade the runner image, or enable runnerVersionUpgrade of the RunnerDeployment, unless the image is pulled with a floating tag",
+		version,
+	))
+
+	if rd, ok := runner.Labels[LabelKeyRunnerDeploymentName]; ok {
+		metrics.IncRunnerDeploymentOutdatedRunners(runner.Namespace, rd, version)
+	}
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestGetOutdatedRunnerVersion(t *testing.T) {
+	logs := "√ Connected to GitHub\n\n" +
+		"2022-05-01 00:00:00Z: Listening for Jobs\n" +
+		"An error occurred: Runner version v2.283.3 is deprecated and cannot receive messages.\n"
+
+	runnerStatus := func(state, last *corev1.ContainerStateTerminated) corev1.Pod {
+		return corev1.Pod{
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:                 containerName,
+						State:                corev1.ContainerState{Terminated: state},
+						LastTerminationState: corev1.ContainerState{Terminated: last},
+					},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name string
+		pod  corev1.Pod
+		want string
+	}{
+		{
+			name: "terminated",
+			pod:  runnerStatus(&corev1.ContainerStateTerminated{ExitCode: 1, Message: logs}, nil),
+			want: "2.283.3",
+		},
+		{
+			name: "restarted",
+			pod:  runnerStatus(nil, &corev1.ContainerStateTerminated{ExitCode: 1, Message: logs}),
+			want: "2.283.3",
+		},
+		{
+			name: "other failure",
+			pod:  runnerStatus(&corev1.ContainerStateTerminated{ExitCode: 1, Message: "An error occurred: Not configured"}, nil),
+			want: "",
+		},
+		{
+			name: "health report",
+			pod:  runnerStatus(&corev1.ContainerStateTerminated{Message: `{"reason":"RunnerUnhealthy","message":"disk full"}`}, nil),
+			want: "",
+		},
+		{
+			name: "running",
+			pod:  corev1.Pod{},
+			want: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := getOutdatedRunnerVersion(tc.pod); got != tc.want {
+				t.Errorf("unexpected version: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRecordRunnerVersionOutdated(t *testing.T) {
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-abcde-fghij",
+			Namespace: "default",
+			Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+		},
+	}
+
+	recorder := record.NewFakeRecorder(1)
+
+	recordRunnerVersionOutdated(recorder, runner, "2.283.3")
+
+	want := "Warning RunnerVersionOutdated Recreated the runner pod as GitHub no longer accepts jobs from runner version 2.283.3."
+
+	if e := <-recorder.Events; !strings.HasPrefix(e, want) {
+		t.Errorf("unexpected event: %s", e)
+	}
+}
@@ -34,7 +34,9 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/anomaly"
 )
 
 // RunnerPodReconciler reconciles a Runner object
@@ -45,8 +47,13 @@ type RunnerPodReconciler struct {
 	Scheme                      *runtime.Scheme
 	GitHubClient                *github.Client
 	Name                        string
+	ControllerOptions           ControllerOptions
 	RegistrationRecheckInterval time.Duration
 	RegistrationRecheckJitter   time.Duration
+	// AnomalyNotifier is notified of the runners that failed to register to GitHub. Can be nil.
+	AnomalyNotifier *anomaly.Notifier
+	// MultiGitHubClient provides the clients of the runner pods annotated with AnnotationKeyGitHubAPICredsSecret. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
 }
 
 const (
@@ -105,8 +112,17 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		finalizers, removed := removeFinalizer(runnerPod.ObjectMeta.Finalizers, runnerPodFinalizerName)
 
 		if removed {
-			ok, err := r.unregisterRunner(ctx, enterprise, org, repo, runnerPod.Name)
-			if err != nil {
+			// The client is resolved only here, so that a runner pod whose credentials are gone can still be deleted
+			ghc, err := gitHubClientForPod(ctx, r.MultiGitHubClient, r.GitHubClient, runnerPod)
+			if isGitHubCredentialsMissing(err) {
+				msg := fmt.Sprintf("Deleting the runner pod without unregistering the runner, as its GitHub API credentials are unavailable: %v", err)
+				log.Info(msg)
+				r.Recorder.Event(&runnerPod, corev1.EventTypeWarning, v1alpha1.RunnerConditionReasonUnregistrationSkipped, msg)
+			} else if err != nil {
+				log.Error(err, "Failed to get the github client of the runner pod")
+
+				return ctrl.Result{}, err
+			} else if ok, err := r.unregisterRunner(ctx, ghc, enterprise, org, repo, runnerPod.Name); err != nil {
 				if errors.Is(err, &gogithub.RateLimitError{}) {
 					// We log the underlying error when we failed calling GitHub API to list or unregisters,
 					// or the runner is still busy.
@@ -121,10 +137,17 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 					return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
 				}
 
-				return ctrl.Result{}, err
-			}
+				if github.IsTransient(err) {
+					log.Info(
+						fmt.Sprintf("Failed to unregister runner due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+						"error", err.Error(),
+					)
+
+					return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+				}
 
-			if !ok {
+				return ctrl.Result{}, err
+			} else if !ok {
 				log.V(1).Info("Runner no longer exists on GitHub")
 			}
 
@@ -174,6 +197,13 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
+	ghc, err := gitHubClientForPod(ctx, r.MultiGitHubClient, r.GitHubClient, runnerPod)
+	if err != nil {
+		log.Error(err, "Failed to get the github client of the runner pod")
+
+		return ctrl.Result{}, err
+	}
+
 	// If pod has ended up succeeded we need to restart it
 	// Happens e.g. when dind is in runner and run completes
 	stopped := runnerPod.Status.Phase == corev1.PodSucceeded
@@ -192,6 +222,19 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	// The watchdog cancelled the job exceeding maxJobDuration.
+	// The pod is recreated regardless of the exit code, so that the next job runs on a fresh runner.
+	jobTimeout := getJobTimeout(runnerPod)
+	if jobTimeout != nil {
+		stopped = true
+	}
+
+	// The health check stopped the unhealthy runner after the job
+	healthReport := getRunnerHealthReport(runnerPod)
+	if healthReport != nil {
+		stopped = true
+	}
+
 	restart := stopped
 
 	var registrationRecheckDelay time.Duration
@@ -253,7 +296,7 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		notFound := false
 		offline := false
 
-		_, err := r.GitHubClient.IsRunnerBusy(ctx, enterprise, org, repo, runnerPod.Name)
+		_, err := ghc.IsRunnerBusy(ctx, enterprise, org, repo, runnerPod.Name)
 
 		currentTime := time.Now()
 
@@ -280,6 +323,15 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 					return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
 				}
 
+				if github.IsTransient(err) {
+					log.Info(
+						fmt.Sprintf("Failed to check if runner is busy due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+						"error", err.Error(),
+					)
+
+					return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+				}
+
 				return ctrl.Result{}, err
 			}
 		}
@@ -300,6 +352,8 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 					"configuredRegistrationTimeout", registrationTimeout,
 				)
 
+				r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Runner %s/%s failed to register itself to GitHub within %s", runnerPod.Namespace, runnerPod.Name, registrationTimeout))
+
 				restart = true
 			} else {
 				log.V(1).Info(
@@ -318,6 +372,8 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 					"configuredRegistrationTimeout", registrationTimeout,
 				)
 
+				r.AnomalyNotifier.Record(anomaly.KindRegistrationFailures, fmt.Sprintf("Runner %s/%s stayed offline on GitHub for %s after its pod was created", runnerPod.Namespace, runnerPod.Name, registrationTimeout))
+
 				restart = true
 			} else {
 				log.V(1).Info(
@@ -374,11 +430,19 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	r.Recorder.Event(&runnerPod, corev1.EventTypeNormal, "PodDeleted", fmt.Sprintf("Deleted pod '%s'", runnerPod.Name))
 	log.Info("Deleted runner pod", "name", runnerPod.Name)
 
+	if jobTimeout != nil {
+		recordJobTimeout(r.Recorder, &runnerPod, runnerPod.Namespace, *jobTimeout)
+	}
+
+	if healthReport != nil {
+		recordRunnerRecycled(r.Recorder, &runnerPod, *healthReport)
+	}
+
 	return ctrl.Result{}, nil
 }
 
-func (r *RunnerPodReconciler) unregisterRunner(ctx context.Context, enterprise, org, repo, name string) (bool, error) {
-	runners, err := r.GitHubClient.ListRunners(ctx, enterprise, org, repo)
+func (r *RunnerPodReconciler) unregisterRunner(ctx context.Context, ghc *github.Client, enterprise, org, repo, name string) (bool, error) {
+	runners, err := ghc.ListRunners(ctx, enterprise, org, repo)
 	if err != nil {
 		return false, err
 	}
@@ -408,7 +472,7 @@ func (r *RunnerPodReconciler) unregisterRunner(ctx context.Context, enterprise,
 	// Trying to remove the offline but busy runner can result in errors like the following:
 	//    failed to remove runner: DELETE https://api.github.com/repos/actions-runner-controller/mumoshu-actions-test/actions/runners/47: 422 Bad request - Runner \"example-runnerset-0\" is still running a job\" []
 	if !busy {
-		if err := r.GitHubClient.RemoveRunner(ctx, enterprise, org, repo, id); err != nil {
+		if err := ghc.RemoveRunner(ctx, enterprise, org, repo, id); err != nil {
 			return false, err
 		}
 	}
@@ -424,8 +488,9 @@ func (r *RunnerPodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Pod{}).
-		Named(name).
-		Complete(r)
+		Named(name)
+
+	return r.ControllerOptions.complete(b, r)
 }
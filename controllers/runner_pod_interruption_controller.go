@@ -0,0 +1,351 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+const (
+	// AnnotationKeyInterruption is added to a runner pod once its interruption is handled, so that it's handled only once.
+	// The value is the reason of the interruption.
+	AnnotationKeyInterruption = "actions-runner-controller/interruption"
+
+	InterruptionReasonEvicted         = "Evicted"
+	InterruptionReasonNodeShutdown    = "NodeShutdown"
+	InterruptionReasonNodeLost        = "NodeLost"
+	InterruptionReasonNodeTermination = "NodeTermination"
+
+	// interruptionIdempotencyKeyPrefix prefixes the idempotency keys of the capacity reservations re-added
+	// for interrupted runner pods.
+	interruptionIdempotencyKeyPrefix = "interruption/"
+
+	podNodeNameKey = "spec.nodeName"
+)
+
+// DefaultNodeTerminationTaints are the taints that the popular node termination handlers put on spot or preemptible
+// nodes that are about to be terminated.
+var DefaultNodeTerminationTaints = []string{
+	// https://github.com/aws/aws-node-termination-handler
+	"aws-node-termination-handler/spot-itn",
+	"aws-node-termination-handler/rebalance-recommendation",
+	"aws-node-termination-handler/scheduled-maintenance",
+	// https://github.com/GoogleCloudPlatform/k8s-node-termination-handler
+	"cloud.google.com/impending-node-termination",
+}
+
+// RunnerPodInterruptionReconciler watches runner pods of RunnerDeployments and the nodes they run on,
+// to detect runner pods interrupted by evictions and node terminations, like ones of spot or preemptible instances.
+//
+// The job run by an interrupted runner fails, but the capacity reserved for the job remains until GitHub sends
+// the completion of the job, or the reservation expires. So this releases the reservation as soon as the interruption
+// is detected, optionally re-adding the same capacity for retrying the job.
+// Each interruption is counted in the runnerdeployment_interruptions_total metric and emitted as an event.
+type RunnerPodInterruptionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+	Name     string
+
+	// NodeTerminationTaints are the keys of the taints that mark nodes about to be terminated.
+	// Defaults to DefaultNodeTerminationTaints.
+	NodeTerminationTaints []string
+
+	// ReAddCapacity makes the capacity reserved for the job of an interrupted runner re-added
+	// as a new reservation that expires after the duration of the scale up trigger.
+	ReAddCapacity bool
+
+	// IgnoreNodes disables watching nodes, so that the controller can run without the cluster-wide permission for nodes.
+	// Only the interruptions recorded in the pod status, like evictions, are detected then.
+	IgnoreNodes bool
+
+	ControllerOptions ControllerOptions
+}
+
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *RunnerPodInterruptionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnerpod", req.NamespacedName)
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	rdName, ok := pod.Labels[LabelKeyRunnerDeploymentName]
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	if _, handled := pod.Annotations[AnnotationKeyInterruption]; handled {
+		return ctrl.Result{}, nil
+	}
+
+	var reason string
+
+	if r.IgnoreNodes {
+		reason = runnerPodStatusInterruptionReason(pod)
+	} else {
+		var node *corev1.Node
+
+		if pod.Spec.NodeName != "" {
+			var n corev1.Node
+			if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &n); err == nil {
+				node = &n
+			} else if !kerrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+
+		reason = runnerPodInterruptionReason(pod, node, r.nodeTerminationTaints())
+	}
+
+	if reason == "" {
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Detected interruption of runner pod", "reason", reason, "node", pod.Spec.NodeName, "runnerdeployment", rdName)
+
+	if err := r.releaseCapacity(ctx, log, pod); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	updated := pod.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[AnnotationKeyInterruption] = reason
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&pod)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	metrics.IncRunnerDeploymentInterruptions(pod.Namespace, rdName, reason)
+
+	msg := fmt.Sprintf("Runner pod %s was interrupted on node %s: %s", pod.Name, pod.Spec.NodeName, reason)
+
+	var rd v1alpha1.RunnerDeployment
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: rdName}, &rd); err == nil {
+		r.Recorder.Event(&rd, corev1.EventTypeWarning, "RunnerInterrupted", msg)
+	} else {
+		r.Recorder.Event(&pod, corev1.EventTypeWarning, "RunnerInterrupted", msg)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *RunnerPodInterruptionReconciler) nodeTerminationTaints() []string {
+	if r.NodeTerminationTaints != nil {
+		return r.NodeTerminationTaints
+	}
+
+	return DefaultNodeTerminationTaints
+}
+
+// runnerPodInterruptionReason returns the reason why the runner pod is interrupted, or an empty string if it isn't.
+// node is nil when the node of the pod doesn't exist anymore.
+func runnerPodInterruptionReason(pod corev1.Pod, node *corev1.Node, nodeTerminationTaints []string) string {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		return ""
+	}
+
+	if reason := runnerPodStatusInterruptionReason(pod); reason != "" {
+		return reason
+	}
+
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+
+	if node == nil {
+		return InterruptionReasonNodeLost
+	}
+
+	for _, t := range node.Spec.Taints {
+		for _, key := range nodeTerminationTaints {
+			if t.Key == key {
+				return InterruptionReasonNodeTermination
+			}
+		}
+	}
+
+	return ""
+}
+
+// runnerPodStatusInterruptionReason returns the reason of the interruption recorded in the status of the failed runner pod,
+// or an empty string if there's none.
+func runnerPodStatusInterruptionReason(pod corev1.Pod) string {
+	if pod.Status.Phase != corev1.PodFailed {
+		return ""
+	}
+
+	switch pod.Status.Reason {
+	case "Evicted":
+		return InterruptionReasonEvicted
+	// The kubelet's graceful node shutdown sets either of these, depending on the version of Kubernetes
+	case "Terminated", "Shutdown", "NodeShutdown":
+		return InterruptionReasonNodeShutdown
+	case "NodeLost":
+		return InterruptionReasonNodeLost
+	}
+
+	return ""
+}
+
+// releaseCapacity removes the capacity reservations made for the job that the interrupted runner picked up,
+// and re-adds the same capacity as new reservations when ReAddCapacity is set.
+func (r *RunnerPodInterruptionReconciler) releaseCapacity(ctx context.Context, log logr.Logger, pod corev1.Pod) error {
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hras, client.InNamespace(pod.Namespace)); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, hra := range hras.Items {
+		reservations, released := releaseInterruptedCapacityReservations(hra, pod, r.ReAddCapacity, now)
+		if released == 0 {
+			continue
+		}
+
+		copy := hra.DeepCopy()
+		copy.Spec.CapacityReservations = reservations
+
+		if err := r.Patch(ctx, copy, client.MergeFrom(&hra)); err != nil {
+			return fmt.Errorf("patching horizontalrunnerautoscaler to release capacity reservation: %w", err)
+		}
+
+		log.Info("Released capacity reservation for interrupted runner", "hra", hra.Name, "replicas", released, "readded", r.ReAddCapacity)
+
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "CapacityReservationReleased", fmt.Sprintf("Released %d replicas reserved for the job of interrupted runner %s", released, pod.Name))
+	}
+
+	return nil
+}
+
+// releaseInterruptedCapacityReservations returns the reservations of the HRA without the ones for the job of the
+// interrupted runner pod, and the number of released replicas.
+// With reAdd, the released replicas are re-added as a reservation keyed by the pod, which makes it safe to retry.
+func releaseInterruptedCapacityReservations(hra v1alpha1.HorizontalRunnerAutoscaler, pod corev1.Pod, reAdd bool, now time.Time) ([]v1alpha1.CapacityReservation, int) {
+	var (
+		reservations []v1alpha1.CapacityReservation
+		released     int
+	)
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		if cr.RunnerName == pod.Name && cr.ExpirationTime.Time.After(now) {
+			released += cr.Replicas
+			continue
+		}
+
+		reservations = append(reservations, cr)
+	}
+
+	if released == 0 || !reAdd {
+		return reservations, released
+	}
+
+	key := interruptionIdempotencyKeyPrefix + string(pod.UID)
+
+	for _, cr := range reservations {
+		if cr.IdempotencyKey == key {
+			return reservations, released
+		}
+	}
+
+	reservations = append(reservations, v1alpha1.CapacityReservation{
+		ExpirationTime: metav1.Time{Time: now.Add(jobScaleUpDuration(hra).Duration)},
+		Replicas:       released,
+		IdempotencyKey: key,
+	})
+
+	return reservations, released
+}
+
+func (r *RunnerPodInterruptionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerpodinterruption-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &corev1.Pod{}, podNodeNameKey, func(rawObj client.Object) []string {
+		pod := rawObj.(*corev1.Pod)
+
+		if _, ok := pod.Labels[LabelKeyRunnerDeploymentName]; !ok || pod.Spec.NodeName == "" {
+			return nil
+		}
+
+		return []string{pod.Spec.NodeName}
+	}); err != nil {
+		return err
+	}
+
+	// Node termination handlers taint nodes some time before terminating them, which lets us release the capacity
+	// before the runner pods on the node are gone.
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{}).
+		Named(name)
+
+	if !r.IgnoreNodes {
+		b = b.Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.runnerPodsOnNode))
+	}
+
+	return r.ControllerOptions.complete(b, r)
+}
+
+func (r *RunnerPodInterruptionReconciler) runnerPodsOnNode(obj client.Object) []reconcile.Request {
+	var pods corev1.PodList
+
+	if err := r.List(context.TODO(), &pods, client.MatchingFields{podNodeNameKey: obj.GetName()}); err != nil {
+		r.Log.Error(err, "Failed to list runner pods on node", "node", obj.GetName())
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, pod := range pods.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}})
+	}
+
+	return reqs
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestRunnerPodInterruptionReason(t *testing.T) {
+	newPod := func(phase corev1.PodPhase, reason string) corev1.Pod {
+		return corev1.Pod{
+			Spec:   corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{Phase: phase, Reason: reason},
+		}
+	}
+
+	node := &corev1.Node{}
+	terminatingNode := &corev1.Node{
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{{Key: "aws-node-termination-handler/spot-itn", Effect: corev1.TaintEffectNoSchedule}},
+		},
+	}
+
+	testcases := []struct {
+		name string
+		pod  corev1.Pod
+		node *corev1.Node
+		want string
+	}{
+		{name: "running", pod: newPod(corev1.PodRunning, ""), node: node, want: ""},
+		{name: "succeeded on terminating node", pod: newPod(corev1.PodSucceeded, ""), node: terminatingNode, want: ""},
+		{name: "evicted", pod: newPod(corev1.PodFailed, "Evicted"), node: node, want: InterruptionReasonEvicted},
+		{name: "node shutdown", pod: newPod(corev1.PodFailed, "Terminated"), node: node, want: InterruptionReasonNodeShutdown},
+		{name: "node gone", pod: newPod(corev1.PodRunning, ""), want: InterruptionReasonNodeLost},
+		{name: "pending without node", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}, want: ""},
+		{name: "terminating node", pod: newPod(corev1.PodRunning, ""), node: terminatingNode, want: InterruptionReasonNodeTermination},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := runnerPodInterruptionReason(tc.pod, tc.node, DefaultNodeTerminationTaints); got != tc.want {
+				t.Errorf("unexpected reason: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerPodInterruption_IgnoreNodes(t *testing.T) {
+	newPod := func(name string, phase corev1.PodPhase, reason string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{Phase: phase, Reason: reason},
+		}
+	}
+
+	// node1 doesn't exist, which isn't observable without the permission for nodes
+	c := fake.NewFakeClientWithScheme(sc, newPod("running", corev1.PodRunning, ""), newPod("evicted", corev1.PodFailed, "Evicted"))
+
+	r := &RunnerPodInterruptionReconciler{
+		Client:      c,
+		Log:         logr.Discard(),
+		Recorder:    record.NewFakeRecorder(10),
+		IgnoreNodes: true,
+	}
+
+	for name, want := range map[string]string{"running": "", "evicted": InterruptionReasonEvicted} {
+		key := types.NamespacedName{Namespace: "default", Name: name}
+
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+
+		var pod corev1.Pod
+		if err := c.Get(context.Background(), key, &pod); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := pod.Annotations[AnnotationKeyInterruption]; got != want {
+			t.Errorf("unexpected interruption of %s: want %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestReleaseInterruptedCapacityReservations(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiration := metav1.Time{Time: now.Add(time.Minute)}
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "runner-abc", UID: "uid1"}}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleUpTriggers: []v1alpha1.ScaleUpTrigger{{Duration: metav1.Duration{Duration: 5 * time.Minute}}},
+			CapacityReservations: []v1alpha1.CapacityReservation{
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1", RunnerName: "runner-abc"},
+				{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/2", RunnerName: "runner-def"},
+			},
+		},
+	}
+
+	got, released := releaseInterruptedCapacityReservations(hra, pod, false, now)
+	if released != 1 {
+		t.Errorf("unexpected released replicas: want 1, got %d", released)
+	}
+
+	if d := cmp.Diff(hra.Spec.CapacityReservations[1:], got); d != "" {
+		t.Errorf("unexpected reservations (-want +got):\n%s", d)
+	}
+
+	got, _ = releaseInterruptedCapacityReservations(hra, pod, true, now)
+
+	want := []v1alpha1.CapacityReservation{
+		hra.Spec.CapacityReservations[1],
+		{ExpirationTime: metav1.Time{Time: now.Add(5 * time.Minute)}, Replicas: 1, IdempotencyKey: "interruption/uid1"},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected reservations (-want +got):\n%s", d)
+	}
+
+	// Retrying after the reservations are released doesn't re-add the capacity twice
+	hra.Spec.CapacityReservations = got
+	if _, released := releaseInterruptedCapacityReservations(hra, pod, true, now); released != 0 {
+		t.Errorf("unexpected released replicas on retry: want 0, got %d", released)
+	}
+}
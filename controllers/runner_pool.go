@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// runnerPoolCandidate is a member of a RunnerPool that can run the workflow job.
+type runnerPoolCandidate struct {
+	member v1alpha1.RunnerPoolMember
+	hra    v1alpha1.HorizontalRunnerAutoscaler
+	// reserved is the number of replicas reserved by the valid capacity reservations of the HRA.
+	reserved int
+}
+
+func (c runnerPoolCandidate) weight() int {
+	if c.member.Weight == nil || *c.member.Weight < 1 {
+		return 1
+	}
+
+	return *c.member.Weight
+}
+
+// full returns true when another capacity reservation wouldn't add a replica, because the HRA is already at maxReplicas.
+func (c runnerPoolCandidate) full() bool {
+	if c.hra.Spec.MaxReplicas == nil {
+		return false
+	}
+
+	var min int
+	if c.hra.Spec.MinReplicas != nil {
+		min = *c.hra.Spec.MinReplicas
+	}
+
+	return min+c.reserved >= *c.hra.Spec.MaxReplicas
+}
+
+// selectRunnerPoolMember redirects the scale target for a workflow job to another member of the RunnerPool
+// that the RunnerDeployment of the target belongs to.
+// The target is returned as-is when the RunnerDeployment isn't a member of any RunnerPool,
+// or when no other member should take the reservation, or as-is when RunnerPools isn't enabled.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) selectRunnerPoolMember(ctx context.Context, log logr.Logger, target *ScaleTarget, labels []string) (*ScaleTarget, error) {
+	if !autoscaler.RunnerPools {
+		return target, nil
+	}
+
+	if kind := target.HorizontalRunnerAutoscaler.Spec.ScaleTargetRef.Kind; kind != "" && kind != "RunnerDeployment" {
+		return target, nil
+	}
+
+	ns := target.HorizontalRunnerAutoscaler.Namespace
+
+	var pools v1alpha1.RunnerPoolList
+
+	if err := autoscaler.Client.List(ctx, &pools, client.InNamespace(ns)); err != nil {
+		return nil, err
+	}
+
+	pool := findRunnerPool(pools.Items, target.HorizontalRunnerAutoscaler.Spec.ScaleTargetRef.Name)
+	if pool == nil {
+		return target, nil
+	}
+
+	candidates, err := autoscaler.getRunnerPoolCandidates(ctx, *pool, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := selectRunnerPoolCandidate(candidates, target.Amount, target.idempotencyKey)
+	if selected == nil || selected.hra.Name == target.HorizontalRunnerAutoscaler.Name {
+		return target, nil
+	}
+
+	log.V(1).Info(
+		"Selected another member of the runner pool for the workflow job",
+		"runnerPool", pool.Name,
+		"runnerDeployment", selected.member.RunnerDeployment,
+		"hra", selected.hra.Name,
+		"reserved", selected.reserved,
+	)
+
+	return &ScaleTarget{
+		HorizontalRunnerAutoscaler: selected.hra,
+		ScaleUpTrigger: v1alpha1.ScaleUpTrigger{
+			Amount:   target.Amount,
+			Duration: jobScaleUpDuration(selected.hra),
+		},
+		repository:     target.repository,
+		idempotencyKey: target.idempotencyKey,
+	}, nil
+}
+
+func findRunnerPool(pools []v1alpha1.RunnerPool, runnerDeployment string) *v1alpha1.RunnerPool {
+	for i := range pools {
+		if !pools[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		for _, m := range pools[i].Spec.Members {
+			if m.RunnerDeployment == runnerDeployment {
+				return &pools[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// getRunnerPoolCandidates returns the members of the pool, in the order of declaration,
+// that have a HorizontalRunnerAutoscaler usable for workflow_job based scaling and runners with all the job labels.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getRunnerPoolCandidates(ctx context.Context, pool v1alpha1.RunnerPool, labels []string) ([]runnerPoolCandidate, error) {
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := autoscaler.Client.List(ctx, &hras, client.InNamespace(pool.Namespace)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	var candidates []runnerPoolCandidate
+
+	for _, m := range pool.Spec.Members {
+		for _, hra := range hras.Items {
+			if !hra.DeletionTimestamp.IsZero() || len(hra.Spec.ScaleUpTriggers) > 1 {
+				continue
+			}
+
+			if kind := hra.Spec.ScaleTargetRef.Kind; kind != "" && kind != "RunnerDeployment" {
+				continue
+			}
+
+			if hra.Spec.ScaleTargetRef.Name != m.RunnerDeployment {
+				continue
+			}
+
+			var rd v1alpha1.RunnerDeployment
+
+			if err := autoscaler.Client.Get(ctx, types.NamespacedName{Namespace: pool.Namespace, Name: m.RunnerDeployment}, &rd); err != nil {
+				if kerrors.IsNotFound(err) {
+					break
+				}
+
+				return nil, err
+			}
+
+			if !workflowJobLabelsMatch(hra, labels, runnerLabels(rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.NodeSelector)) {
+				break
+			}
+
+			candidates = append(candidates, runnerPoolCandidate{
+				member:   m,
+				hra:      hra,
+				reserved: getReservedReplicas(hra, now),
+			})
+
+			break
+		}
+	}
+
+	return candidates, nil
+}
+
+// selectRunnerPoolCandidate returns the candidate that should take the capacity reservation for the workflow job.
+//
+// A job is reserved on the members with the highest priority that aren't at maxReplicas yet,
+// and among them on the member with the fewest reserved replicas relative to its weight.
+// The completion of a job removes the reservation from the member it was reserved on.
+// It returns nil when there's no such candidate.
+func selectRunnerPoolCandidate(candidates []runnerPoolCandidate, amount int, idempotencyKey string) *runnerPoolCandidate {
+	now := time.Now()
+
+	// The reservation for the job needs to stay on the same member, so that neither a redelivered event
+	// reserves the capacity twice on different members nor the completion removes the reservation for another job.
+	if idempotencyKey != "" {
+		for i, c := range candidates {
+			for _, r := range c.hra.Spec.CapacityReservations {
+				if r.IdempotencyKey == idempotencyKey && r.ExpirationTime.Time.After(now) {
+					return &candidates[i]
+				}
+			}
+		}
+	}
+
+	if amount < 0 {
+		return nil
+	}
+
+	var selected *runnerPoolCandidate
+
+	for i := range candidates {
+		c := &candidates[i]
+
+		if c.full() {
+			continue
+		}
+
+		if selected == nil || c.member.Priority > selected.member.Priority {
+			selected = c
+			continue
+		}
+
+		// Compares reserved/weight after the reservation without dividing, so that ties go to the member declared first.
+		if c.member.Priority == selected.member.Priority && (c.reserved+1)*selected.weight() < (selected.reserved+1)*c.weight() {
+			selected = c
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultRunnerPoolRebalanceIdleFor = 10 * time.Minute
+
+	// runnerPoolRebalanceInterval is how often the rebalancer moves reservations between the members of a RunnerPool.
+	runnerPoolRebalanceInterval = time.Minute
+)
+
+// RunnerPoolRebalancer gradually moves capacity reservations between the members of RunnerPools with the same priority
+// toward their weights.
+//
+// The webhook-based autoscaler distributes new reservations by the weights, but a reservation stays on the member
+// it was made on, so the members drift apart over time, e.g. after a member was at maxReplicas for a while.
+// The rebalancer moves only the reservations whose jobs haven't been picked up by any runner while the pool is idle,
+// and the scale down of the source member removes only idle runners, so busy runners are never interrupted.
+type RunnerPoolRebalancer struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+	Name     string
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// runnerPoolRebalance is the move of reservations from a member to another.
+type runnerPoolRebalance struct {
+	from, to *runnerPoolCandidate
+	// moved are the indices of the reservations in from.hra.Spec.CapacityReservations to be moved
+	moved    []int
+	replicas int
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerpools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *RunnerPoolRebalancer) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnerpool", req.NamespacedName)
+
+	var pool v1alpha1.RunnerPool
+	if err := r.Get(ctx, req.NamespacedName, &pool); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pool.DeletionTimestamp.IsZero() || pool.Spec.Rebalance == nil {
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(ctx, &hras, client.InNamespace(pool.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	members := getRunnerPoolMembers(pool, hras.Items, now)
+
+	idleFor := DefaultRunnerPoolRebalanceIdleFor
+	if pool.Spec.Rebalance.IdleFor != nil {
+		idleFor = pool.Spec.Rebalance.IdleFor.Duration
+	}
+
+	for _, m := range members {
+		if t := m.hra.Status.LastSuccessfulScaleOutTime; t != nil && now.Before(t.Add(idleFor)) {
+			log.V(1).Info("Skipped rebalancing the runner pool that scaled up recently", "hra", m.hra.Name, "lastScaleOutTime", t)
+
+			return ctrl.Result{RequeueAfter: runnerPoolRebalanceInterval}, nil
+		}
+	}
+
+	maxReplicas := 1
+	if pool.Spec.Rebalance.MaxReplicasPerStep != nil {
+		maxReplicas = *pool.Spec.Rebalance.MaxReplicasPerStep
+	}
+
+	rebalance := planRunnerPoolRebalance(members, maxReplicas, now)
+	if rebalance == nil {
+		return ctrl.Result{RequeueAfter: runnerPoolRebalanceInterval}, nil
+	}
+
+	from, to := rebalance.from.hra, rebalance.to.hra
+
+	moved := make(map[int]bool, len(rebalance.moved))
+	for _, i := range rebalance.moved {
+		moved[i] = true
+	}
+
+	fromCopy, toCopy := from.DeepCopy(), to.DeepCopy()
+	fromCopy.Spec.CapacityReservations = nil
+
+	for i, cr := range from.Spec.CapacityReservations {
+		if moved[i] {
+			toCopy.Spec.CapacityReservations = append(toCopy.Spec.CapacityReservations, cr)
+		} else {
+			fromCopy.Spec.CapacityReservations = append(fromCopy.Spec.CapacityReservations, cr)
+		}
+	}
+
+	// Adds the reservations before removing them, so that a failure in between never loses the capacity for queued jobs.
+	if err := r.Patch(ctx, toCopy, client.MergeFrom(&to)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("patching horizontalrunnerautoscaler to add rebalanced capacity reservations: %w", err)
+	}
+
+	if err := r.Patch(ctx, fromCopy, client.MergeFrom(&from)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("patching horizontalrunnerautoscaler to remove rebalanced capacity reservations: %w", err)
+	}
+
+	msg := fmt.Sprintf(
+		"Moved %d reserved replicas from %s to %s toward the weights of the members",
+		rebalance.replicas, rebalance.from.member.RunnerDeployment, rebalance.to.member.RunnerDeployment,
+	)
+
+	log.Info(msg, "from", from.Name, "to", to.Name)
+
+	r.Recorder.Event(&pool, corev1.EventTypeNormal, "RunnerPoolRebalanced", msg)
+
+	return ctrl.Result{RequeueAfter: runnerPoolRebalanceInterval}, nil
+}
+
+// getRunnerPoolMembers returns the members of the pool, in the order of declaration, that have a HorizontalRunnerAutoscaler.
+func getRunnerPoolMembers(pool v1alpha1.RunnerPool, hras []v1alpha1.HorizontalRunnerAutoscaler, now time.Time) []runnerPoolCandidate {
+	var members []runnerPoolCandidate
+
+	for _, m := range pool.Spec.Members {
+		for _, hra := range hras {
+			if !hra.DeletionTimestamp.IsZero() {
+				continue
+			}
+
+			if kind := hra.Spec.ScaleTargetRef.Kind; kind != "" && kind != "RunnerDeployment" {
+				continue
+			}
+
+			if hra.Spec.ScaleTargetRef.Name != m.RunnerDeployment {
+				continue
+			}
+
+			members = append(members, runnerPoolCandidate{
+				member:   m,
+				hra:      hra,
+				reserved: getReservedReplicas(hra, now),
+			})
+
+			break
+		}
+	}
+
+	return members
+}
+
+// planRunnerPoolRebalance returns the move of up to maxReplicas reserved replicas from the member most above its share
+// to the member most below its share among the members with the same priority, or nil when they are already balanced.
+// Only the reservations whose jobs haven't been picked up by runners are moved.
+func planRunnerPoolRebalance(members []runnerPoolCandidate, maxReplicas int, now time.Time) *runnerPoolRebalance {
+	var priorities []int
+
+	groups := map[int][]int{}
+
+	for i, m := range members {
+		if _, ok := groups[m.member.Priority]; !ok {
+			priorities = append(priorities, m.member.Priority)
+		}
+
+		groups[m.member.Priority] = append(groups[m.member.Priority], i)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+
+	for _, p := range priorities {
+		group := groups[p]
+
+		if len(group) < 2 {
+			continue
+		}
+
+		var shares []runnerPoolCandidate
+		for _, i := range group {
+			shares = append(shares, members[i])
+		}
+
+		targets := runnerPoolShares(shares)
+
+		from, to := -1, -1
+
+		for j, i := range group {
+			diff := members[i].reserved - targets[j]
+
+			if diff > 0 && (from < 0 || diff > members[group[from]].reserved-targets[from]) {
+				from = j
+			}
+
+			if diff < 0 && !members[i].full() && (to < 0 || diff < members[group[to]].reserved-targets[to]) {
+				to = j
+			}
+		}
+
+		if from < 0 || to < 0 {
+			continue
+		}
+
+		n := maxReplicas
+
+		if over := members[group[from]].reserved - targets[from]; over < n {
+			n = over
+		}
+
+		if under := targets[to] - members[group[to]].reserved; under < n {
+			n = under
+		}
+
+		dst := members[group[to]]
+
+		if dst.hra.Spec.MaxReplicas != nil {
+			var min int
+			if dst.hra.Spec.MinReplicas != nil {
+				min = *dst.hra.Spec.MinReplicas
+			}
+
+			if room := *dst.hra.Spec.MaxReplicas - min - dst.reserved; room < n {
+				n = room
+			}
+		}
+
+		src := &members[group[from]]
+
+		var (
+			moved    []int
+			replicas int
+		)
+
+		for i, cr := range src.hra.Spec.CapacityReservations {
+			if !cr.ExpirationTime.Time.After(now) || cr.RunnerName != "" || replicas+cr.Replicas > n {
+				continue
+			}
+
+			moved = append(moved, i)
+			replicas += cr.Replicas
+		}
+
+		if replicas == 0 {
+			continue
+		}
+
+		return &runnerPoolRebalance{
+			from:     src,
+			to:       &members[group[to]],
+			moved:    moved,
+			replicas: replicas,
+		}
+	}
+
+	return nil
+}
+
+// runnerPoolShares distributes the total reserved replicas of the members in proportion to their weights.
+// The remainder goes to the members with the largest fractions, and ties go to the member declared first.
+func runnerPoolShares(members []runnerPoolCandidate) []int {
+	var total, weights int
+
+	for _, m := range members {
+		total += m.reserved
+		weights += m.weight()
+	}
+
+	shares := make([]int, len(members))
+	order := make([]int, len(members))
+
+	remainder := total
+
+	for i, m := range members {
+		shares[i] = total * m.weight() / weights
+		remainder -= shares[i]
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return total*members[order[a]].weight()%weights > total*members[order[b]].weight()%weights
+	})
+
+	for _, i := range order[:remainder] {
+		shares[i]++
+	}
+
+	return shares
+}
+
+func (r *RunnerPoolRebalancer) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerpool-rebalancer"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RunnerPool{}).
+		Named(name).
+		Complete(r)
+}
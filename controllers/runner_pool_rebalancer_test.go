@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestRunnerPoolRebalancer(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiration := metav1.Time{Time: now.Add(time.Hour)}
+
+	intPtr := func(v int) *int { return &v }
+
+	newHRA := func(name string, reservations ...actionsv1alpha1.CapacityReservation) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef:       actionsv1alpha1.ScaleTargetRef{Name: name},
+				MaxReplicas:          intPtr(10),
+				CapacityReservations: reservations,
+			},
+			Status: actionsv1alpha1.HorizontalRunnerAutoscalerStatus{
+				LastSuccessfulScaleOutTime: &metav1.Time{Time: now.Add(-15 * time.Minute)},
+			},
+		}
+	}
+
+	pool := &actionsv1alpha1.RunnerPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec: actionsv1alpha1.RunnerPoolSpec{
+			Members: []actionsv1alpha1.RunnerPoolMember{
+				{RunnerDeployment: "ondemand"},
+				{RunnerDeployment: "spot-a", Priority: 1, Weight: intPtr(2)},
+				{RunnerDeployment: "spot-b", Priority: 1},
+			},
+			Rebalance: &actionsv1alpha1.RunnerPoolRebalanceSpec{},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		pool,
+		newHRA("ondemand",
+			actionsv1alpha1.CapacityReservation{ExpirationTime: expiration, Replicas: 1},
+			actionsv1alpha1.CapacityReservation{ExpirationTime: expiration, Replicas: 1},
+		),
+		newHRA("spot-a"),
+		newHRA("spot-b",
+			actionsv1alpha1.CapacityReservation{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/1", RunnerName: "spot-b-busy"},
+			actionsv1alpha1.CapacityReservation{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/2"},
+			actionsv1alpha1.CapacityReservation{ExpirationTime: expiration, Replicas: 1, IdempotencyKey: "workflow_job/3"},
+		),
+	)
+
+	recorder := record.NewFakeRecorder(10)
+
+	r := &RunnerPoolRebalancer{
+		Client:   client,
+		Log:      logr.Discard(),
+		Recorder: recorder,
+		now:      func() time.Time { return now },
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pool"}}
+
+	reservations := func(name string) []string {
+		t.Helper()
+
+		var hra actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &hra); err != nil {
+			t.Fatal(err)
+		}
+
+		keys := []string{}
+		for _, cr := range hra.Spec.CapacityReservations {
+			keys = append(keys, cr.IdempotencyKey)
+		}
+
+		return keys
+	}
+
+	rebalance := func() {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.RequeueAfter != runnerPoolRebalanceInterval {
+			t.Errorf("unexpected requeue after: want %s, got %s", runnerPoolRebalanceInterval, res.RequeueAfter)
+		}
+	}
+
+	// spot-a is at 0 of its share of 2, and spot-b is at 3 of 1. Moves one replica per step
+	rebalance()
+
+	if a, b := reservations("spot-a"), reservations("spot-b"); len(a) != 1 || a[0] != "workflow_job/2" || len(b) != 2 {
+		t.Fatalf("unexpected reservations after the first step: spot-a=%v, spot-b=%v", a, b)
+	}
+
+	rebalance()
+
+	if a, b := reservations("spot-a"), reservations("spot-b"); len(a) != 2 || len(b) != 1 || b[0] != "workflow_job/1" {
+		t.Fatalf("unexpected reservations after the second step: spot-a=%v, spot-b=%v", a, b)
+	}
+
+	// Balanced. The members with another priority are never touched
+	rebalance()
+
+	if a, b, o := reservations("spot-a"), reservations("spot-b"), reservations("ondemand"); len(a) != 2 || len(b) != 1 || len(o) != 2 {
+		t.Fatalf("unexpected reservations after balanced: spot-a=%v, spot-b=%v, ondemand=%v", a, b, o)
+	}
+
+	if got := len(recorder.Events); got != 2 {
+		t.Errorf("unexpected number of rebalance events: want 2, got %d", got)
+	}
+}
+
+func TestRunnerPoolRebalancer_NotIdle(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiration := metav1.Time{Time: now.Add(time.Hour)}
+
+	pool := &actionsv1alpha1.RunnerPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec: actionsv1alpha1.RunnerPoolSpec{
+			Members: []actionsv1alpha1.RunnerPoolMember{
+				{RunnerDeployment: "spot-a"},
+				{RunnerDeployment: "spot-b"},
+			},
+			Rebalance: &actionsv1alpha1.RunnerPoolRebalanceSpec{
+				IdleFor: &metav1.Duration{Duration: 5 * time.Minute},
+			},
+		},
+	}
+
+	spotA := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "spot-a", Namespace: "default"},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: "spot-a"},
+			CapacityReservations: []actionsv1alpha1.CapacityReservation{
+				{ExpirationTime: expiration, Replicas: 1},
+				{ExpirationTime: expiration, Replicas: 1},
+			},
+		},
+	}
+
+	spotB := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "spot-b", Namespace: "default"},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: "spot-b"},
+		},
+		Status: actionsv1alpha1.HorizontalRunnerAutoscalerStatus{
+			LastSuccessfulScaleOutTime: &metav1.Time{Time: now.Add(-4 * time.Minute)},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, pool, spotA, spotB)
+
+	r := &RunnerPoolRebalancer{
+		Client:   client,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+		now:      func() time.Time { return now },
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pool"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got actionsv1alpha1.HorizontalRunnerAutoscaler
+	if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "spot-b"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Spec.CapacityReservations) != 0 {
+		t.Errorf("unexpected reservations moved while the pool isn't idle: %v", got.Spec.CapacityReservations)
+	}
+}
+
+func TestRunnerPoolShares(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	members := []runnerPoolCandidate{
+		{member: actionsv1alpha1.RunnerPoolMember{Weight: intPtr(2)}, reserved: 0},
+		{member: actionsv1alpha1.RunnerPoolMember{}, reserved: 4},
+		{member: actionsv1alpha1.RunnerPoolMember{}, reserved: 3},
+	}
+
+	// 7 replicas split 2:1:1 are 3.5, 1.75 and 1.75, whose remainder goes to the largest fractions
+	got := runnerPoolShares(members)
+	want := []int{3, 2, 2}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected shares: want %v, got %v", want, got)
+		}
+	}
+}
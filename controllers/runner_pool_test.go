@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestSelectRunnerPoolMember(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	newRD := func(name string, labels ...string) *actionsv1alpha1.RunnerDeployment {
+		rd := &actionsv1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		rd.Spec.Template.Spec.Labels = labels
+		return rd
+	}
+
+	newHRA := func(name string, max int) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+		return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: name},
+				MaxReplicas:    intPtr(max),
+			},
+		}
+	}
+
+	pool := &actionsv1alpha1.RunnerPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec: actionsv1alpha1.RunnerPoolSpec{
+			Members: []actionsv1alpha1.RunnerPoolMember{
+				{RunnerDeployment: "ondemand"},
+				{RunnerDeployment: "spot-a", Priority: 1, Weight: intPtr(2)},
+				{RunnerDeployment: "spot-b", Priority: 1},
+				{RunnerDeployment: "gpu", Priority: 2},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		pool,
+		newRD("ondemand", "linux"), newHRA("ondemand", 10),
+		newRD("spot-a", "linux"), newHRA("spot-a", 2),
+		newRD("spot-b", "linux"), newHRA("spot-b", 1),
+		newRD("gpu", "gpu"), newHRA("gpu", 10),
+	)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client, RunnerPools: true}
+	installTestLogger(webhook)
+
+	scale := func(amount int, key string) string {
+		t.Helper()
+
+		var hra actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "ondemand"}, &hra); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: hra,
+			ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+				Amount:   amount,
+				Duration: metav1.Duration{Duration: time.Minute},
+			},
+			idempotencyKey: key,
+		}
+
+		target, err := webhook.selectRunnerPoolMember(context.Background(), webhook.Log, target, []string{"self-hosted", "linux"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		return target.HorizontalRunnerAutoscaler.Name
+	}
+
+	// The gpu member has the highest priority but lacks the linux label, so the jobs are distributed
+	// across the spot members by weight, and overflow to the on-demand member once they are at maxReplicas.
+	var got []string
+	for _, key := range []string{"workflow_job/1", "workflow_job/2", "workflow_job/3", "workflow_job/4"} {
+		got = append(got, scale(1, key))
+	}
+
+	want := []string{"spot-a", "spot-a", "spot-b", "ondemand"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected members: want %v, got %v", want, got)
+		}
+	}
+
+	// Redelivered events and completions go to the member the job was reserved on
+	if name := scale(1, "workflow_job/3"); name != "spot-b" {
+		t.Errorf("unexpected member for the redelivered event: want spot-b, got %s", name)
+	}
+
+	if name := scale(-1, "workflow_job/2"); name != "spot-a" {
+		t.Errorf("unexpected member for the completed job: want spot-a, got %s", name)
+	}
+
+	// The capacity freed by the completion is reused before the on-demand member
+	if name := scale(1, "workflow_job/5"); name != "spot-a" {
+		t.Errorf("unexpected member after the completion: want spot-a, got %s", name)
+	}
+}
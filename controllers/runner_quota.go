@@ -0,0 +1,345 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// runnerQuotaResult is the replicas of a runner deployment allowed by the runner quotas in its namespace.
+type runnerQuotaResult struct {
+	// covered is true when any runner quota covers the runner deployment
+	covered bool
+
+	requested int
+	replicas  int
+
+	// limitedBy is the name of the runner quota that limits the replicas below the requested ones, if any
+	limitedBy string
+}
+
+func (res runnerQuotaResult) limited() bool {
+	return res.limitedBy != ""
+}
+
+func (res runnerQuotaResult) message() string {
+	if !res.limited() {
+		return "The replicas are within the runner quotas"
+	}
+
+	return fmt.Sprintf("Limited replicas to %d instead of %d by RunnerQuota '%s'", res.replicas, res.requested, res.limitedBy)
+}
+
+func runnerQuotaCovers(q v1alpha1.RunnerQuota, rd v1alpha1.RunnerDeployment) bool {
+	return q.CoversOrganization(rd.Spec.Template.Spec.Organization, rd.Spec.Template.Spec.Repository)
+}
+
+// runnerQuotaReplicas returns the replicas of the runner deployment counted towards the runner quotas.
+// They are the replicas last applied by the runner deployment controller, which are already limited by the quotas,
+// falling back to the desired replicas until the runner deployment is reconciled for the first time.
+func runnerQuotaReplicas(rd v1alpha1.RunnerDeployment) int {
+	if rd.Status.DesiredReplicas != nil {
+		return *rd.Status.DesiredReplicas
+	}
+
+	return getIntOrDefault(rd.Spec.Replicas, 1)
+}
+
+// computeRunnerQuotaUsage sums the replicas and the resource requests of the runner deployments covered by the quota,
+// except the excluded one.
+// Kubernetes defaults the request of a resource to its limit, so the limit is used when the request is missing.
+func computeRunnerQuotaUsage(q v1alpha1.RunnerQuota, rds []v1alpha1.RunnerDeployment, exclude string) v1alpha1.RunnerQuotaUsage {
+	var replicas int
+	var cpu, memory int64
+
+	for _, rd := range rds {
+		if rd.Name == exclude || !rd.DeletionTimestamp.IsZero() || !runnerQuotaCovers(q, rd) {
+			continue
+		}
+
+		n := runnerQuotaReplicas(rd)
+		requests := placeholderResources(runnerPodSpecFromRD(rd))
+
+		replicas += n
+		cpu += int64(n) * requests.Cpu().MilliValue()
+		memory += int64(n) * requests.Memory().Value()
+	}
+
+	return v1alpha1.RunnerQuotaUsage{
+		Replicas: replicas,
+		CPU:      *resource.NewMilliQuantity(cpu, resource.DecimalSI),
+		Memory:   *resource.NewQuantity(memory, resource.BinarySI),
+	}
+}
+
+// allowedByRunnerQuota returns the maximum replicas that fit in the hard limits on top of the usage,
+// given the resource requests of each replica.
+// The runners without the requests of a resource aren't limited by the limit of the resource.
+func allowedByRunnerQuota(hard v1alpha1.RunnerQuotaLimits, used v1alpha1.RunnerQuotaUsage, requests corev1.ResourceList) int {
+	allowed := math.MaxInt32
+
+	if hard.Replicas != nil && *hard.Replicas-used.Replicas < allowed {
+		allowed = *hard.Replicas - used.Replicas
+	}
+
+	if cpu := requests.Cpu(); hard.CPU != nil && cpu.MilliValue() > 0 {
+		if n := int((hard.CPU.MilliValue() - used.CPU.MilliValue()) / cpu.MilliValue()); n < allowed {
+			allowed = n
+		}
+	}
+
+	if memory := requests.Memory(); hard.Memory != nil && memory.Value() > 0 {
+		if n := int((hard.Memory.Value() - used.Memory.Value()) / memory.Value()); n < allowed {
+			allowed = n
+		}
+	}
+
+	if allowed < 0 {
+		return 0
+	}
+
+	return allowed
+}
+
+// limitByRunnerQuotas limits the requested replicas of the runner deployment to the ones allowed by all the runner quotas
+// that cover it, given the replicas of the other runner deployments covered by each quota.
+//
+// The other runner deployments are counted by their status, which is updated on each reconciliation,
+// so that the quotas are enforced without any state of their own.
+func limitByRunnerQuotas(ctx context.Context, c client.Client, rd v1alpha1.RunnerDeployment, requested int) (runnerQuotaResult, error) {
+	res := runnerQuotaResult{
+		requested: requested,
+		replicas:  requested,
+	}
+
+	var quotas v1alpha1.RunnerQuotaList
+
+	if err := c.List(ctx, &quotas, client.InNamespace(rd.Namespace)); err != nil {
+		return res, fmt.Errorf("listing runnerquotas: %w", err)
+	}
+
+	var rds *v1alpha1.RunnerDeploymentList
+
+	requests := placeholderResources(runnerPodSpecFromRD(rd))
+
+	for _, q := range quotas.Items {
+		if !q.DeletionTimestamp.IsZero() || !runnerQuotaCovers(q, rd) {
+			continue
+		}
+
+		res.covered = true
+
+		if rds == nil {
+			rds = &v1alpha1.RunnerDeploymentList{}
+
+			if err := c.List(ctx, rds, client.InNamespace(rd.Namespace)); err != nil {
+				return res, fmt.Errorf("listing runnerdeployments: %w", err)
+			}
+		}
+
+		used := computeRunnerQuotaUsage(q, rds.Items, rd.Name)
+
+		if allowed := allowedByRunnerQuota(q.Spec.Hard, used, requests); allowed < res.replicas {
+			res.replicas = allowed
+			res.limitedBy = q.Name
+		}
+	}
+
+	return res, nil
+}
+
+// setRunnerDeploymentLimitedCondition sets the Limited condition of the runner deployment to tell whether the replicas are
+// limited by a runner quota, and removes it when no runner quota covers the runner deployment.
+// It returns true when the conditions are changed.
+func setRunnerDeploymentLimitedCondition(rd *v1alpha1.RunnerDeployment, res runnerQuotaResult) bool {
+	prev := meta.FindStatusCondition(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeLimited)
+
+	if !res.covered {
+		meta.RemoveStatusCondition(&rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeLimited)
+
+		return prev != nil
+	}
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.RunnerDeploymentConditionTypeLimited,
+		Status:             metav1.ConditionFalse,
+		Reason:             v1alpha1.RunnerDeploymentConditionReasonWithinRunnerQuota,
+		Message:            res.message(),
+		ObservedGeneration: rd.Generation,
+	}
+
+	if res.limited() {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = v1alpha1.RunnerDeploymentConditionReasonRunnerQuotaExceeded
+	}
+
+	if prev != nil && prev.Status == cond.Status && prev.Reason == cond.Reason && prev.Message == cond.Message && prev.ObservedGeneration == cond.ObservedGeneration {
+		return false
+	}
+
+	meta.SetStatusCondition(&rd.Status.Conditions, cond)
+
+	return true
+}
+
+// runnerDeploymentsForRunnerQuota enqueues the runner deployments covered by the runner quota,
+// so that a change of the limits is applied without waiting for the next sync.
+func (r *RunnerDeploymentReconciler) runnerDeploymentsForRunnerQuota(obj client.Object) []reconcile.Request {
+	q, ok := obj.(*v1alpha1.RunnerQuota)
+	if !ok {
+		return nil
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+
+	if err := r.List(context.TODO(), &rds, client.InNamespace(q.Namespace)); err != nil {
+		r.Log.Error(err, "Failed to list runnerdeployments for the runnerquota", "runnerquota", types.NamespacedName{Namespace: q.Namespace, Name: q.Name})
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, rd := range rds.Items {
+		if runnerQuotaCovers(*q, rd) {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}})
+		}
+	}
+
+	return reqs
+}
+
+// limitedRunnerDeploymentsInNamespace enqueues the other runner deployments in the namespace of the updated one
+// whose replicas are limited by a runner quota, so that they take the replicas freed by the scale down of the updated one.
+func (r *RunnerDeploymentReconciler) limitedRunnerDeploymentsInNamespace(obj client.Object) []reconcile.Request {
+	var rds v1alpha1.RunnerDeploymentList
+
+	if err := r.List(context.TODO(), &rds, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list runnerdeployments limited by runnerquotas", "namespace", obj.GetNamespace())
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, rd := range rds.Items {
+		if rd.Name == obj.GetName() || !meta.IsStatusConditionTrue(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeLimited) {
+			continue
+		}
+
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}})
+	}
+
+	return reqs
+}
+
+// RunnerQuotaReconciler reports the usage of each RunnerQuota, which is enforced by
+// the RunnerDeployment and HorizontalRunnerAutoscaler controllers.
+type RunnerQuotaReconciler struct {
+	client.Client
+	Log  logr.Logger
+	Name string
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerquotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerquotas/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch
+
+func (r *RunnerQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnerquota", req.NamespacedName)
+
+	var q v1alpha1.RunnerQuota
+	if err := r.Get(ctx, req.NamespacedName, &q); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !q.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+
+	if err := r.List(ctx, &rds, client.InNamespace(q.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	used := computeRunnerQuotaUsage(q, rds.Items, "")
+
+	if equality.Semantic.DeepEqual(q.Status.Used, used) {
+		return ctrl.Result{}, nil
+	}
+
+	updated := q.DeepCopy()
+	updated.Status.Used = used
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&q)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("patching runnerquota status: %w", err)
+	}
+
+	log.V(1).Info("Updated runnerquota usage", "replicas", used.Replicas, "cpu", used.CPU.String(), "memory", used.Memory.String())
+
+	return ctrl.Result{}, nil
+}
+
+// runnerQuotasForRunnerDeployment enqueues all the runner quotas in the namespace of the runner deployment.
+func (r *RunnerQuotaReconciler) runnerQuotasForRunnerDeployment(obj client.Object) []reconcile.Request {
+	var quotas v1alpha1.RunnerQuotaList
+
+	if err := r.List(context.TODO(), &quotas, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list runnerquotas for the runnerdeployment", "namespace", obj.GetNamespace())
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, q := range quotas.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: q.Namespace, Name: q.Name}})
+	}
+
+	return reqs
+}
+
+func (r *RunnerQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerquota-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RunnerQuota{}).
+		Watches(&source.Kind{Type: &v1alpha1.RunnerDeployment{}}, handler.EnqueueRequestsFromMapFunc(r.runnerQuotasForRunnerDeployment)).
+		Named(name).
+		Complete(r)
+}
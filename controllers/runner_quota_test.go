@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestLimitByRunnerQuotas(t *testing.T) {
+	dockerdWithinRunnerContainer := true
+	quantityPtr := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	newRD := func(name, org, repo string, desired int, cpu string) v1alpha1.RunnerDeployment {
+		rd := v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		rd.Spec.Template.Spec.Organization = org
+		rd.Spec.Template.Spec.Repository = repo
+		rd.Spec.Template.Spec.DockerdWithinRunnerContainer = &dockerdWithinRunnerContainer
+		rd.Spec.Template.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+		rd.Status.DesiredReplicas = intPtr(desired)
+		return rd
+	}
+
+	newQuota := func(name, org string, hard v1alpha1.RunnerQuotaLimits) *v1alpha1.RunnerQuota {
+		return &v1alpha1.RunnerQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       v1alpha1.RunnerQuotaSpec{Organization: org, Hard: hard},
+		}
+	}
+
+	teamA1 := newRD("team-a-1", "team-a", "", 3, "1")
+	teamA2 := newRD("team-a-2", "", "team-a/app", 2, "500m")
+	teamB := newRD("team-b", "team-b", "", 4, "1")
+
+	testcases := []struct {
+		name      string
+		quotas    []*v1alpha1.RunnerQuota
+		rd        v1alpha1.RunnerDeployment
+		requested int
+		want      runnerQuotaResult
+	}{
+		{
+			name:      "no quotas",
+			rd:        teamA1,
+			requested: 10,
+			want:      runnerQuotaResult{requested: 10, replicas: 10},
+		},
+		{
+			name:      "within the namespace quota",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("ns", "", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(10)})},
+			rd:        teamA1,
+			requested: 4,
+			want:      runnerQuotaResult{covered: true, requested: 4, replicas: 4},
+		},
+		{
+			// 10 - (2 + 4) replicas of the others
+			name:      "limited by replicas of the namespace quota",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("ns", "", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(10)})},
+			rd:        teamA1,
+			requested: 6,
+			want:      runnerQuotaResult{covered: true, requested: 6, replicas: 4, limitedBy: "ns"},
+		},
+		{
+			// The repository runners count towards the owner, and team-b isn't covered
+			name:      "limited by replicas of the organization quota",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("team-a", "team-a", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(5)})},
+			rd:        teamA1,
+			requested: 6,
+			want:      runnerQuotaResult{covered: true, requested: 6, replicas: 3, limitedBy: "team-a"},
+		},
+		{
+			name:      "not covered by the quota of another organization",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("team-a", "team-a", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(5)})},
+			rd:        teamB,
+			requested: 6,
+			want:      runnerQuotaResult{requested: 6, replicas: 6},
+		},
+		{
+			// (6 - 2 * 0.5 - 4 * 1) cpu / 1 cpu
+			name:      "limited by cpu",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("ns", "", v1alpha1.RunnerQuotaLimits{CPU: quantityPtr("6")})},
+			rd:        teamA1,
+			requested: 3,
+			want:      runnerQuotaResult{covered: true, requested: 3, replicas: 1, limitedBy: "ns"},
+		},
+		{
+			name:      "the others already exceed the quota",
+			quotas:    []*v1alpha1.RunnerQuota{newQuota("ns", "", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(5)})},
+			rd:        teamA1,
+			requested: 3,
+			want:      runnerQuotaResult{covered: true, requested: 3, replicas: 0, limitedBy: "ns"},
+		},
+		{
+			name: "limited by the tightest quota",
+			quotas: []*v1alpha1.RunnerQuota{
+				newQuota("ns", "", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(10)}),
+				newQuota("team-a", "team-a", v1alpha1.RunnerQuotaLimits{Replicas: intPtr(4)}),
+			},
+			rd:        teamA1,
+			requested: 6,
+			want:      runnerQuotaResult{covered: true, requested: 6, replicas: 2, limitedBy: "team-a"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := []runtime.Object{teamA1.DeepCopy(), teamA2.DeepCopy(), teamB.DeepCopy()}
+			for _, q := range tc.quotas {
+				objs = append(objs, q)
+			}
+
+			client := fake.NewFakeClientWithScheme(sc, objs...)
+
+			got, err := limitByRunnerQuotas(context.Background(), client, tc.rd, tc.requested)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := cmp.Diff(tc.want, got, cmp.AllowUnexported(runnerQuotaResult{})); d != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", d)
+			}
+
+			rd := tc.rd.DeepCopy()
+			setRunnerDeploymentLimitedCondition(rd, got)
+
+			cond := meta.FindStatusCondition(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeLimited)
+
+			switch {
+			case !got.covered:
+				if cond != nil {
+					t.Errorf("unexpected condition: %v", cond)
+				}
+			case got.limited():
+				if cond == nil || cond.Status != metav1.ConditionTrue {
+					t.Errorf("expected Limited=True, got %v", cond)
+				}
+			default:
+				if cond == nil || cond.Status != metav1.ConditionFalse {
+					t.Errorf("expected Limited=False, got %v", cond)
+				}
+			}
+
+			if setRunnerDeploymentLimitedCondition(rd, got) {
+				t.Errorf("unexpected change of the unchanged condition")
+			}
+		})
+	}
+}
+
+func TestRunnerQuotaReconciler(t *testing.T) {
+	memory := resource.MustParse("4Gi")
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+	rd.Spec.Template.Spec.Organization = "example"
+	rd.Spec.Template.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")}
+	rd.Spec.Template.Spec.DockerdContainerResources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	rd.Status.DesiredReplicas = intPtr(3)
+
+	q := &v1alpha1.RunnerQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec:       v1alpha1.RunnerQuotaSpec{Hard: v1alpha1.RunnerQuotaLimits{Memory: &memory}},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, rd, q)
+
+	r := &RunnerQuotaReconciler{
+		Client: client,
+		Log:    logr.Discard(),
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got v1alpha1.RunnerQuota
+	if err := client.Get(context.Background(), key, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status.Used.Replicas != 3 {
+		t.Errorf("unexpected replicas: want 3, got %d", got.Status.Used.Replicas)
+	}
+
+	if cpu := resource.MustParse("4500m"); got.Status.Used.CPU.Cmp(cpu) != 0 {
+		t.Errorf("unexpected cpu: want %s, got %s", cpu.String(), got.Status.Used.CPU.String())
+	}
+
+	if memory := resource.MustParse("3Gi"); got.Status.Used.Memory.Cmp(memory) != 0 {
+		t.Errorf("unexpected memory: want %s, got %s", memory.String(), got.Status.Used.Memory.String())
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// EnvVarRunnerExitMarker is the path of the file the runner container creates on exit.
+	// The sidecars wait for the file on termination so that they outlive the runner and the job it's running.
+	EnvVarRunnerExitMarker = "RUNNER_EXIT_MARKER"
+
+	runnerExitMarkerFile = ".runner-exited"
+
+	defaultSidecarWorkDir = "/runner/_work"
+)
+
+// sidecarPreStopCommand blocks the termination of the sidecar until the runner has exited.
+// The kubelet kills the sidecar anyway once the termination grace period of the pod is over,
+// and a sidecar image without sh fails the hook and is terminated right away, just like without the hook.
+var sidecarPreStopCommand = []string{"/bin/sh", "-c", `until [ -e "$` + EnvVarRunnerExitMarker + `" ]; do sleep 1; done`}
+
+// injectSidecars adds the sidecars to the runner pod, mounting the working directory of the runner into each of them.
+//
+// The sidecars are put before the runner container, as the kubelet starts the containers in order
+// and doesn't start the next container until the postStart hook of the previous one completes.
+// That's how a sidecar can make the runner wait until it's ready to serve the jobs.
+// On termination, the sidecars without their own preStop hook wait for the runner to exit.
+func injectSidecars(pod *corev1.Pod, sidecars []corev1.Container) error {
+	if len(sidecars) == 0 {
+		return nil
+	}
+
+	runnerIndex := -1
+
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			runnerIndex = i
+			break
+		}
+	}
+
+	if runnerIndex < 0 {
+		return fmt.Errorf("sidecars can't be added to the runner pod without the %s container", containerName)
+	}
+
+	runner := &pod.Spec.Containers[runnerIndex]
+
+	workDir := defaultSidecarWorkDir
+	for _, e := range runner.Env {
+		if e.Name == "RUNNER_WORKDIR" && e.Value != "" {
+			workDir = e.Value
+		}
+	}
+
+	// The working directory is shared only when dockerd runs in its own container, so it's added for the sidecars otherwise
+	if present, _ := workVolumePresent(pod.Spec.Volumes); !present {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "work",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	if present, index := workVolumeMountPresent(runner.VolumeMounts); present {
+		workDir = runner.VolumeMounts[index].MountPath
+	} else {
+		runner.VolumeMounts = append(runner.VolumeMounts, corev1.VolumeMount{
+			Name:      "work",
+			MountPath: workDir,
+		})
+	}
+
+	exitMarker := corev1.EnvVar{
+		Name:  EnvVarRunnerExitMarker,
+		Value: path.Join(workDir, runnerExitMarkerFile),
+	}
+
+	runner.Env = append(runner.Env, exitMarker)
+
+	containers := make([]corev1.Container, 0, len(sidecars)+len(pod.Spec.Containers))
+
+	for _, sidecar := range sidecars {
+		c := *sidecar.DeepCopy()
+
+		if present, _ := workVolumeMountPresent(c.VolumeMounts); !present {
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+				Name:      "work",
+				MountPath: workDir,
+			})
+		}
+
+		c.Env = append(c.Env, exitMarker)
+
+		if c.Lifecycle == nil {
+			c.Lifecycle = &corev1.Lifecycle{}
+		}
+
+		if c.Lifecycle.PreStop == nil {
+			c.Lifecycle.PreStop = &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: sidecarPreStopCommand},
+			}
+		}
+
+		containers = append(containers, c)
+	}
+
+	pod.Spec.Containers = append(containers, pod.Spec.Containers...)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// busyRunnerRecheckInterval is how often the controller checks if the runner being deleted is still busy
+const busyRunnerRecheckInterval = 10 * time.Second
+
+var errRunnerBusy = errors.New("runner is busy")
+
+// waitForBusyRunner records that GitHub reported the runner being deleted as busy, and returns true when
+// the controller should give up unregistering it according to the runner's unregistration config.
+// Otherwise, the returned result requeues the next busy check.
+func (r *RunnerReconciler) waitForBusyRunner(ctx context.Context, runner v1alpha1.Runner, log logr.Logger, now time.Time) (bool, ctrl.Result, error) {
+	config := runner.Spec.Unregistration
+	if config == nil {
+		config = &v1alpha1.RunnerUnregistrationConfig{}
+	}
+
+	busyChecks := runner.Status.BusyChecks + 1
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.RunnerConditionTypeUnregistered,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: runner.Generation,
+	}
+
+	var giveUp bool
+
+	switch {
+	case config.Policy == v1alpha1.UnregistrationPolicyAlways:
+		giveUp = true
+		cond.Reason = v1alpha1.RunnerConditionReasonBusyRunnerForceDeleted
+		cond.Message = "Deleting the busy runner without waiting for its job, as its unregistration policy is Always"
+	case config.BusyWaitTimeout != nil && now.Sub(runner.DeletionTimestamp.Time) >= config.BusyWaitTimeout.Duration:
+		giveUp = true
+		cond.Reason = v1alpha1.RunnerConditionReasonBusyWaitTimedOut
+		cond.Message = fmt.Sprintf("Deleting the runner without unregistering it, as it was still busy %s after the deletion request", config.BusyWaitTimeout.Duration)
+	case config.BusyCheckRetries != nil && busyChecks > *config.BusyCheckRetries:
+		giveUp = true
+		cond.Reason = v1alpha1.RunnerConditionReasonBusyCheckRetriesExhausted
+		cond.Message = fmt.Sprintf("Deleting the runner without unregistering it, as it was still busy after %d busy check retries", *config.BusyCheckRetries)
+	default:
+		cond.Reason = v1alpha1.RunnerConditionReasonRunnerBusy
+		cond.Message = fmt.Sprintf("Waiting for the runner to complete its job before unregistering it. GitHub reported the runner as busy %d time(s)", busyChecks)
+	}
+
+	updated := runner.DeepCopy()
+	updated.Status.BusyChecks = busyChecks
+	updated.Status.LastBusyCheckTime = &metav1.Time{Time: now}
+	meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
+		log.Error(err, "Failed to update runner status for busy check")
+		return false, ctrl.Result{}, err
+	}
+
+	if giveUp {
+		log.Info(cond.Message, "busyChecks", busyChecks)
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, cond.Reason, cond.Message)
+
+		return true, ctrl.Result{}, nil
+	}
+
+	log.V(1).Info("Runner is busy. Retrying unregistration later", "busyChecks", busyChecks, "retryAfter", busyRunnerRecheckInterval)
+
+	return false, ctrl.Result{RequeueAfter: busyRunnerRecheckInterval}, nil
+}
+
+// busyCheckDelay returns how long the controller should wait before checking the runner being deleted again,
+// so that the reconciliations triggered by the status updates in waitForBusyRunner don't result in excessive GitHub API calls.
+func busyCheckDelay(runner v1alpha1.Runner, now time.Time) time.Duration {
+	if runner.Status.LastBusyCheckTime == nil {
+		return 0
+	}
+
+	return runner.Status.LastBusyCheckTime.Add(busyRunnerRecheckInterval).Sub(now)
+}
+
+// unregistrationTimedOut returns true when the runner has failed to be unregistered for longer than
+// the unregistration timeout since the deletion request, so that it can be deleted without being unregistered
+// when the GitHub API is unreachable or keeps failing.
+func unregistrationTimedOut(runner v1alpha1.Runner, now time.Time) bool {
+	config := runner.Spec.Unregistration
+	if config == nil || config.Timeout == nil || runner.DeletionTimestamp == nil {
+		return false
+	}
+
+	return now.Sub(runner.DeletionTimestamp.Time) >= config.Timeout.Duration
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+func TestWaitForBusyRunner(t *testing.T) {
+	deletedAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	testcases := []struct {
+		name   string
+		config *actionsv1alpha1.RunnerUnregistrationConfig
+		// busyChecks is the number of busy checks including the current one
+		busyChecks int
+		now        time.Time
+		wantGiveUp bool
+		wantReason string
+	}{
+		{
+			name:       "defaults to waiting indefinitely",
+			busyChecks: 100,
+			now:        deletedAt.Add(24 * time.Hour),
+			wantReason: actionsv1alpha1.RunnerConditionReasonRunnerBusy,
+		},
+		{
+			name:       "within busy wait timeout",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{BusyWaitTimeout: &metav1.Duration{Duration: 5 * time.Minute}},
+			busyChecks: 1,
+			now:        deletedAt.Add(4 * time.Minute),
+			wantReason: actionsv1alpha1.RunnerConditionReasonRunnerBusy,
+		},
+		{
+			name:       "busy wait timed out",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{BusyWaitTimeout: &metav1.Duration{Duration: 5 * time.Minute}},
+			busyChecks: 2,
+			now:        deletedAt.Add(5 * time.Minute),
+			wantGiveUp: true,
+			wantReason: actionsv1alpha1.RunnerConditionReasonBusyWaitTimedOut,
+		},
+		{
+			name:       "retrying",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{BusyCheckRetries: intPtr(3)},
+			busyChecks: 3,
+			now:        deletedAt,
+			wantReason: actionsv1alpha1.RunnerConditionReasonRunnerBusy,
+		},
+		{
+			name:       "retries exhausted",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{BusyCheckRetries: intPtr(3)},
+			busyChecks: 4,
+			now:        deletedAt,
+			wantGiveUp: true,
+			wantReason: actionsv1alpha1.RunnerConditionReasonBusyCheckRetriesExhausted,
+		},
+		{
+			name:       "no retries",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{BusyCheckRetries: intPtr(0)},
+			busyChecks: 1,
+			now:        deletedAt,
+			wantGiveUp: true,
+			wantReason: actionsv1alpha1.RunnerConditionReasonBusyCheckRetriesExhausted,
+		},
+		{
+			name:       "always policy",
+			config:     &actionsv1alpha1.RunnerUnregistrationConfig{Policy: actionsv1alpha1.UnregistrationPolicyAlways, BusyCheckRetries: intPtr(3)},
+			busyChecks: 1,
+			now:        deletedAt,
+			wantGiveUp: true,
+			wantReason: actionsv1alpha1.RunnerConditionReasonBusyRunnerForceDeleted,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := &actionsv1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "example-runner",
+					Namespace:         "default",
+					DeletionTimestamp: &metav1.Time{Time: deletedAt},
+					Finalizers:        []string{finalizerName},
+				},
+				Spec: actionsv1alpha1.RunnerSpec{
+					Unregistration: tc.config,
+				},
+				Status: actionsv1alpha1.RunnerStatus{
+					BusyChecks: tc.busyChecks - 1,
+				},
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, runner)
+
+			r := &RunnerReconciler{Client: c, Log: logr.Discard(), Recorder: record.NewFakeRecorder(1)}
+
+			giveUp, res, err := r.waitForBusyRunner(context.Background(), *runner, r.Log, tc.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if giveUp != tc.wantGiveUp {
+				t.Errorf("unexpected giveUp: want %v, got %v", tc.wantGiveUp, giveUp)
+			}
+
+			wantRes := ctrl.Result{}
+			if !tc.wantGiveUp {
+				wantRes.RequeueAfter = busyRunnerRecheckInterval
+			}
+
+			if res != wantRes {
+				t.Errorf("unexpected result: want %+v, got %+v", wantRes, res)
+			}
+
+			var got actionsv1alpha1.Runner
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(runner), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Status.BusyChecks != tc.busyChecks {
+				t.Errorf("unexpected busy checks: want %d, got %d", tc.busyChecks, got.Status.BusyChecks)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, actionsv1alpha1.RunnerConditionTypeUnregistered)
+			if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != tc.wantReason {
+				t.Errorf("unexpected condition: want reason %s, got %+v", tc.wantReason, cond)
+			}
+
+			if d := busyCheckDelay(got, tc.now.Add(time.Second)); d != busyRunnerRecheckInterval-time.Second {
+				t.Errorf("unexpected busy check delay: %s", d)
+			}
+		})
+	}
+}
+
+func TestUnregistrationTimedOut(t *testing.T) {
+	deletedAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	runner := actionsv1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: deletedAt}},
+	}
+
+	if unregistrationTimedOut(runner, deletedAt.Add(24*time.Hour)) {
+		t.Errorf("unexpected timeout without unregistration timeout")
+	}
+
+	runner.Spec.Unregistration = &actionsv1alpha1.RunnerUnregistrationConfig{Timeout: &metav1.Duration{Duration: 5 * time.Minute}}
+
+	if unregistrationTimedOut(runner, deletedAt.Add(4*time.Minute)) {
+		t.Errorf("unexpected timeout within unregistration timeout")
+	}
+
+	if !unregistrationTimedOut(runner, deletedAt.Add(5*time.Minute)) {
+		t.Errorf("expected timeout after unregistration timeout")
+	}
+}
+
+func TestProcessRunnerDeletion_NeverPolicy(t *testing.T) {
+	runner := &actionsv1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "example-runner",
+			Namespace:         "default",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{finalizerName},
+		},
+		Spec: actionsv1alpha1.RunnerSpec{
+			Unregistration: &actionsv1alpha1.RunnerUnregistrationConfig{Policy: actionsv1alpha1.UnregistrationPolicyNever},
+		},
+		Status: actionsv1alpha1.RunnerStatus{
+			Registration: actionsv1alpha1.RunnerStatusRegistration{Token: "token"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, runner)
+
+	r := &RunnerReconciler{Client: c, Log: logr.Discard(), Recorder: record.NewFakeRecorder(1)}
+
+	// The nil GitHub client panics if the controller calls GitHub
+	res, err := r.processRunnerDeletion(*runner, context.Background(), r.Log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res != (ctrl.Result{}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// The runner is gone once the finalizer is removed
+	var got actionsv1alpha1.Runner
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(runner), &got); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the runner to be deleted: finalizers %v, error %v", got.Finalizers, err)
+	}
+}
+
+func TestProcessRunnerDeletion_MissingCredentials(t *testing.T) {
+	runner := &actionsv1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "example-runner",
+			Namespace:         "default",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{finalizerName},
+		},
+		Spec: actionsv1alpha1.RunnerSpec{
+			RunnerConfig: actionsv1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				GitHubAPICredentialsFrom: &actionsv1alpha1.GitHubAPICredentialsFrom{
+					SecretRef: actionsv1alpha1.SecretReference{Name: "deleted"},
+				},
+			},
+		},
+		Status: actionsv1alpha1.RunnerStatus{
+			Registration: actionsv1alpha1.RunnerStatusRegistration{Token: "token"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, runner)
+
+	recorder := record.NewFakeRecorder(1)
+
+	r := &RunnerReconciler{
+		Client:            c,
+		Log:               logr.Discard(),
+		Recorder:          recorder,
+		MultiGitHubClient: NewMultiGitHubClient(c, github.Config{}),
+	}
+
+	res, err := r.processRunnerDeletion(*runner, context.Background(), r.Log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res != (ctrl.Result{}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	var got actionsv1alpha1.Runner
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(runner), &got); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the runner to be deleted: finalizers %v, error %v", got.Finalizers, err)
+	}
+
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, actionsv1alpha1.RunnerConditionReasonUnregistrationSkipped) {
+			t.Errorf("unexpected event: %s", e)
+		}
+	default:
+		t.Errorf("expected an event for the skipped unregistration")
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+// runnerUsageSummaryDays is the number of the daily usage summaries kept in the status of a RunnerDeployment.
+const runnerUsageSummaryDays = 7
+
+// RunnerUsageReconciler accounts the usage of the runners of each RunnerDeployment, so that the cost of CI can be attributed
+// to the teams owning the RunnerDeployments.
+//
+// Every Interval, it observes the running runner pods and the runners busy on GitHub, and adds the runner-seconds and
+// busy-seconds since the last observation, and the jobs started since then, to the Prometheus counters.
+// A job is counted when its runner is observed busy after being observed idle or not at all, so the jobs shorter than
+// the interval that start and complete between two observations on the same runner aren't counted.
+type RunnerUsageReconciler struct {
+	client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+	Name         string
+
+	// MultiGitHubClient provides the clients of the runner deployments with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+
+	// Interval is the interval between the observations.
+	Interval time.Duration
+
+	// DailySummaries makes the usage of each day written into the status of the RunnerDeployment, too.
+	DailySummaries bool
+
+	mu     sync.Mutex
+	states map[types.NamespacedName]*runnerUsageState
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type runnerUsageState struct {
+	lastObservationTime time.Time
+	running             int
+	busy                map[string]bool
+}
+
+// runnerUsage is the usage of the runners between two observations.
+type runnerUsage struct {
+	runnerSeconds float64
+	busySeconds   float64
+	jobs          int
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+func (r *RunnerUsageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnerdeployment", req.NamespacedName)
+
+	var rd v1alpha1.RunnerDeployment
+	if err := r.Get(ctx, req.NamespacedName, &rd); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !rd.DeletionTimestamp.IsZero() {
+		r.forget(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	state := r.state(req.NamespacedName)
+
+	// Reconciled on every update of the RunnerDeployment, but observed only at the interval
+	if next := state.lastObservationTime.Add(r.Interval); now.Before(next) {
+		return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+
+	var pods corev1.PodList
+
+	if err := r.List(ctx, &pods, client.InNamespace(rd.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: rd.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	running := 0
+	runners := map[string]bool{}
+
+	for _, pod := range pods.Items {
+		runners[pod.Name] = true
+
+		if pod.Status.Phase == corev1.PodRunning {
+			running++
+		}
+	}
+
+	spec := rd.Spec.Template.Spec
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rd.Namespace, spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ghRunners, err := ghc.ListRunners(ctx, spec.Enterprise, spec.Organization, spec.Repository)
+	if err != nil {
+		var e *gogithub.RateLimitError
+		if errors.As(err, &e) {
+			log.Info(fmt.Sprintf("Failed to observe busy runners due to GitHub API rate limits. Retrying in %s", retryDelayOnGitHubAPIRateLimitError))
+
+			return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	busy := map[string]bool{}
+
+	for _, runner := range ghRunners {
+		if runners[runner.GetName()] && runner.GetBusy() {
+			busy[runner.GetName()] = true
+		}
+	}
+
+	usage := computeRunnerUsage(state, running, busy, now)
+
+	metrics.AddRunnerDeploymentUsage(rd.Namespace, rd.Name, usage.runnerSeconds, usage.busySeconds, usage.jobs)
+
+	if r.DailySummaries && !state.lastObservationTime.IsZero() {
+		updated := rd.DeepCopy()
+		updated.Status.Usage = addRunnerUsageSummary(rd.Status.Usage, usage, now)
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("patching runnerdeployment status for usage: %w", err)
+		}
+	}
+
+	log.V(2).Info("Observed runner usage",
+		"running", running,
+		"busy", len(busy),
+		"runner_seconds", usage.runnerSeconds,
+		"busy_seconds", usage.busySeconds,
+		"jobs", usage.jobs,
+	)
+
+	state.lastObservationTime = now
+	state.running = running
+	state.busy = busy
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+// computeRunnerUsage returns the usage since the last observation, which counts the runners running and busy at the last observation
+// as running and busy until now, and the runners newly busy as the jobs started.
+// Nothing is counted on the first observation, as nothing is known about the runners before it.
+func computeRunnerUsage(state *runnerUsageState, running int, busy map[string]bool, now time.Time) runnerUsage {
+	if state.lastObservationTime.IsZero() {
+		return runnerUsage{}
+	}
+
+	elapsed := now.Sub(state.lastObservationTime).Seconds()
+
+	usage := runnerUsage{
+		runnerSeconds: float64(state.running) * elapsed,
+		busySeconds:   float64(len(state.busy)) * elapsed,
+	}
+
+	for name := range busy {
+		if !state.busy[name] {
+			usage.jobs++
+		}
+	}
+
+	return usage
+}
+
+// addRunnerUsageSummary adds the usage to the summary of the day in UTC, and drops the summaries older than runnerUsageSummaryDays.
+func addRunnerUsageSummary(summaries []v1alpha1.RunnerUsageSummary, usage runnerUsage, now time.Time) []v1alpha1.RunnerUsageSummary {
+	date := now.UTC().Format("2006-01-02")
+	oldest := now.UTC().AddDate(0, 0, -(runnerUsageSummaryDays - 1)).Format("2006-01-02")
+
+	var updated []v1alpha1.RunnerUsageSummary
+
+	for _, s := range summaries {
+		// The dates in the ISO 8601 format are ordered as strings
+		if s.Date >= oldest {
+			updated = append(updated, s)
+		}
+	}
+
+	if n := len(updated); n == 0 || updated[n-1].Date != date {
+		updated = append(updated, v1alpha1.RunnerUsageSummary{Date: date})
+	}
+
+	today := &updated[len(updated)-1]
+	today.RunnerSeconds += int64(math.Round(usage.runnerSeconds))
+	today.BusySeconds += int64(math.Round(usage.busySeconds))
+	today.Jobs += usage.jobs
+
+	return updated
+}
+
+// state returns the state of the observations for the RunnerDeployment.
+// The state is updated without locking, because controller-runtime never reconciles the same RunnerDeployment concurrently.
+func (r *RunnerUsageReconciler) state(key types.NamespacedName) *runnerUsageState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.states == nil {
+		r.states = map[types.NamespacedName]*runnerUsageState{}
+	}
+
+	s, ok := r.states[key]
+	if !ok {
+		s = &runnerUsageState{}
+		r.states[key] = s
+	}
+
+	return s
+}
+
+func (r *RunnerUsageReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.states, key)
+}
+
+func (r *RunnerUsageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerusage-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RunnerDeployment{}).
+		Named(name).
+		Complete(r)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestRunnerUsageReconciler(t *testing.T) {
+	runners := []*github.Runner{
+		{ID: github.Int64(1), Name: github.String("example-a"), Status: github.String("online"), Busy: github.Bool(true)},
+		{ID: github.Int64(2), Name: github.String("example-b"), Status: github.String("online"), Busy: github.Bool(false)},
+		// Not a runner of the RunnerDeployment
+		{ID: github.Int64(3), Name: github.String("other-a"), Status: github.String("online"), Busy: github.Bool(true)},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+		j, err := json.Marshal(github.Runners{TotalCount: len(runners), Runners: runners})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2022, 3, 1, 23, 58, 0, 0, time.UTC)
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+	rd.Spec.Template.Spec.Repository = "test/valid"
+
+	objs := []runtime.Object{rd}
+	for _, name := range []string{"example-a", "example-b"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		})
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, objs...)
+
+	clock := now
+
+	r := &RunnerUsageReconciler{
+		Client:         client,
+		GitHubClient:   newGithubClient(server),
+		Log:            logr.Discard(),
+		Interval:       time.Minute,
+		DailySummaries: true,
+		now:            func() time.Time { return clock },
+	}
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func(wantRequeueAfter time.Duration) []v1alpha1.RunnerUsageSummary {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Errorf("unexpected requeue after: want %s, got %s", wantRequeueAfter, res.RequeueAfter)
+		}
+
+		var got v1alpha1.RunnerDeployment
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got.Status.Usage
+	}
+
+	// Nothing is known before the first observation
+	if usage := reconcile(time.Minute); usage != nil {
+		t.Fatalf("unexpected usage on the first observation: %+v", usage)
+	}
+
+	clock = now.Add(30 * time.Second)
+
+	reconcile(30 * time.Second)
+
+	// example-b picks up a job
+	runners[1].Busy = github.Bool(true)
+
+	clock = now.Add(time.Minute)
+
+	usage := reconcile(time.Minute)
+
+	want := []v1alpha1.RunnerUsageSummary{
+		{Date: "2022-03-01", RunnerSeconds: 120, BusySeconds: 60, Jobs: 1},
+	}
+
+	if d := cmp.Diff(want, usage); d != "" {
+		t.Errorf("unexpected usage (-want +got):\n%s", d)
+	}
+
+	// The usage until the observation after the midnight goes to the next day
+	clock = now.Add(2 * time.Minute)
+
+	usage = reconcile(time.Minute)
+
+	want = append(want, v1alpha1.RunnerUsageSummary{Date: "2022-03-02", RunnerSeconds: 120, BusySeconds: 120})
+
+	if d := cmp.Diff(want, usage); d != "" {
+		t.Errorf("unexpected usage (-want +got):\n%s", d)
+	}
+}
+
+func TestAddRunnerUsageSummary(t *testing.T) {
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	summaries := []v1alpha1.RunnerUsageSummary{
+		{Date: "2022-03-03", RunnerSeconds: 100},
+		{Date: "2022-03-04", RunnerSeconds: 200},
+		{Date: "2022-03-10", RunnerSeconds: 300, BusySeconds: 10, Jobs: 1},
+	}
+
+	got := addRunnerUsageSummary(summaries, runnerUsage{runnerSeconds: 59.6, busySeconds: 30.2, jobs: 2}, now)
+
+	want := []v1alpha1.RunnerUsageSummary{
+		{Date: "2022-03-04", RunnerSeconds: 200},
+		{Date: "2022-03-10", RunnerSeconds: 360, BusySeconds: 40, Jobs: 3},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected summaries (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+// RunnerVersionUpgradeReconciler upgrades the runners of the RunnerDeployments with RunnerVersionUpgrade
+// to the latest version of actions/runner in their channels.
+//
+// It only records the version to upgrade to in the status of each RunnerDeployment, within the maintenance windows of its strategy.
+// The RunnerDeployment controller then rolls the template with the runner image of the version out the same way as any update of the template.
+type RunnerVersionUpgradeReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Name     string
+
+	// Feed tells the latest versions of actions/runner.
+	Feed *github.RunnerReleaseFeed
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *RunnerVersionUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnerdeployment", req.NamespacedName)
+
+	var rd v1alpha1.RunnerDeployment
+	if err := r.Get(ctx, req.NamespacedName, &rd); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !rd.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	upgrade := rd.Spec.RunnerVersionUpgrade
+
+	// Disabling the upgrades rolls the image of the template back out
+	if upgrade == nil {
+		if rd.Status.RunnerVersion == "" {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, r.setRunnerVersion(ctx, rd, "")
+	}
+
+	latest := r.Feed.LatestVersion(upgrade.Channel)
+	if latest == "" || latest == rd.Status.RunnerVersion {
+		return ctrl.Result{RequeueAfter: r.Feed.Interval}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	var windows []v1alpha1.MaintenanceWindow
+	if rd.Spec.Strategy != nil {
+		windows = rd.Spec.Strategy.MaintenanceWindows
+	}
+
+	// The first version is applied right away, as there are no runners to disrupt that are created by RunnerVersionUpgrade yet
+	if rd.Status.RunnerVersion != "" {
+		active, upcoming, err := matchMaintenanceWindows(now, windows)
+		if err != nil {
+			log.Error(err, "Failed to match the maintenance windows")
+
+			return ctrl.Result{}, nil
+		}
+
+		if !active {
+			log.V(1).Info("Waiting for a maintenance window to upgrade the runners", "version", latest, "upcoming", upcoming)
+
+			requeueAfter := r.Feed.Interval
+			if upcoming != nil && upcoming.Sub(now) < requeueAfter {
+				requeueAfter = upcoming.Sub(now)
+			}
+
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+	}
+
+	if err := r.setRunnerVersion(ctx, rd, latest); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	msg := fmt.Sprintf("Upgrading runners from version %q to %q", rd.Status.RunnerVersion, latest)
+
+	r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerVersionUpgrade", msg)
+	log.Info(msg)
+
+	return ctrl.Result{RequeueAfter: r.Feed.Interval}, nil
+}
+
+func (r *RunnerVersionUpgradeReconciler) setRunnerVersion(ctx context.Context, rd v1alpha1.RunnerDeployment, version string) error {
+	updated := rd.DeepCopy()
+	updated.Status.RunnerVersion = version
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		return fmt.Errorf("patching runnerdeployment status for runner version: %w", err)
+	}
+
+	return nil
+}
+
+// matchMaintenanceWindows tells if any of the maintenance windows is active, and when the next one starts otherwise.
+// The upgrades are never blocked without any windows.
+func matchMaintenanceWindows(now time.Time, windows []v1alpha1.MaintenanceWindow) (bool, *time.Time, error) {
+	if len(windows) == 0 {
+		return true, nil, nil
+	}
+
+	var upcoming *time.Time
+
+	for _, w := range windows {
+		a, u, err := MatchSchedule(
+			now, w.StartTime.Time, w.EndTime.Time,
+			RecurrenceRule{
+				Frequency: w.RecurrenceRule.Frequency,
+				UntilTime: w.RecurrenceRule.UntilTime.Time,
+			},
+		)
+		if err != nil {
+			return false, nil, err
+		}
+
+		if a != nil {
+			return true, nil, nil
+		}
+
+		if u != nil && (upcoming == nil || u.StartTime.Before(*upcoming)) {
+			start := u.StartTime
+			upcoming = &start
+		}
+	}
+
+	return false, upcoming, nil
+}
+
+// runnerVersionImage returns the runner image of the version the runners of the RunnerDeployment are upgraded to,
+// or an empty string when the image of the template is used as-is.
+func runnerVersionImage(rd v1alpha1.RunnerDeployment) string {
+	if rd.Spec.RunnerVersionUpgrade == nil || rd.Status.RunnerVersion == "" {
+		return ""
+	}
+
+	return strings.ReplaceAll(rd.Spec.RunnerVersionUpgrade.Image, v1alpha1.RunnerVersionPlaceholder, rd.Status.RunnerVersion)
+}
+
+func (r *RunnerVersionUpgradeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerversionupgrade-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RunnerDeployment{}).
+		Named(name).
+		Complete(r)
+}
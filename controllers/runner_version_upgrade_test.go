@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+func TestRunnerVersionUpgradeReconciler(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		releases = `[{"tag_name":"v2.290.1"}]`
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Write([]byte(releases))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 10 * time.Millisecond
+
+	feed := github.NewRunnerReleaseFeed(server.URL, interval, logr.Discard())
+	go feed.Start(ctx)
+
+	waitForVersion := func(want string) {
+		t.Helper()
+
+		for i := 0; feed.LatestVersion("") != want; i++ {
+			if i > 100 {
+				t.Fatalf("timed out waiting for the version %s to be listed", want)
+			}
+			time.Sleep(interval)
+		}
+	}
+
+	waitForVersion("2.290.1")
+
+	// A Tuesday
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			RunnerVersionUpgrade: &v1alpha1.RunnerVersionUpgradeSpec{
+				Image: "summerwind/actions-runner:v{version}-ubuntu-20.04",
+			},
+			Strategy: &v1alpha1.RunnerDeploymentStrategy{
+				MaintenanceWindows: []v1alpha1.MaintenanceWindow{
+					{
+						StartTime:      metav1.Time{Time: time.Date(2022, 2, 26, 1, 0, 0, 0, time.UTC)},
+						EndTime:        metav1.Time{Time: time.Date(2022, 2, 26, 5, 0, 0, 0, time.UTC)},
+						RecurrenceRule: v1alpha1.RecurrenceRule{Frequency: "Weekly"},
+					},
+				},
+			},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, rd)
+
+	clock := now
+
+	r := &RunnerVersionUpgradeReconciler{
+		Client:   client,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+		Feed:     feed,
+		now:      func() time.Time { return clock },
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func(wantRequeueAfter time.Duration) v1alpha1.RunnerDeployment {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Errorf("unexpected requeue after: want %s, got %s", wantRequeueAfter, res.RequeueAfter)
+		}
+
+		var got v1alpha1.RunnerDeployment
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got
+	}
+
+	// The first version is applied regardless of the maintenance windows
+	got := reconcile(interval)
+
+	if v := got.Status.RunnerVersion; v != "2.290.1" {
+		t.Fatalf("unexpected runner version: want 2.290.1, got %q", v)
+	}
+
+	if image := runnerVersionImage(got); image != "summerwind/actions-runner:v2.290.1-ubuntu-20.04" {
+		t.Errorf("unexpected runner image: %s", image)
+	}
+
+	// The newer version waits for the window, while rechecking the feed every interval
+	mu.Lock()
+	releases = `[{"tag_name":"v2.291.0"},{"tag_name":"v2.290.1"}]`
+	mu.Unlock()
+
+	waitForVersion("2.291.0")
+
+	if v := reconcile(interval).Status.RunnerVersion; v != "2.290.1" {
+		t.Errorf("unexpected runner version outside of the maintenance window: %q", v)
+	}
+
+	clock = time.Date(2022, 3, 5, 2, 0, 0, 0, time.UTC)
+
+	if v := reconcile(interval).Status.RunnerVersion; v != "2.291.0" {
+		t.Errorf("unexpected runner version within the maintenance window: %q", v)
+	}
+}
+
+func TestMatchMaintenanceWindows(t *testing.T) {
+	windows := []v1alpha1.MaintenanceWindow{
+		{
+			StartTime:      metav1.Time{Time: time.Date(2022, 2, 26, 1, 0, 0, 0, time.UTC)},
+			EndTime:        metav1.Time{Time: time.Date(2022, 2, 26, 5, 0, 0, 0, time.UTC)},
+			RecurrenceRule: v1alpha1.RecurrenceRule{Frequency: "Weekly"},
+		},
+		{
+			StartTime:      metav1.Time{Time: time.Date(2022, 2, 23, 22, 0, 0, 0, time.UTC)},
+			EndTime:        metav1.Time{Time: time.Date(2022, 2, 23, 23, 0, 0, 0, time.UTC)},
+			RecurrenceRule: v1alpha1.RecurrenceRule{Frequency: "Weekly"},
+		},
+	}
+
+	active, upcoming, err := matchMaintenanceWindows(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC), windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active {
+		t.Errorf("unexpected active maintenance window")
+	}
+
+	if want := time.Date(2022, 3, 2, 22, 0, 0, 0, time.UTC); upcoming == nil || !upcoming.Equal(want) {
+		t.Errorf("unexpected upcoming maintenance window: want %s, got %v", want, upcoming)
+	}
+
+	active, _, err = matchMaintenanceWindows(time.Date(2022, 3, 5, 4, 0, 0, 0, time.UTC), windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !active {
+		t.Errorf("unexpected inactive maintenance window")
+	}
+
+	if active, _, _ := matchMaintenanceWindows(time.Now(), nil); !active {
+		t.Errorf("unexpected inactive maintenance window without windows")
+	}
+}
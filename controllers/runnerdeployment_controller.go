@@ -22,6 +22,7 @@ import (
 	"hash/fnv"
 	"reflect"
 	"sort"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/types"
@@ -33,12 +34,16 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
 )
 
 const (
@@ -56,6 +61,20 @@ type RunnerDeploymentReconciler struct {
 	Scheme             *runtime.Scheme
 	CommonRunnerLabels []string
 	Name               string
+	ControllerOptions  ControllerOptions
+
+	// GitHubClient is used to tear down GitHub-side artifacts on deletion,
+	// for runner deployments whose GitHubTeardownPolicy is either Delete or DryRun,
+	// and to validate the runner groups of the runner deployments.
+	GitHubClient *github.Client
+	// MultiGitHubClient provides the clients of the runner deployments with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
+	// RunnerQuotas makes the replicas limited by the RunnerQuotas. Requires the RunnerQuota CRD.
+	RunnerQuotas bool
+
+	// runnerGroupChecks are the last validations of the runner groups. See validateRunnerGroup.
+	runnerGroupChecks   map[types.NamespacedName]runnerGroupCheck
+	runnerGroupChecksMu sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch;create;update;patch;delete
@@ -63,6 +82,8 @@ type RunnerDeploymentReconciler struct {
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerreplicasets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerreplicasets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerquotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -74,6 +95,14 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	if !rd.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.processGitHubTeardown(ctx, log, rd)
+	}
+
+	if updated, err := r.syncGitHubTeardownFinalizer(ctx, rd); err != nil {
+		log.Error(err, "Failed to update runnerdeployment finalizers")
+
+		return ctrl.Result{}, err
+	} else if updated {
 		return ctrl.Result{}, nil
 	}
 
@@ -102,6 +131,16 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		oldSets = myRunnerReplicaSets[1:]
 	}
 
+	if rd.Spec.Paused {
+		if newestSet == nil {
+			return ctrl.Result{}, nil
+		}
+
+		// The runner replica sets are left as they are until the runner deployment is unpaused,
+		// while the status keeps reflecting their runners.
+		return r.updateStatus(ctx, log, rd, newPausedRunnerDeploymentStatus(rd, *newestSet, oldSets))
+	}
+
 	desiredRS, err := r.newRunnerReplicaSet(rd)
 	if err != nil {
 		r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerAutoscalingFailure", err.Error())
@@ -111,6 +150,78 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	const defaultReplicas = 1
+
+	warmPool, err := r.syncWarmPool(ctx, rd, time.Now())
+	if err != nil {
+		log.Error(err, "Failed to list the busy runners for the warm pool. Keeping the last observed state")
+
+		warmPool = rd.Status.WarmPool
+	}
+
+	if !reflect.DeepEqual(rd.Status.WarmPool, warmPool) {
+		updated := rd.DeepCopy()
+		updated.Status.WarmPool = warmPool
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			log.Error(err, "Failed to patch the warm pool status of runnerdeployment")
+
+			return ctrl.Result{}, err
+		}
+
+		rd = *updated
+	}
+
+	if rd.Spec.WarmPool != nil {
+		replicas := warmPoolReplicas(getIntOrDefault(rd.Spec.Replicas, defaultReplicas), rd.Spec.WarmPool, warmPool)
+		desiredRS.Spec.Replicas = &replicas
+	}
+
+	var quota runnerQuotaResult
+
+	if r.RunnerQuotas {
+		quota, err = limitByRunnerQuotas(ctx, r.Client, rd, getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas))
+		if err != nil {
+			log.Error(err, "Failed to apply runner quotas")
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	if quota.covered {
+		desiredRS.Spec.Replicas = &quota.replicas
+	}
+
+	if updated := rd.DeepCopy(); setRunnerDeploymentLimitedCondition(updated, quota) {
+		if quota.limited() && !meta.IsStatusConditionTrue(rd.Status.Conditions, v1alpha1.RunnerDeploymentConditionTypeLimited) {
+			r.Recorder.Event(&rd, corev1.EventTypeWarning, v1alpha1.RunnerDeploymentConditionReasonRunnerQuotaExceeded, quota.message())
+		}
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			log.Error(err, "Failed to patch the limited condition of runnerdeployment")
+
+			return ctrl.Result{}, err
+		}
+
+		rd = *updated
+	}
+
+	if cond, checked, err := r.validateRunnerGroup(ctx, rd, time.Now()); err != nil {
+		log.Error(err, "Failed to validate the runner group. Retrying on the next reconciliation")
+	} else if updated := rd.DeepCopy(); checked && setRunnerDeploymentRunnerGroupValidCondition(updated, cond) {
+		if cond != nil && cond.Status == metav1.ConditionFalse {
+			r.Recorder.Event(&rd, corev1.EventTypeWarning, cond.Reason, cond.Message)
+		}
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			log.Error(err, "Failed to patch the runner group condition of runnerdeployment")
+
+			return ctrl.Result{}, err
+		}
+
+		rd = *updated
+	}
+
 	if newestSet == nil {
 		if err := r.Client.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
@@ -136,6 +247,12 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	if newestTemplateHash != desiredTemplateHash {
+		// With a strategy, the rollout scales the runner replica set of the updated template up gradually from zero
+		if rd.Spec.Strategy != nil {
+			zero := 0
+			desiredRS.Spec.Replicas = &zero
+		}
+
 		if err := r.Client.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
 
@@ -169,11 +286,15 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
-	const defaultReplicas = 1
-
 	currentDesiredReplicas := getIntOrDefault(newestSet.Spec.Replicas, defaultReplicas)
 	newDesiredReplicas := getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas)
 
+	if rd.Spec.Strategy != nil {
+		res, err := r.rollout(ctx, log, rd, *newestSet, oldSets, newDesiredReplicas, time.Now())
+
+		return requeueForWarmPool(rd, res), err
+	}
+
 	// Please add more conditions that we can in-place update the newest runnerreplicaset without disruption
 	if currentDesiredReplicas != newDesiredReplicas {
 		newestSet.Spec.Replicas = &newDesiredReplicas
@@ -210,7 +331,10 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			logWithDebugInfo.
 				Info("Waiting until the newest runnerreplicaset to be 100% available")
 
-			return ctrl.Result{}, nil
+			// Report the rollout in progress, so that rollout tooling can tell it from a completed one
+			res, err := r.updateStatus(ctx, log, rd, newRunnerDeploymentStatus(rd, *newestSet, oldSets, newDesiredReplicas))
+
+			return requeueForWarmPool(rd, res), err
 		}
 
 		if oldSetsCount > 0 {
@@ -233,53 +357,125 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
-	var replicaSets []v1alpha1.RunnerReplicaSet
+	res, err := r.updateStatus(ctx, log, rd, newRunnerDeploymentStatus(rd, *newestSet, nil, newDesiredReplicas))
 
-	replicaSets = append(replicaSets, *newestSet)
-	replicaSets = append(replicaSets, oldSets...)
+	return requeueForWarmPool(rd, res), err
+}
 
-	var totalCurrentReplicas, totalStatusAvailableReplicas, updatedReplicas int
+func (r *RunnerDeploymentReconciler) updateStatus(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, status v1alpha1.RunnerDeploymentStatus) (ctrl.Result, error) {
+	if reflect.DeepEqual(rd.Status, status) {
+		return ctrl.Result{}, nil
+	}
 
-	for _, rs := range replicaSets {
-		var current, available int
+	updated := rd.DeepCopy()
+	updated.Status = status
 
-		if rs.Status.Replicas != nil {
-			current = *rs.Status.Replicas
-		}
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		log.Info("Failed to patch runnerdeployment status. Retrying immediately", "error", err.Error())
+		return ctrl.Result{
+			Requeue: true,
+		}, nil
+	}
 
-		if rs.Status.AvailableReplicas != nil {
-			available = *rs.Status.AvailableReplicas
-		}
+	return ctrl.Result{}, nil
+}
 
-		totalCurrentReplicas += current
-		totalStatusAvailableReplicas += available
+// newRunnerDeploymentStatus computes the status of the runner deployment from its runner replica sets,
+// the same way the deployment controller of Kubernetes does from replica sets.
+// See https://github.com/kubernetes/kubernetes/blob/ea0764452222146c47ec826977f49d7001b0ea8c/pkg/controller/deployment/sync.go#L487-L505
+func newRunnerDeploymentStatus(rd v1alpha1.RunnerDeployment, newestSet v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet, desiredReplicas int) v1alpha1.RunnerDeploymentStatus {
+	var totalCurrentReplicas, totalReadyReplicas, totalAvailableReplicas, updatedReplicas, unavailableReplicas int
+	var totalUnschedulableReplicas, totalPreemptingReplicas, totalTerminatingReplicas int
+
+	for _, rs := range append([]v1alpha1.RunnerReplicaSet{newestSet}, oldSets...) {
+		totalCurrentReplicas += getIntOrDefault(rs.Status.Replicas, 0)
+		totalReadyReplicas += getIntOrDefault(rs.Status.ReadyReplicas, 0)
+		totalAvailableReplicas += getIntOrDefault(rs.Status.AvailableReplicas, 0)
+		totalUnschedulableReplicas += getIntOrDefault(rs.Status.UnschedulableReplicas, 0)
+		totalPreemptingReplicas += getIntOrDefault(rs.Status.PreemptingReplicas, 0)
+		totalTerminatingReplicas += getIntOrDefault(rs.Status.TerminatingReplicas, 0)
 	}
 
-	if newestSet.Status.Replicas != nil {
-		updatedReplicas = *newestSet.Status.Replicas
+	updatedReplicas = getIntOrDefault(newestSet.Status.Replicas, 0)
+
+	if totalAvailableReplicas < desiredReplicas {
+		unavailableReplicas = desiredReplicas - totalAvailableReplicas
 	}
 
 	var status v1alpha1.RunnerDeploymentStatus
 
-	status.AvailableReplicas = &totalStatusAvailableReplicas
-	status.ReadyReplicas = &totalStatusAvailableReplicas
-	status.DesiredReplicas = &newDesiredReplicas
+	status.AvailableReplicas = &totalAvailableReplicas
+	status.ReadyReplicas = &totalReadyReplicas
+	status.UnavailableReplicas = &unavailableReplicas
+	status.DesiredReplicas = &desiredReplicas
 	status.Replicas = &totalCurrentReplicas
 	status.UpdatedReplicas = &updatedReplicas
+	status.UnschedulableReplicas = &totalUnschedulableReplicas
+	status.PreemptingReplicas = &totalPreemptingReplicas
+	status.TerminatingReplicas = &totalTerminatingReplicas
+	status.ObservedGeneration = rd.Generation
+	status.WarmPool = rd.Status.WarmPool
+	status.Canary = rd.Status.Canary
+	status.Usage = rd.Status.Usage
+	status.RunnerVersion = rd.Status.RunnerVersion
+
+	lastRolloutTime := newestSet.CreationTimestamp
+	status.LastRolloutTime = &lastRolloutTime
+
+	// Copied so that SetStatusCondition keeps the transition times of the unchanged conditions without modifying rd
+	for _, c := range rd.Status.Conditions {
+		status.Conditions = append(status.Conditions, *c.DeepCopy())
+	}
 
-	if !reflect.DeepEqual(rd.Status, status) {
-		updated := rd.DeepCopy()
-		updated.Status = status
+	available := metav1.Condition{
+		Type:               v1alpha1.RunnerDeploymentConditionTypeAvailable,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: rd.Generation,
+		Reason:             v1alpha1.RunnerDeploymentConditionReasonMinimumReplicasAvailable,
+		Message:            "RunnerDeployment has minimum availability.",
+	}
 
-		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
-			log.Info("Failed to patch runnerdeployment status. Retrying immediately", "error", err.Error())
-			return ctrl.Result{
-				Requeue: true,
-			}, nil
-		}
+	if unavailableReplicas > 0 {
+		available.Status = metav1.ConditionFalse
+		available.Reason = v1alpha1.RunnerDeploymentConditionReasonMinimumReplicasUnavailable
+		available.Message = "RunnerDeployment does not have minimum availability."
 	}
 
-	return ctrl.Result{}, nil
+	meta.SetStatusCondition(&status.Conditions, available)
+
+	// There's no progress deadline for runner deployments, so the rollout is always either progressing or complete
+	progressing := metav1.Condition{
+		Type:               v1alpha1.RunnerDeploymentConditionTypeProgressing,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: rd.Generation,
+		Reason:             v1alpha1.RunnerDeploymentConditionReasonReplicaSetUpdated,
+		Message:            fmt.Sprintf("RunnerReplicaSet %q is progressing.", newestSet.Name),
+	}
+
+	if len(oldSets) == 0 && updatedReplicas == desiredReplicas && getIntOrDefault(newestSet.Status.AvailableReplicas, 0) >= desiredReplicas {
+		progressing.Reason = v1alpha1.RunnerDeploymentConditionReasonNewReplicaSetAvailable
+		progressing.Message = fmt.Sprintf("RunnerReplicaSet %q has successfully progressed.", newestSet.Name)
+	}
+
+	meta.SetStatusCondition(&status.Conditions, progressing)
+
+	return status
+}
+
+// newPausedRunnerDeploymentStatus computes the status of the paused runner deployment,
+// whose Progressing condition is Unknown with the DeploymentPaused reason, the same way as a paused apps/v1 Deployment.
+func newPausedRunnerDeploymentStatus(rd v1alpha1.RunnerDeployment, newestSet v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet) v1alpha1.RunnerDeploymentStatus {
+	status := newRunnerDeploymentStatus(rd, newestSet, oldSets, getIntOrDefault(rd.Spec.Replicas, defaultReplicas))
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.RunnerDeploymentConditionTypeProgressing,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: rd.Generation,
+		Reason:             v1alpha1.RunnerDeploymentConditionReasonDeploymentPaused,
+		Message:            "RunnerDeployment is paused.",
+	})
+
+	return status
 }
 
 func getIntOrDefault(p *int, d int) int {
@@ -378,16 +574,12 @@ func CloneSelectorAndAddLabel(selector *metav1.LabelSelector, labelKey, labelVal
 }
 
 func (r *RunnerDeploymentReconciler) newRunnerReplicaSet(rd v1alpha1.RunnerDeployment) (*v1alpha1.RunnerReplicaSet, error) {
-	return newRunnerReplicaSet(&rd, r.CommonRunnerLabels, r.Scheme)
-}
-
-func getSelector(rd *v1alpha1.RunnerDeployment) *metav1.LabelSelector {
-	selector := rd.Spec.Selector
-	if selector == nil {
-		selector = &metav1.LabelSelector{MatchLabels: map[string]string{LabelKeyRunnerDeploymentName: rd.Name}}
+	// The upgraded runner image changes the template hash, so that the upgrade is rolled out like any update of the template
+	if image := runnerVersionImage(rd); image != "" {
+		rd.Spec.Template.Spec.Image = image
 	}
 
-	return selector
+	return newRunnerReplicaSet(&rd, r.CommonRunnerLabels, r.Scheme)
 }
 
 func newRunnerReplicaSet(rd *v1alpha1.RunnerDeployment, commonRunnerLabels []string, scheme *runtime.Scheme) (*v1alpha1.RunnerReplicaSet, error) {
@@ -399,15 +591,9 @@ func newRunnerReplicaSet(rd *v1alpha1.RunnerDeployment, commonRunnerLabels []str
 
 	templateHash := ComputeHash(&newRSTemplate)
 
-	// Add template hash label to selector.
-	newRSTemplate.ObjectMeta.Labels = CloneAndAddLabel(newRSTemplate.ObjectMeta.Labels, LabelKeyRunnerTemplateHash, templateHash)
+	newRSTemplate.ObjectMeta.Labels = runnerReplicaSetLabels(newRSTemplate.ObjectMeta.Labels, rd, templateHash)
 
-	// This label selector is used by default when rd.Spec.Selector is empty.
-	newRSTemplate.ObjectMeta.Labels = CloneAndAddLabel(newRSTemplate.ObjectMeta.Labels, LabelKeyRunnerDeploymentName, rd.Name)
-
-	selector := getSelector(rd)
-
-	newRSSelector := CloneSelectorAndAddLabel(selector, LabelKeyRunnerTemplateHash, templateHash)
+	newRSSelector := runnerReplicaSetSelector(rd, templateHash)
 
 	rs := v1alpha1.RunnerReplicaSet{
 		TypeMeta: metav1.TypeMeta{},
@@ -454,9 +640,15 @@ func (r *RunnerDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.RunnerDeployment{}).
 		Owns(&v1alpha1.RunnerReplicaSet{}).
-		Named(name).
-		Complete(r)
+		Named(name)
+
+	if r.RunnerQuotas {
+		b = b.Watches(&source.Kind{Type: &v1alpha1.RunnerQuota{}}, handler.EnqueueRequestsFromMapFunc(r.runnerDeploymentsForRunnerQuota)).
+			Watches(&source.Kind{Type: &v1alpha1.RunnerDeployment{}}, handler.EnqueueRequestsFromMapFunc(r.limitedRunnerDeploymentsInNamespace))
+	}
+
+	return r.ControllerOptions.complete(b, r)
 }
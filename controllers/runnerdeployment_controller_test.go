@@ -10,9 +10,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	. "github.com/onsi/ginkgo"
@@ -122,6 +125,139 @@ func TestNewRunnerReplicaSet(t *testing.T) {
 	}
 }
 
+func TestNewRunnerDeploymentStatus(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	newSet := func(name string, replicas, ready, available int) actionsv1alpha1.RunnerReplicaSet {
+		return actionsv1alpha1.RunnerReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: actionsv1alpha1.RunnerReplicaSetStatus{
+				Replicas:          intPtr(replicas),
+				ReadyReplicas:     intPtr(ready),
+				AvailableReplicas: intPtr(available),
+			},
+		}
+	}
+
+	rd := actionsv1alpha1.RunnerDeployment{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+
+	// Rolling out the new template, with the old runners still serving jobs
+	status := newRunnerDeploymentStatus(rd, newSet("new", 2, 2, 1), []actionsv1alpha1.RunnerReplicaSet{newSet("old", 2, 2, 2)}, 2)
+
+	got := map[string]int{
+		"replicas":    *status.Replicas,
+		"ready":       *status.ReadyReplicas,
+		"available":   *status.AvailableReplicas,
+		"unavailable": *status.UnavailableReplicas,
+		"updated":     *status.UpdatedReplicas,
+	}
+	want := map[string]int{"replicas": 4, "ready": 4, "available": 3, "unavailable": 0, "updated": 2}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected replicas (-want +got):\n%s", d)
+	}
+
+	if status.ObservedGeneration != 2 {
+		t.Errorf("unexpected observed generation: %d", status.ObservedGeneration)
+	}
+
+	progressing := meta.FindStatusCondition(status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeProgressing)
+	if progressing == nil || progressing.Status != metav1.ConditionTrue || progressing.Reason != actionsv1alpha1.RunnerDeploymentConditionReasonReplicaSetUpdated {
+		t.Errorf("unexpected progressing condition: %+v", progressing)
+	}
+
+	if !meta.IsStatusConditionTrue(status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeAvailable) {
+		t.Errorf("expected the runner deployment to be available during the rollout: %+v", status.Conditions)
+	}
+
+	// The rollout has completed, but some of the runners went offline
+	rd.Status = status
+	status = newRunnerDeploymentStatus(rd, newSet("new", 2, 2, 2), nil, 3)
+
+	if *status.UnavailableReplicas != 1 {
+		t.Errorf("unexpected unavailable replicas: %d", *status.UnavailableReplicas)
+	}
+
+	available := meta.FindStatusCondition(status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeAvailable)
+	if available == nil || available.Status != metav1.ConditionFalse || available.Reason != actionsv1alpha1.RunnerDeploymentConditionReasonMinimumReplicasUnavailable {
+		t.Errorf("unexpected available condition: %+v", available)
+	}
+
+	status = newRunnerDeploymentStatus(rd, newSet("new", 2, 2, 2), nil, 2)
+
+	progressing = meta.FindStatusCondition(status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeProgressing)
+	if progressing == nil || progressing.Reason != actionsv1alpha1.RunnerDeploymentConditionReasonNewReplicaSetAvailable {
+		t.Errorf("unexpected progressing condition: %+v", progressing)
+	}
+
+	if prev := meta.FindStatusCondition(rd.Status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeProgressing); !prev.LastTransitionTime.Equal(&progressing.LastTransitionTime) {
+		t.Errorf("expected the transition time to be kept while the condition status is unchanged: %v, %v", prev.LastTransitionTime, progressing.LastTransitionTime)
+	}
+}
+
+func TestRunnerDeploymentReconciler_Paused(t *testing.T) {
+	replicas := 2
+
+	rd := &actionsv1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{
+			Replicas: &replicas,
+			Paused:   true,
+			Template: actionsv1alpha1.RunnerTemplate{
+				Spec: actionsv1alpha1.RunnerSpec{
+					RunnerConfig: actionsv1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	oldReplicas := 1
+
+	// The runner replica set of an outdated template with fewer replicas than desired
+	rs := &actionsv1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "example-old",
+			Namespace:       "default",
+			Labels:          map[string]string{LabelKeyRunnerTemplateHash: "old"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "RunnerDeployment", Name: "example"}},
+		},
+		Spec: actionsv1alpha1.RunnerReplicaSetSpec{Replicas: &oldReplicas},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, rd, rs)
+
+	r := &RunnerDeploymentReconciler{Client: c, Log: logf.Log, Scheme: sc, Recorder: record.NewFakeRecorder(10)}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sets actionsv1alpha1.RunnerReplicaSetList
+
+	if err := c.List(context.Background(), &sets); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sets.Items) != 1 || *sets.Items[0].Spec.Replicas != oldReplicas {
+		t.Errorf("expected the runner replica set to be left as it is, got %+v", sets.Items)
+	}
+
+	if err := c.Get(context.Background(), key, rd); err != nil {
+		t.Fatal(err)
+	}
+
+	progressing := meta.FindStatusCondition(rd.Status.Conditions, actionsv1alpha1.RunnerDeploymentConditionTypeProgressing)
+	if progressing == nil || progressing.Status != metav1.ConditionUnknown || progressing.Reason != actionsv1alpha1.RunnerDeploymentConditionReasonDeploymentPaused {
+		t.Errorf("unexpected progressing condition: %+v", progressing)
+	}
+
+	if rd.Status.DesiredReplicas == nil || *rd.Status.DesiredReplicas != replicas {
+		t.Errorf("unexpected desired replicas in the status: %v", rd.Status.DesiredReplicas)
+	}
+}
+
 // SetupDeploymentTest will set up a testing environment.
 // This includes:
 // * creating a Namespace to be used during the test
@@ -0,0 +1,319 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// AnnotationKeyPromoteCanary is the annotation of a RunnerDeployment that promotes its canary
+	// when set to status.canary.templateHash.
+	AnnotationKeyPromoteCanary = "actions-runner-controller/promote-canary"
+)
+
+var (
+	defaultMaxSurge       = intstr.FromString("25%")
+	defaultMaxUnavailable = intstr.FromString("25%")
+)
+
+// resolveRollingUpdate returns the absolute maxSurge and maxUnavailable for the desired replicas,
+// the same way as the rolling update of apps/v1 Deployment.
+func resolveRollingUpdate(spec *v1alpha1.RollingUpdateRunnerDeployment, desired int) (int, int, error) {
+	maxSurge, maxUnavailable := defaultMaxSurge, defaultMaxUnavailable
+
+	if spec != nil && spec.MaxSurge != nil {
+		maxSurge = *spec.MaxSurge
+	}
+
+	if spec != nil && spec.MaxUnavailable != nil {
+		maxUnavailable = *spec.MaxUnavailable
+	}
+
+	surge, err := intstr.GetScaledValueFromIntOrPercent(&maxSurge, desired, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	unavailable, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, desired, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The rollout would never progress otherwise
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+
+	return surge, unavailable, nil
+}
+
+// rollingUpdateReplicas returns the replicas of the runner replica set of the current template and of the old ones,
+// ordered from the newest to the oldest, for the next step of the rolling update.
+//
+// The new runner replica set is scaled up as far as the total replicas stay within the desired replicas plus maxSurge,
+// and the old ones are scaled down, the oldest first, as far as the available runners stay at or above
+// the desired replicas minus maxUnavailable.
+// See https://github.com/kubernetes/kubernetes/blob/ea0764452222146c47ec826977f49d7001b0ea8c/pkg/controller/deployment/rolling.go
+func rollingUpdateReplicas(desired, maxSurge, maxUnavailable int, newSet v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet) (int, []int) {
+	newReplicas := getIntOrDefault(newSet.Spec.Replicas, defaultReplicas)
+
+	oldReplicas := make([]int, len(oldSets))
+
+	total := newReplicas
+
+	for i, rs := range oldSets {
+		oldReplicas[i] = getIntOrDefault(rs.Spec.Replicas, defaultReplicas)
+		total += oldReplicas[i]
+	}
+
+	if newReplicas > desired {
+		total -= newReplicas - desired
+		newReplicas = desired
+	} else if room := desired + maxSurge - total; room > 0 && newReplicas < desired {
+		scaleUp := desired - newReplicas
+		if scaleUp > room {
+			scaleUp = room
+		}
+
+		newReplicas += scaleUp
+		total += scaleUp
+	}
+
+	// The new runners that aren't available yet can't replace the old runners
+	newUnavailable := newReplicas - getIntOrDefault(newSet.Status.AvailableReplicas, 0)
+	if newUnavailable < 0 {
+		newUnavailable = 0
+	}
+
+	scaleDown := total - (desired - maxUnavailable) - newUnavailable
+
+	for i := len(oldReplicas) - 1; i >= 0 && scaleDown > 0; i-- {
+		d := oldReplicas[i]
+		if d > scaleDown {
+			d = scaleDown
+		}
+
+		oldReplicas[i] -= d
+		scaleDown -= d
+	}
+
+	return newReplicas, oldReplicas
+}
+
+// canaryReplicas returns the replicas of the runner replica set of the current template and of the old ones
+// until the canary is promoted.
+// The canary runners are created alongside the old runners, whose total follows the desired replicas.
+// A scale up is applied to the newest old runner replica set, and a scale down to the oldest ones first.
+func canaryReplicas(desired, canary int, oldSets []v1alpha1.RunnerReplicaSet) (int, []int) {
+	if canary > desired {
+		canary = desired
+	}
+
+	oldReplicas := make([]int, len(oldSets))
+
+	var total int
+
+	for i, rs := range oldSets {
+		oldReplicas[i] = getIntOrDefault(rs.Spec.Replicas, defaultReplicas)
+		total += oldReplicas[i]
+	}
+
+	if total < desired && len(oldReplicas) > 0 {
+		oldReplicas[0] += desired - total
+	}
+
+	for i := len(oldReplicas) - 1; i >= 0 && total > desired; i-- {
+		d := oldReplicas[i]
+		if d > total-desired {
+			d = total - desired
+		}
+
+		oldReplicas[i] -= d
+		total -= d
+	}
+
+	return canary, oldReplicas
+}
+
+// syncCanary returns the state of the canary of the template, which is promoted manually by the annotation
+// or automatically once all the canary runners have been available for the soak time.
+// It also returns how long it takes until the soak time elapses, if it's still running.
+func syncCanary(rd v1alpha1.RunnerDeployment, newSet v1alpha1.RunnerReplicaSet, templateHash string, desired int, now time.Time) (*v1alpha1.CanaryStatus, time.Duration) {
+	spec := rd.Spec.Strategy.Canary
+
+	canary := &v1alpha1.CanaryStatus{TemplateHash: templateHash}
+
+	if last := rd.Status.Canary; last != nil && last.TemplateHash == templateHash {
+		canary = last.DeepCopy()
+	}
+
+	if canary.Promoted {
+		return canary, 0
+	}
+
+	want := spec.Replicas
+	if want > desired {
+		want = desired
+	}
+
+	// The soak time restarts whenever any of the canary runners becomes unavailable
+	if getIntOrDefault(newSet.Spec.Replicas, defaultReplicas) >= want && getIntOrDefault(newSet.Status.AvailableReplicas, 0) >= want {
+		if canary.AvailableTime == nil {
+			canary.AvailableTime = &metav1.Time{Time: now}
+		}
+	} else {
+		canary.AvailableTime = nil
+	}
+
+	if rd.Annotations[AnnotationKeyPromoteCanary] == templateHash {
+		canary.Promoted = true
+
+		return canary, 0
+	}
+
+	if spec.SoakTime == nil || canary.AvailableTime == nil {
+		return canary, 0
+	}
+
+	remaining := canary.AvailableTime.Add(spec.SoakTime.Duration).Sub(now)
+	if remaining <= 0 {
+		canary.Promoted = true
+
+		return canary, 0
+	}
+
+	return canary, remaining
+}
+
+// rollout replaces the runners of the old runner replica sets with the ones of the newest runner replica set
+// following the strategy of the runner deployment, and deletes the old runner replica sets that have no runners left.
+func (r *RunnerDeploymentReconciler) rollout(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, newSet v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet, desired int, now time.Time) (ctrl.Result, error) {
+	strategy := rd.Spec.Strategy
+
+	newReplicas, oldReplicas := desired, make([]int, len(oldSets))
+
+	canary := rd.Status.Canary
+
+	var requeueAfter time.Duration
+
+	if len(oldSets) > 0 {
+		promoted := true
+
+		if strategy.Type == v1alpha1.RunnerDeploymentStrategyTypeCanary && strategy.Canary != nil {
+			hash, _ := getTemplateHash(&newSet)
+
+			canary, requeueAfter = syncCanary(rd, newSet, hash, desired, now)
+
+			if canary.Promoted && (rd.Status.Canary == nil || !rd.Status.Canary.Promoted || rd.Status.Canary.TemplateHash != hash) {
+				r.Recorder.Event(&rd, corev1.EventTypeNormal, "CanaryPromoted", fmt.Sprintf("Promoted the canary of runnerreplicaset '%s'", newSet.Name))
+
+				log.Info("Promoted the canary", "runnerreplicaset", newSet.Name)
+			}
+
+			promoted = canary.Promoted
+		}
+
+		if promoted {
+			maxSurge, maxUnavailable, err := resolveRollingUpdate(strategy.RollingUpdate, desired)
+			if err != nil {
+				log.Error(err, "Invalid rolling update strategy")
+
+				return ctrl.Result{}, nil
+			}
+
+			newReplicas, oldReplicas = rollingUpdateReplicas(desired, maxSurge, maxUnavailable, newSet, oldSets)
+		} else {
+			newReplicas, oldReplicas = canaryReplicas(desired, strategy.Canary.Replicas, oldSets)
+		}
+	}
+
+	if getIntOrDefault(newSet.Spec.Replicas, defaultReplicas) != newReplicas {
+		newSet.Spec.Replicas = &newReplicas
+
+		if err := r.Client.Update(ctx, &newSet); err != nil {
+			log.Error(err, "Failed to update runnerreplicaset resource")
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	var remaining []v1alpha1.RunnerReplicaSet
+
+	for i := range oldSets {
+		rs := oldSets[i]
+
+		if oldReplicas[i] == 0 && getIntOrDefault(rs.Status.Replicas, 0) == 0 && getIntOrDefault(rs.Spec.Replicas, defaultReplicas) == 0 {
+			if err := r.Client.Delete(ctx, &rs); err != nil {
+				log.Error(err, "Failed to delete runnerreplicaset resource")
+
+				return ctrl.Result{}, err
+			}
+
+			r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerReplicaSetDeleted", fmt.Sprintf("Deleted runnerreplicaset '%s'", rs.Name))
+
+			log.Info("Deleted runnerreplicaset", "runnerdeployment", rd.ObjectMeta.Name, "runnerreplicaset", rs.Name)
+
+			continue
+		}
+
+		if getIntOrDefault(rs.Spec.Replicas, defaultReplicas) != oldReplicas[i] {
+			rs.Spec.Replicas = &oldReplicas[i]
+
+			if err := r.Client.Update(ctx, &rs); err != nil {
+				log.Error(err, "Failed to update runnerreplicaset resource")
+
+				return ctrl.Result{}, err
+			}
+
+			log.V(1).Info("Scaled old runnerreplicaset", "runnerreplicaset", rs.Name, "replicas", oldReplicas[i])
+		}
+
+		remaining = append(remaining, rs)
+	}
+
+	status := newRunnerDeploymentStatus(rd, newSet, remaining, desired)
+	status.Canary = canary
+
+	if canary != nil && !canary.Promoted && len(remaining) > 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               v1alpha1.RunnerDeploymentConditionTypeProgressing,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: rd.Generation,
+			Reason:             v1alpha1.RunnerDeploymentConditionReasonReplicaSetUpdated,
+			Message:            fmt.Sprintf("RunnerReplicaSet %q is waiting for the canary to be promoted.", newSet.Name),
+		})
+	}
+
+	res, err := r.updateStatus(ctx, log, rd, status)
+	if err != nil || res.Requeue {
+		return res, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
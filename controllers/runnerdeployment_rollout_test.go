@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func newRolloutRunnerReplicaSet(name string, replicas, current, available int) v1alpha1.RunnerReplicaSet {
+	return v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{LabelKeyRunnerTemplateHash: name}},
+		Spec:       v1alpha1.RunnerReplicaSetSpec{Replicas: &replicas},
+		Status:     v1alpha1.RunnerReplicaSetStatus{Replicas: &current, AvailableReplicas: &available},
+	}
+}
+
+func TestResolveRollingUpdate(t *testing.T) {
+	intOrStr := func(v intstr.IntOrString) *intstr.IntOrString { return &v }
+
+	testcases := []struct {
+		name            string
+		spec            *v1alpha1.RollingUpdateRunnerDeployment
+		desired         int
+		wantSurge       int
+		wantUnavailable int
+	}{
+		{name: "defaults", desired: 10, wantSurge: 3, wantUnavailable: 2},
+		{name: "absolute", spec: &v1alpha1.RollingUpdateRunnerDeployment{MaxSurge: intOrStr(intstr.FromInt(5)), MaxUnavailable: intOrStr(intstr.FromInt(0))}, desired: 10, wantSurge: 5, wantUnavailable: 0},
+		{name: "both zero", spec: &v1alpha1.RollingUpdateRunnerDeployment{MaxSurge: intOrStr(intstr.FromInt(0)), MaxUnavailable: intOrStr(intstr.FromString("10%"))}, desired: 5, wantSurge: 0, wantUnavailable: 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			surge, unavailable, err := resolveRollingUpdate(tc.spec, tc.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if surge != tc.wantSurge || unavailable != tc.wantUnavailable {
+				t.Errorf("unexpected result: want %d/%d, got %d/%d", tc.wantSurge, tc.wantUnavailable, surge, unavailable)
+			}
+		})
+	}
+}
+
+func TestRollingUpdateReplicas(t *testing.T) {
+	testcases := []struct {
+		name           string
+		desired        int
+		maxSurge       int
+		maxUnavailable int
+		newSet         v1alpha1.RunnerReplicaSet
+		oldSets        []v1alpha1.RunnerReplicaSet
+		wantNew        int
+		wantOld        []int
+	}{
+		{
+			name:    "surge first",
+			desired: 10, maxSurge: 2, maxUnavailable: 0,
+			newSet:  newRolloutRunnerReplicaSet("new", 0, 0, 0),
+			oldSets: []v1alpha1.RunnerReplicaSet{newRolloutRunnerReplicaSet("old", 10, 10, 10)},
+			wantNew: 2,
+			wantOld: []int{10},
+		},
+		{
+			name:    "old scaled down once new runners are available",
+			desired: 10, maxSurge: 2, maxUnavailable: 0,
+			newSet:  newRolloutRunnerReplicaSet("new", 2, 2, 2),
+			oldSets: []v1alpha1.RunnerReplicaSet{newRolloutRunnerReplicaSet("old", 10, 10, 10)},
+			wantNew: 2,
+			wantOld: []int{8},
+		},
+		{
+			name:    "unavailable allowed",
+			desired: 10, maxSurge: 0, maxUnavailable: 3,
+			newSet:  newRolloutRunnerReplicaSet("new", 0, 0, 0),
+			oldSets: []v1alpha1.RunnerReplicaSet{newRolloutRunnerReplicaSet("old", 10, 10, 10)},
+			wantNew: 0,
+			wantOld: []int{7},
+		},
+		{
+			name:    "oldest scaled down first",
+			desired: 4, maxSurge: 0, maxUnavailable: 1,
+			newSet: newRolloutRunnerReplicaSet("new", 2, 2, 2),
+			oldSets: []v1alpha1.RunnerReplicaSet{
+				newRolloutRunnerReplicaSet("older", 1, 1, 1),
+				newRolloutRunnerReplicaSet("oldest", 1, 1, 1),
+			},
+			wantNew: 2,
+			wantOld: []int{1, 0},
+		},
+		{
+			name:    "completed",
+			desired: 3, maxSurge: 1, maxUnavailable: 0,
+			newSet:  newRolloutRunnerReplicaSet("new", 3, 3, 3),
+			oldSets: []v1alpha1.RunnerReplicaSet{newRolloutRunnerReplicaSet("old", 0, 0, 0)},
+			wantNew: 3,
+			wantOld: []int{0},
+		},
+		{
+			name:    "scaled down during the rollout",
+			desired: 2, maxSurge: 1, maxUnavailable: 0,
+			newSet:  newRolloutRunnerReplicaSet("new", 3, 3, 1),
+			oldSets: []v1alpha1.RunnerReplicaSet{newRolloutRunnerReplicaSet("old", 2, 2, 2)},
+			wantNew: 2,
+			wantOld: []int{1},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotNew, gotOld := rollingUpdateReplicas(tc.desired, tc.maxSurge, tc.maxUnavailable, tc.newSet, tc.oldSets)
+
+			if gotNew != tc.wantNew {
+				t.Errorf("unexpected new replicas: want %d, got %d", tc.wantNew, gotNew)
+			}
+
+			if d := cmp.Diff(tc.wantOld, gotOld); d != "" {
+				t.Errorf("unexpected old replicas: %s", d)
+			}
+		})
+	}
+}
+
+func TestCanaryReplicas(t *testing.T) {
+	oldSets := []v1alpha1.RunnerReplicaSet{
+		newRolloutRunnerReplicaSet("older", 3, 3, 3),
+		newRolloutRunnerReplicaSet("oldest", 2, 2, 2),
+	}
+
+	testcases := []struct {
+		name    string
+		desired int
+		canary  int
+		wantNew int
+		wantOld []int
+	}{
+		{name: "alongside the old runners", desired: 5, canary: 1, wantNew: 1, wantOld: []int{3, 2}},
+		{name: "scaled up", desired: 7, canary: 1, wantNew: 1, wantOld: []int{5, 2}},
+		{name: "scaled down", desired: 2, canary: 1, wantNew: 1, wantOld: []int{2, 0}},
+		{name: "capped at the desired replicas", desired: 2, canary: 5, wantNew: 2, wantOld: []int{2, 0}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotNew, gotOld := canaryReplicas(tc.desired, tc.canary, oldSets)
+
+			if gotNew != tc.wantNew {
+				t.Errorf("unexpected new replicas: want %d, got %d", tc.wantNew, gotNew)
+			}
+
+			if d := cmp.Diff(tc.wantOld, gotOld); d != "" {
+				t.Errorf("unexpected old replicas: %s", d)
+			}
+		})
+	}
+}
+
+func TestSyncCanary(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	availableSince := func(d time.Duration) *metav1.Time { return &metav1.Time{Time: now.Add(-d)} }
+
+	testcases := []struct {
+		name          string
+		soakTime      *metav1.Duration
+		annotation    string
+		last          *v1alpha1.CanaryStatus
+		available     int
+		want          *v1alpha1.CanaryStatus
+		wantRemaining time.Duration
+	}{
+		{
+			name:      "not available yet",
+			soakTime:  &metav1.Duration{Duration: time.Hour},
+			available: 1,
+			want:      &v1alpha1.CanaryStatus{TemplateHash: "new"},
+		},
+		{
+			name:          "soaking",
+			soakTime:      &metav1.Duration{Duration: time.Hour},
+			available:     2,
+			want:          &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(0)},
+			wantRemaining: time.Hour,
+		},
+		{
+			name:      "promoted after the soak time",
+			soakTime:  &metav1.Duration{Duration: time.Hour},
+			last:      &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(time.Hour)},
+			available: 2,
+			want:      &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(time.Hour), Promoted: true},
+		},
+		{
+			name:      "soak time restarted on unavailability",
+			soakTime:  &metav1.Duration{Duration: time.Hour},
+			last:      &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(time.Hour)},
+			available: 1,
+			want:      &v1alpha1.CanaryStatus{TemplateHash: "new"},
+		},
+		{
+			name:      "waiting for the manual promotion",
+			available: 2,
+			want:      &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(0)},
+		},
+		{
+			name:       "promoted manually",
+			annotation: "new",
+			available:  2,
+			want:       &v1alpha1.CanaryStatus{TemplateHash: "new", AvailableTime: availableSince(0), Promoted: true},
+		},
+		{
+			name:       "annotation for another template",
+			annotation: "old",
+			last:       &v1alpha1.CanaryStatus{TemplateHash: "old", Promoted: true},
+			available:  0,
+			want:       &v1alpha1.CanaryStatus{TemplateHash: "new"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rd := v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Strategy: &v1alpha1.RunnerDeploymentStrategy{
+						Type:   v1alpha1.RunnerDeploymentStrategyTypeCanary,
+						Canary: &v1alpha1.CanaryRunnerDeployment{Replicas: 2, SoakTime: tc.soakTime},
+					},
+				},
+				Status: v1alpha1.RunnerDeploymentStatus{Canary: tc.last},
+			}
+
+			if tc.annotation != "" {
+				rd.Annotations[AnnotationKeyPromoteCanary] = tc.annotation
+			}
+
+			got, remaining := syncCanary(rd, newRolloutRunnerReplicaSet("new", 2, 2, tc.available), "new", 5, now)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected canary: %s", d)
+			}
+
+			if remaining != tc.wantRemaining {
+				t.Errorf("unexpected remaining soak time: want %s, got %s", tc.wantRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestRunnerDeploymentReconciler_rollout(t *testing.T) {
+	newSet := newRolloutRunnerReplicaSet("new", 0, 0, 0)
+	oldSet := newRolloutRunnerReplicaSet("old", 3, 3, 3)
+	emptySet := newRolloutRunnerReplicaSet("empty", 0, 0, 0)
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Strategy: &v1alpha1.RunnerDeploymentStrategy{
+				Type:   v1alpha1.RunnerDeploymentStrategyTypeCanary,
+				Canary: &v1alpha1.CanaryRunnerDeployment{Replicas: 1},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, &rd, &newSet, &oldSet, &emptySet)
+
+	r := &RunnerDeploymentReconciler{Client: c, Recorder: record.NewFakeRecorder(10)}
+
+	ctx := context.Background()
+
+	if _, err := r.rollout(ctx, logf.Log, rd, newSet, []v1alpha1.RunnerReplicaSet{oldSet, emptySet}, 3, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	getReplicas := func(name string) int {
+		var rs v1alpha1.RunnerReplicaSet
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &rs); err != nil {
+			t.Fatal(err)
+		}
+
+		return *rs.Spec.Replicas
+	}
+
+	if n := getReplicas("new"); n != 1 {
+		t.Errorf("expected the canary runner to be created, got %d replicas", n)
+	}
+
+	if n := getReplicas("old"); n != 3 {
+		t.Errorf("expected the old runners to be kept, got %d replicas", n)
+	}
+
+	var rs v1alpha1.RunnerReplicaSet
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "empty"}, &rs); err == nil {
+		t.Errorf("expected the old runnerreplicaset without runners to be deleted")
+	}
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &rd); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff(&v1alpha1.CanaryStatus{TemplateHash: "new"}, rd.Status.Canary); d != "" {
+		t.Errorf("unexpected canary status: %s", d)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+)
+
+const (
+	// githubTeardownFinalizerName is added to a RunnerDeployment whose GitHubTeardownPolicy is either Delete or DryRun,
+	// so that the controller can clean up GitHub-side artifacts before the RunnerDeployment is gone.
+	githubTeardownFinalizerName = "runnerdeployment.actions.summerwind.dev/github-teardown"
+
+	teardownRequeueDelay = 10 * time.Second
+)
+
+func wantsGitHubTeardown(rd v1alpha1.RunnerDeployment) bool {
+	switch rd.Spec.GitHubTeardownPolicy {
+	case v1alpha1.GitHubTeardownPolicyDelete, v1alpha1.GitHubTeardownPolicyDryRun:
+		return true
+	default:
+		return false
+	}
+}
+
+// syncGitHubTeardownFinalizer adds or removes the teardown finalizer depending on the GitHubTeardownPolicy.
+// It returns true when the runner deployment has been updated.
+func (r *RunnerDeploymentReconciler) syncGitHubTeardownFinalizer(ctx context.Context, rd v1alpha1.RunnerDeployment) (bool, error) {
+	var (
+		finalizers []string
+		changed    bool
+	)
+
+	if wantsGitHubTeardown(rd) {
+		finalizers, changed = addFinalizer(rd.ObjectMeta.Finalizers, githubTeardownFinalizerName)
+	} else {
+		finalizers, changed = removeFinalizer(rd.ObjectMeta.Finalizers, githubTeardownFinalizerName)
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	updated := rd.DeepCopy()
+	updated.ObjectMeta.Finalizers = finalizers
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// processGitHubTeardown cleans up the GitHub-side artifacts of the runner deployment being deleted,
+// according to its GitHubTeardownPolicy.
+//
+// The controller doesn't create any runner group or repository/organization webhook on GitHub,
+// so the only artifacts to be cleaned up are the runner registrations.
+// Those are usually removed by the runner finalizers, but the ones whose pods have been terminated uncleanly
+// can be left behind.
+func (r *RunnerDeploymentReconciler) processGitHubTeardown(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) (ctrl.Result, error) {
+	finalizers, removed := removeFinalizer(rd.ObjectMeta.Finalizers, githubTeardownFinalizerName)
+	if !removed {
+		return ctrl.Result{}, nil
+	}
+
+	// The garbage collector won't delete the runner replica sets until the runner deployment is gone,
+	// which never happens while our finalizer is there. So we delete them on our own, and wait for the runners
+	// to unregister themselves via their own finalizers.
+	done, err := r.deleteRunnerReplicaSetsAndWait(ctx, log, rd)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !done {
+		return ctrl.Result{RequeueAfter: teardownRequeueDelay}, nil
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rd.Namespace, rd.Spec.Template.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if ghc != nil {
+		requeue, err := r.teardownGitHubRunners(ctx, log, ghc, rd)
+		if err != nil {
+			var e *gogithub.RateLimitError
+			if errors.As(err, &e) {
+				log.Error(
+					err,
+					fmt.Sprintf(
+						"Failed to tear down GitHub runners due to GitHub API rate limit. Retrying in %s to avoid excessive GitHub API calls",
+						retryDelayOnGitHubAPIRateLimitError,
+					),
+				)
+
+				return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, nil
+			}
+
+			if github.IsTransient(err) {
+				log.Info(
+					fmt.Sprintf("Failed to tear down GitHub runners due to a transient GitHub API error. Retrying in %s", retryDelayOnTransientGitHubAPIError),
+					"error", err.Error(),
+				)
+
+				return ctrl.Result{RequeueAfter: retryDelayOnTransientGitHubAPIError}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		if requeue {
+			return ctrl.Result{RequeueAfter: teardownRequeueDelay}, nil
+		}
+	} else {
+		log.Info("Skipped tearing down GitHub runners as no GitHub client is configured")
+	}
+
+	updated := rd.DeepCopy()
+	updated.ObjectMeta.Finalizers = finalizers
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		log.Error(err, "Failed to update runnerdeployment for finalizer removal")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Finished tearing down GitHub-side artifacts", "policy", rd.Spec.GitHubTeardownPolicy)
+
+	return ctrl.Result{}, nil
+}
+
+// deleteRunnerReplicaSetsAndWait deletes all the runner replica sets owned by the runner deployment,
+// and returns true once all of them and their runners are gone.
+func (r *RunnerDeploymentReconciler) deleteRunnerReplicaSetsAndWait(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) (bool, error) {
+	var rsList v1alpha1.RunnerReplicaSetList
+	if err := r.List(ctx, &rsList, client.InNamespace(rd.Namespace), client.MatchingFields{runnerSetOwnerKey: rd.Name}); err != nil {
+		return false, err
+	}
+
+	for i := range rsList.Items {
+		rs := rsList.Items[i]
+
+		if !rs.ObjectMeta.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if err := r.Delete(ctx, &rs); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+
+		log.Info("Deleted runnerreplicaset for tearing down the runnerdeployment", "runnerreplicaset", rs.Name)
+	}
+
+	if len(rsList.Items) > 0 {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(getSelector(&rd))
+	if err != nil {
+		return false, err
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := r.List(ctx, &runners, client.InNamespace(rd.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return false, err
+	}
+
+	if len(runners.Items) > 0 {
+		log.V(1).Info("Waiting for runners to terminate before tearing down GitHub runners", "runners", len(runners.Items))
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// teardownGitHubRunners unregisters the runners of the runner deployment that are still registered on GitHub.
+// It returns true when it needs to be retried later, because some runners are still busy.
+func (r *RunnerDeploymentReconciler) teardownGitHubRunners(ctx context.Context, log logr.Logger, ghc *github.Client, rd v1alpha1.RunnerDeployment) (bool, error) {
+	scope := offlineRunnerScopeForRunnerDeployment(rd)
+
+	runners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+	if err != nil {
+		return false, err
+	}
+
+	var leftovers []*gogithub.Runner
+
+	for _, runner := range runners {
+		if scope.runnerNamePattern.MatchString(runner.GetName()) {
+			leftovers = append(leftovers, runner)
+		}
+	}
+
+	if len(leftovers) == 0 {
+		return false, nil
+	}
+
+	var names []string
+	for _, runner := range leftovers {
+		names = append(names, runner.GetName())
+	}
+
+	if rd.Spec.GitHubTeardownPolicy == v1alpha1.GitHubTeardownPolicyDryRun {
+		msg := fmt.Sprintf("Would remove %d runner(s) from GitHub: %s", len(names), strings.Join(names, ", "))
+
+		r.Recorder.Event(&rd, corev1.EventTypeNormal, "GitHubTeardownDryRun", msg)
+		log.Info(msg, "policy", rd.Spec.GitHubTeardownPolicy)
+
+		return false, nil
+	}
+
+	var busy int
+
+	for _, runner := range leftovers {
+		// Removing a busy runner results in a 422 error, so we retry later.
+		if runner.GetBusy() {
+			busy++
+			continue
+		}
+
+		if err := ghc.RemoveRunner(ctx, scope.enterprise, scope.org, scope.repo, runner.GetID()); err != nil {
+			return false, err
+		}
+
+		r.Recorder.Event(&rd, corev1.EventTypeNormal, "GitHubTeardown", fmt.Sprintf("Removed runner '%s' from GitHub", runner.GetName()))
+		log.Info("Removed runner from GitHub for tearing down the runnerdeployment", "runnerName", runner.GetName(), "runnerID", runner.GetID())
+	}
+
+	if busy > 0 {
+		log.Info("Waiting for busy runners to become idle before removing them from GitHub", "busy", busy)
+
+		return true, nil
+	}
+
+	return false, nil
+}
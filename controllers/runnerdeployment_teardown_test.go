@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestRunnerDeploymentReconciler_teardownGitHubRunners(t *testing.T) {
+	runners := []*github.Runner{
+		{ID: github.Int64(1), Name: github.String("example-abcde-fghij"), Status: github.String("offline"), Busy: github.Bool(false)},
+		{ID: github.Int64(2), Name: github.String("example-bcdef-ghijk"), Status: github.String("online"), Busy: github.Bool(true)},
+		// Belongs to another runner deployment
+		{ID: github.Int64(3), Name: github.String("other-cdefg-hijkl"), Status: github.String("offline"), Busy: github.Bool(false)},
+	}
+
+	newServer := func(removedIDs *[]string) *httptest.Server {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+			j, err := json.Marshal(github.Runners{TotalCount: len(runners), Runners: runners})
+			if err != nil {
+				panic(err)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(j)
+		})
+		mux.HandleFunc("/repos/test/valid/actions/runners/", func(w http.ResponseWriter, req *http.Request) {
+			*removedIDs = append(*removedIDs, req.URL.Path[len("/repos/test/valid/actions/runners/"):])
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		return httptest.NewServer(mux)
+	}
+
+	newRunnerDeployment := func(policy string) v1alpha1.RunnerDeployment {
+		return v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "default",
+			},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				GitHubTeardownPolicy: policy,
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{
+						RunnerConfig: v1alpha1.RunnerConfig{
+							Repository: "test/valid",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("Delete", func(t *testing.T) {
+		var removedIDs []string
+
+		server := newServer(&removedIDs)
+		defer server.Close()
+
+		r := &RunnerDeploymentReconciler{
+			Recorder:     record.NewFakeRecorder(10),
+			GitHubClient: newGithubClient(server),
+		}
+
+		requeue, err := r.teardownGitHubRunners(context.Background(), zap.New(), r.GitHubClient, newRunnerDeployment(v1alpha1.GitHubTeardownPolicyDelete))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !requeue {
+			t.Errorf("expected requeue due to the busy runner")
+		}
+
+		if want := []string{"1"}; !reflect.DeepEqual(removedIDs, want) {
+			t.Errorf("unexpected removed runner IDs: want %v, got %v", want, removedIDs)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		var removedIDs []string
+
+		server := newServer(&removedIDs)
+		defer server.Close()
+
+		recorder := record.NewFakeRecorder(10)
+
+		r := &RunnerDeploymentReconciler{
+			Recorder:     recorder,
+			GitHubClient: newGithubClient(server),
+		}
+
+		requeue, err := r.teardownGitHubRunners(context.Background(), zap.New(), r.GitHubClient, newRunnerDeployment(v1alpha1.GitHubTeardownPolicyDryRun))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if requeue {
+			t.Errorf("unexpected requeue in dry-run mode")
+		}
+
+		if len(removedIDs) != 0 {
+			t.Errorf("unexpected removal of runners in dry-run mode: %v", removedIDs)
+		}
+
+		want := "Normal GitHubTeardownDryRun Would remove 2 runner(s) from GitHub: example-abcde-fghij, example-bcdef-ghijk"
+		if got := <-recorder.Events; got != want {
+			t.Errorf("unexpected event: want %q, got %q", want, got)
+		}
+	})
+}
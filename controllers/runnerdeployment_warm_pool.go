@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultWarmPoolSyncPeriod = time.Minute
+)
+
+func warmPoolSyncPeriod(spec v1alpha1.WarmPoolSpec) time.Duration {
+	if spec.SyncPeriod != nil && spec.SyncPeriod.Duration > 0 {
+		return spec.SyncPeriod.Duration
+	}
+
+	return DefaultWarmPoolSyncPeriod
+}
+
+// syncWarmPool returns the state of the warm pool of the runner deployment, or nil when the warm pool isn't enabled.
+// The busy runners are listed on GitHub at most once per sync period, and the last state in the status is returned in between,
+// so that the frequent reconciliations triggered by the runner replica sets cost no GitHub API call.
+func (r *RunnerDeploymentReconciler) syncWarmPool(ctx context.Context, rd v1alpha1.RunnerDeployment, now time.Time) (*v1alpha1.WarmPoolStatus, error) {
+	spec := rd.Spec.WarmPool
+	if spec == nil {
+		return nil, nil
+	}
+
+	if last := rd.Status.WarmPool; last != nil && last.LastSyncTime.Add(warmPoolSyncPeriod(*spec)).After(now) {
+		return last.DeepCopy(), nil
+	}
+
+	ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, r.GitHubClient, rd.Namespace, rd.Spec.Template.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	if ghc == nil {
+		return nil, errors.New("warm pool requires the GitHub client")
+	}
+
+	scope := offlineRunnerScopeForRunnerDeployment(rd)
+
+	runners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var busy int
+
+	for _, runner := range runners {
+		if scope.runnerNamePattern.MatchString(runner.GetName()) && runner.GetBusy() {
+			busy++
+		}
+	}
+
+	return &v1alpha1.WarmPoolStatus{
+		BusyReplicas: busy,
+		LastSyncTime: metav1.Time{Time: now},
+	}, nil
+}
+
+// warmPoolReplicas returns the replicas of the runner replica set that keep the idle runners of the warm pool
+// on top of the replicas of the runner deployment.
+// The busy runners beyond the replicas are the warm runners consumed by jobs, which are backfilled by adding as many runners.
+func warmPoolReplicas(replicas int, spec *v1alpha1.WarmPoolSpec, status *v1alpha1.WarmPoolStatus) int {
+	if spec == nil {
+		return replicas
+	}
+
+	if status != nil && status.BusyReplicas > replicas {
+		replicas = status.BusyReplicas
+	}
+
+	return replicas + spec.Replicas
+}
+
+// requeueForWarmPool makes the runner deployment with the warm pool reconciled again after the sync period,
+// as the runners picking up jobs change nothing in the cluster that would trigger the reconciliation.
+func requeueForWarmPool(rd v1alpha1.RunnerDeployment, res ctrl.Result) ctrl.Result {
+	if rd.Spec.WarmPool == nil || res.Requeue || res.RequeueAfter > 0 {
+		return res
+	}
+
+	res.RequeueAfter = warmPoolSyncPeriod(*rd.Spec.WarmPool)
+
+	return res
+}
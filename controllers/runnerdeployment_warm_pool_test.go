@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestWarmPoolReplicas(t *testing.T) {
+	spec := &v1alpha1.WarmPoolSpec{Replicas: 2}
+
+	testcases := []struct {
+		name     string
+		replicas int
+		spec     *v1alpha1.WarmPoolSpec
+		status   *v1alpha1.WarmPoolStatus
+		want     int
+	}{
+		{name: "disabled", replicas: 3, spec: nil, want: 3},
+		{name: "not synced yet", replicas: 3, spec: spec, want: 5},
+		{name: "busy within the replicas", replicas: 3, spec: spec, status: &v1alpha1.WarmPoolStatus{BusyReplicas: 3}, want: 5},
+		{name: "warm runners consumed", replicas: 3, spec: spec, status: &v1alpha1.WarmPoolStatus{BusyReplicas: 4}, want: 6},
+		{name: "scaled to zero", replicas: 0, spec: spec, status: &v1alpha1.WarmPoolStatus{BusyReplicas: 1}, want: 3},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := warmPoolReplicas(tc.replicas, tc.spec, tc.status); got != tc.want {
+				t.Errorf("unexpected replicas: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerDeploymentReconciler_syncWarmPool(t *testing.T) {
+	runners := []*github.Runner{
+		{ID: github.Int64(1), Name: github.String("example-abcde-fghij"), Status: github.String("online"), Busy: github.Bool(true)},
+		{ID: github.Int64(2), Name: github.String("example-bcdef-ghijk"), Status: github.String("online"), Busy: github.Bool(false)},
+		// Belongs to another runner deployment
+		{ID: github.Int64(3), Name: github.String("other-cdefg-hijkl"), Status: github.String("online"), Busy: github.Bool(true)},
+	}
+
+	var listed int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, req *http.Request) {
+		listed++
+
+		j, err := json.Marshal(github.Runners{TotalCount: len(runners), Runners: runners})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := &RunnerDeploymentReconciler{GitHubClient: newGithubClient(server)}
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			WarmPool: &v1alpha1.WarmPoolSpec{Replicas: 2},
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository: "test/valid",
+					},
+				},
+			},
+		},
+	}
+
+	got, err := r.syncWarmPool(context.Background(), rd, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &v1alpha1.WarmPoolStatus{BusyReplicas: 1, LastSyncTime: metav1.Time{Time: now}}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected status: %s", d)
+	}
+
+	// The last state is reused within the sync period
+	rd.Status.WarmPool = got
+
+	if _, err := r.syncWarmPool(context.Background(), rd, now.Add(DefaultWarmPoolSyncPeriod-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if listed != 1 {
+		t.Errorf("expected the runners to be listed once within the sync period, got %d", listed)
+	}
+
+	if _, err := r.syncWarmPool(context.Background(), rd, now.Add(DefaultWarmPoolSyncPeriod)); err != nil {
+		t.Fatal(err)
+	}
+
+	if listed != 2 {
+		t.Errorf("expected the runners to be listed again after the sync period, got %d", listed)
+	}
+
+	if res := requeueForWarmPool(rd, ctrl.Result{}); res.RequeueAfter != DefaultWarmPoolSyncPeriod {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	rd.Spec.WarmPool = nil
+
+	if got, err := r.syncWarmPool(context.Background(), rd, now); err != nil || got != nil {
+		t.Errorf("expected no status for the disabled warm pool, got %v: %v", got, err)
+	}
+}
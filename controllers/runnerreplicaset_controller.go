@@ -18,35 +18,43 @@ package controllers
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"reflect"
-	"time"
 
 	"github.com/go-logr/logr"
-	gogithub "github.com/google/go-github/v39/github"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/hash"
 )
 
 // RunnerReplicaSetReconciler reconciles a Runner object
 type RunnerReplicaSetReconciler struct {
 	client.Client
-	Log          logr.Logger
-	Recorder     record.EventRecorder
-	Scheme       *runtime.Scheme
-	GitHubClient *github.Client
-	Name         string
+	Log               logr.Logger
+	Recorder          record.EventRecorder
+	Scheme            *runtime.Scheme
+	GitHubClient      *github.Client
+	Name              string
+	ControllerOptions ControllerOptions
+
+	// DeletionParallelism is the number of runners deleted concurrently on scale down.
+	// Defaults to DefaultRunnerDeletionParallelism.
+	DeletionParallelism int
+
+	// MultiGitHubClient provides the clients of the runners with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerreplicasets,verbs=get;list;watch;create;update;patch;delete
@@ -88,9 +96,10 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	var myRunners []v1alpha1.Runner
 
 	var (
-		current   int
-		ready     int
-		available int
+		current     int
+		ready       int
+		available   int
+		terminating int
 	)
 
 	for _, r := range allRunners.Items {
@@ -101,9 +110,17 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 			current += 1
 
+			if !r.DeletionTimestamp.IsZero() {
+				terminating += 1
+			}
+
 			if r.Status.Phase == string(corev1.PodRunning) {
 				ready += 1
-				// available is currently the same as ready, as we don't yet have minReadySeconds for runners
+			}
+
+			// A runner is available only after GitHub reports it as online, so that a runner still registering itself
+			// isn't counted as a runner that can take a job.
+			if r.Status.Ready {
 				available += 1
 			}
 		}
@@ -152,95 +169,47 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
-	if current > desired {
-		n := current - desired
+	if current-terminating > desired {
+		n := current - terminating - desired
 
-		log.V(0).Info(fmt.Sprintf("Deleting %d runners", n), "desired", desired, "current", current, "ready", ready)
+		log.V(0).Info(fmt.Sprintf("Deleting %d runners", n), "desired", desired, "current", current, "ready", ready, "terminating", terminating)
 
 		// get runners that are currently offline/not busy/timed-out to register
-		var deletionCandidates []v1alpha1.Runner
-
-		for _, runner := range allRunners.Items {
-			busy, err := r.GitHubClient.IsRunnerBusy(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
-			if err != nil {
-				notRegistered := false
-				offline := false
-
-				var notFoundException *github.RunnerNotFound
-				var offlineException *github.RunnerOffline
-				if errors.As(err, &notFoundException) {
-					log.V(1).Info("Failed to check if runner is busy. Either this runner has never been successfully registered to GitHub or it still needs more time.", "runnerName", runner.Name)
-					notRegistered = true
-				} else if errors.As(err, &offlineException) {
-					offline = true
-				} else {
-					var e *gogithub.RateLimitError
-					if errors.As(err, &e) {
-						// We log the underlying error when we failed calling GitHub API to list or unregisters,
-						// or the runner is still busy.
-						log.Error(
-							err,
-							fmt.Sprintf(
-								"Failed to check if runner is busy due to GitHub API rate limit. Retrying in %s to avoid excessive GitHub API calls",
-								retryDelayOnGitHubAPIRateLimitError,
-							),
-						)
-
-						return ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
-					}
-
-					return ctrl.Result{}, err
-				}
-
-				registrationTimeout := 15 * time.Minute
-				currentTime := time.Now()
-				registrationDidTimeout := currentTime.Sub(runner.CreationTimestamp.Add(registrationTimeout)) > 0
-
-				if notRegistered && registrationDidTimeout {
-					log.Info(
-						"Runner failed to register itself to GitHub in timely manner. "+
-							"Marking the runner for scale down. "+
-							"CAUTION: If you see this a lot, you should investigate the root cause. "+
-							"See https://github.com/actions-runner-controller/actions-runner-controller/issues/288",
-						"runnerCreationTimestamp", runner.CreationTimestamp,
-						"currentTime", currentTime,
-						"configuredRegistrationTimeout", registrationTimeout,
-					)
-
-					deletionCandidates = append(deletionCandidates, runner)
-				}
-
-				// offline runners should always be a great target for scale down
-				if offline {
-					deletionCandidates = append(deletionCandidates, runner)
-				}
-			} else if !busy {
-				deletionCandidates = append(deletionCandidates, runner)
-			}
+		deletionCandidates, res, err := r.getDeletionCandidates(ctx, log, rs, myRunners)
+		if err != nil || res.Requeue || res.RequeueAfter > 0 {
+			return res, err
 		}
 
 		if len(deletionCandidates) < n {
 			n = len(deletionCandidates)
 		}
 
-		log.V(0).Info(fmt.Sprintf("Deleting %d runner(s)", n), "desired", desired, "current", current, "ready", ready)
+		log.V(0).Info(fmt.Sprintf("Deleting %d runner(s)", n), "desired", desired, "current", current, "ready", ready, "terminating", terminating)
 
-		for i := 0; i < n; i++ {
-			if err := r.Client.Delete(ctx, &deletionCandidates[i]); client.IgnoreNotFound(err) != nil {
-				log.Error(err, "Failed to delete runner resource")
-
-				return ctrl.Result{}, err
-			}
-
-			r.Recorder.Event(&rs, corev1.EventTypeNormal, "RunnerDeleted", fmt.Sprintf("Deleted runner '%s'", deletionCandidates[i].Name))
-			log.Info("Deleted runner")
+		if err := r.deleteRunners(ctx, log, rs, deletionCandidates[:n]); err != nil {
+			return ctrl.Result{}, err
 		}
 	} else if desired > current {
 		n := desired - current
 
 		log.V(0).Info(fmt.Sprintf("Creating %d runner(s)", n), "desired", desired, "available", current, "ready", ready)
 
-		for i := 0; i < n; i++ {
+		existing := map[string]struct{}{}
+		for _, runner := range allRunners.Items {
+			existing[runner.Name] = struct{}{}
+		}
+
+		// Each new runner is named after its slot, so that a retried reconciliation with a stale cache tries to create
+		// the same runners again, which fails with AlreadyExists, rather than creating extra runners.
+		// A slot is filled again with the same name once its runner is deleted, which is the norm for ephemeral runners,
+		// and the runner controller doesn't adopt the pod of the previous runner that can still be terminating.
+		for slot, created := 0, 0; created < n; slot++ {
+			name := runnerNameForSlot(rs, slot)
+
+			if _, ok := existing[name]; ok {
+				continue
+			}
+
 			newRunner, err := r.newRunner(rs)
 			if err != nil {
 				log.Error(err, "Could not create runner")
@@ -248,7 +217,21 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 				return ctrl.Result{}, err
 			}
 
+			newRunner.GenerateName = ""
+			newRunner.Name = name
+
+			created++
+
 			if err := r.Client.Create(ctx, &newRunner); err != nil {
+				if kerrors.IsAlreadyExists(err) {
+					log.Info(
+						"Skipped creating runner as it already exists. Probably it has been created in a previous reconciliation but is still not in the informer cache",
+						"runner", newRunner.Name,
+					)
+
+					continue
+				}
+
 				log.Error(err, "Failed to create runner resource")
 
 				return ctrl.Result{}, err
@@ -256,11 +239,21 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
+	runnerPods, err := r.getRunnerPods(ctx, myRunners)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	unschedulable, preempting := countUnschedulablePods(runnerPods)
+
 	var status v1alpha1.RunnerReplicaSetStatus
 
 	status.Replicas = &current
 	status.AvailableReplicas = &available
 	status.ReadyReplicas = &ready
+	status.UnschedulableReplicas = &unschedulable
+	status.PreemptingReplicas = &preempting
+	status.TerminatingReplicas = &terminating
 
 	if !reflect.DeepEqual(rs.Status, status) {
 		updated := rs.DeepCopy()
@@ -304,11 +297,20 @@ func (r *RunnerReplicaSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.RunnerReplicaSet{}).
 		Owns(&v1alpha1.Runner{}).
-		Named(name).
-		Complete(r)
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.runnerReplicaSetForPod)).
+		Named(name)
+
+	return r.ControllerOptions.complete(b, r)
+}
+
+// runnerNameForSlot returns the name of the runner that fills the slot of the replicaset.
+// It's derived from the UID rather than the name of the replicaset so that a recreated replicaset of the same name
+// doesn't reuse the names of the runners of its predecessor.
+func runnerNameForSlot(rs v1alpha1.RunnerReplicaSet, slot int) string {
+	return rs.Name + "-" + hash.FNVHashStringObjects(rs.UID, slot)
 }
 
 func registrationOnlyRunnerNameFor(rsName string) string {
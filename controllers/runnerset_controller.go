@@ -44,7 +44,8 @@ const (
 
 // RunnerSetReconciler reconciles a Runner object
 type RunnerSetReconciler struct {
-	Name string
+	Name              string
+	ControllerOptions ControllerOptions
 
 	client.Client
 	Log      logr.Logger
@@ -54,9 +55,15 @@ type RunnerSetReconciler struct {
 	CommonRunnerLabels     []string
 	GitHubBaseURL          string
 	RunnerImage            string
+	ARM64RunnerImage       string
+	WindowsRunnerImage     string
 	RunnerImagePullSecrets []string
 	DockerImage            string
 	DockerRegistryMirror   string
+	// RunnerCacheURL is the cache server of the runners without their own cache config. Empty for the cache of GitHub.
+	RunnerCacheURL string
+	// MultiGitHubClient provides the clients of the runners with githubAPICredentialsFrom. Can be nil.
+	MultiGitHubClient *MultiGitHubClient
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnersets,verbs=get;list;watch;create;update;patch;delete
@@ -65,6 +72,7 @@ type RunnerSetReconciler struct {
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=create
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update
 
 // Note that coordination.k8s.io/leases permission must be added to any of the controllers to avoid the following error:
@@ -90,7 +98,22 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	metrics.SetRunnerSet(*runnerSet)
 
-	desiredStatefulSet, err := r.newStatefulSet(runnerSet)
+	githubBaseURL := r.GitHubBaseURL
+
+	if from := runnerSet.Spec.GitHubAPICredentialsFrom; from != nil {
+		ghc, err := gitHubClientFor(ctx, r.MultiGitHubClient, nil, runnerSet.Namespace, from)
+		if err != nil {
+			r.Recorder.Event(runnerSet, corev1.EventTypeWarning, "InvalidGitHubAPICredentials", err.Error())
+
+			log.Error(err, "Could not get the github client of githubAPICredentialsFrom")
+
+			return ctrl.Result{}, err
+		}
+
+		githubBaseURL = ghc.GithubBaseURL
+	}
+
+	desiredStatefulSet, err := r.newStatefulSet(runnerSet, githubBaseURL)
 	if err != nil {
 		r.Recorder.Event(runnerSet, corev1.EventTypeNormal, "RunnerAutoscalingFailure", err.Error())
 
@@ -99,6 +122,12 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	if err := ensureToolCacheClaim(ctx, r.Client, runnerSet.Namespace, runnerSet.Spec.ToolCache); err != nil {
+		log.Error(err, "Failed to provision the tool cache")
+
+		return ctrl.Result{}, err
+	}
+
 	liveStatefulSet := &appsv1.StatefulSet{}
 	if err := r.Get(ctx, types.NamespacedName{Namespace: runnerSet.Namespace, Name: runnerSet.Name}, liveStatefulSet); err != nil {
 		if !errors.IsNotFound(err) {
@@ -230,36 +259,27 @@ func getStatefulSetTemplateHash(rs *appsv1.StatefulSet) (string, bool) {
 	return hash, ok
 }
 
-func getRunnerSetSelector(runnerSet *v1alpha1.RunnerSet) *metav1.LabelSelector {
-	selector := runnerSet.Spec.Selector
-	if selector == nil {
-		selector = &metav1.LabelSelector{MatchLabels: map[string]string{LabelKeyRunnerSetName: runnerSet.Name}}
-	}
-
-	return selector
-}
-
 var LabelKeyPodMutation = "actions-runner-controller/inject-registration-token"
 var LabelValuePodMutation = "true"
 
-func (r *RunnerSetReconciler) newStatefulSet(runnerSet *v1alpha1.RunnerSet) (*appsv1.StatefulSet, error) {
+// newStatefulSet returns the statefulset whose runners register to GitHub at the githubBaseURL.
+func (r *RunnerSetReconciler) newStatefulSet(runnerSet *v1alpha1.RunnerSet, githubBaseURL string) (*appsv1.StatefulSet, error) {
 	runnerSetWithOverrides := *runnerSet.Spec.DeepCopy()
 
 	for _, l := range r.CommonRunnerLabels {
 		runnerSetWithOverrides.Labels = append(runnerSetWithOverrides.Labels, l)
 	}
 
-	// This label selector is used by default when rd.Spec.Selector is empty.
-	runnerSetWithOverrides.Template.ObjectMeta.Labels = CloneAndAddLabel(runnerSetWithOverrides.Template.ObjectMeta.Labels, LabelKeyRunnerSetName, runnerSet.Name)
-
-	runnerSetWithOverrides.Template.ObjectMeta.Labels = CloneAndAddLabel(runnerSetWithOverrides.Template.ObjectMeta.Labels, LabelKeyPodMutation, LabelValuePodMutation)
+	runnerSetWithOverrides.Template.ObjectMeta.Labels = runnerSetPodLabels(runnerSetWithOverrides.Template.ObjectMeta.Labels, runnerSet)
 
 	template := corev1.Pod{
 		ObjectMeta: runnerSetWithOverrides.StatefulSetSpec.Template.ObjectMeta,
 		Spec:       runnerSetWithOverrides.StatefulSetSpec.Template.Spec,
 	}
 
-	pod, err := newRunnerPod(template, runnerSet.Spec.RunnerConfig, r.RunnerImage, r.RunnerImagePullSecrets, r.DockerImage, r.DockerRegistryMirror, r.GitHubBaseURL, false)
+	runnerImage := runnerImageFor(runnerSet.Spec.RunnerConfig, template.Spec.NodeSelector, r.RunnerImage, r.ARM64RunnerImage, r.WindowsRunnerImage)
+
+	pod, err := newRunnerPod(template, withDefaultRunnerCache(runnerSet.Spec.RunnerConfig, r.RunnerCacheURL), runnerImage, r.RunnerImagePullSecrets, r.DockerImage, r.DockerRegistryMirror, githubBaseURL, false)
 	if err != nil {
 		return nil, err
 	}
@@ -278,12 +298,7 @@ func (r *RunnerSetReconciler) newStatefulSet(runnerSet *v1alpha1.RunnerSet) (*ap
 	// Add template hash label to selector.
 	runnerSetWithOverrides.Template.ObjectMeta.Labels = CloneAndAddLabel(runnerSetWithOverrides.Template.ObjectMeta.Labels, LabelKeyRunnerTemplateHash, templateHash)
 
-	selector := getRunnerSetSelector(runnerSet)
-	selector = CloneSelectorAndAddLabel(selector, LabelKeyRunnerTemplateHash, templateHash)
-	selector = CloneSelectorAndAddLabel(selector, LabelKeyRunnerSetName, runnerSet.Name)
-	selector = CloneSelectorAndAddLabel(selector, LabelKeyPodMutation, LabelValuePodMutation)
-
-	runnerSetWithOverrides.StatefulSetSpec.Selector = selector
+	runnerSetWithOverrides.StatefulSetSpec.Selector = runnerSetStatefulSetSelector(runnerSet, templateHash)
 
 	rs := appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{},
@@ -310,9 +325,10 @@ func (r *RunnerSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.RunnerSet{}).
 		Owns(&appsv1.StatefulSet{}).
-		Named(name).
-		Complete(r)
+		Named(name)
+
+	return r.ControllerOptions.complete(b, r)
 }
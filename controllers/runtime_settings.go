@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
+)
+
+// DefaultRuntimeSettingsFileReloadInterval is how often RuntimeSettingsFile checks the file for changes.
+// Kubernetes propagates the change of a mounted configmap with a delay of up to a minute, so there's no point in
+// checking much more often than this.
+const DefaultRuntimeSettingsFileReloadInterval = 10 * time.Second
+
+// RuntimeSettings are the settings that can be changed without restarting the controller or the webhook server,
+// which would lose in-memory state like the rate limits of log lines and the caches of GitHub API responses.
+// Unset fields fall back to the values of the command-line flags.
+type RuntimeSettings struct {
+	// LogLevel is one of "debug", "info", "warn" and "error". See -log-level.
+	LogLevel *string `json:"logLevel,omitempty"`
+
+	// LogRateLimitInterval is the minimum interval between repetitive warnings with the same reason. See -log-rate-limit-interval.
+	LogRateLimitInterval *metav1.Duration `json:"logRateLimitInterval,omitempty"`
+
+	// DryRun toggles the dry-run mode of the webhook-based autoscaler. See -dry-run of the github-webhook-server.
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+// RuntimeSettingsFile provides the RuntimeSettings read from a YAML file, usually a mounted Kubernetes configmap.
+//
+// The file is periodically re-read while the manager is running, and the changed settings are applied to the logger and
+// LogRateLimiter. Other settings, like DryRun, are read by their users on every use.
+type RuntimeSettingsFile struct {
+	Path           string
+	ReloadInterval time.Duration
+	Log            logr.Logger
+
+	// Defaults are the settings given by the command-line flags, used for the settings missing in the file.
+	// All the fields need to be set.
+	Defaults RuntimeSettings
+
+	// LogRateLimiter gets LogRateLimitInterval applied. Can be nil.
+	LogRateLimiter *logging.RateLimiter
+
+	mu       sync.RWMutex
+	content  []byte
+	settings RuntimeSettings
+}
+
+// NewRuntimeSettingsFile returns a RuntimeSettingsFile that is loaded from the path and applied.
+// It fails when the file can't be read or contains invalid settings, so that the misconfiguration is noticed on startup.
+func NewRuntimeSettingsFile(path string, defaults RuntimeSettings, logRateLimiter *logging.RateLimiter, log logr.Logger) (*RuntimeSettingsFile, error) {
+	f := &RuntimeSettingsFile{
+		Path:           path,
+		ReloadInterval: DefaultRuntimeSettingsFileReloadInterval,
+		Log:            log,
+		Defaults:       defaults,
+		LogRateLimiter: logRateLimiter,
+	}
+
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Settings returns the settings currently in effect, with the defaults filled in for the settings missing in the file.
+func (f *RuntimeSettingsFile) Settings() RuntimeSettings {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.settings
+}
+
+// DryRun returns true when the dry-run mode is currently enabled.
+// A nil RuntimeSettingsFile returns the default.
+func (f *RuntimeSettingsFile) DryRun(defaultValue bool) bool {
+	if f == nil {
+		return defaultValue
+	}
+
+	if v := f.Settings().DryRun; v != nil {
+		return *v
+	}
+
+	return defaultValue
+}
+
+// Reload re-reads the file, applies the settings, and returns true when its content has changed.
+// Invalid settings are rejected as a whole, keeping the previously applied ones.
+func (f *RuntimeSettingsFile) Reload() (bool, error) {
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.content != nil && bytes.Equal(f.content, content) {
+		return false, nil
+	}
+
+	settings, err := parseRuntimeSettings(content, f.Defaults)
+	if err != nil {
+		return false, fmt.Errorf("parsing %s: %w", f.Path, err)
+	}
+
+	if err := logging.SetLogLevel(*settings.LogLevel); err != nil {
+		return false, fmt.Errorf("parsing %s: %w", f.Path, err)
+	}
+
+	if f.LogRateLimiter != nil {
+		f.LogRateLimiter.SetInterval(settings.LogRateLimitInterval.Duration)
+	}
+
+	f.content = content
+	f.settings = settings
+
+	return true, nil
+}
+
+// Start implements manager.Runnable to reload the file until the context is done.
+// A failed reload keeps the previously applied settings, because the file can be missing for a moment
+// while Kubernetes updates the mounted configmap.
+func (f *RuntimeSettingsFile) Start(ctx context.Context) error {
+	interval := f.ReloadInterval
+	if interval <= 0 {
+		interval = DefaultRuntimeSettingsFileReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			changed, err := f.Reload()
+			if err != nil {
+				f.Log.Error(err, "Failed to reload runtime settings file. Keeping the previously applied settings", "path", f.Path)
+				continue
+			}
+
+			if changed {
+				s := f.Settings()
+				f.Log.Info("Reloaded runtime settings file", "path", f.Path, "logLevel", *s.LogLevel, "logRateLimitInterval", s.LogRateLimitInterval.Duration, "dryRun", *s.DryRun)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that every replica,
+// not only the leader, applies the settings.
+func (f *RuntimeSettingsFile) NeedLeaderElection() bool {
+	return false
+}
+
+func parseRuntimeSettings(content []byte, defaults RuntimeSettings) (RuntimeSettings, error) {
+	var s RuntimeSettings
+
+	// Strict so that a misspelled setting isn't silently ignored
+	if err := yaml.UnmarshalStrict(content, &s); err != nil {
+		return s, err
+	}
+
+	if s.LogLevel == nil {
+		s.LogLevel = defaults.LogLevel
+	}
+
+	if s.LogRateLimitInterval == nil {
+		s.LogRateLimitInterval = defaults.LogRateLimitInterval
+	}
+
+	if s.DryRun == nil {
+		s.DryRun = defaults.DryRun
+	}
+
+	return s, nil
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
+)
+
+func TestRuntimeSettingsFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+
+	if err := ioutil.WriteFile(path, []byte("logLevel: info\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	logLevel, dryRun := logging.LogLevelDebug, false
+	defaults := RuntimeSettings{
+		LogLevel:             &logLevel,
+		LogRateLimitInterval: &metav1.Duration{Duration: time.Minute},
+		DryRun:               &dryRun,
+	}
+
+	defer logging.SetLogLevel(logging.LogLevelDebug)
+
+	f, err := NewRuntimeSettingsFile(path, defaults, logging.NewRateLimiter(0), zap.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := f.Settings(); *s.LogLevel != logging.LogLevelInfo || s.LogRateLimitInterval.Duration != time.Minute || f.DryRun(false) {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+
+	if changed, err := f.Reload(); err != nil || changed {
+		t.Errorf("expected no change, got changed=%v, err=%v", changed, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("dryRun: true\nlogRateLimitInterval: 5m\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := f.Reload(); err != nil || !changed {
+		t.Errorf("expected change, got changed=%v, err=%v", changed, err)
+	}
+
+	if s := f.Settings(); *s.LogLevel != logging.LogLevelDebug || s.LogRateLimitInterval.Duration != 5*time.Minute || !f.DryRun(false) {
+		t.Errorf("unexpected settings after reload: %+v", s)
+	}
+
+	// Invalid settings are rejected as a whole
+	for _, content := range []string{"logLevel: verbose\ndryRun: false\n", "dry-run: false\n"} {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Reload(); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+
+		if !f.DryRun(false) {
+			t.Errorf("expected the previous settings to be kept after loading %q", content)
+		}
+	}
+
+	var nilFile *RuntimeSettingsFile
+	if !nilFile.DryRun(true) {
+		t.Errorf("expected the default without the runtime settings file")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// scaleDownBehavior is the set of the scale-down knobs a ScaleDownBehavior preset translates into.
+type scaleDownBehavior struct {
+	// delay is the stabilization window after the last scale up within which the scale target isn't scaled down.
+	delay time.Duration
+
+	// maxRatePerMinute is the maximum number of replicas removed in a minute, or 0 for no limit.
+	maxRatePerMinute int
+
+	// scaleDownThreshold and scaleDownFactor are the defaults of PercentageRunnersBusy.
+	scaleDownThreshold float64
+	scaleDownFactor    float64
+
+	// idleRunnersOnly keeps PercentageRunnersBusy from scaling down below the number of the busy runners,
+	// so that only the idle runners are removed rather than the busy runners being removed one by one as they finish their jobs.
+	idleRunnersOnly bool
+}
+
+var scaleDownBehaviors = map[string]scaleDownBehavior{
+	v1alpha1.ScaleDownBehaviorConservative: {
+		delay:              30 * time.Minute,
+		maxRatePerMinute:   1,
+		scaleDownThreshold: 0.1,
+		scaleDownFactor:    0.9,
+		idleRunnersOnly:    true,
+	},
+	v1alpha1.ScaleDownBehaviorBalanced: {
+		delay:              DefaultScaleDownDelay,
+		maxRatePerMinute:   5,
+		scaleDownThreshold: defaultScaleDownThreshold,
+		scaleDownFactor:    defaultScaleDownFactor,
+		idleRunnersOnly:    true,
+	},
+	v1alpha1.ScaleDownBehaviorAggressive: {
+		delay:              time.Minute,
+		scaleDownThreshold: 0.5,
+		scaleDownFactor:    0.5,
+	},
+}
+
+// scaleDownBehaviorOf returns the scale-down knobs of the HRA, which are the ones of its ScaleDownBehavior preset
+// overridden by the fields set explicitly, or the defaults without the preset.
+func scaleDownBehaviorOf(hra v1alpha1.HorizontalRunnerAutoscaler) scaleDownBehavior {
+	b, ok := scaleDownBehaviors[hra.Spec.ScaleDownBehavior]
+	if !ok {
+		b = scaleDownBehavior{
+			delay:              DefaultScaleDownDelay,
+			scaleDownThreshold: defaultScaleDownThreshold,
+			scaleDownFactor:    defaultScaleDownFactor,
+		}
+	}
+
+	if v := hra.Spec.ScaleDownDelaySecondsAfterScaleUp; v != nil {
+		b.delay = time.Duration(*v) * time.Second
+	}
+
+	if v := hra.Spec.ScaleDownMaxRatePerMinute; v != nil {
+		b.maxRatePerMinute = *v
+	}
+
+	return b
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestScaleDownBehaviorOf(t *testing.T) {
+	delay, rate := 120, 3
+
+	testcases := []struct {
+		name string
+		spec v1alpha1.HorizontalRunnerAutoscalerSpec
+		want scaleDownBehavior
+	}{
+		{
+			name: "defaults",
+			want: scaleDownBehavior{delay: DefaultScaleDownDelay, scaleDownThreshold: defaultScaleDownThreshold, scaleDownFactor: defaultScaleDownFactor},
+		},
+		{
+			name: "preset",
+			spec: v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownBehavior: v1alpha1.ScaleDownBehaviorConservative},
+			want: scaleDownBehaviors[v1alpha1.ScaleDownBehaviorConservative],
+		},
+		{
+			name: "preset overridden",
+			spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleDownBehavior:                 v1alpha1.ScaleDownBehaviorAggressive,
+				ScaleDownDelaySecondsAfterScaleUp: &delay,
+				ScaleDownMaxRatePerMinute:         &rate,
+			},
+			want: scaleDownBehavior{delay: 2 * time.Minute, maxRatePerMinute: 3, scaleDownThreshold: 0.5, scaleDownFactor: 0.5},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := scaleDownBehaviorOf(v1alpha1.HorizontalRunnerAutoscaler{Spec: tc.spec})
+
+			if d := cmp.Diff(tc.want, got, cmp.AllowUnexported(scaleDownBehavior{})); d != "" {
+				t.Errorf("unexpected scale down behavior (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestSuggestReplicasByPercentageRunnersBusy_ScaleDownBehavior(t *testing.T) {
+	testcases := []struct {
+		behavior string
+		want     int
+	}{
+		// 2 busy out of 10 is below the default scale down threshold, and scaled down by the adjustment of 9
+		{behavior: "", want: 1},
+		// The busy runners are kept
+		{behavior: v1alpha1.ScaleDownBehaviorBalanced, want: 2},
+		// 2 busy out of 10 isn't below the threshold of 0.1
+		{behavior: v1alpha1.ScaleDownBehaviorConservative, want: 10},
+		{behavior: v1alpha1.ScaleDownBehaviorAggressive, want: 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.behavior, func(t *testing.T) {
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log: zap.New(),
+			}
+
+			replicas := 10
+
+			runnerMap := map[string]struct{}{}
+			for i := 1; i <= replicas; i++ {
+				runnerMap[fmt.Sprintf("example-runner-%d", i)] = struct{}{}
+			}
+
+			st := scaleTarget{
+				st:       "example",
+				kind:     "RunnerDeployment",
+				repo:     "test/valid",
+				replicas: &replicas,
+				getRunnerMap: func() (map[string]struct{}, error) {
+					return runnerMap, nil
+				},
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownBehavior: tc.behavior},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					BusyRunners: []v1alpha1.BusyRunner{{Name: "example-runner-1"}, {Name: "example-runner-2"}},
+				},
+			}
+
+			metric := v1alpha1.MetricSpec{
+				Type:                v1alpha1.AutoscalingMetricTypePercentageRunnersBusy,
+				BusyRunnersSource:   v1alpha1.BusyRunnersSourceWorkflowJobEvents,
+				ScaleDownAdjustment: 9,
+			}
+
+			got, err := h.suggestReplicasByPercentageRunnersBusy(st, hra, metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %d", tc.want, *got)
+			}
+		})
+	}
+}
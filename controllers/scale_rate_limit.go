@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// scaleRateLimitWindow is the length of the window within which the desired replicas can change by up to
+// ScaleUpMaxRatePerMinute or ScaleDownMaxRatePerMinute replicas.
+const scaleRateLimitWindow = time.Minute
+
+// limitScaleRate clamps the desired replicas so that they change by up to ScaleUpMaxRatePerMinute or
+// ScaleDownMaxRatePerMinute replicas from the desired replicas at the start of the current window.
+// ScaleDownMaxRatePerMinute defaults to the one of the ScaleDownBehavior preset.
+// It returns the clamped desired replicas and the window to be recorded in the status,
+// which is nil when the HRA has no rate limits.
+func limitScaleRate(hra v1alpha1.HorizontalRunnerAutoscaler, desiredReplicas int, now time.Time) (int, *v1alpha1.ScaleRateLimitWindow) {
+	up, down := hra.Spec.ScaleUpMaxRatePerMinute, scaleDownBehaviorOf(hra).maxRatePerMinute
+
+	if up == nil && down == 0 {
+		return desiredReplicas, nil
+	}
+
+	// There's nothing to limit the rate against until the first scale
+	if hra.Status.DesiredReplicas == nil {
+		return desiredReplicas, &v1alpha1.ScaleRateLimitWindow{StartTime: metav1.Time{Time: now}, StartReplicas: desiredReplicas}
+	}
+
+	window := hra.Status.ScaleRateLimitWindow
+	if window == nil || !now.Before(window.StartTime.Add(scaleRateLimitWindow)) {
+		window = &v1alpha1.ScaleRateLimitWindow{StartTime: metav1.Time{Time: now}, StartReplicas: *hra.Status.DesiredReplicas}
+	}
+
+	limited := desiredReplicas
+
+	if up != nil && limited > window.StartReplicas+*up {
+		limited = window.StartReplicas + *up
+	}
+
+	if down > 0 && limited < window.StartReplicas-down {
+		limited = window.StartReplicas - down
+	}
+
+	return limited, window
+}
+
+// recordScaleRateLimit emits the event when limitScaleRate suppressed a part of the scale.
+func (r *HorizontalRunnerAutoscalerReconciler) recordScaleRateLimit(hra v1alpha1.HorizontalRunnerAutoscaler, desiredReplicas, limitedReplicas int) {
+	switch {
+	case limitedReplicas < desiredReplicas:
+		r.Recorder.Event(&hra, corev1.EventTypeWarning, "ScaleUpSuppressed", fmt.Sprintf(
+			"Limited scale up to %d replicas instead of %d by scaleUpMaxRatePerMinute of %d",
+			limitedReplicas, desiredReplicas, *hra.Spec.ScaleUpMaxRatePerMinute,
+		))
+	case limitedReplicas > desiredReplicas:
+		by := fmt.Sprintf("scaleDownMaxRatePerMinute of %d", scaleDownBehaviorOf(hra).maxRatePerMinute)
+		if hra.Spec.ScaleDownMaxRatePerMinute == nil {
+			by = fmt.Sprintf("scaleDownBehavior %s", hra.Spec.ScaleDownBehavior)
+		}
+
+		r.Recorder.Event(&hra, corev1.EventTypeNormal, "ScaleDownSuppressed", fmt.Sprintf(
+			"Limited scale down to %d replicas instead of %d by %s",
+			limitedReplicas, desiredReplicas, by,
+		))
+	}
+}
+
+// capacityReservationAllowance returns the number of replicas the webhook-based autoscaler can still reserve
+// within the last minute under ScaleUpMaxRatePerMinute, and false when the HRA has no such limit.
+// The reservations don't record when they were added, so a reservation is assumed to be added within
+// the last minute when it expires later than the one added a minute ago for the same trigger duration would.
+func capacityReservationAllowance(hra v1alpha1.HorizontalRunnerAutoscaler, reservations []v1alpha1.CapacityReservation, duration time.Duration, now time.Time) (int, bool) {
+	rate := hra.Spec.ScaleUpMaxRatePerMinute
+
+	if rate == nil {
+		return 0, false
+	}
+
+	addedSince := now.Add(duration - scaleRateLimitWindow)
+
+	var added int
+
+	for _, r := range reservations {
+		if r.ExpirationTime.Time.After(addedSince) {
+			added += r.Replicas
+		}
+	}
+
+	allowance := *rate - added
+	if allowance < 0 {
+		allowance = 0
+	}
+
+	return allowance, true
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLimitScaleRate(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	up, down := 10, 2
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	window := func(start time.Time, replicas int) *v1alpha1.ScaleRateLimitWindow {
+		return &v1alpha1.ScaleRateLimitWindow{StartTime: metav1.Time{Time: start}, StartReplicas: replicas}
+	}
+
+	testcases := []struct {
+		name       string
+		up, down   *int
+		behavior   string
+		current    *int
+		prev       *v1alpha1.ScaleRateLimitWindow
+		desired    int
+		want       int
+		wantWindow *v1alpha1.ScaleRateLimitWindow
+	}{
+		{
+			name:    "no limits",
+			current: intPtr(5),
+			desired: 500,
+			want:    500,
+		},
+		{
+			name:       "first scale",
+			up:         &up,
+			desired:    500,
+			want:       500,
+			wantWindow: window(now, 500),
+		},
+		{
+			name:       "scale up within the rate",
+			up:         &up,
+			current:    intPtr(5),
+			desired:    15,
+			want:       15,
+			wantWindow: window(now, 5),
+		},
+		{
+			name:       "scale up beyond the rate",
+			up:         &up,
+			current:    intPtr(5),
+			desired:    500,
+			want:       15,
+			wantWindow: window(now, 5),
+		},
+		{
+			name:       "scale up within the same window",
+			up:         &up,
+			current:    intPtr(15),
+			prev:       window(now.Add(-30*time.Second), 5),
+			desired:    500,
+			want:       15,
+			wantWindow: window(now.Add(-30*time.Second), 5),
+		},
+		{
+			name:       "scale up in the next window",
+			up:         &up,
+			current:    intPtr(15),
+			prev:       window(now.Add(-time.Minute), 5),
+			desired:    500,
+			want:       25,
+			wantWindow: window(now, 15),
+		},
+		{
+			name:       "scale down beyond the rate",
+			up:         &up,
+			down:       &down,
+			current:    intPtr(25),
+			desired:    0,
+			want:       23,
+			wantWindow: window(now, 25),
+		},
+		{
+			name:       "scale down by the preset",
+			behavior:   v1alpha1.ScaleDownBehaviorConservative,
+			current:    intPtr(25),
+			desired:    0,
+			want:       24,
+			wantWindow: window(now, 25),
+		},
+		{
+			name:       "scale down beyond the rate overriding the preset",
+			down:       &down,
+			behavior:   v1alpha1.ScaleDownBehaviorConservative,
+			current:    intPtr(25),
+			desired:    0,
+			want:       23,
+			wantWindow: window(now, 25),
+		},
+		{
+			name:       "scale down without the limit",
+			up:         &up,
+			current:    intPtr(25),
+			desired:    0,
+			want:       0,
+			wantWindow: window(now, 25),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleUpMaxRatePerMinute:   tc.up,
+					ScaleDownMaxRatePerMinute: tc.down,
+					ScaleDownBehavior:         tc.behavior,
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					DesiredReplicas:      tc.current,
+					ScaleRateLimitWindow: tc.prev,
+				},
+			}
+
+			got, gotWindow := limitScaleRate(hra, tc.desired, now)
+
+			if got != tc.want {
+				t.Errorf("unexpected desired replicas: want %d, got %d", tc.want, got)
+			}
+
+			if d := cmp.Diff(tc.wantWindow, gotWindow); d != "" {
+				t.Errorf("unexpected window (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+const (
+	DefaultTerminalRunnerGCDeletionsPerSecond = 5
+	DefaultTerminalRunnerGCBatchSize          = 50
+)
+
+// TerminalRunnerCollector deletes runners whose pods have terminated and stayed so for the retention period,
+// along with their pods.
+//
+// Runners whose pods have failed, e.g. due to evictions, stay around until someone deletes them. In high-churn fleets
+// of ephemeral runners they accumulate into thousands of objects that slow down listing and inflate etcd.
+// The deletions are rate-limited so that a backlog of terminal runners doesn't flood the API server.
+type TerminalRunnerCollector struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Name     string
+
+	// SucceededTTL is how long a runner whose pod succeeded is retained. 0 retains it forever.
+	SucceededTTL time.Duration
+
+	// FailedTTL is how long a runner whose pod failed is retained. 0 retains it forever.
+	// It's usually longer than SucceededTTL, to leave time to investigate the failure.
+	FailedTTL time.Duration
+
+	// DeletionsPerSecond and BatchSize are the rate and the burst of the deletions.
+	DeletionsPerSecond float64
+	BatchSize          int
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+func (r *TerminalRunnerCollector) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runner", req.NamespacedName)
+
+	var runner v1alpha1.Runner
+	if err := r.Get(ctx, req.NamespacedName, &runner); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The registration-only runner is kept stopped on purpose, to let GitHub queue jobs while scaled to zero
+	if !runner.DeletionTimestamp.IsZero() || metav1.HasAnnotation(runner.ObjectMeta, annotationKeyRegistrationOnly) {
+		return ctrl.Result{}, nil
+	}
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Namespace: runner.Namespace, Name: runner.Name}, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	ttl, terminatedAt, ok := r.retention(pod)
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(terminatedAt.Add(ttl)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if delay := r.reserveDeletion(); delay > 0 {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	// The runner pod is deleted by the garbage collector, as it's owned by the runner
+	if err := r.Delete(ctx, &runner, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !kerrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete terminal runner")
+		return ctrl.Result{}, err
+	}
+
+	phase := string(pod.Status.Phase)
+
+	log.Info("Deleted terminal runner", "phase", phase, "terminatedAt", terminatedAt, "ttl", ttl)
+	r.Recorder.Eventf(&runner, corev1.EventTypeNormal, "TerminalRunnerDeleted", "Deleted runner whose pod has been %s since %s", phase, terminatedAt.Format(time.RFC3339))
+
+	metrics.IncRunnerTerminalCollected(runner.Namespace, phase)
+
+	return ctrl.Result{}, nil
+}
+
+// retention returns the retention period of the runner pod and when it terminated, and false when the pod
+// hasn't terminated or the runners in its phase are retained forever.
+func (r *TerminalRunnerCollector) retention(pod corev1.Pod) (time.Duration, time.Time, bool) {
+	var ttl time.Duration
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		ttl = r.SucceededTTL
+	case corev1.PodFailed:
+		ttl = r.FailedTTL
+	}
+
+	if ttl <= 0 {
+		return 0, time.Time{}, false
+	}
+
+	return ttl, podTerminatedAt(pod), true
+}
+
+// podTerminatedAt returns when the last container of the terminated pod finished.
+// A pod evicted before starting its containers has no finish time, in which case it's when the pod stopped being ready,
+// or when it was created.
+func podTerminatedAt(pod corev1.Pod) time.Time {
+	var t time.Time
+
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Terminated != nil && s.State.Terminated.FinishedAt.After(t) {
+			t = s.State.Terminated.FinishedAt.Time
+		}
+	}
+
+	if !t.IsZero() {
+		return t
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady && !c.LastTransitionTime.IsZero() {
+			return c.LastTransitionTime.Time
+		}
+	}
+
+	return pod.CreationTimestamp.Time
+}
+
+// reserveDeletion returns 0 when a runner can be deleted now, or how long to wait for the next slot.
+// Up to BatchSize runners are deleted at once, and DeletionsPerSecond after that.
+func (r *TerminalRunnerCollector) reserveDeletion() time.Duration {
+	r.limiterOnce.Do(func() {
+		perSecond := r.DeletionsPerSecond
+		if perSecond <= 0 {
+			perSecond = DefaultTerminalRunnerGCDeletionsPerSecond
+		}
+
+		batchSize := r.BatchSize
+		if batchSize <= 0 {
+			batchSize = DefaultTerminalRunnerGCBatchSize
+		}
+
+		r.limiter = rate.NewLimiter(rate.Limit(perSecond), batchSize)
+	})
+
+	if r.limiter.Allow() {
+		return 0
+	}
+
+	// Not reserving the next slot, so that the requeued runners compete for it on retry
+	return time.Duration(float64(time.Second) / float64(r.limiter.Limit()))
+}
+
+func (r *TerminalRunnerCollector) SetupWithManager(mgr ctrl.Manager) error {
+	name := "terminal-runner-collector"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.Runner{}).
+		Owns(&corev1.Pod{}).
+		Named(name).
+		Complete(r)
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestTerminalRunnerCollector(t *testing.T) {
+	now := time.Now()
+
+	newRunner := func(name string, annotations map[string]string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: annotations}}
+	}
+
+	newPod := func(name string, phase corev1.PodPhase, finishedAgo time.Duration) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+
+		if finishedAgo > 0 {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{
+					Name: containerName,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.Time{Time: now.Add(-finishedAgo)}},
+					},
+				},
+			}
+		}
+
+		return pod
+	}
+
+	client := fake.NewFakeClientWithScheme(sc,
+		newRunner("succeeded-expired", nil), newPod("succeeded-expired", corev1.PodSucceeded, 2*time.Hour),
+		newRunner("succeeded-retained", nil), newPod("succeeded-retained", corev1.PodSucceeded, 30*time.Minute),
+		newRunner("failed-retained", nil), newPod("failed-retained", corev1.PodFailed, 2*time.Hour),
+		newRunner("failed-expired", nil), newPod("failed-expired", corev1.PodFailed, 25*time.Hour),
+		newRunner("running", nil), newPod("running", corev1.PodRunning, 0),
+		newRunner("registration-only", map[string]string{annotationKeyRegistrationOnly: "true"}), newPod("registration-only", corev1.PodSucceeded, 48*time.Hour),
+	)
+
+	r := &TerminalRunnerCollector{
+		Client:             client,
+		Log:                logr.Discard(),
+		Recorder:           record.NewFakeRecorder(10),
+		SucceededTTL:       time.Hour,
+		FailedTTL:          24 * time.Hour,
+		DeletionsPerSecond: 0.001,
+		BatchSize:          1,
+	}
+
+	testcases := []struct {
+		name        string
+		wantDeleted bool
+		wantRequeue bool
+	}{
+		{name: "succeeded-expired", wantDeleted: true},
+		{name: "succeeded-retained", wantRequeue: true},
+		{name: "failed-retained", wantRequeue: true},
+		{name: "running"},
+		{name: "registration-only"},
+		// Throttled, as the batch is used up by the first deletion
+		{name: "failed-expired", wantRequeue: true},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range testcases {
+		key := types.NamespacedName{Namespace: "default", Name: tc.name}
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if got := res.RequeueAfter > 0; got != tc.wantRequeue {
+			t.Errorf("%s: unexpected requeue: want %v, got %s", tc.name, tc.wantRequeue, res.RequeueAfter)
+		}
+
+		err = client.Get(ctx, key, &v1alpha1.Runner{})
+		if deleted := kerrors.IsNotFound(err); deleted != tc.wantDeleted {
+			t.Errorf("%s: unexpected deletion: want %v, got %v", tc.name, tc.wantDeleted, err)
+		}
+	}
+}
+
+func TestPodTerminatedAt(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: created}},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	if got := podTerminatedAt(pod); !got.Equal(created) {
+		t.Errorf("expected the creation time for the pod evicted before starting, got %s", got)
+	}
+
+	notReady := created.Add(time.Minute)
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.Time{Time: notReady}}}
+
+	if got := podTerminatedAt(pod); !got.Equal(notReady) {
+		t.Errorf("expected the time the pod stopped being ready, got %s", got)
+	}
+
+	finished := created.Add(2 * time.Minute)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.Time{Time: created.Add(90 * time.Second)}}}},
+		{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.Time{Time: finished}}}},
+	}
+
+	if got := podTerminatedAt(pod); !got.Equal(finished) {
+		t.Errorf("expected the time the last container finished, got %s", got)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	toolCacheVolumeName = "tool-cache"
+
+	// defaultToolCacheMountPath is the tool cache directory of the runner images of this project
+	defaultToolCacheMountPath = "/opt/hostedtoolcache"
+
+	// defaultWindowsToolCacheMountPath is the tool cache directory of the Windows runners hosted by GitHub
+	defaultWindowsToolCacheMountPath = `C:\hostedtoolcache\windows`
+)
+
+// newToolCacheConfig returns the environment variables, volumes, and volume mounts for the runner container
+// that share the tool cache across the runners.
+// The volume mounts are needed by the docker sidecar too, because the runner mounts the tool cache to job containers
+// by the path in the runner container.
+func newToolCacheConfig(toolCache v1alpha1.ToolCacheSpec, windows bool) ([]corev1.EnvVar, []corev1.Volume, []corev1.VolumeMount) {
+	mountPath := toolCache.MountPath
+	if mountPath == "" {
+		if windows {
+			mountPath = defaultWindowsToolCacheMountPath
+		} else {
+			mountPath = defaultToolCacheMountPath
+		}
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "RUNNER_TOOL_CACHE",
+			Value: mountPath,
+		},
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: toolCacheVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: toolCache.ClaimName},
+			},
+		},
+	}
+
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      toolCacheVolumeName,
+			MountPath: mountPath,
+		},
+	}
+
+	return env, volumes, volumeMounts
+}
+
+// newToolCacheClaim returns the ReadWriteMany PersistentVolumeClaim provisioned for the tool cache.
+func newToolCacheClaim(namespace string, toolCache v1alpha1.ToolCacheSpec) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      toolCache.ClaimName,
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			StorageClassName: toolCache.Provision.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: toolCache.Provision.Size,
+				},
+			},
+		},
+	}
+}
+
+// ensureToolCacheClaim provisions the PersistentVolumeClaim for the tool cache unless it already exists.
+// The claim is created without reading it first, so that the controller doesn't need to cache all the claims in the cluster.
+// The existing claim is kept as-is, as the storage of a bound claim can't be changed anyway.
+func ensureToolCacheClaim(ctx context.Context, c client.Client, namespace string, toolCache *v1alpha1.ToolCacheSpec) error {
+	if toolCache == nil || toolCache.Provision == nil {
+		return nil
+	}
+
+	claim := newToolCacheClaim(namespace, *toolCache)
+
+	if err := c.Create(ctx, &claim); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("provisioning persistentvolumeclaim %s for the tool cache: %w", toolCache.ClaimName, err)
+	}
+
+	return nil
+}
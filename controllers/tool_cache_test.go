@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestEnsureToolCacheClaim(t *testing.T) {
+	existing := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, existing)
+	ctx := context.Background()
+
+	storageClassName := "efs"
+
+	provision := &v1alpha1.ToolCacheProvision{
+		StorageClassName: &storageClassName,
+		Size:             resource.MustParse("20Gi"),
+	}
+
+	if err := ensureToolCacheClaim(ctx, client, "default", &v1alpha1.ToolCacheSpec{ClaimName: "tool-cache", Provision: provision}); err != nil {
+		t.Fatal(err)
+	}
+
+	var claim corev1.PersistentVolumeClaim
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "tool-cache"}, &claim); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := claim.Spec.AccessModes; len(m) != 1 || m[0] != corev1.ReadWriteMany {
+		t.Errorf("unexpected access modes: %v", m)
+	}
+
+	if c := claim.Spec.StorageClassName; c == nil || *c != "efs" {
+		t.Errorf("unexpected storage class: %v", c)
+	}
+
+	if q := claim.Spec.Resources.Requests[corev1.ResourceStorage]; q.String() != "20Gi" {
+		t.Errorf("unexpected storage request: %s", q.String())
+	}
+
+	// The existing claim is kept as-is
+	if err := ensureToolCacheClaim(ctx, client, "default", &v1alpha1.ToolCacheSpec{ClaimName: "existing", Provision: provision}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "existing"}, &claim); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := claim.Spec.AccessModes; len(m) != 1 || m[0] != corev1.ReadWriteOnce {
+		t.Errorf("unexpected access modes of the existing claim: %v", m)
+	}
+
+	// Nothing is provisioned without provision
+	if err := ensureToolCacheClaim(ctx, client, "default", &v1alpha1.ToolCacheSpec{ClaimName: "unprovisioned"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "unprovisioned"}, &claim); err == nil {
+		t.Error("unexpected claim provisioned without provision")
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// UnmatchedJob is a queued workflow job that no HorizontalRunnerAutoscaler scales for.
+type UnmatchedJob struct {
+	ID         int64    `json:"id"`
+	RunID      int64    `json:"runID"`
+	Name       string   `json:"name"`
+	Repository string   `json:"repository"`
+	Enterprise string   `json:"enterprise,omitempty"`
+	Labels     []string `json:"labels"`
+
+	// QueuedAt is when GitHub queued the job.
+	QueuedAt time.Time `json:"queuedAt"`
+	// ReceivedAt is when the webhook server received the queued event.
+	ReceivedAt time.Time `json:"receivedAt"`
+	// Delivery is the X-GitHub-Delivery header of the queued event.
+	Delivery string `json:"delivery,omitempty"`
+
+	// repositoryName, owner, ownerType, enterpriseHost and namespaces are for finding the scale target again.
+	repositoryName string
+	owner          string
+	ownerType      string
+	enterpriseHost string
+	// namespaces are the namespaces the WebhookSource of the queued event limits the scale targets to.
+	namespaces []string
+}
+
+// UnmatchedJobs keeps the latest queued workflow jobs that no HorizontalRunnerAutoscaler scales for,
+// which otherwise hang in the queue forever, so that operators can discover the missing runner pools.
+//
+// A job is forgotten once it is picked up or completed, like when it's run by a GitHub-hosted runner or cancelled,
+// or once a HorizontalRunnerAutoscaler created or updated later scales for it.
+// When full, the oldest job is evicted.
+type UnmatchedJobs struct {
+	size int
+
+	mu sync.Mutex
+	// jobs are sorted by ReceivedAt, the oldest first
+	jobs []UnmatchedJob
+	// retried are the fingerprints of the HRAs that the jobs were last retried for. See hraFingerprint.
+	retried map[types.NamespacedName]string
+
+	now func() time.Time
+}
+
+// NewUnmatchedJobs returns the UnmatchedJobs that keeps up to size jobs.
+func NewUnmatchedJobs(size int) *UnmatchedJobs {
+	return &UnmatchedJobs{size: size, retried: map[types.NamespacedName]string{}, now: time.Now}
+}
+
+// List returns the unmatched jobs, the oldest first.
+func (u *UnmatchedJobs) List() []UnmatchedJob {
+	if u == nil {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return append([]UnmatchedJob{}, u.jobs...)
+}
+
+// ServeHTTP responds the unmatched jobs in JSON.
+func (u *UnmatchedJobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(u.List()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// add records the queued job. A redelivered event replaces the job recorded for the same ID.
+func (u *UnmatchedJobs) add(e *gogithub.WorkflowJobEvent, enterprise, enterpriseHost, delivery string, namespaces []string) {
+	if u == nil || u.size <= 0 {
+		return
+	}
+
+	job := e.GetWorkflowJob()
+
+	// The job without ID can't be forgotten on completion
+	if job.GetID() == 0 {
+		return
+	}
+
+	unmatched := UnmatchedJob{
+		ID:         job.GetID(),
+		RunID:      job.GetRunID(),
+		Name:       job.GetName(),
+		Repository: e.GetRepo().GetFullName(),
+		Enterprise: enterprise,
+		Labels:     job.Labels,
+		QueuedAt:   job.GetStartedAt().Time,
+		ReceivedAt: u.now(),
+		Delivery:   delivery,
+
+		repositoryName: e.GetRepo().GetName(),
+		owner:          e.GetRepo().GetOwner().GetLogin(),
+		ownerType:      e.GetRepo().GetOwner().GetType(),
+		enterpriseHost: enterpriseHost,
+		namespaces:     namespaces,
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.removeLocked(unmatched.ID)
+
+	u.jobs = append(u.jobs, unmatched)
+
+	if over := len(u.jobs) - u.size; over > 0 {
+		u.jobs = append([]UnmatchedJob{}, u.jobs[over:]...)
+	}
+}
+
+// remove forgets the job, returning true if it had been recorded.
+func (u *UnmatchedJobs) remove(id int64) bool {
+	if u == nil {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.removeLocked(id)
+}
+
+func (u *UnmatchedJobs) removeLocked(id int64) bool {
+	for i, j := range u.jobs {
+		if j.ID == id {
+			u.jobs = append(u.jobs[:i], u.jobs[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// retriedFor returns the fingerprint of the HRA that the jobs were last retried for.
+func (u *UnmatchedJobs) retriedFor(name types.NamespacedName) (string, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	fingerprint, ok := u.retried[name]
+
+	return fingerprint, ok
+}
+
+// setRetriedFor records the fingerprint of the HRA that the jobs were retried for. An empty fingerprint forgets the HRA.
+func (u *UnmatchedJobs) setRetriedFor(name types.NamespacedName, fingerprint string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if fingerprint == "" {
+		delete(u.retried, name)
+	} else {
+		u.retried[name] = fingerprint
+	}
+}
+
+// retryUnmatchedJobs scales for the unmatched jobs once the HRA is created, or the HRA or its scale target is updated
+// e.g. to have new labels, so that a job queued a minute before its runner pool was deployed doesn't hang forever.
+// The jobs are retried against every HRA, not only the one reconciled, as the change can make another HRA the scale target,
+// like when the updated HRA now selects the jobs by labels that another HRA used to.
+//
+// The HRA status updates don't trigger the retries, as they don't change the fingerprint of the HRA.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) retryUnmatchedJobs(ctx context.Context, name types.NamespacedName) error {
+	if autoscaler.UnmatchedJobs == nil {
+		return nil
+	}
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := autoscaler.Client.Get(ctx, name, &hra); kerrors.IsNotFound(err) {
+		autoscaler.UnmatchedJobs.setRetriedFor(name, "")
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	fingerprint, err := autoscaler.hraFingerprint(ctx, hra)
+	if err != nil {
+		return err
+	}
+
+	if last, ok := autoscaler.UnmatchedJobs.retriedFor(name); ok && last == fingerprint {
+		return nil
+	}
+
+	log := autoscaler.Log.WithValues("horizontalrunnerautoscaler", name)
+
+	for _, job := range autoscaler.UnmatchedJobs.List() {
+		jobLog := log.WithValues("workflowJob.id", job.ID, "repository", job.Repository, "labels", job.Labels)
+		jobCtx := withWebhookSourceNamespaces(withGitHubEnterpriseHost(ctx, job.enterpriseHost), job.namespaces)
+
+		target, err := autoscaler.getJobScaleUpTargetForRepoOrOrg(jobCtx, jobLog, job.repositoryName, job.owner, job.ownerType, job.Enterprise, job.Labels)
+		if err != nil {
+			return err
+		}
+
+		if target == nil {
+			continue
+		}
+
+		target.repository = job.Repository
+		target.Amount = workflowJobAmount(target.HorizontalRunnerAutoscaler, job.Labels)
+		target.idempotencyKey = fmt.Sprintf("workflow_job/%d", job.ID)
+
+		target, err = autoscaler.selectRunnerPoolMember(jobCtx, jobLog, target, job.Labels)
+		if err != nil {
+			return err
+		}
+
+		if err := autoscaler.tryScale(jobCtx, target); err != nil {
+			return err
+		}
+
+		autoscaler.UnmatchedJobs.remove(job.ID)
+
+		msg := fmt.Sprintf("Scaled by %d for workflow job %d of %s queued at %s before this scale target was found", target.Amount, job.ID, job.Repository, job.QueuedAt.Format(time.RFC3339))
+
+		jobLog.Info(msg, "scaleTarget", types.NamespacedName{Namespace: target.Namespace, Name: target.Name})
+
+		if !autoscaler.dryRun() && autoscaler.Recorder != nil {
+			autoscaler.Recorder.Event(&target.HorizontalRunnerAutoscaler, corev1.EventTypeNormal, "UnmatchedJobRetried", msg)
+		}
+	}
+
+	autoscaler.UnmatchedJobs.setRetriedFor(name, fingerprint)
+
+	return nil
+}
+
+// hraFingerprint changes whenever the HRA or its scale target changes in a way that can make the HRA match
+// a workflow job it didn't, like the scaleUpTriggers of the HRA, the repositories annotation, and the runner labels.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) hraFingerprint(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (string, error) {
+	ref := hra.Spec.ScaleTargetRef
+
+	var target client.Object
+
+	switch ref.Kind {
+	case "RunnerSet":
+		target = &v1alpha1.RunnerSet{}
+	case "RunnerDeployment", "":
+		target = &v1alpha1.RunnerDeployment{}
+	default:
+		return "", fmt.Errorf("unsupported scaleTargetRef.kind: %v", ref.Kind)
+	}
+
+	var targetGeneration int64
+
+	if err := autoscaler.Client.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: ref.Name}, target); err == nil {
+		targetGeneration = target.GetGeneration()
+	} else if !kerrors.IsNotFound(err) {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%d/%s/%s/%d/%s", hra.UID, hra.Generation, ref.Kind, ref.Name, targetGeneration, hra.Annotations[AnnotationKeyRepositories]), nil
+}
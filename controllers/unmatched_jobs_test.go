@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestUnmatchedJobs_Handle(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	unmatched := NewUnmatchedJobs(10)
+	unmatched.now = func() time.Time { return now }
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client:        fake.NewFakeClientWithScheme(sc),
+		UnmatchedJobs: unmatched,
+	}
+	installTestLogger(webhook)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", webhook.Handle)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	send := func(action string) {
+		t.Helper()
+
+		res, err := sendWebhook(server, "workflow_job", &github.WorkflowJobEvent{
+			WorkflowJob: &github.WorkflowJob{
+				ID:        github.Int64(1),
+				RunID:     github.Int64(2),
+				Name:      github.String("build"),
+				Labels:    []string{"self-hosted", "gpu"},
+				StartedAt: &github.Timestamp{Time: now.Add(-time.Minute)},
+			},
+			Action: github.String(action),
+			Repo: &github.Repository{
+				Name:     github.String("repo"),
+				FullName: github.String("owner/repo"),
+				Owner: &github.User{
+					Login: github.String("owner"),
+					Type:  github.String("Organization"),
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	send("queued")
+
+	want := []UnmatchedJob{
+		{
+			ID:         1,
+			RunID:      2,
+			Name:       "build",
+			Repository: "owner/repo",
+			Labels:     []string{"self-hosted", "gpu"},
+			QueuedAt:   now.Add(-time.Minute),
+			ReceivedAt: now,
+		},
+	}
+
+	if d := cmp.Diff(want, unmatched.List(), cmpopts.IgnoreUnexported(UnmatchedJob{})); d != "" {
+		t.Errorf("unexpected unmatched jobs (-want +got):\n%s", d)
+	}
+
+	// The redelivered event doesn't duplicate the job
+	send("queued")
+
+	if got := unmatched.List(); len(got) != 1 {
+		t.Errorf("expected 1 unmatched job after the redelivery, got %+v", got)
+	}
+
+	send("completed")
+
+	if got := unmatched.List(); len(got) != 0 {
+		t.Errorf("expected the completed job to be forgotten, got %+v", got)
+	}
+}
+
+func TestUnmatchedJobs_Evict(t *testing.T) {
+	unmatched := NewUnmatchedJobs(2)
+
+	for id := int64(1); id <= 3; id++ {
+		unmatched.add(&github.WorkflowJobEvent{WorkflowJob: &github.WorkflowJob{ID: github.Int64(id)}}, "", "", "", nil)
+	}
+
+	var ids []int64
+	for _, j := range unmatched.List() {
+		ids = append(ids, j.ID)
+	}
+
+	if d := cmp.Diff([]int64{2, 3}, ids); d != "" {
+		t.Errorf("unexpected unmatched jobs (-want +got):\n%s", d)
+	}
+}
+
+func TestUnmatchedJobs_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	client := fake.NewFakeClientWithScheme(sc)
+
+	unmatched := NewUnmatchedJobs(10)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client:        client,
+		Recorder:      record.NewFakeRecorder(10),
+		UnmatchedJobs: unmatched,
+	}
+	installTestLogger(webhook)
+
+	unmatched.add(&github.WorkflowJobEvent{
+		WorkflowJob: &github.WorkflowJob{
+			ID:     github.Int64(1),
+			Labels: []string{"self-hosted", "gpu"},
+		},
+		Repo: &github.Repository{
+			Name:     github.String("repo"),
+			FullName: github.String("owner/repo"),
+			Owner: &github.User{
+				Login: github.String("owner"),
+				Type:  github.String("Organization"),
+			},
+		},
+	}, "", "", "", nil)
+
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gpu"},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{Name: "gpu"},
+		},
+	}
+
+	rd := &actionsv1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gpu"},
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{
+			Template: actionsv1alpha1.RunnerTemplate{
+				Spec: actionsv1alpha1.RunnerSpec{
+					RunnerConfig: actionsv1alpha1.RunnerConfig{
+						Organization: "owner",
+						Labels:       []string{"linux"},
+					},
+				},
+			},
+		},
+	}
+
+	if err := client.Create(ctx, hra); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Create(ctx, rd); err != nil {
+		t.Fatal(err)
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "gpu"}
+
+	reconcileAndGetReservations := func() []actionsv1alpha1.CapacityReservation {
+		t.Helper()
+
+		if _, err := webhook.Reconcile(ctx, reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+
+		var got actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got.Spec.CapacityReservations
+	}
+
+	// The runners lack the gpu label
+	if rs := reconcileAndGetReservations(); len(rs) != 0 {
+		t.Fatalf("expected no capacity reservation for the job the runners don't match, got %+v", rs)
+	}
+
+	if err := client.Get(ctx, key, rd); err != nil {
+		t.Fatal(err)
+	}
+
+	rd.Spec.Template.Spec.Labels = []string{"gpu"}
+	rd.Generation++
+
+	if err := client.Update(ctx, rd); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := reconcileAndGetReservations()
+	if len(rs) != 1 || rs[0].IdempotencyKey != "workflow_job/1" || rs[0].Replicas != 1 {
+		t.Fatalf("unexpected capacity reservations after the runners got the gpu label: %+v", rs)
+	}
+
+	if got := unmatched.List(); len(got) != 0 {
+		t.Errorf("expected the retried job to be forgotten, got %+v", got)
+	}
+}
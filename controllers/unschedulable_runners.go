@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	DefaultUnschedulableLimitDuration = 5 * time.Minute
+
+	unschedulableScaleTargetKey = "unschedulableScaleTarget"
+)
+
+// podUnschedulable returns true when the pod is Pending because the scheduler found no node for it.
+// preempting is true when the scheduler has nominated a node for the pod, where lower-priority pods are being preempted.
+func podUnschedulable(pod corev1.Pod) (unschedulable, preempting bool) {
+	if pod.Status.Phase != corev1.PodPending || !pod.DeletionTimestamp.IsZero() {
+		return false, false
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+			return true, pod.Status.NominatedNodeName != ""
+		}
+	}
+
+	return false, false
+}
+
+// countUnschedulablePods returns the number of the unschedulable pods, and the number of them waiting for preemption.
+func countUnschedulablePods(pods []corev1.Pod) (unschedulable, preempting int) {
+	for _, pod := range pods {
+		u, p := podUnschedulable(pod)
+
+		if u {
+			unschedulable++
+		}
+
+		if p {
+			preempting++
+		}
+	}
+
+	return unschedulable, preempting
+}
+
+// unschedulableRunnerNames returns the names of the runners whose pods are unschedulable.
+// A runner pod is named after its runner.
+func unschedulableRunnerNames(pods []corev1.Pod) map[string]bool {
+	names := map[string]bool{}
+
+	for _, pod := range pods {
+		if unschedulable, _ := podUnschedulable(pod); unschedulable {
+			names[pod.Name] = true
+		}
+	}
+
+	return names
+}
+
+// getRunnerPods returns the pods of the runners that aren't ready yet, which are the only ones that can be unschedulable.
+func (r *RunnerReplicaSetReconciler) getRunnerPods(ctx context.Context, runners []v1alpha1.Runner) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+
+	for _, runner := range runners {
+		if runner.Status.Ready {
+			continue
+		}
+
+		var pod corev1.Pod
+
+		if err := r.Get(ctx, types.NamespacedName{Namespace: runner.Namespace, Name: runner.Name}, &pod); kerrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// runnerReplicaSetForPod maps the runner pod to the runner replica set of the runner,
+// so that the status of the runner replica set follows the scheduling of the pod.
+func (r *RunnerReplicaSetReconciler) runnerReplicaSetForPod(obj client.Object) []reconcile.Request {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil || owner.Kind != "Runner" {
+		return nil
+	}
+
+	var runner v1alpha1.Runner
+
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: obj.GetNamespace(), Name: owner.Name}, &runner); err != nil {
+		return nil
+	}
+
+	rsOwner := metav1.GetControllerOf(&runner)
+	if rsOwner == nil || rsOwner.Kind != "RunnerReplicaSet" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: runner.Namespace, Name: rsOwner.Name}}}
+}
+
+// suspendScaleUpWhileUnschedulable keeps the desired replicas at the current replicas of the scale target
+// while any of its runner pods is unschedulable, when the HRA opts in.
+// It returns the desired replicas and the number of the unschedulable runner pods.
+func suspendScaleUpWhileUnschedulable(hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, desiredReplicas int) (int, int, error) {
+	if !hra.Spec.SuspendScaleUpWhileUnschedulable || st.getUnschedulableReplicas == nil {
+		return desiredReplicas, 0, nil
+	}
+
+	unschedulable, err := st.getUnschedulableReplicas()
+	if err != nil {
+		return desiredReplicas, 0, err
+	}
+
+	current := getIntOrDefault(st.replicas, defaultReplicas)
+
+	if unschedulable > 0 && desiredReplicas > current {
+		return current, unschedulable, nil
+	}
+
+	return desiredReplicas, unschedulable, nil
+}
+
+// limitWhileUnschedulable caps the desired replicas at the replicas the cluster can run, which are the current replicas
+// of the scale target minus the unschedulable ones, when the HRA opts in.
+// The cap is kept until it expires even after the unschedulable pods are removed by it, so that the scale up is retried
+// only once per duration rather than on every reconciliation.
+// It returns the desired replicas, the cap if any, and the number of the unschedulable runner pods.
+func limitWhileUnschedulable(hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, desiredReplicas int, now time.Time) (int, *v1alpha1.UnschedulableLimitStatus, int, error) {
+	spec := hra.Spec.LimitWhileUnschedulable
+	if spec == nil || st.getUnschedulableReplicas == nil {
+		return desiredReplicas, nil, 0, nil
+	}
+
+	unschedulable, err := st.getUnschedulableReplicas()
+	if err != nil {
+		return desiredReplicas, nil, 0, err
+	}
+
+	limit := hra.Status.UnschedulableLimit
+	if limit != nil && !limit.ExpirationTime.After(now) {
+		limit = nil
+	}
+
+	// The cap isn't lowered while it's in effect, because the runner pods keep being counted as unschedulable
+	// until the ones beyond the cap are deleted.
+	if unschedulable > 0 && limit == nil {
+		achievable := getIntOrDefault(st.replicas, defaultReplicas) - unschedulable
+		if achievable < 0 {
+			achievable = 0
+		}
+
+		duration := DefaultUnschedulableLimitDuration
+		if spec.Duration != nil && spec.Duration.Duration > 0 {
+			duration = spec.Duration.Duration
+		}
+
+		limit = &v1alpha1.UnschedulableLimitStatus{
+			Replicas:       achievable,
+			ExpirationTime: metav1.Time{Time: now.Add(duration)},
+		}
+	}
+
+	if limit != nil && desiredReplicas > limit.Replicas {
+		return limit.Replicas, limit, unschedulable, nil
+	}
+
+	return desiredReplicas, limit, unschedulable, nil
+}
+
+// setLimitedCondition sets the Limited condition of the HRA to tell whether the desired replicas are capped by
+// limitWhileUnschedulable or a runner quota, and removes it when the HRA doesn't opt in and no runner quota covers the scale target.
+func setLimitedCondition(hra *v1alpha1.HorizontalRunnerAutoscaler, limit *v1alpha1.UnschedulableLimitStatus, quota runnerQuotaResult) {
+	if hra.Spec.LimitWhileUnschedulable == nil && !quota.covered {
+		meta.RemoveStatusCondition(&hra.Status.Conditions, v1alpha1.HorizontalRunnerAutoscalerConditionTypeLimited)
+
+		return
+	}
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.HorizontalRunnerAutoscalerConditionTypeLimited,
+		Status:             metav1.ConditionFalse,
+		Reason:             v1alpha1.HorizontalRunnerAutoscalerConditionReasonRunnerPodsSchedulable,
+		Message:            "The desired replicas aren't limited by unschedulable runner pods",
+		ObservedGeneration: hra.Generation,
+	}
+
+	if hra.Spec.LimitWhileUnschedulable == nil {
+		cond.Reason = v1alpha1.HorizontalRunnerAutoscalerConditionReasonWithinRunnerQuota
+		cond.Message = quota.message()
+	}
+
+	switch {
+	case quota.limited():
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = v1alpha1.HorizontalRunnerAutoscalerConditionReasonRunnerQuotaExceeded
+		cond.Message = quota.message()
+	case limit != nil:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = v1alpha1.HorizontalRunnerAutoscalerConditionReasonRunnerPodsUnschedulable
+		cond.Message = fmt.Sprintf("Capped desired replicas at %d, the replicas the cluster could run, until %s because runner pods were unschedulable",
+			limit.Replicas, limit.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+
+	meta.SetStatusCondition(&hra.Status.Conditions, cond)
+}
+
+// horizontalRunnerAutoscalersForUnschedulablePod maps the unschedulable runner pod to the HRAs of its scale target,
+// so that the desired replicas are limited as soon as the pod is found unschedulable.
+func (r *HorizontalRunnerAutoscalerReconciler) horizontalRunnerAutoscalersForUnschedulablePod(obj client.Object) []reconcile.Request {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+
+	if unschedulable, _ := podUnschedulable(*pod); !unschedulable {
+		return nil
+	}
+
+	var kind, name string
+
+	if v := pod.Labels[LabelKeyRunnerDeploymentName]; v != "" {
+		kind, name = "RunnerDeployment", v
+	} else if v := pod.Labels[LabelKeyRunnerSetName]; v != "" {
+		kind, name = "RunnerSet", v
+	} else {
+		return nil
+	}
+
+	// Only the HRAs that opt in to the unschedulable runner handling are indexed,
+	// so that this is a lookup that finds nothing rather than a list of all the HRAs when no HRA opts in.
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+
+	if err := r.List(context.Background(), &hras, client.InNamespace(pod.Namespace), client.MatchingFields{unschedulableScaleTargetKey: kind + "/" + name}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, hra := range hras.Items {
+		// The index is consulted in the first place, but we check it here too to be sure
+		if v := unschedulableScaleTarget(hra); len(v) == 0 || v[0] != kind+"/"+name {
+			continue
+		}
+
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}})
+	}
+
+	return reqs
+}
+
+// unschedulableScaleTarget returns the KIND/NAME of the scale target of the HRA for the unschedulableScaleTargetKey index,
+// or nothing when the HRA uses neither limitWhileUnschedulable nor suspendScaleUpWhileUnschedulable.
+func unschedulableScaleTarget(hra v1alpha1.HorizontalRunnerAutoscaler) []string {
+	if hra.Spec.LimitWhileUnschedulable == nil && !hra.Spec.SuspendScaleUpWhileUnschedulable {
+		return nil
+	}
+
+	kind := hra.Spec.ScaleTargetRef.Kind
+	if kind == "" {
+		kind = "RunnerDeployment"
+	}
+
+	return []string{kind + "/" + hra.Spec.ScaleTargetRef.Name}
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func newPendingPod(name string, reason, nominatedNodeName string) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodPending,
+			NominatedNodeName: nominatedNodeName,
+		},
+	}
+
+	if reason != "" {
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: reason},
+		}
+	}
+
+	return pod
+}
+
+func TestCountUnschedulablePods(t *testing.T) {
+	running := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+
+	pods := []corev1.Pod{
+		running,
+		newPendingPod("pulling", "", ""),
+		newPendingPod("unschedulable", corev1.PodReasonUnschedulable, ""),
+		newPendingPod("preempting", corev1.PodReasonUnschedulable, "node1"),
+		newPendingPod("gated", "SchedulingGated", ""),
+	}
+
+	unschedulable, preempting := countUnschedulablePods(pods)
+
+	if unschedulable != 2 || preempting != 1 {
+		t.Errorf("unexpected counts: unschedulable=%d, preempting=%d", unschedulable, preempting)
+	}
+
+	if d := cmp.Diff(map[string]bool{"unschedulable": true, "preempting": true}, unschedulableRunnerNames(pods)); d != "" {
+		t.Errorf("unexpected unschedulable runners: %s", d)
+	}
+}
+
+func TestSuspendScaleUpWhileUnschedulable(t *testing.T) {
+	newST := func(replicas, unschedulable int) scaleTarget {
+		return scaleTarget{
+			replicas: &replicas,
+			getUnschedulableReplicas: func() (int, error) {
+				return unschedulable, nil
+			},
+		}
+	}
+
+	testcases := []struct {
+		name          string
+		enabled       bool
+		st            scaleTarget
+		desired       int
+		want          int
+		unschedulable int
+	}{
+		{name: "disabled", enabled: false, st: newST(3, 2), desired: 5, want: 5, unschedulable: 0},
+		{name: "scale up suspended", enabled: true, st: newST(3, 2), desired: 5, want: 3, unschedulable: 2},
+		{name: "scale down allowed", enabled: true, st: newST(3, 2), desired: 1, want: 1, unschedulable: 2},
+		{name: "all scheduled", enabled: true, st: newST(3, 0), desired: 5, want: 5, unschedulable: 0},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{SuspendScaleUpWhileUnschedulable: tc.enabled},
+			}
+
+			got, unschedulable, err := suspendScaleUpWhileUnschedulable(hra, tc.st, tc.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want || unschedulable != tc.unschedulable {
+				t.Errorf("unexpected result: want %d replicas with %d unschedulable, got %d with %d", tc.want, tc.unschedulable, got, unschedulable)
+			}
+		})
+	}
+}
+
+func TestRunnerReplicaSetForPod(t *testing.T) {
+	controller := true
+
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-abcde",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "RunnerReplicaSet", Name: "example-xyz", Controller: &controller},
+			},
+		},
+	}
+
+	r := &RunnerReplicaSetReconciler{Client: fake.NewFakeClientWithScheme(sc, runner)}
+
+	pod := newPendingPod("example-abcde", corev1.PodReasonUnschedulable, "")
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{Kind: "Runner", Name: "example-abcde", Controller: &controller},
+	}
+
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "example-xyz"}}}
+
+	if d := cmp.Diff(want, r.runnerReplicaSetForPod(&pod)); d != "" {
+		t.Errorf("unexpected requests: %s", d)
+	}
+
+	orphan := newPendingPod("orphan", "", "")
+
+	if reqs := r.runnerReplicaSetForPod(&orphan); len(reqs) != 0 {
+		t.Errorf("expected no requests for the pod without a runner, got %v", reqs)
+	}
+}
+
+func TestLimitWhileUnschedulable(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newST := func(replicas, unschedulable int) scaleTarget {
+		return scaleTarget{
+			replicas: &replicas,
+			getUnschedulableReplicas: func() (int, error) {
+				return unschedulable, nil
+			},
+		}
+	}
+
+	newLimit := func(replicas int, expiresIn time.Duration) *v1alpha1.UnschedulableLimitStatus {
+		return &v1alpha1.UnschedulableLimitStatus{Replicas: replicas, ExpirationTime: metav1.Time{Time: now.Add(expiresIn)}}
+	}
+
+	testcases := []struct {
+		name      string
+		spec      *v1alpha1.LimitWhileUnschedulableSpec
+		prev      *v1alpha1.UnschedulableLimitStatus
+		st        scaleTarget
+		desired   int
+		want      int
+		wantLimit *v1alpha1.UnschedulableLimitStatus
+	}{
+		{
+			name:    "disabled",
+			st:      newST(5, 2),
+			desired: 8,
+			want:    8,
+		},
+		{
+			name:    "all scheduled",
+			spec:    &v1alpha1.LimitWhileUnschedulableSpec{},
+			st:      newST(5, 0),
+			desired: 8,
+			want:    8,
+		},
+		{
+			name:      "capped at the achievable replicas",
+			spec:      &v1alpha1.LimitWhileUnschedulableSpec{},
+			st:        newST(5, 2),
+			desired:   8,
+			want:      3,
+			wantLimit: newLimit(3, DefaultUnschedulableLimitDuration),
+		},
+		{
+			name:      "custom duration",
+			spec:      &v1alpha1.LimitWhileUnschedulableSpec{Duration: &metav1.Duration{Duration: time.Minute}},
+			st:        newST(5, 5),
+			desired:   8,
+			want:      0,
+			wantLimit: newLimit(0, time.Minute),
+		},
+		{
+			name:      "scale down below the cap",
+			spec:      &v1alpha1.LimitWhileUnschedulableSpec{},
+			st:        newST(5, 2),
+			desired:   1,
+			want:      1,
+			wantLimit: newLimit(3, DefaultUnschedulableLimitDuration),
+		},
+		{
+			name:      "kept after the unschedulable pods are removed",
+			spec:      &v1alpha1.LimitWhileUnschedulableSpec{},
+			prev:      newLimit(3, time.Minute),
+			st:        newST(3, 0),
+			desired:   8,
+			want:      3,
+			wantLimit: newLimit(3, time.Minute),
+		},
+		{
+			name:      "not lowered while the pods beyond the cap are being deleted",
+			spec:      &v1alpha1.LimitWhileUnschedulableSpec{},
+			prev:      newLimit(3, time.Minute),
+			st:        newST(3, 2),
+			desired:   8,
+			want:      3,
+			wantLimit: newLimit(3, time.Minute),
+		},
+		{
+			name:    "lifted on expiry",
+			spec:    &v1alpha1.LimitWhileUnschedulableSpec{},
+			prev:    newLimit(3, 0),
+			st:      newST(3, 0),
+			desired: 8,
+			want:    8,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec:   v1alpha1.HorizontalRunnerAutoscalerSpec{LimitWhileUnschedulable: tc.spec},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{UnschedulableLimit: tc.prev},
+			}
+
+			got, limit, _, err := limitWhileUnschedulable(hra, tc.st, tc.desired, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected desired replicas: want %d, got %d", tc.want, got)
+			}
+
+			if d := cmp.Diff(tc.wantLimit, limit); d != "" {
+				t.Errorf("unexpected limit: %s", d)
+			}
+
+			setLimitedCondition(&hra, limit, runnerQuotaResult{})
+
+			cond := meta.FindStatusCondition(hra.Status.Conditions, v1alpha1.HorizontalRunnerAutoscalerConditionTypeLimited)
+
+			switch {
+			case tc.spec == nil:
+				if cond != nil {
+					t.Errorf("unexpected condition: %v", cond)
+				}
+			case limit != nil:
+				if cond == nil || cond.Status != metav1.ConditionTrue {
+					t.Errorf("expected Limited=True, got %v", cond)
+				}
+			default:
+				if cond == nil || cond.Status != metav1.ConditionFalse {
+					t.Errorf("expected Limited=False, got %v", cond)
+				}
+			}
+		})
+	}
+}
+
+func TestHorizontalRunnerAutoscalersForUnschedulablePod(t *testing.T) {
+	newHRA := func(name, kind, target string, limit bool) *v1alpha1.HorizontalRunnerAutoscaler {
+		hra := newRoutedHRA("default", name, kind, target)
+		if limit {
+			hra.Spec.LimitWhileUnschedulable = &v1alpha1.LimitWhileUnschedulableSpec{}
+		}
+		return hra
+	}
+
+	r := &HorizontalRunnerAutoscalerReconciler{Client: fake.NewFakeClientWithScheme(sc,
+		newHRA("rd", "", "example", true),
+		newHRA("rs", "RunnerSet", "example", true),
+		newHRA("disabled", "", "example", false),
+	)}
+
+	rdPod := newPendingPod("example-abcde", corev1.PodReasonUnschedulable, "")
+	rdPod.Labels = map[string]string{LabelKeyRunnerDeploymentName: "example"}
+
+	rsPod := newPendingPod("example-0", corev1.PodReasonUnschedulable, "")
+	rsPod.Labels = map[string]string{LabelKeyRunnerSetName: "example"}
+
+	scheduled := newPendingPod("example-fghij", "", "")
+	scheduled.Labels = map[string]string{LabelKeyRunnerDeploymentName: "example"}
+
+	testcases := []struct {
+		name string
+		pod  corev1.Pod
+		want []reconcile.Request
+	}{
+		{name: "runner deployment", pod: rdPod, want: []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "rd"}}}},
+		{name: "runner set", pod: rsPod, want: []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "rs"}}}},
+		{name: "schedulable", pod: scheduled, want: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if d := cmp.Diff(tc.want, r.horizontalRunnerAutoscalersForUnschedulablePod(&tc.pod)); d != "" {
+				t.Errorf("unexpected requests: %s", d)
+			}
+		})
+	}
+}
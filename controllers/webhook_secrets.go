@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultWebhookSecretsFileReloadInterval is how often WebhookSecretsFile checks the file for changes.
+// Kubernetes propagates the change of a mounted secret with a delay of up to a minute, so there's no point in
+// checking much more often than this.
+const DefaultWebhookSecretsFileReloadInterval = 10 * time.Second
+
+// WebhookSecretsFile provides the webhook secret tokens read from a file, usually a mounted Kubernetes secret,
+// that contains one token per line. Empty lines and lines starting with # are ignored.
+//
+// Put the new token next to the current one in the file, update the webhook on GitHub, and then remove the old token,
+// so that you can rotate the token without rejecting any webhook delivery in the meantime.
+// The file is periodically re-read while the manager is running, so no restart is required.
+type WebhookSecretsFile struct {
+	Path           string
+	ReloadInterval time.Duration
+	Log            logr.Logger
+
+	mu      sync.RWMutex
+	content []byte
+	keys    [][]byte
+}
+
+// NewWebhookSecretsFile returns a WebhookSecretsFile that is loaded from the path.
+// It fails when the file can't be read, so that the misconfiguration is noticed on startup.
+func NewWebhookSecretsFile(path string, log logr.Logger) (*WebhookSecretsFile, error) {
+	f := &WebhookSecretsFile{
+		Path:           path,
+		ReloadInterval: DefaultWebhookSecretsFileReloadInterval,
+		Log:            log,
+	}
+
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Keys returns the webhook secret tokens that are currently in the file.
+func (f *WebhookSecretsFile) Keys() [][]byte {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.keys
+}
+
+// Reload re-reads the file and returns true when its content has changed.
+func (f *WebhookSecretsFile) Reload() (bool, error) {
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.content != nil && bytes.Equal(f.content, content) {
+		return false, nil
+	}
+
+	f.content = content
+	f.keys = parseWebhookSecrets(content)
+
+	return true, nil
+}
+
+// Start implements manager.Runnable to reload the file until the context is done.
+// A failed reload keeps the previously loaded tokens, because the file can be missing for a moment
+// while Kubernetes updates the mounted secret.
+func (f *WebhookSecretsFile) Start(ctx context.Context) error {
+	interval := f.ReloadInterval
+	if interval <= 0 {
+		interval = DefaultWebhookSecretsFileReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			changed, err := f.Reload()
+			if err != nil {
+				f.Log.Error(err, "Failed to reload webhook secrets file. Keeping the previously loaded secrets", "path", f.Path)
+				continue
+			}
+
+			if changed {
+				f.Log.Info("Reloaded webhook secrets file", "path", f.Path, "secrets", len(f.Keys()))
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that every replica of the webhook server,
+// not only the leader, keeps its tokens up to date.
+func (f *WebhookSecretsFile) NeedLeaderElection() bool {
+	return false
+}
+
+func parseWebhookSecrets(content []byte) [][]byte {
+	var keys [][]byte
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keys = append(keys, []byte(line))
+	}
+
+	return keys
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestValidatePayloadWithAnyOf(t *testing.T) {
+	body := `{"zen":"Keep it logically awesome."}`
+
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	testcases := []struct {
+		name    string
+		signer  string
+		secrets []string
+		wantErr bool
+	}{
+		{name: "current secret", signer: "new", secrets: []string{"new", "old"}},
+		{name: "previous secret", signer: "old", secrets: []string{"new", "old"}},
+		{name: "unknown secret", signer: "other", secrets: []string{"new", "old"}, wantErr: true},
+		{name: "no secret", signer: "new", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Hub-Signature-256", sign(tc.signer))
+
+			var keys [][]byte
+			for _, s := range tc.secrets {
+				keys = append(keys, []byte(s))
+			}
+
+			payload, err := validatePayloadWithAnyOf(req, keys)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(payload) != body {
+				t.Errorf("unexpected payload: want %q, got %q", body, string(payload))
+			}
+		})
+	}
+}
+
+func TestWebhookSecretsFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets")
+
+	if err := ioutil.WriteFile(path, []byte("# current\nnew\n\nold\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewWebhookSecretsFile(path, zap.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := f.Keys(); len(got) != 2 || string(got[0]) != "new" || string(got[1]) != "old" {
+		t.Errorf("unexpected keys: %q", got)
+	}
+
+	if changed, err := f.Reload(); err != nil || changed {
+		t.Errorf("expected no change, got changed=%v, err=%v", changed, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := f.Reload(); err != nil || !changed {
+		t.Errorf("expected change, got changed=%v, err=%v", changed, err)
+	}
+
+	if got := f.Keys(); len(got) != 1 || string(got[0]) != "new" {
+		t.Errorf("unexpected keys after reload: %q", got)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+const (
+	// DefaultWebhookSourceSecretKey is the key of the secret tokens in the Secret referenced by a WebhookSource
+	// when the WebhookSource doesn't specify one.
+	DefaultWebhookSourceSecretKey = "github_webhook_secret_token"
+
+	// webhookSourceResyncInterval is how often the Secret of each WebhookSource is re-read, so that the rotated tokens are
+	// accepted without watching, and caching, all the Secrets in the namespaces.
+	webhookSourceResyncInterval = time.Minute
+)
+
+// webhookSource is a WebhookSource loaded along with the secret tokens in its Secret.
+type webhookSource struct {
+	name          types.NamespacedName
+	hookIDs       []int64
+	organizations []string
+	namespaces    []string
+	keys          [][]byte
+}
+
+// WebhookSources are the WebhookSources that the webhook server validates the deliveries with.
+// They're kept up to date by WebhookSourceReconciler.
+type WebhookSources struct {
+	mu      sync.RWMutex
+	sources map[types.NamespacedName]webhookSource
+}
+
+// NewWebhookSources returns the empty WebhookSources.
+func NewWebhookSources() *WebhookSources {
+	return &WebhookSources{sources: map[types.NamespacedName]webhookSource{}}
+}
+
+func (s *WebhookSources) set(source webhookSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sources[source.name] = source
+}
+
+func (s *WebhookSources) delete(name types.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sources, name)
+}
+
+// find returns the WebhookSource for the webhook by the hook ID, or otherwise by the organization.
+// When multiple WebhookSources match, the first one by namespace and name is returned,
+// so that every replica of the webhook server picks the same one.
+func (s *WebhookSources) find(hookID, organization string) (*webhookSource, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var byHookID, byOrganization []webhookSource
+
+	id, _ := strconv.ParseInt(hookID, 10, 64)
+
+	for _, source := range s.sources {
+		for _, i := range source.hookIDs {
+			if id != 0 && i == id {
+				byHookID = append(byHookID, source)
+			}
+		}
+
+		for _, o := range source.organizations {
+			if organization != "" && strings.EqualFold(o, organization) {
+				byOrganization = append(byOrganization, source)
+			}
+		}
+	}
+
+	for _, sources := range [][]webhookSource{byHookID, byOrganization} {
+		if len(sources) == 0 {
+			continue
+		}
+
+		sort.Slice(sources, func(i, j int) bool {
+			return lessName(sources[i].name, sources[j].name)
+		})
+
+		return &sources[0], true
+	}
+
+	return nil, false
+}
+
+// findWebhookSource returns the WebhookSource for the webhook delivery, if any.
+// The organization is read from the payload before validating it, which is safe because the payload is then validated
+// with the secret tokens of the WebhookSource, and only them.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) findWebhookSource(r *http.Request) (*webhookSource, error) {
+	if autoscaler.Sources == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	source, _ := autoscaler.Sources.find(r.Header.Get("X-GitHub-Hook-ID"), webhookOrganization(body))
+
+	return source, nil
+}
+
+// webhookOrganization returns the organization of the webhook event, or the owner of the repository
+// for the events that don't have the organization, like the ones of the repositories owned by users.
+func webhookOrganization(payload []byte) string {
+	var event struct {
+		Organization struct {
+			Login string `json:"login,omitempty"`
+		} `json:"organization,omitempty"`
+		Repository struct {
+			Owner struct {
+				Login string `json:"login,omitempty"`
+			} `json:"owner,omitempty"`
+		} `json:"repository,omitempty"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return ""
+	}
+
+	if event.Organization.Login != "" {
+		return event.Organization.Login
+	}
+
+	return event.Repository.Owner.Login
+}
+
+// webhookSourceNamespaces returns the namespaces of the HRAs the WebhookSource scales, which default to its own namespace,
+// so that a WebhookSource created by a tenant can't scale the HRAs of the others unless it explicitly asks for "*".
+func webhookSourceNamespaces(ws v1alpha1.WebhookSource) []string {
+	if len(ws.Spec.Namespaces) == 0 {
+		return []string{ws.Namespace}
+	}
+
+	return ws.Spec.Namespaces
+}
+
+type webhookSourceNamespacesContextKey struct{}
+
+// withWebhookSourceNamespaces returns the context that carries the namespaces the WebhookSource of the webhook event
+// limits the scale targets to, down to the lookups of the scale targets.
+func withWebhookSourceNamespaces(ctx context.Context, namespaces []string) context.Context {
+	return context.WithValue(ctx, webhookSourceNamespacesContextKey{}, namespaces)
+}
+
+func webhookSourceNamespacesFrom(ctx context.Context) []string {
+	namespaces, _ := ctx.Value(webhookSourceNamespacesContextKey{}).([]string)
+
+	return namespaces
+}
+
+// matchesWebhookSourceNamespaces returns true if the HRA in the namespace can be scaled on the webhook event
+// whose WebhookSource is carried by the context. The events without WebhookSources can scale the HRAs in all the namespaces.
+func matchesWebhookSourceNamespaces(ctx context.Context, namespace string) bool {
+	namespaces := webhookSourceNamespacesFrom(ctx)
+	if len(namespaces) == 0 {
+		return true
+	}
+
+	for _, ns := range namespaces {
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookSourceReconciler loads the WebhookSources along with the secret tokens in their Secrets into Sources.
+type WebhookSourceReconciler struct {
+	client.Client
+	Log     logr.Logger
+	Name    string
+	Sources *WebhookSources
+
+	// SecretReader reads the Secrets of the WebhookSources without caching all the Secrets in the namespaces.
+	// Defaults to the Client.
+	SecretReader client.Reader
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=webhooksources,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+
+func (r *WebhookSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("webhooksource", req.NamespacedName)
+
+	var ws v1alpha1.WebhookSource
+	if err := r.Get(ctx, req.NamespacedName, &ws); kerrors.IsNotFound(err) {
+		r.Sources.delete(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !ws.DeletionTimestamp.IsZero() {
+		r.Sources.delete(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	source := webhookSource{
+		name:          req.NamespacedName,
+		hookIDs:       ws.Spec.HookIDs,
+		organizations: ws.Spec.Organizations,
+		namespaces:    webhookSourceNamespaces(ws),
+	}
+
+	key := ws.Spec.SecretRef.Key
+	if key == "" {
+		key = DefaultWebhookSourceSecretKey
+	}
+
+	reader := r.SecretReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	// The source is registered even without the tokens, so that its deliveries are rejected
+	// instead of being validated with the default tokens of the webhook server
+	var secret corev1.Secret
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: ws.Namespace, Name: ws.Spec.SecretRef.Name}, &secret); err != nil {
+		log.Error(err, "Failed to get the secret of the webhook source. Rejecting its webhook deliveries", "secret", ws.Spec.SecretRef.Name)
+	} else if source.keys = parseWebhookSecrets(secret.Data[key]); len(source.keys) == 0 {
+		log.Info("The secret of the webhook source has no secret token. Rejecting its webhook deliveries", "secret", ws.Spec.SecretRef.Name, "key", key)
+	}
+
+	r.Sources.set(source)
+
+	return ctrl.Result{RequeueAfter: webhookSourceResyncInterval}, nil
+}
+
+func (r *WebhookSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "webhooksource-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.WebhookSource{}).
+		Named(name).
+		Complete(r)
+}
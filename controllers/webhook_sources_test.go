@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestWebhookSourcesFind(t *testing.T) {
+	sources := NewWebhookSources()
+
+	for _, s := range []webhookSource{
+		{name: types.NamespacedName{Namespace: "team-a", Name: "org"}, organizations: []string{"team-a"}},
+		{name: types.NamespacedName{Namespace: "team-a", Name: "app"}, hookIDs: []int64{123}, organizations: []string{"team-a"}},
+		{name: types.NamespacedName{Namespace: "team-b", Name: "org"}, organizations: []string{"Team-B"}},
+		{name: types.NamespacedName{Namespace: "team-b", Name: "another"}, organizations: []string{"team-b"}},
+	} {
+		sources.set(s)
+	}
+
+	testcases := []struct {
+		name         string
+		hookID       string
+		organization string
+		want         string
+	}{
+		{name: "by hook ID", hookID: "123", organization: "team-b", want: "team-a/app"},
+		{name: "by organization", hookID: "456", organization: "team-a", want: "team-a/app"},
+		{name: "first one by name", organization: "TEAM-B", want: "team-b/another"},
+		{name: "no match", hookID: "456", organization: "team-c"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+
+			if s, ok := sources.find(tc.hookID, tc.organization); ok {
+				got = s.name.String()
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected source: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	var nilSources *WebhookSources
+	if _, ok := nilSources.find("123", "team-a"); ok {
+		t.Errorf("unexpected source found in nil sources")
+	}
+}
+
+func TestWebhookOrganization(t *testing.T) {
+	testcases := map[string]string{
+		`{"organization":{"login":"org"},"repository":{"owner":{"login":"owner"}}}`: "org",
+		`{"repository":{"owner":{"login":"user"}}}`:                                 "user",
+		`{"zen":"Keep it logically awesome."}`:                                      "",
+		`not json`:                                                                  "",
+	}
+
+	for payload, want := range testcases {
+		if got := webhookOrganization([]byte(payload)); got != want {
+			t.Errorf("unexpected organization of %s: want %q, got %q", payload, want, got)
+		}
+	}
+}
+
+func TestWebhookSourceReconciler(t *testing.T) {
+	ws := &v1alpha1.WebhookSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "default"},
+		Spec: v1alpha1.WebhookSourceSpec{
+			HookIDs:       []int64{123},
+			Organizations: []string{"team-a"},
+			SecretRef:     v1alpha1.WebhookSourceSecretRef{Name: "team-a-webhook"},
+			Namespaces:    []string{"team-a"},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a-webhook", Namespace: "default"},
+		Data: map[string][]byte{
+			DefaultWebhookSourceSecretKey: []byte("new\nold\n"),
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, ws, secret)
+
+	sources := NewWebhookSources()
+
+	r := &WebhookSourceReconciler{
+		Client:  client,
+		Log:     zap.New(),
+		Sources: sources,
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "team-a"}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := sources.find("123", "")
+	if !ok {
+		t.Fatalf("webhook source not found")
+	}
+
+	want := &webhookSource{
+		name:          key,
+		hookIDs:       []int64{123},
+		organizations: []string{"team-a"},
+		namespaces:    []string{"team-a"},
+		keys:          [][]byte{[]byte("new"), []byte("old")},
+	}
+
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(webhookSource{})); d != "" {
+		t.Errorf("unexpected webhook source (-want +got):\n%s", d)
+	}
+
+	// The source without the secret still matches the deliveries, so that they're rejected
+	if err := client.Delete(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := sources.find("", "team-a"); !ok || len(got.keys) != 0 {
+		t.Errorf("expected the webhook source without keys, got %v", got)
+	}
+
+	if err := client.Delete(context.Background(), ws); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := sources.find("123", "team-a"); ok {
+		t.Errorf("unexpected webhook source found after the deletion")
+	}
+}
+
+func TestMatchesWebhookSourceNamespaces(t *testing.T) {
+	ctx := context.Background()
+
+	if !matchesWebhookSourceNamespaces(ctx, "default") {
+		t.Errorf("expected any namespace to match without the webhook source")
+	}
+
+	ctx = withWebhookSourceNamespaces(ctx, []string{"team-a", "team-b"})
+
+	if !matchesWebhookSourceNamespaces(ctx, "team-b") {
+		t.Errorf("expected team-b to match")
+	}
+
+	if matchesWebhookSourceNamespaces(ctx, "default") {
+		t.Errorf("unexpected match of default")
+	}
+
+	ctx = withWebhookSourceNamespaces(context.Background(), []string{"*"})
+
+	if !matchesWebhookSourceNamespaces(ctx, "default") {
+		t.Errorf("expected any namespace to match the wildcard")
+	}
+}
+
+func TestWebhookSourceReconcilerDefaultsNamespaces(t *testing.T) {
+	ws := &v1alpha1.WebhookSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+		Spec: v1alpha1.WebhookSourceSpec{
+			HookIDs:   []int64{123},
+			SecretRef: v1alpha1.WebhookSourceSecretRef{Name: "team-a-webhook"},
+		},
+	}
+
+	sources := NewWebhookSources()
+
+	r := &WebhookSourceReconciler{
+		Client:  fake.NewFakeClientWithScheme(sc, ws),
+		Log:     zap.New(),
+		Sources: sources,
+	}
+
+	key := types.NamespacedName{Namespace: "team-a", Name: "team-a"}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := sources.find("123", "")
+	if !ok {
+		t.Fatalf("webhook source not found")
+	}
+
+	if d := cmp.Diff([]string{"team-a"}, got.namespaces); d != "" {
+		t.Errorf("unexpected namespaces (-want +got):\n%s", d)
+	}
+
+	ctx := withWebhookSourceNamespaces(context.Background(), got.namespaces)
+
+	if matchesWebhookSourceNamespaces(ctx, "default") {
+		t.Errorf("unexpected match of default")
+	}
+}
+
+func TestWebhookSourcesHandle(t *testing.T) {
+	sources := NewWebhookSources()
+	sources.set(webhookSource{
+		name:          types.NamespacedName{Namespace: "team-a", Name: "team-a"},
+		organizations: []string{"team-a"},
+		keys:          [][]byte{[]byte("team-a-secret")},
+	})
+
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client:         fake.NewFakeClientWithScheme(sc),
+		SecretKeyBytes: []byte("default-secret"),
+		Sources:        sources,
+	}
+	installTestLogger(hraWebhook)
+
+	server := httptest.NewServer(http.HandlerFunc(hraWebhook.Handle))
+	defer server.Close()
+
+	testcases := []struct {
+		name     string
+		org      string
+		secret   string
+		wantCode int
+	}{
+		{name: "signed with the source's secret", org: "team-a", secret: "team-a-secret", wantCode: 200},
+		{name: "signed with the default secret", org: "team-a", secret: "default-secret", wantCode: 500},
+		{name: "no source", org: "team-b", secret: "default-secret", wantCode: 200},
+		{name: "another source's secret", org: "team-b", secret: "team-a-secret", wantCode: 500},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := []byte(`{"zen":"zen","organization":{"login":"` + tc.org + `"}}`)
+
+			mac := hmac.New(sha256.New, []byte(tc.secret))
+			mac.Write(body)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-GitHub-Event", "ping")
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("unexpected status: want %d, got %d", tc.wantCode, resp.StatusCode)
+			}
+		})
+	}
+}
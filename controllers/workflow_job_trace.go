@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=workflowjobtraces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+
+// recordWorkflowJobTrace records the WorkflowJobTrace that links the workflow job to the runner pod that picked it up.
+// Jobs picked up by runners other than ours, like GitHub-hosted runners, are ignored.
+// Failures are only logged, because the trace is informational.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) recordWorkflowJobTrace(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent, runnerName string) {
+	job := e.GetWorkflowJob()
+
+	if job.GetID() == 0 || runnerName == "" {
+		return
+	}
+
+	pod, err := autoscaler.findRunnerPod(ctx, runnerName)
+	if err != nil {
+		log.Error(err, "Failed to find runner pod for recording workflow job trace", "runnerName", runnerName)
+
+		return
+	}
+
+	if pod == nil {
+		log.V(1).Info("Skipped recording workflow job trace for the runner without pod", "runnerName", runnerName)
+
+		return
+	}
+
+	trace := v1alpha1.WorkflowJobTrace{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      strconv.FormatInt(job.GetID(), 10),
+		},
+		Spec: v1alpha1.WorkflowJobTraceSpec{
+			JobID:            job.GetID(),
+			RunID:            job.GetRunID(),
+			Repository:       e.Repo.GetFullName(),
+			JobName:          job.GetName(),
+			HTMLURL:          job.GetHTMLURL(),
+			Labels:           job.Labels,
+			RunnerName:       runnerName,
+			PodUID:           pod.UID,
+			NodeName:         pod.Spec.NodeName,
+			RunnerDeployment: pod.Labels[LabelKeyRunnerDeploymentName],
+			RunnerSet:        pod.Labels[LabelKeyRunnerSetName],
+			ExpirationTime:   metav1.Time{Time: time.Now().Add(autoscaler.WorkflowJobTraceTTL)},
+		},
+	}
+
+	if job.StartedAt != nil {
+		trace.Spec.StartedAt = &metav1.Time{Time: job.StartedAt.Time}
+	}
+
+	if err := autoscaler.Client.Create(ctx, &trace); err != nil {
+		// GitHub may redeliver the event, in which case the trace is already recorded
+		if kerrors.IsAlreadyExists(err) {
+			return
+		}
+
+		log.Error(err, "Failed to record workflow job trace", "jobID", job.GetID(), "runnerName", runnerName)
+
+		return
+	}
+
+	log.V(1).Info("Recorded workflow job trace", "jobID", job.GetID(), "runnerName", runnerName, "namespace", pod.Namespace, "node", pod.Spec.NodeName)
+}
+
+// completeWorkflowJobTrace records the conclusion of the workflow job to the WorkflowJobTrace recorded for the job, if any.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) completeWorkflowJobTrace(ctx context.Context, log logr.Logger, e *gogithub.WorkflowJobEvent) {
+	job := e.GetWorkflowJob()
+
+	if job.GetID() == 0 {
+		return
+	}
+
+	name := strconv.FormatInt(job.GetID(), 10)
+
+	var opts []client.ListOption
+
+	if autoscaler.Namespace != "" {
+		opts = append(opts, client.InNamespace(autoscaler.Namespace))
+	}
+
+	var traces v1alpha1.WorkflowJobTraceList
+
+	if err := autoscaler.Client.List(ctx, &traces, opts...); err != nil {
+		log.Error(err, "Failed to list workflow job traces", "jobID", job.GetID())
+
+		return
+	}
+
+	for _, trace := range traces.Items {
+		if trace.Name != name {
+			continue
+		}
+
+		copy := trace.DeepCopy()
+		copy.Spec.Conclusion = job.GetConclusion()
+
+		if job.CompletedAt != nil {
+			copy.Spec.CompletedAt = &metav1.Time{Time: job.CompletedAt.Time}
+		}
+
+		if err := autoscaler.Client.Patch(ctx, copy, client.MergeFrom(&trace)); err != nil {
+			log.Error(err, "Failed to record the conclusion to workflow job trace", "jobID", job.GetID())
+		}
+
+		return
+	}
+}
+
+// findRunnerPod returns the pod of the runner, which has the same name as the runner, or nil if there's no such pod.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) findRunnerPod(ctx context.Context, runnerName string) (*corev1.Pod, error) {
+	reader := autoscaler.APIReader
+	if reader == nil {
+		reader = autoscaler.Client
+	}
+
+	// Listing pods across the namespaces requires the cluster-wide permission, so the pod is got from each namespace instead
+	if len(autoscaler.Namespaces) > 1 {
+		for _, ns := range autoscaler.Namespaces {
+			var pod corev1.Pod
+
+			err := reader.Get(ctx, types.NamespacedName{Namespace: ns, Name: runnerName}, &pod)
+			if err == nil {
+				return &pod, nil
+			}
+
+			if !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	opts := []client.ListOption{client.MatchingFields{"metadata.name": runnerName}}
+
+	if autoscaler.Namespace != "" {
+		opts = append(opts, client.InNamespace(autoscaler.Namespace))
+	}
+
+	var pods corev1.PodList
+
+	if err := reader.List(ctx, &pods, opts...); err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Name == runnerName {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// WorkflowJobTraceReconciler deletes WorkflowJobTraces once they expire.
+type WorkflowJobTraceReconciler struct {
+	client.Client
+	Log  logr.Logger
+	Name string
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+func (r *WorkflowJobTraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("workflowjobtrace", req.NamespacedName)
+
+	var trace v1alpha1.WorkflowJobTrace
+	if err := r.Get(ctx, req.NamespacedName, &trace); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !trace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	if remaining := trace.Spec.ExpirationTime.Sub(now); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err := r.Delete(ctx, &trace); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	log.V(1).Info("Deleted expired workflow job trace")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *WorkflowJobTraceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "workflowjobtrace-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.WorkflowJobTrace{}).
+		Named(name).
+		Complete(r)
+}
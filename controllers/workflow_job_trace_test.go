@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+func TestWorkflowJobTrace(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-runner-abcde",
+			Namespace: "runners",
+			UID:       "pod-uid",
+			Labels: map[string]string{
+				LabelKeyRunnerDeploymentName: "example-runner",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, pod)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client, WorkflowJobTraceTTL: time.Hour}
+	installTestLogger(webhook)
+
+	startedAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	e := &github.WorkflowJobEvent{
+		WorkflowJob: &github.WorkflowJob{
+			ID:        github.Int64(123456789),
+			RunID:     github.Int64(42),
+			Name:      github.String("build"),
+			Labels:    []string{"self-hosted"},
+			StartedAt: &github.Timestamp{Time: startedAt},
+		},
+		Repo: &github.Repository{FullName: github.String("example/myrepo")},
+	}
+
+	ctx := context.Background()
+
+	// Jobs run by runners without pods, like GitHub-hosted runners, are not recorded
+	webhook.recordWorkflowJobTrace(ctx, webhook.Log, e, "GitHub Actions 2")
+	webhook.recordWorkflowJobTrace(ctx, webhook.Log, e, "example-runner-abcde")
+	// Redelivered
+	webhook.recordWorkflowJobTrace(ctx, webhook.Log, e, "example-runner-abcde")
+
+	e.WorkflowJob.Conclusion = github.String("failure")
+	webhook.completeWorkflowJobTrace(ctx, webhook.Log, e)
+
+	key := types.NamespacedName{Namespace: "runners", Name: "123456789"}
+
+	var got actionsv1alpha1.WorkflowJobTrace
+	if err := client.Get(ctx, key, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	s := got.Spec
+	if s.JobID != 123456789 || s.RunID != 42 || s.Repository != "example/myrepo" || s.JobName != "build" ||
+		s.RunnerName != "example-runner-abcde" || s.PodUID != "pod-uid" || s.NodeName != "node-1" ||
+		s.RunnerDeployment != "example-runner" || s.Conclusion != "failure" || !s.StartedAt.Time.Equal(startedAt) {
+		t.Errorf("unexpected trace: %+v", s)
+	}
+
+	r := &WorkflowJobTraceReconciler{Client: client, Log: webhook.Log}
+
+	r.now = func() time.Time { return s.ExpirationTime.Add(-time.Minute) }
+
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("unexpected requeue after: want %s, got %s", time.Minute, res.RequeueAfter)
+	}
+
+	r.now = func() time.Time { return s.ExpirationTime.Add(time.Second) }
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Get(ctx, key, &got); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the expired trace to be deleted, got %v", err)
+	}
+}
+
+func TestFindRunnerPod_Namespaces(t *testing.T) {
+	newPod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+
+	client := fake.NewFakeClientWithScheme(sc, newPod("team-b", "example-runner-abcde"), newPod("unwatched", "example-runner-fghij"))
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: client, Namespaces: []string{"team-a", "team-b"}}
+
+	pod, err := webhook.findRunnerPod(context.Background(), "example-runner-abcde")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pod == nil || pod.Namespace != "team-b" {
+		t.Errorf("expected the pod in team-b, got %v", pod)
+	}
+
+	pod, err = webhook.findRunnerPod(context.Background(), "example-runner-fghij")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pod != nil {
+		t.Errorf("expected the pod in the unwatched namespace to be ignored, got %s/%s", pod.Namespace, pod.Name)
+	}
+}
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderGitHubEnterpriseVersion is the response header that tells the version of GitHub Enterprise Server,
+// like "3.2.5". It's missing in the responses of github.com.
+const HeaderGitHubEnterpriseVersion = "X-GitHub-Enterprise-Version"
+
+// capabilitiesRedetectInterval is how long the failed detection is cached before it's retried,
+// so that a server that doesn't respond to the probe isn't probed on every API call.
+const capabilitiesRedetectInterval = 10 * time.Minute
+
+// Capability is a feature of GitHub that some versions of GitHub Enterprise Server lack.
+type Capability string
+
+const (
+	// CapabilityRunnerGroups is the API of the organization and enterprise runner groups.
+	CapabilityRunnerGroups Capability = "runner groups"
+	// CapabilityEphemeralRunners is the --ephemeral option of the runner registration.
+	CapabilityEphemeralRunners Capability = "ephemeral runners"
+	// CapabilityWorkflowJobEvents is the workflow_job webhook event.
+	CapabilityWorkflowJobEvents Capability = "workflow_job events"
+)
+
+// MinimumGHESVersions are the earliest versions of GitHub Enterprise Server that support the capabilities.
+// github.com supports all of them.
+var MinimumGHESVersions = map[Capability]string{
+	CapabilityRunnerGroups:      "3.0",
+	CapabilityEphemeralRunners:  "3.3",
+	CapabilityWorkflowJobEvents: "3.3",
+}
+
+// Capabilities tells what the GitHub server that the client calls supports.
+type Capabilities struct {
+	// GHESVersion is the version of GitHub Enterprise Server, or empty for github.com.
+	GHESVersion string
+}
+
+// Supports returns an UnsupportedError when the GitHub server lacks the capability.
+func (c Capabilities) Supports(capability Capability) error {
+	if c.GHESVersion == "" {
+		return nil
+	}
+
+	min, ok := MinimumGHESVersions[capability]
+	if !ok || !versionLess(c.GHESVersion, min) {
+		return nil
+	}
+
+	return &UnsupportedError{Capability: capability, GHESVersion: c.GHESVersion, MinimumGHESVersion: min}
+}
+
+// UnsupportedError is the error of a feature that the GitHub server lacks, returned instead of
+// the 404 Not Found errors of the missing API endpoints.
+type UnsupportedError struct {
+	Capability         Capability
+	GHESVersion        string
+	MinimumGHESVersion string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s are not supported on GitHub Enterprise Server %s. Upgrade it to %s or later", e.Capability, e.GHESVersion, e.MinimumGHESVersion)
+}
+
+func IsUnsupported(err error) bool {
+	var e *UnsupportedError
+	return errors.As(err, &e)
+}
+
+// DetectCapabilities probes the GitHub server for its version and caches the result.
+// github.com is detected from the API URL without any request.
+func (c *Client) DetectCapabilities(ctx context.Context) (Capabilities, error) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	return c.detectCapabilities(ctx)
+}
+
+// CheckCapability returns an UnsupportedError when the GitHub server is known to lack the capability.
+// It returns nil when the detection fails, so that the feature is tried as usual and
+// any error is reported by the API call itself.
+func (c *Client) CheckCapability(ctx context.Context, capability Capability) error {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	if c.capabilities == nil && time.Since(c.capabilitiesDetectedAt) < capabilitiesRedetectInterval {
+		return nil
+	}
+
+	capabilities, err := c.detectCapabilities(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return capabilities.Supports(capability)
+}
+
+func (c *Client) detectCapabilities(ctx context.Context) (Capabilities, error) {
+	if c.capabilities != nil {
+		return *c.capabilities, nil
+	}
+
+	c.capabilitiesDetectedAt = time.Now()
+
+	if c.BaseURL != nil && c.BaseURL.Host == "api.github.com" {
+		c.capabilities = &Capabilities{}
+
+		return *c.capabilities, nil
+	}
+
+	// Every response of GitHub Enterprise Server has the version header, and /meta is
+	// the cheapest endpoint that is available to every kind of credentials
+	_, res, err := c.Client.APIMeta(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("detecting the version of GitHub Enterprise Server: %w", categorize(err))
+	}
+
+	c.capabilities = &Capabilities{GHESVersion: res.Header.Get(HeaderGitHubEnterpriseVersion)}
+
+	return *c.capabilities, nil
+}
+
+// versionLess returns true when the version a, like "3.2.5", is older than b, like "3.3".
+// Missing and non-numeric components are treated as 0.
+func versionLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		if x != y {
+			return x < y
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,298 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+)
+
+// The names of the files in the credentials directory, which are the same as the keys of the Secret of the controller.
+const (
+	credentialsFileToken             = "github_token"
+	credentialsFileEnterpriseToken   = "github_enterprise_token"
+	credentialsFileAppID             = "github_app_id"
+	credentialsFileAppInstallationID = "github_app_installation_id"
+	credentialsFileAppPrivateKey     = "github_app_private_key"
+)
+
+// The keys of the GitHub endpoint in the Secret referenced by githubAPICredentialsFrom of the runners,
+// which has the same keys as the Secret of the controller plus these ones.
+const (
+	secretKeyEnterpriseURL   = "github_enterprise_url"
+	secretKeyURL             = "github_url"
+	secretKeyUploadURL       = "github_upload_url"
+	secretKeyRunnerGitHubURL = "runner_github_url"
+)
+
+// Credentials are the credentials of GitHub that can change while the controller runs,
+// like the ones rotated by Vault or External Secrets.
+type Credentials struct {
+	Token             string
+	EnterpriseToken   string
+	AppID             int64
+	AppInstallationID int64
+	// AppPrivateKey is the PEM-encoded private key of the GitHub App.
+	AppPrivateKey string
+}
+
+// CredentialsSource provides the latest credentials of GitHub.
+type CredentialsSource interface {
+	// Watch calls onChange with the credentials every time they change, until the context is done.
+	Watch(ctx context.Context, onChange func(Credentials)) error
+}
+
+// SetCredentials replaces all the credentials in the config with the given ones, including the zero values,
+// so that none of the previous ones is left, like the token taking precedence over the GitHub App switched to.
+func (c *Config) SetCredentials(creds Credentials) {
+	c.Token = creds.Token
+	c.EnterpriseToken = creds.EnterpriseToken
+	c.AppID = creds.AppID
+	c.AppInstallationID = creds.AppInstallationID
+	c.AppPrivateKey = creds.AppPrivateKey
+}
+
+// ConfigForSecret returns the config of the client for the GitHub endpoint and the credentials in the data of a Secret
+// referenced by githubAPICredentialsFrom of the runners. The endpoint defaults to github.com, not the one in c,
+// and the other settings, like the proxy and the retries, are the same as c.
+func (c Config) ConfigForSecret(data map[string][]byte) (Config, error) {
+	get := func(key string) string {
+		return strings.TrimSpace(string(data[key]))
+	}
+
+	parse := func(key string) (int64, error) {
+		v := get(key)
+		if v == "" {
+			return 0, nil
+		}
+
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s: %w", key, err)
+		}
+
+		return n, nil
+	}
+
+	config := c
+
+	config.EnterpriseURL = get(secretKeyEnterpriseURL)
+	config.URL = get(secretKeyURL)
+	config.UploadURL = get(secretKeyUploadURL)
+	config.RunnerGitHubURL = get(secretKeyRunnerGitHubURL)
+
+	config.Token = get(credentialsFileToken)
+	config.EnterpriseToken = get(credentialsFileEnterpriseToken)
+	config.AppPrivateKey = get(credentialsFileAppPrivateKey)
+	config.BasicauthUsername = ""
+	config.BasicauthPassword = ""
+	config.CredentialsDir = ""
+
+	var err error
+
+	if config.AppID, err = parse(credentialsFileAppID); err != nil {
+		return Config{}, err
+	}
+
+	if config.AppInstallationID, err = parse(credentialsFileAppInstallationID); err != nil {
+		return Config{}, err
+	}
+
+	if config.Token == "" && (config.AppID == 0 || config.AppInstallationID == 0 || config.AppPrivateKey == "") {
+		return Config{}, fmt.Errorf("either %s, or %s, %s and %s are required", credentialsFileToken, credentialsFileAppID, credentialsFileAppInstallationID, credentialsFileAppPrivateKey)
+	}
+
+	return config, nil
+}
+
+// UpdateCredentials replaces the credentials the API calls are authenticated with, without recreating the client.
+// The credentials replace all the current ones, so they must be complete, like the ones read from the credentials directory.
+// The enterprise token can only be rotated, as the client for the enterprise-level API calls is created only when it's given at first.
+func (c *Client) UpdateCredentials(creds Credentials) error {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+
+	config := c.config
+	config.SetCredentials(creds)
+
+	if config.EnterpriseToken != "" && c.enterpriseAuth == nil {
+		return fmt.Errorf("the enterprise token can't be added without restarting, as none was given at startup")
+	}
+
+	if config.EnterpriseToken == "" && c.enterpriseAuth != nil {
+		return fmt.Errorf("the enterprise token can't be removed without restarting")
+	}
+
+	basicAuth := config.BasicauthUsername != "" && config.BasicauthPassword != ""
+	if !basicAuth && config.Token == "" && (config.AppID == 0 || config.AppInstallationID == 0 || config.AppPrivateKey == "") {
+		return fmt.Errorf("either %s, or %s, %s and %s are required", credentialsFileToken, credentialsFileAppID, credentialsFileAppInstallationID, credentialsFileAppPrivateKey)
+	}
+
+	transport, err := config.newAuthTransport(c.base)
+	if err != nil {
+		return err
+	}
+
+	c.auth.set(transport)
+
+	if c.enterpriseAuth != nil {
+		c.enterpriseAuth.set(newTokenTransport(config.EnterpriseToken, c.base))
+	}
+
+	c.config = config
+
+	return nil
+}
+
+// switchableTransport is the transport whose underlying transport can be replaced while it's used,
+// so that the authentication of the API calls is updated without recreating the clients and their caches.
+type switchableTransport struct {
+	mu        sync.RWMutex
+	transport http.RoundTripper
+}
+
+func (t *switchableTransport) set(transport http.RoundTripper) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.transport = transport
+}
+
+func (t *switchableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	transport := t.transport
+	t.mu.RUnlock()
+
+	return transport.RoundTrip(req)
+}
+
+// FileCredentialsSource reads the credentials from the files in the directory, like the mounted Secret of the controller
+// or the files written by Vault Agent, and watches the directory for the changes.
+// The files are named after the keys of the Secret of the controller, like github_token and github_app_private_key.
+type FileCredentialsSource struct {
+	Dir string
+	Log logr.Logger
+}
+
+// Read returns the credentials in the files. The missing files are read as the zero values.
+func (s *FileCredentialsSource) Read() (Credentials, error) {
+	var (
+		creds Credentials
+		err   error
+	)
+
+	read := func(name string) string {
+		b, e := os.ReadFile(filepath.Join(s.Dir, name))
+		if e != nil && !os.IsNotExist(e) && err == nil {
+			err = e
+		}
+
+		return strings.TrimSpace(string(b))
+	}
+
+	parse := func(name string) int64 {
+		v := read(name)
+		if v == "" {
+			return 0
+		}
+
+		n, e := strconv.ParseInt(v, 10, 64)
+		if e != nil && err == nil {
+			err = fmt.Errorf("parsing %s: %w", name, e)
+		}
+
+		return n
+	}
+
+	creds.Token = read(credentialsFileToken)
+	creds.EnterpriseToken = read(credentialsFileEnterpriseToken)
+	creds.AppID = parse(credentialsFileAppID)
+	creds.AppInstallationID = parse(credentialsFileAppInstallationID)
+	creds.AppPrivateKey = read(credentialsFileAppPrivateKey)
+
+	return creds, err
+}
+
+// Watch watches the directory instead of the files, because Kubernetes updates the files of the mounted Secret
+// by swapping the symlink to the directory of the files.
+func (s *FileCredentialsSource) Watch(ctx context.Context, onChange func(Credentials)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(s.Dir); err != nil {
+		return fmt.Errorf("watching credentials directory %s: %w", s.Dir, err)
+	}
+
+	last, err := s.Read()
+	if err != nil {
+		s.Log.Error(err, "Failed to read the credentials", "dir", s.Dir)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			creds, err := s.Read()
+			if err != nil {
+				// The files may be in the middle of being written
+				s.Log.V(1).Info("Failed to read the credentials. Retrying on the next change", "dir", s.Dir, "error", err.Error())
+
+				continue
+			}
+
+			if creds == last {
+				continue
+			}
+
+			last = creds
+
+			onChange(creds)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			s.Log.Error(err, "Failed to watch the credentials", "dir", s.Dir)
+		}
+	}
+}
+
+// CredentialsReloader updates the credentials of the client every time the source provides new ones,
+// so that the rotated credentials are used without restarting the controller and the webhook server.
+type CredentialsReloader struct {
+	Client *Client
+	Source CredentialsSource
+	Log    logr.Logger
+}
+
+func (r *CredentialsReloader) Start(ctx context.Context) error {
+	return r.Source.Watch(ctx, func(creds Credentials) {
+		if err := r.Client.UpdateCredentials(creds); err != nil {
+			r.Log.Error(err, "Failed to update the GitHub credentials. Keeping the current ones")
+
+			return
+		}
+
+		r.Log.Info("Updated the GitHub credentials")
+	})
+}
+
+// NeedLeaderElection makes the credentials reloaded on every replica, as each of them calls the GitHub API.
+func (r *CredentialsReloader) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// ErrorCategory tells how the caller should react to the failed GitHub API call.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryRateLimited is the error due to the primary or secondary rate limit. Retry after the rate limit resets.
+	ErrorCategoryRateLimited ErrorCategory = "RateLimited"
+	// ErrorCategoryNotFound is the error due to the missing resource, like a runner that is already removed.
+	ErrorCategoryNotFound ErrorCategory = "NotFound"
+	// ErrorCategoryUnauthorized is the error due to the invalid or expired credential, which retries don't resolve.
+	ErrorCategoryUnauthorized ErrorCategory = "Unauthorized"
+	// ErrorCategoryTransient is the error that is likely to be resolved by retrying later, like 502 Bad Gateway.
+	ErrorCategoryTransient ErrorCategory = "Transient"
+	// ErrorCategoryUnknown is any other error.
+	ErrorCategoryUnknown ErrorCategory = "Unknown"
+)
+
+// Error is the error of the GitHub API call along with its category.
+// The original error, like *github.RateLimitError, is still available via errors.As.
+type Error struct {
+	Category ErrorCategory
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Category, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// categorize wraps the error returned by go-github into Error.
+func categorize(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &Error{Category: categoryOf(err), Err: err}
+}
+
+func categoryOf(err error) ErrorCategory {
+	var (
+		rateLimitErr      *github.RateLimitError
+		abuseRateLimitErr *github.AbuseRateLimitError
+		errResp           *github.ErrorResponse
+		netErr            net.Error
+	)
+
+	switch {
+	case errors.As(err, &rateLimitErr), errors.As(err, &abuseRateLimitErr):
+		return ErrorCategoryRateLimited
+	case errors.As(err, &errResp) && errResp.Response != nil:
+		return categoryOfStatus(errResp.Response.StatusCode)
+	case errors.Is(err, context.Canceled):
+		return ErrorCategoryUnknown
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrorCategoryTransient
+	}
+
+	return ErrorCategoryUnknown
+}
+
+func categoryOfStatus(status int) ErrorCategory {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorCategoryRateLimited
+	case status == http.StatusNotFound:
+		return ErrorCategoryNotFound
+	case status == http.StatusUnauthorized:
+		return ErrorCategoryUnauthorized
+	case isTransientStatus(status):
+		return ErrorCategoryTransient
+	}
+
+	return ErrorCategoryUnknown
+}
+
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// ErrorCategoryOf returns the category of the error returned by Client, or by go-github directly.
+// It returns the empty category for nil.
+func ErrorCategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Category
+	}
+
+	return categoryOf(err)
+}
+
+func IsRateLimited(err error) bool {
+	return ErrorCategoryOf(err) == ErrorCategoryRateLimited
+}
+
+func IsNotFound(err error) bool {
+	return ErrorCategoryOf(err) == ErrorCategoryNotFound
+}
+
+func IsUnauthorized(err error) bool {
+	return ErrorCategoryOf(err) == ErrorCategoryUnauthorized
+}
+
+func IsTransient(err error) bool {
+	return ErrorCategoryOf(err) == ErrorCategoryTransient
+}
@@ -0,0 +1,175 @@
+package github
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+)
+
+// defaultETagCacheSize is the number of the responses kept for the conditional requests.
+// Each page of the workflow runs and jobs of each repository takes one.
+const defaultETagCacheSize = 1000
+
+// conditionalEndpoints are the GitHub API endpoints polled by the pull-based autoscaling metrics,
+// whose results rarely change between the polls.
+var conditionalEndpoints = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{name: "workflow_runs", pattern: regexp.MustCompile(`/repos/[^/]+/[^/]+/actions/runs$`)},
+	{name: "workflow_jobs", pattern: regexp.MustCompile(`/repos/[^/]+/[^/]+/actions/runs/[0-9]+/jobs$`)},
+}
+
+// etagCacheTransport makes the listing of workflow runs and jobs conditional requests.
+// It sends the ETag of the last response to the same URL as If-None-Match, and serves the cached response when GitHub
+// answers 304 Not Modified, which costs no rate limit point.
+// See https://docs.github.com/en/rest/overview/resources-in-the-rest-api#conditional-requests
+type etagCacheTransport struct {
+	Transport http.RoundTripper
+	Size      int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the cached responses, the least recently used at the back
+	lru *list.List
+}
+
+type etagCacheEntry struct {
+	url    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func newETagCacheTransport(transport http.RoundTripper, size int) *etagCacheTransport {
+	return &etagCacheTransport{
+		Transport: transport,
+		Size:      size,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+func (t *etagCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := conditionalEndpoint(req)
+	if endpoint == "" {
+		return t.Transport.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	cached := t.get(key)
+	if cached != nil {
+		// RoundTrip must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		metrics.ObserveConditionalRequest(endpoint, metrics.ConditionalRequestHit)
+
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+
+		return cached.response(req, res), nil
+	}
+
+	// Every request not served from the cache counts as a miss, including the first one to the URL
+	metrics.ObserveConditionalRequest(endpoint, metrics.ConditionalRequestMiss)
+
+	etag := res.Header.Get("ETag")
+	if res.StatusCode != http.StatusOK || etag == "" {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.put(&etagCacheEntry{url: key, etag: etag, header: res.Header.Clone(), body: body})
+
+	return res, nil
+}
+
+// response returns the cached response in place of the 304 Not Modified one.
+// The headers of the latter, like the rate limit ones, take precedence.
+func (e *etagCacheEntry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := e.header.Clone()
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+
+	res := *notModified
+	res.Request = req
+	res.StatusCode = http.StatusOK
+	res.Status = "200 OK"
+	res.Header = header
+	res.Body = ioutil.NopCloser(bytes.NewReader(e.body))
+	res.ContentLength = int64(len(e.body))
+
+	return &res
+}
+
+func (t *etagCacheTransport) get(key string) *etagCacheEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+
+	t.lru.MoveToFront(elem)
+
+	return elem.Value.(*etagCacheEntry)
+}
+
+func (t *etagCacheTransport) put(entry *etagCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[entry.url]; ok {
+		elem.Value = entry
+		t.lru.MoveToFront(elem)
+
+		return
+	}
+
+	t.entries[entry.url] = t.lru.PushFront(entry)
+
+	for t.lru.Len() > t.Size {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*etagCacheEntry).url)
+	}
+}
+
+// conditionalEndpoint returns the name of the endpoint of the request that is made conditional, or empty.
+func conditionalEndpoint(req *http.Request) string {
+	if req.Method != http.MethodGet {
+		return ""
+	}
+
+	for _, e := range conditionalEndpoints {
+		if e.pattern.MatchString(req.URL.Path) {
+			return e.name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,197 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/google/go-cmp/cmp"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestETagCache(t *testing.T) {
+	var (
+		conditional []string
+		etag        = `"v1"`
+		body        = `{"total_count":1,"workflow_runs":[{"id":1,"status":"queued"}]}`
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, body)
+	}))
+	defer s.Close()
+
+	c := Config{
+		Token: "token",
+		URL:   s.URL,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+
+	list := func() int {
+		t.Helper()
+
+		runs, err := client.listRepositoryWorkflowRuns(ctx, "test", "valid", "queued")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return len(runs)
+	}
+
+	// The first call isn't conditional, the second is served from the cache, and the third gets the modified response
+	if n := list(); n != 1 {
+		t.Errorf("unexpected number of runs: want 1, got %d", n)
+	}
+
+	if n := list(); n != 1 {
+		t.Errorf("unexpected number of runs from the cache: want 1, got %d", n)
+	}
+
+	etag = `"v2"`
+	body = `{"total_count":2,"workflow_runs":[{"id":1,"status":"queued"},{"id":2,"status":"queued"}]}`
+
+	if n := list(); n != 2 {
+		t.Errorf("unexpected number of runs: want 2, got %d", n)
+	}
+
+	if n := list(); n != 2 {
+		t.Errorf("unexpected number of runs from the cache: want 2, got %d", n)
+	}
+
+	if d := cmp.Diff([]string{"", `"v1"`, `"v1"`, `"v2"`}, conditional); d != "" {
+		t.Errorf("unexpected If-None-Match headers (-want +got):\n%s", d)
+	}
+
+	// Runners aren't listed conditionally
+	conditional = nil
+
+	if _, err := client.ListRunners(ctx, "", "test", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.ListRunners(ctx, "", "test", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff([]string{"", ""}, conditional); d != "" {
+		t.Errorf("unexpected If-None-Match headers (-want +got):\n%s", d)
+	}
+}
+
+func TestETagCacheEviction(t *testing.T) {
+	tr := newETagCacheTransport(http.DefaultTransport, 2)
+
+	for _, url := range []string{"a", "b", "a", "c"} {
+		tr.put(&etagCacheEntry{url: url, etag: url})
+	}
+
+	for url, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if got := tr.get(url) != nil; got != want {
+			t.Errorf("unexpected cache entry of %s: want %v, got %v", url, want, got)
+		}
+	}
+}
+
+func TestETagCacheMetrics(t *testing.T) {
+	etag := `"v1"`
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"total_count":0,"workflow_runs":[]}`)
+	}))
+	defer s.Close()
+
+	tr := newETagCacheTransport(http.DefaultTransport, defaultETagCacheSize)
+
+	count := func(result string) float64 {
+		t.Helper()
+
+		families, err := ctrlmetrics.Registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, f := range families {
+			if f.GetName() != "github_conditional_requests_total" {
+				continue
+			}
+
+			for _, m := range f.GetMetric() {
+				labels := map[string]string{}
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				if labels["endpoint"] == "workflow_runs" && labels["result"] == result {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+
+		return 0
+	}
+
+	get := func() {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, s.URL+"/repos/test/metrics/actions/runs", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res.Body.Close()
+	}
+
+	hits, misses := count(metrics.ConditionalRequestHit), count(metrics.ConditionalRequestMiss)
+
+	// The first request to the URL has nothing cached, so it's a miss
+	get()
+
+	if got := count(metrics.ConditionalRequestMiss) - misses; got != 1 {
+		t.Errorf("unexpected misses after the first request: want 1, got %v", got)
+	}
+
+	get()
+
+	if got := count(metrics.ConditionalRequestHit) - hits; got != 1 {
+		t.Errorf("unexpected hits after the second request: want 1, got %v", got)
+	}
+
+	etag = `"v2"`
+
+	get()
+
+	if got := count(metrics.ConditionalRequestMiss) - misses; got != 2 {
+		t.Errorf("unexpected misses after the modification: want 2, got %v", got)
+	}
+}
@@ -21,6 +21,17 @@ const (
     {"id": 2, "name": "test2", "os": "linux", "status": "offline", "busy": false}
   ]
 }
+`
+
+	OrganizationRunnerGroupsListBody = `
+{
+  "total_count": 3,
+  "runner_groups": [
+    {"id": 1, "name": "Default", "visibility": "all", "default": true},
+    {"id": 2, "name": "selected", "visibility": "selected"},
+    {"id": 3, "name": "enterprise", "visibility": "all", "inherited": true}
+  ]
+}
 `
 )
 
@@ -75,6 +86,12 @@ func (h *MapHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 type ServerConfig struct {
 	*FixedResponses
+
+	// Faults injects failures into the responses when set
+	Faults *FaultInjector
+
+	// EnterpriseVersion is sent in the X-GitHub-Enterprise-Version header of every response when set
+	EnterpriseVersion string
 }
 
 // NewServer creates a fake server for running unit tests
@@ -199,6 +216,26 @@ func NewServer(opts ...Option) *httptest.Server {
 			Body:   "",
 		},
 
+		// For GetRunnerGroup
+		"/orgs/test/actions/runner-groups": &Handler{
+			Status: http.StatusOK,
+			Body:   OrganizationRunnerGroupsListBody,
+		},
+		"/orgs/test/actions/runner-groups/2/repositories": &Handler{
+			Status: http.StatusOK,
+			Body:   `{"total_count": 1, "repositories": [{"id": 1, "name": "valid", "full_name": "test/valid"}]}`,
+		},
+		"/enterprises/test/actions/runner-groups": &Handler{
+			Status: http.StatusOK,
+			Body:   `{"total_count": 1, "runner_groups": [{"id": 3, "name": "enterprise", "visibility": "all"}]}`,
+		},
+
+		// For detecting capabilities
+		"/meta": &Handler{
+			Status: http.StatusOK,
+			Body:   "{}",
+		},
+
 		// For auto-scaling based on the number of queued(pending) workflow runs
 		"/repos/test/valid/actions/runs": config.FixedResponses.ListRepositoryWorkflowRuns,
 
@@ -211,7 +248,20 @@ func NewServer(opts ...Option) *httptest.Server {
 		mux.Handle(path, handler)
 	}
 
-	return httptest.NewServer(mux)
+	var h http.Handler = mux
+
+	if config.EnterpriseVersion != "" {
+		h = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-GitHub-Enterprise-Version", config.EnterpriseVersion)
+			mux.ServeHTTP(w, req)
+		})
+	}
+
+	if config.Faults != nil {
+		return httptest.NewServer(config.Faults.Wrap(h))
+	}
+
+	return httptest.NewServer(h)
 }
 
 func DefaultListRunnersHandler() *ListRunnersHandler {
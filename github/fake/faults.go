@@ -0,0 +1,203 @@
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fault is the error response that the FaultInjector returns instead of calling the fake handler.
+type Fault struct {
+	Status  int
+	Message string
+	Header  http.Header
+}
+
+// RateLimitFault returns the fault that GitHub responds with when the primary rate limit is exceeded,
+// which go-github turns into a RateLimitError.
+func RateLimitFault(reset time.Time) Fault {
+	return Fault{
+		Status:  http.StatusForbidden,
+		Message: "API rate limit exceeded",
+		Header:  rateLimitHeader(0, reset),
+	}
+}
+
+// ServerErrorFault returns the fault of a transient server-side error, like 500 or 502.
+func ServerErrorFault(status int) Fault {
+	return Fault{
+		Status:  status,
+		Message: http.StatusText(status),
+	}
+}
+
+// UnauthorizedFault returns the fault that GitHub responds with when the token or the installation token has expired.
+func UnauthorizedFault() Fault {
+	return Fault{
+		Status:  http.StatusUnauthorized,
+		Message: "Bad credentials",
+	}
+}
+
+// FaultInjector wraps the fake server's handler to let tests exercise controllers against
+// GitHub API failures like rate limits, server errors, token expiry, and slow responses.
+//
+// Faults queued with Enqueue are returned first, one per request, in order.
+// After that, requests fail with RandomFault at ErrorRate.
+// All the methods are safe to call while the server is serving requests.
+type FaultInjector struct {
+	mu sync.Mutex
+
+	// Latency delays every response, including the faults.
+	Latency time.Duration
+
+	// ErrorRate is the ratio, between 0 and 1, of the requests that fail with RandomFault.
+	ErrorRate float64
+
+	// RandomFault is the fault returned at ErrorRate. Defaults to 500 Internal Server Error.
+	RandomFault *Fault
+
+	queue          []Fault
+	rateLimits     []int
+	rateLimitReset time.Time
+	rand           *rand.Rand
+	requests       int
+	faults         int
+}
+
+// NewFaultInjector returns the FaultInjector whose random faults are reproducible with the seed.
+func NewFaultInjector(seed int64) *FaultInjector {
+	return &FaultInjector{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Enqueue makes the next requests fail with the faults in order.
+func (f *FaultInjector) Enqueue(faults ...Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queue = append(f.queue, faults...)
+}
+
+// SetErrorRate updates ErrorRate, e.g. to 0 for letting the controllers recover.
+func (f *FaultInjector) SetErrorRate(rate float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ErrorRate = rate
+}
+
+// SetLatency updates Latency.
+func (f *FaultInjector) SetLatency(latency time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Latency = latency
+}
+
+// SequenceRateLimits attaches the X-RateLimit-* headers with the remaining counts to the next successful responses in order,
+// so that tests can see how the client reacts to the rate limit running out before any request actually fails.
+// go-github stops sending requests until the reset time once it sees the remaining count of 0.
+func (f *FaultInjector) SequenceRateLimits(reset time.Time, remaining ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rateLimits = append(f.rateLimits, remaining...)
+	f.rateLimitReset = reset
+}
+
+// Requests returns the number of requests the server has received.
+func (f *FaultInjector) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests
+}
+
+// Faults returns the number of requests that failed with injected faults.
+func (f *FaultInjector) Faults() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.faults
+}
+
+// Wrap returns the handler that injects the faults before calling h.
+func (f *FaultInjector) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fault, header, latency := f.next()
+
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+
+		for k, vs := range header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+
+		if fault == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		for k, vs := range fault.Header {
+			for _, v := range vs {
+				w.Header().Set(k, v)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(fault.Status)
+		fmt.Fprintf(w, `{"message": %q, "documentation_url": "https://docs.github.com/rest"}`, fault.Message)
+	})
+}
+
+// next returns the fault for the current request, or the headers to add to the successful response.
+func (f *FaultInjector) next() (*Fault, http.Header, time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+
+	if len(f.queue) > 0 {
+		fault := f.queue[0]
+		f.queue = f.queue[1:]
+		f.faults++
+
+		return &fault, nil, f.Latency
+	}
+
+	if f.ErrorRate > 0 && f.rand.Float64() < f.ErrorRate {
+		fault := ServerErrorFault(http.StatusInternalServerError)
+		if f.RandomFault != nil {
+			fault = *f.RandomFault
+		}
+		f.faults++
+
+		return &fault, nil, f.Latency
+	}
+
+	var header http.Header
+
+	if len(f.rateLimits) > 0 {
+		header = rateLimitHeader(f.rateLimits[0], f.rateLimitReset)
+		f.rateLimits = f.rateLimits[1:]
+	}
+
+	return nil, header, f.Latency
+}
+
+func rateLimitHeader(remaining int, reset time.Time) http.Header {
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "5000")
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+	return h
+}
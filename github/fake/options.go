@@ -46,3 +46,16 @@ func WithFixedResponses(responses *FixedResponses) Option {
 		c.FixedResponses = responses
 	}
 }
+
+func WithFaultInjector(faults *FaultInjector) Option {
+	return func(c *ServerConfig) {
+		c.Faults = faults
+	}
+}
+
+// WithEnterpriseVersion makes the server respond as GitHub Enterprise Server of the version.
+func WithEnterpriseVersion(version string) Option {
+	return func(c *ServerConfig) {
+		c.EnterpriseVersion = version
+	}
+}
@@ -32,8 +32,10 @@ func (r *RunnersList) GetServer() *httptest.Server {
 	router := mux.NewRouter()
 
 	router.Handle("/repos/{owner}/{repo}/actions/runners", r.HandleList())
+	router.Handle("/repos/{owner}/{repo}/actions/runners/{id}", r.handleGet()).Methods(http.MethodGet)
 	router.Handle("/repos/{owner}/{repo}/actions/runners/{id}", r.handleRemove())
 	router.Handle("/orgs/{org}/actions/runners", r.HandleList())
+	router.Handle("/orgs/{org}/actions/runners/{id}", r.handleGet()).Methods(http.MethodGet)
 	router.Handle("/orgs/{org}/actions/runners/{id}", r.handleRemove())
 
 	return httptest.NewServer(router)
@@ -54,6 +56,25 @@ func (r *RunnersList) HandleList() http.HandlerFunc {
 	}
 }
 
+func (r *RunnersList) handleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, res *http.Request) {
+		vars := mux.Vars(res)
+		for _, runner := range r.runners {
+			if runner.ID != nil && vars["id"] == strconv.FormatInt(*runner.ID, 10) {
+				j, err := json.Marshal(runner)
+				if err != nil {
+					panic(err)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				w.Write(j)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
 func (r *RunnersList) handleRemove() http.HandlerFunc {
 	return func(w http.ResponseWriter, res *http.Request) {
 		vars := mux.Vars(res)
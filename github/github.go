@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,8 +12,11 @@ import (
 	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/tracing"
 	"github.com/bradleyfalzon/ghinstallation"
 	"github.com/google/go-github/v39/github"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/http/httpproxy"
 	"golang.org/x/oauth2"
 )
 
@@ -28,61 +32,217 @@ type Config struct {
 	BasicauthUsername string `split_words:"true"`
 	BasicauthPassword string `split_words:"true"`
 	RunnerGitHubURL   string `split_words:"true"`
+
+	// EnterpriseToken is the personal access token used only for the enterprise-level API calls,
+	// like managing enterprise runners, so that the other API calls can be made as a GitHub App.
+	EnterpriseToken string `split_words:"true"`
+
+	// HTTPProxy, HTTPSProxy, and NoProxy configure the proxy used for GitHub API calls.
+	// Each of them defaults to the corresponding standard HTTP_PROXY, HTTPS_PROXY, and NO_PROXY
+	// environment variable.
+	HTTPProxy  string `split_words:"true"`
+	HTTPSProxy string `split_words:"true"`
+	NoProxy    string `split_words:"true"`
+
+	// MaxRetries is the number of times a GitHub API call that failed with a transient error, like 502 Bad Gateway,
+	// is retried with jittered exponential backoff. Defaults to 3 when read from the environment. 0 disables retries.
+	MaxRetries int `split_words:"true" default:"3"`
+	// RetryBaseDelay and RetryMaxDelay are the initial and the maximum delays between the retries.
+	// Default to 500ms and 10s respectively.
+	RetryBaseDelay time.Duration `split_words:"true"`
+	RetryMaxDelay  time.Duration `split_words:"true"`
+
+	// OnRateLimitExhausted is called with the time the rate limit resets, when a response tells that
+	// the rate limit is exhausted.
+	OnRateLimitExhausted func(reset time.Time) `ignored:"true"`
+
+	// ListRunnersConcurrency is the number of the pages of runners fetched concurrently, so that listing thousands of
+	// runners doesn't take long enough to delay reconciliations. Defaults to 4 when read from the environment.
+	// 1 or less fetches the pages one by one.
+	ListRunnersConcurrency int `split_words:"true" default:"4"`
+
+	// CredentialName identifies the credentials in the metrics of the API calls, like the Secret they're read from.
+	// Defaults to the kind of the credentials, like app:<app ID>/<installation ID>. See credentialIdentity.
+	CredentialName string `ignored:"true"`
+
+	// CredentialsDir is the directory of the files of the credentials, like the mounted Secret of the controller,
+	// which is watched to update the credentials of the client without restarting. See FileCredentialsSource.
+	CredentialsDir string `split_words:"true"`
 }
 
 // Client wraps GitHub client with some additional
 type Client struct {
 	*github.Client
-	regTokens map[string]*github.RegistrationToken
-	mu        sync.Mutex
+	// enterpriseClient is used for the enterprise-level API calls instead of Client when set
+	enterpriseClient *github.Client
+	regTokens        map[string]*github.RegistrationToken
+	mu               sync.Mutex
 	// GithubBaseURL to Github without API suffix.
 	GithubBaseURL string
+
+	// capabilities are cached once detected. See DetectCapabilities.
+	capabilities           *Capabilities
+	capabilitiesDetectedAt time.Time
+	capabilitiesMu         sync.Mutex
+
+	// listRunnersConcurrency is the number of the pages of runners fetched concurrently. See Config.ListRunnersConcurrency.
+	listRunnersConcurrency int
+
+	// inventory serves ListRunners when enabled. See EnableRunnerInventory.
+	inventory *RunnerInventory
+
+	// config, base, auth, and enterpriseAuth are used to replace the authentication of the API calls
+	// on the update of the credentials. See UpdateCredentials.
+	config         Config
+	base           http.RoundTripper
+	auth           *switchableTransport
+	enterpriseAuth *switchableTransport
+	credentialsMu  sync.Mutex
 }
 
 type BasicAuthTransport struct {
 	Username string
 	Password string
+
+	// Transport is the underlying transport. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
 }
 
 func (p BasicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.SetBasicAuth(p.Username, p.Password)
 	req.Header.Set("User-Agent", "actions-runner-controller")
-	return http.DefaultTransport.RoundTrip(req)
+
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return transport.RoundTrip(req)
 }
 
 // NewClient creates a Github Client
 func (c *Config) NewClient() (*Client, error) {
-	var transport http.RoundTripper
+	base := c.newBaseTransport()
+
+	transport, err := c.newAuthTransport(base)
+	if err != nil {
+		return nil, err
+	}
+
+	// The credentials can be updated without recreating the client. See UpdateCredentials.
+	auth := &switchableTransport{}
+	auth.set(transport)
+
+	client, githubBaseURL, err := c.newGitHubClient(auth, c.credentialIdentity())
+	if err != nil {
+		return nil, err
+	}
+
+	// The enterprise-level API calls are made with the separate PAT when given, because GitHub Apps
+	// can't be installed to enterprises and so can't call them, at least on some GHES versions.
+	var (
+		enterpriseClient *github.Client
+		enterpriseAuth   *switchableTransport
+	)
+
+	if len(c.EnterpriseToken) > 0 {
+		enterpriseAuth = &switchableTransport{}
+		enterpriseAuth.set(newTokenTransport(c.EnterpriseToken, base))
+
+		enterpriseClient, _, err = c.newGitHubClient(enterpriseAuth, c.enterpriseCredentialIdentity())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Client{
+		Client:           client,
+		enterpriseClient: enterpriseClient,
+		regTokens:        map[string]*github.RegistrationToken{},
+		mu:               sync.Mutex{},
+		GithubBaseURL:    githubBaseURL,
+
+		listRunnersConcurrency: c.ListRunnersConcurrency,
+
+		config:         *c,
+		base:           base,
+		auth:           auth,
+		enterpriseAuth: enterpriseAuth,
+	}, nil
+}
+
+// newAuthTransport returns the transport that authenticates the GitHub API calls with the credentials in the Config,
+// preferring the basic auth, then the personal access token, and then the GitHub App.
+func (c *Config) newAuthTransport(base http.RoundTripper) (http.RoundTripper, error) {
 	if len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0 {
-		transport = BasicAuthTransport{Username: c.BasicauthUsername, Password: c.BasicauthPassword}
-	} else if len(c.Token) > 0 {
-		transport = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Token})).Transport
-	} else {
-		var tr *ghinstallation.Transport
+		return BasicAuthTransport{Username: c.BasicauthUsername, Password: c.BasicauthPassword, Transport: base}, nil
+	}
 
-		if _, err := os.Stat(c.AppPrivateKey); err == nil {
-			tr, err = ghinstallation.NewKeyFromFile(http.DefaultTransport, c.AppID, c.AppInstallationID, c.AppPrivateKey)
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key at %s: %v", c.AppPrivateKey, err)
-			}
-		} else {
-			tr, err = ghinstallation.New(http.DefaultTransport, c.AppID, c.AppInstallationID, []byte(c.AppPrivateKey))
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key of size %d (%s...): %v", len(c.AppPrivateKey), strings.Split(c.AppPrivateKey, "\n")[0], err)
-			}
+	if len(c.Token) > 0 {
+		return newTokenTransport(c.Token, base), nil
+	}
+
+	var tr *ghinstallation.Transport
+
+	if _, err := os.Stat(c.AppPrivateKey); err == nil {
+		tr, err = ghinstallation.NewKeyFromFile(base, c.AppID, c.AppInstallationID, c.AppPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: using private key at %s: %v", c.AppPrivateKey, err)
+		}
+	} else {
+		tr, err = ghinstallation.New(base, c.AppID, c.AppInstallationID, []byte(c.AppPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: using private key of size %d (%s...): %v", len(c.AppPrivateKey), strings.Split(c.AppPrivateKey, "\n")[0], err)
 		}
+	}
 
-		if len(c.EnterpriseURL) > 0 {
-			githubAPIURL, err := getEnterpriseApiUrl(c.EnterpriseURL)
-			if err != nil {
-				return nil, fmt.Errorf("enterprise url incorrect: %v", err)
-			}
-			tr.BaseURL = githubAPIURL
+	if len(c.EnterpriseURL) > 0 {
+		githubAPIURL, err := getEnterpriseApiUrl(c.EnterpriseURL)
+		if err != nil {
+			return nil, fmt.Errorf("enterprise url incorrect: %v", err)
 		}
-		transport = tr
+		tr.BaseURL = githubAPIURL
+	}
+
+	return tr, nil
+}
+
+func newTokenTransport(token string, base http.RoundTripper) http.RoundTripper {
+	return &oauth2.Transport{
+		Source: oauth2.ReuseTokenSource(nil, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})),
+		Base:   base,
 	}
+}
 
-	transport = metrics.Transport{Transport: transport}
+// credentialIdentity returns the identity of the credentials used in the metrics of the API calls,
+// which never includes the secrets like the token or the password.
+func (c *Config) credentialIdentity() string {
+	switch {
+	case c.CredentialName != "":
+		return c.CredentialName
+	case len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0:
+		return "basicauth:" + c.BasicauthUsername
+	case len(c.Token) > 0:
+		return "token"
+	default:
+		return fmt.Sprintf("app:%d/%d", c.AppID, c.AppInstallationID)
+	}
+}
+
+// enterpriseCredentialIdentity returns the identity of EnterpriseToken used in the metrics of the API calls.
+func (c *Config) enterpriseCredentialIdentity() string {
+	if c.CredentialName != "" {
+		return c.CredentialName + "/enterprise-token"
+	}
+
+	return "enterprise-token"
+}
+
+// newGitHubClient returns the go-github client that calls the API at the configured URL via the transport,
+// along with the URL of GitHub that runners register themselves to.
+// The API calls are counted in the metrics labeled with the credential.
+func (c *Config) newGitHubClient(transport http.RoundTripper, credential string) (*github.Client, string, error) {
+	transport = metrics.Transport{Transport: newETagCacheTransport(transport, defaultETagCacheSize), OnRateLimitExhausted: c.OnRateLimitExhausted, Credential: credential}
 	httpClient := &http.Client{Transport: transport}
 
 	var client *github.Client
@@ -91,7 +251,7 @@ func (c *Config) NewClient() (*Client, error) {
 		var err error
 		client, err = github.NewEnterpriseClient(c.EnterpriseURL, c.EnterpriseURL, httpClient)
 		if err != nil {
-			return nil, fmt.Errorf("enterprise client creation failed: %v", err)
+			return nil, "", fmt.Errorf("enterprise client creation failed: %v", err)
 		}
 		githubBaseURL = fmt.Sprintf("%s://%s%s", client.BaseURL.Scheme, client.BaseURL.Host, strings.TrimSuffix(client.BaseURL.Path, "api/v3/"))
 	} else {
@@ -101,7 +261,7 @@ func (c *Config) NewClient() (*Client, error) {
 		if len(c.URL) > 0 {
 			baseUrl, err := url.Parse(c.URL)
 			if err != nil {
-				return nil, fmt.Errorf("github client creation failed: %v", err)
+				return nil, "", fmt.Errorf("github client creation failed: %v", err)
 			}
 			if !strings.HasSuffix(baseUrl.Path, "/") {
 				baseUrl.Path += "/"
@@ -112,7 +272,7 @@ func (c *Config) NewClient() (*Client, error) {
 		if len(c.UploadURL) > 0 {
 			uploadUrl, err := url.Parse(c.UploadURL)
 			if err != nil {
-				return nil, fmt.Errorf("github client creation failed: %v", err)
+				return nil, "", fmt.Errorf("github client creation failed: %v", err)
 			}
 			if !strings.HasSuffix(uploadUrl.Path, "/") {
 				uploadUrl.Path += "/"
@@ -128,12 +288,51 @@ func (c *Config) NewClient() (*Client, error) {
 		}
 	}
 
-	return &Client{
-		Client:        client,
-		regTokens:     map[string]*github.RegistrationToken{},
-		mu:            sync.Mutex{},
-		GithubBaseURL: githubBaseURL,
-	}, nil
+	return client, githubBaseURL, nil
+}
+
+// newBaseTransport returns the transport that all the GitHub API calls go through,
+// which retries transient errors when MaxRetries is set.
+// The proxy settings in the Config take precedence over the ones from environment variables.
+func (c *Config) newBaseTransport() http.RoundTripper {
+	base := c.newProxyTransport()
+
+	if c.MaxRetries > 0 {
+		base = &retryTransport{
+			Transport:  base,
+			MaxRetries: c.MaxRetries,
+			BaseDelay:  c.RetryBaseDelay,
+			MaxDelay:   c.RetryMaxDelay,
+		}
+	}
+
+	return base
+}
+
+func (c *Config) newProxyTransport() http.RoundTripper {
+	if c.HTTPProxy == "" && c.HTTPSProxy == "" && c.NoProxy == "" {
+		return http.DefaultTransport
+	}
+
+	proxyConfig := httpproxy.FromEnvironment()
+	if c.HTTPProxy != "" {
+		proxyConfig.HTTPProxy = c.HTTPProxy
+	}
+	if c.HTTPSProxy != "" {
+		proxyConfig.HTTPSProxy = c.HTTPSProxy
+	}
+	if c.NoProxy != "" {
+		proxyConfig.NoProxy = c.NoProxy
+	}
+
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	return tr
 }
 
 // GetRegistrationToken returns a registration token tied with the name of repository and runner.
@@ -160,7 +359,7 @@ func (c *Client) GetRegistrationToken(ctx context.Context, enterprise, org, repo
 	rt, res, err := c.createRegistrationToken(ctx, enterprise, owner, repo)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create registration token: %v", err)
+		return nil, fmt.Errorf("failed to create registration token: %w", categorize(err))
 	}
 
 	if res.StatusCode != 201 {
@@ -186,17 +385,22 @@ func (c *Client) RemoveRunner(ctx context.Context, enterprise, org, repo string,
 	res, err := c.removeRunner(ctx, enterprise, owner, repo, runnerID)
 
 	if err != nil {
-		return fmt.Errorf("failed to remove runner: %w", err)
+		return fmt.Errorf("failed to remove runner: %w", categorize(err))
 	}
 
 	if res.StatusCode != 204 {
 		return fmt.Errorf("unexpected status: %d", res.StatusCode)
 	}
 
+	if c.inventory != nil {
+		c.inventory.invalidate(runnerScope{enterprise: enterprise, owner: owner, repo: repo})
+	}
+
 	return nil
 }
 
 // ListRunners returns a list of runners of specified owner/repository name.
+// The runners are served from the runner inventory when it's enabled. See EnableRunnerInventory.
 func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string) ([]*github.Runner, error) {
 	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
 
@@ -204,6 +408,16 @@ func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string)
 		return nil, err
 	}
 
+	if c.inventory != nil {
+		return c.inventory.list(ctx, runnerScope{enterprise: enterprise, owner: owner, repo: repo})
+	}
+
+	return c.listRunnersFromAPI(ctx, enterprise, owner, repo)
+}
+
+// listRunnersFromAPI lists the runners via the GitHub API.
+// Once the first page tells the number of the pages, the rest of them are fetched concurrently up to ListRunnersConcurrency at a time.
+func (c *Client) listRunnersFromAPI(ctx context.Context, enterprise, owner, repo string) ([]*github.Runner, error) {
 	var runners []*github.Runner
 
 	opts := github.ListOptions{PerPage: 100}
@@ -211,23 +425,117 @@ func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string)
 		list, res, err := c.listRunners(ctx, enterprise, owner, repo, &opts)
 
 		if err != nil {
-			return runners, fmt.Errorf("failed to list runners: %w", err)
+			return runners, fmt.Errorf("failed to list runners: %w", categorize(err))
 		}
 
 		runners = append(runners, list.Runners...)
 		if res.NextPage == 0 {
 			break
 		}
+
+		if c.listRunnersConcurrency > 1 && res.LastPage > res.NextPage {
+			rest, err := c.listRunnerPages(ctx, enterprise, owner, repo, res.NextPage, res.LastPage)
+			if err != nil {
+				return runners, err
+			}
+
+			return dedupeRunners(append(runners, rest...)), nil
+		}
+
 		opts.Page = res.NextPage
 	}
 
 	return runners, nil
 }
 
-func (c *Client) GetRunnerGroupsFromRepository(ctx context.Context, org, repo string, potentialEnterpriseGroups []string, potentialOrgGroups []string) ([]string, []string, error) {
+// listRunnerPages fetches the pages from first to last, up to listRunnersConcurrency pages at a time,
+// and returns the runners in the order of the pages.
+func (c *Client) listRunnerPages(ctx context.Context, enterprise, owner, repo string, first, last int) ([]*github.Runner, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		pages = make([][]*github.Runner, last-first+1)
+		sem   = make(chan struct{}, c.listRunnersConcurrency)
+		wg    sync.WaitGroup
+
+		errMu    sync.Mutex
+		firstErr error
+	)
+
+	for page := first; page <= last; page++ {
+		page := page
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			list, _, err := c.listRunners(ctx, enterprise, owner, repo, &github.ListOptions{PerPage: 100, Page: page})
+			if err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to list runners: %w", categorize(err))
+					// No need to fetch the other pages once a page fails
+					cancel()
+				}
+				errMu.Unlock()
+
+				return
+			}
+
+			pages[page-first] = list.Runners
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	var runners []*github.Runner
+
+	for _, p := range pages {
+		runners = append(runners, p...)
+	}
+
+	return runners, nil
+}
+
+// dedupeRunners removes the runners listed twice, as a runner registered or removed while the pages are fetched
+// shifts the other runners across the pages.
+func dedupeRunners(runners []*github.Runner) []*github.Runner {
+	seen := map[int64]bool{}
+
+	deduped := runners[:0]
+
+	for _, r := range runners {
+		if seen[r.GetID()] {
+			continue
+		}
 
-	var enterpriseRunnerGroups []string
-	var orgRunnerGroups []string
+		seen[r.GetID()] = true
+
+		deduped = append(deduped, r)
+	}
+
+	return deduped
+}
+
+func (c *Client) GetRunnerGroupsFromRepository(ctx context.Context, org, repo string, potentialEnterpriseGroups []string, potentialOrgGroups []string) (enterpriseRunnerGroups []string, orgRunnerGroups []string, err error) {
+	ctx, span := tracing.Start(ctx, "GetRunnerGroupsFromRepository",
+		attribute.String("github.organization", org),
+		attribute.String("github.repository", repo),
+	)
+	defer func() { tracing.End(span, err) }()
 
 	if org != "" {
 		runnerGroups, err := c.getOrganizationRunnerGroups(ctx, org, repo)
@@ -271,12 +579,15 @@ func (c *Client) GetRunnerGroupsFromRepository(ctx context.Context, org, repo st
 	return enterpriseRunnerGroups, orgRunnerGroups, nil
 }
 
-func (c *Client) hasRepoAccessToOrganizationRunnerGroup(ctx context.Context, org string, runnerGroupId int64, repo string) (bool, error) {
+func (c *Client) hasRepoAccessToOrganizationRunnerGroup(ctx context.Context, org string, runnerGroupId int64, repo string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "ListRepositoryAccessRunnerGroup", attribute.Int64("github.runner_group.id", runnerGroupId))
+	defer func() { tracing.End(span, err) }()
+
 	opts := github.ListOptions{PerPage: 100}
 	for {
 		list, res, err := c.Client.Actions.ListRepositoryAccessRunnerGroup(ctx, org, runnerGroupId, &opts)
 		if err != nil {
-			return false, fmt.Errorf("failed to list repository access for runner group: %w", err)
+			return false, fmt.Errorf("failed to list repository access for runner group: %w", categorize(err))
 		}
 		for _, githubRepo := range list.Repositories {
 			if githubRepo.GetFullName() == repo {
@@ -291,14 +602,15 @@ func (c *Client) hasRepoAccessToOrganizationRunnerGroup(ctx context.Context, org
 	return false, nil
 }
 
-func (c *Client) getOrganizationRunnerGroups(ctx context.Context, org, repo string) ([]*github.RunnerGroup, error) {
-	var runnerGroups []*github.RunnerGroup
+func (c *Client) getOrganizationRunnerGroups(ctx context.Context, org, repo string) (runnerGroups []*github.RunnerGroup, err error) {
+	ctx, span := tracing.Start(ctx, "ListOrganizationRunnerGroups")
+	defer func() { tracing.End(span, err) }()
 
 	opts := github.ListOptions{PerPage: 100}
 	for {
 		list, res, err := c.Client.Actions.ListOrganizationRunnerGroups(ctx, org, &opts)
 		if err != nil {
-			return runnerGroups, fmt.Errorf("failed to list organization runner groups: %w", err)
+			return runnerGroups, fmt.Errorf("failed to list organization runner groups: %w", categorize(err))
 		}
 
 		runnerGroups = append(runnerGroups, list.RunnerGroups...)
@@ -311,6 +623,78 @@ func (c *Client) getOrganizationRunnerGroups(ctx context.Context, org, repo stri
 	return runnerGroups, nil
 }
 
+// GetRunnerGroup returns the runner group of the enterprise or the organization by the name, or nil if it doesn't exist.
+// The runner groups of an organization include the ones inherited from its enterprise.
+func (c *Client) GetRunnerGroup(ctx context.Context, enterprise, org, name string) (_ *github.RunnerGroup, err error) {
+	ctx, span := tracing.Start(ctx, "GetRunnerGroup",
+		attribute.String("github.enterprise", enterprise),
+		attribute.String("github.organization", org),
+		attribute.String("github.runner_group.name", name),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var runnerGroups []*github.RunnerGroup
+
+	if org != "" {
+		runnerGroups, err = c.getOrganizationRunnerGroups(ctx, org, "")
+	} else {
+		runnerGroups, err = c.getEnterpriseRunnerGroups(ctx, enterprise)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, runnerGroup := range runnerGroups {
+		if runnerGroup.GetName() == name {
+			return runnerGroup, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// HasRepositoryAccessToRunnerGroup returns true if the repository, in the owner/name form, can use the runners
+// in the organization runner group.
+func (c *Client) HasRepositoryAccessToRunnerGroup(ctx context.Context, org string, runnerGroup *github.RunnerGroup, repo string) (bool, error) {
+	if runnerGroup.GetVisibility() != "selected" {
+		return true, nil
+	}
+
+	return c.hasRepoAccessToOrganizationRunnerGroup(ctx, org, runnerGroup.GetID(), repo)
+}
+
+// getEnterpriseRunnerGroups lists the runner groups of the enterprise.
+// go-github has no API for them, so the request is made by hand.
+func (c *Client) getEnterpriseRunnerGroups(ctx context.Context, enterprise string) (runnerGroups []*github.RunnerGroup, err error) {
+	ctx, span := tracing.Start(ctx, "ListEnterpriseRunnerGroups")
+	defer func() { tracing.End(span, err) }()
+
+	client := c.enterprise()
+
+	page := 1
+	for {
+		req, err := client.NewRequest("GET", fmt.Sprintf("enterprises/%s/actions/runner-groups?per_page=100&page=%d", enterprise, page), nil)
+		if err != nil {
+			return runnerGroups, err
+		}
+
+		var list github.RunnerGroups
+
+		res, err := client.Do(ctx, req, &list)
+		if err != nil {
+			return runnerGroups, fmt.Errorf("failed to list enterprise runner groups: %w", categorize(err))
+		}
+
+		runnerGroups = append(runnerGroups, list.RunnerGroups...)
+		if res.NextPage == 0 {
+			break
+		}
+		page = res.NextPage
+	}
+
+	return runnerGroups, nil
+}
+
 // cleanup removes expired registration tokens.
 func (c *Client) cleanup() {
 	c.mu.Lock()
@@ -323,6 +707,15 @@ func (c *Client) cleanup() {
 	}
 }
 
+// enterprise returns the client for the enterprise-level API calls.
+func (c *Client) enterprise() *github.Client {
+	if c.enterpriseClient != nil {
+		return c.enterpriseClient
+	}
+
+	return c.Client
+}
+
 // wrappers for github functions (switch between enterprise/organization/repository mode)
 // so the calling functions don't need to switch and their code is a bit cleaner
 
@@ -333,7 +726,7 @@ func (c *Client) createRegistrationToken(ctx context.Context, enterprise, org, r
 	if len(org) > 0 {
 		return c.Client.Actions.CreateOrganizationRegistrationToken(ctx, org)
 	}
-	return c.Client.Enterprise.CreateRegistrationToken(ctx, enterprise)
+	return c.enterprise().Enterprise.CreateRegistrationToken(ctx, enterprise)
 }
 
 func (c *Client) removeRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Response, error) {
@@ -343,7 +736,30 @@ func (c *Client) removeRunner(ctx context.Context, enterprise, org, repo string,
 	if len(org) > 0 {
 		return c.Client.Actions.RemoveOrganizationRunner(ctx, org, runnerID)
 	}
-	return c.Client.Enterprise.RemoveRunner(ctx, enterprise, runnerID)
+	return c.enterprise().Enterprise.RemoveRunner(ctx, enterprise, runnerID)
+}
+
+func (c *Client) getRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Runner, *github.Response, error) {
+	if len(repo) > 0 {
+		return c.Client.Actions.GetRunner(ctx, org, repo, runnerID)
+	}
+	if len(org) > 0 {
+		return c.Client.Actions.GetOrganizationRunner(ctx, org, runnerID)
+	}
+
+	// go-github has no wrapper for the enterprise one
+	req, err := c.enterprise().NewRequest("GET", fmt.Sprintf("enterprises/%v/actions/runners/%v", enterprise, runnerID), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runner := new(github.Runner)
+	res, err := c.enterprise().Do(ctx, req, runner)
+	if err != nil {
+		return nil, res, err
+	}
+
+	return runner, res, nil
 }
 
 func (c *Client) listRunners(ctx context.Context, enterprise, org, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
@@ -353,7 +769,7 @@ func (c *Client) listRunners(ctx context.Context, enterprise, org, repo string,
 	if len(org) > 0 {
 		return c.Client.Actions.ListOrganizationRunners(ctx, org, opts)
 	}
-	return c.Client.Enterprise.ListRunners(ctx, enterprise, opts)
+	return c.enterprise().Enterprise.ListRunners(ctx, enterprise, opts)
 }
 
 func (c *Client) ListRepositoryWorkflowRuns(ctx context.Context, user string, repoName string) ([]*github.WorkflowRun, error) {
@@ -375,6 +791,68 @@ func (c *Client) ListRepositoryWorkflowRuns(ctx context.Context, user string, re
 	return workflowRuns, nil
 }
 
+// ListQueuedWorkflowJobs returns the queued jobs of the queued and in_progress workflow runs of the repository.
+func (c *Client) ListQueuedWorkflowJobs(ctx context.Context, user string, repoName string) ([]*github.WorkflowJob, error) {
+	runs, err := c.ListRepositoryWorkflowRuns(ctx, user, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	var queued []*github.WorkflowJob
+
+	for _, run := range runs {
+		opts := github.ListWorkflowJobsOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+
+		for {
+			list, res, err := c.Client.Actions.ListWorkflowJobs(ctx, user, repoName, run.GetID(), &opts)
+			if err != nil {
+				return queued, fmt.Errorf("failed to list workflow jobs: %w", categorize(err))
+			}
+
+			for _, job := range list.Jobs {
+				if job.GetStatus() == "queued" {
+					queued = append(queued, job)
+				}
+			}
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	return queued, nil
+}
+
+// WorkflowJob is the workflow job with the fields that go-github lacks.
+type WorkflowJob struct {
+	*github.WorkflowJob
+
+	// RunnerName is the name of the runner that picked up the job, or empty while the job is queued.
+	RunnerName string `json:"runner_name,omitempty"`
+}
+
+// GetWorkflowJob returns the workflow job of the repository.
+func (c *Client) GetWorkflowJob(ctx context.Context, user string, repoName string, id int64) (*WorkflowJob, error) {
+	req, err := c.Client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/actions/jobs/%d", user, repoName, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var job WorkflowJob
+
+	if _, err := c.Client.Do(ctx, req, &job); err != nil {
+		return nil, fmt.Errorf("failed to get workflow job: %w", categorize(err))
+	}
+
+	return &job, nil
+}
+
 func (c *Client) listRepositoryWorkflowRuns(ctx context.Context, user string, repoName, status string) ([]*github.WorkflowRun, error) {
 	var workflowRuns []*github.WorkflowRun
 
@@ -389,7 +867,7 @@ func (c *Client) listRepositoryWorkflowRuns(ctx context.Context, user string, re
 		list, res, err := c.Client.Actions.ListRepositoryWorkflowRuns(ctx, user, repoName, &opts)
 
 		if err != nil {
-			return workflowRuns, fmt.Errorf("failed to list workflow runs: %v", err)
+			return workflowRuns, fmt.Errorf("failed to list workflow runs: %w", categorize(err))
 		}
 
 		workflowRuns = append(workflowRuns, list.WorkflowRuns...)
@@ -464,21 +942,72 @@ func (e *RunnerOffline) Error() string {
 }
 
 func (r *Client) IsRunnerBusy(ctx context.Context, enterprise, org, repo, name string) (bool, error) {
+	busy, _, err := r.IsRunnerBusyByID(ctx, enterprise, org, repo, name, 0)
+
+	return busy, err
+}
+
+// IsRunnerBusyByID is IsRunnerBusy that looks up the runner by its ID via the get-single-runner API, instead of listing every runner in the scope.
+// It falls back to listing the runners when the ID is unknown(0), or the runner with the ID is missing or has another name, like after the runner re-registered itself.
+// It returns the ID of the runner, so that the caller can remember it for the next check.
+func (r *Client) IsRunnerBusyByID(ctx context.Context, enterprise, org, repo, name string, id int64) (bool, int64, error) {
+	runner, err := r.getRunnerByNameAndID(ctx, enterprise, org, repo, name, id)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if runner.GetStatus() == "offline" {
+		return runner.GetBusy(), runner.GetID(), &RunnerOffline{runnerName: name}
+	}
+
+	return runner.GetBusy(), runner.GetID(), nil
+}
+
+func (r *Client) getRunnerByNameAndID(ctx context.Context, enterprise, org, repo, name string, id int64) (*github.Runner, error) {
+	if id != 0 {
+		runner, err := r.GetRunner(ctx, enterprise, org, repo, id)
+		if err != nil {
+			var notFound *RunnerNotFound
+			if !errors.As(err, &notFound) {
+				return nil, err
+			}
+		} else if runner.GetName() == name {
+			return runner, nil
+		}
+	}
+
 	runners, err := r.ListRunners(ctx, enterprise, org, repo)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	for _, runner := range runners {
 		if runner.GetName() == name {
-			if runner.GetStatus() == "offline" {
-				return runner.GetBusy(), &RunnerOffline{runnerName: name}
-			}
-			return runner.GetBusy(), nil
+			return runner, nil
+		}
+	}
+
+	return nil, &RunnerNotFound{runnerName: name}
+}
+
+// GetRunner returns the runner with the ID in the enterprise, organization or repository.
+// It returns RunnerNotFound when there's no such runner.
+func (c *Client) GetRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Runner, error) {
+	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, res, err := c.getRunner(ctx, enterprise, owner, repo, runnerID)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return nil, &RunnerNotFound{runnerName: fmt.Sprintf("id=%d", runnerID)}
 		}
+
+		return nil, fmt.Errorf("failed to get runner: %w", categorize(err))
 	}
 
-	return false, &RunnerNotFound{runnerName: name}
+	return runner, nil
 }
 
 func containsString(list []string, value string) bool {
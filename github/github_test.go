@@ -2,12 +2,18 @@ package github
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v39/github"
 )
 
@@ -129,6 +135,50 @@ func TestRemoveRunner(t *testing.T) {
 	}
 }
 
+func TestGetRunnerGroup(t *testing.T) {
+	tests := []struct {
+		enterprise string
+		org        string
+		name       string
+		repo       string
+		found      bool
+		access     bool
+	}{
+		{org: "test", name: "Default", repo: "test/valid", found: true, access: true},
+		{org: "test", name: "selected", repo: "test/valid", found: true, access: true},
+		{org: "test", name: "selected", repo: "test/other", found: true, access: false},
+		{org: "test", name: "enterprise", repo: "test/other", found: true, access: true},
+		{org: "test", name: "missing"},
+		{enterprise: "test", name: "enterprise", found: true},
+		{enterprise: "test", name: "selected"},
+	}
+
+	client := newTestClient()
+	for i, tt := range tests {
+		runnerGroup, err := client.GetRunnerGroup(context.Background(), tt.enterprise, tt.org, tt.name)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if found := runnerGroup != nil; found != tt.found {
+			t.Errorf("[%d] unexpected runner group: want found=%v, got %v", i, tt.found, runnerGroup)
+		}
+
+		if runnerGroup == nil || tt.repo == "" {
+			continue
+		}
+
+		access, err := client.HasRepositoryAccessToRunnerGroup(context.Background(), tt.org, runnerGroup, tt.repo)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if access != tt.access {
+			t.Errorf("[%d] unexpected access of %s: want %v, got %v", i, tt.repo, tt.access, access)
+		}
+	}
+}
+
 func TestCleanup(t *testing.T) {
 	token := "token"
 
@@ -152,3 +202,559 @@ func TestCleanup(t *testing.T) {
 		t.Errorf("expired token still exists")
 	}
 }
+
+func TestNewClientWithProxy(t *testing.T) {
+	// The fake server acts as the proxy here, so that the API calls to the unresolvable host succeed
+	// only when they go through the proxy.
+	c := Config{
+		Token:     "token",
+		URL:       "http://api.github.invalid/",
+		HTTPProxy: server.URL,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.ListRunners(context.Background(), "", "", "test/valid"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewClientWithEnterpriseToken(t *testing.T) {
+	var mu sync.Mutex
+	authorizations := map[string]string{}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations[r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+
+		fmt.Fprint(w, `{"total_count": 0, "runners": []}`)
+	}))
+	defer s.Close()
+
+	c := Config{
+		Token:           "token",
+		EnterpriseToken: "enterprise-token",
+		URL:             s.URL,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.ListRunners(context.Background(), "test", "", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := client.ListRunners(context.Background(), "", "test", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"/enterprises/test/actions/runners": "Bearer enterprise-token",
+		"/orgs/test/actions/runners":        "Bearer token",
+	}
+
+	if d := cmp.Diff(want, authorizations); d != "" {
+		t.Errorf("unexpected authorizations (-want +got):\n%s", d)
+	}
+}
+
+func TestCredentialIdentity(t *testing.T) {
+	testcases := []struct {
+		config         Config
+		want           string
+		wantEnterprise string
+	}{
+		{config: Config{Token: "secret"}, want: "token", wantEnterprise: "enterprise-token"},
+		{config: Config{BasicauthUsername: "user", BasicauthPassword: "secret"}, want: "basicauth:user", wantEnterprise: "enterprise-token"},
+		{config: Config{AppID: 1, AppInstallationID: 2, AppPrivateKey: "secret"}, want: "app:1/2", wantEnterprise: "enterprise-token"},
+		{config: Config{Token: "secret", CredentialName: "secret:default/github"}, want: "secret:default/github", wantEnterprise: "secret:default/github/enterprise-token"},
+	}
+
+	for _, tc := range testcases {
+		if got := tc.config.credentialIdentity(); got != tc.want {
+			t.Errorf("unexpected identity: want %s, got %s", tc.want, got)
+		}
+
+		if got := tc.config.enterpriseCredentialIdentity(); got != tc.wantEnterprise {
+			t.Errorf("unexpected enterprise identity: want %s, got %s", tc.wantEnterprise, got)
+		}
+	}
+}
+
+func TestClientWithFaults(t *testing.T) {
+	faults := fake.NewFaultInjector(1)
+
+	s := fake.NewServer(
+		fake.WithListRunnersResponse(200, fake.RunnersListBody),
+		fake.WithFaultInjector(faults),
+	)
+	defer s.Close()
+
+	var exhausted []time.Time
+
+	newClient := func() *Client {
+		c := Config{
+			Token:                "token",
+			URL:                  s.URL,
+			OnRateLimitExhausted: func(reset time.Time) { exhausted = append(exhausted, reset) },
+		}
+
+		client, err := c.NewClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return client
+	}
+
+	client := newClient()
+	ctx := context.Background()
+
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	faults.Enqueue(
+		fake.ServerErrorFault(http.StatusBadGateway),
+		fake.UnauthorizedFault(),
+		fake.RateLimitFault(reset),
+	)
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !IsTransient(err) {
+		t.Errorf("expected transient error for the server error, got %v", err)
+	}
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !IsUnauthorized(err) {
+		t.Errorf("expected unauthorized error for the expired token, got %v", err)
+	}
+
+	var rateLimitErr *github.RateLimitError
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !errors.As(err, &rateLimitErr) || !IsRateLimited(err) {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+
+	// go-github doesn't send any request until the rate limit resets
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !errors.As(err, &rateLimitErr) {
+		t.Errorf("expected rate limit error without request, got %v", err)
+	}
+
+	if got := faults.Requests(); got != 3 {
+		t.Errorf("unexpected number of requests: want 3, got %d", got)
+	}
+
+	if len(exhausted) != 1 || !exhausted[0].Equal(reset) {
+		t.Errorf("expected the exhausted rate limit to be reported once with the reset time %s, got %v", reset, exhausted)
+	}
+
+	// Recovers once the faults are gone
+	client = newClient()
+
+	runners, err := client.ListRunners(ctx, "", "", "test/valid")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(runners) != 2 {
+		t.Errorf("unexpected runners: %v", runners)
+	}
+
+	faults.SequenceRateLimits(time.Now().Add(time.Hour), 1, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ListRunners(ctx, "", "", "test/valid"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !errors.As(err, &rateLimitErr) {
+		t.Errorf("expected rate limit error after the rate limit ran out, got %v", err)
+	}
+
+	faults.SetErrorRate(0.5)
+
+	client = newClient()
+
+	var failed int
+	for i := 0; i < 100; i++ {
+		if _, err := client.ListRunners(ctx, "", "", "test/valid"); err != nil {
+			failed++
+		}
+	}
+
+	if failed < 30 || failed > 70 {
+		t.Errorf("unexpected number of failures at the error rate of 0.5: %d", failed)
+	}
+}
+
+func TestClientRetriesTransientErrors(t *testing.T) {
+	faults := fake.NewFaultInjector(1)
+
+	s := fake.NewServer(
+		fake.WithListRunnersResponse(200, fake.RunnersListBody),
+		fake.WithFaultInjector(faults),
+	)
+	defer s.Close()
+
+	c := Config{
+		Token:          "token",
+		URL:            s.URL,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  time.Millisecond,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+
+	faults.Enqueue(
+		fake.ServerErrorFault(http.StatusBadGateway),
+		fake.ServerErrorFault(http.StatusServiceUnavailable),
+	)
+
+	runners, err := client.ListRunners(ctx, "", "", "test/valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runners) != 2 {
+		t.Errorf("unexpected runners: %v", runners)
+	}
+
+	if got := faults.Requests(); got != 3 {
+		t.Errorf("unexpected number of requests: want 3, got %d", got)
+	}
+
+	// Gives up after MaxRetries
+	faults.Enqueue(
+		fake.ServerErrorFault(http.StatusBadGateway),
+		fake.ServerErrorFault(http.StatusBadGateway),
+		fake.ServerErrorFault(http.StatusBadGateway),
+	)
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !IsTransient(err) {
+		t.Errorf("expected transient error, got %v", err)
+	}
+
+	if got := faults.Requests(); got != 6 {
+		t.Errorf("unexpected number of requests: want 6, got %d", got)
+	}
+
+	// Non-transient errors are never retried
+	faults.Enqueue(fake.UnauthorizedFault())
+
+	if err := client.RemoveRunner(ctx, "", "", "test/valid", 1); !IsUnauthorized(err) {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+
+	if got := faults.Requests(); got != 7 {
+		t.Errorf("unexpected number of requests: want 7, got %d", got)
+	}
+}
+
+func TestErrorCategoryOf(t *testing.T) {
+	testcases := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{err: nil, want: ""},
+		{err: &github.RateLimitError{}, want: ErrorCategoryRateLimited},
+		{err: &github.AbuseRateLimitError{}, want: ErrorCategoryRateLimited},
+		{err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}, want: ErrorCategoryNotFound},
+		{err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}, want: ErrorCategoryUnauthorized},
+		{err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway}}, want: ErrorCategoryTransient},
+		{err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnprocessableEntity}}, want: ErrorCategoryUnknown},
+		{err: fmt.Errorf("failed to list runners: %w", categorize(&github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}})), want: ErrorCategoryTransient},
+	}
+
+	for _, tc := range testcases {
+		if got := ErrorCategoryOf(tc.err); got != tc.want {
+			t.Errorf("unexpected category of %v: want %q, got %q", tc.err, tc.want, got)
+		}
+	}
+}
+
+func TestClientCapabilities(t *testing.T) {
+	testcases := []struct {
+		version         string
+		wantUnsupported []Capability
+	}{
+		{version: "", wantUnsupported: nil},
+		{version: "2.22.10", wantUnsupported: []Capability{CapabilityRunnerGroups, CapabilityEphemeralRunners, CapabilityWorkflowJobEvents}},
+		{version: "3.2.5", wantUnsupported: []Capability{CapabilityEphemeralRunners, CapabilityWorkflowJobEvents}},
+		{version: "3.3.0", wantUnsupported: nil},
+		{version: "3.10.1", wantUnsupported: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.version, func(t *testing.T) {
+			s := fake.NewServer(fake.WithListRunnersResponse(200, fake.RunnersListBody), fake.WithEnterpriseVersion(tc.version))
+			defer s.Close()
+
+			c := Config{Token: "token", URL: s.URL}
+
+			client, err := c.NewClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			capabilities, err := client.DetectCapabilities(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if capabilities.GHESVersion != tc.version {
+				t.Errorf("unexpected version: want %q, got %q", tc.version, capabilities.GHESVersion)
+			}
+
+			var unsupported []Capability
+			for _, capability := range []Capability{CapabilityRunnerGroups, CapabilityEphemeralRunners, CapabilityWorkflowJobEvents} {
+				if err := client.CheckCapability(context.Background(), capability); IsUnsupported(err) {
+					unsupported = append(unsupported, capability)
+				} else if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+
+			if d := cmp.Diff(tc.wantUnsupported, unsupported); d != "" {
+				t.Errorf("unexpected unsupported capabilities (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestClientCapabilities_DetectionFailure(t *testing.T) {
+	var requests int
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	c := Config{Token: "token", URL: s.URL}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.DetectCapabilities(context.Background()); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// Every feature is tried as usual without probing the server again and again
+	for i := 0; i < 3; i++ {
+		if err := client.CheckCapability(context.Background(), CapabilityRunnerGroups); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("unexpected number of requests: want 1, got %d", requests)
+	}
+}
+
+func TestListRunnersConcurrently(t *testing.T) {
+	const total = 450
+
+	var (
+		mu              sync.Mutex
+		inFlight, maxIn int
+		failPage        string
+	)
+
+	var s *httptest.Server
+
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxIn {
+			maxIn = inFlight
+		}
+		fail := failPage
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		page := req.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+
+		if page == fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var p int
+		fmt.Sscanf(page, "%d", &p)
+
+		last := (total + 99) / 100
+
+		if p < last {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next", <%s%s?page=%d>; rel="last"`, s.URL, req.URL.Path, p+1, s.URL, req.URL.Path, last))
+		}
+
+		var runners []string
+		for id := (p-1)*100 + 1; id <= p*100 && id <= total; id++ {
+			runners = append(runners, fmt.Sprintf(`{"id":%d,"name":"runner-%d"}`, id, id))
+		}
+
+		fmt.Fprintf(w, `{"total_count":%d,"runners":[%s]}`, total, strings.Join(runners, ","))
+	}))
+	defer s.Close()
+
+	c := Config{
+		Token:                  "token",
+		URL:                    s.URL,
+		ListRunnersConcurrency: 2,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runners, err := client.ListRunners(context.Background(), "", "test", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runners) != total {
+		t.Fatalf("unexpected number of runners: want %d, got %d", total, len(runners))
+	}
+
+	for i, r := range runners {
+		if r.GetID() != int64(i+1) {
+			t.Fatalf("unexpected order of runners: runner %d at %d", r.GetID(), i)
+		}
+	}
+
+	mu.Lock()
+	if maxIn != 2 {
+		t.Errorf("unexpected max concurrency: want 2, got %d", maxIn)
+	}
+
+	failPage = "4"
+	mu.Unlock()
+
+	if _, err := client.ListRunners(context.Background(), "", "test", ""); err == nil {
+		t.Errorf("expected error for the failed page")
+	}
+}
+
+func TestDedupeRunners(t *testing.T) {
+	runners := []*github.Runner{
+		{ID: github.Int64(1)},
+		{ID: github.Int64(2)},
+		{ID: github.Int64(2)},
+		{ID: github.Int64(3)},
+	}
+
+	var got []int64
+	for _, r := range dedupeRunners(runners) {
+		got = append(got, r.GetID())
+	}
+
+	if d := cmp.Diff([]int64{1, 2, 3}, got); d != "" {
+		t.Errorf("unexpected runners (-want +got):\n%s", d)
+	}
+}
+
+func TestIsRunnerBusyByID(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		gets, lists  int
+		runnerByID   = map[string]string{"1": `{"id":1,"name":"a","status":"online","busy":true}`, "2": `{"id":2,"name":"other","status":"online","busy":false}`}
+		listResponse = `{"total_count":2,"runners":[{"id":1,"name":"a","status":"online","busy":true},{"id":3,"name":"b","status":"offline","busy":false}]}`
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == "/repos/test/valid/actions/runners" {
+			lists++
+			w.Write([]byte(listResponse))
+			return
+		}
+
+		gets++
+
+		body, ok := runnerByID[strings.TrimPrefix(req.URL.Path, "/repos/test/valid/actions/runners/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+	defer s.Close()
+
+	c := Config{
+		Token: "token",
+		URL:   s.URL,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		id        int64
+		wantBusy  bool
+		wantID    int64
+		wantErr   error
+		wantGets  int
+		wantLists int
+	}{
+		{name: "a", id: 1, wantBusy: true, wantID: 1, wantGets: 1},
+		// The ID is unknown
+		{name: "a", id: 0, wantBusy: true, wantID: 1, wantLists: 1},
+		// The runner re-registered itself with another ID
+		{name: "b", id: 4, wantID: 3, wantErr: &RunnerOffline{runnerName: "b"}, wantGets: 1, wantLists: 1},
+		// The ID is reused for another runner
+		{name: "a", id: 2, wantBusy: true, wantID: 1, wantGets: 1, wantLists: 1},
+		{name: "c", id: 5, wantErr: &RunnerNotFound{runnerName: "c"}, wantGets: 1, wantLists: 1},
+	}
+
+	for i, tt := range tests {
+		mu.Lock()
+		gets, lists = 0, 0
+		mu.Unlock()
+
+		busy, id, err := client.IsRunnerBusyByID(context.Background(), "", "", "test/valid", tt.name, tt.id)
+
+		if fmt.Sprint(err) != fmt.Sprint(tt.wantErr) {
+			t.Errorf("[%d] unexpected error: want %v, got %v", i, tt.wantErr, err)
+		}
+
+		if busy != tt.wantBusy || id != tt.wantID {
+			t.Errorf("[%d] unexpected result: want busy=%v id=%d, got busy=%v id=%d", i, tt.wantBusy, tt.wantID, busy, id)
+		}
+
+		mu.Lock()
+		if gets != tt.wantGets || lists != tt.wantLists {
+			t.Errorf("[%d] unexpected requests: want %d gets and %d lists, got %d gets and %d lists", i, tt.wantGets, tt.wantLists, gets, lists)
+		}
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,232 @@
+package github
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+)
+
+// runnerInventoryIdleIntervals is the number of the refresh intervals after which the runners of a scope
+// that no one read are dropped from the inventory instead of being refreshed.
+const runnerInventoryIdleIntervals = 5
+
+// RunnerInventory is the in-memory snapshot of the runners of each enterprise, organization and repository,
+// shared by every controller that lists runners, so that e.g. the runner, runnerreplicaset and horizontalrunnerautoscaler
+// controllers reconciling for the same organization list its thousands of runners once per interval instead of once per reconciliation.
+//
+// A snapshot is served until it gets older than Interval or is invalidated, after which it's listed again on the next read.
+// The snapshots read recently are refreshed in the background every Interval, so that reads rarely wait for the GitHub API.
+type RunnerInventory struct {
+	Log logr.Logger
+
+	// Interval is the maximum age of the snapshots, and the interval of the background refreshes.
+	Interval time.Duration
+
+	client *Client
+
+	mu     sync.Mutex
+	scopes map[runnerScope]*runnerSnapshot
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type runnerScope struct {
+	enterprise, owner, repo string
+}
+
+type runnerSnapshot struct {
+	runners   []*github.Runner
+	fetchedAt time.Time
+	readAt    time.Time
+	valid     bool
+	// invalidations counts the invalidations, so that a listing started before an invalidation doesn't make the snapshot valid
+	invalidations int
+
+	// fetching is closed when the ongoing listing completes, so that concurrent reads wait for it instead of listing again
+	fetching chan struct{}
+	err      error
+}
+
+// EnableRunnerInventory makes ListRunners serve the runners from the returned inventory, whose snapshots are refreshed every interval.
+// The inventory needs to be started, e.g. by adding it to the controller manager, for the background refreshes.
+func (c *Client) EnableRunnerInventory(interval time.Duration, log logr.Logger) *RunnerInventory {
+	c.inventory = &RunnerInventory{
+		Log:      log,
+		Interval: interval,
+		client:   c,
+		scopes:   map[runnerScope]*runnerSnapshot{},
+		now:      time.Now,
+	}
+
+	return c.inventory
+}
+
+// InvalidateRunners makes the next ListRunners for the enterprise, organization or repository list the runners via the GitHub API,
+// like when a webhook event tells that a runner is about to be registered or picked up a job.
+// It does nothing unless the runner inventory is enabled.
+func (c *Client) InvalidateRunners(enterprise, org, repo string) {
+	if c.inventory == nil {
+		return
+	}
+
+	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
+	if err != nil {
+		return
+	}
+
+	c.inventory.invalidate(runnerScope{enterprise: enterprise, owner: owner, repo: repo})
+}
+
+// list returns the snapshot of the runners of the scope, listing them via the GitHub API when it's missing, stale or invalidated.
+func (i *RunnerInventory) list(ctx context.Context, scope runnerScope) ([]*github.Runner, error) {
+	i.mu.Lock()
+
+	s, ok := i.scopes[scope]
+	if !ok {
+		s = &runnerSnapshot{}
+		i.scopes[scope] = s
+	}
+
+	now := i.now()
+
+	s.readAt = now
+
+	if s.valid && now.Sub(s.fetchedAt) < i.Interval {
+		runners := append([]*github.Runner{}, s.runners...)
+
+		i.mu.Unlock()
+
+		return runners, nil
+	}
+
+	fetching := s.fetching
+	if fetching == nil {
+		fetching = i.startFetchLocked(scope, s, false)
+	}
+
+	i.mu.Unlock()
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return append([]*github.Runner{}, s.runners...), s.err
+}
+
+// startFetchLocked lists the runners of the scope in the background and returns the channel closed on completion.
+// The failure of the background refresh is logged, while the failure of the listing started by a read is returned to the readers.
+func (i *RunnerInventory) startFetchLocked(scope runnerScope, s *runnerSnapshot, refresh bool) chan struct{} {
+	fetching := make(chan struct{})
+
+	s.fetching = fetching
+
+	invalidations := s.invalidations
+
+	go func() {
+		defer close(fetching)
+
+		// The listing outlives the read that started it, as other reads may be waiting for it
+		runners, err := i.client.listRunnersFromAPI(context.Background(), scope.enterprise, scope.owner, scope.repo)
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		s.fetching = nil
+		s.err = err
+
+		if err != nil {
+			// The next read lists the runners again
+			s.valid = false
+
+			if refresh {
+				i.Log.Error(err, "Failed to refresh runner inventory", "enterprise", scope.enterprise, "owner", scope.owner, "repository", scope.repo)
+			}
+
+			return
+		}
+
+		s.runners = runners
+		s.fetchedAt = i.now()
+		s.valid = s.invalidations == invalidations
+	}()
+
+	return fetching
+}
+
+func (i *RunnerInventory) invalidate(scope runnerScope) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if s, ok := i.scopes[scope]; ok {
+		s.valid = false
+		s.invalidations++
+	}
+}
+
+// refresh lists the runners of the scopes read within the last runnerInventoryIdleIntervals one scope at a time, and drops the others.
+func (i *RunnerInventory) refresh(ctx context.Context) {
+	i.mu.Lock()
+
+	now := i.now()
+
+	var scopes []runnerScope
+
+	for scope, s := range i.scopes {
+		if now.Sub(s.readAt) > runnerInventoryIdleIntervals*i.Interval {
+			if s.fetching == nil {
+				delete(i.scopes, scope)
+			}
+
+			continue
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	i.mu.Unlock()
+
+	for _, scope := range scopes {
+		i.mu.Lock()
+
+		s, ok := i.scopes[scope]
+		if !ok || s.fetching != nil {
+			i.mu.Unlock()
+
+			continue
+		}
+
+		fetching := i.startFetchLocked(scope, s, true)
+
+		i.mu.Unlock()
+
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Start refreshes the snapshots every Interval until the context is canceled.
+func (i *RunnerInventory) Start(ctx context.Context) error {
+	ticker := time.NewTicker(i.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			i.refresh(ctx)
+		}
+	}
+}
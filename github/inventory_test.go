@@ -0,0 +1,121 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestRunnerInventory(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		// Concurrent reads wait for the same listing
+		time.Sleep(10 * time.Millisecond)
+
+		w.Write([]byte(`{"total_count":2,"runners":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`))
+	}))
+	defer s.Close()
+
+	c := Config{
+		Token: "token",
+		URL:   s.URL,
+	}
+
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inventory := client.EnableRunnerInventory(time.Minute, logr.Discard())
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	inventory.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	list := func(wantRequests int) {
+		t.Helper()
+
+		runners, err := client.ListRunners(ctx, "", "test", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(runners) != 2 {
+			t.Errorf("unexpected runners: %v", runners)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if requests != wantRequests {
+			t.Errorf("unexpected number of requests: want %d, got %d", wantRequests, requests)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ListRunners(ctx, "", "test", ""); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	list(1)
+
+	// Served from the snapshot until it gets older than the interval
+	now = now.Add(30 * time.Second)
+
+	list(1)
+
+	now = now.Add(time.Minute)
+
+	list(2)
+
+	// Invalidated by the signal for the scope only
+	client.InvalidateRunners("", "other", "")
+
+	list(2)
+
+	client.InvalidateRunners("", "test", "")
+
+	list(3)
+
+	// Refreshed in the background while read recently, and dropped once idle
+	inventory.refresh(ctx)
+
+	list(4)
+
+	now = now.Add(runnerInventoryIdleIntervals*time.Minute + time.Second)
+
+	inventory.refresh(ctx)
+
+	mu.Lock()
+	if requests != 4 {
+		t.Errorf("unexpected refresh of the idle scope")
+	}
+	mu.Unlock()
+
+	inventory.mu.Lock()
+	if n := len(inventory.scopes); n != 0 {
+		t.Errorf("unexpected number of scopes: want 0, got %d", n)
+	}
+	inventory.mu.Unlock()
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(metricConditionalRequests)
+}
+
+const (
+	// ConditionalRequestHit is the result of the conditional request answered with 304 Not Modified,
+	// which doesn't count against the rate limit.
+	ConditionalRequestHit = "hit"
+	// ConditionalRequestMiss is the result of the request that wasn't served from the cache,
+	// either because the response was modified or because there was no cached response to the URL yet.
+	ConditionalRequestMiss = "miss"
+)
+
+var (
+	metricConditionalRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_conditional_requests_total",
+			Help: "The number of the GitHub API calls to the endpoints cached by ETag, by the endpoint and whether the call was served from the cache(hit) or not(miss)",
+		},
+		[]string{"endpoint", "result"},
+	)
+)
+
+// ObserveConditionalRequest counts the conditional request made to the endpoint with the result, either ConditionalRequestHit or ConditionalRequestMiss.
+func ObserveConditionalRequest(endpoint, result string) {
+	metricConditionalRequests.WithLabelValues(endpoint, result).Inc()
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"strings"
+)
+
+// ownerSegments are the path segments followed by the name of the owner of the resources, which is templated.
+var ownerSegments = map[string]string{
+	"orgs":        "{org}",
+	"enterprises": "{enterprise}",
+	"users":       "{user}",
+}
+
+// refSegments are the path segments followed by a Git ref, a SHA, or a name, which is templated.
+var refSegments = map[string]string{
+	"commits":  "{ref}",
+	"statuses": "{sha}",
+	"tags":     "{tag}",
+}
+
+// EndpointTemplate returns the template of the GitHub API endpoint the path is of, like /repos/{owner}/{repo}/actions/runners/{id},
+// so that the requests are counted per endpoint rather than per URL. The prefix of GitHub Enterprise Server, /api/v3, is removed.
+func EndpointTemplate(path string) string {
+	path = strings.TrimPrefix(path, "/api/v3")
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(segments) == 1 && segments[0] == "" {
+		return "/"
+	}
+
+	for i := 0; i < len(segments); i++ {
+		s := segments[i]
+
+		switch {
+		case i == 0 && s == "repos" && len(segments) >= 3:
+			segments[1], segments[2] = "{owner}", "{repo}"
+			i += 2
+		case i == 0 && ownerSegments[s] != "" && len(segments) >= 2:
+			segments[1] = ownerSegments[s]
+			i++
+		case s == "contents" && i+1 < len(segments):
+			// The path of the file can have any number of segments
+			segments = append(segments[:i+1], "{path}")
+			i++
+		case refSegments[s] != "" && i+1 < len(segments):
+			segments[i+1] = refSegments[s]
+			i++
+		case isNumeric(s):
+			segments[i] = "{id}"
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	testcases := map[string]string{
+		"/":                                                  "/",
+		"/repos/test/valid/actions/runners/123":              "/repos/{owner}/{repo}/actions/runners/{id}",
+		"/api/v3/repos/test/valid/actions/runs":              "/repos/{owner}/{repo}/actions/runs",
+		"/repos/test/valid/actions/runs/1/jobs":              "/repos/{owner}/{repo}/actions/runs/{id}/jobs",
+		"/orgs/test/actions/runners/registration-token":      "/orgs/{org}/actions/runners/registration-token",
+		"/enterprises/test/actions/runner-groups":            "/enterprises/{enterprise}/actions/runner-groups",
+		"/repos/test/valid/commits/main/check-runs":          "/repos/{owner}/{repo}/commits/{ref}/check-runs",
+		"/repos/test/valid/statuses/0123abcd":                "/repos/{owner}/{repo}/statuses/{sha}",
+		"/repos/test/valid/contents/.github/workflows/a.yml": "/repos/{owner}/{repo}/contents/{path}",
+		"/app/installations/42/access_tokens":                "/app/installations/{id}/access_tokens",
+		"/user":                                              "/user",
+	}
+
+	for path, want := range testcases {
+		if got := EndpointTemplate(path); got != want {
+			t.Errorf("unexpected template of %s: want %s, got %s", path, want, got)
+		}
+	}
+}
@@ -7,13 +7,21 @@ package metrics
 import (
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 func init() {
-	metrics.Registry.MustRegister(metricRateLimit, metricRateLimitRemaining)
+	metrics.Registry.MustRegister(
+		metricRateLimit,
+		metricRateLimitRemaining,
+		metricRequests,
+		metricRateLimitRemainingByCredential,
+		metricRateLimitResetByCredential,
+	)
 }
 
 var (
@@ -32,26 +40,99 @@ var (
 	)
 )
 
+var (
+	metricRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_api_requests_total",
+			Help: "The number of the GitHub API calls, by the endpoint template, the method, the status code, and the credentials they're authenticated with",
+		},
+		[]string{"endpoint", "method", "status", "credential"},
+	)
+	metricRateLimitRemainingByCredential = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "github_api_rate_limit_remaining",
+			Help: "The number of requests remaining in the current rate limit window of the credentials, by the rate limit resource like core or search",
+		},
+		[]string{"credential", "resource"},
+	)
+	metricRateLimitResetByCredential = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "github_api_rate_limit_reset_timestamp_seconds",
+			Help: "The time the current rate limit window of the credentials resets in seconds since the epoch, by the rate limit resource like core or search",
+		},
+		[]string{"credential", "resource"},
+	)
+)
+
 const (
 	// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
 	headerRateLimit          = "X-RateLimit-Limit"
 	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRateLimitResource  = "X-RateLimit-Resource"
+
+	// statusError is the status of the requests that failed without a response, like on a connection error.
+	statusError = "error"
 )
 
+// RateLimit is the rate limit of the GitHub API observed in the headers of a response.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// ObservedAt is the time the response was received.
+	ObservedAt time.Time
+}
+
+var (
+	lastRateLimit   *RateLimit
+	lastRateLimitMu sync.Mutex
+)
+
+// LastRateLimit returns the rate limit observed in the latest response of the GitHub API,
+// or nil if no response with the rate limit headers has been received yet.
+func LastRateLimit() *RateLimit {
+	lastRateLimitMu.Lock()
+	defer lastRateLimitMu.Unlock()
+
+	if lastRateLimit == nil {
+		return nil
+	}
+
+	l := *lastRateLimit
+
+	return &l
+}
+
 // Transport wraps a transport with metrics monitoring
 type Transport struct {
 	Transport http.RoundTripper
+
+	// OnRateLimitExhausted is called with the time the rate limit resets when no request remains. Can be nil.
+	OnRateLimitExhausted func(reset time.Time)
+
+	// Credential identifies the credentials the requests are authenticated with, without revealing them,
+	// so that the API calls and the rate limits are told apart per GitHub App or token.
+	Credential string
 }
 
 func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	resp, err := t.Transport.RoundTrip(req)
+
+	status := statusError
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	metricRequests.WithLabelValues(EndpointTemplate(req.URL.Path), req.Method, status, t.Credential).Inc()
+
 	if resp != nil {
-		parseResponse(resp)
+		t.parseResponse(resp)
 	}
 	return resp, err
 }
 
-func parseResponse(resp *http.Response) {
+func (t Transport) parseResponse(resp *http.Response) {
 	rateLimit, err := strconv.Atoi(resp.Header.Get(headerRateLimit))
 	if err == nil {
 		metricRateLimit.Set(float64(rateLimit))
@@ -59,5 +140,29 @@ func parseResponse(resp *http.Response) {
 	rateLimitRemaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining))
 	if err == nil {
 		metricRateLimitRemaining.Set(float64(rateLimitRemaining))
+
+		var reset time.Time
+		if epoch, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil {
+			reset = time.Unix(epoch, 0)
+		}
+
+		resource := resp.Header.Get(headerRateLimitResource)
+		if resource == "" {
+			resource = "core"
+		}
+
+		metricRateLimitRemainingByCredential.WithLabelValues(t.Credential, resource).Set(float64(rateLimitRemaining))
+
+		if !reset.IsZero() {
+			metricRateLimitResetByCredential.WithLabelValues(t.Credential, resource).Set(float64(reset.Unix()))
+		}
+
+		lastRateLimitMu.Lock()
+		lastRateLimit = &RateLimit{Limit: rateLimit, Remaining: rateLimitRemaining, Reset: reset, ObservedAt: time.Now()}
+		lastRateLimitMu.Unlock()
+
+		if rateLimitRemaining == 0 && t.OnRateLimitExhausted != nil {
+			t.OnRateLimitExhausted(reset)
+		}
 	}
 }
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "5000")
+		w.Header().Set(headerRateLimitRemaining, "4999")
+		w.Header().Set(headerRateLimitReset, "1646136000")
+		w.Header().Set(headerRateLimitResource, "core")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	client := &http.Client{Transport: Transport{Transport: http.DefaultTransport, Credential: "app:1/2"}}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(s.URL + "/repos/test/valid/actions/runners/" + strconv.Itoa(i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if got := testutil.ToFloat64(metricRequests.WithLabelValues("/repos/{owner}/{repo}/actions/runners/{id}", "GET", "404", "app:1/2")); got != 2 {
+		t.Errorf("unexpected number of requests: %v", got)
+	}
+
+	if got := testutil.ToFloat64(metricRateLimitRemainingByCredential.WithLabelValues("app:1/2", "core")); got != 4999 {
+		t.Errorf("unexpected remaining rate limit: %v", got)
+	}
+
+	if got := testutil.ToFloat64(metricRateLimitResetByCredential.WithLabelValues("app:1/2", "core")); got != 1646136000 {
+		t.Errorf("unexpected rate limit reset: %v", got)
+	}
+}
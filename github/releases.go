@@ -0,0 +1,186 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultRunnerReleaseFeedURL is the GitHub API endpoint that lists the releases of actions/runner.
+const DefaultRunnerReleaseFeedURL = "https://api.github.com/repos/actions/runner/releases"
+
+// RunnerReleaseFeed watches the releases of actions/runner, so that the runners can be upgraded automatically
+// when a new version is published.
+//
+// The releases are listed without authentication, as they are public and the feed is polled rarely,
+// so that the feed works the same regardless of the GitHub Enterprise Server the runners are registered to.
+type RunnerReleaseFeed struct {
+	Log logr.Logger
+
+	// URL is the endpoint that lists the releases in the format of the GitHub API.
+	URL string
+
+	// Interval is the interval between the listings.
+	Interval time.Duration
+
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	versions []string
+}
+
+// NewRunnerReleaseFeed returns the feed of the releases listed from the URL every interval.
+// The feed needs to be started, e.g. by adding it to the controller manager.
+func NewRunnerReleaseFeed(url string, interval time.Duration, log logr.Logger) *RunnerReleaseFeed {
+	if url == "" {
+		url = DefaultRunnerReleaseFeedURL
+	}
+
+	return &RunnerReleaseFeed{
+		Log:        log,
+		URL:        url,
+		Interval:   interval,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// LatestVersion returns the latest version published in the channel, without the `v` prefix.
+// The channel is either empty for all the versions or a version prefix like "2.290".
+// It returns an empty string until the releases are listed.
+func (f *RunnerReleaseFeed) LatestVersion(channel string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var latest string
+
+	for _, v := range f.versions {
+		if channel != "" && v != channel && !strings.HasPrefix(v, channel+".") {
+			continue
+		}
+
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+
+	return latest
+}
+
+func (f *RunnerReleaseFeed) Start(ctx context.Context) error {
+	f.refresh(ctx)
+
+	ticker := time.NewTicker(f.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			f.refresh(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection makes the feed run on every replica of the controller, as it only caches the releases.
+func (f *RunnerReleaseFeed) NeedLeaderElection() bool {
+	return false
+}
+
+func (f *RunnerReleaseFeed) refresh(ctx context.Context) {
+	versions, err := f.list(ctx)
+	if err != nil {
+		f.Log.Error(err, "Failed to list the releases of actions/runner. Keeping the last listed versions")
+
+		return
+	}
+
+	f.mu.Lock()
+	f.versions = versions
+	f.mu.Unlock()
+
+	f.Log.V(1).Info("Listed the releases of actions/runner", "versions", len(versions))
+}
+
+// list returns the versions of the releases, excluding the drafts and the pre-releases.
+func (f *RunnerReleaseFeed) list(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	res, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status listing releases: %s", res.Status)
+	}
+
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("decoding releases: %w", err)
+	}
+
+	var versions []string
+
+	for _, r := range releases {
+		if r.Draft || r.Prerelease {
+			continue
+		}
+
+		v := strings.TrimPrefix(r.TagName, "v")
+		if _, ok := parseVersion(v); !ok {
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+// compareVersions compares the dot-separated numeric versions, returning a positive number when a is newer than b.
+func compareVersions(a, b string) int {
+	x, _ := parseVersion(a)
+	y, _ := parseVersion(b)
+
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return x[i] - y[i]
+		}
+	}
+
+	return len(x) - len(y)
+}
+
+func parseVersion(v string) ([]int, bool) {
+	var parts []int
+
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+
+		parts = append(parts, n)
+	}
+
+	return parts, true
+}
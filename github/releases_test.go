@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestRunnerReleaseFeed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[
+			{"tag_name":"v2.291.0","prerelease":true},
+			{"tag_name":"v2.290.1"},
+			{"tag_name":"v2.290.0"},
+			{"tag_name":"v2.289.10"},
+			{"tag_name":"v2.289.9"},
+			{"tag_name":"v2.292.0","draft":true}
+		]`))
+	}))
+	defer s.Close()
+
+	feed := NewRunnerReleaseFeed(s.URL, time.Hour, logr.Discard())
+
+	if got := feed.LatestVersion(""); got != "" {
+		t.Errorf("unexpected version before listing: %q", got)
+	}
+
+	feed.refresh(context.Background())
+
+	testcases := map[string]string{
+		"":        "2.290.1",
+		"2.289":   "2.289.10",
+		"2.28":    "",
+		"2":       "2.290.1",
+		"2.290.0": "2.290.0",
+	}
+
+	for channel, want := range testcases {
+		if got := feed.LatestVersion(channel); got != want {
+			t.Errorf("unexpected latest version in channel %q: want %q, got %q", channel, want, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 10 * time.Second
+)
+
+// retryTransport retries the GitHub API calls that failed with transient errors, like 502 Bad Gateway or a connection reset,
+// with jittered exponential backoff. Rate limits aren't retried here, because the caller needs to wait until they reset.
+type retryTransport struct {
+	Transport  http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	// sleep is overridden in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := t.Transport.RoundTrip(req)
+
+		if attempt >= t.MaxRetries || !isRetriable(req, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			// Drain and close the body to let the connection be reused
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepWithContext
+		}
+
+		if err := sleep(req.Context(), t.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before the retry, which is randomized between the half and the whole of
+// the exponentially growing delay so that concurrent reconciliations don't retry at once.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	base := t.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	max := t.MaxDelay
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	d := base << uint(attempt)
+	if d <= 0 || d > max {
+		d = max
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func isRetriable(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	return isTransientStatus(res.StatusCode)
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -17,22 +17,28 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/externalmetrics"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/anomaly"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/logging"
 	"github.com/kelseyhightower/envconfig"
-	zaplib "go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -40,10 +46,8 @@ const (
 	defaultRunnerImage = "summerwind/actions-runner:latest"
 	defaultDockerImage = "docker:dind"
 
-	logLevelDebug = "debug"
-	logLevelInfo  = "info"
-	logLevelWarn  = "warn"
-	logLevelError = "error"
+	componentControllers       = "controllers"
+	componentAdmissionWebhooks = "admission-webhooks"
 )
 
 var (
@@ -75,6 +79,7 @@ func main() {
 		ghClient *github.Client
 
 		metricsAddr          string
+		webhookAddr          string
 		enableLeaderElection bool
 		leaderElectionId     string
 		syncPeriod           time.Duration
@@ -82,14 +87,77 @@ func main() {
 		gitHubAPICacheDuration time.Duration
 
 		runnerImage            string
+		arm64RunnerImage       string
+		windowsRunnerImage     string
 		runnerImagePullSecrets stringSlice
 
 		dockerImage          string
 		dockerRegistryMirror string
+		runnerCacheURL       string
 		namespace            string
 		logLevel             string
 
+		logSampling          logging.SamplingOptions
+		logRateLimitInterval time.Duration
+		runtimeSettingsFile  string
+
+		anomalyNotificationConfig string
+
 		commonRunnerLabels commaSeparatedStringSlice
+
+		offlineRunnerGCGracePeriod time.Duration
+		offlineRunnerGCInterval    time.Duration
+
+		terminalRunnerGCSucceededTTL       time.Duration
+		terminalRunnerGCFailedTTL          time.Duration
+		terminalRunnerGCDeletionsPerSecond float64
+		terminalRunnerGCBatchSize          int
+
+		externalMetricsAddr    string
+		externalMetricsCertDir string
+
+		kedaExternalScalerAddr    string
+		kedaExternalScalerCertDir string
+
+		components commaSeparatedStringSlice
+
+		handleRunnerInterruptions bool
+		watchNodes                bool
+		nodeTerminationTaints     commaSeparatedStringSlice
+		reAddInterruptedCapacity  bool
+
+		githubAccountMaxRunners int
+		clusterMaxRunners       int
+
+		enableNamespaceTemplates bool
+		enableRunnerQuotas       bool
+		enableRunnerPools        bool
+
+		busyLedgerSnapshotInterval time.Duration
+		busyLedgerDriftThreshold   float64
+
+		missedWorkflowJobCheckInterval time.Duration
+		missedWorkflowJobGracePeriod   time.Duration
+
+		runnerInventoryRefreshInterval time.Duration
+
+		runnerUsageInterval       time.Duration
+		runnerUsageDailySummaries bool
+
+		runnerDiagnosticsTailLines  int64
+		runnerDiagnosticsConfigMaps bool
+
+		runnerVersionCheckInterval time.Duration
+		runnerReleaseFeedURL       string
+
+		runnerFleetStatusInterval time.Duration
+
+		runnerDeletionParallelism int
+
+		kubeAPIQPS              float64
+		kubeAPIBurst            int
+		maxConcurrentReconciles commaSeparatedStringSlice
+		resyncPeriods           commaSeparatedStringSlice
 	)
 
 	var c github.Config
@@ -100,44 +168,145 @@ func main() {
 	}
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&webhookAddr, "webhook-addr", ":9443", "The address the admission webhook server binds to. An empty host like :9443 binds to all the IPv4 and IPv6 addresses. Use e.g. [::]:9443 or [fd00::1]:9443 to bind to IPv6 addresses explicitly.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&leaderElectionId, "leader-election-id", "actions-runner-controller", "Controller id for leader election.")
 	flag.StringVar(&runnerImage, "runner-image", defaultRunnerImage, "The image name of self-hosted runner container.")
+	flag.StringVar(&arm64RunnerImage, "arm64-runner-image", "", "The image name of self-hosted runner container for the runners with arch: arm64 or the kubernetes.io/arch=arm64 node selector. Defaults to --runner-image, which needs to be a multi-arch image then.")
+	flag.StringVar(&windowsRunnerImage, "windows-runner-image", "", "The image name of self-hosted runner container for the runners with os: windows.")
 	flag.StringVar(&dockerImage, "docker-image", defaultDockerImage, "The image name of docker sidecar container.")
 	flag.Var(&runnerImagePullSecrets, "runner-image-pull-secret", "The default image-pull secret name for self-hosted runner container.")
 	flag.StringVar(&dockerRegistryMirror, "docker-registry-mirror", "", "The default Docker Registry Mirror used by runners.")
+	flag.StringVar(&runnerCacheURL, "runner-cache-url", "", "The base URL of the actions/cache compatible cache server used by the runners without spec.cache, instead of the cache of GitHub. Requires the runner image to ship the job hooks of actions-runner-controller")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
+	flag.StringVar(&c.EnterpriseToken, "github-enterprise-token", c.EnterpriseToken, "The personal access token of GitHub used only for the enterprise-level API calls, like managing enterprise runners. Set along with the GitHub App credential, which can't call them, to use the GitHub App for the other API calls")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&c.AppInstallationID, "github-app-installation-id", c.AppInstallationID, "The installation ID of GitHub App.")
 	flag.StringVar(&c.AppPrivateKey, "github-app-private-key", c.AppPrivateKey, "The path of a private key file to authenticate as a GitHub App")
+	flag.StringVar(&c.CredentialsDir, "github-credentials-dir", c.CredentialsDir, "The directory of the files of the GitHub credentials named after the keys of the controller's Secret, like github_token and github_app_private_key. The files take precedence over the other GitHub credential flags, and are watched so that the rotated credentials, like the ones written by Vault Agent or External Secrets, are used without restarting")
 	flag.StringVar(&c.URL, "github-url", c.URL, "GitHub URL to be used for GitHub API calls")
 	flag.StringVar(&c.UploadURL, "github-upload-url", c.UploadURL, "GitHub Upload URL to be used for GitHub API calls")
 	flag.StringVar(&c.BasicauthUsername, "github-basicauth-username", c.BasicauthUsername, "Username for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.BasicauthPassword, "github-basicauth-password", c.BasicauthPassword, "Password for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.RunnerGitHubURL, "runner-github-url", c.RunnerGitHubURL, "GitHub URL to be used by runners during registration")
+	flag.StringVar(&c.HTTPProxy, "github-http-proxy", c.HTTPProxy, "The proxy URL for plain HTTP GitHub API calls. Defaults to the HTTP_PROXY environment variable")
+	flag.StringVar(&c.HTTPSProxy, "github-https-proxy", c.HTTPSProxy, "The proxy URL for HTTPS GitHub API calls. Defaults to the HTTPS_PROXY environment variable")
+	flag.StringVar(&c.NoProxy, "github-no-proxy", c.NoProxy, "Comma-separated list of hosts that GitHub API calls should reach without the proxy. Defaults to the NO_PROXY environment variable")
+	flag.IntVar(&c.MaxRetries, "github-api-max-retries", c.MaxRetries, "The number of times a GitHub API call that failed with a transient error, like 502 Bad Gateway, is retried with jittered exponential backoff before the reconciliation is requeued. Set to 0 to disable retries")
+	flag.DurationVar(&c.RetryBaseDelay, "github-api-retry-base-delay", c.RetryBaseDelay, "The initial delay between the retries of GitHub API calls. Defaults to 500ms")
+	flag.DurationVar(&c.RetryMaxDelay, "github-api-retry-max-delay", c.RetryMaxDelay, "The maximum delay between the retries of GitHub API calls. Defaults to 10s")
+	flag.IntVar(&c.ListRunnersConcurrency, "github-api-list-runners-concurrency", c.ListRunnersConcurrency, "The number of the pages of runners fetched concurrently when listing more than 100 runners. Set to 1 to fetch the pages one by one")
+	flag.DurationVar(&runnerInventoryRefreshInterval, "runner-inventory-refresh-interval", 0, "The interval at which the runners of each enterprise, organization and repository are listed from the GitHub API into the inventory shared by all controllers, instead of every controller listing them on every reconciliation. Changes in the capacity reservations made by the webhook-based autoscaler refresh the runners of the scale target on the next read. Defaults to 0, which disables the inventory")
 	flag.DurationVar(&gitHubAPICacheDuration, "github-api-cache-duration", 0, "The duration until the GitHub API cache expires. Setting this to e.g. 10m results in the controller tries its best not to make the same API call within 10m to reduce the chance of being rate-limited. Defaults to mostly the same value as sync-period. If you're tweaking this in order to make autoscaling more responsive, you'll probably want to tweak sync-period, too")
 	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Minute, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled. When you use autoscaling, set to a lower value like 10 minute, because this corresponds to the minimum time to react on demand change. . If you're tweaking this in order to make autoscaling more responsive, you'll probably want to tweak github-api-cache-duration, too")
 	flag.Var(&commonRunnerLabels, "common-runner-labels", "Runner labels in the K1=V1,K2=V2,... format that are inherited all the runners created by the controller. See https://github.com/actions-runner-controller/actions-runner-controller/issues/321 for more information")
-	flag.StringVar(&namespace, "watch-namespace", "", "The namespace to watch for custom resources. Set to empty for letting it watch for all namespaces.")
-	flag.StringVar(&logLevel, "log-level", logLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
+	flag.StringVar(&namespace, "watch-namespace", "", "Comma-separated list of the namespaces to watch for custom resources and runner pods. Set to empty for letting it watch for all namespaces. With one or more namespaces, the controller needs only Roles in those namespaces, unless -handle-runner-interruptions is set without -watch-nodes=false")
+	flag.StringVar(&logLevel, "log-level", logging.LogLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
+	flag.IntVar(&logSampling.First, "log-sampling-first", 0, "The number of log lines with the same level and message logged per second before the rest are sampled. Defaults to 0, which disables the sampling")
+	flag.IntVar(&logSampling.Thereafter, "log-sampling-thereafter", 100, "Every N-th log line with the same level and message is logged once -log-sampling-first lines are logged within a second. Only used when -log-sampling-first is set")
+	flag.DurationVar(&logRateLimitInterval, "log-rate-limit-interval", 0, "The minimum interval between repetitive warnings with the same reason, like a webhook event without a scale target. The suppressed lines are still counted in the log_events_suppressed_total metric. Defaults to 0, which logs every line")
+	flag.StringVar(&runtimeSettingsFile, "runtime-settings-file", "", "The path to the YAML file, usually mounted from a configmap, that overrides logLevel and logRateLimitInterval. The file is re-read on change so that they can be changed without restarting the controller")
+	flag.StringVar(&anomalyNotificationConfig, "anomaly-notification-config", "", "The path to the YAML file that configures the webhooks, like Slack incoming webhooks or PagerDuty Events API, notified of anomalies like repeated registration failures of runners and the exhausted GitHub API rate limit. Defaults to empty, which disables the notifications")
+	flag.DurationVar(&offlineRunnerGCGracePeriod, "offline-runner-gc-grace-period", 0, "The duration a GitHub runner of a RunnerDeployment or a RunnerSet needs to be offline without any backing pod before the controller unregisters it from GitHub. Set to e.g. 30m to enable the cleanup of offline runners left behind by uncleanly terminated pods. Defaults to 0, which disables the cleanup")
+	flag.DurationVar(&offlineRunnerGCInterval, "offline-runner-gc-interval", controllers.DefaultOfflineRunnerCollectionInterval, "The interval at which the controller lists runners on GitHub to find offline runners without pods, per RunnerDeployment and RunnerSet. Only used when -offline-runner-gc-grace-period is set")
+	flag.DurationVar(&terminalRunnerGCSucceededTTL, "terminal-runner-gc-succeeded-ttl", 0, "The duration a runner whose pod has succeeded is retained before the controller deletes the runner and its pod. Defaults to 0, which retains it until something else deletes it")
+	flag.DurationVar(&terminalRunnerGCFailedTTL, "terminal-runner-gc-failed-ttl", 0, "The duration a runner whose pod has failed, e.g. by an eviction, is retained before the controller deletes the runner and its pod. Set it longer than -terminal-runner-gc-succeeded-ttl to leave time to investigate failures. Defaults to 0, which retains it until something else deletes it")
+	flag.Float64Var(&terminalRunnerGCDeletionsPerSecond, "terminal-runner-gc-deletions-per-second", controllers.DefaultTerminalRunnerGCDeletionsPerSecond, "The maximum rate of the deletions of terminal runners, so that a backlog of them doesn't flood the Kubernetes API server. Only used when -terminal-runner-gc-succeeded-ttl or -terminal-runner-gc-failed-ttl is set")
+	flag.IntVar(&terminalRunnerGCBatchSize, "terminal-runner-gc-batch-size", controllers.DefaultTerminalRunnerGCBatchSize, "The maximum number of terminal runners deleted at once before being throttled to -terminal-runner-gc-deletions-per-second")
+	flag.StringVar(&externalMetricsAddr, "external-metrics-addr", "", "The address the external.metrics.k8s.io API server binds to, e.g. :6443. Defaults to empty, which disables the external metrics API")
+	flag.StringVar(&externalMetricsCertDir, "external-metrics-cert-dir", "", "The directory that contains tls.crt and tls.key for the external metrics API server. A self-signed certificate is used when omitted")
+	flag.StringVar(&kedaExternalScalerAddr, "keda-external-scaler-addr", "", "The address the gRPC server implementing KEDA's external scaler binds to, e.g. :9090. Defaults to empty, which disables the external scaler")
+	flag.StringVar(&kedaExternalScalerCertDir, "keda-external-scaler-cert-dir", "", "The directory that contains tls.crt and tls.key, and optionally ca.crt for verifying the client certificates, for the KEDA external scaler. A self-signed certificate is used when omitted")
+	flag.BoolVar(&handleRunnerInterruptions, "handle-runner-interruptions", false, "Detect runner pods of RunnerDeployments interrupted by evictions and node terminations, like the ones of spot or preemptible instances, to release the capacity reserved for their jobs and count them in the runnerdeployment_interruptions_total metric")
+	flag.BoolVar(&watchNodes, "watch-nodes", true, "Watch nodes for the taints of node termination handlers when -handle-runner-interruptions is set. Set to false for running the controller without the cluster-wide permission to watch nodes, in which case only evictions and pods marked by the node shutdown manager are detected as interruptions")
+	flag.Var(&nodeTerminationTaints, "node-termination-taints", fmt.Sprintf("Comma-separated list of the keys of the taints that node termination handlers put on nodes about to be terminated. Only used when -handle-runner-interruptions is set. Defaults to %s", strings.Join(controllers.DefaultNodeTerminationTaints, ",")))
+	flag.BoolVar(&reAddInterruptedCapacity, "readd-interrupted-capacity", false, "Re-add the capacity released for the job of an interrupted runner as a new reservation, so that the job can be retried without waiting for a scale up. Only used when -handle-runner-interruptions is set")
+	flag.IntVar(&githubAccountMaxRunners, "github-account-max-runners", 0, "The maximum number of runners shared by all the HorizontalRunnerAutoscalers whose scale targets belong to the same GitHub enterprise, organization or user. The desired replicas of the HorizontalRunnerAutoscalers are clamped in proportion to their demands once they collectively exceed it. Defaults to 0, which disables the limit")
+	flag.IntVar(&clusterMaxRunners, "cluster-max-runners", 0, "The maximum number of runners shared by all the HorizontalRunnerAutoscalers in the cluster. Once they collectively desire more, the runners are allocated to the HorizontalRunnerAutoscalers with the highest spec.capacityShare.priority first, and shared by spec.capacityShare.weight among the ones with the same priority. Defaults to 0, which disables the limit")
+	flag.DurationVar(&busyLedgerSnapshotInterval, "busy-ledger-snapshot-interval", 0, "The interval between the snapshots of busy runners listed by the GitHub API, which are compared with the busy runners recorded from workflow_job events to release the capacity reserved for the jobs whose completion events were missed. The drift is exported as the horizontalrunnerautoscaler_busy_ledger_drift_percentage metric. Defaults to 0, which disables the snapshots")
+	flag.Float64Var(&busyLedgerDriftThreshold, "busy-ledger-drift-threshold", controllers.DefaultBusyLedgerDriftThreshold, "The percentage of the drift of busy runners above which the snapshots are taken more frequently, down to every minute. Only used when -busy-ledger-snapshot-interval is set")
+	flag.DurationVar(&missedWorkflowJobCheckInterval, "missed-workflow-job-check-interval", 0, "The interval between the checks of the queued workflow jobs listed by the GitHub API, which reserve capacity for the jobs whose queued webhook events were missed. Only HorizontalRunnerAutoscalers scaled by workflow_job events are checked. Defaults to 0, which disables the checks")
+	flag.DurationVar(&missedWorkflowJobGracePeriod, "missed-workflow-job-grace-period", controllers.DefaultMissedWorkflowJobGracePeriod, "How long a workflow job can be queued without a capacity reservation before it's considered missed. Only used when -missed-workflow-job-check-interval is set")
+	flag.DurationVar(&runnerUsageInterval, "runner-usage-interval", 0, "The interval between the observations of the running and busy runners of each RunnerDeployment, which are accounted as the runnerdeployment_runner_seconds_total, runnerdeployment_busy_seconds_total and runnerdeployment_jobs_total metrics. Defaults to 0, which disables the accounting")
+	flag.BoolVar(&runnerUsageDailySummaries, "runner-usage-daily-summaries", false, "Write the usage of the runners in each of the last 7 days into status.usage of each RunnerDeployment, too. Only used when -runner-usage-interval is set")
+	flag.Int64Var(&runnerDiagnosticsTailLines, "runner-diagnostics-tail-lines", 0, "The number of the last lines of the runner and docker container logs collected into a RunnerDiagnostics event on the runner when its pod fails to register the runner in time or crash-loops. Defaults to 0, which disables the collection")
+	flag.BoolVar(&runnerDiagnosticsConfigMaps, "runner-diagnostics-configmaps", false, "Write the collected logs into the ConfigMap named RUNNER_NAME-diagnostics, too. Only used when -runner-diagnostics-tail-lines is set")
+	flag.DurationVar(&runnerVersionCheckInterval, "runner-version-check-interval", 0, "The interval between the listings of the releases of actions/runner, for upgrading the runners of the RunnerDeployments with spec.runnerVersionUpgrade. Defaults to 0, which disables the automatic runner version upgrades")
+	flag.StringVar(&runnerReleaseFeedURL, "runner-release-feed-url", github.DefaultRunnerReleaseFeedURL, "The URL that lists the releases of actions/runner in the format of the GitHub API, like the one of a mirror for air-gapped environments")
+	flag.DurationVar(&runnerFleetStatusInterval, "runner-fleet-status-interval", 0, "The interval at which the runners, the busy runners, the queued demand and the GitHub API budget of all the namespaces are summed into the cluster-scoped RunnerFleetStatus named default. Requires the RunnerFleetStatus CRD and the cluster-wide permission to update it. Defaults to 0, which disables the aggregation")
+	flag.IntVar(&runnerDeletionParallelism, "runner-deletion-parallelism", controllers.DefaultRunnerDeletionParallelism, "The maximum number of the runners of a RunnerReplicaSet deleted concurrently on scale down. The runners are then unregistered from GitHub and have their pods deleted as concurrently as -max-concurrent-reconciles allows for the runner controller, so raise both to scale down by hundreds of runners faster")
+	flag.BoolVar(&enableNamespaceTemplates, "enable-namespace-templates", false, "Create and keep in sync the RunnerDeployments and HorizontalRunnerAutoscalers of NamespaceTemplates in the namespaces selected by the templates. Requires the NamespaceTemplate CRD and the cluster-wide permission to watch namespaces, and can't be combined with -watch-namespace")
+	flag.BoolVar(&enableRunnerPools, "enable-runner-pools", false, "Rebalance the capacity reservations between the members of the RunnerPools with spec.rebalance. Requires the RunnerPool CRD")
+	flag.BoolVar(&enableRunnerQuotas, "enable-runner-quotas", false, "Limit the replicas of the RunnerDeployments by the RunnerQuotas in their namespaces, and keep the usage in the status of the RunnerQuotas up to date. Requires the RunnerQuota CRD")
+	flag.Var(&components, "components", `Comma-separated list of the components to run, out of "controllers" and "admission-webhooks". Defaults to running both. Run them in separate deployments to give each its own ServiceAccount with a minimal role`)
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 0, "The maximum queries per second from the controller to the Kubernetes API server. Defaults to 0, which uses the default of controller-runtime")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 0, "The maximum burst of queries from the controller to the Kubernetes API server. Defaults to 0, which uses the default of controller-runtime")
+	flag.Var(&maxConcurrentReconciles, "max-concurrent-reconciles", `Comma-separated list of the maximum numbers of concurrent reconciliations per controller, like "4,runner=16,runnerpod=16". A value without a controller name applies to all the controllers without their own value. Valid controller names are runner, runnerreplicaset, runnerdeployment, runnerset, runnerpod, horizontalrunnerautoscaler, and runnerpodinterruption. Defaults to 1 for every controller`)
+	flag.Var(&resyncPeriods, "resync-periods", `Comma-separated list of the durations after which each resource is reconciled again after a successful reconciliation per controller, like "horizontalrunnerautoscaler=1m". Accepts the same controller names as -max-concurrent-reconciles. Defaults to relying only on -sync-period`)
 	flag.Parse()
 
-	logger := zap.New(func(o *zap.Options) {
-		switch logLevel {
-		case logLevelDebug:
-			o.Development = true
-		case logLevelInfo:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.InfoLevel)
-			o.Level = &lvl
-		case logLevelWarn:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.WarnLevel)
-			o.Level = &lvl
-		case logLevelError:
-			lvl := zaplib.NewAtomicLevelAt(zaplib.ErrorLevel)
-			o.Level = &lvl
+	if len(components) == 0 {
+		components = commaSeparatedStringSlice{componentControllers, componentAdmissionWebhooks}
+	}
+
+	var runControllers, runAdmissionWebhooks bool
+
+	for _, component := range components {
+		switch component {
+		case componentControllers:
+			runControllers = true
+		case componentAdmissionWebhooks:
+			runAdmissionWebhooks = true
+		default:
+			fmt.Fprintf(os.Stderr, "Error: invalid -components %q: unknown component %q\n", components, component)
+			os.Exit(1)
+		}
+	}
+
+	if enableNamespaceTemplates && namespace != "" {
+		fmt.Fprintln(os.Stderr, "Error: -enable-namespace-templates can't be combined with -watch-namespace, because NamespaceTemplates are cluster-scoped and select namespaces cluster-wide")
+		os.Exit(1)
+	}
+
+	controllerOptions, err := controllers.ParseControllerOptions(maxConcurrentReconciles, resyncPeriods)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(logLevel, logSampling)
+	logRateLimiter := logging.NewRateLimiter(logRateLimitInterval)
+
+	var anomalyNotifier *anomaly.Notifier
+
+	if anomalyNotificationConfig != "" {
+		config, err := anomaly.LoadConfig(anomalyNotificationConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: loading -anomaly-notification-config: %v\n", err)
+			os.Exit(1)
+		}
+
+		anomalyNotifier, err = anomaly.NewNotifier("controller", config, logger.WithName("anomaly"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -anomaly-notification-config: %v\n", err)
+			os.Exit(1)
+		}
+
+		c.OnRateLimitExhausted = func(reset time.Time) {
+			anomalyNotifier.Record(anomaly.KindRateLimitExhausted, fmt.Sprintf("The rate limit resets at %s", reset.Format(time.RFC3339)))
+		}
+	}
+
+	if c.CredentialsDir != "" {
+		creds, err := (&github.FileCredentialsSource{Dir: c.CredentialsDir}).Read()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: Reading GitHub credentials failed.", err)
+			os.Exit(1)
 		}
-	})
+
+		c.SetCredentials(creds)
+	}
 
 	ghClient, err = c.NewClient()
 	if err != nil {
@@ -147,143 +316,518 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	detectCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	capabilities, err := ghClient.DetectCapabilities(detectCtx)
+	cancel()
+
+	if err != nil {
+		log.Error(err, "unable to detect the version of the GitHub server. Assuming that it supports every feature")
+	} else if capabilities.GHESVersion != "" {
+		log.Info("Detected GitHub Enterprise Server", "version", capabilities.GHESVersion)
+	}
+
+	webhookHost, webhookPort, err := splitHostPort(webhookAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -webhook-addr %q: %v\n", webhookAddr, err)
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	if kubeAPIQPS > 0 {
+		restConfig.QPS = float32(kubeAPIQPS)
+	}
+	if kubeAPIBurst > 0 {
+		restConfig.Burst = kubeAPIBurst
+	}
+
+	watchNamespaces := controllers.ParseWatchNamespaces(namespace)
+	cacheNamespace, newCache := controllers.WatchNamespacesCacheOptions(watchNamespaces)
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
-		LeaderElection:     enableLeaderElection,
+		LeaderElection:     enableLeaderElection && runControllers,
 		LeaderElectionID:   leaderElectionId,
-		Port:               9443,
+		Host:               webhookHost,
+		Port:               webhookPort,
 		SyncPeriod:         &syncPeriod,
-		Namespace:          namespace,
+		Namespace:          cacheNamespace,
+		NewCache:           newCache,
 	})
 	if err != nil {
 		log.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	runnerReconciler := &controllers.RunnerReconciler{
-		Client:               mgr.GetClient(),
-		Log:                  log.WithName("runner"),
-		Scheme:               mgr.GetScheme(),
-		GitHubClient:         ghClient,
-		DockerImage:          dockerImage,
-		DockerRegistryMirror: dockerRegistryMirror,
-		// Defaults for self-hosted runner containers
-		RunnerImage:            runnerImage,
-		RunnerImagePullSecrets: runnerImagePullSecrets,
-	}
+	if runtimeSettingsFile != "" {
+		dryRun := false
 
-	if err = runnerReconciler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "Runner")
-		os.Exit(1)
-	}
+		defaults := controllers.RuntimeSettings{
+			LogLevel:             &logLevel,
+			LogRateLimitInterval: &metav1.Duration{Duration: logRateLimitInterval},
+			DryRun:               &dryRun,
+		}
 
-	runnerReplicaSetReconciler := &controllers.RunnerReplicaSetReconciler{
-		Client:       mgr.GetClient(),
-		Log:          log.WithName("runnerreplicaset"),
-		Scheme:       mgr.GetScheme(),
-		GitHubClient: ghClient,
-	}
+		runtimeSettings, err := controllers.NewRuntimeSettingsFile(runtimeSettingsFile, defaults, logRateLimiter, log.WithName("runtimesettings"))
+		if err != nil {
+			log.Error(err, "unable to load runtime settings file", "path", runtimeSettingsFile)
+			os.Exit(1)
+		}
 
-	if err = runnerReplicaSetReconciler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "RunnerReplicaSet")
-		os.Exit(1)
+		if err := mgr.Add(runtimeSettings); err != nil {
+			log.Error(err, "unable to watch runtime settings file", "path", runtimeSettingsFile)
+			os.Exit(1)
+		}
 	}
 
-	runnerDeploymentReconciler := &controllers.RunnerDeploymentReconciler{
-		Client:             mgr.GetClient(),
-		Log:                log.WithName("runnerdeployment"),
-		Scheme:             mgr.GetScheme(),
-		CommonRunnerLabels: commonRunnerLabels,
-	}
+	if c.CredentialsDir != "" {
+		credentialsReloader := &github.CredentialsReloader{
+			Client: ghClient,
+			Source: &github.FileCredentialsSource{Dir: c.CredentialsDir, Log: log.WithName("credentials")},
+			Log:    log.WithName("credentials"),
+		}
 
-	if err = runnerDeploymentReconciler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "RunnerDeployment")
-		os.Exit(1)
+		if err := mgr.Add(credentialsReloader); err != nil {
+			log.Error(err, "unable to watch GitHub credentials", "dir", c.CredentialsDir)
+			os.Exit(1)
+		}
 	}
 
-	runnerSetReconciler := &controllers.RunnerSetReconciler{
-		Client:               mgr.GetClient(),
-		Log:                  log.WithName("runnerset"),
-		Scheme:               mgr.GetScheme(),
-		CommonRunnerLabels:   commonRunnerLabels,
-		DockerImage:          dockerImage,
-		DockerRegistryMirror: dockerRegistryMirror,
-		GitHubBaseURL:        ghClient.GithubBaseURL,
-		// Defaults for self-hosted runner containers
-		RunnerImage:            runnerImage,
-		RunnerImagePullSecrets: runnerImagePullSecrets,
-	}
+	// The clients of the runners that select their GitHub endpoints and credentials by githubAPICredentialsFrom.
+	// The Secrets are read without the cache, so that all the Secrets in the cluster aren't watched.
+	multiGitHubClient := controllers.NewMultiGitHubClient(mgr.GetAPIReader(), c)
 
-	if err = runnerSetReconciler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "RunnerSet")
-		os.Exit(1)
-	}
-	if gitHubAPICacheDuration == 0 {
-		gitHubAPICacheDuration = syncPeriod - 10*time.Second
-	}
+	if runControllers {
+		if runnerInventoryRefreshInterval > 0 {
+			runnerInventory := ghClient.EnableRunnerInventory(runnerInventoryRefreshInterval, log.WithName("runnerinventory"))
 
-	if gitHubAPICacheDuration < 0 {
-		gitHubAPICacheDuration = 0
-	}
+			if err = mgr.Add(runnerInventory); err != nil {
+				log.Error(err, "unable to add runner inventory")
+				os.Exit(1)
+			}
+		}
 
-	log.Info(
-		"Initializing actions-runner-controller",
-		"github-api-cache-duration", gitHubAPICacheDuration,
-		"sync-period", syncPeriod,
-		"runner-image", runnerImage,
-		"docker-image", dockerImage,
-		"common-runnner-labels", commonRunnerLabels,
-		"watch-namespace", namespace,
-	)
+		runnerReconciler := &controllers.RunnerReconciler{
+			Client:               mgr.GetClient(),
+			Log:                  log.WithName("runner"),
+			Scheme:               mgr.GetScheme(),
+			GitHubClient:         ghClient,
+			DockerImage:          dockerImage,
+			DockerRegistryMirror: dockerRegistryMirror,
+			// Defaults for self-hosted runner containers
+			RunnerImage:            runnerImage,
+			ARM64RunnerImage:       arm64RunnerImage,
+			WindowsRunnerImage:     windowsRunnerImage,
+			RunnerImagePullSecrets: runnerImagePullSecrets,
+			RunnerCacheURL:         runnerCacheURL,
+			ControllerOptions:      controllerOptions[controllers.ControllerNameRunner],
+			LogRateLimiter:         logRateLimiter,
+			AnomalyNotifier:        anomalyNotifier,
+			MultiGitHubClient:      multiGitHubClient,
+		}
 
-	horizontalRunnerAutoscaler := &controllers.HorizontalRunnerAutoscalerReconciler{
-		Client:        mgr.GetClient(),
-		Log:           log.WithName("horizontalrunnerautoscaler"),
-		Scheme:        mgr.GetScheme(),
-		GitHubClient:  ghClient,
-		CacheDuration: gitHubAPICacheDuration,
-	}
+		if runnerDiagnosticsTailLines > 0 {
+			kubeClient, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				log.Error(err, "unable to create kubernetes client for runner diagnostics")
+				os.Exit(1)
+			}
 
-	runnerPodReconciler := &controllers.RunnerPodReconciler{
-		Client:       mgr.GetClient(),
-		Log:          log.WithName("runnerpod"),
-		Scheme:       mgr.GetScheme(),
-		GitHubClient: ghClient,
-	}
+			runnerReconciler.DiagnosticsTailLines = runnerDiagnosticsTailLines
+			runnerReconciler.DiagnosticsConfigMaps = runnerDiagnosticsConfigMaps
+			runnerReconciler.KubeClient = kubeClient
+		}
 
-	if err = runnerPodReconciler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "RunnerPod")
-		os.Exit(1)
-	}
+		if err = runnerReconciler.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to create controller", "controller", "Runner")
+			os.Exit(1)
+		}
 
-	if err = horizontalRunnerAutoscaler.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create controller", "controller", "HorizontalRunnerAutoscaler")
-		os.Exit(1)
-	}
+		runnerReplicaSetReconciler := &controllers.RunnerReplicaSetReconciler{
+			Client:              mgr.GetClient(),
+			Log:                 log.WithName("runnerreplicaset"),
+			Scheme:              mgr.GetScheme(),
+			GitHubClient:        ghClient,
+			ControllerOptions:   controllerOptions[controllers.ControllerNameRunnerReplicaSet],
+			DeletionParallelism: runnerDeletionParallelism,
+			MultiGitHubClient:   multiGitHubClient,
+		}
 
-	if err = (&actionsv1alpha1.Runner{}).SetupWebhookWithManager(mgr); err != nil {
-		log.Error(err, "unable to create webhook", "webhook", "Runner")
-		os.Exit(1)
-	}
-	if err = (&actionsv1alpha1.RunnerDeployment{}).SetupWebhookWithManager(mgr); err != nil {
-		log.Error(err, "unable to create webhook", "webhook", "RunnerDeployment")
-		os.Exit(1)
-	}
-	if err = (&actionsv1alpha1.RunnerReplicaSet{}).SetupWebhookWithManager(mgr); err != nil {
-		log.Error(err, "unable to create webhook", "webhook", "RunnerReplicaSet")
-		os.Exit(1)
-	}
-	// +kubebuilder:scaffold:builder
+		if err = runnerReplicaSetReconciler.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to create controller", "controller", "RunnerReplicaSet")
+			os.Exit(1)
+		}
+
+		runnerDeploymentReconciler := &controllers.RunnerDeploymentReconciler{
+			Client:             mgr.GetClient(),
+			Log:                log.WithName("runnerdeployment"),
+			Scheme:             mgr.GetScheme(),
+			CommonRunnerLabels: commonRunnerLabels,
+			GitHubClient:       ghClient,
+			ControllerOptions:  controllerOptions[controllers.ControllerNameRunnerDeployment],
+			MultiGitHubClient:  multiGitHubClient,
+			RunnerQuotas:       enableRunnerQuotas,
+		}
+
+		if err = runnerDeploymentReconciler.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to create controller", "controller", "RunnerDeployment")
+			os.Exit(1)
+		}
+
+		// RunnerSets are optional. Their controllers are skipped rather than failing on the first reconciliation
+		// when the ServiceAccount isn't allowed to manage StatefulSets and runner pods.
+		var deniedRunnerSetAccesses []controllers.ResourceAccess
+
+		reviewedNamespaces := watchNamespaces
+		if len(reviewedNamespaces) == 0 {
+			reviewedNamespaces = []string{""}
+		}
+
+		for _, ns := range reviewedNamespaces {
+			denied, err := controllers.DeniedResourceAccesses(context.Background(), mgr.GetClient(), ns, controllers.RunnerSetResourceAccesses)
+			if err != nil {
+				log.Error(err, "unable to review permissions for RunnerSets", "namespace", ns)
+				os.Exit(1)
+			}
+
+			deniedRunnerSetAccesses = append(deniedRunnerSetAccesses, denied...)
+		}
+
+		runnerSetEnabled := len(deniedRunnerSetAccesses) == 0
+		if !runnerSetEnabled {
+			log.Info("Skipping RunnerSet and RunnerPod controllers due to missing permissions", "denied", fmt.Sprintf("%v", deniedRunnerSetAccesses))
+		}
+
+		runnerSetReconciler := &controllers.RunnerSetReconciler{
+			Client:               mgr.GetClient(),
+			Log:                  log.WithName("runnerset"),
+			Scheme:               mgr.GetScheme(),
+			CommonRunnerLabels:   commonRunnerLabels,
+			DockerImage:          dockerImage,
+			DockerRegistryMirror: dockerRegistryMirror,
+			GitHubBaseURL:        ghClient.GithubBaseURL,
+			// Defaults for self-hosted runner containers
+			RunnerImage:            runnerImage,
+			ARM64RunnerImage:       arm64RunnerImage,
+			WindowsRunnerImage:     windowsRunnerImage,
+			RunnerImagePullSecrets: runnerImagePullSecrets,
+			RunnerCacheURL:         runnerCacheURL,
+			ControllerOptions:      controllerOptions[controllers.ControllerNameRunnerSet],
+			MultiGitHubClient:      multiGitHubClient,
+		}
+
+		if runnerSetEnabled {
+			if err = runnerSetReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerSet")
+				os.Exit(1)
+			}
+		}
+
+		if offlineRunnerGCGracePeriod > 0 {
+			kinds := []string{"RunnerDeployment"}
+			if runnerSetEnabled {
+				kinds = append(kinds, "RunnerSet")
+			}
+
+			for _, kind := range kinds {
+				offlineRunnerCollector := &controllers.OfflineRunnerCollector{
+					Client:            mgr.GetClient(),
+					Log:               log.WithName("offlinerunnercollector"),
+					Scheme:            mgr.GetScheme(),
+					GitHubClient:      ghClient,
+					Kind:              kind,
+					GracePeriod:       offlineRunnerGCGracePeriod,
+					Interval:          offlineRunnerGCInterval,
+					MultiGitHubClient: multiGitHubClient,
+				}
+
+				if err = offlineRunnerCollector.SetupWithManager(mgr); err != nil {
+					log.Error(err, "unable to create controller", "controller", "OfflineRunnerCollector", "kind", kind)
+					os.Exit(1)
+				}
+			}
+		}
+
+		if terminalRunnerGCSucceededTTL > 0 || terminalRunnerGCFailedTTL > 0 {
+			terminalRunnerCollector := &controllers.TerminalRunnerCollector{
+				Client:             mgr.GetClient(),
+				Log:                log.WithName("terminalrunnercollector"),
+				SucceededTTL:       terminalRunnerGCSucceededTTL,
+				FailedTTL:          terminalRunnerGCFailedTTL,
+				DeletionsPerSecond: terminalRunnerGCDeletionsPerSecond,
+				BatchSize:          terminalRunnerGCBatchSize,
+			}
+
+			if err = terminalRunnerCollector.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "TerminalRunnerCollector")
+				os.Exit(1)
+			}
+		}
+
+		if externalMetricsAddr != "" {
+			externalMetricsServer := &externalmetrics.Server{
+				Client:    mgr.GetClient(),
+				APIReader: mgr.GetAPIReader(),
+				Log:       log.WithName("externalmetrics"),
+				Addr:      externalMetricsAddr,
+				CertDir:   externalMetricsCertDir,
+			}
+
+			if err = mgr.Add(externalMetricsServer); err != nil {
+				log.Error(err, "unable to add external metrics server")
+				os.Exit(1)
+			}
+		}
+
+		if kedaExternalScalerAddr != "" {
+			kedaExternalScaler := &controllers.KEDAExternalScaler{
+				Client:            mgr.GetClient(),
+				GitHubClient:      ghClient,
+				MultiGitHubClient: multiGitHubClient,
+				Log:               log.WithName("kedaexternalscaler"),
+				Addr:              kedaExternalScalerAddr,
+				CertDir:           kedaExternalScalerCertDir,
+				Namespaces:        watchNamespaces,
+			}
+
+			if err = mgr.Add(kedaExternalScaler); err != nil {
+				log.Error(err, "unable to add KEDA external scaler")
+				os.Exit(1)
+			}
+		}
+
+		if gitHubAPICacheDuration == 0 {
+			gitHubAPICacheDuration = syncPeriod - 10*time.Second
+		}
+
+		if gitHubAPICacheDuration < 0 {
+			gitHubAPICacheDuration = 0
+		}
+
+		log.Info(
+			"Initializing actions-runner-controller",
+			"github-api-cache-duration", gitHubAPICacheDuration,
+			"sync-period", syncPeriod,
+			"runner-image", runnerImage,
+			"arm64-runner-image", arm64RunnerImage,
+			"windows-runner-image", windowsRunnerImage,
+			"docker-image", dockerImage,
+			"common-runnner-labels", commonRunnerLabels,
+			"watch-namespace", namespace,
+			"offline-runner-gc-grace-period", offlineRunnerGCGracePeriod,
+			"terminal-runner-gc-succeeded-ttl", terminalRunnerGCSucceededTTL,
+			"terminal-runner-gc-failed-ttl", terminalRunnerGCFailedTTL,
+			"components", components,
+			"kube-api-qps", kubeAPIQPS,
+			"kube-api-burst", kubeAPIBurst,
+			"max-concurrent-reconciles", maxConcurrentReconciles,
+			"resync-periods", resyncPeriods,
+			"handle-runner-interruptions", handleRunnerInterruptions,
+			"watch-nodes", watchNodes,
+		)
+
+		horizontalRunnerAutoscaler := &controllers.HorizontalRunnerAutoscalerReconciler{
+			Client:            mgr.GetClient(),
+			Log:               log.WithName("horizontalrunnerautoscaler"),
+			Scheme:            mgr.GetScheme(),
+			GitHubClient:      ghClient,
+			CacheDuration:     gitHubAPICacheDuration,
+			ControllerOptions: controllerOptions[controllers.ControllerNameHorizontalRunnerAutoscaler],
+			LogRateLimiter:    logRateLimiter,
+			AccountMaxRunners: githubAccountMaxRunners,
+			ClusterMaxRunners: clusterMaxRunners,
+			MultiGitHubClient: multiGitHubClient,
+			RunnerQuotas:      enableRunnerQuotas,
+		}
+
+		runnerPodReconciler := &controllers.RunnerPodReconciler{
+			Client:            mgr.GetClient(),
+			Log:               log.WithName("runnerpod"),
+			Scheme:            mgr.GetScheme(),
+			GitHubClient:      ghClient,
+			ControllerOptions: controllerOptions[controllers.ControllerNameRunnerPod],
+			AnomalyNotifier:   anomalyNotifier,
+			MultiGitHubClient: multiGitHubClient,
+		}
 
-	injector := &controllers.PodRunnerTokenInjector{
-		Client:       mgr.GetClient(),
-		GitHubClient: ghClient,
-		Log:          ctrl.Log.WithName("webhook").WithName("PodRunnerTokenInjector"),
+		if runnerSetEnabled {
+			if err = runnerPodReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerPod")
+				os.Exit(1)
+			}
+		}
+
+		if err = horizontalRunnerAutoscaler.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to create controller", "controller", "HorizontalRunnerAutoscaler")
+			os.Exit(1)
+		}
+
+		if busyLedgerSnapshotInterval > 0 {
+			busyLedgerReconciler := &controllers.BusyLedgerReconciler{
+				Client:            mgr.GetClient(),
+				GitHubClient:      ghClient,
+				Log:               log.WithName("busyledger"),
+				Interval:          busyLedgerSnapshotInterval,
+				DriftThreshold:    busyLedgerDriftThreshold,
+				MultiGitHubClient: multiGitHubClient,
+			}
+
+			if err = busyLedgerReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "BusyLedger")
+				os.Exit(1)
+			}
+		}
+
+		if missedWorkflowJobCheckInterval > 0 {
+			missedWorkflowJobReconciler := &controllers.MissedWorkflowJobReconciler{
+				Client:            mgr.GetClient(),
+				GitHubClient:      ghClient,
+				Log:               log.WithName("missedworkflowjob"),
+				Interval:          missedWorkflowJobCheckInterval,
+				GracePeriod:       missedWorkflowJobGracePeriod,
+				MultiGitHubClient: multiGitHubClient,
+			}
+
+			if err = missedWorkflowJobReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "MissedWorkflowJob")
+				os.Exit(1)
+			}
+		}
+
+		if runnerVersionCheckInterval > 0 {
+			runnerReleaseFeed := github.NewRunnerReleaseFeed(runnerReleaseFeedURL, runnerVersionCheckInterval, log.WithName("runnerreleasefeed"))
+
+			if err = mgr.Add(runnerReleaseFeed); err != nil {
+				log.Error(err, "unable to add runner release feed")
+				os.Exit(1)
+			}
+
+			runnerVersionUpgradeReconciler := &controllers.RunnerVersionUpgradeReconciler{
+				Client: mgr.GetClient(),
+				Log:    log.WithName("runnerversionupgrade"),
+				Feed:   runnerReleaseFeed,
+			}
+
+			if err = runnerVersionUpgradeReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerVersionUpgrade")
+				os.Exit(1)
+			}
+		}
+
+		if runnerUsageInterval > 0 {
+			runnerUsageReconciler := &controllers.RunnerUsageReconciler{
+				Client:            mgr.GetClient(),
+				GitHubClient:      ghClient,
+				Log:               log.WithName("runnerusage"),
+				Interval:          runnerUsageInterval,
+				DailySummaries:    runnerUsageDailySummaries,
+				MultiGitHubClient: multiGitHubClient,
+			}
+
+			if err = runnerUsageReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerUsage")
+				os.Exit(1)
+			}
+		}
+
+		if runnerFleetStatusInterval > 0 {
+			runnerFleetStatusAggregator := &controllers.RunnerFleetStatusAggregator{
+				Client:   mgr.GetClient(),
+				Reader:   mgr.GetAPIReader(),
+				Log:      log.WithName("runnerfleetstatus"),
+				Interval: runnerFleetStatusInterval,
+			}
+
+			if err = mgr.Add(runnerFleetStatusAggregator); err != nil {
+				log.Error(err, "unable to add runner fleet status aggregator")
+				os.Exit(1)
+			}
+		}
+
+		if enableRunnerPools {
+			runnerPoolRebalancer := &controllers.RunnerPoolRebalancer{
+				Client: mgr.GetClient(),
+				Log:    log.WithName("runnerpoolrebalancer"),
+				Scheme: mgr.GetScheme(),
+			}
+
+			if err = runnerPoolRebalancer.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerPoolRebalancer")
+				os.Exit(1)
+			}
+		}
+
+		if enableRunnerQuotas {
+			runnerQuotaReconciler := &controllers.RunnerQuotaReconciler{
+				Client: mgr.GetClient(),
+				Log:    log.WithName("runnerquota"),
+			}
+
+			if err = runnerQuotaReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerQuota")
+				os.Exit(1)
+			}
+		}
+
+		if enableNamespaceTemplates {
+			namespaceTemplateReconciler := &controllers.NamespaceTemplateReconciler{
+				Client: mgr.GetClient(),
+				Log:    log.WithName("namespacetemplate"),
+				Scheme: mgr.GetScheme(),
+			}
+
+			if err = namespaceTemplateReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "NamespaceTemplate")
+				os.Exit(1)
+			}
+		}
+
+		if handleRunnerInterruptions {
+			runnerPodInterruptionReconciler := &controllers.RunnerPodInterruptionReconciler{
+				Client:            mgr.GetClient(),
+				Log:               log.WithName("runnerpodinterruption"),
+				Scheme:            mgr.GetScheme(),
+				ReAddCapacity:     reAddInterruptedCapacity,
+				IgnoreNodes:       !watchNodes,
+				ControllerOptions: controllerOptions[controllers.ControllerNameRunnerPodInterruption],
+			}
+
+			if len(nodeTerminationTaints) > 0 {
+				runnerPodInterruptionReconciler.NodeTerminationTaints = nodeTerminationTaints
+			}
+
+			if err = runnerPodInterruptionReconciler.SetupWithManager(mgr); err != nil {
+				log.Error(err, "unable to create controller", "controller", "RunnerPodInterruption")
+				os.Exit(1)
+			}
+		}
 	}
-	if err = injector.SetupWithManager(mgr); err != nil {
-		log.Error(err, "unable to create webhook server", "webhook", "PodRunnerTokenInjector")
-		os.Exit(1)
+
+	if runAdmissionWebhooks {
+		if err = (&actionsv1alpha1.Runner{}).SetupWebhookWithManager(mgr); err != nil {
+			log.Error(err, "unable to create webhook", "webhook", "Runner")
+			os.Exit(1)
+		}
+		if err = (&actionsv1alpha1.RunnerDeployment{}).SetupWebhookWithManager(mgr); err != nil {
+			log.Error(err, "unable to create webhook", "webhook", "RunnerDeployment")
+			os.Exit(1)
+		}
+		if err = (&actionsv1alpha1.RunnerReplicaSet{}).SetupWebhookWithManager(mgr); err != nil {
+			log.Error(err, "unable to create webhook", "webhook", "RunnerReplicaSet")
+			os.Exit(1)
+		}
+		// +kubebuilder:scaffold:builder
+
+		injector := &controllers.PodRunnerTokenInjector{
+			Client:            mgr.GetClient(),
+			GitHubClient:      ghClient,
+			Log:               ctrl.Log.WithName("webhook").WithName("PodRunnerTokenInjector"),
+			MultiGitHubClient: multiGitHubClient,
+		}
+		if err = injector.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to create webhook server", "webhook", "PodRunnerTokenInjector")
+			os.Exit(1)
+		}
 	}
 
 	log.Info("starting manager")
@@ -309,3 +853,18 @@ func (s *commaSeparatedStringSlice) Set(value string) error {
 	}
 	return nil
 }
+
+// splitHostPort splits an address like :9443, 0.0.0.0:9443, or [::]:9443 into the host and the port number.
+func splitHostPort(addr string) (string, int, error) {
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q: %w", p, err)
+	}
+
+	return host, port, nil
+}
@@ -19,7 +19,7 @@ func Match(pat string, s string) bool {
 
 	tokens := strings.SplitAfter(pat, "*")
 
-	var wildcardInHead bool
+	var wildcardInHead, notFound bool
 
 	for i := 0; i < len(tokens); i++ {
 		p := tokens[i]
@@ -47,7 +47,8 @@ func Match(pat string, s string) bool {
 
 		subs := strings.SplitN(s, p, 2)
 
-		if len(subs) == 0 {
+		if len(subs) < 2 {
+			notFound = true
 			break
 		}
 
@@ -68,7 +69,7 @@ func Match(pat string, s string) bool {
 		wildcardInHead = wildcardInTail
 	}
 
-	r := s == ""
+	r := s == "" && !notFound
 
 	if inverse {
 		r = !r
@@ -148,6 +148,30 @@ func TestMatch(t *testing.T) {
 		})
 	})
 
+	t.Run("*foo == bar", func(t *testing.T) {
+		run(t, testcase{
+			Pattern: "*foo",
+			Target:  "bar",
+			Want:    false,
+		})
+	})
+
+	t.Run("!*foo == bar", func(t *testing.T) {
+		run(t, testcase{
+			Pattern: "!*foo",
+			Target:  "bar",
+			Want:    true,
+		})
+	})
+
+	t.Run("foo == empty", func(t *testing.T) {
+		run(t, testcase{
+			Pattern: "foo",
+			Target:  "",
+			Want:    false,
+		})
+	})
+
 	t.Run("foo* == foo", func(t *testing.T) {
 		run(t, testcase{
 			Pattern: "foo*",
@@ -0,0 +1,370 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package anomaly notifies webhooks, like the ones of Slack and PagerDuty, of scaling anomalies,
+// so that broken autoscaling is noticed before developers complain about jobs stuck in the queue.
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Kind is the kind of scaling anomaly detected by the built-in detectors.
+type Kind string
+
+const (
+	// KindUnmatchedJobs is workflow jobs queued without any HorizontalRunnerAutoscaler to scale for them.
+	KindUnmatchedJobs Kind = "unmatchedJobs"
+	// KindScaleUpFailures is failed updates of HorizontalRunnerAutoscalers for workflow jobs and other webhook events.
+	KindScaleUpFailures Kind = "scaleUpFailures"
+	// KindRegistrationFailures is runners that failed to register themselves to GitHub in time,
+	// and failures to get registration tokens.
+	KindRegistrationFailures Kind = "registrationFailures"
+	// KindRateLimitExhausted is GitHub API responses telling that the rate limit is exhausted.
+	KindRateLimitExhausted Kind = "rateLimitExhausted"
+)
+
+const (
+	DefaultCooldown = 30 * time.Minute
+
+	// DefaultTemplate renders a message accepted by Slack incoming webhooks.
+	DefaultTemplate = `{"text": {{ .Text | json }}}`
+
+	// maxDetails is the number of the latest occurrences included in a notification.
+	maxDetails = 5
+
+	notificationTimeout = 10 * time.Second
+)
+
+// DefaultDetectors are the detectors used for the kinds missing in Config.Detectors.
+var DefaultDetectors = map[Kind]DetectorConfig{
+	KindUnmatchedJobs:        {Threshold: 5, Window: &metav1.Duration{Duration: 10 * time.Minute}},
+	KindScaleUpFailures:      {Threshold: 3, Window: &metav1.Duration{Duration: 10 * time.Minute}},
+	KindRegistrationFailures: {Threshold: 5, Window: &metav1.Duration{Duration: 10 * time.Minute}},
+	KindRateLimitExhausted:   {Threshold: 1, Window: &metav1.Duration{Duration: 10 * time.Minute}},
+}
+
+var descriptions = map[Kind]string{
+	KindUnmatchedJobs:        "workflow jobs were queued without any HorizontalRunnerAutoscaler to scale for them",
+	KindScaleUpFailures:      "scale ups failed",
+	KindRegistrationFailures: "runners failed to register to GitHub",
+	KindRateLimitExhausted:   "GitHub API responses told that the rate limit was exhausted",
+}
+
+// Config is the configuration of the notifications, usually read from a YAML file mounted from a Kubernetes secret,
+// as the webhook URLs are credentials.
+type Config struct {
+	// Webhooks are notified of every anomaly.
+	Webhooks []WebhookConfig `json:"webhooks"`
+
+	// Cooldown is the minimum interval between the notifications of the same kind of anomaly. Defaults to 30m.
+	// +optional
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+
+	// Detectors configure the detectors by kind. The missing ones use DefaultDetectors.
+	// +optional
+	Detectors map[Kind]DetectorConfig `json:"detectors,omitempty"`
+}
+
+// WebhookConfig is the webhook notified of anomalies with a POST request.
+type WebhookConfig struct {
+	URL string `json:"url"`
+
+	// Template is the Go template of the request body, rendered with Notification.
+	// The json function renders a value as JSON. Defaults to DefaultTemplate.
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// Headers are added to the request. Content-Type defaults to application/json.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DetectorConfig makes an anomaly of Threshold or more occurrences within Window.
+type DetectorConfig struct {
+	// +optional
+	Threshold int `json:"threshold,omitempty"`
+
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// Disabled disables the detector.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Notification is the data the templates of the webhooks are rendered with.
+type Notification struct {
+	Kind Kind
+	// Component is the component that detected the anomaly, like "controller" and "github-webhook-server".
+	Component string
+	Count     int
+	Window    time.Duration
+	Time      time.Time
+	// Message summarizes the anomaly, like "5 scale ups failed within 10m0s".
+	Message string
+	// Details are the details of the latest occurrences, up to 5.
+	Details []string
+}
+
+// Text is the message of the notification followed by the details, for chat messages.
+func (n Notification) Text() string {
+	text := fmt.Sprintf("[actions-runner-controller %s] %s", n.Component, n.Message)
+
+	for _, d := range n.Details {
+		text += "\n- " + d
+	}
+
+	return text
+}
+
+// LoadConfig reads the config from the YAML file.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	// Strict so that a misspelled setting isn't silently ignored
+	if err := yaml.UnmarshalStrict(content, &c); err != nil {
+		return c, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+type occurrence struct {
+	time   time.Time
+	detail string
+}
+
+type webhook struct {
+	WebhookConfig
+	template *template.Template
+}
+
+// Notifier counts the occurrences of each kind of anomaly within the window of its detector, and notifies the webhooks
+// once the count reaches the threshold. The notifications of the same kind are sent at most once per cooldown.
+//
+// A nil Notifier discards the occurrences, so that the callers don't need to check whether the notifications are enabled.
+type Notifier struct {
+	Component  string
+	Log        logr.Logger
+	HTTPClient *http.Client
+
+	cooldown  time.Duration
+	detectors map[Kind]DetectorConfig
+	webhooks  []webhook
+
+	mu           sync.Mutex
+	occurrences  map[Kind][]occurrence
+	lastNotified map[Kind]time.Time
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// NewNotifier returns a Notifier of the anomalies detected by the component.
+// It fails when a template is invalid, so that the misconfiguration is noticed on startup.
+func NewNotifier(component string, config Config, log logr.Logger) (*Notifier, error) {
+	n := &Notifier{
+		Component:    component,
+		Log:          log,
+		HTTPClient:   &http.Client{Timeout: notificationTimeout},
+		cooldown:     DefaultCooldown,
+		detectors:    map[Kind]DetectorConfig{},
+		occurrences:  map[Kind][]occurrence{},
+		lastNotified: map[Kind]time.Time{},
+		now:          time.Now,
+	}
+
+	if config.Cooldown != nil {
+		n.cooldown = config.Cooldown.Duration
+	}
+
+	for kind, d := range DefaultDetectors {
+		n.detectors[kind] = d
+	}
+
+	for kind, d := range config.Detectors {
+		def, ok := DefaultDetectors[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown anomaly detector %q", kind)
+		}
+
+		if d.Threshold <= 0 {
+			d.Threshold = def.Threshold
+		}
+
+		if d.Window == nil {
+			d.Window = def.Window
+		}
+
+		n.detectors[kind] = d
+	}
+
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+
+	for i, w := range config.Webhooks {
+		if w.URL == "" {
+			return nil, fmt.Errorf("webhooks[%d]: url is required", i)
+		}
+
+		text := w.Template
+		if text == "" {
+			text = DefaultTemplate
+		}
+
+		t, err := template.New(fmt.Sprintf("webhooks[%d]", i)).Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: parsing template: %w", i, err)
+		}
+
+		n.webhooks = append(n.webhooks, webhook{WebhookConfig: w, template: t})
+	}
+
+	return n, nil
+}
+
+// Record records an occurrence of the anomaly with the detail, and notifies the webhooks in the background
+// once the anomaly is detected.
+func (n *Notifier) Record(kind Kind, detail string) {
+	if n == nil {
+		return
+	}
+
+	if notification, ok := n.record(kind, detail); ok {
+		// Notify in the background, as this is called from webhook handlers and reconcilers
+		go n.post(notification)
+	}
+}
+
+// record returns the notification to send, and false when there's none.
+func (n *Notifier) record(kind Kind, detail string) (Notification, bool) {
+	d, ok := n.detectors[kind]
+	if !ok || d.Disabled {
+		return Notification{}, false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+
+	var recent []occurrence
+
+	for _, o := range n.occurrences[kind] {
+		if now.Sub(o.time) < d.Window.Duration {
+			recent = append(recent, o)
+		}
+	}
+
+	recent = append(recent, occurrence{time: now, detail: detail})
+	n.occurrences[kind] = recent
+
+	if len(recent) < d.Threshold {
+		return Notification{}, false
+	}
+
+	if last, ok := n.lastNotified[kind]; ok && now.Sub(last) < n.cooldown {
+		return Notification{}, false
+	}
+
+	n.lastNotified[kind] = now
+
+	notification := Notification{
+		Kind:      kind,
+		Component: n.Component,
+		Count:     len(recent),
+		Window:    d.Window.Duration,
+		Time:      now,
+		Message:   fmt.Sprintf("%d %s within %s", len(recent), descriptions[kind], d.Window.Duration),
+	}
+
+	for i := len(recent) - 1; i >= 0 && len(notification.Details) < maxDetails; i-- {
+		if recent[i].detail != "" {
+			notification.Details = append(notification.Details, recent[i].detail)
+		}
+	}
+
+	return notification, true
+}
+
+// post sends the notification to every webhook. Failures are only logged, as there's nothing else to notify.
+func (n *Notifier) post(notification Notification) {
+	n.Log.Info("Detected scaling anomaly", "kind", notification.Kind, "message", notification.Message)
+
+	for _, w := range n.webhooks {
+		if err := n.postTo(w, notification); err != nil {
+			n.Log.Error(err, "Failed to notify webhook of scaling anomaly", "kind", notification.Kind, "webhook", w.template.Name())
+		}
+	}
+}
+
+func (n *Notifier) postTo(w webhook, notification Notification) error {
+	var body bytes.Buffer
+
+	if err := w.template.Execute(&body, notification); err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := n.HTTPClient.Do(req)
+	if err != nil {
+		// The error contains the URL, which is a credential of e.g. Slack incoming webhooks
+		return fmt.Errorf("sending request: %s", strings.ReplaceAll(err.Error(), w.URL, "<redacted>"))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package anomaly
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNotifier_Record(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	n, err := NewNotifier("github-webhook-server", Config{
+		Cooldown: &metav1.Duration{Duration: 30 * time.Minute},
+		Detectors: map[Kind]DetectorConfig{
+			KindUnmatchedJobs:   {Threshold: 3, Window: &metav1.Duration{Duration: 10 * time.Minute}},
+			KindScaleUpFailures: {Disabled: true},
+		},
+	}, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Notification
+
+	n.now = func() time.Time { return now }
+
+	record := func(kind Kind, detail string) {
+		if notification, ok := n.record(kind, detail); ok {
+			got = append(got, notification)
+		}
+	}
+
+	record(KindUnmatchedJobs, "job 1")
+	now = now.Add(11 * time.Minute)
+	record(KindUnmatchedJobs, "job 2")
+	record(KindUnmatchedJobs, "job 3")
+	record(KindScaleUpFailures, "failure")
+	record(KindScaleUpFailures, "failure")
+	record(KindScaleUpFailures, "failure")
+
+	if len(got) != 0 {
+		t.Fatalf("expected no notification below the threshold within the window, got %+v", got)
+	}
+
+	record(KindUnmatchedJobs, "job 4")
+
+	want := []Notification{
+		{
+			Kind:      KindUnmatchedJobs,
+			Component: "github-webhook-server",
+			Count:     3,
+			Window:    10 * time.Minute,
+			Time:      now,
+			Message:   "3 workflow jobs were queued without any HorizontalRunnerAutoscaler to scale for them within 10m0s",
+			Details:   []string{"job 4", "job 3", "job 2"},
+		},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("unexpected notifications (-want +got):\n%s", d)
+	}
+
+	// Within the cooldown
+	now = now.Add(29 * time.Minute)
+	record(KindUnmatchedJobs, "job 5")
+	record(KindUnmatchedJobs, "job 6")
+	record(KindUnmatchedJobs, "job 7")
+
+	if len(got) != 1 {
+		t.Fatalf("expected no notification within the cooldown, got %+v", got[1:])
+	}
+
+	now = now.Add(time.Minute)
+	record(KindUnmatchedJobs, "job 8")
+
+	if len(got) != 2 || got[1].Count != 4 {
+		t.Errorf("expected a notification after the cooldown, got %+v", got[1:])
+	}
+
+	// A nil notifier is a no-op
+	var nilNotifier *Notifier
+	nilNotifier.Record(KindUnmatchedJobs, "job")
+}
+
+func TestNotifier_post(t *testing.T) {
+	bodies := make(chan string, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type: %s", r.Header.Get("Content-Type"))
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- r.Header.Get("X-Routing-Key") + " " + string(b)
+	}))
+	defer server.Close()
+
+	n, err := NewNotifier("controller", Config{
+		Webhooks: []WebhookConfig{
+			{URL: server.URL},
+			{
+				URL:      server.URL,
+				Template: `{"event_action": "trigger", "payload": {"summary": {{ .Message | json }}, "source": {{ .Component | json }}}}`,
+				Headers:  map[string]string{"X-Routing-Key": "key"},
+			},
+		},
+		Detectors: map[Kind]DetectorConfig{
+			KindRateLimitExhausted: {Threshold: 1},
+		},
+	}, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.Record(KindRateLimitExhausted, `resets at "12:00"`)
+
+	want := []string{
+		` {"text": "[actions-runner-controller controller] 1 GitHub API responses told that the rate limit was exhausted within 10m0s\n- resets at \"12:00\""}`,
+		`key {"event_action": "trigger", "payload": {"summary": "1 GitHub API responses told that the rate limit was exhausted within 10m0s", "source": "controller"}}`,
+	}
+
+	for _, w := range want {
+		select {
+		case got := <-bodies:
+			if got != w {
+				t.Errorf("unexpected request:\nwant %s\ngot  %s", w, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the notification")
+		}
+	}
+}
+
+func TestNewNotifier_InvalidConfig(t *testing.T) {
+	testcases := []Config{
+		{Webhooks: []WebhookConfig{{}}},
+		{Webhooks: []WebhookConfig{{URL: "http://example.com", Template: "{{ .Unclosed"}}},
+		{Detectors: map[Kind]DetectorConfig{"unknown": {}}},
+	}
+
+	for _, c := range testcases {
+		if _, err := NewNotifier("controller", c, logr.Discard()); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records every webhook delivery and the scaling decision made for it as a JSON line,
+// so that security and capacity teams can query who triggered which scale long after the logs are gone.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Decision is what the webhook server did for the delivery.
+type Decision string
+
+const (
+	// DecisionScaled is a delivery that added or removed a capacity reservation of the HorizontalRunnerAutoscaler.
+	DecisionScaled Decision = "scaled"
+	// DecisionDryRun is a delivery that would have been DecisionScaled without the dry run.
+	DecisionDryRun Decision = "dryRun"
+	// DecisionDuplicate is a redelivery of the event whose capacity reservation had already been added.
+	DecisionDuplicate Decision = "duplicate"
+	// DecisionSuppressed is a scale up suppressed by the scaleUpMaxRatePerMinute of the HorizontalRunnerAutoscaler.
+	DecisionSuppressed Decision = "suppressed"
+	// DecisionPaused is a scale up skipped because the HorizontalRunnerAutoscaler is paused.
+	DecisionPaused Decision = "paused"
+	// DecisionNoScaleTarget is a delivery that no HorizontalRunnerAutoscaler scales for.
+	DecisionNoScaleTarget Decision = "noScaleTarget"
+	// DecisionIgnored is a delivery that triggers neither scale up nor scale down, like a ping event.
+	DecisionIgnored Decision = "ignored"
+	// DecisionRejected is a delivery with an invalid signature or an unparsable payload.
+	DecisionRejected Decision = "rejected"
+	// DecisionFailed is a delivery whose scale target lookup or scale failed.
+	DecisionFailed Decision = "failed"
+)
+
+const (
+	// Stdout is the sink that writes the records to the standard output.
+	Stdout = "stdout"
+
+	// queueSize is the number of the records buffered for the sink, so that a slow sink doesn't slow down webhook deliveries.
+	queueSize = 1000
+
+	httpTimeout = 10 * time.Second
+)
+
+// Record is the audit record of a webhook delivery.
+type Record struct {
+	Time time.Time `json:"time"`
+
+	// Delivery is the X-GitHub-Delivery header that identifies the delivery.
+	Delivery string `json:"delivery,omitempty"`
+
+	Event      string   `json:"event"`
+	Action     string   `json:"action,omitempty"`
+	Repository string   `json:"repository,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+
+	// HorizontalRunnerAutoscaler is the NAMESPACE/NAME of the scale target, if any.
+	HorizontalRunnerAutoscaler string `json:"horizontalRunnerAutoscaler,omitempty"`
+
+	Decision Decision `json:"decision"`
+
+	// Amount is the number of replicas reserved, or released when negative.
+	Amount int `json:"amount,omitempty"`
+
+	// ReservedReplicasBefore and ReservedReplicasAfter are the sums of the replicas of the valid capacity reservations
+	// of the HorizontalRunnerAutoscaler before and after the scale.
+	ReservedReplicasBefore *int `json:"reservedReplicasBefore,omitempty"`
+	ReservedReplicasAfter  *int `json:"reservedReplicasAfter,omitempty"`
+
+	// MinReplicas and MaxReplicas are the bounds the HorizontalRunnerAutoscaler clamps the desired replicas within.
+	MinReplicas *int `json:"minReplicas,omitempty"`
+	MaxReplicas *int `json:"maxReplicas,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Log writes the records to the sink in the background.
+type Log struct {
+	Log logr.Logger
+
+	sink    func(context.Context, []byte) error
+	records chan Record
+}
+
+// New returns the Log that writes the records to the sink, which is either Stdout,
+// an http:// or https:// URL that every record is POSTed to, or the path of the file the records are appended to.
+func New(sink string, log logr.Logger) (*Log, error) {
+	l := &Log{
+		Log:     log,
+		records: make(chan Record, queueSize),
+	}
+
+	switch {
+	case sink == "":
+		return nil, fmt.Errorf("audit log sink must not be empty")
+	case sink == Stdout:
+		l.sink = writerSink(os.Stdout)
+	case strings.HasPrefix(sink, "http://") || strings.HasPrefix(sink, "https://"):
+		l.sink = httpSink(sink, http.DefaultClient)
+	default:
+		f, err := os.OpenFile(strings.TrimPrefix(sink, "file://"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening audit log file: %w", err)
+		}
+
+		l.sink = writerSink(f)
+	}
+
+	return l, nil
+}
+
+// Record queues the record for the sink. A record is dropped when the queue is full.
+func (l *Log) Record(r Record) {
+	if l == nil {
+		return
+	}
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	select {
+	case l.records <- r:
+	default:
+		l.Log.Info("Dropped audit record as the queue is full", "delivery", r.Delivery, "decision", r.Decision)
+	}
+}
+
+// Start writes the queued records to the sink until the context is canceled.
+func (l *Log) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case r := <-l.records:
+			l.write(ctx, r)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that every replica of the webhook server
+// records the deliveries it received.
+func (l *Log) NeedLeaderElection() bool {
+	return false
+}
+
+func (l *Log) write(ctx context.Context, r Record) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		l.Log.Error(err, "Failed to marshal audit record", "delivery", r.Delivery)
+
+		return
+	}
+
+	if err := l.sink(ctx, line); err != nil {
+		l.Log.Error(err, "Failed to write audit record", "delivery", r.Delivery)
+	}
+}
+
+func writerSink(w io.Writer) func(context.Context, []byte) error {
+	return func(_ context.Context, line []byte) error {
+		_, err := w.Write(append(line, '\n'))
+
+		return err
+	}
+}
+
+func httpSink(url string, client *http.Client) func(context.Context, []byte) error {
+	return func(ctx context.Context, line []byte) error {
+		ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(line))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("sending request: %w", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode/100 != 2 {
+			return fmt.Errorf("unexpected status: %s", res.Status)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLog_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	l, err := New("file://"+path, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, after := 1, 3
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{
+			Time:                       now,
+			Delivery:                   "1",
+			Event:                      "workflow_job",
+			Action:                     "queued",
+			Repository:                 "owner/repo",
+			Labels:                     []string{"self-hosted", "linux"},
+			HorizontalRunnerAutoscaler: "default/hra",
+			Decision:                   DecisionScaled,
+			Amount:                     2,
+			ReservedReplicasBefore:     &before,
+			ReservedReplicasAfter:      &after,
+		},
+		{Time: now, Delivery: "2", Event: "workflow_job", Action: "queued", Repository: "owner/other", Decision: DecisionNoScaleTarget},
+	}
+
+	for _, r := range records {
+		l.Record(r)
+	}
+
+	for len(l.records) > 0 {
+		l.write(context.Background(), <-l.records)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != len(records) {
+		t.Fatalf("unexpected number of lines: want %d, got %d: %s", len(records), len(lines), data)
+	}
+
+	var got []Record
+	for _, line := range lines {
+		var r Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+
+	if d := cmp.Diff(records, got); d != "" {
+		t.Errorf("unexpected records (-want +got):\n%s", d)
+	}
+}
+
+func TestLog_HTTP(t *testing.T) {
+	received := make(chan Record, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec Record
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			t.Error(err)
+		}
+		received <- rec
+	}))
+	defer srv.Close()
+
+	l, err := New(srv.URL, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go l.Start(ctx)
+
+	l.Record(Record{Delivery: "1", Event: "ping", Decision: DecisionIgnored})
+
+	select {
+	case rec := <-received:
+		if rec.Delivery != "1" || rec.Decision != DecisionIgnored || rec.Time.IsZero() {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the record")
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+
+	l.Record(Record{Event: "ping"})
+}
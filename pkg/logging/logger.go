@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging provides the loggers of actions-runner-controller that stay cheap during event storms,
+// by sampling log lines and rate limiting repetitive ones, while counting every line in metrics.
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	zaplib "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// SamplingOptions configures the sampling of log lines.
+//
+// Within each Tick, the first First lines with the same level and message are logged,
+// and then every Thereafter-th line. The other lines are dropped and only counted
+// in the log_messages_dropped_total metric.
+type SamplingOptions struct {
+	// Tick defaults to 1s.
+	Tick time.Duration
+	// First is the number of lines logged per Tick before sampling starts.
+	// Zero disables the sampling.
+	First int
+	// Thereafter is the interval of the sampled lines. Zero drops all the lines after the first First lines.
+	Thereafter int
+}
+
+// level is the level of the loggers returned by NewLogger, which can be changed at runtime with SetLogLevel.
+var level = zaplib.NewAtomicLevelAt(zaplib.DebugLevel)
+
+// NewLogger returns the logger for the log level, one of "debug", "info", "warn" and "error".
+func NewLogger(logLevel string, sampling SamplingOptions) logr.Logger {
+	return zap.New(func(o *zap.Options) {
+		if logLevel == LogLevelDebug {
+			o.Development = true
+		}
+
+		if lvl, err := parseLogLevel(logLevel); err == nil {
+			level.SetLevel(lvl)
+			o.Level = &level
+		}
+
+		if sampling.First > 0 {
+			tick := sampling.Tick
+			if tick <= 0 {
+				tick = time.Second
+			}
+
+			o.ZapOpts = append(o.ZapOpts, zaplib.WrapCore(func(core zapcore.Core) zapcore.Core {
+				return zapcore.NewSamplerWithOptions(core, tick, sampling.First, sampling.Thereafter, zapcore.SamplerHook(func(e zapcore.Entry, dec zapcore.SamplingDecision) {
+					if dec&zapcore.LogDropped > 0 {
+						logMessagesDropped.WithLabelValues(e.Level.String()).Inc()
+					}
+				}))
+			}))
+		}
+	})
+}
+
+// SetLogLevel changes the level of the loggers returned by NewLogger without restarting the process.
+func SetLogLevel(logLevel string) error {
+	lvl, err := parseLogLevel(logLevel)
+	if err != nil {
+		return err
+	}
+
+	level.SetLevel(lvl)
+
+	return nil
+}
+
+func parseLogLevel(logLevel string) (zapcore.Level, error) {
+	switch logLevel {
+	case LogLevelDebug:
+		return zaplib.DebugLevel, nil
+	case LogLevelInfo:
+		return zaplib.InfoLevel, nil
+	case LogLevelWarn:
+		return zaplib.WarnLevel, nil
+	case LogLevelError:
+		return zaplib.ErrorLevel, nil
+	}
+
+	return 0, fmt.Errorf("invalid log level %q: valid values are %q, %q, %q, and %q", logLevel, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		logMessagesDropped,
+		logEvents,
+		logEventsSuppressed,
+	)
+}
+
+var (
+	logMessagesDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_messages_dropped_total",
+			Help: "Number of log lines dropped by the log sampling, by level.",
+		},
+		[]string{"level"},
+	)
+	logEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_events_total",
+			Help: "Number of rate-limited log lines requested, including the suppressed ones, by reason.",
+		},
+		[]string{"reason"},
+	)
+	logEventsSuppressed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_events_suppressed_total",
+			Help: "Number of rate-limited log lines suppressed, by reason.",
+		},
+		[]string{"reason"},
+	)
+)
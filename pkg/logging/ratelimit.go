@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// RateLimiter logs at most one line per reason within the interval, for warnings that repeat
+// for every webhook event or reconciliation while the cause persists.
+//
+// Every call is counted in the log_events_total metric labeled with the reason, and suppressed ones in
+// log_events_suppressed_total, so that the frequency is still observable.
+// The next line logged for the reason tells how many lines were suppressed before it.
+//
+// Reasons must be constant strings, because they are used as metric labels.
+//
+// A nil RateLimiter logs every line.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	reasons map[string]*rateLimitedReason
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type rateLimitedReason struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// NewRateLimiter returns a RateLimiter that logs at most one line per reason within the interval.
+// A non-positive interval disables the rate limiting, although the metrics are still recorded.
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		reasons:  map[string]*rateLimitedReason{},
+		now:      time.Now,
+	}
+}
+
+// SetInterval changes the interval without restarting the process.
+func (r *RateLimiter) SetInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interval = interval
+}
+
+// Info logs the message at the info level unless another line has been logged for the reason within the interval.
+func (r *RateLimiter) Info(log logr.Logger, reason, msg string, keysAndValues ...interface{}) {
+	if kvs, ok := r.allow(reason, keysAndValues); ok {
+		log.Info(msg, kvs...)
+	}
+}
+
+// Error logs the error unless another line has been logged for the reason within the interval.
+func (r *RateLimiter) Error(log logr.Logger, err error, reason, msg string, keysAndValues ...interface{}) {
+	if kvs, ok := r.allow(reason, keysAndValues); ok {
+		log.Error(err, msg, kvs...)
+	}
+}
+
+func (r *RateLimiter) allow(reason string, keysAndValues []interface{}) ([]interface{}, bool) {
+	logEvents.WithLabelValues(reason).Inc()
+
+	if r == nil {
+		return keysAndValues, true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval <= 0 {
+		return keysAndValues, true
+	}
+
+	now := r.now()
+
+	s, ok := r.reasons[reason]
+	if !ok {
+		s = &rateLimitedReason{}
+		r.reasons[reason] = s
+	} else if now.Sub(s.lastLogged) < r.interval {
+		s.suppressed++
+		logEventsSuppressed.WithLabelValues(reason).Inc()
+
+		return nil, false
+	}
+
+	if s.suppressed > 0 {
+		keysAndValues = append(keysAndValues, "suppressed", s.suppressed, "reason", reason)
+	}
+
+	s.lastLogged = now
+	s.suppressed = 0
+
+	return keysAndValues, true
+}
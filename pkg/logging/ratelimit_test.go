@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var lines []string
+
+	log := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	r := NewRateLimiter(time.Minute)
+	r.now = func() time.Time { return now }
+
+	r.Info(log, "not_found", "not found", "n", 1)
+	r.Info(log, "not_found", "not found", "n", 2)
+	r.Info(log, "other", "other", "n", 3)
+
+	now = now.Add(30 * time.Second)
+	r.Info(log, "not_found", "not found", "n", 4)
+
+	now = now.Add(30 * time.Second)
+	r.Info(log, "not_found", "not found", "n", 5)
+	r.Info(log, "not_found", "not found", "n", 6)
+
+	want := []string{
+		`"level"=0 "msg"="not found" "n"=1`,
+		`"level"=0 "msg"="other" "n"=3`,
+		`"level"=0 "msg"="not found" "n"=5 "suppressed"=2 "reason"="not_found"`,
+	}
+
+	if d := cmp.Diff(want, lines); d != "" {
+		t.Errorf("unexpected lines (-want +got):\n%s", d)
+	}
+
+	var disabled *RateLimiter
+
+	lines = nil
+	disabled.Info(log, "not_found", "not found")
+	disabled.Info(log, "not_found", "not found")
+
+	if len(lines) != 2 {
+		t.Errorf("unexpected number of lines logged by the nil rate limiter: want 2, got %d", len(lines))
+	}
+}
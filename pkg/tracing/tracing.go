@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports OpenTelemetry spans via OTLP, so that slow webhook deliveries can be broken down
+// into the time spent in finding the scale target, calling the GitHub API, and patching the HorizontalRunnerAutoscaler.
+//
+// Until Setup is called, the spans are started from the no-op tracer provider and cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer that starts every span of actions-runner-controller.
+const InstrumentationName = "github.com/actions-runner-controller/actions-runner-controller"
+
+// Options configures the export of the spans.
+type Options struct {
+	// ServiceName is the service.name resource attribute of the spans, like "github-webhook-server".
+	ServiceName string
+
+	// Endpoint is the host and port of the OTLP/HTTP receiver, like "otel-collector:4318".
+	// Empty means the endpoint is read from the OTEL_EXPORTER_OTLP_ENDPOINT environment variable.
+	Endpoint string
+
+	// Insecure exports the spans over plain HTTP instead of HTTPS.
+	Insecure bool
+
+	// SampleRatio is the ratio of the traces sampled, from 0 to 1.
+	// The traces started by the callers that sampled them are always sampled.
+	SampleRatio float64
+}
+
+// Setup registers the tracer provider that exports the spans via OTLP/HTTP, along with the W3C trace context propagator,
+// so that the spans can be children of the ones of the callers.
+// The returned func flushes the spans not yet exported, and needs to be called before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio must be between 0 and 1: %v", opts.SampleRatio)
+	}
+
+	clientOpts := []otlptracehttp.Option{}
+
+	if opts.Endpoint != "" {
+		clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+	}
+
+	if opts.Insecure {
+		clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(clientOpts...))
+	if err != nil {
+		return nil, fmt.Errorf("creating otlp trace exporter: %w", err)
+	}
+
+	resource, err := sdkresource.New(ctx,
+		sdkresource.WithFromEnv(),
+		sdkresource.WithAttributes(attribute.String("service.name", opts.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(resource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts the span named after the operation, as the child of the span in the context if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording the error if any so that the failed span stands out in the trace.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prev)
+
+	ctx, parent := Start(context.Background(), "Handle")
+
+	_, child := Start(ctx, "tryScale")
+	End(child, errors.New("conflict"))
+
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	tryScale, handle := spans[0], spans[1]
+
+	if tryScale.Parent().SpanID() != handle.SpanContext().SpanID() {
+		t.Errorf("expected tryScale to be the child of Handle")
+	}
+
+	if got := tryScale.Status(); got.Code != codes.Error || got.Description != "conflict" {
+		t.Errorf("expected the error status of tryScale, got %+v", got)
+	}
+
+	if got := handle.Status(); got.Code != codes.Unset {
+		t.Errorf("expected the unset status of Handle, got %+v", got)
+	}
+}
+
+func TestSetupRejectsInvalidSampleRatio(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{ServiceName: "test", SampleRatio: 1.5}); err == nil {
+		t.Fatal("expected an error for the sample ratio above 1")
+	}
+}
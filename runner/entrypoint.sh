@@ -32,6 +32,12 @@ else
   log "Docker wait check skipped. Either Docker is disabled or the wait is disabled, continuing with entrypoint"
 fi
 
+if [ -n "${RUNNER_EXIT_MARKER:-}" ]; then
+  # The sidecars wait for the marker on termination so that they terminate after the runner
+  rm -f "${RUNNER_EXIT_MARKER}"
+  trap 'touch "${RUNNER_EXIT_MARKER}"' EXIT
+fi
+
 if [ -z "${GITHUB_URL}" ]; then
   log "Working with public GitHub"
   GITHUB_URL="https://github.com/"
@@ -81,6 +87,12 @@ if [ -z "${UNITTEST:-}" ]; then
   sudo chown -R runner:docker ${RUNNER_HOME}
   # use cp over mv to avoid issues when /runnertmp and {RUNNER_HOME} are on different devices
   cp -r /runnertmp/* ${RUNNER_HOME}/
+
+  # The tool cache may be a volume shared by the runners, whose root directory is owned by root
+  if [ -n "${RUNNER_TOOL_CACHE:-}" ] && [ -d "${RUNNER_TOOL_CACHE}" ] && [ ! -w "${RUNNER_TOOL_CACHE}" ]; then
+    sudo chown runner:docker "${RUNNER_TOOL_CACHE}" && sudo chmod g+rwx "${RUNNER_TOOL_CACHE}" \
+      || error "Failed to make the tool cache ${RUNNER_TOOL_CACHE} writable. Tools will be downloaded by every job."
+  fi
 fi
 
 cd ${RUNNER_HOME}
@@ -165,4 +177,13 @@ if [ "${RUNNER_FEATURE_FLAG_EPHEMERAL:-}" != "true" -a "${RUNNER_EPHEMERAL}" !=
 fi
 
 unset RUNNER_NAME RUNNER_REPO RUNNER_TOKEN
+
+if [ -n "${RUNNER_EXIT_MARKER:-}" ]; then
+  # Not exec'ed so that the exit trap creates the marker once the runner has exited.
+  # dumb-init forwards the signals to the whole process group, so the runner still receives them.
+  trap : TERM INT
+  ./bin/runsvc.sh "${args[@]}"
+  exit $?
+fi
+
 exec ./bin/runsvc.sh "${args[@]}"
\ No newline at end of file
@@ -0,0 +1,116 @@
+#!/bin/bash
+# Checks the disk usage of the work directory, the memory usage of the runner container, and dockerd when docker is enabled.
+#
+# Run by the readiness probe of the runner container, which gets unready while the check fails,
+# so that the controller reports it in the Healthy condition of the runner, and the output is shown in the events of the pod.
+#
+# With --recycle, run in the background by job-completed.sh. It waits for the job to complete, and stops the runner service
+# when the check fails. The report is written to the termination message of the runner container, so that the controller can
+# recreate the runner pod and record why.
+# With RUNNER_HEALTH_CHECK_STRICT, the result is written to RECYCLE_FILE for job-started.sh of the next job: the file is removed
+# when the runner is healthy, and contains "recycle" when it's being stopped.
+
+TERMINATION_MESSAGE_PATH=${TERMINATION_MESSAGE_PATH:-/dev/termination-log}
+DISK_USAGE_PERCENT=${RUNNER_HEALTH_CHECK_DISK_USAGE_PERCENT:-90}
+MEMORY_USAGE_PERCENT=${RUNNER_HEALTH_CHECK_MEMORY_USAGE_PERCENT:-90}
+RECYCLE_FILE=${RECYCLE_FILE:-/tmp/arc-recycle}
+
+# Prints the memory usage of the container relative to its limit, excluding the inactive page cache like kubelet does,
+# or nothing when the container has no memory limit.
+memory_usage_percent() {
+  local usage limit inactive
+
+  if [ -f /sys/fs/cgroup/memory.max ]; then
+    limit=$(cat /sys/fs/cgroup/memory.max)
+    usage=$(cat /sys/fs/cgroup/memory.current)
+    inactive=$(awk '$1 == "inactive_file" { print $2 }' /sys/fs/cgroup/memory.stat)
+  elif [ -f /sys/fs/cgroup/memory/memory.limit_in_bytes ]; then
+    limit=$(cat /sys/fs/cgroup/memory/memory.limit_in_bytes)
+    usage=$(cat /sys/fs/cgroup/memory/memory.usage_in_bytes)
+    inactive=$(awk '$1 == "total_inactive_file" { print $2 }' /sys/fs/cgroup/memory/memory.stat)
+  fi
+
+  # cgroup v1 reports a huge number instead of "max" for no limit
+  if [ -z "${limit}" ] || [ "${limit}" = "max" ] || [ "${#limit}" -ge 19 ]; then
+    return
+  fi
+
+  echo $(( (usage - ${inactive:-0}) * 100 / limit ))
+}
+
+check() {
+  local dir=${RUNNER_WORKDIR:-/runner/_work}
+  local problems=()
+
+  # The work directory is created on the first job
+  while [ ! -d "${dir}" ] && [ "${dir}" != "/" ]; do
+    dir=$(dirname "${dir}")
+  done
+
+  disk_usage=$(df -P "${dir}" | awk 'NR == 2 { sub("%", "", $5); print $5 }')
+  if [ -n "${disk_usage}" ] && [ "${disk_usage}" -gt "${DISK_USAGE_PERCENT}" ]; then
+    problems+=("disk usage of ${dir} is ${disk_usage}%, above ${DISK_USAGE_PERCENT}%")
+  fi
+
+  memory_usage=$(memory_usage_percent)
+  if [ -n "${memory_usage}" ] && [ "${memory_usage}" -gt "${MEMORY_USAGE_PERCENT}" ]; then
+    problems+=("memory usage is ${memory_usage}% of the limit, above ${MEMORY_USAGE_PERCENT}%")
+  fi
+
+  dockerd_alive=
+  if [ "${DOCKER_ENABLED}" = "true" ]; then
+    dockerd_alive=true
+    if ! timeout 10 docker info >/dev/null 2>&1; then
+      dockerd_alive=false
+      problems+=("dockerd is not responding")
+    fi
+  fi
+
+  message=$(IFS=';'; echo "${problems[*]}" | sed 's/;/; /g')
+
+  [ ${#problems[@]} -eq 0 ]
+}
+
+if [ "$1" != "--recycle" ]; then
+  if check; then
+    echo "Runner is healthy: disk usage ${disk_usage}%${memory_usage:+, memory usage ${memory_usage}%}"
+    exit 0
+  fi
+
+  echo "Runner is unhealthy: ${message}"
+  exit 1
+fi
+
+# The job-completed hook is run by the worker of the job.
+# Only the worker of the job is waited for when known, because the worker of the next job waits for this check in strict mode.
+if [ -n "${RUNNER_WORKER_PID}" ]; then
+  while kill -0 "${RUNNER_WORKER_PID}" 2>/dev/null; do
+    sleep 1
+  done
+else
+  while pgrep -f Runner.Worker >/dev/null; do
+    sleep 1
+  done
+fi
+
+if check; then
+  rm -f "${RECYCLE_FILE}"
+  exit 0
+fi
+
+if [ "${RUNNER_HEALTH_CHECK_STRICT}" = "true" ]; then
+  echo recycle > "${RECYCLE_FILE}"
+fi
+
+echo "Runner is unhealthy: ${message}. Stopping the runner to recreate the runner pod"
+
+jq -n -c \
+  --arg reason RunnerUnhealthy \
+  --arg message "${message}" \
+  --argjson diskUsagePercent "${disk_usage:-null}" \
+  --argjson memoryUsagePercent "${memory_usage:-null}" \
+  --argjson dockerdAlive "${dockerd_alive:-null}" \
+  '{reason: $reason, message: $message, diskUsagePercent: $diskUsagePercent, memoryUsagePercent: $memoryUsagePercent, dockerdAlive: $dockerdAlive}' \
+  > "${TERMINATION_MESSAGE_PATH}"
+
+pkill -INT -f RunnerService.js
@@ -0,0 +1,28 @@
+#!/bin/bash
+# Run by the runner after the job through ACTIONS_RUNNER_HOOK_JOB_COMPLETED.
+# Stops the watchdog started by job-started.sh, starts the health check that recycles the unhealthy runner after the job
+# when RUNNER_HEALTH_CHECK_RECYCLE is true, and then runs the post-job hook of the runner at RUNNER_POST_JOB_HOOK, if any.
+
+WATCHDOG_PID_FILE=${WATCHDOG_PID_FILE:-/tmp/arc-job-watchdog.pid}
+RECYCLE_FILE=${RECYCLE_FILE:-/tmp/arc-recycle}
+
+if [ -f "${WATCHDOG_PID_FILE}" ]; then
+  # The watchdog leads its own process group, which includes the pending sleep
+  kill -- "-$(cat "${WATCHDOG_PID_FILE}")" 2>/dev/null || :
+  rm -f "${WATCHDOG_PID_FILE}"
+fi
+
+if [ "${RUNNER_HEALTH_CHECK_RECYCLE}" = "true" ]; then
+  if [ "${RUNNER_HEALTH_CHECK_STRICT}" = "true" ]; then
+    # Written before the worker exits, so that it's seen by job-started.sh of any job the runner picks up next
+    echo pending > "${RECYCLE_FILE}"
+  fi
+
+  # Detached like the watchdog, so that it outlives the job it waits for.
+  # The worker of this job is the only one until the hook completes.
+  RUNNER_WORKER_PID=$(pgrep -f Runner.Worker | head -n 1) RUNNER_TRACKING_ID= setsid /etc/arc/hooks/health-check.sh --recycle </dev/null >>/tmp/arc-health-check.log 2>&1 &
+fi
+
+if [ -n "${RUNNER_POST_JOB_HOOK}" ]; then
+  exec bash "${RUNNER_POST_JOB_HOOK}"
+fi
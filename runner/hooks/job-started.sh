@@ -0,0 +1,44 @@
+#!/bin/bash
+# Run by the runner before the job through ACTIONS_RUNNER_HOOK_JOB_STARTED.
+# Starts the watchdog that cancels the job once it runs longer than RUNNER_MAX_JOB_DURATION_SECONDS,
+# and then runs the pre-job hook of the runner at RUNNER_PRE_JOB_HOOK, if any.
+#
+# With RUNNER_CACHE_URL, it points actions/cache in the steps of the job at the cache server, via ACTIONS_CACHE_URL.
+#
+# With RUNNER_HEALTH_CHECK_STRICT, it first waits for the health check after the previous job written to RECYCLE_FILE
+# by job-completed.sh, and fails the job when the runner is being recycled, so that no job runs on the unhealthy runner.
+
+WATCHDOG_PID_FILE=${WATCHDOG_PID_FILE:-/tmp/arc-job-watchdog.pid}
+RECYCLE_FILE=${RECYCLE_FILE:-/tmp/arc-recycle}
+RECYCLE_WAIT_SECONDS=${RECYCLE_WAIT_SECONDS:-60}
+
+if [ "${RUNNER_HEALTH_CHECK_STRICT}" = "true" ]; then
+  waited=0
+  while [ "$(cat "${RECYCLE_FILE}" 2>/dev/null)" = "pending" ] && [ "${waited}" -lt "${RECYCLE_WAIT_SECONDS}" ]; do
+    sleep 1
+    waited=$((waited + 1))
+  done
+
+  # Also refuses the job when the health check didn't complete in time, as the runner can't be told healthy
+  if [ -f "${RECYCLE_FILE}" ]; then
+    echo "::error::This runner is being recycled because it was unhealthy after the previous job. Re-run the job to run it on another runner"
+    exit 1
+  fi
+fi
+
+if [ -n "${RUNNER_MAX_JOB_DURATION_SECONDS}" ]; then
+  # The watchdog is detached from the hook and from the runner's orphan process cleanup,
+  # so that it keeps running until the job completes.
+  RUNNER_TRACKING_ID= setsid /etc/arc/hooks/job-watchdog.sh </dev/null >>/tmp/arc-job-watchdog.log 2>&1 &
+  echo $! > "${WATCHDOG_PID_FILE}"
+
+  echo "This job is cancelled after ${RUNNER_MAX_JOB_DURATION_SECONDS} seconds by the maxJobDuration of the runner"
+fi
+
+if [ -n "${RUNNER_CACHE_URL}" ] && [ -n "${GITHUB_ENV}" ]; then
+  echo "ACTIONS_CACHE_URL=${RUNNER_CACHE_URL}" >> "${GITHUB_ENV}"
+fi
+
+if [ -n "${RUNNER_PRE_JOB_HOOK}" ]; then
+  exec bash "${RUNNER_PRE_JOB_HOOK}"
+fi
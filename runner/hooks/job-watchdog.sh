@@ -0,0 +1,31 @@
+#!/bin/bash
+# Cancels the job once it runs longer than RUNNER_MAX_JOB_DURATION_SECONDS.
+#
+# The job is cancelled by gracefully stopping the runner service, which makes the runner cancel the running job and exit.
+# The reason is written to the termination message of the runner container, so that the controller can recreate the runner pod
+# and attribute the timeout to the repository and the workflow.
+
+TERMINATION_MESSAGE_PATH=${TERMINATION_MESSAGE_PATH:-/dev/termination-log}
+GRACE_PERIOD_SECONDS=${RUNNER_MAX_JOB_DURATION_GRACE_PERIOD_SECONDS:-60}
+
+sleep "${RUNNER_MAX_JOB_DURATION_SECONDS}"
+
+echo "Job ${GITHUB_JOB} of workflow ${GITHUB_WORKFLOW} in ${GITHUB_REPOSITORY} exceeded the max job duration of ${RUNNER_MAX_JOB_DURATION_SECONDS} seconds. Cancelling"
+
+jq -n -c \
+  --arg reason MaxJobDurationExceeded \
+  --arg repository "${GITHUB_REPOSITORY}" \
+  --arg workflow "${GITHUB_WORKFLOW}" \
+  --arg job "${GITHUB_JOB}" \
+  --arg runID "${GITHUB_RUN_ID}" \
+  --argjson maxJobDurationSeconds "${RUNNER_MAX_JOB_DURATION_SECONDS}" \
+  '{reason: $reason, repository: $repository, workflow: $workflow, job: $job, runID: $runID, maxJobDurationSeconds: $maxJobDurationSeconds}' \
+  > "${TERMINATION_MESSAGE_PATH}"
+
+pkill -INT -f RunnerService.js
+
+sleep "${GRACE_PERIOD_SECONDS}"
+
+# The runner didn't stop in time, e.g. because a step ignores the cancellation
+pkill -KILL -f Runner.Worker
+pkill -KILL -f Runner.Listener
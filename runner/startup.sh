@@ -31,6 +31,10 @@ fi
 if [ -n "${DOCKER_REGISTRY_MIRROR}" ]; then
 jq ".\"registry-mirrors\"[0] = \"${DOCKER_REGISTRY_MIRROR}\"" /etc/docker/daemon.json > /tmp/.daemon.json && mv /tmp/.daemon.json /etc/docker/daemon.json
 fi
+
+if [ -n "${DOCKER_IPV6_CIDR}" ]; then
+jq ".\"ipv6\" = true | .\"fixed-cidr-v6\" = \"${DOCKER_IPV6_CIDR}\" | .\"experimental\" = true | .\"ip6tables\" = true" /etc/docker/daemon.json > /tmp/.daemon.json && mv /tmp/.daemon.json /etc/docker/daemon.json
+fi
 SCRIPT
 
 INFO "Using /etc/docker/daemon.json with the following content"